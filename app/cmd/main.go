@@ -1,6 +1,7 @@
 // @title Subscriptions API
 // @version 1.0
-// @description REST API для управления подписками пользователей
+// @description REST API для управления подписками пользователей.
+// @description Ошибки возвращаются как {"error": "..."} или, при Accept: application/problem+json, в формате RFC 7807.
 // @host localhost:8080
 // @BasePath /
 package main
@@ -9,11 +10,16 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"reflect"
 	"subscriptionsservice/internal/application"
 	"subscriptionsservice/internal/config"
 	"subscriptionsservice/internal/database"
 	"subscriptionsservice/internal/logger"
+	"subscriptionsservice/internal/retry"
+	"subscriptionsservice/internal/runtimeutil"
+	"sync"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -28,10 +34,19 @@ func main() {
 		panic("error on loading config: " + err.Error())
 	}
 
-	log := logger.NewLogger(cfg.App.LogLevel)
+	var logOpts []logger.Option
+	if cfg.App.LogUnredacted {
+		logOpts = append(logOpts, logger.WithoutRedaction())
+	}
+	log, logLevel := logger.New(cfg.App.LogLevel, logOpts...)
 	defer log.Sync()
 
-	err = database.Migrate(cfg.App.MirgationDir, cfg.DatabaseURL)
+	log.Info("config loaded", zap.String("env", cfg.App.Env), zap.String("path", configFilePath))
+	if cfg.App.LogUnredacted {
+		log.Warn("log redaction is off: user IDs, emails and payment details are logged as is")
+	}
+
+	err = database.Migrate(cfg.App.MirgationDir, database.MigrationURL(cfg.Database.Dialect, cfg.MigrationDatabaseURL()))
 	if err != nil {
 		log.Fatal("error on migrating database", zap.Error(err))
 	}
@@ -39,11 +54,23 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
-	app := application.New(cfg, log)
+	app, err := application.New(ctx, cfg, log)
 	if err != nil {
 		log.Fatal("error on creating app", zap.Error(err))
 	}
 
+	reloader := &configReloader{path: configFilePath, current: cfg, level: logLevel, app: app, log: log}
+	// A panic while reloading must not stop config reloads for good.
+	restart := runtimeutil.WithRestart(retry.ExponentialBackoff{Base: time.Second, Factor: 2, Max: time.Minute})
+	runtimeutil.Go(ctx, log, reloader.handleSIGHUP, runtimeutil.WithName("sighup reloader"), restart)
+	if cfg.Remote.Provider != "" {
+		runtimeutil.Go(ctx, log, func(ctx context.Context) {
+			cfg.Remote.Watch(ctx, func() { reloader.reload("remote") }, func(err error) {
+				log.Warn("failed to watch remote config", zap.Error(err))
+			})
+		}, runtimeutil.WithName("remote config watcher"), restart)
+	}
+
 	if err := app.Run(ctx); err != nil {
 		if ctx.Err() != nil {
 			log.Info("app stopped by context")
@@ -52,3 +79,66 @@ func main() {
 		}
 	}
 }
+
+// configReloader reloads the config file and the remote config. The log
+// level and usage limits are applied at once; other changed settings take
+// effect after a restart.
+type configReloader struct {
+	path    string
+	current *config.Config
+	level   zap.AtomicLevel
+	app     *application.App
+	log     *zap.Logger
+
+	mu sync.Mutex
+}
+
+// handleSIGHUP reloads the config on SIGHUP (sent by logrotate) until ctx is
+// canceled. Logs are written to stdout, so there are no log files to reopen;
+// buffered entries are flushed.
+func (r *configReloader) handleSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		_ = r.log.Sync()
+		r.reload("SIGHUP")
+	}
+}
+
+// reload loads the config and applies its tunables; trigger is logged.
+func (r *configReloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load(r.path)
+	if err != nil {
+		r.log.Error("failed to reload config, keeping the current one", zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		r.log.Error("reloaded config is invalid, keeping the current one", zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+
+	r.level.SetLevel(logger.ParseLevel(cfg.App.LogLevel))
+	r.app.Reconfigure(cfg)
+
+	// Compare the rest of the settings to report what needs a restart.
+	cmp := *cfg
+	cmp.App.LogLevel = r.current.App.LogLevel
+	cmp.Limits = r.current.Limits
+	if !reflect.DeepEqual(&cmp, r.current) {
+		r.log.Warn("config changed, restart to apply settings other than app.log_level and limits")
+	}
+
+	r.log.Info("config reloaded", zap.String("trigger", trigger),
+		zap.String("log_level", cfg.App.LogLevel), zap.Any("limits", cfg.Limits))
+}
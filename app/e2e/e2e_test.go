@@ -0,0 +1,533 @@
+//go:build e2e
+// +build e2e
+
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/application"
+	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/database"
+	"subscriptionsservice/internal/logger"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tc "github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// baseURL points to the application under test. It is taken from E2E_BASE_URL
+// (docker-compose "e2e" profile) or set up by TestMain, which boots the whole
+// application against a Postgres testcontainer.
+var baseURL string
+
+func TestMain(m *testing.M) {
+	if url := os.Getenv("E2E_BASE_URL"); url != "" {
+		baseURL = url
+		if err := waitReady(baseURL, 30*time.Second); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(m.Run())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pgContainer, err := postgres.Run(ctx,
+		"postgres:15.3-alpine",
+		postgres.WithDatabase("test_db"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		tc.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).WithStartupTimeout(10*time.Second)),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	done, err := bootApp(ctx, dsn, port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	baseURL = "http://127.0.0.1:" + port
+	if err := waitReady(baseURL, 10*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+
+	cancel()
+	<-done
+	_ = pgContainer.Terminate(context.Background())
+
+	os.Exit(code)
+}
+
+// bootApp wires the application the same way cmd/main.go does:
+// config file + env, migrations, then App.Run until ctx is canceled.
+func bootApp(ctx context.Context, dsn, port string) (<-chan struct{}, error) {
+	migrationDir, err := filepath.Abs("../../migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	configPath := filepath.Join(os.TempDir(), "subs-e2e-config.yaml")
+	configData := fmt.Sprintf("app:\n  port: %q\n  log_level: error\nretry:\n  max_attempts: 1\n", port)
+	if err := os.WriteFile(configPath, []byte(configData), 0o600); err != nil {
+		return nil, err
+	}
+
+	os.Setenv("DATABASE_URL", dsn)
+	os.Setenv("APP_MIGRATION_DIR", migrationDir)
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.Migrate(cfg.App.MirgationDir, cfg.DatabaseURL); err != nil {
+		return nil, err
+	}
+
+	app, err := application.New(ctx, cfg, logger.NewLogger(cfg.App.LogLevel))
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = app.Run(ctx)
+	}()
+
+	return done, nil
+}
+
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	return port, err
+}
+
+func waitReady(url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(url + "/readyz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("app at %s is not ready after %s", url, timeout)
+}
+
+func doJSON(t *testing.T, method, path string, body any, out any) int {
+	t.Helper()
+	return doRequest(t, method, path, body, out).StatusCode
+}
+
+// doRequest sends body as JSON and decodes a successful response into out.
+// The returned response body is already closed.
+func doRequest(t *testing.T, method, path string, body any, out any) *http.Response {
+	t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), method, baseURL+path, &reqBody)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < http.StatusMultipleChoices && resp.StatusCode != http.StatusNoContent {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+
+	return resp
+}
+
+type subscription struct {
+	ID          int64  `json:"id"`
+	ServiceName string `json:"service_name"`
+	Price       int    `json:"price"`
+	UserID      string `json:"user_id"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date,omitempty"`
+}
+
+func TestSubscriptionsLifecycle(t *testing.T) {
+	userID := uuid.NewString()
+
+	var created subscription
+	status := doJSON(t, http.MethodPost, "/subscriptions/", subscription{
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserID:      userID,
+		StartDate:   "07-2025",
+	}, &created)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotZero(t, created.ID)
+
+	path := fmt.Sprintf("/subscriptions/%d", created.ID)
+
+	t.Run("create duplicate", func(t *testing.T) {
+		dup := subscription{
+			ServiceName: created.ServiceName,
+			Price:       created.Price,
+			UserID:      created.UserID,
+			StartDate:   created.StartDate,
+		}
+
+		status := doJSON(t, http.MethodPost, "/subscriptions/", dup, nil)
+		assert.Equal(t, http.StatusConflict, status)
+
+		var existing subscription
+		status = doJSON(t, http.MethodPost, "/subscriptions/?on_conflict=ignore", dup, &existing)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, created.ID, existing.ID)
+	})
+
+	t.Run("get by id", func(t *testing.T) {
+		var got subscription
+		status := doJSON(t, http.MethodGet, path, nil, &got)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, created, got)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var page struct {
+			Data   []subscription `json:"data"`
+			Limit  int            `json:"limit"`
+			Offset int            `json:"offset"`
+		}
+		status := doJSON(t, http.MethodGet, "/subscriptions/?limit=100", nil, &page)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 100, page.Limit)
+		assert.Contains(t, page.Data, created)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		upd := created
+		upd.Price = 450
+		upd.EndDate = "09-2025"
+
+		var got subscription
+		status := doJSON(t, http.MethodPut, path, upd, &got)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, upd, got)
+	})
+
+	t.Run("active on month", func(t *testing.T) {
+		var page struct {
+			Data []subscription `json:"data"`
+		}
+		status := doJSON(t, http.MethodGet, "/subscriptions/active?on=08-2025&user_id="+userID, nil, &page)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, created.ID, page.Data[0].ID)
+
+		page.Data = nil
+		status = doJSON(t, http.MethodGet, "/subscriptions/active?on=10-2025&user_id="+userID, nil, &page)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, page.Data)
+	})
+
+	t.Run("services", func(t *testing.T) {
+		var page struct {
+			Data []struct {
+				ServiceName string `json:"service_name"`
+				Count       int    `json:"count"`
+			} `json:"data"`
+		}
+		req := "/subscriptions/services?prefix=yandex"
+		require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, req, nil, &page))
+		require.NotEmpty(t, page.Data)
+		for _, s := range page.Data {
+			assert.True(t, strings.HasPrefix(strings.ToLower(s.ServiceName), "yandex"), s.ServiceName)
+			assert.Positive(t, s.Count)
+		}
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		var sum struct {
+			Total   int               `json:"total"`
+			Count   int               `json:"count"`
+			From    string            `json:"from"`
+			To      string            `json:"to"`
+			Filters map[string]string `json:"filters"`
+		}
+		status := doJSON(t, http.MethodGet, "/subscriptions/summary?from=07-2025&to=10-2025&user_id="+userID, nil, &sum)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 450*3, sum.Total)
+		assert.Equal(t, 1, sum.Count)
+		assert.Equal(t, "07-2025", sum.From)
+		assert.Equal(t, "10-2025", sum.To)
+		assert.Equal(t, map[string]string{"user_id": userID}, sum.Filters)
+	})
+
+	t.Run("summary without matches", func(t *testing.T) {
+		var sum struct {
+			Total int `json:"total"`
+			Count int `json:"count"`
+		}
+		status := doJSON(t, http.MethodGet, "/subscriptions/summary?from=07-2025&to=10-2025&service_name=nothing&user_id="+userID, nil, &sum)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Zero(t, sum.Total)
+		assert.Zero(t, sum.Count)
+	})
+
+	t.Run("deprecated POST summary", func(t *testing.T) {
+		var sum struct {
+			Total int `json:"total"`
+		}
+		resp := doRequest(t, http.MethodPost, "/subscriptions/summary", map[string]any{
+			"from":    "07-2025",
+			"to":      "10-2025",
+			"user_id": userID,
+		}, &sum)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Deprecation"))
+		assert.Contains(t, resp.Header.Get("Link"), `rel="deprecation"`)
+		assert.Equal(t, 450*3, sum.Total)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var tombstone struct {
+			subscription
+			DeletedAt time.Time `json:"deleted_at"`
+		}
+		status := doJSON(t, http.MethodDelete, path+"?return=representation", nil, &tombstone)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, created.ID, tombstone.ID)
+		assert.False(t, tombstone.DeletedAt.IsZero())
+
+		status = doJSON(t, http.MethodDelete, path, nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status = doJSON(t, http.MethodGet, path, nil, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+	}{
+		{
+			name:   "create without service name",
+			method: http.MethodPost,
+			path:   "/subscriptions/",
+			body:   map[string]any{"price": 100, "user_id": uuid.NewString(), "start_date": "07-2025"},
+		},
+		{
+			name:   "create with malformed date",
+			method: http.MethodPost,
+			path:   "/subscriptions/",
+			body:   map[string]any{"service_name": "Netflix", "price": 100, "user_id": uuid.NewString(), "start_date": "2025/07"},
+		},
+		{
+			name:   "create ending before start",
+			method: http.MethodPost,
+			path:   "/subscriptions/",
+			body:   map[string]any{"service_name": "Netflix", "price": 100, "user_id": uuid.NewString(), "start_date": "07-2025", "end_date": "06-2025"},
+		},
+		{
+			name:   "get with invalid id",
+			method: http.MethodGet,
+			path:   "/subscriptions/abc",
+		},
+		{
+			name:   "list with limit above max page size",
+			method: http.MethodGet,
+			path:   "/subscriptions/?limit=1000000",
+		},
+		{
+			name:   "list with invalid user_id",
+			method: http.MethodGet,
+			path:   "/subscriptions/?user_id=not-a-uuid",
+		},
+		{
+			name:   "summary without period",
+			method: http.MethodPost,
+			path:   "/subscriptions/summary",
+			body:   map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := doJSON(t, tt.method, tt.path, tt.body, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	var created subscription
+	require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/subscriptions/", subscription{
+		ServiceName: "Kinopoisk",
+		Price:       300,
+		UserID:      uuid.NewString(),
+		StartDate:   "07-2025",
+		EndDate:     "12-2025",
+	}, &created))
+	path := fmt.Sprintf("/subscriptions/%d", created.ID)
+
+	t.Run("empty patch", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doJSON(t, http.MethodPatch, path, map[string]any{}, nil))
+	})
+
+	t.Run("absent fields unchanged", func(t *testing.T) {
+		var got subscription
+		require.Equal(t, http.StatusOK, doJSON(t, http.MethodPatch, path, map[string]any{"price": 350}, &got))
+		want := created
+		want.Price = 350
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("null end_date clears it", func(t *testing.T) {
+		var got subscription
+		require.Equal(t, http.StatusOK, doJSON(t, http.MethodPatch, path, map[string]any{"end_date": nil}, &got))
+		assert.Empty(t, got.EndDate)
+		assert.Equal(t, "Kinopoisk", got.ServiceName, "other fields are unchanged")
+		assert.Equal(t, 350, got.Price)
+
+		var stored subscription
+		require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, path, nil, &stored))
+		assert.Equal(t, got, stored)
+	})
+}
+
+func TestListFilters(t *testing.T) {
+	userID, otherID := uuid.NewString(), uuid.NewString()
+	ids := map[string]int64{}
+	for _, s := range []subscription{
+		{ServiceName: "Netflix", Price: 800, UserID: userID, StartDate: "07-2025", EndDate: "08-2025"},
+		{ServiceName: "Spotify", Price: 200, UserID: userID, StartDate: "09-2025"},
+		{ServiceName: "Netflix", Price: 800, UserID: otherID, StartDate: "07-2025"},
+	} {
+		var created subscription
+		require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/subscriptions/", s, &created))
+		ids[s.ServiceName+" "+s.UserID] = created.ID
+	}
+	netflix, spotify, othersNetflix := ids["Netflix "+userID], ids["Spotify "+userID], ids["Netflix "+otherID]
+
+	tests := []struct {
+		name string
+		path string
+		want []int64
+	}{
+		{name: "user", path: "/subscriptions/?user_id=" + userID, want: []int64{netflix, spotify}},
+		{name: "user and service", path: "/subscriptions/?service_name=Netflix&user_id=" + userID, want: []int64{netflix}},
+		{name: "other user", path: "/subscriptions/?user_id=" + otherID, want: []int64{othersNetflix}},
+		{name: "user without matching service", path: "/subscriptions/?service_name=Hulu&user_id=" + userID},
+		// GET /subscriptions/ has no period; months are filtered by /active.
+		{name: "user in month", path: "/subscriptions/active?on=08-2025&user_id=" + userID, want: []int64{netflix}},
+		{name: "user in later month", path: "/subscriptions/active?on=10-2025&user_id=" + userID, want: []int64{spotify}},
+		{name: "service in month", path: "/subscriptions/active?on=10-2025&service_name=Netflix&user_id=" + otherID, want: []int64{othersNetflix}},
+		{name: "user before start", path: "/subscriptions/active?on=06-2025&user_id=" + userID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page struct {
+				Data []subscription `json:"data"`
+			}
+			require.Equal(t, http.StatusOK, doJSON(t, http.MethodGet, tt.path+"&limit=100", nil, &page))
+			var got []int64
+			for _, s := range page.Data {
+				got = append(got, s.ID)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	var report struct {
+		Status     string `json:"status"`
+		Components map[string]struct {
+			Status string `json:"status"`
+		} `json:"components"`
+	}
+	status := doJSON(t, http.MethodGet, "/healthz", nil, &report)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "up", report.Status)
+	assert.Equal(t, "up", report.Components["db"].Status)
+	assert.Equal(t, "up", report.Components["migrations"].Status)
+
+	status = doJSON(t, http.MethodGet, "/readyz", nil, nil)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestSummaryFilters(t *testing.T) {
+	userID := uuid.NewString()
+	for _, s := range []map[string]any{
+		{"service_name": "Netflix", "price": 800, "start_date": "07-2025"},
+		{"service_name": "Kinopoisk", "price": 300, "start_date": "07-2025", "trial": true},
+		{"service_name": "VK Music", "price": 0, "start_date": "07-2025"},
+	} {
+		s["user_id"] = userID
+		require.Equal(t, http.StatusCreated, doJSON(t, http.MethodPost, "/subscriptions/", s, nil))
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "", want: 800 + 300},
+		{query: "&exclude_trials=true", want: 800},
+		{query: "&min_price=1&exclude_trials=true", want: 800},
+		{query: "&min_price=500", want: 800},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var sum struct {
+				Total int `json:"total"`
+			}
+			status := doJSON(t, http.MethodGet, "/subscriptions/summary?from=07-2025&to=07-2025&user_id="+userID+tt.query, nil, &sum)
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, tt.want, sum.Total)
+		})
+	}
+}
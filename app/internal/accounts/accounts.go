@@ -0,0 +1,80 @@
+// Package accounts checks that users exist in the accounts service, so
+// subscriptions are not attached to mistyped or deleted user IDs when the
+// service has no users table of its own to check against.
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/cache"
+	"subscriptionsservice/internal/httpclient"
+
+	"github.com/google/uuid"
+)
+
+// IDPlaceholder is replaced with the user ID in the user URL template.
+const IDPlaceholder = "{id}"
+
+// cacheSize bounds the number of cached users.
+const cacheSize = 100000
+
+// Client asks the accounts service whether users exist: GET on the user URL
+// answers 2xx for an existing user and 404 (or 410) for an unknown one.
+// Existing users are cached; unknown ones are not, so a user created in the
+// accounts service just now is accepted right away.
+type Client struct {
+	client      *httpclient.Client
+	urlTemplate string
+	token       string
+	known       *cache.TTL[uuid.UUID, struct{}]
+}
+
+// New creates a Client. urlTemplate is the user URL with IDPlaceholder, e.g.
+// https://accounts.internal/v1/users/{id}; token, if set, is sent as a bearer
+// token. Existing users are cached for cacheTTL, 0 — not cached.
+func New(client *httpclient.Client, urlTemplate, token string, cacheTTL time.Duration) *Client {
+	return &Client{
+		client:      client,
+		urlTemplate: urlTemplate,
+		token:       token,
+		known:       cache.New[uuid.UUID, struct{}](cacheTTL, cacheSize),
+	}
+}
+
+// UserExists reports whether the accounts service knows the user.
+func (c *Client) UserExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	if _, ok := c.known.Get(id); ok {
+		return true, nil
+	}
+
+	url := strings.ReplaceAll(c.urlTemplate, IDPlaceholder, id.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("check user %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		c.known.Set(id, struct{}{})
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return false, nil
+	default:
+		return false, fmt.Errorf("check user %s: unexpected status %d", id, resp.StatusCode)
+	}
+}
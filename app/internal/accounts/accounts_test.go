@@ -0,0 +1,55 @@
+package accounts_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/accounts"
+	"subscriptionsservice/internal/httpclient"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UserExists(t *testing.T) {
+	known := uuid.New()
+	broken := uuid.New()
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/users/" + known.String():
+			w.WriteHeader(http.StatusOK)
+		case "/v1/users/" + broken.String():
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := accounts.New(httpclient.New("accounts", httpclient.WithRetrier(retry.NoRetry())),
+		srv.URL+"/v1/users/{id}", "secret", time.Minute)
+
+	for range 2 {
+		ok, err := client.UserExists(t.Context(), known)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, calls, "existing users are cached")
+
+	for range 2 {
+		ok, err := client.UserExists(t.Context(), uuid.New())
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, 3, calls, "unknown users are not cached")
+
+	_, err := client.UserExists(t.Context(), broken)
+	assert.ErrorContains(t, err, "unexpected status 403")
+}
@@ -0,0 +1,74 @@
+// Package admin serves support endpoints describing the running service.
+package admin
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"subscriptionsservice/internal/buildinfo"
+	"subscriptionsservice/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves /admin endpoints.
+type Handler struct {
+	cfg     *config.Config
+	started time.Time
+}
+
+// NewHandler creates a Handler reporting cfg as the effective configuration.
+func NewHandler(cfg *config.Config, started time.Time) *Handler {
+	return &Handler{cfg: cfg, started: started}
+}
+
+// RegisterRoutes registers admin routes on rg.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/info", h.Info)
+}
+
+// Info is the response of GET /admin/info.
+type Info struct {
+	Build    buildinfo.Info  `json:"build"`
+	Config   *config.Config  `json:"config"`
+	Features map[string]bool `json:"features"`
+	Runtime  Runtime         `json:"runtime"`
+}
+
+// Runtime holds Go runtime statistics.
+type Runtime struct {
+	Uptime      string `json:"uptime"`
+	Goroutines  int    `json:"goroutines"`
+	GOMAXPROCS  int    `json:"gomaxprocs"`
+	NumCPU      int    `json:"num_cpu"`
+	HeapAlloc   uint64 `json:"heap_alloc_bytes"`
+	HeapSys     uint64 `json:"heap_sys_bytes"`
+	TotalAlloc  uint64 `json:"total_alloc_bytes"`
+	NumGC       uint32 `json:"num_gc"`
+	LastGCPause uint64 `json:"last_gc_pause_ns"`
+}
+
+// Info returns build version, non-secret configuration, feature flags and
+// runtime statistics. Secrets are excluded from the config by its JSON tags.
+func (h *Handler) Info(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	c.JSON(http.StatusOK, Info{
+		Build:    buildinfo.Get(),
+		Config:   h.cfg,
+		Features: h.cfg.FeatureFlags(),
+		Runtime: Runtime{
+			Uptime:      time.Since(h.started).Round(time.Second).String(),
+			Goroutines:  runtime.NumGoroutine(),
+			GOMAXPROCS:  runtime.GOMAXPROCS(0),
+			NumCPU:      runtime.NumCPU(),
+			HeapAlloc:   mem.HeapAlloc,
+			HeapSys:     mem.HeapSys,
+			TotalAlloc:  mem.TotalAlloc,
+			NumGC:       mem.NumGC,
+			LastGCPause: mem.PauseNs[(mem.NumGC+255)%256],
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Info(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		App:         config.App{Port: "8080"},
+		Admin:       config.Admin{Token: "s3cret"},
+		Limits:      config.Limits{WritesPerUserPerHour: 100},
+		DatabaseURL: "postgres://user:password@db/subs",
+		Features:    map[string]bool{"new_summary": true},
+	}
+
+	e := gin.New()
+	NewHandler(cfg, time.Now()).RegisterRoutes(e.Group("/admin"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/info", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.NotContains(t, w.Body.String(), "s3cret")
+	assert.NotContains(t, w.Body.String(), "password")
+
+	var info Info
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.NotEmpty(t, info.Build.Version)
+	assert.Equal(t, "8080", info.Config.App.Port)
+	assert.Equal(t, map[string]bool{
+		"new_summary":               true,
+		"active_subscription_limit": false,
+		"write_quota":               true,
+		"hedged_reads":              false,
+		"transaction_pooling":       false,
+		"request_transactions":      false,
+		"backups":                   false,
+		"fault_injection":           false,
+		"ownership_checks":          false,
+		"remote_config":             false,
+		"lenient_prices":            false,
+		"tenant_schemas":            false,
+		"provisioned_users":         false,
+		"user_check":                false,
+		"price_change_guard":        false,
+		"summary_cache":             false,
+		"summary_warmup":            false,
+		"response_cache":            false,
+	}, info.Features)
+	assert.Positive(t, info.Runtime.Goroutines)
+}
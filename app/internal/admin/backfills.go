@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backfill"
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Backfills starts and pauses data migrations, see backfill.Runner.
+type Backfills interface {
+	List(ctx context.Context) ([]models.Backfill, error)
+	Run(ctx context.Context, name string) (*models.Backfill, error)
+	Pause(ctx context.Context, name string) (*models.Backfill, error)
+}
+
+// BackfillsHandler serves /admin/backfills endpoints.
+type BackfillsHandler struct {
+	backfills Backfills
+}
+
+// NewBackfillsHandler creates a BackfillsHandler.
+func NewBackfillsHandler(b Backfills) *BackfillsHandler {
+	return &BackfillsHandler{backfills: b}
+}
+
+// RegisterRoutes registers backfill routes on rg.
+func (h *BackfillsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/backfills", h.List)
+	rg.POST("/backfills/:name/start", h.Start)
+	rg.POST("/backfills/:name/pause", h.Pause)
+}
+
+// List returns the backfills with their status and progress.
+func (h *BackfillsHandler) List(c *gin.Context) {
+	list, err := h.backfills.List(c.Request.Context())
+	if err != nil {
+		abortWithBackfillError(c, err, "failed to list backfills")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// Start starts a backfill, or resumes a paused or failed one after its
+// last committed batch. It runs in the background; its progress is
+// returned as of the start.
+func (h *BackfillsHandler) Start(c *gin.Context) {
+	b, err := h.backfills.Run(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abortWithBackfillError(c, err, "failed to start backfill")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, b)
+}
+
+// Pause pauses a running backfill once its current batch is committed.
+func (h *BackfillsHandler) Pause(c *gin.Context) {
+	b, err := h.backfills.Pause(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abortWithBackfillError(c, err, "failed to pause backfill")
+		return
+	}
+
+	c.JSON(http.StatusOK, b)
+}
+
+// abortWithBackfillError maps backfill errors to API errors; detail is used
+// for unexpected ones.
+func abortWithBackfillError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, backfill.ErrUnknown):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "backfill not found")
+	case errors.Is(err, backfill.ErrDone):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "backfill is already done")
+	case errors.Is(err, backfill.ErrNotRunning):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "backfill is not running")
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backfill"
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackfills has a running "dates" backfill and a finished "prices" one.
+type fakeBackfills struct{}
+
+func (fakeBackfills) List(context.Context) ([]models.Backfill, error) {
+	return []models.Backfill{{Name: "dates", Status: models.BackfillRunning}}, nil
+}
+
+func (fakeBackfills) Run(_ context.Context, name string) (*models.Backfill, error) {
+	switch name {
+	case "dates":
+		return &models.Backfill{Name: name, Status: models.BackfillRunning}, nil
+	case "prices":
+		return nil, backfill.ErrDone
+	}
+	return nil, backfill.ErrUnknown
+}
+
+func (fakeBackfills) Pause(_ context.Context, name string) (*models.Backfill, error) {
+	switch name {
+	case "dates":
+		return &models.Backfill{Name: name, Status: models.BackfillPaused}, nil
+	case "prices":
+		return nil, backfill.ErrNotRunning
+	}
+	return nil, backfill.ErrUnknown
+}
+
+func TestBackfillsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewBackfillsHandler(fakeBackfills{}).RegisterRoutes(e.Group("/admin"))
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/admin/backfills", http.StatusOK},
+		{http.MethodPost, "/admin/backfills/dates/start", http.StatusAccepted},
+		{http.MethodPost, "/admin/backfills/prices/start", http.StatusConflict},
+		{http.MethodPost, "/admin/backfills/missing/start", http.StatusNotFound},
+		{http.MethodPost, "/admin/backfills/dates/pause", http.StatusOK},
+		{http.MethodPost, "/admin/backfills/prices/pause", http.StatusConflict},
+		{http.MethodPost, "/admin/backfills/missing/pause", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backup"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackupsHandler serves /admin/backups endpoints.
+type BackupsHandler struct {
+	backups *backup.Backups
+}
+
+// NewBackupsHandler creates a BackupsHandler.
+func NewBackupsHandler(b *backup.Backups) *BackupsHandler {
+	return &BackupsHandler{backups: b}
+}
+
+// RegisterRoutes registers backup routes on rg.
+func (h *BackupsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/backups", h.Create)
+	rg.GET("/backups", h.List)
+	rg.GET("/backups/:name", h.Download)
+}
+
+// Create dumps all subscriptions. Query: format=jsonl (default) or csv.
+func (h *BackupsHandler) Create(c *gin.Context) {
+	format := backup.Format(c.DefaultQuery("format", string(backup.FormatJSONL)))
+
+	b, err := h.backups.Create(c.Request.Context(), format)
+	if err != nil {
+		abortWithBackupError(c, err, "failed to create backup")
+		return
+	}
+
+	c.JSON(http.StatusCreated, b)
+}
+
+// List returns stored backups, newest first.
+func (h *BackupsHandler) List(c *gin.Context) {
+	objs, err := h.backups.List(c.Request.Context())
+	if err != nil {
+		abortWithBackupError(c, err, "failed to list backups")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": objs})
+}
+
+// Download streams a stored backup as an attachment.
+func (h *BackupsHandler) Download(c *gin.Context) {
+	name := c.Param("name")
+
+	rc, format, err := h.backups.Open(c.Request.Context(), name)
+	if err != nil {
+		abortWithBackupError(c, err, "failed to open backup")
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, rc)
+}
+
+// abortWithBackupError maps backup errors to API errors; detail is used for
+// unexpected ones.
+func abortWithBackupError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, backup.ErrNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "backup not found")
+	case errors.Is(err, backup.ErrInvalidName):
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid backup name")
+	case errors.Is(err, backup.ErrUnknownFormat):
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "format must be jsonl or csv")
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
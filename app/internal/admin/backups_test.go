@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backup"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// emptySource has no subscriptions.
+type emptySource struct{}
+
+func (emptySource) Iterate(context.Context, models.SubscriptionFilter, func(models.Subscription) error, ...repository.Option) error {
+	return nil
+}
+
+func TestBackupsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dir.Close() })
+
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewBackupsHandler(backup.New(emptySource{}, dir, zap.NewNop())).RegisterRoutes(e.Group("/admin"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/backups?format=csv", nil))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	objs, err := dir.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backups/"+objs[0].Name, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,service_name,price,user_id,start_date,end_date,trial,currency\n", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), objs[0].Name)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/admin/backups", http.StatusOK},
+		{http.MethodPost, "/admin/backups?format=xml", http.StatusBadRequest},
+		{http.MethodGet, "/admin/backups/subscriptions-19700101T000000Z.csv", http.StatusNotFound},
+		{http.MethodGet, "/admin/backups/..csv", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/deadletter"
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDeadLettersPageSize is the page size of GET /admin/dead-letters.
+const defaultDeadLettersPageSize = 50
+
+// DeadLettersHandler serves /admin/dead-letters endpoints.
+type DeadLettersHandler struct {
+	queue *deadletter.Queue
+}
+
+// NewDeadLettersHandler creates a DeadLettersHandler.
+func NewDeadLettersHandler(q *deadletter.Queue) *DeadLettersHandler {
+	return &DeadLettersHandler{queue: q}
+}
+
+// RegisterRoutes registers dead letter routes on rg.
+func (h *DeadLettersHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/dead-letters", h.List)
+	rg.GET("/dead-letters/:id", h.Get)
+	rg.POST("/dead-letters/:id/redeliver", h.Redeliver)
+}
+
+// List returns dead letters ordered by ID. Query: limit, offset and
+// pending=true to skip already redelivered ones.
+func (h *DeadLettersHandler) List(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeadLettersPageSize)))
+	if err != nil || limit < 1 {
+		limit = defaultDeadLettersPageSize
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	pending, _ := strconv.ParseBool(c.Query("pending"))
+
+	dls, err := h.queue.List(c.Request.Context(), limit, offset, pending)
+	if err != nil {
+		abortWithQueueError(c, nil, err, "failed to list dead letters")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   dls,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// Get returns a dead letter with its payload and error chain.
+func (h *DeadLettersHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+
+	dl, err := h.queue.Get(c.Request.Context(), id)
+	if err != nil {
+		abortWithQueueError(c, dl, err, "failed to get dead letter")
+		return
+	}
+
+	c.JSON(http.StatusOK, dl)
+}
+
+// Redeliver delivers a dead letter again and returns it updated.
+func (h *DeadLettersHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+
+	dl, err := h.queue.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		abortWithQueueError(c, dl, err, "failed to redeliver dead letter")
+		return
+	}
+
+	c.JSON(http.StatusOK, dl)
+}
+
+// abortWithQueueError maps deadletter errors to API errors; detail is used
+// for unexpected ones. dl may be nil if the dead letter was not loaded.
+func abortWithQueueError(c *gin.Context, dl *models.DeadLetter, err error, detail string) {
+	switch {
+	case errors.Is(err, deadletter.ErrNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "dead letter not found")
+	case errors.Is(err, deadletter.ErrAlreadyRedelivered):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "dead letter already redelivered")
+	case errors.Is(err, deadletter.ErrUnknownKind) && dl != nil:
+		apierr.Abortf(c, http.StatusUnprocessableEntity, apierr.CodeInvalidRequest,
+			"no redelivery handler for kind %q", dl.Kind)
+	case errors.Is(err, deadletter.ErrDeliveryFailed):
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusBadGateway,
+			Code:   apierr.CodeDeliveryFailed,
+			Detail: "redelivery failed, the error is appended to the dead letter",
+			Err:    err,
+		})
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
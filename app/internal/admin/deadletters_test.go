@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/deadletter"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// singleStore holds one dead letter of an unregistered kind.
+type singleStore struct {
+	deadletter.Store
+}
+
+func (singleStore) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.DeadLetter, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &models.DeadLetter{ID: 1, Kind: "webhook"}, nil
+}
+
+func TestDeadLettersHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewDeadLettersHandler(deadletter.New(singleStore{}, zap.NewNop())).RegisterRoutes(e.Group("/admin"))
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/admin/dead-letters/1", http.StatusOK},
+		{http.MethodGet, "/admin/dead-letters/2", http.StatusNotFound},
+		{http.MethodGet, "/admin/dead-letters/abc", http.StatusBadRequest},
+		{http.MethodPost, "/admin/dead-letters/1/redeliver", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Reencrypter re-encrypts the values of an encrypted column with the active
+// key and returns their number.
+type Reencrypter interface {
+	Reencrypt(ctx context.Context, opts ...repository.Option) (int, error)
+}
+
+// EncryptionHandler serves /admin/encryption endpoints.
+type EncryptionHandler struct {
+	columns map[string]Reencrypter
+	log     *zap.Logger
+}
+
+// NewEncryptionHandler creates an EncryptionHandler for the encrypted
+// columns by name, e.g. "webhook_keys.private_key".
+func NewEncryptionHandler(columns map[string]Reencrypter, log *zap.Logger) *EncryptionHandler {
+	return &EncryptionHandler{columns: columns, log: log}
+}
+
+// RegisterRoutes registers encryption routes on rg.
+func (h *EncryptionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/encryption/reencrypt", h.Reencrypt)
+}
+
+// ReencryptResponse is the response of POST /admin/encryption/reencrypt.
+type ReencryptResponse struct {
+	// Reencrypted holds the numbers of re-encrypted values by column.
+	Reencrypted map[string]int `json:"reencrypted"`
+}
+
+// Reencrypt moves the values of all encrypted columns to the active key,
+// plain text ones included. Once it has run after a key rotation, the
+// previous key can be removed from encryption.keys. It is idempotent, so it
+// can be rerun after a failure.
+func (h *EncryptionHandler) Reencrypt(c *gin.Context) {
+	resp := ReencryptResponse{Reencrypted: make(map[string]int, len(h.columns))}
+	for column, r := range h.columns {
+		n, err := r.Reencrypt(c.Request.Context())
+		resp.Reencrypted[column] = n
+		if err != nil {
+			apierr.AbortWithError(c, &apierr.Error{
+				Status: http.StatusInternalServerError,
+				Code:   apierr.CodeInternal,
+				Detail: "failed to re-encrypt values",
+				Err:    err,
+			})
+			return
+		}
+	}
+
+	h.log.Info("encrypted values re-encrypted", zap.Any("reencrypted", resp.Reencrypted))
+	c.JSON(http.StatusOK, resp)
+}
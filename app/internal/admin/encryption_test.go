@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type reencrypterFunc func() (int, error)
+
+func (f reencrypterFunc) Reencrypt(context.Context, ...repository.Option) (int, error) { return f() }
+
+func TestEncryptionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reencrypt := func(columns map[string]Reencrypter) *httptest.ResponseRecorder {
+		e := gin.New()
+		e.Use(apierr.Middleware())
+		NewEncryptionHandler(columns, zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/encryption/reencrypt", nil))
+		return w
+	}
+
+	w := reencrypt(map[string]Reencrypter{"webhook_keys.private_key": reencrypterFunc(func() (int, error) { return 3, nil })})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"reencrypted": {"webhook_keys.private_key": 3}}`, w.Body.String())
+
+	w = reencrypt(map[string]Reencrypter{"webhook_keys.private_key": reencrypterFunc(func() (int, error) {
+		return 1, errors.New("unknown encryption key: 2024-01")
+	})})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/integrity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrityChecker checks stored subscriptions, see integrity.Checker.
+type IntegrityChecker interface {
+	Run(ctx context.Context) error
+	Last() (integrity.Report, bool)
+}
+
+// IntegrityHandler serves /admin/integrity.
+type IntegrityHandler struct {
+	checker IntegrityChecker
+}
+
+// NewIntegrityHandler creates an IntegrityHandler.
+func NewIntegrityHandler(c IntegrityChecker) *IntegrityHandler {
+	return &IntegrityHandler{checker: c}
+}
+
+// RegisterRoutes registers integrity routes on rg.
+func (h *IntegrityHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/integrity", h.Report)
+}
+
+// Report returns the findings of the last integrity check run on this
+// replica. The checks are run now with refresh=true or if they have not
+// run here yet, e.g. because the periodic job runs on another replica.
+func (h *IntegrityHandler) Report(c *gin.Context) {
+	refresh, err := strconv.ParseBool(c.DefaultQuery("refresh", "false"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid refresh")
+		return
+	}
+
+	report, ok := h.checker.Last()
+	if refresh || !ok {
+		if err := h.checker.Run(c.Request.Context()); err != nil {
+			apierr.AbortWithError(c, &apierr.Error{
+				Status: http.StatusInternalServerError,
+				Code:   apierr.CodeInternal,
+				Detail: "failed to check integrity",
+				Err:    err,
+			})
+			return
+		}
+		report, _ = h.checker.Last()
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/integrity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChecker counts runs; each run finds one more subscription.
+type fakeChecker struct {
+	runs int
+}
+
+func (c *fakeChecker) Run(context.Context) error {
+	c.runs++
+	return nil
+}
+
+func (c *fakeChecker) Last() (integrity.Report, bool) {
+	return integrity.Report{Total: c.runs}, c.runs > 0
+}
+
+func TestIntegrityHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := &fakeChecker{}
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewIntegrityHandler(checker).RegisterRoutes(e.Group("/admin"))
+
+	get := func(path string) (int, integrity.Report) {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report integrity.Report
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		}
+		return w.Code, report
+	}
+
+	code, report := get("/admin/integrity")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, report.Total, "checks are run if they have not run yet")
+
+	_, report = get("/admin/integrity")
+	assert.Equal(t, 1, report.Total, "the last report is reused")
+
+	_, report = get("/admin/integrity?refresh=true")
+	assert.Equal(t, 2, report.Total)
+
+	code, _ = get("/admin/integrity?refresh=maybe")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maintainer runs maintenance statements on the subscriptions table.
+type Maintainer interface {
+	// Analyze refreshes planner statistics.
+	Analyze(ctx context.Context, opts ...repository.Option) error
+
+	// Reindex rebuilds indexes without blocking writes.
+	Reindex(ctx context.Context, opts ...repository.Option) error
+}
+
+// MaintenanceHandler serves /admin/db endpoints.
+type MaintenanceHandler struct {
+	db Maintainer
+}
+
+// NewMaintenanceHandler creates a MaintenanceHandler.
+func NewMaintenanceHandler(db Maintainer) *MaintenanceHandler {
+	return &MaintenanceHandler{db: db}
+}
+
+// RegisterRoutes registers maintenance routes on rg.
+func (h *MaintenanceHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/db/analyze", h.Analyze)
+}
+
+// AnalyzeResult is the response of POST /admin/db/analyze.
+type AnalyzeResult struct {
+	Reindexed bool   `json:"reindexed"`
+	Duration  string `json:"duration"`
+}
+
+// Analyze refreshes planner statistics of the subscriptions table, e.g.
+// after a bulk import. With reindex=true its indexes are rebuilt
+// concurrently first, so the statistics describe the new indexes.
+func (h *MaintenanceHandler) Analyze(c *gin.Context) {
+	reindex, err := strconv.ParseBool(c.DefaultQuery("reindex", "false"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid reindex")
+		return
+	}
+
+	ctx := c.Request.Context()
+	start := time.Now()
+
+	if reindex {
+		if err := h.db.Reindex(ctx); err != nil {
+			abortWithMaintenanceError(c, err, "failed to reindex")
+			return
+		}
+	}
+	if err := h.db.Analyze(ctx); err != nil {
+		abortWithMaintenanceError(c, err, "failed to analyze")
+		return
+	}
+
+	c.JSON(http.StatusOK, AnalyzeResult{
+		Reindexed: reindex,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	})
+}
+
+func abortWithMaintenanceError(c *gin.Context, err error, detail string) {
+	apierr.AbortWithError(c, &apierr.Error{
+		Status: http.StatusInternalServerError,
+		Code:   apierr.CodeInternal,
+		Detail: detail,
+		Err:    err,
+	})
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMaintainer records maintenance calls.
+type fakeMaintainer struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeMaintainer) Analyze(context.Context, ...repository.Option) error {
+	f.calls = append(f.calls, "analyze")
+	return f.err
+}
+
+func (f *fakeMaintainer) Reindex(context.Context, ...repository.Option) error {
+	f.calls = append(f.calls, "reindex")
+	return f.err
+}
+
+func TestMaintenanceHandler_Analyze(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		query     string
+		err       error
+		want      int
+		wantCalls []string
+	}{
+		{"analyze", "", nil, http.StatusOK, []string{"analyze"}},
+		{"reindex", "?reindex=true", nil, http.StatusOK, []string{"reindex", "analyze"}},
+		{"invalid reindex", "?reindex=maybe", nil, http.StatusBadRequest, nil},
+		{"failure", "", errors.New("connection refused"), http.StatusInternalServerError, []string{"analyze"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeMaintainer{err: tt.err}
+			e := gin.New()
+			e.Use(apierr.Middleware())
+			NewMaintenanceHandler(db).RegisterRoutes(e.Group("/admin"))
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/db/analyze"+tt.query, nil))
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.wantCalls, db.calls)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ProfileHandler serves /admin/debug endpoints.
+type ProfileHandler struct {
+	log *zap.Logger
+}
+
+// NewProfileHandler creates a ProfileHandler.
+func NewProfileHandler(log *zap.Logger) *ProfileHandler {
+	return &ProfileHandler{log: log}
+}
+
+// RegisterRoutes registers profiling routes on rg.
+func (h *ProfileHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/debug/heap-profile", h.HeapProfile)
+}
+
+// HeapProfile dumps a heap profile in pprof format as an attachment, for
+// `go tool pprof`, e.g. while a large export is running. With gc=true a
+// collection runs first, so the profile shows only live objects.
+func (h *ProfileHandler) HeapProfile(c *gin.Context) {
+	gc, err := strconv.ParseBool(c.DefaultQuery("gc", "false"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid gc")
+		return
+	}
+	if gc {
+		runtime.GC()
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: "failed to write heap profile",
+			Err:    err,
+		})
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	h.log.Info("heap profile dumped", zap.Bool("gc", gc),
+		zap.Uint64("heap_alloc_bytes", mem.HeapAlloc), zap.Int("size_bytes", buf.Len()))
+
+	name := "heap-" + time.Now().UTC().Format("20060102T150405Z") + ".pb.gz"
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
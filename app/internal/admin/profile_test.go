@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProfileHandler_HeapProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewProfileHandler(zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/debug/heap-profile?gc=true", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `attachment; filename="heap-`)
+
+	// pprof profiles are gzipped protobuf.
+	r, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+	profile, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.NotEmpty(t, profile)
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/debug/heap-profile?gc=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/readonly"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReadOnlyPath is the route of the read-only mode switch, relative to the
+// admin group. It has to be served in read-only mode too.
+const ReadOnlyPath = "/read-only"
+
+// ReadOnlyHandler serves /admin/read-only endpoints.
+type ReadOnlyHandler struct {
+	mode *readonly.Mode
+	log  *zap.Logger
+}
+
+// NewReadOnlyHandler creates a ReadOnlyHandler.
+func NewReadOnlyHandler(mode *readonly.Mode, log *zap.Logger) *ReadOnlyHandler {
+	return &ReadOnlyHandler{mode: mode, log: log}
+}
+
+// RegisterRoutes registers read-only mode routes on rg.
+func (h *ReadOnlyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET(ReadOnlyPath, h.Get)
+	rg.PUT(ReadOnlyPath, h.Set)
+}
+
+// ReadOnlyRequest is the body of PUT /admin/read-only.
+type ReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason"`
+}
+
+// Get returns the read-only mode state.
+func (h *ReadOnlyHandler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.State())
+}
+
+// Set switches read-only mode on or off, e.g. before a failover, and
+// returns the new state.
+func (h *ReadOnlyHandler) Set(c *gin.Context) {
+	var req ReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	state := h.mode.Set(*req.Enabled, req.Reason)
+	h.log.Warn("read-only mode switched", zap.Bool("enabled", state.Enabled), zap.String("reason", state.Reason))
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/readonly"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadOnlyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mode := readonly.New(false, "")
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewReadOnlyHandler(mode, zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/read-only", strings.NewReader(body)))
+		return w
+	}
+
+	w := put(`{"enabled": true, "reason": "failover"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, mode.Enabled())
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/read-only", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var state readonly.State
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.True(t, state.Enabled)
+	assert.Equal(t, "failover", state.Reason)
+
+	assert.Equal(t, http.StatusBadRequest, put(`{"reason": "no flag"}`).Code)
+	assert.True(t, mode.Enabled(), "an invalid request keeps the mode")
+
+	require.Equal(t, http.StatusOK, put(`{"enabled": false}`).Code)
+	assert.False(t, mode.Enabled())
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backup"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestoresHandler serves /admin/restores.
+type RestoresHandler struct {
+	restorer *backup.Restorer
+	backups  *backup.Backups
+}
+
+// NewRestoresHandler creates a RestoresHandler restoring uploaded snapshots
+// or ones stored in backups.
+func NewRestoresHandler(r *backup.Restorer, b *backup.Backups) *RestoresHandler {
+	return &RestoresHandler{restorer: r, backups: b}
+}
+
+// RegisterRoutes registers restore routes on rg.
+func (h *RestoresHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/restores", h.Restore)
+}
+
+// Restore loads a snapshot in one transaction. Query: backup=<name> restores
+// a stored backup, otherwise the request body is the snapshot in format=jsonl
+// (default) or csv; dry_run=true rolls the load back; replace=true deletes
+// existing subscriptions first.
+func (h *RestoresHandler) Restore(c *gin.Context) {
+	var opts backup.RestoreOptions
+	for name, dst := range map[string]*bool{"dry_run": &opts.DryRun, "replace": &opts.Replace} {
+		v, err := strconv.ParseBool(c.DefaultQuery(name, "false"))
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid query parameters")
+			return
+		}
+		*dst = v
+	}
+
+	var (
+		src    io.Reader = c.Request.Body
+		format           = backup.Format(c.DefaultQuery("format", string(backup.FormatJSONL)))
+	)
+	if name := c.Query("backup"); name != "" {
+		rc, f, err := h.backups.Open(c.Request.Context(), name)
+		if err != nil {
+			abortWithBackupError(c, err, "failed to open backup")
+			return
+		}
+		defer rc.Close()
+		src, format = rc, f
+	}
+
+	res, err := h.restorer.Restore(c.Request.Context(), src, format, opts)
+	if err != nil {
+		abortWithRestoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func abortWithRestoreError(c *gin.Context, err error) {
+	var rowErr *backup.InvalidRowError
+	switch {
+	case errors.As(err, &rowErr):
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusUnprocessableEntity,
+			Code:   apierr.CodeValidationFailed,
+			Detail: "invalid snapshot row %d: %s",
+			Args:   []any{rowErr.Row, rowErr.Err},
+			Err:    err,
+		})
+	case errors.Is(err, repository.ErrDuplicate):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict,
+			"snapshot conflicts with existing subscriptions, use replace=true")
+	default:
+		abortWithBackupError(c, err, "failed to restore snapshot")
+	}
+}
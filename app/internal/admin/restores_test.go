@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/backup"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingLoader counts copied subscriptions.
+type countingLoader struct{ rows int }
+
+func (l *countingLoader) CopyFromSubscriptions(_ context.Context, subs []models.Subscription, _ ...repository.Option) (int64, error) {
+	l.rows += len(subs)
+	return int64(len(subs)), nil
+}
+
+func (l *countingLoader) DeleteAll(context.Context, ...repository.Option) (int64, error) {
+	return 0, nil
+}
+
+// noTx runs fn without a transaction.
+type noTx struct{}
+
+func (noTx) Do(ctx context.Context, fn repository.TxFunc, _ ...repository.Option) error {
+	return fn(ctx, nil)
+}
+
+func TestRestoresHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dir.Close() })
+
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewRestoresHandler(
+		backup.NewRestorer(&countingLoader{}, noTx{}, zap.NewNop()),
+		backup.New(emptySource{}, dir, zap.NewNop()),
+	).RegisterRoutes(e.Group("/admin"))
+
+	const row = `{"service_name":"Yandex Plus","price":400,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2024"}`
+
+	tests := []struct {
+		name, query, body string
+		want              int
+	}{
+		{"jsonl", "", row, http.StatusOK},
+		{"dry run", "?dry_run=true", row, http.StatusOK},
+		{"invalid row", "", `{"price":-1}`, http.StatusUnprocessableEntity},
+		{"invalid dry_run", "?dry_run=maybe", row, http.StatusBadRequest},
+		{"unknown format", "?format=xml", row, http.StatusBadRequest},
+		{"missing backup", "?backup=subscriptions-19700101T000000Z.jsonl", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/restores"+tt.query, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultTenantsPageSize is the page size of GET /admin/tenants.
+const defaultTenantsPageSize = 50
+
+// TenantsHandler serves /admin/tenants endpoints.
+type TenantsHandler struct {
+	registry *tenant.Registry
+	log      *zap.Logger
+}
+
+// NewTenantsHandler creates a TenantsHandler.
+func NewTenantsHandler(registry *tenant.Registry, log *zap.Logger) *TenantsHandler {
+	return &TenantsHandler{registry: registry, log: log}
+}
+
+// RegisterRoutes registers tenant routes on rg.
+func (h *TenantsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/tenants", h.Create)
+	rg.GET("/tenants", h.List)
+	rg.GET("/tenants/:tenant", h.Get)
+	rg.PUT("/tenants/:tenant", h.Update)
+	rg.DELETE("/tenants/:tenant", h.Delete)
+	rg.PUT("/tenants/:tenant/schema", h.ProvisionSchema)
+	rg.POST("/tenants/:tenant/api-keys", h.IssueAPIKey)
+	rg.GET("/tenants/:tenant/api-keys", h.ListAPIKeys)
+	rg.DELETE("/tenants/:tenant/api-keys/:id", h.RevokeAPIKey)
+}
+
+// CreateTenantRequest is the body of POST /admin/tenants.
+type CreateTenantRequest struct {
+	ID     string              `json:"id" binding:"required"`
+	Name   string              `json:"name" binding:"required"`
+	Limits models.TenantLimits `json:"limits"`
+}
+
+// UpdateTenantRequest is the body of PUT /admin/tenants/{tenant}.
+type UpdateTenantRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Limits models.TenantLimits `json:"limits"`
+}
+
+// TenantSchema is the response of PUT /admin/tenants/{tenant}/schema.
+type TenantSchema struct {
+	Tenant   tenant.ID `json:"tenant"`
+	Schema   string    `json:"schema"`
+	Version  uint      `json:"version"`
+	Dirty    bool      `json:"dirty"`
+	Duration string    `json:"duration"`
+}
+
+// IssuedAPIKey is the response of POST /admin/tenants/{tenant}/api-keys.
+type IssuedAPIKey struct {
+	// Key is shown only once: the service keeps a hash of it.
+	Key string `json:"key"`
+	models.APIKey
+}
+
+// Create registers a tenant and provisions its schema.
+func (h *TenantsHandler) Create(c *gin.Context) {
+	var req CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	t := &models.Tenant{ID: req.ID, Name: req.Name, Limits: req.Limits}
+	if err := h.registry.Create(c.Request.Context(), t); err != nil {
+		abortWithTenantError(c, err, "failed to create tenant")
+		return
+	}
+
+	c.Header("Location", "/admin/tenants/"+t.ID)
+	c.JSON(http.StatusCreated, t)
+}
+
+// List returns tenants ordered by ID. Query: limit and offset.
+func (h *TenantsHandler) List(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTenantsPageSize)))
+	if err != nil || limit < 1 {
+		limit = defaultTenantsPageSize
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tenants, err := h.registry.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		abortWithTenantError(c, err, "failed to list tenants")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   tenants,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// Get returns a tenant with its limits.
+func (h *TenantsHandler) Get(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.registry.Get(c.Request.Context(), id)
+	if err != nil {
+		abortWithTenantError(c, err, "failed to get tenant")
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// Update replaces the name and limits of a tenant. Other instances apply
+// new limits within 30 seconds.
+func (h *TenantsHandler) Update(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	t := &models.Tenant{ID: string(id), Name: req.Name, Limits: req.Limits}
+	if err := h.registry.Update(c.Request.Context(), t); err != nil {
+		abortWithTenantError(c, err, "failed to update tenant")
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// Delete unregisters a tenant and revokes its API keys. Its schema is kept.
+func (h *TenantsHandler) Delete(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.registry.Delete(c.Request.Context(), id); err != nil {
+		abortWithTenantError(c, err, "failed to delete tenant")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ProvisionSchema applies pending migrations to the schema of a tenant. It
+// is idempotent: run it for every tenant after deploying new migrations.
+func (h *TenantsHandler) ProvisionSchema(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	version, dirty, err := h.registry.MigrateSchema(c.Request.Context(), id)
+	if err != nil {
+		abortWithTenantError(c, err, "failed to migrate tenant schema")
+		return
+	}
+
+	h.log.Info("tenant schema migrated", zap.String("tenant", string(id)), zap.Uint("version", version))
+	c.JSON(http.StatusOK, TenantSchema{
+		Tenant:   id,
+		Schema:   id.Schema(),
+		Version:  version,
+		Dirty:    dirty,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	})
+}
+
+// IssueAPIKey creates an API key of a tenant and returns it once.
+func (h *TenantsHandler) IssueAPIKey(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	key, k, err := h.registry.IssueAPIKey(c.Request.Context(), id)
+	if err != nil {
+		abortWithTenantError(c, err, "failed to issue api key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, IssuedAPIKey{Key: key, APIKey: *k})
+}
+
+// ListAPIKeys returns the API keys of a tenant, without the keys themselves.
+func (h *TenantsHandler) ListAPIKeys(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.registry.ListAPIKeys(c.Request.Context(), id)
+	if err != nil {
+		abortWithTenantError(c, err, "failed to list api keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// RevokeAPIKey revokes an API key of a tenant. Other instances may accept
+// it for up to 30 seconds.
+func (h *TenantsHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+
+	if err := h.registry.RevokeAPIKey(c.Request.Context(), id, keyID); err != nil {
+		abortWithTenantError(c, err, "failed to revoke api key")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// tenantParam parses the tenant path parameter, aborting with 400 if it is
+// invalid.
+func tenantParam(c *gin.Context) (tenant.ID, bool) {
+	id, err := tenant.Parse(c.Param("tenant"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid tenant")
+		return "", false
+	}
+	return id, true
+}
+
+// abortWithTenantError maps tenant registry errors to API errors; detail is
+// used for unexpected ones.
+func abortWithTenantError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, tenant.ErrInvalidID):
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid tenant")
+	case errors.Is(err, tenant.ErrInvalidLimits):
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "tenant limits must not be negative")
+	case errors.Is(err, tenant.ErrNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "tenant not found")
+	case errors.Is(err, tenant.ErrAPIKeyNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "api key not found")
+	case errors.Is(err, tenant.ErrExists):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "tenant already exists")
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
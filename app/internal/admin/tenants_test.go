@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeTenantStore keeps tenants and API keys in memory.
+type fakeTenantStore struct {
+	tenants map[string]models.Tenant
+	keys    []models.APIKey
+	hashes  map[string]string // Tenant by key hash
+}
+
+func newFakeTenantStore() *fakeTenantStore {
+	return &fakeTenantStore{tenants: make(map[string]models.Tenant), hashes: make(map[string]string)}
+}
+
+func (f *fakeTenantStore) Create(_ context.Context, t *models.Tenant, _ ...repository.Option) error {
+	if _, ok := f.tenants[t.ID]; ok {
+		return repository.ErrDuplicate
+	}
+	f.tenants[t.ID] = *t
+	return nil
+}
+
+func (f *fakeTenantStore) GetByID(_ context.Context, id string, _ ...repository.Option) (*models.Tenant, error) {
+	t, ok := f.tenants[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (f *fakeTenantStore) List(context.Context, int, int, ...repository.Option) ([]models.Tenant, error) {
+	var ts []models.Tenant
+	for _, t := range f.tenants {
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+func (f *fakeTenantStore) Update(_ context.Context, t *models.Tenant, _ ...repository.Option) error {
+	if _, ok := f.tenants[t.ID]; !ok {
+		return repository.ErrNotFound
+	}
+	f.tenants[t.ID] = *t
+	return nil
+}
+
+func (f *fakeTenantStore) Delete(_ context.Context, id string, _ ...repository.Option) error {
+	if _, ok := f.tenants[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(f.tenants, id)
+	return nil
+}
+
+func (f *fakeTenantStore) CreateAPIKey(_ context.Context, k *models.APIKey, hash []byte, _ ...repository.Option) error {
+	if _, ok := f.tenants[k.TenantID]; !ok {
+		return repository.ErrForeignKeyViolation
+	}
+	k.ID = int64(len(f.keys) + 1)
+	f.keys = append(f.keys, *k)
+	f.hashes[string(hash)] = k.TenantID
+	return nil
+}
+
+func (f *fakeTenantStore) ListAPIKeys(_ context.Context, tenantID string, _ ...repository.Option) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, k := range f.keys {
+		if k.TenantID == tenantID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (f *fakeTenantStore) RevokeAPIKey(_ context.Context, tenantID string, id int64, at time.Time, _ ...repository.Option) error {
+	for i, k := range f.keys {
+		if k.ID == id && k.TenantID == tenantID && k.RevokedAt == nil {
+			f.keys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (f *fakeTenantStore) TenantByAPIKey(_ context.Context, hash []byte, _ ...repository.Option) (string, error) {
+	id, ok := f.hashes[string(hash)]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	return id, nil
+}
+
+// fakeSchemas records migrated schemas.
+type fakeSchemas struct {
+	migrated []string
+	err      error
+}
+
+func (f *fakeSchemas) MigrateSchema(_ context.Context, schema string) error {
+	f.migrated = append(f.migrated, schema)
+	return f.err
+}
+
+func (f *fakeSchemas) SchemaVersion(context.Context, string) (uint, bool, error) {
+	return 10, false, nil
+}
+
+func TestTenantsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newFakeTenantStore()
+	schemas := &fakeSchemas{}
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewTenantsHandler(tenant.NewRegistry(store, schemas, zap.NewNop()), zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/tenants", `{"id": "billing", "name": "Billing", "limits": {"max_active_per_user": 5}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "/admin/tenants/billing", w.Header().Get("Location"))
+	assert.Equal(t, []string{"tenant_billing"}, schemas.migrated, "the schema is provisioned")
+
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/admin/tenants", `{"id": "billing", "name": "Billing"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/tenants", `{"id": "Billing", "name": "Billing"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/tenants", `{"id": "search", "name": "Search", "limits": {"writes_per_user_per_hour": -1}}`).Code)
+
+	w = do(http.MethodPut, "/admin/tenants/billing", `{"name": "Billing team", "limits": {"writes_per_user_per_hour": 100}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(http.MethodGet, "/admin/tenants/billing", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got models.Tenant
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "Billing team", got.Name)
+	assert.Nil(t, got.Limits.MaxActivePerUser, "limits are replaced")
+	require.NotNil(t, got.Limits.WritesPerUserPerHour)
+	assert.Equal(t, 100, *got.Limits.WritesPerUserPerHour)
+
+	w = do(http.MethodPut, "/admin/tenants/billing/schema", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema TenantSchema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	assert.Equal(t, TenantSchema{Tenant: "billing", Schema: "tenant_billing", Version: 10, Duration: schema.Duration}, schema)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/admin/tenants/search/schema", "").Code)
+
+	w = do(http.MethodPost, "/admin/tenants/billing/api-keys", "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var issued IssuedAPIKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix))
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/tenants/search/api-keys", "").Code)
+
+	w = do(http.MethodGet, "/admin/tenants/billing/api-keys", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), issued.Key, "keys are not shown again")
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/tenants/billing/api-keys/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/tenants/billing/api-keys/1", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/tenants/billing", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/tenants/billing", "").Code)
+}
+
+func TestTenantsHandler_SchemaFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newFakeTenantStore()
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	schemas := &fakeSchemas{err: errors.New("connection refused")}
+	NewTenantsHandler(tenant.NewRegistry(store, schemas, zap.NewNop()), zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/tenants", strings.NewReader(`{"id": "billing", "name": "Billing"}`)))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, store.tenants, "a tenant without a schema is not registered")
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/signing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebhookKeysHandler serves /admin/webhook-keys endpoints.
+type WebhookKeysHandler struct {
+	keys *signing.Keyring
+	log  *zap.Logger
+}
+
+// NewWebhookKeysHandler creates a WebhookKeysHandler.
+func NewWebhookKeysHandler(keys *signing.Keyring, log *zap.Logger) *WebhookKeysHandler {
+	return &WebhookKeysHandler{keys: keys, log: log}
+}
+
+// RegisterRoutes registers webhook signing key routes on rg.
+func (h *WebhookKeysHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/webhook-keys", h.List)
+	rg.POST("/webhook-keys/rotate", h.Rotate)
+}
+
+// List returns the published signing keys, newest first; the first one
+// signs unless it is retired. Key material is not included.
+func (h *WebhookKeysHandler) List(c *gin.Context) {
+	keys, err := h.keys.Keys(c.Request.Context())
+	if err != nil {
+		abortWithWebhookKeyError(c, err, "failed to list webhook keys")
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Rotate generates a key that signs webhooks from now on, e.g. on a
+// schedule or after a suspected leak. The replaced key stays published for
+// notifications.webhook.key_grace_period.
+func (h *WebhookKeysHandler) Rotate(c *gin.Context) {
+	key, err := h.keys.Rotate(c.Request.Context())
+	if err != nil {
+		abortWithWebhookKeyError(c, err, "failed to rotate webhook key")
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// abortWithWebhookKeyError maps keyring errors to API errors; detail is
+// used for unexpected ones.
+func abortWithWebhookKeyError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, signing.ErrRotationConflict):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "webhook key was rotated concurrently")
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/signing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeWebhookKeyStore keeps keys newest first; rotations fail with
+// ErrDuplicate once conflict is set.
+type fakeWebhookKeyStore struct {
+	keys     []models.WebhookKey
+	conflict bool
+}
+
+func (s *fakeWebhookKeyStore) List(context.Context, time.Time, ...repository.Option) ([]models.WebhookKey, error) {
+	return s.keys, nil
+}
+
+func (s *fakeWebhookKeyStore) Rotate(_ context.Context, k *models.WebhookKey, _ time.Time, _ ...repository.Option) error {
+	if s.conflict {
+		return repository.ErrDuplicate
+	}
+	for i := range s.keys {
+		if s.keys[i].RetiredAt == nil {
+			s.keys[i].RetiredAt = &k.CreatedAt
+		}
+	}
+	s.keys = append([]models.WebhookKey{*k}, s.keys...)
+	return nil
+}
+
+func TestWebhookKeysHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakeWebhookKeyStore{}
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	NewWebhookKeysHandler(signing.NewKeyring(store, time.Hour, zap.NewNop()), zap.NewNop()).RegisterRoutes(e.Group("/admin"))
+
+	rotate := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/webhook-keys/rotate", nil))
+		return w
+	}
+
+	for range 2 {
+		require.Equal(t, http.StatusCreated, rotate().Code)
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/webhook-keys", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "private_key")
+	var keys []models.WebhookKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	require.Len(t, keys, 2)
+	assert.Nil(t, keys[0].RetiredAt)
+	assert.NotNil(t, keys[1].RetiredAt)
+
+	store.conflict = true
+	assert.Equal(t, http.StatusConflict, rotate().Code)
+}
@@ -0,0 +1,217 @@
+package apierr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details.
+const MIMEProblemJSON = "application/problem+json"
+
+// Error codes shared by handlers.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeValidationFailed    = "validation_failed"
+	CodeInvalidID           = "invalid_id"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeRouteNotFound       = "route_not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodeLimitExceeded       = "limit_exceeded"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodePriceChangeTooLarge = "price_change_too_large"
+	CodeTimeout             = "timeout"
+	CodeOverloaded          = "overloaded"
+	CodeReadOnly            = "read_only"
+	CodeRejected            = "rejected"
+	CodeDeliveryFailed      = "delivery_failed"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeInjectedFault       = "injected_fault"
+	CodeInternal            = "internal"
+)
+
+// Error is an API error rendered by Middleware.
+type Error struct {
+	Status int    // HTTP status code.
+	Code   string // Machine-readable error code, e.g. "not_found".
+	Detail string // Human-readable explanation (English, fmt format if Args are set).
+	Args   []any  // Optional Detail format arguments.
+	Err    error  // Underlying error, never sent to the client.
+
+	// Extensions are additional members of the body, e.g. the ID of a
+	// conflicting resource, so clients can recover without another request.
+	Extensions map[string]any
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Message() + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Message()
+}
+
+// Message returns Detail formatted with Args.
+func (e *Error) Message() string {
+	if len(e.Args) == 0 {
+		return e.Detail
+	}
+	return fmt.Sprintf(e.Detail, e.Args...)
+}
+
+// Unwrap returns the underlying error for compatibility with errors.Is/As.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code,omitempty"`
+
+	// Extensions are additional members, rendered next to the standard ones.
+	Extensions map[string]any `json:"-"`
+}
+
+// Translator localizes the message of err for the request.
+type Translator interface {
+	Translate(r *http.Request, err *Error) string
+}
+
+// Option configures Middleware.
+type Option func(*options)
+
+type options struct {
+	translator Translator
+}
+
+// WithTranslator localizes rendered error messages.
+func WithTranslator(t Translator) Option {
+	return func(o *options) {
+		o.translator = t
+	}
+}
+
+// Abort stops the handler chain; the error is rendered by Middleware.
+func Abort(c *gin.Context, status int, code, detail string) {
+	AbortWithError(c, &Error{Status: status, Code: code, Detail: detail})
+}
+
+// Abortf is like Abort, with detail being a format for args.
+func Abortf(c *gin.Context, status int, code, format string, args ...any) {
+	AbortWithError(c, &Error{Status: status, Code: code, Detail: format, Args: args})
+}
+
+// AbortWithError stops the handler chain with err; it is rendered by Middleware.
+func AbortWithError(c *gin.Context, err *Error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Middleware renders the last error added to the context, unless the
+// response is already written. Clients accepting application/problem+json
+// get RFC 7807 problem details, others the legacy {"error": "..."} body.
+// Errors that are not *Error are rendered as 500 without exposing details.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+
+		var apiErr *Error
+		if !errors.As(c.Errors.Last().Err, &apiErr) {
+			apiErr = &Error{
+				Status: http.StatusInternalServerError,
+				Code:   CodeInternal,
+				Detail: "internal server error",
+				Err:    c.Errors.Last().Err,
+			}
+		}
+
+		detail := apiErr.Message()
+		if o.translator != nil {
+			detail = o.translator.Translate(c.Request, apiErr)
+		}
+
+		Render(c, apiErr, detail)
+	}
+}
+
+// Render writes err with the given detail message using content negotiation.
+func Render(c *gin.Context, err *Error, detail string) {
+	if !acceptsProblem(c.Request) {
+		body := gin.H{"error": detail}
+		for k, v := range err.Extensions {
+			body[k] = v
+		}
+		c.JSON(err.Status, body)
+		return
+	}
+
+	c.Render(err.Status, problemRender{Problem{
+		Type:       "urn:subscriptions:error:" + err.Code,
+		Title:      http.StatusText(err.Status),
+		Status:     err.Status,
+		Detail:     detail,
+		Instance:   c.Request.URL.Path,
+		Code:       err.Code,
+		Extensions: err.Extensions,
+	}})
+}
+
+// acceptsProblem reports whether the Accept header explicitly lists
+// application/problem+json with a non-zero quality.
+func acceptsProblem(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(mediaType), MIMEProblemJSON) {
+				continue
+			}
+			return quality(params) > 0
+		}
+	}
+	return false
+}
+
+// quality returns the q parameter of a media range (1 if absent or malformed).
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(key, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 1
+		}
+		return q
+	}
+	return 1
+}
+
+// NoRoute renders unknown routes as API errors.
+func NoRoute(c *gin.Context) {
+	Abort(c, http.StatusNotFound, CodeRouteNotFound, "route not found")
+}
+
+// NoMethod renders unsupported methods as API errors.
+func NoMethod(c *gin.Context) {
+	Abort(c, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
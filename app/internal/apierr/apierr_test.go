@@ -0,0 +1,123 @@
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(Middleware())
+	e.GET("/not-found", func(c *gin.Context) {
+		Abort(c, http.StatusNotFound, CodeNotFound, "subscription not found")
+	})
+	e.GET("/conflict", func(c *gin.Context) {
+		AbortWithError(c, &Error{
+			Status:     http.StatusConflict,
+			Code:       CodeConflict,
+			Detail:     "subscription already exists",
+			Extensions: map[string]any{"existing_id": 42},
+		})
+	})
+	e.GET("/unexpected", func(c *gin.Context) {
+		_ = c.Error(errors.New("connection refused"))
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   map[string]any
+	}{
+		{
+			name:       "legacy body by default",
+			path:       "/not-found",
+			accept:     "application/json",
+			wantStatus: http.StatusNotFound,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "subscription not found"},
+		},
+		{
+			name:       "problem details when accepted",
+			path:       "/not-found",
+			accept:     "application/json;q=0.5, application/problem+json",
+			wantStatus: http.StatusNotFound,
+			wantType:   MIMEProblemJSON,
+			wantBody: map[string]any{
+				"type":     "urn:subscriptions:error:not_found",
+				"title":    "Not Found",
+				"status":   float64(http.StatusNotFound),
+				"detail":   "subscription not found",
+				"instance": "/not-found",
+				"code":     "not_found",
+			},
+		},
+		{
+			name:       "problem details refused with q=0",
+			path:       "/not-found",
+			accept:     "application/problem+json;q=0",
+			wantStatus: http.StatusNotFound,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "subscription not found"},
+		},
+		{
+			name:       "extensions in the legacy body",
+			path:       "/conflict",
+			wantStatus: http.StatusConflict,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "subscription already exists", "existing_id": float64(42)},
+		},
+		{
+			name:       "extensions in problem details",
+			path:       "/conflict",
+			accept:     MIMEProblemJSON,
+			wantStatus: http.StatusConflict,
+			wantType:   MIMEProblemJSON,
+			wantBody: map[string]any{
+				"type":        "urn:subscriptions:error:conflict",
+				"title":       "Conflict",
+				"status":      float64(http.StatusConflict),
+				"detail":      "subscription already exists",
+				"instance":    "/conflict",
+				"code":        "conflict",
+				"existing_id": float64(42),
+			},
+		},
+		{
+			name:       "unexpected errors are hidden",
+			path:       "/unexpected",
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "internal server error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantType, w.Header().Get("Content-Type"))
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package apierr
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// problemRender renders Problem with the application/problem+json content type.
+type problemRender struct {
+	problem Problem
+}
+
+// Render implements render.Render.
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+// MarshalJSON encodes the standard members followed by the extensions.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	ext, err := json.Marshal(p.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	// Splice {"a":1} and {"b":2} into {"a":1,"b":2}.
+	return append(append(data[:len(data)-1], ','), ext[1:]...), nil
+}
+
+// WriteContentType implements render.Render.
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", MIMEProblemJSON)
+}
@@ -2,20 +2,51 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"subscriptionsservice/internal/accounts"
+	"subscriptionsservice/internal/admin"
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/audit"
+	"subscriptionsservice/internal/backfill"
+	"subscriptionsservice/internal/backup"
 	"subscriptionsservice/internal/config"
 	"subscriptionsservice/internal/database"
+	"subscriptionsservice/internal/deadletter"
+	"subscriptionsservice/internal/diagnostics"
+	"subscriptionsservice/internal/docs"
+	"subscriptionsservice/internal/encryption"
+	"subscriptionsservice/internal/eventbus"
+	"subscriptionsservice/internal/events"
+	"subscriptionsservice/internal/fault"
 	"subscriptionsservice/internal/handler"
+	"subscriptionsservice/internal/health"
+	"subscriptionsservice/internal/httpclient"
+	"subscriptionsservice/internal/i18n"
+	"subscriptionsservice/internal/integrity"
+	"subscriptionsservice/internal/lock"
+	"subscriptionsservice/internal/memory"
+	"subscriptionsservice/internal/metrics"
+	"subscriptionsservice/internal/middleware"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/notifications"
+	"subscriptionsservice/internal/readonly"
 	"subscriptionsservice/internal/repository"
 	"subscriptionsservice/internal/service"
+	"subscriptionsservice/internal/signing"
+	"subscriptionsservice/internal/slo"
+	"subscriptionsservice/internal/tenant"
+	"subscriptionsservice/internal/workerpool"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
-
-	_ "subscriptionsservice/internal/docs"
 )
 
 // App represents the application with its dependencies.
@@ -25,50 +56,516 @@ type App struct {
 	db     *pgxpool.Pool
 	engine *gin.Engine
 
+	// ready is set once startup self-checks pass.
+	ready atomic.Bool
+
+	// notifier delivers admin alerts to the configured channels.
+	notifier notifications.Channel
+	// deadLetters keeps alerts that could not be delivered.
+	deadLetters *deadletter.Queue
+	// subs is reconfigured with new usage limits on config reloads.
+	subs *service.SubscriptionService
+	// bus delivers subscription events to in-process subscribers.
+	bus *eventbus.Bus[events.Event]
+	// workers runs background tasks with bounded concurrency.
+	workers *workerpool.Pool
+	// warmer precomputes dashboard summaries at startup, nil if disabled.
+	warmer *service.SummaryWarmer
+	// lifecycle starts and stops the subsystems above in order.
+	lifecycle *Lifecycle
+
 	log *zap.Logger
 }
 
+const (
+	// eventBufferSize is the number of events buffered per bus subscriber.
+	eventBufferSize = 256
+	// eventDrainTimeout limits waiting for bus subscribers on shutdown.
+	eventDrainTimeout = 5 * time.Second
+	// workersDrainTimeout limits waiting for background tasks on shutdown.
+	workersDrainTimeout = 10 * time.Second
+	// backfillStopTimeout limits waiting for interrupted backfill batches
+	// on shutdown; they are rolled back and rerun on the next start.
+	backfillStopTimeout = 5 * time.Second
+	// summarySubscriptionsStopTimeout limits waiting for summaries being
+	// sent on shutdown; unsent ones stay due and are sent after restart.
+	summarySubscriptionsStopTimeout = 5 * time.Second
+	// integrityStopTimeout limits waiting for integrity checks in progress
+	// on shutdown; they are read-only and rerun on the next interval.
+	integrityStopTimeout = 5 * time.Second
+	// businessMetricsStopTimeout limits waiting for a business metrics
+	// refresh in progress on shutdown.
+	businessMetricsStopTimeout = 2 * time.Second
+	// responseCacheSize limits responses in the shared response cache.
+	responseCacheSize = 10000
+)
+
+// Option configures App.
+type Option func(*options)
+
+type options struct {
+	hooks *service.Hooks
+}
+
+// WithHooks runs the service extension hooks registered in h, e.g. by
+// tenant-specific extensions (see service.Hooks).
+func WithHooks(h *service.Hooks) Option {
+	return func(o *options) {
+		o.hooks = h
+	}
+}
+
 // New creates a new App instance, initializes database, services, handlers and routes.
-func New(cfg *config.Config, log *zap.Logger) *App {
-	db, err := database.Connect(context.Background(), cfg.DatabaseURL)
+func New(ctx context.Context, cfg *config.Config, log *zap.Logger, opts ...Option) (*App, error) {
+	o := options{hooks: service.NewHooks()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	catalog, err := i18n.NewCatalog(models.Validator())
 	if err != nil {
-		log.Fatal("failed to connect to database", zap.Error(err))
+		return nil, fmt.Errorf("failed to load message catalog: %w", err)
+	}
+
+	schemas, err := events.NewRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event schemas: %w", err)
+	}
+
+	poolOpts := []database.PoolOption{
+		database.WithQueryExecMode(cfg.Database.ExecMode()),
+		database.WithStatementCache(cfg.Database.StatementCacheCapacity, cfg.Database.DescriptionCacheCapacity),
+	}
+	tenantSchemas := cfg.Tenancy.Mode == config.TenancySchema
+	if tenantSchemas {
+		// Queries of tenant requests run in the tenant's schema, others,
+		// e.g. of background tasks, in the default one.
+		poolOpts = append(poolOpts, database.WithSearchPath(tenant.SchemaFromContext))
+	}
+	db, err := database.Connect(ctx, cfg.DatabaseURL, poolOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	dateFormat, err := models.ParseDateFormat(cfg.App.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+	models.SetDefaultDateFormat(dateFormat)
+	models.SetLenientPrices(cfg.App.LenientPrices)
+
+	var memLimit int64
+	if cfg.App.MemoryLimit != "" {
+		if memLimit, err = memory.ParseLimit(cfg.App.MemoryLimit); err != nil {
+			db.Close()
+			return nil, err
+		}
 	}
+	mem := memory.Apply(memory.Settings{Limit: memLimit, GCPercent: cfg.App.GCPercent})
+	log.Info("runtime memory settings", zap.Int64("memory_limit_bytes", mem.Limit), zap.Int("gc_percent", mem.GCPercent))
+
+	reg := metrics.NewRegistry()
 
 	e := gin.New()
+	e.HandleMethodNotAllowed = true
+	// Outside apierr.Middleware, so rendered errors are seen with their status.
+	e.Use(middleware.AccessLog(log, middleware.AccessLogConfig(cfg.AccessLog)))
+	e.Use(middleware.Metrics(metrics.NewHTTPMetrics(reg)))
+	// Probes and scrapes would dilute the objectives of API requests.
+	sloTracker := slo.NewTracker(cfg.SLO.Window, slo.WithIgnoredRoutes("/status", "/healthz", "/readyz", "/metrics"))
+	e.Use(middleware.Metrics(sloTracker))
+	e.Use(apierr.Middleware(apierr.WithTranslator(catalog)))
+	e.NoRoute(apierr.NoRoute)
+	e.NoMethod(apierr.NoMethod)
+
+	httpMetrics := httpclient.NewMetrics(reg)
+
+	readOnly := readonly.New(cfg.App.ReadOnly, "app.read_only")
+	if cfg.App.ReadOnly {
+		log.Warn("read-only mode is enabled, writes are rejected")
+	}
+	// Innermost, so transactions begun on it are guarded too.
+	guard := repository.NewReadOnlyGuard(db, readOnly.Enabled)
+
+	var exec repository.Executer = guard
+	var faults *fault.Injector
+	if cfg.Faults.Enabled {
+		log.Warn("fault injection is enabled, it must never be used in production")
+		faults = fault.New(fault.Faults{
+			HTTP:   fault.Spec(cfg.Faults.HTTP),
+			Status: cfg.Faults.HTTPStatus,
+			DB:     fault.Spec(cfg.Faults.DB),
+		}, cfg.Faults.AllowHeaders)
+		exec = fault.NewExecuter(exec, faults)
+	}
+	if cfg.Database.LogQueries {
+		exec = repository.NewQueryLogger(exec, log)
+	}
+
+	repoRetrier := newRepoRetrier(cfg.Retry, isRetryableFunc, metrics.NewRetryMetrics(reg))
+
+	var webhookKeyRepoOpts []repository.WebhookKeyRepoOption
+	encrypted := len(cfg.Encryption.Keys) > 0
+	if encrypted {
+		keyring, err := encryption.New(cfg.Encryption.Keys, cfg.Encryption.ActiveKey)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to configure encryption: %w", err)
+		}
+		webhookKeyRepoOpts = append(webhookKeyRepoOpts, repository.WithWebhookKeyCodec(keyring))
+	}
+	webhookKeyRepo := repository.NewWebhookKeyRepo(exec, repoRetrier, webhookKeyRepoOpts...)
+
+	var webhookKeys *signing.Keyring
+	var signer notifications.Signer
+	if slices.Contains(cfg.Notifications.Channels, "webhook") {
+		webhookKeys = signing.NewKeyring(webhookKeyRepo, cfg.Notifications.Webhook.KeyGracePeriod, log)
+		signer = webhookKeys
+	}
+	notifier, err := notifications.New(cfg.Notifications,
+		httpclient.New("notifications", httpclient.WithMetrics(httpMetrics)), signer)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure notifications: %w", err)
+	}
+
+	subsRepoOpts := []repository.SubscriptionsRepoOption{
+		repository.WithHedgedReads(cfg.Hedge.Delay),
+		repository.WithSummaryBoundaries(repository.SummaryBoundaries(cfg.App.SummaryBoundaries)),
+		repository.WithRegion(cfg.App.Region),
+	}
+	if cfg.Users.RequireProvisioned {
+		subsRepoOpts = append(subsRepoOpts, repository.WithProvisionedUsers())
+	}
+	rawSubsRepo := repository.NewSubscriptionsRepo(exec, repoRetrier, subsRepoOpts...)
+	subsRepo := metrics.NewInstrumentedRepo(rawSubsRepo, reg)
+	quotaRepo := repository.NewWriteQuotaRepo(exec, repoRetrier)
+	bus := eventbus.New[events.Event](eventBufferSize, log)
+	auditRepo := repository.NewAuditRepo(exec, repoRetrier)
+	subsOpts := []service.Option{
+		service.WithMaxActivePerUser(cfg.Limits.MaxActivePerUser),
+		service.WithWriteQuota(quotaRepo, cfg.Limits.WritesPerUserPerHour),
+		service.WithServicesCache(cfg.App.ServicesCacheTTL),
+		service.WithSummaryCache(cfg.App.SummaryCacheTTL),
+		service.WithPriceChangeGuard(cfg.Limits.MaxPriceChangePercent),
+		service.WithAudit(audit.NewLog(log)),
+		service.WithTransactor(repository.NewTxManager(guard)),
+		service.WithHooks(o.hooks),
+		// No broker yet: events are validated, so contract drift shows up in
+		// logs, and only delivered to in-process subscribers of the bus.
+		service.WithPublisher(events.Validating(schemas, bus)),
+	}
+	if cfg.Audit.Store {
+		subsOpts = append(subsOpts, service.WithWriteAudit(audit.Tee(audit.NewLog(log), audit.NewStore(auditRepo, log))))
+	}
+	if a := cfg.Users.Accounts; a.URL != "" {
+		client := httpclient.New("accounts", httpclient.WithTimeout(a.Timeout), httpclient.WithMetrics(httpMetrics))
+		subsOpts = append(subsOpts, service.WithUserValidator(accounts.New(client, a.URL, a.Token, a.CacheTTL)))
+	}
+	var tenants *tenant.Registry
+	if tenantSchemas {
+		tenants = tenant.NewRegistry(repository.NewTenantRepo(exec, repoRetrier), schemaMigrator{
+			db:    db,
+			dir:   cfg.App.MirgationDir,
+			dbURL: database.MigrationURL(cfg.Database.Dialect, cfg.MigrationDatabaseURL()),
+		}, log)
+		subsOpts = append(subsOpts, service.WithTenantLimits(tenants))
+	}
+	responses := middleware.NewResponses(cfg.ResponseCache.TTL, responseCacheSize)
+	if responses != nil {
+		subsOpts = append(subsOpts, service.WithResponseCache(responses))
+	}
+	subsSvc := service.NewSubscriptionService(subsRepo, log, subsOpts...)
+	var warmer *service.SummaryWarmer
+	if cfg.App.SummaryWarmupUsers > 0 {
+		warmer = service.NewSummaryWarmer(subsSvc, rawSubsRepo, cfg.App.SummaryWarmupUsers, log)
+		// Writes drop the summaries they change; the bus subscriber
+		// recomputes them off the request path.
+		if _, err := bus.Subscribe("summary warm-up", func(ctx context.Context, _ events.Event) {
+			warmer.Refill(ctx)
+		}); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to subscribe summary warm-up: %w", err)
+		}
+	}
+	deadLetters := deadletter.New(repository.NewDeadLetterRepo(exec, repoRetrier), log)
+	deadLetters.Register(notificationKind, func(ctx context.Context, payload json.RawMessage) error {
+		var msg notifications.Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return notifier.Send(ctx, msg)
+	})
+
+	analyticsSvc := service.NewAnalyticsService(repository.NewAnalyticsRepo(exec, repoRetrier), log)
 
-	subsRepo := repository.NewSubscriptionsRepo(
-		db, newRepoRetrier(cfg.Retry, isRetryableFunc),
-	)
-	subsSvc := service.NewSubscriptionService(subsRepo, log)
-	subsHandler := handler.NewSubscriptionHandler(subsSvc, log)
+	routes := middleware.NewRoutes()
+	routes.Deprecate(http.MethodPost, "/subscriptions/summary", middleware.Deprecation{
+		Since: time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		// The replacement has the same path and another method, which a
+		// successor-version link cannot tell, so the link points to the
+		// operation description saying to use GET.
+		Docs: "/swagger/index.html#/subscriptions/post_subscriptions_summary",
+	})
+	for _, r := range cfg.Routes {
+		routes.Limit(strings.ToUpper(r.Method), r.Path, middleware.Limit{
+			Timeout:     r.Timeout,
+			MaxInFlight: r.MaxInFlight,
+		})
+	}
+	for _, path := range []string{"/subscriptions/", "/subscriptions/summary"} {
+		routes.Cache(http.MethodGet, path, middleware.Cache{MaxAge: cfg.ResponseCache.MaxAge})
+	}
+	routes.MarkSafe(http.MethodPost, "/subscriptions/summary")
+	routes.MarkSafe(http.MethodPut, "/admin"+admin.ReadOnlyPath)
+
+	summarySubs := service.NewSummarySubscriptionService(repository.NewSummarySubscriptionRepo(exec, repoRetrier), subsSvc, notifier, log)
+
+	handlerOpts := []handler.Option{
+		handler.WithPageSize(cfg.App.DefaultPageSize, cfg.App.MaxPageSize),
+		handler.WithSavedFilters(service.NewSavedFilterService(repository.NewSavedFilterRepo(exec, repoRetrier), log)),
+		handler.WithSummarySubscriptions(summarySubs),
+	}
+	if cfg.Audit.Store {
+		handlerOpts = append(handlerOpts, handler.WithActivity(service.NewActivityService(auditRepo, log)))
+	}
+	var tenantMiddleware []gin.HandlerFunc
+	if tenantSchemas {
+		// Before request transactions, so they are begun in the tenant's schema.
+		tenantMiddleware = append(tenantMiddleware, middleware.Tenant(cfg.Tenancy.Header, cfg.Tenancy.APIKeyHeader, tenants))
+		handlerOpts = append(handlerOpts, handler.WithMiddleware(tenantMiddleware...))
+	}
+	if cfg.Auth.UserHeader != "" {
+		handlerOpts = append(handlerOpts, handler.WithMiddleware(middleware.Principal(cfg.Auth.UserHeader, cfg.Auth.RolesHeader)))
+	}
+	// After Tenant and Principal, as responses depend on the tenant and the caller.
+	handlerOpts = append(handlerOpts, handler.WithMiddleware(middleware.ResponseCache(routes, responses)))
+	if cfg.Database.RequestTransactions {
+		// Begun below fault injection and query logging: statements in the
+		// transaction bypass them, as with repository.WithTx.
+		handlerOpts = append(handlerOpts, handler.WithMiddleware(middleware.Transaction(routes, guard)))
+	}
+	subsHandler := handler.NewSubscriptionHandler(subsSvc, log, handlerOpts...)
+
+	e.Use(middleware.Deprecated(routes))
+	e.Use(middleware.Limits(routes))
+	e.Use(middleware.ReadOnly(routes, readOnly.Enabled))
+	e.Use(middleware.Consistency())
+	e.Use(middleware.DateFormat())
+	if faults != nil {
+		e.Use(middleware.Faults(faults))
+	}
 
 	subsHandler.RegisterRoutes(e)
 
-	e.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	schemas.RegisterRoutes(e)
+	swagger, err := swaggerHandler(cfg.Swagger.OIDC)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure swagger: %w", err)
+	}
+	e.GET("/swagger/*any", swagger)
+	e.GET("/typescript/api.d.ts", gin.WrapH(docs.TypeScriptHandler()))
+	e.GET("/metrics", gin.WrapH(metrics.Handler(reg)))
+
+	healthReg := health.NewRegistry(healthCheckTimeout)
+	registerHealthChecks(healthReg, db)
+	e.GET("/healthz", health.Handler(healthReg))
+	e.GET("/status", slo.Handler(sloTracker, slo.Targets{
+		Availability: cfg.SLO.Availability,
+		LatencyP95:   cfg.SLO.LatencyP95,
+		LatencyP99:   cfg.SLO.LatencyP99,
+	}))
+	if webhookKeys != nil {
+		e.GET("/.well-known/webhook-keys", signing.Handler(webhookKeys))
+	}
+
+	integrityChecker := integrity.NewChecker(repository.NewIntegrityRepo(exec, repoRetrier), log,
+		integrity.WithRecorder(metrics.NewIntegrityMetrics(reg)), integrity.WithSamples(cfg.Integrity.Samples))
+
+	backfillRepo := repository.NewBackfillRepo(exec, repoRetrier)
+	backfills := backfill.NewRunner(backfillRepo, repository.NewTxManager(guard), log,
+		[]backfill.Backfill{backfill.NormalizeDates(backfillRepo)},
+		backfill.WithBatchSize(cfg.Backfill.BatchSize), backfill.WithPause(cfg.Backfill.Pause))
+
+	if cfg.Admin.Token != "" {
+		adminGroup := e.Group("/admin", middleware.BearerToken(cfg.Admin.Token))
+		admin.NewHandler(cfg, time.Now()).RegisterRoutes(adminGroup)
+		admin.NewDeadLettersHandler(deadLetters).RegisterRoutes(adminGroup)
+		admin.NewMaintenanceHandler(repository.NewMaintenanceRepo(exec, repoRetrier)).RegisterRoutes(adminGroup)
+		admin.NewReadOnlyHandler(readOnly, log).RegisterRoutes(adminGroup)
+		admin.NewProfileHandler(log).RegisterRoutes(adminGroup)
+		admin.NewBackfillsHandler(backfills).RegisterRoutes(adminGroup)
+		admin.NewIntegrityHandler(integrityChecker).RegisterRoutes(adminGroup)
+		if tenantSchemas {
+			admin.NewTenantsHandler(tenants, log).RegisterRoutes(adminGroup)
+		}
+		if webhookKeys != nil {
+			admin.NewWebhookKeysHandler(webhookKeys, log).RegisterRoutes(adminGroup)
+		}
+		if encrypted {
+			admin.NewEncryptionHandler(map[string]admin.Reencrypter{
+				"webhook_keys.private_key": webhookKeyRepo,
+			}, log).RegisterRoutes(adminGroup)
+		}
+		if cfg.Backups.Dir != "" {
+			dir, err := backup.NewDir(cfg.Backups.Dir)
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+			// Dumps are not counted in repository metrics: Iterate is not instrumented.
+			backups := backup.New(rawSubsRepo, dir, log)
+			admin.NewBackupsHandler(backups).RegisterRoutes(adminGroup)
+			restorer := backup.NewRestorer(rawSubsRepo, repository.NewTxManager(guard), log)
+			admin.NewRestoresHandler(restorer, backups).RegisterRoutes(adminGroup)
+		}
+
+		analyticsGroup := e.Group("/analytics", append([]gin.HandlerFunc{middleware.BearerToken(cfg.Admin.Token)}, tenantMiddleware...)...)
+		handler.NewAnalyticsHandler(analyticsSvc, log).RegisterRoutes(analyticsGroup)
+
+		usersGroup := e.Group("/users", append([]gin.HandlerFunc{middleware.BearerToken(cfg.Admin.Token)}, tenantMiddleware...)...)
+		usersSvc := service.NewUserService(repository.NewUserRepo(exec, repoRetrier), log)
+		handler.NewUserHandler(usersSvc, log).RegisterRoutes(usersGroup)
+	} else {
+		log.Info("admin, analytics and user sync endpoints are disabled: admin.token is not set")
+	}
+
+	// Started last, so failed setup steps above leave no workers behind.
+	workers := workerpool.New(cfg.Workers.Size, cfg.Workers.QueueDepth, log,
+		workerpool.WithMetricsRecorder(metrics.NewWorkerPoolMetrics(reg).Recorder("background")))
 
-	return &App{
-		cfg:    cfg,
-		db:     db,
-		engine: e,
-		log:    log,
+	// Stopped in reverse: requests stop coming in before event subscribers
+	// and the background tasks they queue are drained, the database is
+	// closed last.
+	lifecycle := NewLifecycle(log)
+	lifecycle.Register("database", StopFunc(func(context.Context) error {
+		db.Close()
+		return nil
+	}), 0)
+	if cfg.App.MemStatsInterval > 0 {
+		lifecycle.Register("memory stats", memory.NewReporter(log, cfg.App.MemStatsInterval), 0)
+	}
+	lifecycle.Register("backfills", backfills, backfillStopTimeout)
+	if s := cfg.SummarySubscriptions; s.Interval > 0 {
+		sendDue := func(ctx context.Context) error { return summarySubs.SendDue(ctx, s.BatchSize) }
+		if tenantSchemas {
+			// Summary subscriptions of tenants are in their schemas.
+			sendDue = func(ctx context.Context) error {
+				return tenants.ForEach(ctx, func(ctx context.Context) error { return summarySubs.SendDue(ctx, s.BatchSize) })
+			}
+		}
+		lifecycle.Register("summary subscriptions", lock.NewPeriodic("summary_subscriptions", s.Interval, lock.NewPostgres(db),
+			sendDue, log), summarySubscriptionsStopTimeout)
 	}
+	if cfg.Integrity.Interval > 0 {
+		lifecycle.Register("integrity", lock.NewPeriodic("integrity", cfg.Integrity.Interval, lock.NewPostgres(db),
+			integrityChecker.Run, log), integrityStopTimeout)
+	}
+	if b := cfg.BusinessMetrics; b.Interval > 0 {
+		lifecycle.Register("business metrics", metrics.NewBusinessMetrics(repository.NewAnalyticsRepo(exec, repoRetrier),
+			b.Interval, b.TopServices, log, reg), businessMetricsStopTimeout)
+	}
+	lifecycle.Register("workers", workers, workersDrainTimeout)
+	lifecycle.Register("events", StopFunc(bus.Close), eventDrainTimeout)
+	lifecycle.Register("http", newHTTPServer(cfg.ListenAddrs(), e.Handler(), log), cfg.App.ShutdownTimeout)
+
+	a := &App{
+		cfg:         cfg,
+		db:          db,
+		engine:      e,
+		notifier:    notifier,
+		deadLetters: deadLetters,
+		subs:        subsSvc,
+		bus:         bus,
+		workers:     workers,
+		warmer:      warmer,
+		lifecycle:   lifecycle,
+		log:         log,
+	}
+	e.GET("/readyz", a.readyz)
+
+	return a, nil
+}
+
+// readyz reports whether the service may receive traffic.
+func (a *App) readyz(c *gin.Context) {
+	if !a.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
 }
 
-// Run starts the HTTP server and waits for context cancellation.
+// Run starts the subsystems, runs startup self-checks and waits for context
+// cancellation. The service becomes ready only if all critical checks pass;
+// otherwise it keeps running, so the failure is visible in logs and /healthz.
 func (a *App) Run(ctx context.Context) error {
-	go func() {
-		if err := a.engine.Run(":" + a.cfg.App.Port); err != nil {
-			a.log.Error("failed to run server", zap.Error(err))
+	if err := a.lifecycle.Start(ctx); err != nil {
+		return err
+	}
+
+	if a.warmer != nil {
+		if err := a.workers.Submit("summary warm-up", a.warmer.Warm); err != nil {
+			a.log.Error("failed to queue summary warm-up", zap.Error(err))
 		}
-	}()
+	}
+
+	if err := diagnostics.Run(ctx, a.log, startupChecks(a.cfg, a.db)); err != nil {
+		a.log.Error("startup self-check failed, service stays not ready", zap.Error(err))
+		// Run waits for the alert only on shutdown, when workers are drained.
+		if err := a.workers.Submit("startup alert", func(ctx context.Context) error {
+			a.alert(ctx, "subscriptions: startup self-check failed", err.Error())
+			return nil
+		}); err != nil {
+			a.log.Error("failed to queue startup alert", zap.Error(err))
+		}
+	} else {
+		a.ready.Store(true)
+		a.log.Info("startup self-check passed, service is ready")
+	}
 
 	<-ctx.Done()
+	// Readiness fails first and the server keeps serving for
+	// app.shutdown_delay, so load balancers stop routing here before it
+	// stops accepting requests.
+	a.ready.Store(false)
+	if d := a.cfg.App.ShutdownDelay; d > 0 {
+		a.log.Info("not ready, waiting before shutdown", zap.Duration("delay", d))
+		time.Sleep(d)
+	}
 	return a.Shutdown()
 }
 
-// Shutdown closes database connections and other resources.
+// notificationKind is the dead letter kind of undelivered admin alerts.
+const notificationKind = "notification"
+
+// alert notifies administrators. An undelivered alert is moved to dead
+// letters, so it can be redelivered via /admin/dead-letters.
+func (a *App) alert(ctx context.Context, subject, text string) {
+	msg := notifications.Message{Subject: subject, Text: text}
+	if err := a.notifier.Send(ctx, msg); err != nil {
+		if _, dlErr := a.deadLetters.Add(ctx, notificationKind, msg, 1, err); dlErr != nil {
+			a.log.Error("failed to send admin alert", zap.String("subject", subject),
+				zap.Error(err), zap.NamedError("dead_letter_error", dlErr))
+		}
+	}
+}
+
+// Reconfigure applies the settings of cfg that may change at runtime: the
+// usage limits. Other settings are fixed at startup.
+func (a *App) Reconfigure(cfg *config.Config) {
+	a.subs.SetLimits(service.Limits{
+		MaxActivePerUser: cfg.Limits.MaxActivePerUser,
+		WritesPerHour:    cfg.Limits.WritesPerUserPerHour,
+		MaxPriceChange:   cfg.Limits.MaxPriceChangePercent,
+	})
+}
+
+// Shutdown stops the subsystems in reverse start order, each within its own
+// timeout: the HTTP server (app.shutdown_timeout), event subscribers,
+// background tasks and finally the database connections. Errors of all
+// subsystems are returned joined.
 func (a *App) Shutdown() error {
-	a.db.Close()
-	return nil
+	return a.lifecycle.Stop()
 }
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/database"
+	"subscriptionsservice/internal/diagnostics"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxClockSkew is the largest accepted difference between local and database time.
+const maxClockSkew = time.Minute
+
+// requiredIndexes are the indexes on subscriptions that queries rely on.
+var requiredIndexes = []string{
+	"idx_subscriptions_user_id",
+	"idx_subscriptions_service_name",
+	"idx_subscriptions_user_period",
+	"idx_subscriptions_period",
+	"idx_subscriptions_user_period_eff",
+	"uq_subscriptions_user_service_start",
+}
+
+// startupChecks returns the self-checks run before the service becomes ready.
+func startupChecks(cfg *config.Config, db *pgxpool.Pool) []diagnostics.Check {
+	return []diagnostics.Check{
+		{
+			Name:     "config",
+			Critical: true,
+			Run: func(context.Context) error {
+				return cfg.Validate()
+			},
+		},
+		{
+			Name:     "migrations",
+			Critical: true,
+			Run: func(ctx context.Context) error {
+				version, dirty, err := database.MigrationVersion(ctx, db)
+				if err != nil {
+					return err
+				}
+				if dirty {
+					return fmt.Errorf("migration %d failed, schema is dirty", version)
+				}
+				latest, err := database.LatestMigration(cfg.App.MirgationDir)
+				if err != nil {
+					return err
+				}
+				if version < latest {
+					return fmt.Errorf("schema version %d, expected %d", version, latest)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "indexes",
+			Critical: true,
+			Run: func(ctx context.Context) error {
+				indexes, err := database.Indexes(ctx, db, "subscriptions")
+				if err != nil {
+					return err
+				}
+				var missing []string
+				for _, name := range requiredIndexes {
+					if !slices.Contains(indexes, name) {
+						missing = append(missing, name)
+					}
+				}
+				if len(missing) > 0 {
+					return fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "clock",
+			Critical: true,
+			Run: func(ctx context.Context) error {
+				dbNow, err := database.Now(ctx, db)
+				if err != nil {
+					return err
+				}
+				skew := time.Since(dbNow).Abs()
+				if skew > maxClockSkew {
+					return fmt.Errorf("local clock differs from database by %s", skew)
+				}
+				return nil
+			},
+		},
+	}
+}
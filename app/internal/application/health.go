@@ -0,0 +1,30 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"subscriptionsservice/internal/database"
+	"subscriptionsservice/internal/health"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthCheckTimeout limits a single health check.
+const healthCheckTimeout = 2 * time.Second
+
+// registerHealthChecks registers checks of the application's dependencies.
+func registerHealthChecks(reg *health.Registry, db *pgxpool.Pool) {
+	reg.Register("db", db.Ping)
+	reg.Register("migrations", func(ctx context.Context) error {
+		_, dirty, err := database.MigrationVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return errors.New("last migration failed, schema is dirty")
+		}
+		return nil
+	})
+}
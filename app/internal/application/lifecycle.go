@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Starter is a subsystem that has to be started, e.g. a server or a consumer.
+type Starter interface {
+	// Start starts the subsystem and returns; long-running work goes to
+	// its own goroutines.
+	Start(ctx context.Context) error
+}
+
+// Stopper is a subsystem that has to be stopped, e.g. a pool to drain.
+type Stopper interface {
+	// Stop stops the subsystem, giving up when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+// StartFunc adapts a function to Starter.
+type StartFunc func(ctx context.Context) error
+
+// Start implements Starter.
+func (f StartFunc) Start(ctx context.Context) error { return f(ctx) }
+
+// StopFunc adapts a function to Stopper.
+type StopFunc func(ctx context.Context) error
+
+// Stop implements Stopper.
+func (f StopFunc) Stop(ctx context.Context) error { return f(ctx) }
+
+// component is a registered subsystem.
+type component struct {
+	name        string
+	starter     Starter
+	stopper     Stopper
+	stopTimeout time.Duration
+	// running is set once the component is started. Components without a
+	// Starter run from registration, e.g. a pool created with its workers.
+	running bool
+}
+
+// Lifecycle starts subsystems in the order they are registered and stops
+// them in reverse order, each with its own timeout, so e.g. the HTTP server
+// stops taking requests before the pools it feeds are drained and the
+// database is closed last.
+type Lifecycle struct {
+	log        *zap.Logger
+	components []component
+}
+
+// NewLifecycle creates an empty lifecycle.
+func NewLifecycle(log *zap.Logger) *Lifecycle {
+	return &Lifecycle{log: log}
+}
+
+// Register adds a subsystem. c must implement Starter, Stopper or both.
+// stopTimeout bounds its Stop; 0 means no bound.
+func (l *Lifecycle) Register(name string, c any, stopTimeout time.Duration) {
+	starter, _ := c.(Starter)
+	stopper, _ := c.(Stopper)
+	if starter == nil && stopper == nil {
+		panic(fmt.Sprintf("lifecycle: %s implements neither Starter nor Stopper", name))
+	}
+	l.components = append(l.components, component{
+		name:        name,
+		starter:     starter,
+		stopper:     stopper,
+		stopTimeout: stopTimeout,
+		running:     starter == nil,
+	})
+}
+
+// Start starts the registered subsystems in order. If one fails, the
+// running ones are stopped and its error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for i := range l.components {
+		c := &l.components[i]
+		if c.running {
+			continue
+		}
+		if err := c.starter.Start(ctx); err != nil {
+			err = fmt.Errorf("failed to start %s: %w", c.name, err)
+			return errors.Join(err, l.Stop())
+		}
+		c.running = true
+		l.log.Debug("started", zap.String("component", c.name))
+	}
+	return nil
+}
+
+// Stop stops the running subsystems in reverse order. A subsystem that
+// fails or times out does not keep the others from stopping; all errors are
+// returned joined. Stop may be called more than once.
+func (l *Lifecycle) Stop() error {
+	var errs []error
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := &l.components[i]
+		if !c.running {
+			continue
+		}
+		c.running = false
+		if c.stopper == nil {
+			continue
+		}
+		if err := l.stop(c); err != nil {
+			l.log.Error("failed to stop", zap.String("component", c.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.name, err))
+			continue
+		}
+		l.log.Debug("stopped", zap.String("component", c.name))
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Lifecycle) stop(c *component) error {
+	ctx := context.Background()
+	if c.stopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.stopTimeout)
+		defer cancel()
+	}
+	return c.stopper.Stop(ctx)
+}
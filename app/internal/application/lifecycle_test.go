@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeComponent records its start and stop calls into calls.
+type fakeComponent struct {
+	name     string
+	calls    *[]string
+	startErr error
+	stopErr  error
+}
+
+func (c *fakeComponent) Start(context.Context) error {
+	*c.calls = append(*c.calls, "start "+c.name)
+	return c.startErr
+}
+
+func (c *fakeComponent) Stop(context.Context) error {
+	*c.calls = append(*c.calls, "stop "+c.name)
+	return c.stopErr
+}
+
+func TestLifecycle_Order(t *testing.T) {
+	var calls []string
+	l := NewLifecycle(zap.NewNop())
+	l.Register("db", StopFunc(func(context.Context) error {
+		calls = append(calls, "stop db")
+		return nil
+	}), 0)
+	l.Register("server", &fakeComponent{name: "server", calls: &calls}, 0)
+	l.Register("consumer", &fakeComponent{name: "consumer", calls: &calls}, 0)
+
+	require.NoError(t, l.Start(t.Context()))
+	require.NoError(t, l.Stop())
+	require.NoError(t, l.Stop(), "stop is idempotent")
+
+	assert.Equal(t, []string{
+		"start server", "start consumer",
+		"stop consumer", "stop server", "stop db",
+	}, calls)
+}
+
+func TestLifecycle_StartFailure(t *testing.T) {
+	var calls []string
+	l := NewLifecycle(zap.NewNop())
+	l.Register("first", &fakeComponent{name: "first", calls: &calls}, 0)
+	l.Register("broken", &fakeComponent{name: "broken", calls: &calls, startErr: errors.New("boom")}, 0)
+	l.Register("never", &fakeComponent{name: "never", calls: &calls}, 0)
+
+	err := l.Start(t.Context())
+	assert.ErrorContains(t, err, "failed to start broken: boom")
+	assert.Equal(t, []string{"start first", "start broken", "stop first"}, calls,
+		"only started components are stopped")
+}
+
+func TestLifecycle_StopErrors(t *testing.T) {
+	var calls []string
+	l := NewLifecycle(zap.NewNop())
+	l.Register("a", &fakeComponent{name: "a", calls: &calls, stopErr: errors.New("a failed")}, 0)
+	l.Register("slow", StopFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), 10*time.Millisecond)
+	l.Register("b", &fakeComponent{name: "b", calls: &calls}, 0)
+	require.NoError(t, l.Start(t.Context()))
+
+	err := l.Stop()
+	assert.ErrorContains(t, err, "failed to stop a: a failed")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "stop timeout is applied")
+	assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, calls,
+		"a failing component does not keep others from stopping")
+}
+
+func TestLifecycle_RegisterInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		NewLifecycle(zap.NewNop()).Register("nothing", struct{}{}, 0)
+	})
+}
@@ -0,0 +1,52 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"subscriptionsservice/internal/listen"
+	"subscriptionsservice/internal/runtimeutil"
+
+	"go.uber.org/zap"
+)
+
+// httpServer serves the API as a lifecycle component.
+type httpServer struct {
+	addrs []string
+	srv   *http.Server
+	log   *zap.Logger
+}
+
+func newHTTPServer(addrs []string, h http.Handler, log *zap.Logger) *httpServer {
+	return &httpServer{addrs: addrs, srv: &http.Server{Handler: h}, log: log}
+}
+
+// Start listens on all configured addresses and serves in the background.
+// If any address cannot be listened on, none is served.
+func (s *httpServer) Start(context.Context) error {
+	listeners, err := listen.ListenAll(s.addrs)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range listeners {
+		s.log.Info("listening", zap.Stringer("addr", l.Addr()))
+		runtimeutil.Go(context.Background(), s.log, func(context.Context) {
+			if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.log.Error("failed to run server", zap.Stringer("addr", l.Addr()), zap.Error(err))
+			}
+		}, runtimeutil.WithName("http server"))
+	}
+	return nil
+}
+
+// Stop stops accepting connections and waits for in-flight requests until
+// ctx is done, then closes the remaining connections.
+func (s *httpServer) Stop(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/docs"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// swaggerHandler serves the Swagger UI. With cfg.AuthorizationURL set, the
+// UI logs in at the OIDC provider with the authorization code flow and
+// PKCE, and the served document requires the resulting bearer token.
+func swaggerHandler(cfg config.SwaggerOIDC) (gin.HandlerFunc, error) {
+	if cfg.AuthorizationURL == "" {
+		return ginSwagger.WrapHandler(swaggerFiles.Handler), nil
+	}
+
+	doc, err := docs.WithOAuth2(docs.SwaggerInfo.ReadDoc(), docs.OAuth2{
+		AuthorizationURL: cfg.AuthorizationURL,
+		TokenURL:         cfg.TokenURL,
+		Scopes:           cfg.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler,
+		ginSwagger.Oauth2DefaultClientID(cfg.ClientID),
+		ginSwagger.Oauth2UsePkce(true),
+		// Keep the token across page reloads.
+		ginSwagger.PersistAuthorization(true),
+	)
+	return func(c *gin.Context) {
+		if c.Param("any") == "/doc.json" {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(doc))
+			return
+		}
+		ui(c)
+	}, nil
+}
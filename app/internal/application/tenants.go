@@ -0,0 +1,25 @@
+package application
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/database"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schemaMigrator migrates tenant schemas with the service's migrations
+// (see admin.SchemaMigrator).
+type schemaMigrator struct {
+	db    *pgxpool.Pool
+	dir   string
+	dbURL string // Migration URL, see database.MigrationURL
+}
+
+func (m schemaMigrator) MigrateSchema(ctx context.Context, schema string) error {
+	return database.MigrateSchema(ctx, m.db, m.dir, m.dbURL, schema)
+}
+
+func (m schemaMigrator) SchemaVersion(ctx context.Context, schema string) (uint, bool, error) {
+	return database.SchemaMigrationVersion(ctx, m.db, schema)
+}
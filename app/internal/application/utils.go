@@ -4,13 +4,31 @@ import (
 	"errors"
 
 	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/metrics"
+	"subscriptionsservice/internal/models"
 	"subscriptionsservice/internal/repository"
 	"subscriptionsservice/internal/retry"
 )
 
-func newRepoRetrier(cfg config.Retry, retryableFunc retry.IsRetryableFunc) retry.Retrier {
+// newRepoRetrier returns the repository retrier. With retry.profiles it is a
+// *retry.Profiles, so repositories pick the read or write policy per operation.
+// Results are recorded in m as retrier "repo" or "repo_<profile>".
+func newRepoRetrier(cfg config.Retry, retryableFunc retry.IsRetryableFunc, m *metrics.RetryMetrics) retry.Retrier {
+	if len(cfg.Profiles) == 0 {
+		return newRetrier(cfg, retryableFunc, m.Recorder("repo"))
+	}
+
+	named := make(map[string]retry.Retrier, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		named[name] = newRetrier(cfg.Profile(name), retryableFunc, m.Recorder("repo_"+name))
+	}
+	return retry.NewProfiles(newRetrier(cfg, retryableFunc, m.Recorder("repo")), named)
+}
+
+func newRetrier(cfg config.Retry, retryableFunc retry.IsRetryableFunc, rec retry.MetricsRecorder) retry.Retrier {
 	opts := []retry.RetryOption{
 		retry.WithMaxAttempts(cfg.MaxAttempts),
+		retry.WithMetricsRecorder(rec),
 	}
 
 	if retryableFunc != nil {
@@ -30,6 +48,12 @@ func newRepoRetrier(cfg config.Retry, retryableFunc retry.IsRetryableFunc) retry
 }
 
 func isRetryableFunc(err error) bool {
+	// Serialization failures are routine under CockroachDB's SERIALIZABLE
+	// isolation; a statement outside a transaction can simply be rerun.
+	if errors.Is(err, repository.ErrSerialization) {
+		return true
+	}
+
 	unretryableErrors := []error{
 		repository.ErrDuplicate,
 		repository.ErrNotFound,
@@ -37,6 +61,9 @@ func isRetryableFunc(err error) bool {
 		repository.ErrForeignKeyViolation,
 		repository.ErrNotFound,
 		repository.ErrTxAborted,
+		models.ErrCurrencyMismatch,
+		models.ErrMoneyOverflow,
+		repository.ErrReadOnly,
 	}
 
 	for _, unretryableErr := range unretryableErrors {
@@ -0,0 +1,118 @@
+// Package audit records changes that matter for financial reports and
+// access control, separately from operational logs.
+package audit
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Actions of the writes of subscriptions, listed in activity feeds.
+const (
+	ActionCreated = "subscription.created"
+	ActionUpdated = "subscription.updated"
+	ActionDeleted = "subscription.deleted"
+)
+
+// Event is an audited action.
+type Event struct {
+	Action         string         // What happened, e.g. "price_change.rejected"
+	SubscriptionID int64          // Affected subscription, 0 if none
+	UserID         uuid.UUID      // User whose subscription is affected, uuid.Nil if unknown
+	Details        map[string]any // Action-specific data
+}
+
+// Recorder records audit events.
+type Recorder interface {
+	Record(ctx context.Context, ev Event)
+}
+
+// Discard drops events.
+var Discard Recorder = discard{}
+
+type discard struct{}
+
+func (discard) Record(context.Context, Event) {}
+
+// Log writes events to a logger named "audit", with the caller from the
+// request context as actor.
+type Log struct {
+	log *zap.Logger
+}
+
+// NewLog creates a Log writing to log.
+func NewLog(log *zap.Logger) *Log {
+	return &Log{log: log.Named("audit")}
+}
+
+// Record implements Recorder.
+func (l *Log) Record(ctx context.Context, ev Event) {
+	fields := []zap.Field{
+		zap.String("action", ev.Action),
+		zap.Int64("subscription_id", ev.SubscriptionID),
+		zap.Any("details", ev.Details),
+	}
+	if ev.UserID != uuid.Nil {
+		fields = append(fields, zap.Stringer("user_id", ev.UserID))
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		fields = append(fields, zap.Stringer("actor", p.UserID), zap.Bool("actor_admin", p.Admin))
+	}
+	l.log.Info("audit event", fields...)
+}
+
+// Tee records events to all of rs in order.
+func Tee(rs ...Recorder) Recorder {
+	return tee(rs)
+}
+
+type tee []Recorder
+
+func (t tee) Record(ctx context.Context, ev Event) {
+	for _, r := range t {
+		r.Record(ctx, ev)
+	}
+}
+
+// EntryWriter stores audit entries, e.g. repository.AuditRepo.
+type EntryWriter interface {
+	Insert(ctx context.Context, e *models.AuditEntry, opts ...repository.Option) error
+}
+
+// Store records events to w, with the caller from the request context as
+// actor, so they can be queried, e.g. for activity feeds. The audited
+// operation has already succeeded, so failures are only logged.
+type Store struct {
+	w   EntryWriter
+	log *zap.Logger
+}
+
+// NewStore creates a Store writing to w.
+func NewStore(w EntryWriter, log *zap.Logger) *Store {
+	return &Store{w: w, log: log}
+}
+
+// Record implements Recorder.
+func (s *Store) Record(ctx context.Context, ev Event) {
+	e := &models.AuditEntry{
+		Action:         ev.Action,
+		SubscriptionID: ev.SubscriptionID,
+		Details:        ev.Details,
+	}
+	if ev.UserID != uuid.Nil {
+		e.UserID = &ev.UserID
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		e.Actor = &p.UserID
+	}
+	if err := s.w.Insert(ctx, e); err != nil {
+		s.log.Error("failed to store audit event", zap.String("action", ev.Action),
+			zap.Int64("subscription_id", ev.SubscriptionID), zap.Error(err))
+	}
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLog_Record(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	actor := uuid.New()
+	ctx := auth.WithPrincipal(t.Context(), auth.Principal{UserID: actor})
+
+	NewLog(zap.New(core)).Record(ctx, Event{
+		Action:         "price_change.rejected",
+		SubscriptionID: 7,
+		Details:        map[string]any{"from": 100, "to": 10000},
+	})
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "audit", entry.LoggerName)
+	fields := entry.ContextMap()
+	assert.Equal(t, "price_change.rejected", fields["action"])
+	assert.Equal(t, int64(7), fields["subscription_id"])
+	assert.Equal(t, actor.String(), fields["actor"])
+}
+
+// entries stores audit entries, failing for actions in fail.
+type entries struct {
+	stored []models.AuditEntry
+	fail   string
+}
+
+func (w *entries) Insert(_ context.Context, e *models.AuditEntry, _ ...repository.Option) error {
+	if e.Action == w.fail {
+		return errors.New("connection refused")
+	}
+	w.stored = append(w.stored, *e)
+	return nil
+}
+
+func TestStore_Record(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	actor, userID := uuid.New(), uuid.New()
+	w := &entries{fail: ActionDeleted}
+	rec := Tee(Discard, NewStore(w, zap.New(core)))
+
+	rec.Record(auth.WithPrincipal(t.Context(), auth.Principal{UserID: actor}), Event{
+		Action:         ActionCreated,
+		SubscriptionID: 7,
+		UserID:         userID,
+		Details:        map[string]any{"service_name": "Netflix"},
+	})
+	rec.Record(t.Context(), Event{Action: "price_change.rejected", SubscriptionID: 7})
+	rec.Record(t.Context(), Event{Action: ActionDeleted, SubscriptionID: 7})
+
+	require.Len(t, w.stored, 2)
+	assert.Equal(t, models.AuditEntry{
+		Action:         ActionCreated,
+		UserID:         &userID,
+		SubscriptionID: 7,
+		Actor:          &actor,
+		Details:        map[string]any{"service_name": "Netflix"},
+	}, w.stored[0])
+	assert.Nil(t, w.stored[1].UserID)
+	assert.Nil(t, w.stored[1].Actor)
+	assert.Equal(t, 1, logs.FilterMessage("failed to store audit event").Len(), "failures are logged")
+}
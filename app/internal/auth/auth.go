@@ -0,0 +1,33 @@
+// Package auth carries the authenticated caller of a request through its
+// context. The service does not authenticate users itself: an upstream
+// gateway does and passes the caller in trusted headers.
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// RoleAdmin is the role allowed to access subscriptions of all users.
+const RoleAdmin = "admin"
+
+// Principal is the caller of a request.
+type Principal struct {
+	UserID uuid.UUID
+	Admin  bool
+}
+
+type ctxKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the caller carried by ctx. ok is false if callers are
+// not identified, i.e. auth is disabled.
+func FromContext(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
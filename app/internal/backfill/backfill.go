@@ -0,0 +1,397 @@
+// Package backfill runs data migrations too long for a schema migration,
+// such as rewriting every subscription, in batches while the service
+// serves requests. Each batch is committed together with the progress it
+// makes in the backfills table, so a backfill can be paused, survives
+// restarts and failures, and resumes after the last committed batch.
+//
+// Batches lock the backfill's progress row, so replicas that run the same
+// backfill take turns instead of processing a batch twice.
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/runtimeutil"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// BatchFunc processes up to size rows with IDs after afterID in tx. It
+// returns the ID of the last row of the batch and the number of its rows;
+// fewer than size means the backfill is done. Batches must be idempotent:
+// a batch interrupted before its commit is run again.
+type BatchFunc func(ctx context.Context, tx pgx.Tx, afterID int64, size int) (lastID int64, n int, err error)
+
+// Backfill is a data migration.
+type Backfill struct {
+	Name        string // Unique name, the key of its progress.
+	Description string // What it does, shown by the admin API.
+	Batch       BatchFunc
+}
+
+// Store defines repository methods required by Runner.
+type Store interface {
+	// Ensure creates the progress of a backfill unless it exists.
+	Ensure(ctx context.Context, name string, opts ...repository.Option) error
+	// Get retrieves the progress of a backfill.
+	Get(ctx context.Context, name string, opts ...repository.Option) (*models.Backfill, error)
+	// List returns the progress of all backfills.
+	List(ctx context.Context, opts ...repository.Option) ([]models.Backfill, error)
+	// Update saves the progress of a backfill.
+	Update(ctx context.Context, b *models.Backfill, opts ...repository.Option) error
+}
+
+// Transactor runs functions in a transaction.
+type Transactor interface {
+	Do(ctx context.Context, fn repository.TxFunc, opts ...repository.Option) error
+}
+
+var (
+	// ErrUnknown is returned for names of backfills not registered.
+	ErrUnknown = errors.New("unknown backfill")
+	// ErrDone is returned when starting a finished backfill.
+	ErrDone = errors.New("backfill is done")
+	// ErrNotRunning is returned when pausing a backfill that is not running.
+	ErrNotRunning = errors.New("backfill is not running")
+)
+
+// Defaults of the Runner options.
+const (
+	DefaultBatchSize = 1000
+	DefaultPause     = 100 * time.Millisecond
+)
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithBatchSize sets the number of rows per batch.
+func WithBatchSize(n int) Option {
+	return func(r *Runner) {
+		r.batchSize = n
+	}
+}
+
+// WithPause sets the delay between batches, which leaves the database
+// time for requests.
+func WithPause(d time.Duration) Option {
+	return func(r *Runner) {
+		r.pause = d
+	}
+}
+
+// Runner starts, pauses and runs backfills. It is a lifecycle component:
+// Start resumes the backfills that were running when the service stopped,
+// Stop interrupts the batches in progress, which are rolled back and run
+// again on the next start.
+type Runner struct {
+	backfills map[string]Backfill
+	names     []string
+	store     Store
+	tx        Transactor
+	log       *zap.Logger
+	batchSize int
+	pause     time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running map[string]*job
+}
+
+// job is a backfill running in the background.
+type job struct {
+	done  <-chan struct{}
+	again bool // Run again once stopped.
+}
+
+// NewRunner creates a Runner of backfills storing their progress in store.
+// Batches run in transactions of tx.
+func NewRunner(store Store, tx Transactor, log *zap.Logger, backfills []Backfill, opts ...Option) *Runner {
+	r := &Runner{
+		backfills: make(map[string]Backfill, len(backfills)),
+		store:     store,
+		tx:        tx,
+		log:       log,
+		batchSize: DefaultBatchSize,
+		pause:     DefaultPause,
+		now:       time.Now,
+		running:   make(map[string]*job),
+	}
+	for _, b := range backfills {
+		r.backfills[b.Name] = b
+		r.names = append(r.names, b.Name)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start creates the progress of new backfills and resumes the running ones.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.mu.Unlock()
+
+	for _, name := range r.names {
+		if err := r.store.Ensure(ctx, name); err != nil {
+			return fmt.Errorf("backfill %s: %w", name, err)
+		}
+		p, err := r.store.Get(ctx, name)
+		if err != nil {
+			return fmt.Errorf("backfill %s: %w", name, err)
+		}
+		if p.Status == models.BackfillRunning {
+			r.log.Info("resuming backfill", zap.String("backfill", name), zap.Int64("last_id", p.LastID))
+			r.launch(name)
+		}
+	}
+	return nil
+}
+
+// Stop interrupts the running backfills, waiting for them until ctx is done.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	running := make([]<-chan struct{}, 0, len(r.running))
+	for _, j := range r.running {
+		running = append(running, j.done)
+	}
+	r.mu.Unlock()
+
+	for _, done := range running {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// List returns the progress of the registered backfills in registration
+// order.
+func (r *Runner) List(ctx context.Context) ([]models.Backfill, error) {
+	stored, err := r.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.Backfill, len(stored))
+	for _, p := range stored {
+		byName[p.Name] = p
+	}
+
+	list := make([]models.Backfill, 0, len(r.names))
+	for _, name := range r.names {
+		p, ok := byName[name]
+		if !ok {
+			p = models.Backfill{Name: name, Status: models.BackfillPending}
+		}
+		p.Description = r.backfills[name].Description
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// Run starts a backfill, or resumes a paused or failed one, in the
+// background and returns its progress.
+func (r *Runner) Run(ctx context.Context, name string) (*models.Backfill, error) {
+	p, err := r.transition(ctx, name, func(p *models.Backfill) error {
+		if p.Status == models.BackfillDone {
+			return ErrDone
+		}
+		p.Status = models.BackfillRunning
+		p.Error = ""
+		if p.StartedAt == nil {
+			now := r.now()
+			p.StartedAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("backfill started", zap.String("backfill", name), zap.Int64("last_id", p.LastID))
+	r.launch(name)
+	return p, nil
+}
+
+// Pause pauses a running backfill after the batch in progress and returns
+// its progress. It takes effect on all replicas.
+func (r *Runner) Pause(ctx context.Context, name string) (*models.Backfill, error) {
+	p, err := r.transition(ctx, name, func(p *models.Backfill) error {
+		if p.Status != models.BackfillRunning {
+			return ErrNotRunning
+		}
+		p.Status = models.BackfillPaused
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("backfill paused", zap.String("backfill", name), zap.Int64("last_id", p.LastID))
+	return p, nil
+}
+
+// transition changes the progress of a registered backfill with fn.
+func (r *Runner) transition(ctx context.Context, name string, fn func(*models.Backfill) error) (*models.Backfill, error) {
+	b, ok := r.backfills[name]
+	if !ok {
+		return nil, ErrUnknown
+	}
+
+	var p *models.Backfill
+	err := r.tx.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := r.store.Ensure(ctx, name, repository.WithTx(tx)); err != nil {
+			return err
+		}
+		var err error
+		if p, err = r.store.Get(ctx, name, repository.WithTx(tx), repository.WithLock(repository.ForUpdate)); err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+		return r.store.Update(ctx, p, repository.WithTx(tx))
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.Description = b.Description
+	return p, nil
+}
+
+// launch runs a backfill in the background. If it already runs here, it
+// is run again once the run in progress stops: Run may have been called
+// after its last batch found the backfill paused or failed.
+func (r *Runner) launch(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j, ok := r.running[name]; ok {
+		j.again = true
+		return
+	}
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return
+	}
+
+	b, j := r.backfills[name], &job{}
+	r.running[name] = j
+	j.done = runtimeutil.Go(r.ctx, r.log, func(ctx context.Context) {
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, name)
+			r.mu.Unlock()
+		}()
+		for again := true; again && ctx.Err() == nil; {
+			r.run(ctx, b)
+
+			r.mu.Lock()
+			again, j.again = j.again, false
+			r.mu.Unlock()
+		}
+	}, runtimeutil.WithName("backfill "+name))
+}
+
+// run runs batches until the backfill is done, paused or fails.
+func (r *Runner) run(ctx context.Context, b Backfill) {
+	log := r.log.With(zap.String("backfill", b.Name))
+	for {
+		p, err := r.batch(ctx, b)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("backfill failed", zap.Error(err))
+			r.fail(ctx, b.Name, err)
+			return
+		}
+		if p.Status != models.BackfillRunning {
+			log.Info("backfill stopped", zap.String("status", string(p.Status)),
+				zap.Int64("processed", p.Processed), zap.Int64("last_id", p.LastID))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pause):
+		}
+	}
+}
+
+// batch runs the next batch of a running backfill and returns the progress
+// it committed, or the progress found if the backfill is no longer running.
+func (r *Runner) batch(ctx context.Context, b Backfill) (*models.Backfill, error) {
+	var p *models.Backfill
+	err := r.tx.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		if p, err = r.store.Get(ctx, b.Name, repository.WithTx(tx), repository.WithLock(repository.ForUpdate)); err != nil {
+			return err
+		}
+		if p.Status != models.BackfillRunning {
+			return nil
+		}
+
+		lastID, n, err := b.Batch(ctx, tx, p.LastID, r.batchSize)
+		if err != nil {
+			return fmt.Errorf("batch after id %d: %w", p.LastID, err)
+		}
+		if n > 0 {
+			p.LastID = lastID
+			p.Processed += int64(n)
+		}
+		if n < r.batchSize {
+			now := r.now()
+			p.Status = models.BackfillDone
+			p.FinishedAt = &now
+		}
+		return r.store.Update(ctx, p, repository.WithTx(tx))
+	})
+	return p, err
+}
+
+// fail records the error of a failed batch.
+func (r *Runner) fail(ctx context.Context, name string, batchErr error) {
+	_, err := r.transition(ctx, name, func(p *models.Backfill) error {
+		if p.Status != models.BackfillRunning {
+			return nil
+		}
+		p.Status = models.BackfillFailed
+		p.Error = batchErr.Error()
+		return nil
+	})
+	if err != nil {
+		r.log.Error("failed to record backfill failure", zap.String("backfill", name), zap.Error(err))
+	}
+}
+
+// DateNormalizer runs the batches of the normalize_dates backfill, see
+// repository.BackfillRepo.
+type DateNormalizer interface {
+	NormalizeDates(ctx context.Context, afterID int64, size int, opts ...repository.Option) (lastID int64, n int, err error)
+}
+
+// NormalizeDates moves subscription start and end dates to the first day
+// of their month.
+func NormalizeDates(d DateNormalizer) Backfill {
+	return Backfill{
+		Name:        "normalize_dates",
+		Description: "Moves subscription start and end dates to the first day of their month.",
+		Batch: func(ctx context.Context, tx pgx.Tx, afterID int64, size int) (int64, int, error) {
+			return d.NormalizeDates(ctx, afterID, size, repository.WithTx(tx))
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu       sync.Mutex
+	progress map[string]models.Backfill
+}
+
+func newMemStore() *memStore {
+	return &memStore{progress: make(map[string]models.Backfill)}
+}
+
+func (s *memStore) Ensure(_ context.Context, name string, _ ...repository.Option) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.progress[name]; !ok {
+		s.progress[name] = models.Backfill{Name: name, Status: models.BackfillPending}
+	}
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, name string, _ ...repository.Option) (*models.Backfill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.progress[name]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (s *memStore) List(context.Context, ...repository.Option) ([]models.Backfill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.Backfill
+	for _, p := range s.progress {
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+func (s *memStore) Update(_ context.Context, b *models.Backfill, _ ...repository.Option) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.UpdatedAt = time.Now()
+	s.progress[b.Name] = *b
+	return nil
+}
+
+// serialTx runs one function at a time, as the row lock of a batch does.
+type serialTx struct{ mu sync.Mutex }
+
+func (t *serialTx) Do(ctx context.Context, fn repository.TxFunc, _ ...repository.Option) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(ctx, nil)
+}
+
+// rows is a table of IDs a backfill goes over, failing at failAt.
+type rows struct {
+	mu     sync.Mutex
+	ids    []int64
+	seen   []int64
+	failAt int64
+}
+
+func (r *rows) backfill() Backfill {
+	return Backfill{
+		Name:        "touch",
+		Description: "Touches rows.",
+		Batch: func(_ context.Context, _ pgx.Tx, afterID int64, size int) (int64, int, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			var batch []int64
+			for _, id := range r.ids {
+				if id > afterID && len(batch) < size {
+					if id == r.failAt {
+						return 0, 0, errors.New("boom")
+					}
+					batch = append(batch, id)
+				}
+			}
+			r.seen = append(r.seen, batch...)
+			if len(batch) == 0 {
+				return 0, 0, nil
+			}
+			return batch[len(batch)-1], len(batch), nil
+		},
+	}
+}
+
+func waitStatus(t *testing.T, r *Runner, status models.BackfillStatus) models.Backfill {
+	t.Helper()
+	var p models.Backfill
+	require.Eventually(t, func() bool {
+		list, err := r.List(t.Context())
+		require.NoError(t, err)
+		p = list[0]
+		return p.Status == status
+	}, time.Second, time.Millisecond)
+	return p
+}
+
+func TestRunner(t *testing.T) {
+	data := &rows{ids: []int64{1, 2, 3, 5, 8, 13, 21}}
+	r := NewRunner(newMemStore(), &serialTx{}, zap.NewNop(), []Backfill{data.backfill()},
+		WithBatchSize(3), WithPause(0))
+	require.NoError(t, r.Start(t.Context()))
+	t.Cleanup(func() { require.NoError(t, r.Stop(t.Context())) })
+
+	list, err := r.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, models.BackfillPending, list[0].Status)
+	assert.Equal(t, "Touches rows.", list[0].Description)
+
+	started, err := r.Run(t.Context(), "touch")
+	require.NoError(t, err)
+	assert.NotNil(t, started.StartedAt)
+
+	p := waitStatus(t, r, models.BackfillDone)
+	assert.EqualValues(t, 7, p.Processed)
+	assert.EqualValues(t, 21, p.LastID)
+	assert.NotNil(t, p.FinishedAt)
+	assert.Equal(t, data.ids, data.seen, "each row once, in ID order")
+
+	_, err = r.Run(t.Context(), "touch")
+	assert.ErrorIs(t, err, ErrDone)
+	_, err = r.Run(t.Context(), "missing")
+	assert.ErrorIs(t, err, ErrUnknown)
+}
+
+func TestRunner_PauseAndResume(t *testing.T) {
+	data := &rows{ids: []int64{1, 2, 3, 4, 5, 6}}
+	store := newMemStore()
+	// The first batch is followed by a long pause, during which the
+	// backfill is paused.
+	r := NewRunner(store, &serialTx{}, zap.NewNop(), []Backfill{data.backfill()},
+		WithBatchSize(2), WithPause(time.Hour))
+	require.NoError(t, r.Start(t.Context()))
+
+	_, err := r.Run(t.Context(), "touch")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		p, err := store.Get(t.Context(), "touch")
+		return err == nil && p.Processed == 2
+	}, time.Second, time.Millisecond)
+
+	p, err := r.Pause(t.Context(), "touch")
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillPaused, p.Status)
+	_, err = r.Pause(t.Context(), "touch")
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	// A restart does not resume paused backfills; Run does, after the last
+	// committed batch.
+	require.NoError(t, r.Stop(t.Context()))
+	r = NewRunner(store, &serialTx{}, zap.NewNop(), []Backfill{data.backfill()},
+		WithBatchSize(2), WithPause(0))
+	require.NoError(t, r.Start(t.Context()))
+	t.Cleanup(func() { require.NoError(t, r.Stop(t.Context())) })
+	assert.Equal(t, models.BackfillPaused, waitStatus(t, r, models.BackfillPaused).Status)
+
+	_, err = r.Run(t.Context(), "touch")
+	require.NoError(t, err)
+	p2 := waitStatus(t, r, models.BackfillDone)
+	assert.EqualValues(t, 6, p2.Processed)
+	assert.Equal(t, data.ids, data.seen)
+}
+
+func TestRunner_Failure(t *testing.T) {
+	data := &rows{ids: []int64{1, 2, 3, 4}, failAt: 3}
+	store := newMemStore()
+	r := NewRunner(store, &serialTx{}, zap.NewNop(), []Backfill{data.backfill()},
+		WithBatchSize(2), WithPause(0))
+	require.NoError(t, r.Start(t.Context()))
+	t.Cleanup(func() { require.NoError(t, r.Stop(t.Context())) })
+
+	_, err := r.Run(t.Context(), "touch")
+	require.NoError(t, err)
+	p := waitStatus(t, r, models.BackfillFailed)
+	assert.EqualValues(t, 2, p.LastID, "the failed batch is not committed")
+	assert.Contains(t, p.Error, "batch after id 2: boom")
+
+	data.mu.Lock()
+	data.failAt = 0
+	data.mu.Unlock()
+	_, err = r.Run(t.Context(), "touch")
+	require.NoError(t, err)
+	p = waitStatus(t, r, models.BackfillDone)
+	assert.Empty(t, p.Error)
+	assert.EqualValues(t, 4, p.Processed)
+}
+
+func TestRunner_ResumesRunning(t *testing.T) {
+	data := &rows{ids: []int64{1, 2, 3}}
+	store := newMemStore()
+	store.progress["touch"] = models.Backfill{Name: "touch", Status: models.BackfillRunning, LastID: 1, Processed: 1}
+
+	r := NewRunner(store, &serialTx{}, zap.NewNop(), []Backfill{data.backfill()}, WithPause(0))
+	require.NoError(t, r.Start(t.Context()))
+	t.Cleanup(func() { require.NoError(t, r.Stop(t.Context())) })
+
+	p := waitStatus(t, r, models.BackfillDone)
+	assert.EqualValues(t, 3, p.Processed)
+	assert.Equal(t, []int64{2, 3}, data.seen)
+}
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// sliceSource streams subs, failing after them with err if set.
+type sliceSource struct {
+	subs []models.Subscription
+	err  error
+}
+
+func (s sliceSource) Iterate(_ context.Context, _ models.SubscriptionFilter, fn func(models.Subscription) error, _ ...repository.Option) error {
+	for _, sub := range s.subs {
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func month(m time.Month, y int) models.MonthDate {
+	return models.MonthDate{Time: time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+var testSubs = func() []models.Subscription {
+	end := month(time.March, 2025)
+	return []models.Subscription{
+		{ID: 1, ServiceName: "Yandex Plus", Price: 400, Currency: "RUB", UserID: uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"), StartDate: month(time.July, 2024)},
+		{ID: 2, ServiceName: `Kion, "HD"`, Price: 0, Currency: "USD", UserID: uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"), StartDate: month(time.January, 2025), EndDate: &end, Trial: true},
+	}
+}()
+
+func newTestBackups(t *testing.T, src Source) *Backups {
+	t.Helper()
+
+	dir, err := NewDir(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dir.Close() })
+	return New(src, dir, zap.NewNop())
+}
+
+func readBackup(t *testing.T, b *Backups, name string) string {
+	t.Helper()
+
+	rc, _, err := b.Open(t.Context(), name)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBackups_Create(t *testing.T) {
+	b := newTestBackups(t, sliceSource{subs: testSubs})
+	b.now = func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC) }
+
+	t.Run("jsonl", func(t *testing.T) {
+		got, err := b.Create(t.Context(), FormatJSONL)
+		require.NoError(t, err)
+		assert.Equal(t, "subscriptions-20261016T120000Z.jsonl", got.Name)
+		assert.Equal(t, int64(2), got.Rows)
+
+		assert.Equal(t, `{"id":1,"service_name":"Yandex Plus","price":400,"currency":"RUB","user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2024","trial":false}
+{"id":2,"service_name":"Kion, \"HD\"","price":0,"currency":"USD","user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"01-2025","end_date":"03-2025","trial":true}
+`, readBackup(t, b, got.Name))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		got, err := b.Create(t.Context(), FormatCSV)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), got.Rows)
+		assert.Equal(t, int64(len(readBackup(t, b, got.Name))), got.Size)
+
+		assert.Equal(t, `id,service_name,price,user_id,start_date,end_date,trial,currency
+1,Yandex Plus,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2024,,false,RUB
+2,"Kion, ""HD""",0,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2025,03-2025,true,USD
+`, readBackup(t, b, got.Name))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := b.Create(t.Context(), "xml")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	objs, err := b.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+}
+
+func TestBackups_FailedDumpIsNotListed(t *testing.T) {
+	errQuery := errors.New("connection reset")
+	b := newTestBackups(t, sliceSource{subs: testSubs, err: errQuery})
+
+	_, err := b.Create(t.Context(), FormatJSONL)
+	require.ErrorIs(t, err, errQuery)
+
+	objs, err := b.List(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, objs)
+}
+
+func TestBackups_Open(t *testing.T) {
+	b := newTestBackups(t, sliceSource{})
+
+	_, _, err := b.Open(t.Context(), "subscriptions-20261016T120000Z.jsonl")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, _, err = b.Open(t.Context(), "../config.jsonl")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	_, _, err = b.Open(t.Context(), "config.yaml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestFormatOf(t *testing.T) {
+	f, err := FormatOf("subscriptions.csv")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+	assert.True(t, strings.HasPrefix(f.ContentType(), "text/csv"))
+
+	_, err = FormatOf("subscriptions")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tmpSuffix marks snapshots being written; they are not listed.
+const tmpSuffix = ".tmp"
+
+// Dir stores snapshots as files in a directory, e.g. a mounted volume or an
+// object storage bucket mounted with s3fs or gcsfuse.
+type Dir struct {
+	root *os.Root
+}
+
+// NewDir opens dir, creating it if needed.
+func NewDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup dir: %w", err)
+	}
+	return &Dir{root: root}, nil
+}
+
+// Put implements Storage. The snapshot is written to a temporary file that
+// is renamed once complete.
+func (d *Dir) Put(_ context.Context, name string, write func(io.Writer) error) (Object, error) {
+	if err := checkName(name); err != nil {
+		return Object{}, err
+	}
+	tmp := name + tmpSuffix
+	f, err := d.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return Object{}, err
+	}
+
+	err = write(f)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = d.root.Rename(tmp, name)
+	}
+	if err != nil {
+		_ = d.root.Remove(tmp)
+		return Object{}, err
+	}
+
+	info, err := d.root.Stat(name)
+	if err != nil {
+		return Object{}, err
+	}
+	return object(info), nil
+}
+
+// Open implements Storage.
+func (d *Dir) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	f, err := d.root.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// List implements Storage.
+func (d *Dir) List(_ context.Context) ([]Object, error) {
+	entries, err := fs.ReadDir(d.root.FS(), ".")
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]Object, 0, len(entries))
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasSuffix(e.Name(), tmpSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, object(info))
+	}
+	return objs, nil
+}
+
+// Close closes the directory.
+func (d *Dir) Close() error {
+	return d.root.Close()
+}
+
+func object(info fs.FileInfo) Object {
+	return Object{Name: info.Name(), Size: info.Size(), CreatedAt: info.ModTime().UTC()}
+}
+
+// checkName rejects names that are not plain file names.
+func checkName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
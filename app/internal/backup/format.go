@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+
+	"subscriptionsservice/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Format is a snapshot encoding.
+type Format string
+
+// Supported formats.
+const (
+	FormatJSONL Format = "jsonl" // One subscription JSON object per line
+	FormatCSV   Format = "csv"   // Header row followed by csvColumns
+)
+
+// ErrUnknownFormat is returned for formats other than jsonl and csv.
+var ErrUnknownFormat = errors.New("unknown backup format")
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// FormatOf returns the format of a snapshot by its name extension.
+func FormatOf(name string) (Format, error) {
+	f := Format(path.Ext(name))
+	if f != "" {
+		f = f[1:]
+	}
+	if _, ok := encoders[f]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, f)
+	}
+	return f, nil
+}
+
+// csvColumns is the CSV header. Dates use the API's "MM-YYYY" format.
+var csvColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "trial", "currency"}
+
+// legacyCSVColumns is the header of snapshots made before subscriptions had
+// a currency; their prices are in models.DefaultCurrency.
+var legacyCSVColumns = csvColumns[:7]
+
+type encoder interface {
+	Encode(models.Subscription) error
+	Flush() error
+}
+
+var encoders = map[Format]func(io.Writer) encoder{
+	FormatJSONL: newJSONLEncoder,
+	FormatCSV:   newCSVEncoder,
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) encoder {
+	bw := bufio.NewWriter(w)
+	return &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *jsonlEncoder) Encode(s models.Subscription) error { return e.enc.Encode(s) }
+
+func (e *jsonlEncoder) Flush() error { return e.w.Flush() }
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVEncoder(w io.Writer) encoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Encode(s models.Subscription) error {
+	if !e.header {
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+		e.header = true
+	}
+	endDate := ""
+	if s.EndDate != nil {
+		endDate = monthString(*s.EndDate)
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(s.ID, 10),
+		s.ServiceName,
+		strconv.Itoa(int(s.Price)),
+		s.UserID.String(),
+		monthString(s.StartDate),
+		endDate,
+		strconv.FormatBool(s.Trial),
+		string(s.Currency.OrDefault()),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	if !e.header {
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func monthString(m models.MonthDate) string {
+	return m.Format("01-2006")
+}
+
+type decoder interface {
+	// Decode returns the next subscription or io.EOF.
+	Decode() (models.Subscription, error)
+}
+
+var decoders = map[Format]func(io.Reader) decoder{
+	FormatJSONL: newJSONLDecoder,
+	FormatCSV:   newCSVDecoder,
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+func newJSONLDecoder(r io.Reader) decoder {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return &jsonlDecoder{dec: dec}
+}
+
+func (d *jsonlDecoder) Decode() (models.Subscription, error) {
+	var s models.Subscription
+	err := d.dec.Decode(&s)
+	return s, err
+}
+
+type csvDecoder struct {
+	r      *csv.Reader
+	header bool
+}
+
+func newCSVDecoder(r io.Reader) decoder {
+	cr := csv.NewReader(r)
+	// Records must have as many fields as the header.
+	cr.FieldsPerRecord = 0
+	cr.ReuseRecord = true
+	return &csvDecoder{r: cr}
+}
+
+func (d *csvDecoder) Decode() (models.Subscription, error) {
+	if !d.header {
+		rec, err := d.r.Read()
+		if err != nil {
+			return models.Subscription{}, err
+		}
+		if !slices.Equal(rec, csvColumns) && !slices.Equal(rec, legacyCSVColumns) {
+			return models.Subscription{}, fmt.Errorf("unexpected header %q, want %q", rec, csvColumns)
+		}
+		d.header = true
+	}
+
+	rec, err := d.r.Read()
+	if err != nil {
+		return models.Subscription{}, err
+	}
+
+	var (
+		s    models.Subscription
+		errs []error
+	)
+	check := func(column string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", column, err))
+		}
+	}
+
+	s.ID, err = strconv.ParseInt(rec[0], 10, 64)
+	check("id", err)
+	s.ServiceName = rec[1]
+	price, err := strconv.Atoi(rec[2])
+	check("price", err)
+	s.Price = models.Price(price)
+	s.UserID, err = uuid.Parse(rec[3])
+	check("user_id", err)
+	check("start_date", s.StartDate.UnmarshalParam(rec[4]))
+	if rec[5] != "" {
+		s.EndDate = &models.MonthDate{}
+		check("end_date", s.EndDate.UnmarshalParam(rec[5]))
+	}
+	s.Trial, err = strconv.ParseBool(rec[6])
+	check("trial", err)
+	s.Currency = models.DefaultCurrency
+	if len(rec) > 7 {
+		s.Currency, err = models.ParseCurrency(rec[7])
+		check("currency", err)
+	}
+	return s, errors.Join(errs...)
+}
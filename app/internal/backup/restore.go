@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// restoreBatchSize is the number of rows decoded and copied at once.
+const restoreBatchSize = 1000
+
+// Loader writes restored subscriptions.
+type Loader interface {
+	CopyFromSubscriptions(ctx context.Context, subs []models.Subscription, opts ...repository.Option) (int64, error)
+	DeleteAll(ctx context.Context, opts ...repository.Option) (int64, error)
+}
+
+// Transactor runs functions in a transaction.
+type Transactor interface {
+	Do(ctx context.Context, fn repository.TxFunc, opts ...repository.Option) error
+}
+
+// InvalidRowError reports a snapshot row that cannot be decoded or fails
+// validation. Row numbers start at 1 and do not count the CSV header.
+type InvalidRowError struct {
+	Row int
+	Err error
+}
+
+func (e *InvalidRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *InvalidRowError) Unwrap() error { return e.Err }
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// DryRun loads the snapshot and rolls the transaction back, so
+	// validation and constraint errors are reported without changes.
+	DryRun bool
+
+	// Replace deletes existing subscriptions before loading.
+	Replace bool
+}
+
+// RestoreResult describes a restore.
+type RestoreResult struct {
+	Rows    int64 `json:"rows"`    // Loaded rows
+	Deleted int64 `json:"deleted"` // Rows removed by Replace
+	DryRun  bool  `json:"dry_run"` // Changes were rolled back
+}
+
+var (
+	// errDryRun rolls back a dry run.
+	errDryRun = errors.New("dry run")
+
+	// errRerun fails a restore whose transaction is run again.
+	errRerun = errors.New("restore transaction cannot be rerun, the snapshot was already read")
+)
+
+// Restorer loads snapshots created by Backups.
+type Restorer struct {
+	loader Loader
+	tx     Transactor
+	log    *zap.Logger
+}
+
+// NewRestorer creates a Restorer writing to loader in transactions of tx.
+func NewRestorer(loader Loader, tx Transactor, log *zap.Logger) *Restorer {
+	return &Restorer{loader: loader, tx: tx, log: log}
+}
+
+// Restore loads a snapshot in format from r in one transaction, so a failed
+// restore leaves the data unchanged. Rows are validated and copied in
+// batches; subscription IDs are not restored, new ones are generated.
+func (rs *Restorer) Restore(ctx context.Context, r io.Reader, format Format, opts RestoreOptions) (*RestoreResult, error) {
+	newDecoder, ok := decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	dec := newDecoder(r)
+	res := &RestoreResult{DryRun: opts.DryRun}
+	started := false
+
+	err := rs.tx.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		// r cannot be read again if the transaction is rerun, e.g. on a
+		// serialization failure.
+		if started {
+			return errRerun
+		}
+		started = true
+
+		if opts.Replace {
+			n, err := rs.loader.DeleteAll(ctx, repository.WithTx(tx))
+			if err != nil {
+				return fmt.Errorf("delete subscriptions: %w", err)
+			}
+			res.Deleted = n
+		}
+
+		batch := make([]models.Subscription, 0, restoreBatchSize)
+		flush := func() error {
+			n, err := rs.loader.CopyFromSubscriptions(ctx, batch, repository.WithTx(tx))
+			res.Rows += n
+			batch = batch[:0]
+			return err
+		}
+
+		for row := 1; ; row++ {
+			s, err := dec.Decode()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err == nil {
+				err = models.Validate(&s)
+			}
+			if err != nil {
+				return &InvalidRowError{Row: row, Err: err}
+			}
+
+			batch = append(batch, s)
+			if len(batch) == restoreBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	rs.log.Info("snapshot restored", zap.String("format", string(format)),
+		zap.Int64("rows", res.Rows), zap.Int64("deleted", res.Deleted), zap.Bool("dry_run", res.DryRun))
+	return res, nil
+}
@@ -0,0 +1,176 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memLoader collects copied subscriptions.
+type memLoader struct {
+	subs    []models.Subscription
+	deleted int
+}
+
+func (l *memLoader) CopyFromSubscriptions(_ context.Context, subs []models.Subscription, _ ...repository.Option) (int64, error) {
+	l.subs = append(l.subs, subs...)
+	return int64(len(subs)), nil
+}
+
+func (l *memLoader) DeleteAll(context.Context, ...repository.Option) (int64, error) {
+	l.deleted++
+	return 3, nil
+}
+
+// fakeTx runs fn without a database and records whether it committed.
+type fakeTx struct {
+	runs      int
+	committed bool
+}
+
+func (f *fakeTx) Do(ctx context.Context, fn repository.TxFunc, _ ...repository.Option) error {
+	f.runs++
+	err := fn(ctx, nil)
+	f.committed = err == nil
+	return err
+}
+
+func TestRestorer_RoundTrip(t *testing.T) {
+	b := newTestBackups(t, sliceSource{subs: testSubs})
+
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			created, err := b.Create(t.Context(), format)
+			require.NoError(t, err)
+			rc, _, err := b.Open(t.Context(), created.Name)
+			require.NoError(t, err)
+			defer rc.Close()
+
+			loader, tx := &memLoader{}, &fakeTx{}
+			res, err := NewRestorer(loader, tx, zap.NewNop()).Restore(t.Context(), rc, format, RestoreOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, &RestoreResult{Rows: 2}, res)
+			assert.Equal(t, testSubs, loader.subs)
+			assert.True(t, tx.committed)
+		})
+	}
+}
+
+func TestRestorer_LegacyCSV(t *testing.T) {
+	const snapshot = `id,service_name,price,user_id,start_date,end_date,trial
+1,Yandex Plus,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2024,,false
+`
+	loader := &memLoader{}
+	_, err := NewRestorer(loader, &fakeTx{}, zap.NewNop()).Restore(t.Context(),
+		strings.NewReader(snapshot), FormatCSV, RestoreOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, loader.subs, 1)
+	assert.Equal(t, models.DefaultCurrency, loader.subs[0].Currency)
+}
+
+func TestRestorer_Options(t *testing.T) {
+	const snapshot = `{"service_name":"Yandex Plus","price":400,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2024"}` + "\n"
+
+	t.Run("dry run rolls back", func(t *testing.T) {
+		loader, tx := &memLoader{}, &fakeTx{}
+		res, err := NewRestorer(loader, tx, zap.NewNop()).Restore(t.Context(),
+			strings.NewReader(snapshot), FormatJSONL, RestoreOptions{DryRun: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, &RestoreResult{Rows: 1, DryRun: true}, res)
+		assert.False(t, tx.committed)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		loader, tx := &memLoader{}, &fakeTx{}
+		res, err := NewRestorer(loader, tx, zap.NewNop()).Restore(t.Context(),
+			strings.NewReader(snapshot), FormatJSONL, RestoreOptions{Replace: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, &RestoreResult{Rows: 1, Deleted: 3}, res)
+		assert.Equal(t, 1, loader.deleted)
+	})
+
+	t.Run("rerun", func(t *testing.T) {
+		tx := &fakeTx{}
+		rs := NewRestorer(&memLoader{}, rerunTx{tx}, zap.NewNop())
+		_, err := rs.Restore(t.Context(), strings.NewReader(snapshot), FormatJSONL, RestoreOptions{})
+		assert.ErrorIs(t, err, errRerun)
+	})
+}
+
+// rerunTx runs fn twice, as TxManager does after a serialization failure.
+type rerunTx struct{ *fakeTx }
+
+func (r rerunTx) Do(ctx context.Context, fn repository.TxFunc, opts ...repository.Option) error {
+	_ = r.fakeTx.Do(ctx, fn, opts...)
+	return r.fakeTx.Do(ctx, fn, opts...)
+}
+
+func TestRestorer_InvalidRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		snapshot string
+		wantRow  int
+	}{
+		{
+			name:   "failed validation",
+			format: FormatJSONL,
+			snapshot: `{"service_name":"Yandex Plus","price":400,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2024"}
+{"service_name":"","price":400,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2024"}
+`,
+			wantRow: 2,
+		},
+		{
+			name:     "unknown field",
+			format:   FormatJSONL,
+			snapshot: `{"name":"Yandex Plus"}`,
+			wantRow:  1,
+		},
+		{
+			name:     "bad csv header",
+			format:   FormatCSV,
+			snapshot: "id,name,price,user_id,start_date,end_date,trial\n",
+			wantRow:  1,
+		},
+		{
+			name:   "bad csv values",
+			format: FormatCSV,
+			snapshot: `id,service_name,price,user_id,start_date,end_date,trial
+1,Yandex Plus,free,60601fee-2bf1-4721-ae6f-7636e79a0cba,2024-07,,false
+`,
+			wantRow: 1,
+		},
+		{
+			name:   "bad csv currency",
+			format: FormatCSV,
+			snapshot: `id,service_name,price,user_id,start_date,end_date,trial,currency
+1,Yandex Plus,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2024,,false,RUB
+2,Kion,300,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2024,,false,rubles
+`,
+			wantRow: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{}
+			_, err := NewRestorer(&memLoader{}, tx, zap.NewNop()).Restore(t.Context(),
+				strings.NewReader(tt.snapshot), tt.format, RestoreOptions{})
+
+			var rowErr *InvalidRowError
+			require.ErrorAs(t, err, &rowErr)
+			assert.Equal(t, tt.wantRow, rowErr.Row)
+			assert.False(t, tx.committed)
+		})
+	}
+}
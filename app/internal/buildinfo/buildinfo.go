@@ -0,0 +1,52 @@
+// Package buildinfo exposes the version of the running binary.
+//
+// Version, Commit and Date are set at build time:
+//
+//	go build -ldflags "-X subscriptionsservice/internal/buildinfo.Version=v1.2.3 \
+//		-X subscriptionsservice/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X subscriptionsservice/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set by -ldflags at build time.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns build information. Commit and date fall back to the VCS
+// stamp embedded by the Go toolchain when not set via -ldflags.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+
+	return info
+}
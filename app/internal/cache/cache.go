@@ -0,0 +1,110 @@
+// Package cache provides a small in-memory cache with expiring entries for
+// read-mostly query results.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTL caches values for a fixed time. A nil *TTL is a disabled cache: it
+// stores nothing. It is safe for concurrent use.
+type TTL[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	items      map[K]entry[V]
+	now        func() time.Time
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// New creates a cache keeping values for ttl. When maxEntries is reached,
+// expired entries are dropped, and if none are, the whole cache is.
+// It returns nil (a disabled cache) if ttl is not positive.
+func New[K comparable, V any](ttl time.Duration, maxEntries int) *TTL[K, V] {
+	if ttl <= 0 {
+		return nil
+	}
+	return &TTL[K, V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      make(map[K]entry[V]),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value cached for key if it has not expired.
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set caches value for key.
+func (c *TTL[K, V]) Set(key K, value V) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.items[key]; !ok && c.maxEntries > 0 && len(c.items) >= c.maxEntries {
+		for k, e := range c.items {
+			if !now.Before(e.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		if len(c.items) >= c.maxEntries {
+			clear(c.items)
+		}
+	}
+	c.items[key] = entry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// Delete drops the value cached for key.
+func (c *TTL[K, V]) Delete(key K) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+// DeleteFunc drops the values of keys for which del returns true.
+func (c *TTL[K, V]) DeleteFunc(del func(K) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.items {
+		if del(k) {
+			delete(c.items, k)
+		}
+	}
+}
+
+// Clear drops all values, e.g. after a write that makes them stale.
+func (c *TTL[K, V]) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+}
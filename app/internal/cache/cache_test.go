@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string, int](time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "expired")
+
+	c.Set("b", 2)
+	c.Set("c", 3) // "a" expired and is dropped to make room
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	c.Set("d", 4) // full of live entries: everything is dropped
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("d")
+	assert.True(t, ok)
+
+	c.Set("e", 5)
+	c.Delete("e")
+	_, ok = c.Get("e")
+	assert.False(t, ok)
+	_, ok = c.Get("d")
+	assert.True(t, ok, "other values are kept")
+
+	c.Clear()
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+}
+
+func TestTTL_DeleteFunc(t *testing.T) {
+	c := New[string, int](time.Minute, 10)
+	c.Set("user:1", 1)
+	c.Set("user:2", 2)
+
+	c.DeleteFunc(func(k string) bool { return k == "user:1" })
+	_, ok := c.Get("user:1")
+	assert.False(t, ok)
+	_, ok = c.Get("user:2")
+	assert.True(t, ok)
+}
+
+func TestTTL_Disabled(t *testing.T) {
+	c := New[string, int](0, 10)
+	assert.Nil(t, c)
+
+	c.Set("a", 1)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	c.Clear()
+	c.DeleteFunc(func(string) bool { return true })
+}
@@ -1,46 +1,499 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"subscriptionsservice/internal/encryption"
+	"subscriptionsservice/internal/listen"
+	"subscriptionsservice/internal/memory"
+	"subscriptionsservice/internal/models"
+
 	"github.com/spf13/viper"
 )
 
 // Config holds application configuration.
 type Config struct {
-	App         App    `mapstructure:"app"`
-	Retry       Retry  `mapstructure:"retry"`
-	DatabaseURL string `mapstructure:"database_url"`
+	App   App   `mapstructure:"app" json:"app"`
+	Retry Retry `mapstructure:"retry" json:"retry"`
+	Hedge Hedge `mapstructure:"hedge" json:"hedge"`
+	// ResponseCache configures caching of list and summary responses.
+	ResponseCache ResponseCache `mapstructure:"response_cache" json:"response_cache"`
+	// AccessLog configures logging of served requests.
+	AccessLog AccessLog `mapstructure:"access_log" json:"access_log"`
+	// SLO holds the service level objectives reported by GET /status.
+	SLO SLO `mapstructure:"slo" json:"slo"`
+	// Workers configures the pool running background tasks.
+	Workers Workers `mapstructure:"workers" json:"workers"`
+	Limits  Limits  `mapstructure:"limits" json:"limits"`
+	Admin   Admin   `mapstructure:"admin" json:"admin"`
+	Auth    Auth    `mapstructure:"auth" json:"auth"`
+	Tenancy Tenancy `mapstructure:"tenancy" json:"tenancy"`
+	Users   Users   `mapstructure:"users" json:"users"`
+	Swagger Swagger `mapstructure:"swagger" json:"swagger"`
+
+	Remote  Remote  `mapstructure:"remote" json:"remote"`
+	Backups Backups `mapstructure:"backups" json:"backups"`
+	Faults  Faults  `mapstructure:"faults" json:"faults"`
+
+	// Backfill configures data migrations run in batches, see /admin/backfills.
+	Backfill Backfill `mapstructure:"backfill" json:"backfill"`
+
+	// SummarySubscriptions configures sending of scheduled summaries, see
+	// /summary-subscriptions.
+	SummarySubscriptions SummarySubscriptions `mapstructure:"summary_subscriptions" json:"summary_subscriptions"`
+
+	// Integrity configures the periodic integrity checks of subscriptions,
+	// see /admin/integrity.
+	Integrity Integrity `mapstructure:"integrity" json:"integrity"`
+
+	// BusinessMetrics configures the periodically refreshed product KPIs
+	// exported at /metrics.
+	BusinessMetrics BusinessMetrics `mapstructure:"business_metrics" json:"business_metrics"`
+
+	// Audit configures storing of audited writes for activity feeds, see
+	// /users/{user_id}/activity.
+	Audit Audit `mapstructure:"audit" json:"audit"`
+
+	// Encryption configures column-level encryption of sensitive fields.
+	Encryption Encryption `mapstructure:"encryption" json:"encryption"`
+
+	Notifications Notifications `mapstructure:"notifications" json:"notifications"`
+	DatabaseURL   string        `mapstructure:"database_url" json:"-"`
+	Database      Database      `mapstructure:"database" json:"database"`
+
+	// Routes holds per-route timeouts and concurrency limits.
+	Routes []RouteLimit `mapstructure:"routes" json:"routes"`
+
+	// Features holds feature flags by name; unknown flags are off.
+	Features map[string]bool `mapstructure:"features" json:"features"`
 }
 
 // App contains general application settings.
 type App struct {
-	Port         string `mapstructure:"port"`          // HTTP server port
-	MirgationDir string `mapstructure:"migration_dir"` // Directory for DB migrations
-	LogLevel     string `mapstructure:"log_level"`     // Log level (e.g., debug, info, error)
+	Env          string   `mapstructure:"env" json:"env"`                     // Environment, e.g. dev, stage, prod; selects the config override file
+	Port         string   `mapstructure:"port" json:"port"`                   // HTTP server port
+	Listen       []string `mapstructure:"listen" json:"listen"`               // Listener specs overriding port: host:port, tcp6://[::]:port, unix:///path, systemd; comma-separated in env
+	MirgationDir string   `mapstructure:"migration_dir" json:"migration_dir"` // Directory for DB migrations
+	LogLevel     string   `mapstructure:"log_level" json:"log_level"`         // Log level (e.g., debug, info, error)
+
+	// Region is the name of the region this instance runs in, e.g.
+	// eu-central. Writes are stamped with it as origin_region; empty — not
+	// stamped.
+	Region string `mapstructure:"region" json:"region"`
+
+	// LogUnredacted logs user IDs, emails and payment details as is instead
+	// of masking them. For debugging in dev environments; rejected with
+	// app.env prod.
+	LogUnredacted bool `mapstructure:"log_unredacted" json:"log_unredacted"`
+
+	DefaultPageSize int `mapstructure:"default_page_size" json:"default_page_size"` // List page size when limit is not set
+	MaxPageSize     int `mapstructure:"max_page_size" json:"max_page_size"`         // Largest accepted limit
+
+	// SummaryBoundaries is how the summary matches dates against months:
+	// calendar_month (default) or legacy.
+	SummaryBoundaries string `mapstructure:"summary_boundaries" json:"summary_boundaries"`
+
+	// DateFormat is how month dates are encoded in responses: MM-YYYY
+	// (default), YYYY-MM or RFC3339. Requests may override it with the
+	// Date-Format header.
+	DateFormat string `mapstructure:"date_format" json:"date_format"`
+
+	// LenientPrices accepts prices sent as numeric strings, e.g. "499",
+	// for partners that cannot send numbers.
+	LenientPrices bool `mapstructure:"lenient_prices" json:"lenient_prices"`
+
+	ServicesCacheTTL time.Duration `mapstructure:"services_cache_ttl" json:"services_cache_ttl"` // How long GET /subscriptions/services results are cached, 0 — no cache
+
+	// SummaryCacheTTL is how long summaries of a single user without other
+	// filters, as shown by dashboards, are cached; 0 — no cache.
+	SummaryCacheTTL time.Duration `mapstructure:"summary_cache_ttl" json:"summary_cache_ttl"`
+	// SummaryWarmupUsers is the number of most active users whose
+	// current-month summaries are computed into the cache at startup and
+	// again after writes drop them; 0 — no warm-up.
+	SummaryWarmupUsers int `mapstructure:"summary_warmup_users" json:"summary_warmup_users"`
+
+	// MemoryLimit is the soft memory limit of the Go runtime in GOMEMLIMIT
+	// syntax, e.g. 900MiB; empty — GOMEMLIMIT or none. Set it below the
+	// container limit, so the GC works harder instead of the process being
+	// killed.
+	MemoryLimit string `mapstructure:"memory_limit" json:"memory_limit"`
+	// GCPercent is GOGC: the heap growth in percent that triggers a
+	// collection; -1 — collect only at MemoryLimit, 0 — GOGC or 100.
+	GCPercent int `mapstructure:"gc_percent" json:"gc_percent"`
+	// MemStatsInterval is how often runtime memory statistics are logged;
+	// 0 — never.
+	MemStatsInterval time.Duration `mapstructure:"memstats_interval" json:"memstats_interval"`
+
+	// ShutdownTimeout limits waiting for in-flight requests on shutdown,
+	// e.g. on SIGTERM during a rolling deploy; requests still running
+	// then are cut off. Keep it below the termination grace period.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" json:"shutdown_timeout"`
+	// ShutdownDelay is how long the service keeps serving after /readyz
+	// starts failing on shutdown, so load balancers stop routing to it
+	// before the HTTP server stops accepting requests. Set it to at least
+	// the readiness probe period times its failure threshold; 0 — stop at
+	// once.
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay" json:"shutdown_delay"`
+
+	// ReadOnly starts the service in read-only mode: writes are rejected
+	// with 503. At runtime the mode is switched via PUT /admin/read-only.
+	ReadOnly bool `mapstructure:"read_only" json:"read_only"`
 }
 
 // Retry holds retry strategy configuration.
 type Retry struct {
-	Backoff     string        `mapstructure:"backoff"`      // Backoff type: fixed, linear, exponential
-	Base        time.Duration `mapstructure:"base"`         // Base duration for backoff
-	Factor      float64       `mapstructure:"factor"`       // Exponential factor
-	Max         time.Duration `mapstructure:"max"`          // Maximum wait duration
-	MaxAttempts int           `mapstructure:"max_attempts"` // Max retry attempts
-	Jitter      float64       `mapstructure:"jitter"`       // Random jitter fraction
+	Backoff     string        `mapstructure:"backoff" json:"backoff"`           // Backoff type: fixed, linear, exponential
+	Base        time.Duration `mapstructure:"base" json:"base"`                 // Base duration for backoff
+	Factor      float64       `mapstructure:"factor" json:"factor"`             // Exponential factor
+	Max         time.Duration `mapstructure:"max" json:"max"`                   // Maximum wait duration
+	MaxAttempts int           `mapstructure:"max_attempts" json:"max_attempts"` // Max retry attempts
+	Jitter      float64       `mapstructure:"jitter" json:"jitter"`             // Random jitter fraction
+
+	// Profiles overrides the settings above for a class of repository
+	// operations: "read" or "write".
+	Profiles map[string]RetryProfile `mapstructure:"profiles" json:"profiles"`
+}
+
+// RetryProfile overrides Retry settings; zero fields keep the base value.
+type RetryProfile struct {
+	Backoff     string        `mapstructure:"backoff" json:"backoff,omitempty"`
+	Base        time.Duration `mapstructure:"base" json:"base,omitempty"`
+	Factor      float64       `mapstructure:"factor" json:"factor,omitempty"`
+	Max         time.Duration `mapstructure:"max" json:"max,omitempty"`
+	MaxAttempts int           `mapstructure:"max_attempts" json:"max_attempts,omitempty"`
+	Jitter      float64       `mapstructure:"jitter" json:"jitter,omitempty"`
+}
+
+// RetryProfiles are the known retry profile names.
+var RetryProfiles = []string{"read", "write"}
+
+// Profile returns the base settings overridden by the named profile.
+func (r Retry) Profile(name string) Retry {
+	p := r.Profiles[name]
+	res := r
+	res.Profiles = nil
+	if p.Backoff != "" {
+		res.Backoff = p.Backoff
+	}
+	if p.Base != 0 {
+		res.Base = p.Base
+	}
+	if p.Factor != 0 {
+		res.Factor = p.Factor
+	}
+	if p.Max != 0 {
+		res.Max = p.Max
+	}
+	if p.MaxAttempts != 0 {
+		res.MaxAttempts = p.MaxAttempts
+	}
+	if p.Jitter != 0 {
+		res.Jitter = p.Jitter
+	}
+	return res
+}
+
+// Database configures the connection pool.
+type Database struct {
+	Dialect string `mapstructure:"dialect" json:"dialect"` // postgres (default) or cockroachdb
+
+	// QueryExecMode is one of cache_statement (default), cache_describe,
+	// describe_exec, exec, simple_protocol. Behind PgBouncer in transaction
+	// pooling mode use exec or simple_protocol.
+	QueryExecMode            string `mapstructure:"query_exec_mode" json:"query_exec_mode"`
+	StatementCacheCapacity   int    `mapstructure:"statement_cache_capacity" json:"statement_cache_capacity"`     // Prepared statements per connection, 0 — default
+	DescriptionCacheCapacity int    `mapstructure:"description_cache_capacity" json:"description_cache_capacity"` // Statement descriptions per connection, 0 — default
+
+	// TransactionPooling is set when database_url points to a transaction
+	// pooler such as PgBouncer: queries default to the exec mode, and
+	// migrations, which hold a session advisory lock, use MigrationURL.
+	TransactionPooling bool   `mapstructure:"transaction_pooling" json:"transaction_pooling"`
+	MigrationURL       string `mapstructure:"migration_url" json:"-"` // Direct connection for migrations, defaults to database_url
+
+	// LogQueries logs repository statements with redacted args at debug level.
+	LogQueries bool `mapstructure:"log_queries" json:"log_queries"`
+
+	// RequestTransactions runs every mutating subscriptions request in one
+	// transaction, committed only if the request succeeds.
+	RequestTransactions bool `mapstructure:"request_transactions" json:"request_transactions"`
+}
+
+// ExecMode returns the query exec mode to use: QueryExecMode if set,
+// otherwise exec with TransactionPooling or the pgx default.
+func (d Database) ExecMode() string {
+	if d.QueryExecMode == "" && d.TransactionPooling {
+		return "exec"
+	}
+	return d.QueryExecMode
+}
+
+// AccessLog configures the access log. Failed and slow requests are always
+// logged, others are sampled.
+type AccessLog struct {
+	SampleRate    float64       `mapstructure:"sample_rate" json:"sample_rate"`       // Fraction of other requests logged, 1 — all
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" json:"slow_threshold"` // Requests slower than this are always logged, 0 — none
+}
+
+// SLO configures the service level objectives GET /status compares the
+// requests served within the rolling window with.
+type SLO struct {
+	Window       time.Duration `mapstructure:"window" json:"window"`             // Rolling window of served requests
+	Availability float64       `mapstructure:"availability" json:"availability"` // Target share of requests without server errors, e.g. 0.999
+	LatencyP95   time.Duration `mapstructure:"latency_p95" json:"latency_p95"`   // Target 95th percentile latency, 0 — none
+	LatencyP99   time.Duration `mapstructure:"latency_p99" json:"latency_p99"`   // Target 99th percentile latency, 0 — none
+}
+
+// Hedge configures hedged reads of a subscription by ID.
+type Hedge struct {
+	Delay time.Duration `mapstructure:"delay" json:"delay"` // Delay before a second query, 0 — no hedging
+}
+
+// ResponseCache configures Cache-Control and ETag of GET /subscriptions/
+// and GET /subscriptions/summary, and the shared cache of their responses.
+type ResponseCache struct {
+	MaxAge time.Duration `mapstructure:"max_age" json:"max_age"` // How long clients may reuse a response, 0 — revalidate with ETag every time
+	TTL    time.Duration `mapstructure:"ttl" json:"ttl"`         // How long responses are cached in-process by normalized query, 0 — no shared cache
+}
+
+// Workers configures the shared worker pool for background tasks such as
+// webhooks, outbox delivery and reports.
+type Workers struct {
+	Size       int `mapstructure:"size" json:"size"`               // Tasks run at once
+	QueueDepth int `mapstructure:"queue_depth" json:"queue_depth"` // Tasks waiting for a worker; further ones are rejected
+}
+
+// Backfill configures the batches of data migrations.
+type Backfill struct {
+	BatchSize int           `mapstructure:"batch_size" json:"batch_size"` // Rows per batch, each in its own transaction
+	Pause     time.Duration `mapstructure:"pause" json:"pause"`           // Delay between batches, leaving the database to requests
+}
+
+// SummarySubscriptions configures the job sending scheduled summaries.
+type SummarySubscriptions struct {
+	Interval  time.Duration `mapstructure:"interval" json:"interval"`     // How often due summaries are sent, 0 — never
+	BatchSize int           `mapstructure:"batch_size" json:"batch_size"` // Summaries sent per run at most
+}
+
+// Integrity configures the job checking stored subscriptions for anomalies.
+type Integrity struct {
+	Interval time.Duration `mapstructure:"interval" json:"interval"` // How often the checks run, 0 — only via /admin/integrity
+	Samples  int           `mapstructure:"samples" json:"samples"`   // IDs of found subscriptions listed per check
+}
+
+// BusinessMetrics configures the job refreshing business gauges, e.g.
+// active subscriptions and monthly spend.
+type BusinessMetrics struct {
+	Interval    time.Duration `mapstructure:"interval" json:"interval"`         // How often the gauges are refreshed, 0 — not exported
+	TopServices int           `mapstructure:"top_services" json:"top_services"` // Services with own per-service counts, the rest are "other"
+}
+
+// Audit configures the audit of subscription writes. Without the store
+// writes are not audited and the activity feed is not served, sparing
+// updates and deletes the reads of the changed rows.
+type Audit struct {
+	Store bool `mapstructure:"store" json:"store"` // Store creations, updates and deletions in audit_events
+}
+
+// RouteLimit restricts a route, e.g. GET /subscriptions/summary.
+type RouteLimit struct {
+	Method      string        `mapstructure:"method" json:"method"`               // HTTP method
+	Path        string        `mapstructure:"path" json:"path"`                   // Route pattern, e.g. /subscriptions/:id
+	Timeout     time.Duration `mapstructure:"timeout" json:"timeout"`             // Request deadline, 0 — none
+	MaxInFlight int           `mapstructure:"max_in_flight" json:"max_in_flight"` // Max concurrent requests, 0 — unlimited
+}
+
+// Admin holds settings of the /admin endpoints.
+type Admin struct {
+	Token string `mapstructure:"token" json:"-"` // Bearer token; admin endpoints are disabled if empty
+}
+
+// Auth configures identification of API callers. Users are authenticated by
+// a gateway in front of the service, which passes the caller in headers.
+type Auth struct {
+	UserHeader  string `mapstructure:"user_header" json:"user_header"`   // Header with the caller's user ID, e.g. X-User-ID; ownership checks are disabled if empty
+	RolesHeader string `mapstructure:"roles_header" json:"roles_header"` // Header with comma-separated caller roles; "admin" may access all subscriptions
+}
+
+// Tenancy configures serving several tenants, e.g. internal teams, from one
+// database.
+type Tenancy struct {
+	// Mode is "" for a single tenant or "schema" for a database schema per
+	// tenant, registered via POST /admin/tenants.
+	Mode         string `mapstructure:"mode" json:"mode"`
+	Header       string `mapstructure:"header" json:"header"`                 // Header with the caller's tenant, set by the gateway
+	APIKeyHeader string `mapstructure:"api_key_header" json:"api_key_header"` // Header with a tenant API key, used instead of Header if present
+}
+
+// TenancySchema is the schema-per-tenant mode.
+const TenancySchema = "schema"
+
+// Encryption holds the AES-256 keys sensitive fields, e.g. webhook signing
+// keys, are encrypted with at rest. Without keys they are stored in plain
+// text.
+type Encryption struct {
+	Keys      []string `mapstructure:"keys" json:"-"`                // "<id>:<base64 32-byte key>"; comma-separated in env
+	ActiveKey string   `mapstructure:"active_key" json:"active_key"` // ID of the key new values are encrypted with
+}
+
+// Users configures the users subscriptions belong to, synced by an upstream
+// identity system via /users.
+type Users struct {
+	// RequireProvisioned rejects subscriptions of users not created via
+	// POST /users, catching mistyped user IDs. Otherwise unknown users are
+	// registered by their first subscription.
+	RequireProvisioned bool `mapstructure:"require_provisioned" json:"require_provisioned"`
+
+	// Accounts checks users against the accounts service on subscription
+	// creates and updates, without a users table to check against.
+	Accounts UserAccounts `mapstructure:"accounts" json:"accounts"`
+}
+
+// UserAccounts configures checking that users exist in the accounts service.
+type UserAccounts struct {
+	URL      string        `mapstructure:"url" json:"url"`             // User URL with {id}, e.g. https://accounts.internal/v1/users/{id}; empty disables the check
+	Token    string        `mapstructure:"token" json:"-"`             // Bearer token for the accounts service
+	CacheTTL time.Duration `mapstructure:"cache_ttl" json:"cache_ttl"` // How long existing users are cached, 0 — not cached
+	Timeout  time.Duration `mapstructure:"timeout" json:"timeout"`     // Timeout of a single request attempt
+}
+
+// Swagger configures the Swagger UI at /swagger.
+type Swagger struct {
+	OIDC SwaggerOIDC `mapstructure:"oidc" json:"oidc"`
+}
+
+// SwaggerOIDC lets the Swagger UI obtain access tokens from the OpenID
+// Connect provider with the authorization code flow and PKCE, so
+// authenticated endpoints can be tried out from /swagger. The provider must
+// allow the redirect URI <service>/swagger/oauth2-redirect.html for the client.
+type SwaggerOIDC struct {
+	AuthorizationURL string   `mapstructure:"authorization_url" json:"authorization_url"` // Provider's authorization endpoint; login from Swagger UI is disabled if empty
+	TokenURL         string   `mapstructure:"token_url" json:"token_url"`                 // Provider's token endpoint
+	ClientID         string   `mapstructure:"client_id" json:"client_id"`                 // Public client (without secret) registered for the Swagger UI
+	Scopes           []string `mapstructure:"scopes" json:"scopes"`                       // Requested scopes; comma-separated in env
+}
+
+// Backups configures /admin/backups.
+type Backups struct {
+	Dir string `mapstructure:"dir" json:"dir"` // Directory snapshots are written to, e.g. a mounted bucket; backups are disabled if empty
+}
+
+// Faults configures fault injection for testing retries. It must never be
+// enabled in production.
+type Faults struct {
+	Enabled      bool      `mapstructure:"enabled" json:"enabled"`             // Fault injection is off unless set
+	AllowHeaders bool      `mapstructure:"allow_headers" json:"allow_headers"` // Let requests set faults with Fault-* headers
+	HTTP         FaultSpec `mapstructure:"http" json:"http"`                   // Faults of every response
+	HTTPStatus   int       `mapstructure:"http_status" json:"http_status"`     // Status of failed responses, 0 — 503
+	DB           FaultSpec `mapstructure:"db" json:"db"`                       // Faults of every database call
+}
+
+// FaultSpec describes injected faults.
+type FaultSpec struct {
+	Latency   time.Duration `mapstructure:"latency" json:"latency"`       // Added delay
+	ErrorRate float64       `mapstructure:"error_rate" json:"error_rate"` // Share of failed calls, 0..1
+}
+
+// Notifications selects and configures notification channels.
+type Notifications struct {
+	Channels []string     `mapstructure:"channels" json:"channels"` // Enabled channels: email, telegram, slack, webhook
+	Email    EmailChannel `mapstructure:"email" json:"email"`
+	Telegram Telegram     `mapstructure:"telegram" json:"telegram"`
+	Slack    Slack        `mapstructure:"slack" json:"slack"`
+	Webhook  Webhook      `mapstructure:"webhook" json:"webhook"`
+}
+
+// EmailChannel holds SMTP settings.
+type EmailChannel struct {
+	Host     string   `mapstructure:"host" json:"host"`
+	Port     int      `mapstructure:"port" json:"port"`
+	Username string   `mapstructure:"username" json:"username"`
+	Password string   `mapstructure:"password" json:"-"`
+	From     string   `mapstructure:"from" json:"from"`
+	To       []string `mapstructure:"to" json:"to"`
+}
+
+// Telegram holds Telegram bot settings.
+type Telegram struct {
+	Token  string `mapstructure:"token" json:"-"`
+	ChatID string `mapstructure:"chat_id" json:"chat_id"`
+}
+
+// Slack holds Slack incoming webhook settings.
+type Slack struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"-"`
+}
+
+// Webhook holds settings of the webhook channel, whose requests are signed
+// with rotating keys published at /.well-known/webhook-keys.
+type Webhook struct {
+	URL            string        `mapstructure:"url" json:"-"`
+	KeyGracePeriod time.Duration `mapstructure:"key_grace_period" json:"key_grace_period"` // How long a rotated out key stays published
+}
+
+// Limits holds per-user usage limits. Zero disables a limit.
+type Limits struct {
+	MaxActivePerUser     int `mapstructure:"max_active_per_user" json:"max_active_per_user"`           // Max active subscriptions per user
+	WritesPerUserPerHour int `mapstructure:"writes_per_user_per_hour" json:"writes_per_user_per_hour"` // Max creates and updates per user per hour
+
+	MaxPriceChangePercent float64 `mapstructure:"max_price_change_percent" json:"max_price_change_percent"` // Largest price change per update without allow_price_change
 }
 
 // Load reads configuration from file or environment variables.
 // Config file is optional; environment variables override file values.
+//
+// If app.env (APP_ENV) is set, the override file for the environment next to
+// the config file, e.g. config.prod.yaml for config.yaml, is merged over it.
+// The override file must exist.
+//
+// If remote.provider is set, the YAML document at remote.path in the store is
+// merged over the files. Environment variables still take precedence.
 func Load(configFilePath string) (*Config, error) {
 	v := viper.New()
 
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.BindEnv("app.env")
 	v.BindEnv("database_url")
 	v.BindEnv("app.migration_dir")
+	v.BindEnv("app.listen")
+	v.BindEnv("app.read_only")
+	v.BindEnv("app.log_unredacted")
+	v.BindEnv("app.region")
+	v.BindEnv("database.dialect")
+	v.BindEnv("database.query_exec_mode")
+	v.BindEnv("database.transaction_pooling")
+	v.BindEnv("database.log_queries")
+	v.BindEnv("database.request_transactions")
+	v.BindEnv("database.migration_url")
+	v.BindEnv("admin.token")
+	v.BindEnv("tenancy.mode")
+	v.BindEnv("users.require_provisioned")
+	v.BindEnv("users.accounts.url")
+	v.BindEnv("users.accounts.token")
+	v.BindEnv("encryption.keys")
+	v.BindEnv("encryption.active_key")
+	v.BindEnv("remote.provider")
+	v.BindEnv("remote.endpoint")
+	v.BindEnv("remote.path")
+	v.BindEnv("backups.dir")
+	v.BindEnv("swagger.oidc.authorization_url")
+	v.BindEnv("swagger.oidc.token_url")
+	v.BindEnv("swagger.oidc.client_id")
+	v.BindEnv("faults.enabled")
+	v.BindEnv("faults.allow_headers")
+	v.BindEnv("notifications.email.password")
+	v.BindEnv("notifications.telegram.token")
+	v.BindEnv("notifications.slack.webhook_url")
+	v.BindEnv("notifications.webhook.url")
 
 	if configFilePath != "" {
 		v.SetConfigFile(configFilePath)
@@ -49,8 +502,77 @@ func Load(configFilePath string) (*Config, error) {
 		}
 	}
 
+	if env := v.GetString("app.env"); env != "" {
+		if configFilePath == "" {
+			return nil, fmt.Errorf("app.env %q is set without a config file", env)
+		}
+		path, err := envFilePath(configFilePath, env)
+		if err != nil {
+			return nil, err
+		}
+		v.SetConfigFile(path)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to merge config file for env %q: %w", env, err)
+		}
+	}
+
+	// UnmarshalKey would miss settings given only by environment variables.
+	remote := Remote{
+		Provider: v.GetString("remote.provider"),
+		Endpoint: v.GetString("remote.endpoint"),
+		Path:     v.GetString("remote.path"),
+	}
+	if remote.Provider != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteReadTimeout)
+		data, err := remote.read(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		v.SetConfigType("yaml")
+		if err := v.MergeConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to merge remote config: %w", err)
+		}
+	}
+
 	v.SetDefault("app.port", "8080")
 	v.SetDefault("app.shutdown_timeout", "5s")
+	v.SetDefault("app.shutdown_delay", "0s")
+	v.SetDefault("app.default_page_size", 10)
+	v.SetDefault("app.max_page_size", 100)
+	v.SetDefault("app.summary_boundaries", "calendar_month")
+	v.SetDefault("app.services_cache_ttl", "30s")
+	v.SetDefault("app.memstats_interval", "1m")
+	v.SetDefault("app.date_format", string(models.DateFormatMonthYear))
+	v.SetDefault("database.dialect", "postgres")
+	v.SetDefault("remote.watch_timeout", "5m")
+	v.SetDefault("access_log.sample_rate", 1.0)
+	v.SetDefault("tenancy.header", "X-Tenant-ID")
+	v.SetDefault("tenancy.api_key_header", "X-API-Key")
+	v.SetDefault("swagger.oidc.scopes", []string{"openid"})
+	v.SetDefault("users.accounts.cache_ttl", "5m")
+	v.SetDefault("users.accounts.timeout", "2s")
+	v.SetDefault("access_log.slow_threshold", "1s")
+	v.SetDefault("slo.window", "1h")
+	v.SetDefault("slo.availability", 0.999)
+	v.SetDefault("slo.latency_p95", "300ms")
+	v.SetDefault("slo.latency_p99", "1s")
+	v.SetDefault("workers.size", 4)
+	v.SetDefault("workers.queue_depth", 100)
+	v.SetDefault("backfill.batch_size", 1000)
+	v.SetDefault("backfill.pause", "100ms")
+	v.SetDefault("summary_subscriptions.interval", "15m")
+	v.SetDefault("summary_subscriptions.batch_size", 100)
+	v.SetDefault("integrity.interval", "1h")
+	v.SetDefault("integrity.samples", 10)
+	v.SetDefault("business_metrics.interval", "1m")
+	v.SetDefault("business_metrics.top_services", 20)
+	v.SetDefault("audit.store", false)
+	v.SetDefault("remote.retry_delay", "10s")
+	v.SetDefault("notifications.email.port", 587)
+	v.SetDefault("notifications.webhook.key_grace_period", "72h")
+	v.SetDefault("limits.max_active_per_user", 0)
+	v.SetDefault("limits.writes_per_user_per_hour", 0)
 	v.SetDefault("retry.max_attempts", 3)
 	v.SetDefault("retry.backoff", "fixed")
 	v.SetDefault("retry.jitter", 0.0)
@@ -62,3 +584,277 @@ func Load(configFilePath string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// envFilePath returns the override file of env for the base config file:
+// the base name with env inserted before the extension.
+func envFilePath(configFilePath, env string) (string, error) {
+	if env == "" || strings.ContainsAny(env, `/\`) {
+		return "", fmt.Errorf("invalid app.env %q", env)
+	}
+	ext := filepath.Ext(configFilePath)
+	return strings.TrimSuffix(configFilePath, ext) + "." + env + ext, nil
+}
+
+// FeatureFlags returns the state of configured feature flags and of features
+// enabled by other settings.
+func (c *Config) FeatureFlags() map[string]bool {
+	flags := make(map[string]bool, len(c.Features)+18)
+	for name, on := range c.Features {
+		flags[name] = on
+	}
+	flags["active_subscription_limit"] = c.Limits.MaxActivePerUser > 0
+	flags["write_quota"] = c.Limits.WritesPerUserPerHour > 0
+	flags["price_change_guard"] = c.Limits.MaxPriceChangePercent > 0
+	flags["hedged_reads"] = c.Hedge.Delay > 0
+	flags["transaction_pooling"] = c.Database.TransactionPooling
+	flags["request_transactions"] = c.Database.RequestTransactions
+	flags["backups"] = c.Backups.Dir != ""
+	flags["fault_injection"] = c.Faults.Enabled
+	flags["ownership_checks"] = c.Auth.UserHeader != ""
+	flags["remote_config"] = c.Remote.Provider != ""
+	flags["lenient_prices"] = c.App.LenientPrices
+	flags["tenant_schemas"] = c.Tenancy.Mode == TenancySchema
+	flags["provisioned_users"] = c.Users.RequireProvisioned
+	flags["user_check"] = c.Users.Accounts.URL != ""
+	flags["summary_cache"] = c.App.SummaryCacheTTL > 0
+	flags["summary_warmup"] = c.App.SummaryWarmupUsers > 0
+	flags["response_cache"] = c.ResponseCache.TTL > 0
+	return flags
+}
+
+// MigrationDatabaseURL returns the database URL for migrations:
+// database.migration_url if set, otherwise database_url.
+func (c *Config) MigrationDatabaseURL() string {
+	if c.Database.MigrationURL != "" {
+		return c.Database.MigrationURL
+	}
+	return c.DatabaseURL
+}
+
+// ListenAddrs returns the listener specs: app.listen if set, otherwise
+// ":" + app.port, which accepts both IPv4 and IPv6 connections.
+func (c *Config) ListenAddrs() []string {
+	if len(c.App.Listen) > 0 {
+		return c.App.Listen
+	}
+	return []string{":" + c.App.Port}
+}
+
+// regionPattern matches region names, e.g. eu-central or us-east-1.
+var regionPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Validate reports incoherent settings.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.App.Port == "" && len(c.App.Listen) == 0 {
+		errs = append(errs, errors.New("app.port and app.listen are empty"))
+	}
+	for _, spec := range c.App.Listen {
+		if err := listen.Check(spec); err != nil {
+			errs = append(errs, fmt.Errorf("app.listen: %w", err))
+		}
+	}
+	if c.App.LogUnredacted && c.App.Env == "prod" {
+		errs = append(errs, errors.New("app.log_unredacted must not be set with app.env prod"))
+	}
+	if c.App.DefaultPageSize < 1 {
+		errs = append(errs, errors.New("app.default_page_size must be positive"))
+	}
+	if c.App.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("app.shutdown_timeout must be positive"))
+	}
+	if c.App.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("app.shutdown_delay must not be negative"))
+	}
+	if c.App.MaxPageSize < c.App.DefaultPageSize {
+		errs = append(errs, errors.New("app.max_page_size must not be less than app.default_page_size"))
+	}
+	if r := c.App.Region; r != "" && !regionPattern.MatchString(r) {
+		errs = append(errs, fmt.Errorf("app.region %q must be lowercase letters, digits and dashes, at most 63 characters", r))
+	}
+	if c.App.MemoryLimit != "" {
+		if _, err := memory.ParseLimit(c.App.MemoryLimit); err != nil {
+			errs = append(errs, fmt.Errorf("app.memory_limit: %w", err))
+		}
+	}
+	if c.App.GCPercent < -1 {
+		errs = append(errs, errors.New("app.gc_percent must be -1 (off), 0 (default) or positive"))
+	}
+	if c.App.GCPercent == -1 && c.App.MemoryLimit == "" {
+		errs = append(errs, errors.New("app.gc_percent -1 needs app.memory_limit, otherwise the heap grows without collections"))
+	}
+	if c.App.MemStatsInterval < 0 {
+		errs = append(errs, errors.New("app.memstats_interval must not be negative"))
+	}
+	if c.App.SummaryCacheTTL < 0 || c.App.SummaryWarmupUsers < 0 {
+		errs = append(errs, errors.New("app.summary_cache_ttl and app.summary_warmup_users must not be negative"))
+	}
+	if c.App.SummaryWarmupUsers > 0 {
+		if c.App.SummaryCacheTTL <= 0 {
+			errs = append(errs, errors.New("app.summary_warmup_users needs app.summary_cache_ttl to keep warmed summaries"))
+		}
+		if c.Tenancy.Mode == TenancySchema {
+			errs = append(errs, errors.New("app.summary_warmup_users is not supported with tenancy.mode schema: summaries are warmed in the default schema"))
+		}
+	}
+	errs = append(errs, validateRetry("retry", c.Retry)...)
+	for name := range c.Retry.Profiles {
+		if !slices.Contains(RetryProfiles, name) {
+			errs = append(errs, fmt.Errorf("retry.profiles: unknown profile %q, known: %s", name, strings.Join(RetryProfiles, ", ")))
+			continue
+		}
+		errs = append(errs, validateRetry("retry.profiles."+name, c.Retry.Profile(name))...)
+	}
+	for _, r := range c.Routes {
+		if r.Method == "" || r.Path == "" || r.Timeout < 0 || r.MaxInFlight < 0 {
+			errs = append(errs, fmt.Errorf("routes: invalid limit for %q %q", r.Method, r.Path))
+		}
+	}
+	switch c.App.SummaryBoundaries {
+	case "calendar_month", "legacy":
+	default:
+		errs = append(errs, fmt.Errorf("app.summary_boundaries %q is not one of calendar_month, legacy", c.App.SummaryBoundaries))
+	}
+	if _, err := models.ParseDateFormat(c.App.DateFormat); err != nil {
+		errs = append(errs, fmt.Errorf("app.date_format: %w", err))
+	}
+	switch c.Database.Dialect {
+	case "postgres", "cockroachdb":
+	default:
+		errs = append(errs, fmt.Errorf("database.dialect %q is not one of postgres, cockroachdb", c.Database.Dialect))
+	}
+	switch c.Database.QueryExecMode {
+	case "", "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+	default:
+		errs = append(errs, fmt.Errorf("database.query_exec_mode %q is not one of cache_statement, cache_describe, describe_exec, exec, simple_protocol", c.Database.QueryExecMode))
+	}
+	if c.Database.TransactionPooling {
+		switch c.Database.QueryExecMode {
+		case "cache_statement", "cache_describe":
+			errs = append(errs, fmt.Errorf("database.query_exec_mode %q keeps prepared statements, which breaks with database.transaction_pooling", c.Database.QueryExecMode))
+		}
+	}
+	if c.Database.StatementCacheCapacity < 0 || c.Database.DescriptionCacheCapacity < 0 {
+		errs = append(errs, errors.New("database cache capacities must not be negative"))
+	}
+	if c.Hedge.Delay < 0 {
+		errs = append(errs, errors.New("hedge.delay must not be negative"))
+	}
+	if c.ResponseCache.MaxAge < 0 || c.ResponseCache.TTL < 0 {
+		errs = append(errs, errors.New("response_cache.max_age and response_cache.ttl must not be negative"))
+	}
+	if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 || c.AccessLog.SlowThreshold < 0 {
+		errs = append(errs, errors.New("access_log.sample_rate must be between 0 and 1 and access_log.slow_threshold must not be negative"))
+	}
+	if c.SLO.Window <= 0 || c.SLO.Availability <= 0 || c.SLO.Availability >= 1 || c.SLO.LatencyP95 < 0 || c.SLO.LatencyP99 < 0 {
+		errs = append(errs, errors.New("slo.window must be positive, slo.availability between 0 and 1 exclusive and slo latencies must not be negative"))
+	}
+	if c.Workers.Size < 1 || c.Workers.QueueDepth < 0 {
+		errs = append(errs, errors.New("workers.size must be at least 1 and workers.queue_depth must not be negative"))
+	}
+	if c.Backfill.BatchSize < 1 || c.Backfill.Pause < 0 {
+		errs = append(errs, errors.New("backfill.batch_size must be at least 1 and backfill.pause must not be negative"))
+	}
+	if c.SummarySubscriptions.Interval < 0 || c.SummarySubscriptions.BatchSize < 1 {
+		errs = append(errs, errors.New("summary_subscriptions.interval must not be negative and summary_subscriptions.batch_size must be at least 1"))
+	}
+	if c.Integrity.Interval < 0 || c.Integrity.Samples < 0 {
+		errs = append(errs, errors.New("integrity.interval and integrity.samples must not be negative"))
+	}
+	if c.BusinessMetrics.Interval < 0 || c.BusinessMetrics.TopServices < 0 {
+		errs = append(errs, errors.New("business_metrics.interval and business_metrics.top_services must not be negative"))
+	}
+	if c.Remote.Provider != "" {
+		if !slices.Contains(RemoteProviders, c.Remote.Provider) {
+			errs = append(errs, fmt.Errorf("remote.provider %q is not one of %s", c.Remote.Provider, strings.Join(RemoteProviders, ", ")))
+		}
+		if c.Remote.Endpoint == "" || c.Remote.Path == "" {
+			errs = append(errs, errors.New("remote.endpoint and remote.path are required with remote.provider"))
+		}
+		if c.Remote.WatchTimeout < 0 || c.Remote.RetryDelay <= 0 {
+			errs = append(errs, errors.New("remote.watch_timeout must not be negative and remote.retry_delay must be positive"))
+		}
+	}
+	errs = append(errs, validateFaultSpec("faults.http", c.Faults.HTTP)...)
+	errs = append(errs, validateFaultSpec("faults.db", c.Faults.DB)...)
+	if s := c.Faults.HTTPStatus; s != 0 && (s < 400 || s > 599) {
+		errs = append(errs, fmt.Errorf("faults.http_status %d is not an error status", s))
+	}
+	if c.Limits.MaxActivePerUser < 0 || c.Limits.WritesPerUserPerHour < 0 || c.Limits.MaxPriceChangePercent < 0 {
+		errs = append(errs, errors.New("limits must not be negative"))
+	}
+	switch c.Tenancy.Mode {
+	case "":
+	case TenancySchema:
+		if c.Tenancy.Header == "" || c.Tenancy.APIKeyHeader == "" {
+			errs = append(errs, errors.New("tenancy.header and tenancy.api_key_header are required with tenancy.mode schema"))
+		}
+		if c.Admin.Token == "" {
+			errs = append(errs, errors.New("tenancy.mode schema needs admin.token: tenants are registered via /admin/tenants"))
+		}
+		if c.Database.TransactionPooling {
+			errs = append(errs, errors.New("tenancy.mode schema sets search_path per connection, which breaks with database.transaction_pooling"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tenancy.mode %q is not one of schema or empty", c.Tenancy.Mode))
+	}
+	if c.Users.RequireProvisioned && c.Admin.Token == "" {
+		errs = append(errs, errors.New("users.require_provisioned needs admin.token: users are created via /users"))
+	}
+	if a := c.Users.Accounts; a.URL != "" {
+		if u, err := url.Parse(a.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(a.URL, "{id}") {
+			errs = append(errs, fmt.Errorf("users.accounts.url %q must be an http(s) URL with {id}", a.URL))
+		}
+		if a.CacheTTL < 0 || a.Timeout <= 0 {
+			errs = append(errs, errors.New("users.accounts.cache_ttl must not be negative and users.accounts.timeout must be positive"))
+		}
+	}
+	if len(c.Encryption.Keys) > 0 || c.Encryption.ActiveKey != "" {
+		if _, err := encryption.New(c.Encryption.Keys, c.Encryption.ActiveKey); err != nil {
+			errs = append(errs, fmt.Errorf("encryption: %w", err))
+		}
+	}
+	if slices.Contains(c.Notifications.Channels, "webhook") && c.Notifications.Webhook.KeyGracePeriod <= 0 {
+		errs = append(errs, errors.New("notifications.webhook.key_grace_period must be positive"))
+	}
+	if oidc := c.Swagger.OIDC; oidc.AuthorizationURL != "" {
+		for _, f := range []struct{ key, url string }{
+			{"authorization_url", oidc.AuthorizationURL},
+			{"token_url", oidc.TokenURL},
+		} {
+			if u, err := url.Parse(f.url); err != nil || !u.IsAbs() {
+				errs = append(errs, fmt.Errorf("swagger.oidc.%s %q is not an absolute URL", f.key, f.url))
+			}
+		}
+		if oidc.ClientID == "" {
+			errs = append(errs, errors.New("swagger.oidc.client_id is required with swagger.oidc.authorization_url"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateRetry reports invalid retry settings under the config key prefix.
+func validateRetry(prefix string, r Retry) []error {
+	var errs []error
+	switch r.Backoff {
+	case "fixed", "linear", "exponential":
+	default:
+		errs = append(errs, fmt.Errorf("%s.backoff %q is not one of fixed, linear, exponential", prefix, r.Backoff))
+	}
+	if r.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s.max_attempts must be at least 1", prefix))
+	}
+	return errs
+}
+
+// validateFaultSpec reports invalid fault settings under the config key prefix.
+func validateFaultSpec(prefix string, f FaultSpec) []error {
+	var errs []error
+	if f.Latency < 0 {
+		errs = append(errs, fmt.Errorf("%s.latency must not be negative", prefix))
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		errs = append(errs, fmt.Errorf("%s.error_rate must be within 0..1", prefix))
+	}
+	return errs
+}
@@ -0,0 +1,423 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_EnvOverride(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", `
+app:
+  port: 8080
+  log_level: debug
+limits:
+  max_active_per_user: 100
+`)
+	writeFile(t, dir, "config.prod.yaml", `
+app:
+  log_level: info
+`)
+
+	t.Run("base only", func(t *testing.T) {
+		cfg, err := Load(base)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.App.Env)
+		assert.Equal(t, "debug", cfg.App.LogLevel)
+	})
+
+	t.Run("override merged", func(t *testing.T) {
+		t.Setenv("APP_ENV", "prod")
+		cfg, err := Load(base)
+		require.NoError(t, err)
+		assert.Equal(t, "prod", cfg.App.Env)
+		assert.Equal(t, "info", cfg.App.LogLevel)
+		assert.Equal(t, "8080", cfg.App.Port)
+		assert.Equal(t, 100, cfg.Limits.MaxActivePerUser)
+	})
+
+	t.Run("env vars win over override", func(t *testing.T) {
+		t.Setenv("APP_ENV", "prod")
+		t.Setenv("APP_LOG_LEVEL", "warn")
+		cfg, err := Load(base)
+		require.NoError(t, err)
+		assert.Equal(t, "warn", cfg.App.LogLevel)
+	})
+
+	t.Run("missing override", func(t *testing.T) {
+		t.Setenv("APP_ENV", "stage")
+		_, err := Load(base)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid env", func(t *testing.T) {
+		t.Setenv("APP_ENV", "../prod")
+		_, err := Load(base)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoad_Listen(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("list in file", func(t *testing.T) {
+		cfg, err := Load(writeFile(t, dir, "list.yaml", `
+app:
+  listen:
+    - 0.0.0.0:8080
+    - tcp6://[::1]:8080
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0.0.0.0:8080", "tcp6://[::1]:8080"}, cfg.ListenAddrs())
+	})
+
+	t.Run("single spec", func(t *testing.T) {
+		cfg, err := Load(writeFile(t, dir, "single.yaml", `
+app:
+  listen: unix:///run/subs.sock
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"unix:///run/subs.sock"}, cfg.ListenAddrs())
+	})
+
+	t.Run("comma-separated env", func(t *testing.T) {
+		t.Setenv("APP_LISTEN", "127.0.0.1:8080,[::1]:8080")
+		cfg, err := Load(writeFile(t, dir, "port.yaml", "app:\n  port: 8080\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1:8080", "[::1]:8080"}, cfg.ListenAddrs())
+	})
+
+	t.Run("port", func(t *testing.T) {
+		cfg, err := Load(writeFile(t, dir, "port.yaml", "app:\n  port: 8080\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{":8080"}, cfg.ListenAddrs())
+	})
+
+	t.Run("IPv6 host without brackets", func(t *testing.T) {
+		cfg, err := Load(writeFile(t, dir, "invalid.yaml", "app:\n  listen: '::1:8080'\n"))
+		require.NoError(t, err)
+		assert.ErrorContains(t, cfg.Validate(), "app.listen: invalid address")
+	})
+}
+
+func TestLoad_Tenancy(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	t.Setenv("TENANCY_MODE", "schema")
+	t.Setenv("ADMIN_TOKEN", "secret")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Tenancy{Mode: TenancySchema, Header: "X-Tenant-ID", APIKeyHeader: "X-API-Key"}, cfg.Tenancy)
+	assert.True(t, cfg.FeatureFlags()["tenant_schemas"])
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Database.TransactionPooling = true
+	assert.ErrorContains(t, cfg.Validate(), "database.transaction_pooling")
+
+	cfg.Admin.Token = ""
+	assert.ErrorContains(t, cfg.Validate(), "admin.token")
+
+	cfg.Tenancy.Mode = "table"
+	assert.ErrorContains(t, cfg.Validate(), "tenancy.mode")
+}
+
+func TestLoad_Users(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	t.Setenv("USERS_REQUIRE_PROVISIONED", "true")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Users.RequireProvisioned)
+	assert.True(t, cfg.FeatureFlags()["provisioned_users"])
+	assert.ErrorContains(t, cfg.Validate(), "admin.token")
+
+	cfg.Admin.Token = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	t.Run("accounts", func(t *testing.T) {
+		t.Setenv("USERS_ACCOUNTS_URL", "https://accounts.internal/v1/users/{id}")
+		t.Setenv("USERS_ACCOUNTS_TOKEN", "token")
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, UserAccounts{
+			URL:      "https://accounts.internal/v1/users/{id}",
+			Token:    "token",
+			CacheTTL: 5 * time.Minute,
+			Timeout:  2 * time.Second,
+		}, cfg.Users.Accounts)
+		assert.True(t, cfg.FeatureFlags()["user_check"])
+		cfg.Admin.Token = "secret"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Users.Accounts.URL = "https://accounts.internal/v1/users"
+		assert.ErrorContains(t, cfg.Validate(), "users.accounts.url")
+	})
+}
+
+func TestLoad_SwaggerOIDC(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Swagger.OIDC.AuthorizationURL)
+		assert.Equal(t, []string{"openid"}, cfg.Swagger.OIDC.Scopes)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("SWAGGER_OIDC_AUTHORIZATION_URL", "https://id.example.com/auth")
+		t.Setenv("SWAGGER_OIDC_TOKEN_URL", "https://id.example.com/token")
+		t.Setenv("SWAGGER_OIDC_CLIENT_ID", "swagger-ui")
+		t.Setenv("SWAGGER_OIDC_SCOPES", "openid,profile")
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, SwaggerOIDC{
+			AuthorizationURL: "https://id.example.com/auth",
+			TokenURL:         "https://id.example.com/token",
+			ClientID:         "swagger-ui",
+			Scopes:           []string{"openid", "profile"},
+		}, cfg.Swagger.OIDC)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		t.Setenv("SWAGGER_OIDC_AUTHORIZATION_URL", "https://id.example.com/auth")
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		err = cfg.Validate()
+		assert.ErrorContains(t, err, "swagger.oidc.token_url")
+		assert.ErrorContains(t, err, "swagger.oidc.client_id")
+	})
+}
+
+func TestLoad_Webhook(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nnotifications:\n  channels: [webhook]\n")
+
+	t.Setenv("NOTIFICATIONS_WEBHOOK_URL", "https://hooks.example.com/alerts")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Webhook{URL: "https://hooks.example.com/alerts", KeyGracePeriod: 72 * time.Hour}, cfg.Notifications.Webhook)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Webhook.KeyGracePeriod = 0
+	assert.ErrorContains(t, cfg.Validate(), "notifications.webhook.key_grace_period")
+}
+
+func TestLoad_Encryption(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+	key := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	t.Setenv("ENCRYPTION_KEYS", "2025-01:"+key+",2025-07:"+key)
+	t.Setenv("ENCRYPTION_ACTIVE_KEY", "2025-07")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-01:" + key, "2025-07:" + key}, cfg.Encryption.Keys)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Encryption.ActiveKey = "2026-01"
+	assert.ErrorContains(t, cfg.Validate(), "encryption: active key")
+}
+
+func TestLoad_LogUnredacted(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	t.Setenv("APP_LOG_UNREDACTED", "true")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.App.LogUnredacted)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.App.Env = "prod"
+	assert.ErrorContains(t, cfg.Validate(), "app.log_unredacted")
+}
+
+func TestLoad_SummaryWarmup(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n  summary_cache_ttl: 10m\n  summary_warmup_users: 100\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, cfg.App.SummaryCacheTTL)
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.FeatureFlags()["summary_warmup"])
+
+	cfg.App.SummaryCacheTTL = 0
+	assert.ErrorContains(t, cfg.Validate(), "app.summary_warmup_users needs app.summary_cache_ttl")
+}
+
+func TestLoad_ResponseCache(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nresponse_cache:\n  max_age: 30s\n  ttl: 1m\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, ResponseCache{MaxAge: 30 * time.Second, TTL: time.Minute}, cfg.ResponseCache)
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.FeatureFlags()["response_cache"])
+
+	cfg.ResponseCache.TTL = -time.Second
+	assert.ErrorContains(t, cfg.Validate(), "response_cache.max_age and response_cache.ttl must not be negative")
+}
+
+func TestLoad_Backfill(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Backfill{BatchSize: 1000, Pause: 100 * time.Millisecond}, cfg.Backfill)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Backfill.BatchSize = 0
+	assert.ErrorContains(t, cfg.Validate(), "backfill.batch_size must be at least 1")
+}
+
+func TestLoad_SummarySubscriptions(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nsummary_subscriptions:\n  interval: 1h\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, SummarySubscriptions{Interval: time.Hour, BatchSize: 100}, cfg.SummarySubscriptions)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SummarySubscriptions.BatchSize = 0
+	assert.ErrorContains(t, cfg.Validate(), "summary_subscriptions.batch_size must be at least 1")
+}
+
+func TestLoad_ShutdownTimeout(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.App.ShutdownTimeout)
+
+	t.Setenv("APP_SHUTDOWN_TIMEOUT", "25s")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 25*time.Second, cfg.App.ShutdownTimeout)
+
+	cfg.App.ShutdownTimeout = 0
+	assert.ErrorContains(t, cfg.Validate(), "app.shutdown_timeout must be positive")
+}
+
+func TestLoad_ShutdownDelay(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Zero(t, cfg.App.ShutdownDelay)
+
+	t.Setenv("APP_SHUTDOWN_DELAY", "10s")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.App.ShutdownDelay)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.App.ShutdownDelay = -time.Second
+	assert.ErrorContains(t, cfg.Validate(), "app.shutdown_delay must not be negative")
+}
+
+func TestLoad_Integrity(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nintegrity:\n  interval: 6h\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Integrity{Interval: 6 * time.Hour, Samples: 10}, cfg.Integrity)
+
+	cfg.Integrity.Samples = -1
+	assert.ErrorContains(t, cfg.Validate(), "integrity.interval and integrity.samples must not be negative")
+}
+
+func TestLoad_BusinessMetrics(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nbusiness_metrics:\n  top_services: 5\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, BusinessMetrics{Interval: time.Minute, TopServices: 5}, cfg.BusinessMetrics)
+
+	cfg.BusinessMetrics.Interval = -time.Second
+	assert.ErrorContains(t, cfg.Validate(), "business_metrics.interval and business_metrics.top_services must not be negative")
+}
+
+func TestLoad_Audit(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.False(t, cfg.Audit.Store, "off by default")
+
+	t.Setenv("AUDIT_STORE", "true")
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Audit.Store)
+}
+
+func TestLoad_SLO(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\nslo:\n  availability: 0.99\n  latency_p99: 2s\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, SLO{Window: time.Hour, Availability: 0.99, LatencyP95: 300 * time.Millisecond, LatencyP99: 2 * time.Second}, cfg.SLO)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SLO.Availability = 1
+	assert.ErrorContains(t, cfg.Validate(), "slo.availability between 0 and 1 exclusive")
+}
+
+func TestLoad_Region(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n")
+
+	t.Setenv("APP_REGION", "eu-central")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "eu-central", cfg.App.Region)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.App.Region = "EU Central"
+	assert.ErrorContains(t, cfg.Validate(), "app.region")
+}
+
+func TestLoad_Memory(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", "app:\n  port: 8080\n  memory_limit: 900MiB\n  gc_percent: 50\n")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "900MiB", cfg.App.MemoryLimit)
+	assert.Equal(t, 50, cfg.App.GCPercent)
+	assert.Equal(t, time.Minute, cfg.App.MemStatsInterval)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.App.MemoryLimit = "1GB"
+	assert.ErrorContains(t, cfg.Validate(), "app.memory_limit")
+
+	cfg.App.MemoryLimit, cfg.App.GCPercent = "", -1
+	assert.ErrorContains(t, cfg.Validate(), "app.gc_percent -1 needs app.memory_limit")
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Remote configures a key/value store holding a YAML document merged over
+// the config files, so tunables change across the fleet without a redeploy.
+// The store is talked to over its HTTP API: etcd v3 (gRPC gateway) or
+// Consul KV.
+type Remote struct {
+	Provider string `mapstructure:"provider" json:"provider"` // etcd or consul; remote config is off if empty
+	Endpoint string `mapstructure:"endpoint" json:"endpoint"` // Store URL, e.g. http://etcd:2379 or http://consul:8500
+	Path     string `mapstructure:"path" json:"path"`         // Key of the document, e.g. config/subscriptions
+
+	// WatchTimeout bounds a single watch request; it is renewed after that.
+	WatchTimeout time.Duration `mapstructure:"watch_timeout" json:"watch_timeout"`
+	// RetryDelay is the pause after a failed store request while watching.
+	RetryDelay time.Duration `mapstructure:"retry_delay" json:"retry_delay"`
+}
+
+// RemoteProviders are the supported remote.provider values.
+var RemoteProviders = []string{"etcd", "consul"}
+
+// ErrRemoteKeyNotFound is returned when remote.path is missing in the store.
+var ErrRemoteKeyNotFound = errors.New("remote config key not found")
+
+// remoteReadTimeout bounds reading the remote config in Load.
+const remoteReadTimeout = 10 * time.Second
+
+// kvStore reads and watches a single key.
+type kvStore interface {
+	// get returns the value of the key and its modification index.
+	get(ctx context.Context) ([]byte, uint64, error)
+	// wait blocks until the key is modified after index, ctx is done or the
+	// watch times out. It reports whether the key was modified.
+	wait(ctx context.Context, index uint64) (bool, error)
+}
+
+func (r Remote) store() (kvStore, error) {
+	endpoint := strings.TrimSuffix(r.Endpoint, "/")
+	client := &http.Client{}
+	switch r.Provider {
+	case "etcd":
+		return &etcdStore{client: client, endpoint: endpoint, key: r.Path, timeout: r.WatchTimeout}, nil
+	case "consul":
+		return &consulStore{client: client, endpoint: endpoint, key: strings.TrimPrefix(r.Path, "/"), timeout: r.WatchTimeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown remote.provider %q, known: %s", r.Provider, strings.Join(RemoteProviders, ", "))
+	}
+}
+
+// read returns the remote config document.
+func (r Remote) read(ctx context.Context) ([]byte, error) {
+	s, err := r.store()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := s.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote config %s %s: %w", r.Provider, r.Path, err)
+	}
+	return data, nil
+}
+
+// Watch calls onChange after each modification of the remote config until
+// ctx is canceled. Store errors are passed to onError and the watch is
+// retried after RetryDelay. onChange reloads the config itself, e.g. with Load.
+func (r Remote) Watch(ctx context.Context, onChange func(), onError func(error)) {
+	s, err := r.store()
+	if err != nil {
+		onError(err)
+		return
+	}
+
+	var index uint64
+	known := false
+	for ctx.Err() == nil {
+		if !known {
+			_, index, err = s.get(ctx)
+			if err != nil {
+				r.failed(ctx, onError, err)
+				continue
+			}
+			known = true
+		}
+
+		changed, err := s.wait(ctx, index)
+		if err != nil {
+			r.failed(ctx, onError, err)
+			known = false
+			continue
+		}
+		if changed {
+			// The next get picks up the new index, so a change made while
+			// onChange runs is not missed.
+			known = false
+			onChange()
+		}
+	}
+}
+
+func (r Remote) failed(ctx context.Context, onError func(error), err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	onError(fmt.Errorf("remote config %s %s: %w", r.Provider, r.Path, err))
+	t := time.NewTimer(r.RetryDelay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// consulStore uses the Consul KV API with blocking queries.
+type consulStore struct {
+	client   *http.Client
+	endpoint string
+	key      string
+	timeout  time.Duration
+}
+
+func (s *consulStore) get(ctx context.Context) ([]byte, uint64, error) {
+	return s.query(ctx, url.Values{"raw": {""}})
+}
+
+func (s *consulStore) wait(ctx context.Context, index uint64) (bool, error) {
+	q := url.Values{"index": {strconv.FormatUint(index, 10)}}
+	if s.timeout > 0 {
+		q.Set("wait", s.timeout.String())
+	}
+	_, next, err := s.query(ctx, q)
+	if err != nil {
+		return false, err
+	}
+	// A blocking query returns the same index when it times out.
+	return next != index, nil
+}
+
+func (s *consulStore) query(ctx context.Context, q url.Values) ([]byte, uint64, error) {
+	u := s.endpoint + "/v1/kv/" + s.key + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, 0, ErrRemoteKeyNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, 0, fmt.Errorf("consul responded %s", resp.Status)
+	}
+	index, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid X-Consul-Index: %w", err)
+	}
+	return body, index, nil
+}
+
+// etcdStore uses the etcd v3 JSON gateway. Keys and values are base64 in
+// JSON, which is how []byte is encoded.
+type etcdStore struct {
+	client   *http.Client
+	endpoint string
+	key      string
+	timeout  time.Duration
+}
+
+func (s *etcdStore) get(ctx context.Context) ([]byte, uint64, error) {
+	var resp struct {
+		KVs []struct {
+			Value       []byte `json:"value"`
+			ModRevision string `json:"mod_revision"`
+		} `json:"kvs"`
+	}
+	if err := s.post(ctx, "/v3/kv/range", map[string]any{"key": []byte(s.key)}, func(d *json.Decoder) error {
+		return d.Decode(&resp)
+	}); err != nil {
+		return nil, 0, err
+	}
+	if len(resp.KVs) == 0 {
+		return nil, 0, ErrRemoteKeyNotFound
+	}
+	rev, err := strconv.ParseUint(resp.KVs[0].ModRevision, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid mod_revision: %w", err)
+	}
+	return resp.KVs[0].Value, rev, nil
+}
+
+// wait reads the watch stream until the first event. The stream has no
+// timeout of its own, so it is ended after timeout.
+func (s *etcdStore) wait(ctx context.Context, index uint64) (bool, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	req := map[string]any{"create_request": map[string]any{
+		"key":            []byte(s.key),
+		"start_revision": strconv.FormatUint(index+1, 10),
+	}}
+	changed := false
+	err := s.post(ctx, "/v3/watch", req, func(d *json.Decoder) error {
+		for {
+			var msg struct {
+				Result struct {
+					Canceled     bool              `json:"canceled"`
+					CancelReason string            `json:"cancel_reason"`
+					Events       []json.RawMessage `json:"events"`
+				} `json:"result"`
+			}
+			if err := d.Decode(&msg); err != nil {
+				return err
+			}
+			if msg.Result.Canceled {
+				return fmt.Errorf("etcd canceled the watch: %s", msg.Result.CancelReason)
+			}
+			if len(msg.Result.Events) > 0 {
+				changed = true
+				return nil
+			}
+		}
+	})
+	if err != nil && ctx.Err() != nil {
+		return false, nil
+	}
+	return changed, err
+}
+
+func (s *etcdStore) post(ctx context.Context, path string, body any, decode func(*json.Decoder) error) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd responded %s", resp.Status)
+	}
+	return decode(json.NewDecoder(resp.Body))
+}
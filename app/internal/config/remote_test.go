@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKV is a single key store serving the Consul and etcd HTTP APIs.
+type fakeKV struct {
+	mu      sync.Mutex
+	value   string
+	index   uint64
+	changed chan struct{}
+}
+
+func newFakeKV(value string) *fakeKV {
+	return &fakeKV{value: value, index: 1, changed: make(chan struct{})}
+}
+
+func (kv *fakeKV) set(value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.value = value
+	kv.index++
+	close(kv.changed)
+	kv.changed = make(chan struct{})
+}
+
+func (kv *fakeKV) state() (string, uint64, chan struct{}) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.value, kv.index, kv.changed
+}
+
+func (kv *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/kv/config/subscriptions":
+		value, index, changed := kv.state()
+		if q := r.URL.Query().Get("index"); q == strconv.FormatUint(index, 10) {
+			select {
+			case <-changed:
+			case <-time.After(100 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+			value, index, _ = kv.state()
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+		fmt.Fprint(w, value)
+	case "/v3/kv/range":
+		value, index, _ := kv.state()
+		json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string]any{
+			{"value": []byte(value), "mod_revision": strconv.FormatUint(index, 10)},
+		}})
+	case "/v3/watch":
+		_, _, changed := kv.state()
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"created": true}})
+		w.(http.Flusher).Flush()
+		select {
+		case <-changed:
+			json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"events": []any{map[string]any{"type": "PUT"}}}})
+		case <-r.Context().Done():
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestLoad_Remote(t *testing.T) {
+	for _, provider := range RemoteProviders {
+		t.Run(provider, func(t *testing.T) {
+			srv := httptest.NewServer(newFakeKV("limits:\n  max_active_per_user: 5\n"))
+			defer srv.Close()
+
+			base := writeFile(t, t.TempDir(), "config.yaml", fmt.Sprintf(`
+app:
+  log_level: debug
+limits:
+  max_active_per_user: 100
+remote:
+  provider: %s
+  endpoint: %s
+  path: config/subscriptions
+`, provider, srv.URL))
+
+			t.Setenv("APP_LOG_LEVEL", "warn")
+			cfg, err := Load(base)
+			require.NoError(t, err)
+			assert.Equal(t, 5, cfg.Limits.MaxActivePerUser, "remote values win over files")
+			assert.Equal(t, "warn", cfg.App.LogLevel, "environment wins over remote values")
+			assert.NoError(t, cfg.Validate())
+		})
+	}
+}
+
+func TestLoad_RemoteKeyNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	t.Setenv("REMOTE_PROVIDER", "consul")
+	t.Setenv("REMOTE_ENDPOINT", srv.URL)
+	t.Setenv("REMOTE_PATH", "config/subscriptions")
+	_, err := Load("")
+	assert.ErrorIs(t, err, ErrRemoteKeyNotFound)
+}
+
+func TestRemote_Watch(t *testing.T) {
+	for _, provider := range RemoteProviders {
+		t.Run(provider, func(t *testing.T) {
+			kv := newFakeKV("app:\n  log_level: debug\n")
+			srv := httptest.NewServer(kv)
+			defer srv.Close()
+
+			remote := Remote{
+				Provider:     provider,
+				Endpoint:     srv.URL,
+				Path:         "config/subscriptions",
+				WatchTimeout: time.Second,
+				RetryDelay:   10 * time.Millisecond,
+			}
+			changes := make(chan struct{}, 10)
+			go remote.Watch(t.Context(), func() { changes <- struct{}{} }, func(err error) {
+				t.Errorf("unexpected watch error: %v", err)
+			})
+
+			// Let the watch start, so the change is not read as the initial state.
+			time.Sleep(50 * time.Millisecond)
+			kv.set("app:\n  log_level: info\n")
+
+			select {
+			case <-changes:
+			case <-time.After(5 * time.Second):
+				t.Fatal("change was not noticed")
+			}
+		})
+	}
+}
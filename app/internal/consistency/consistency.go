@@ -0,0 +1,58 @@
+// Package consistency carries the read consistency requested by a client
+// through the request context.
+//
+// All reads currently go to the primary database, so every read is strong.
+// Read replicas and caches must serve a request only if FromContext returns
+// Eventual, so a client can read its own writes by asking for Strong.
+package consistency
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Level is a read consistency level.
+type Level string
+
+const (
+	// Eventual allows reads from replicas and caches. It is the default.
+	Eventual Level = "eventual"
+	// Strong requires reads from the primary database.
+	Strong Level = "strong"
+)
+
+// ErrInvalidLevel is returned by Parse for unknown levels.
+var ErrInvalidLevel = errors.New("invalid consistency level")
+
+// Parse parses a level name case-insensitively. An empty string is Eventual.
+func Parse(s string) (Level, error) {
+	switch Level(strings.ToLower(strings.TrimSpace(s))) {
+	case "", Eventual:
+		return Eventual, nil
+	case Strong:
+		return Strong, nil
+	default:
+		return "", ErrInvalidLevel
+	}
+}
+
+type ctxKey struct{}
+
+// WithLevel returns a copy of ctx carrying the level.
+func WithLevel(ctx context.Context, l Level) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the level carried by ctx, Eventual if none.
+func FromContext(ctx context.Context) Level {
+	if l, ok := ctx.Value(ctxKey{}).(Level); ok {
+		return l
+	}
+	return Eventual
+}
+
+// IsStrong reports whether ctx requires strongly consistent reads.
+func IsStrong(ctx context.Context) bool {
+	return FromContext(ctx) == Strong
+}
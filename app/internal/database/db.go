@@ -3,17 +3,35 @@ package database
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	_ "github.com/golang-migrate/migrate/v4/database/cockroachdb"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 // Connect establishes a connection pool to the PostgreSQL database and verifies it with a ping.
-func Connect(ctx context.Context, dbURL string) (*pgxpool.Pool, error) {
-	db, err := pgxpool.New(ctx, dbURL)
+func Connect(ctx context.Context, dbURL string, opts ...PoolOption) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("database connection error: %w", err)
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, fmt.Errorf("database connection error: %w", err)
+		}
+	}
+
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("database connection error: %w", err)
 	}
@@ -23,6 +41,27 @@ func Connect(ctx context.Context, dbURL string) (*pgxpool.Pool, error) {
 	return db, nil
 }
 
+// Dialects supported by the service.
+const (
+	DialectPostgres    = "postgres"
+	DialectCockroachDB = "cockroachdb"
+)
+
+// MigrationURL returns dbURL with the scheme of the migrate driver for the
+// dialect. CockroachDB has no session advisory locks, so its driver locks
+// migrations with a table instead.
+func MigrationURL(dialect, dbURL string) string {
+	if dialect != DialectCockroachDB {
+		return dbURL
+	}
+	for _, scheme := range []string{"postgres://", "postgresql://"} {
+		if rest, ok := strings.CutPrefix(dbURL, scheme); ok {
+			return "cockroachdb://" + rest
+		}
+	}
+	return dbURL
+}
+
 // Migrate runs database migrations from the given directory.
 // Ignores ErrNoChange if there are no new migrations to apply.
 func Migrate(migrationDir string, dbURL string) error {
@@ -35,3 +74,94 @@ func Migrate(migrationDir string, dbURL string) error {
 	}
 	return nil
 }
+
+// MigrateSchema creates schema if it does not exist and runs the migrations
+// from migrationDir in it, e.g. for a new tenant. The schema keeps its own
+// migration version table, so schemas are migrated independently.
+func MigrateSchema(ctx context.Context, db *pgxpool.Pool, migrationDir, dbURL, schema string) error {
+	if _, err := db.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize()); err != nil {
+		return fmt.Errorf("create schema %s: %w", schema, err)
+	}
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return fmt.Errorf("parse database url: %w", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	if err := Migrate(migrationDir, u.String()); err != nil {
+		return fmt.Errorf("migrate schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// SchemaMigrationVersion is MigrationVersion of the migrations run in schema
+// by MigrateSchema.
+func SchemaMigrationVersion(ctx context.Context, db *pgxpool.Pool, schema string) (version uint, dirty bool, err error) {
+	table := pgx.Identifier{schema, "schema_migrations"}.Sanitize()
+	err = db.QueryRow(ctx, "SELECT version, dirty FROM "+table+" LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("read migration version of schema %s: %w", schema, err)
+	}
+	return version, dirty, nil
+}
+
+// MigrationVersion returns the current schema version recorded by migrate
+// and whether the last migration failed half-way (dirty).
+func MigrationVersion(ctx context.Context, db *pgxpool.Pool) (version uint, dirty bool, err error) {
+	err = db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// migrationFile matches up migration file names, e.g. "3_unique_subscription.up.sql".
+var migrationFile = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// LatestMigration returns the highest migration version found in migrationDir.
+func LatestMigration(migrationDir string) (uint, error) {
+	entries, err := os.ReadDir(migrationDir)
+	if err != nil {
+		return 0, fmt.Errorf("read migration dir: %w", err)
+	}
+
+	var latest uint
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse migration version of %s: %w", e.Name(), err)
+		}
+		latest = max(latest, uint(v))
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations in %s", migrationDir)
+	}
+	return latest, nil
+}
+
+// Indexes returns the names of indexes defined on table.
+func Indexes(ctx context.Context, db *pgxpool.Pool, table string) ([]string, error) {
+	rows, err := db.Query(ctx, "SELECT indexname FROM pg_indexes WHERE tablename = $1", table)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+	return names, nil
+}
+
+// Now returns the database server time.
+func Now(ctx context.Context, db *pgxpool.Pool) (time.Time, error) {
+	var now time.Time
+	if err := db.QueryRow(ctx, "SELECT now()").Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("read database time: %w", err)
+	}
+	return now, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// QueryExecModes maps config names to pgx query execution modes. Modes other
+// than cache_statement and cache_describe do not keep prepared statements on
+// the connection, so they work behind PgBouncer in transaction pooling mode;
+// simple_protocol also avoids the extended protocol altogether.
+var QueryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// PoolOption configures the connection pool created by Connect.
+type PoolOption func(*pgxpool.Config) error
+
+// WithQueryExecMode sets how queries are executed, see QueryExecModes.
+// An empty mode keeps the default (cache_statement or the connection string's
+// default_query_exec_mode).
+func WithQueryExecMode(mode string) PoolOption {
+	return func(c *pgxpool.Config) error {
+		if mode == "" {
+			return nil
+		}
+		m, ok := QueryExecModes[mode]
+		if !ok {
+			return fmt.Errorf("unknown query exec mode %q", mode)
+		}
+		c.ConnConfig.DefaultQueryExecMode = m
+		return nil
+	}
+}
+
+// WithStatementCache sets per-connection prepared statement and statement
+// description cache capacities. Zero keeps the default capacity.
+func WithStatementCache(statements, descriptions int) PoolOption {
+	return func(c *pgxpool.Config) error {
+		if statements > 0 {
+			c.ConnConfig.StatementCacheCapacity = statements
+		}
+		if descriptions > 0 {
+			c.ConnConfig.DescriptionCacheCapacity = descriptions
+		}
+		return nil
+	}
+}
+
+// WithSearchPath sets the search_path of every connection acquired from the
+// pool to the schema returned by schema for the acquiring context, e.g. the
+// schema of the request's tenant, or resets it to the default for "". The
+// connection keeps it for the statement or transaction it is acquired for.
+//
+// Only that schema is searched, so a query of a tenant whose schema does
+// not exist fails instead of reading the default schema. search_path is a
+// session setting: it does not work behind a transaction pooler.
+func WithSearchPath(schema func(context.Context) string) PoolOption {
+	return func(c *pgxpool.Config) error {
+		// The search_path connections were left with, "" — the default. It
+		// is only changed when it differs, saving a round trip per query.
+		var mu sync.Mutex
+		paths := make(map[*pgx.Conn]string)
+
+		c.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+			want := schema(ctx)
+			mu.Lock()
+			have := paths[conn]
+			mu.Unlock()
+			if want == have {
+				return true, nil
+			}
+
+			sql := "RESET search_path"
+			if want != "" {
+				sql = "SET search_path TO " + pgx.Identifier{want}.Sanitize()
+			}
+			if _, err := conn.Exec(ctx, sql); err != nil {
+				// The connection is destroyed: its search_path is unknown.
+				return false, fmt.Errorf("set search_path: %w", err)
+			}
+			mu.Lock()
+			paths[conn] = want
+			mu.Unlock()
+			return true, nil
+		}
+		c.BeforeClose = func(conn *pgx.Conn) {
+			mu.Lock()
+			delete(paths, conn)
+			mu.Unlock()
+		}
+		return nil
+	}
+}
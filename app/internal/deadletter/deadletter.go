@@ -0,0 +1,175 @@
+// Package deadletter parks async deliveries (outbox events, webhooks, jobs)
+// that exhausted their retries and redelivers them on demand.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Store defines repository methods required by Queue.
+type Store interface {
+	// Create inserts a dead letter and fills its ID and CreatedAt.
+	Create(ctx context.Context, dl *models.DeadLetter, opts ...repository.Option) error
+
+	// GetByID returns a dead letter by its ID.
+	GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.DeadLetter, error)
+
+	// List returns dead letters, optionally only not yet redelivered ones.
+	List(ctx context.Context, limit, offset int, pendingOnly bool, opts ...repository.Option) ([]models.DeadLetter, error)
+
+	// MarkRedelivered records a successful redelivery.
+	MarkRedelivered(ctx context.Context, id int64, at time.Time, opts ...repository.Option) error
+
+	// RecordFailure appends a failed redelivery's error.
+	RecordFailure(ctx context.Context, id int64, errMsg string, opts ...repository.Option) error
+}
+
+// DeliverFunc delivers a payload of one kind again.
+type DeliverFunc func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	// ErrNotFound is returned when a dead letter does not exist.
+	ErrNotFound = errors.New("dead letter not found")
+
+	// ErrAlreadyRedelivered is returned when redelivering a dead letter that
+	// was already delivered successfully.
+	ErrAlreadyRedelivered = errors.New("dead letter already redelivered")
+
+	// ErrUnknownKind is returned when no DeliverFunc is registered for the kind.
+	ErrUnknownKind = errors.New("unknown dead letter kind")
+
+	// ErrDeliveryFailed wraps the error of a failed redelivery.
+	ErrDeliveryFailed = errors.New("redelivery failed")
+)
+
+// Queue stores failed deliveries and redelivers them through the
+// DeliverFunc registered for their kind.
+type Queue struct {
+	store Store
+	log   *zap.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]DeliverFunc
+
+	now func() time.Time
+}
+
+// New creates a Queue backed by store.
+func New(store Store, log *zap.Logger) *Queue {
+	return &Queue{
+		store:    store,
+		log:      log,
+		handlers: make(map[string]DeliverFunc),
+		now:      time.Now,
+	}
+}
+
+// Register sets the function used to redeliver dead letters of kind.
+func (q *Queue) Register(kind string, fn DeliverFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = fn
+}
+
+// Add parks a delivery of kind that failed with err after attempts tries.
+// payload is stored as JSON.
+func (q *Queue) Add(ctx context.Context, kind string, payload any, attempts int, err error) (*models.DeadLetter, error) {
+	raw, mErr := json.Marshal(payload)
+	if mErr != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", kind, mErr)
+	}
+
+	dl := &models.DeadLetter{
+		Kind:     kind,
+		Payload:  raw,
+		Errors:   Chain(err),
+		Attempts: attempts,
+	}
+	if err := q.store.Create(ctx, dl); err != nil {
+		return nil, fmt.Errorf("failed to store dead letter: %w", err)
+	}
+
+	q.log.Warn("delivery moved to dead letters",
+		zap.Int64("id", dl.ID), zap.String("kind", kind), zap.Error(err))
+	return dl, nil
+}
+
+// Get returns a dead letter by ID.
+func (q *Queue) Get(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	dl, err := q.store.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return dl, err
+}
+
+// List returns dead letters, optionally only not yet redelivered ones.
+func (q *Queue) List(ctx context.Context, limit, offset int, pendingOnly bool) ([]models.DeadLetter, error) {
+	return q.store.List(ctx, limit, offset, pendingOnly)
+}
+
+// Redeliver delivers a dead letter again. A failed attempt is recorded in the
+// dead letter's error chain and returned wrapped in ErrDeliveryFailed.
+// The dead letter is returned along with errors once it is loaded.
+func (q *Queue) Redeliver(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	dl, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dl.RedeliveredAt != nil {
+		return dl, ErrAlreadyRedelivered
+	}
+
+	q.mu.RLock()
+	deliver, ok := q.handlers[dl.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		return dl, fmt.Errorf("%w: %q", ErrUnknownKind, dl.Kind)
+	}
+
+	if err := deliver(ctx, dl.Payload); err != nil {
+		if rErr := q.store.RecordFailure(ctx, id, err.Error()); rErr != nil {
+			q.log.Error("failed to record redelivery failure", zap.Int64("id", id), zap.Error(rErr))
+		}
+		return dl, fmt.Errorf("%w: %w", ErrDeliveryFailed, err)
+	}
+
+	now := q.now().UTC()
+	if err := q.store.MarkRedelivered(ctx, id, now); err != nil {
+		return dl, fmt.Errorf("redelivered, but failed to mark dead letter: %w", err)
+	}
+	dl.RedeliveredAt = &now
+	dl.Attempts++
+	return dl, nil
+}
+
+// Chain flattens err into messages, outermost first: wrapped errors follow
+// their wrapper and joined errors are listed in order.
+func Chain(err error) []string {
+	if err == nil {
+		return []string{}
+	}
+
+	chain := []string{err.Error()}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := u.Unwrap(); inner != nil {
+			chain = append(chain, Chain(inner)...)
+		}
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			chain = append(chain, Chain(e)...)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,118 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	dls map[int64]*models.DeadLetter
+}
+
+func newMemStore() *memStore {
+	return &memStore{dls: make(map[int64]*models.DeadLetter)}
+}
+
+func (s *memStore) Create(_ context.Context, dl *models.DeadLetter, _ ...repository.Option) error {
+	dl.ID = int64(len(s.dls) + 1)
+	dl.CreatedAt = time.Now()
+	cp := *dl
+	s.dls[dl.ID] = &cp
+	return nil
+}
+
+func (s *memStore) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.DeadLetter, error) {
+	dl, ok := s.dls[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *dl
+	return &cp, nil
+}
+
+func (s *memStore) List(context.Context, int, int, bool, ...repository.Option) ([]models.DeadLetter, error) {
+	return nil, nil
+}
+
+func (s *memStore) MarkRedelivered(_ context.Context, id int64, at time.Time, _ ...repository.Option) error {
+	s.dls[id].RedeliveredAt = &at
+	s.dls[id].Attempts++
+	return nil
+}
+
+func (s *memStore) RecordFailure(_ context.Context, id int64, errMsg string, _ ...repository.Option) error {
+	s.dls[id].Errors = append(s.dls[id].Errors, errMsg)
+	s.dls[id].Attempts++
+	return nil
+}
+
+func TestChain(t *testing.T) {
+	root := errors.New("connection refused")
+	err := errors.Join(fmt.Errorf("slack: %w", root), errors.New("telegram: 500"))
+
+	assert.Equal(t, []string{
+		"slack: connection refused\ntelegram: 500",
+		"slack: connection refused",
+		"connection refused",
+		"telegram: 500",
+	}, Chain(err))
+	assert.Empty(t, Chain(nil))
+}
+
+func TestQueue_Redeliver(t *testing.T) {
+	store := newMemStore()
+	q := New(store, zap.NewNop())
+
+	fail := true
+	var got string
+	q.Register("echo", func(_ context.Context, payload json.RawMessage) error {
+		if fail {
+			return errors.New("still down")
+		}
+		return json.Unmarshal(payload, &got)
+	})
+
+	dl, err := q.Add(t.Context(), "echo", "hello", 3, errors.New("down"))
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"hello"`), dl.Payload)
+
+	_, err = q.Redeliver(t.Context(), dl.ID)
+	require.ErrorIs(t, err, ErrDeliveryFailed)
+	assert.Equal(t, []string{"down", "still down"}, store.dls[dl.ID].Errors)
+
+	fail = false
+	redelivered, err := q.Redeliver(t.Context(), dl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+	assert.NotNil(t, redelivered.RedeliveredAt)
+	assert.Equal(t, 5, redelivered.Attempts)
+
+	_, err = q.Redeliver(t.Context(), dl.ID)
+	assert.ErrorIs(t, err, ErrAlreadyRedelivered)
+}
+
+func TestQueue_RedeliverErrors(t *testing.T) {
+	store := newMemStore()
+	q := New(store, zap.NewNop())
+
+	_, err := q.Redeliver(t.Context(), 42)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	dl, err := q.Add(t.Context(), "webhook", map[string]int{"id": 1}, 1, errors.New("timeout"))
+	require.NoError(t, err)
+
+	_, err = q.Redeliver(t.Context(), dl.ID)
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
@@ -0,0 +1,44 @@
+// Package diagnostics runs startup self-checks and reports what is missing.
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Check is a startup self-check.
+type Check struct {
+	Name     string
+	Critical bool // A failed critical check keeps the service not ready.
+	Run      func(ctx context.Context) error
+}
+
+// Run executes checks in order and logs the outcome of each. It returns the
+// joined errors of failed critical checks, nil if the service may serve traffic.
+func Run(ctx context.Context, log *zap.Logger, checks []Check) error {
+	var errs []error
+	for _, c := range checks {
+		start := time.Now()
+		err := c.Run(ctx)
+		fields := []zap.Field{
+			zap.String("check", c.Name),
+			zap.Bool("critical", c.Critical),
+			zap.Duration("took", time.Since(start)),
+		}
+
+		switch {
+		case err == nil:
+			log.Info("self-check passed", fields...)
+		case c.Critical:
+			log.Error("self-check failed", append(fields, zap.Error(err))...)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+		default:
+			log.Warn("self-check failed", append(fields, zap.Error(err))...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,36 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRun(t *testing.T) {
+	errMissing := errors.New("index is missing")
+	core, logs := observer.New(zap.InfoLevel)
+
+	err := Run(t.Context(), zap.New(core), []Check{
+		{Name: "passing", Critical: true, Run: func(context.Context) error { return nil }},
+		{Name: "optional", Run: func(context.Context) error { return errors.New("clock skew") }},
+		{Name: "indexes", Critical: true, Run: func(context.Context) error { return errMissing }},
+	})
+
+	assert.ErrorIs(t, err, errMissing)
+	assert.EqualError(t, err, "indexes: index is missing")
+
+	assert.Equal(t, 1, logs.FilterMessage("self-check passed").Len())
+	assert.Equal(t, 1, logs.FilterMessage("self-check failed").FilterField(zap.Bool("critical", false)).Len())
+	assert.Equal(t, 1, logs.FilterMessage("self-check failed").FilterField(zap.Bool("critical", true)).Len())
+}
+
+func TestRun_AllPassed(t *testing.T) {
+	err := Run(t.Context(), zap.NewNop(), []Check{
+		{Name: "passing", Critical: true, Run: func(context.Context) error { return nil }},
+	})
+	assert.NoError(t, err)
+}
@@ -15,9 +15,76 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/analytics/retention": {
+            "get": {
+                "description": "Группирует подписки по месяцу начала и показывает, сколько из них были активны через 1, 3, 6 и 12 месяцев.\nЕще не наступившие месяцы не возвращаются. Требуется заголовок Authorization: Bearer \u003cadmin.token\u003e.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Удержание подписок по когортам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Первая когорта (MM-YYYY)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последняя когорта (MM-YYYY)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: когорты",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.RetentionCohort"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/subscriptions/": {
             "get": {
-                "description": "Возвращает список подписок с пагинацией",
+                "description": "Возвращает список подписок с пагинацией, с фильтрами по пользователю и сервису или сохраненного фильтра filter_id.\nПри заданном auth.user_header пользователь видит только свои подписки, администратор — любые",
                 "produces": [
                     "application/json"
                 ],
@@ -26,9 +93,21 @@ const docTemplate = `{
                 ],
                 "summary": "Получить список подписок",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр по пользователю (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по сервису",
+                        "name": "service_name",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Количество элементов на странице (по умолчанию 10)",
+                        "description": "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)",
                         "name": "limit",
                         "in": "query"
                     },
@@ -37,6 +116,22 @@ const docTemplate = `{
                         "description": "Смещение (по умолчанию 0)",
                         "name": "offset",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID сохраненного фильтра вызывающего (см. /users/{user_id}/filters); user_id и service_name имеют приоритет",
+                        "name": "filter_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -47,6 +142,33 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "Превышен максимальный limit, некорректный user_id или filter_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "user_id другого пользователя (при заданном auth.user_header)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Сохраненный фильтр не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -79,13 +201,46 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.Subscription"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=existing — вернуть существующую подписку вместо ошибки 409",
+                        "name": "Prefer",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "ignore"
+                        ],
+                        "type": "string",
+                        "description": "ignore — то же, что Prefer: return=existing",
+                        "name": "on_conflict",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Подписка уже существует (Prefer: return=existing)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SubscriptionResource"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL подписки"
+                            }
+                        }
+                    },
                     "201": {
                         "description": "Успешное создание",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "$ref": "#/definitions/handler.SubscriptionResource"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL подписки"
+                            }
                         }
                     },
                     "400": {
@@ -97,54 +252,33 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера",
+                    "403": {
+                        "description": "Подписка другого пользователя (при включенной идентификации вызывающего)",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/subscriptions/summary": {
-            "post": {
-                "description": "Возвращает общую сумму подписок за указанный период с учетом фильтров",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "subscriptions"
-                ],
-                "summary": "Получить сумму подписок за период",
-                "parameters": [
-                    {
-                        "description": "Параметры периода и фильтров",
-                        "name": "summary",
-                        "in": "body",
-                        "required": true,
+                    },
+                    "409": {
+                        "description": "Подписка с таким user_id, service_name и start_date уже существует; existing_id — ее ID",
                         "schema": {
-                            "$ref": "#/definitions/models.SummaryRequest"
+                            "type": "object",
+                            "additionalProperties": true
                         }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Сумма подписок",
+                    },
+                    "422": {
+                        "description": "Превышен лимит активных подписок пользователя, пользователь не создан (users.require_provisioned) или неизвестен сервису аккаунтов",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "integer"
+                                "type": "string"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Некорректный запрос",
+                    "429": {
+                        "description": "Превышена квота записи пользователя",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -160,38 +294,82 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "503": {
+                        "description": "Сервис аккаунтов недоступен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/subscriptions/active": {
             "get": {
-                "description": "Возвращает данные подписки по ID",
+                "description": "Возвращает подписки, период которых включает указанный месяц",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Получить подписку по ID",
+                "summary": "Подписки, активные в месяце",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Месяц (MM-YYYY)",
+                        "name": "on",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по пользователю",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по сервису",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение (по умолчанию 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Найдена",
+                        "description": "data: список подписок, limit, offset",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Некорректный ID",
+                        "description": "Некорректный запрос",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -199,8 +377,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Не найдена",
+                    "403": {
+                        "description": "user_id другого пользователя (при заданном auth.user_header)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -209,46 +396,58 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет данные существующей подписки",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/subscriptions/services": {
+            "get": {
+                "description": "Возвращает уникальные названия сервисов с числом подписок, начиная с самых популярных (для автодополнения). Результат может кэшироваться.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Список сервисов",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало названия сервиса, без учета регистра",
+                        "name": "prefix",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "Обновленные данные подписки",
-                        "name": "subscription",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Subscription"
-                        }
+                        "type": "integer",
+                        "description": "Смещение (по умолчанию 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Обновлено",
+                        "description": "data: список сервисов (service_name, count), limit, offset",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные",
+                        "description": "Некорректный запрос",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -266,36 +465,1589 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет подписку по ID",
+            }
+        },
+        "/subscriptions/summary": {
+            "get": {
+                "description": "Возвращает общую сумму подписок за указанный период с учетом фильтров",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Получить сумму подписок за период",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Начало периода (MM-YYYY)",
+                        "name": "from",
+                        "in": "query",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "Удалено"
                     },
-                    "400": {
-                        "description": "Некорректный ID",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                    {
+                        "type": "string",
+                        "description": "Конец периода (MM-YYYY)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     },
-                    "500": {
+                    {
+                        "type": "string",
+                        "description": "Фильтр по пользователю",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по сервису",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Не учитывать пробные подписки",
+                        "name": "exclude_trials",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Не учитывать подписки дешевле указанной цены (например, 1 — без бесплатных тарифов)",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта ISO 4217; обязательна, если у подписок разные валюты",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID сохраненного фильтра вызывающего; фильтры запроса имеют приоритет",
+                        "name": "filter_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user_id",
+                            "service_name"
+                        ],
+                        "type": "string",
+                        "description": "Разбить сумму по пользователям (только для администраторов) или сервисам; группы по убыванию суммы",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Групп разбивки на странице (по умолчанию app.default_page_size, не больше app.max_page_size); остальные суммируются в other",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение по группам разбивки (по умолчанию 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть вклад каждой подписки (только для администраторов)",
+                        "name": "debug",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сумма подписок",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "debug и group_by=user_id доступны только администраторам, user_id — только свой",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Сохраненный фильтр не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Превышен лимит одновременных запросов (routes)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Превышено время обработки (routes)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Возвращает общую сумму подписок за указанный период с учетом фильтров.\nУстарел: используйте GET /subscriptions/summary.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить сумму подписок за период",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Параметры периода и фильтров",
+                        "name": "summary",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SummaryRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID сохраненного фильтра вызывающего; фильтры запроса имеют приоритет",
+                        "name": "filter_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть вклад каждой подписки (только для администраторов)",
+                        "name": "debug",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сумма подписок",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "debug и group_by=user_id доступны только администраторам, user_id — только свой",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Сохраненный фильтр не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Превышен лимит одновременных запросов (routes)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Превышено время обработки (routes)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Возвращает данные подписки по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить подписку по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные существующей подписки",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Обновить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновленные данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Подтвердить изменение цены больше limits.max_price_change_percent",
+                        "name": "allow_price_change",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновлено",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Перенос подписки другому пользователю",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Изменение цены больше допустимого без allow_price_change, пользователь не создан (users.require_provisioned) или неизвестен сервису аккаунтов",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышена квота записи пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис аккаунтов недоступен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет подписку по ID.\nС return=representation (query или Prefer) возвращает удаленную подписку и время удаления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "representation"
+                        ],
+                        "type": "string",
+                        "description": "representation — вернуть удаленную подписку",
+                        "name": "return",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "return=representation — то же, что return=representation в query",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Удалено (return=representation)",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeletedSubscription"
+                        }
+                    },
+                    "204": {
+                        "description": "Удалено"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Возвращает 200, если подписка существует, без тела ответа",
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Проверить существование подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Существует"
+                    },
+                    "400": {
+                        "description": "Некорректный ID"
+                    },
+                    "404": {
+                        "description": "Не найдена"
+                    },
+                    "500": {
+                        "description": "Ошибка сервера"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Меняет только переданные поля: service_name, price и end_date (null — бессрочная подписка).\nОстальные поля не перезаписываются, поэтому параллельные изменения других полей сохраняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Частично обновить подписку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionPatch"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Подтвердить изменение цены больше limits.max_price_change_percent",
+                        "name": "allow_price_change",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная подписка",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные или нет изменяемых полей",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Изменение цены больше допустимого без allow_price_change",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышена квота записи пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/summary-subscriptions/": {
+            "get": {
+                "description": "Возвращает подписки пользователя на регулярную сумму, упорядоченные по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary-subscriptions"
+                ],
+                "summary": "Подписки пользователя на сумму",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: подписки на сумму",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.SummarySubscription"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный user_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Подписки другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Подписывает пользователя на сумму его подписок за каждый месяц, квартал или год с фильтрами, как в запросе суммы (user_id фильтра всегда равен user_id подписки). Сумма за прошедший период отправляется в начале следующего через канал уведомлений с адресатом (email) на адрес recipient. Каналы с общим назначением (slack, telegram, webhook) не подходят. На recipient отправляется токен подтверждения: суммы отправляются только после подтверждения через POST /summary-subscriptions/confirm.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary-subscriptions"
+                ],
+                "summary": "Подписаться на регулярную сумму",
+                "parameters": [
+                    {
+                        "description": "user_id, period, filter, channel и recipient (остальные поля игнорируются)",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SummarySubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Подписка создана и ожидает подтверждения",
+                        "schema": {
+                            "$ref": "#/definitions/models.SummarySubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Канал не настроен, не отправляет адресату или recipient ему не подходит",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера или отправки подтверждения",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/summary-subscriptions/confirm": {
+            "post": {
+                "description": "Подтверждает адрес подписки на сумму токеном, отправленным на него при подписке; после этого суммы отправляются. Токен действует один раз.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summary-subscriptions"
+                ],
+                "summary": "Подтвердить подписку на регулярную сумму",
+                "parameters": [
+                    {
+                        "description": "Токен подтверждения",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SummarySubscriptionConfirmation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка подтверждена",
+                        "schema": {
+                            "$ref": "#/definitions/models.SummarySubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Ни одна подписка не ожидает такого токена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/summary-subscriptions/{id}": {
+            "delete": {
+                "description": "Удаляет подписку пользователя на регулярную сумму",
+                "tags": [
+                    "summary-subscriptions"
+                ],
+                "summary": "Отписаться от регулярной суммы",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки на сумму",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка удалена"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Подписка другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/": {
+            "get": {
+                "description": "Возвращает пользователей, упорядоченных по ID, для сверки с внешней системой идентификации.\nТребуется заголовок Authorization: Bearer \u003cadmin.token\u003e.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить список пользователей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество элементов на странице (по умолчанию 100, не больше 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение (по умолчанию 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: список пользователей, limit, offset",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Превышен максимальный limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Регистрирует пользователя, которому могут принадлежать подписки. Повторный вызов для существующего пользователя возвращает 200.\nТребуется заголовок Authorization: Bearer \u003cadmin.token\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создать пользователя",
+                "parameters": [
+                    {
+                        "description": "Пользователь (created_at игнорируется)",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "201": {
+                        "description": "Пользователь создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "delete": {
+                "description": "Удаляет пользователя без подписок; подписки пользователя нужно удалить заранее.\nТребуется заголовок Authorization: Bearer \u003cadmin.token\u003e.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пользователь удален"
+                    },
+                    "400": {
+                        "description": "Некорректный user_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "У пользователя есть подписки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/activity": {
+            "get": {
+                "description": "Возвращает изменения подписок пользователя из аудит-лога, начиная с новых: создание, изменение цены, установку даты окончания, другие изменения и удаление.\nkind — created, price_changed, ended, updated или cancelled; message — краткое описание, например \"cancelled Spotify\"; details — записанные данные, у изменений — changes с from и to по полям.\nЛента ведется с момента включения аудита в БД, более ранних действий в ней нет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Лента действий пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: действия пользователя, limit, offset",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный user_id или limit больше допустимого",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Лента другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/filters": {
+            "get": {
+                "description": "Возвращает сохраненные фильтры пользователя, упорядоченные по названию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "filters"
+                ],
+                "summary": "Сохраненные фильтры пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: фильтры",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.SavedFilter"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный user_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтры другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Сохраняет именованный набор фильтров пользователя; его id передается как filter_id в GET /subscriptions/ и запросы суммы.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "filters"
+                ],
+                "summary": "Сохранить фильтр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Название и фильтры (id, user_id и даты игнорируются)",
+                        "name": "filter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilter"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Фильтр сохранен",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilter"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL фильтра"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтры другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "У пользователя уже есть фильтр с таким названием",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/filters/{filter_id}": {
+            "get": {
+                "description": "Возвращает сохраненный фильтр пользователя по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "filters"
+                ],
+                "summary": "Получить сохраненный фильтр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID фильтра",
+                        "name": "filter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтры другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет название и фильтры сохраненного фильтра пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "filters"
+                ],
+                "summary": "Изменить сохраненный фильтр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID фильтра",
+                        "name": "filter_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Название и фильтры (id, user_id и даты игнорируются)",
+                        "name": "filter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilter"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Фильтр изменен",
+                        "schema": {
+                            "$ref": "#/definitions/models.SavedFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтры другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "У пользователя уже есть фильтр с таким названием",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет сохраненный фильтр пользователя",
+                "tags": [
+                    "filters"
+                ],
+                "summary": "Удалить сохраненный фильтр",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID фильтра",
+                        "name": "filter_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Фильтр удален"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Фильтры другого пользователя (при включенной идентификации вызывающего)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions/overlaps": {
+            "get": {
+                "description": "Возвращает пары подписок пользователя на один сервис с пересекающимися периодами (вероятная двойная оплата)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Пересекающиеся подписки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "strong",
+                            "eventual"
+                        ],
+                        "type": "string",
+                        "description": "strong — читать только из основной БД, минуя реплики и кэш",
+                        "name": "Consistency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data: пары пересекающихся подписок",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Overlap"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный user_id",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Подписки другого пользователя (при заданном auth.user_header)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
                         "description": "Ошибка сервера",
                         "schema": {
                             "type": "object",
@@ -303,16 +2055,385 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.ResourceLinks": {
+            "type": "object",
+            "properties": {
+                "self": {
+                    "description": "URL самого ресурса",
+                    "type": "string",
+                    "example": "/subscriptions/1"
+                }
+            }
+        },
+        "handler.SubscriptionResource": {
+            "type": "object",
+            "required": [
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 code; RUB if empty.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "end_date": {
+                    "description": "Optional end date.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Subscription identifier.",
+                    "type": "integer"
+                },
+                "links": {
+                    "$ref": "#/definitions/handler.ResourceLinks"
+                },
+                "origin_region": {
+                    "description": "OriginRegion is the region of the instance that last wrote the\nsubscription (app.region); empty if not configured. It is set by the\nservice, values sent by clients are ignored.",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Monthly price in whole units of Currency.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "service_name": {
+                    "description": "Service name.",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Start date (month-year).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "trial": {
+                    "description": "Trial period; excluded from reports on request.",
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "description": "Associated user ID.",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.SummaryFilters": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Валюта",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "exclude_trials": {
+                    "description": "Пробные подписки не учитывались",
+                    "type": "boolean"
+                },
+                "min_price": {
+                    "description": "Минимальная цена",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "description": "Сервис",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Пользователь",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.SummaryResponse": {
+            "type": "object",
+            "properties": {
+                "breakdown": {
+                    "description": "Страница разбивки суммы по group_by; только с group_by",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SummaryBreakdown"
+                        }
+                    ]
+                },
+                "count": {
+                    "description": "Число подписок, попавших в период",
+                    "type": "integer",
+                    "example": 3
+                },
+                "currency": {
+                    "description": "Валюта ISO 4217",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ],
+                    "example": "RUB"
+                },
+                "filters": {
+                    "description": "Примененные фильтры",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.SummaryFilters"
+                        }
+                    ]
+                },
+                "from": {
+                    "description": "Начало периода, использованное при подсчете",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "lines": {
+                    "description": "Вклад каждой подписки в сумму; только с debug=true",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SummaryLine"
+                    }
+                },
+                "to": {
+                    "description": "Конец периода, использованный при подсчете",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "total": {
+                    "description": "Сумма в целых единицах валюты",
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "models.Currency": {
+            "type": "string",
+            "enum": [
+                "RUB"
+            ],
+            "x-enum-varnames": [
+                "DefaultCurrency"
+            ]
+        },
+        "models.DeletedSubscription": {
+            "type": "object",
+            "required": [
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 code; RUB if empty.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "deleted_at": {
+                    "description": "Deletion time (UTC).",
+                    "type": "string"
+                },
+                "end_date": {
+                    "description": "Optional end date.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Subscription identifier.",
+                    "type": "integer"
+                },
+                "origin_region": {
+                    "description": "OriginRegion is the region of the instance that last wrote the\nsubscription (app.region); empty if not configured. It is set by the\nservice, values sent by clients are ignored.",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Monthly price in whole units of Currency.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "service_name": {
+                    "description": "Service name.",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Start date (month-year).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "trial": {
+                    "description": "Trial period; excluded from reports on request.",
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "description": "Associated user ID.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FilterCriteria": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Only subscriptions in this currency.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "exclude_trials": {
+                    "description": "Ignore trial subscriptions.",
+                    "type": "boolean"
+                },
+                "min_price": {
+                    "description": "Ignore subscriptions cheaper than this.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "service_name": {
+                    "description": "Only subscriptions of this service.",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Only subscriptions of this user.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MonthDate": {
+            "type": "object",
+            "properties": {
+                "time.Time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Overlap": {
+            "type": "object",
+            "properties": {
+                "first_id": {
+                    "description": "Subscription that starts first.",
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "First month of the overlap.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "second_id": {
+                    "description": "Subscription that starts later.",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "description": "Service both subscriptions are for.",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Last month of the overlap; absent if both are open-ended.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.MonthDate": {
+        },
+        "models.RetentionCohort": {
             "type": "object",
             "properties": {
-                "time.Time": {
+                "cohort": {
+                    "description": "Start month of the subscriptions.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "month_1": {
+                    "description": "Still active 1 month later.",
+                    "type": "integer"
+                },
+                "month_12": {
+                    "description": "Still active 12 months later.",
+                    "type": "integer"
+                },
+                "month_3": {
+                    "description": "Still active 3 months later.",
+                    "type": "integer"
+                },
+                "month_6": {
+                    "description": "Still active 6 months later.",
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "Subscriptions started in the cohort month.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SavedFilter": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "When the filter was saved.",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Saved filters.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FilterCriteria"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Saved filter identifier.",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name, unique per user.",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "updated_at": {
+                    "description": "When the filter was last changed.",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Owner; set from the path.",
                     "type": "string"
                 }
             }
@@ -325,6 +2446,14 @@ const docTemplate = `{
                 "user_id"
             ],
             "properties": {
+                "currency": {
+                    "description": "ISO 4217 code; RUB if empty.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
                 "end_date": {
                     "description": "Optional end date.",
                     "allOf": [
@@ -337,8 +2466,12 @@ const docTemplate = `{
                     "description": "Subscription identifier.",
                     "type": "integer"
                 },
+                "origin_region": {
+                    "description": "OriginRegion is the region of the instance that last wrote the\nsubscription (app.region); empty if not configured. It is set by the\nservice, values sent by clients are ignored.",
+                    "type": "string"
+                },
                 "price": {
-                    "description": "Monthly price.",
+                    "description": "Monthly price in whole units of Currency.",
                     "type": "integer",
                     "minimum": 0
                 },
@@ -354,12 +2487,195 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "trial": {
+                    "description": "Trial period; excluded from reports on request.",
+                    "type": "boolean"
+                },
                 "user_id": {
                     "description": "Associated user ID.",
                     "type": "string"
                 }
             }
         },
+        "models.SubscriptionPatch": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "New end date; null makes it open-ended.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "price": {
+                    "description": "New monthly price.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "service_name": {
+                    "description": "New service name.",
+                    "type": "string",
+                    "minLength": 1
+                }
+            }
+        },
+        "models.SummaryBreakdown": {
+            "type": "object",
+            "properties": {
+                "group_by": {
+                    "description": "Field the subscriptions are grouped by.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SummaryGroupBy"
+                        }
+                    ]
+                },
+                "groups": {
+                    "description": "Groups of the page, largest amount first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SummaryGroup"
+                    }
+                },
+                "limit": {
+                    "description": "Page size.",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Groups skipped before the page.",
+                    "type": "integer"
+                },
+                "other": {
+                    "description": "Groups after the page added up; nil if there are none.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SummaryOther"
+                        }
+                    ]
+                },
+                "total_groups": {
+                    "description": "Number of groups on all pages.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SummaryGroup": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Sum; in JSON in whole units of the currency, like the total.",
+                    "type": "integer"
+                },
+                "count": {
+                    "description": "Number of subscriptions in the group.",
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "User ID or service name.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SummaryGroupBy": {
+            "type": "string",
+            "enum": [
+                "user_id",
+                "service_name"
+            ],
+            "x-enum-varnames": [
+                "GroupByUser",
+                "GroupByService"
+            ]
+        },
+        "models.SummaryLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Price times months; in JSON in whole units of the currency.",
+                    "type": "integer"
+                },
+                "currency": {
+                    "description": "Currency of the price.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "from": {
+                    "description": "First counted month.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Subscription ID.",
+                    "type": "integer"
+                },
+                "months": {
+                    "description": "Number of counted months.",
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Monthly price.",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "description": "Service name.",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Last counted month.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MonthDate"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "Owner of the subscription.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SummaryOther": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Sum; in JSON in whole units of the currency, like the total.",
+                    "type": "integer"
+                },
+                "count": {
+                    "description": "Number of subscriptions in the groups.",
+                    "type": "integer"
+                },
+                "groups": {
+                    "description": "Number of groups added up.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SummaryPeriod": {
+            "type": "string",
+            "enum": [
+                "month",
+                "quarter",
+                "year"
+            ],
+            "x-enum-comments": {
+                "PeriodMonth": "Calendar month.",
+                "PeriodQuarter": "Calendar quarter, starting in January, April, July or October.",
+                "PeriodYear": "Calendar year."
+            },
+            "x-enum-varnames": [
+                "PeriodMonth",
+                "PeriodQuarter",
+                "PeriodYear"
+            ]
+        },
         "models.SummaryRequest": {
             "type": "object",
             "required": [
@@ -367,6 +2683,18 @@ const docTemplate = `{
                 "to"
             ],
             "properties": {
+                "currency": {
+                    "description": "Only subscriptions in this currency; required if they use several.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Currency"
+                        }
+                    ]
+                },
+                "exclude_trials": {
+                    "description": "Ignore trial subscriptions.",
+                    "type": "boolean"
+                },
                 "from": {
                     "description": "Start of the period.",
                     "allOf": [
@@ -375,6 +2703,33 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "group_by": {
+                    "description": "Optional breakdown of the total by user or service. Groups are ordered\nby amount, largest first; Limit and Offset select a page of them and\nthe groups after the page are added up into one \"other\" bucket.",
+                    "enum": [
+                        "user_id",
+                        "service_name"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SummaryGroupBy"
+                        }
+                    ]
+                },
+                "limit": {
+                    "description": "Groups per page; 0 means the default page size.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "min_price": {
+                    "description": "Ignore subscriptions cheaper than this, e.g. 1 for free tiers.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "offset": {
+                    "description": "Groups to skip.",
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "service_name": {
                     "description": "Optional service filter.",
                     "type": "string"
@@ -392,6 +2747,104 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.SummarySubscription": {
+            "type": "object",
+            "required": [
+                "channel",
+                "period",
+                "recipient",
+                "user_id"
+            ],
+            "properties": {
+                "channel": {
+                    "description": "Notification channel, one of notifications.channels, e.g. email.",
+                    "type": "string"
+                },
+                "confirmed_at": {
+                    "description": "When the recipient confirmed the address with the token sent there;\nsummaries are only sent after that.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "When the user subscribed.",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Filters of the summary, as in SummaryRequest.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FilterCriteria"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Summary subscription identifier.",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "description": "Why the last attempt failed; retried on the next run.",
+                    "type": "string"
+                },
+                "last_sent_at": {
+                    "description": "When the last summary was sent.",
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "description": "When the next summary is sent.",
+                    "type": "string"
+                },
+                "period": {
+                    "description": "Period each summary covers.",
+                    "enum": [
+                        "month",
+                        "quarter",
+                        "year"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SummaryPeriod"
+                        }
+                    ]
+                },
+                "recipient": {
+                    "description": "Address the summaries are sent to; only channels sending to any\nrecipient (email) can be used.",
+                    "type": "string",
+                    "maxLength": 254
+                },
+                "user_id": {
+                    "description": "User who subscribed.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SummarySubscriptionConfirmation": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "Token from the confirmation message.",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "When the user was provisioned.",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "User ID, as in subscriptions.",
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -403,7 +2856,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Subscriptions API",
-	Description:      "REST API для управления подписками пользователей",
+	Description:      "REST API для управления подписками пользователей.\nОшибки возвращаются как {\"error\": \"...\"} или, при Accept: application/problem+json, в формате RFC 7807.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
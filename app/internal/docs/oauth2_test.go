@@ -0,0 +1,35 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOAuth2(t *testing.T) {
+	doc, err := WithOAuth2(SwaggerInfo.ReadDoc(), OAuth2{
+		AuthorizationURL: "https://id.example.com/auth",
+		TokenURL:         "https://id.example.com/token",
+		Scopes:           []string{"openid", "profile"},
+	})
+	require.NoError(t, err)
+
+	var spec struct {
+		SecurityDefinitions map[string]map[string]any `json:"securityDefinitions"`
+		Security            []map[string][]string     `json:"security"`
+		Paths               map[string]any            `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(doc), &spec))
+
+	assert.Equal(t, map[string]any{
+		"type":             "oauth2",
+		"flow":             "accessCode",
+		"authorizationUrl": "https://id.example.com/auth",
+		"tokenUrl":         "https://id.example.com/token",
+		"scopes":           map[string]any{"openid": "", "profile": ""},
+	}, spec.SecurityDefinitions[OAuth2SecurityName])
+	assert.Equal(t, []map[string][]string{{OAuth2SecurityName: {"openid", "profile"}}}, spec.Security)
+	assert.Contains(t, spec.Paths, "/subscriptions/", "the rest of the document is kept")
+}
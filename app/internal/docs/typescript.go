@@ -0,0 +1,152 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// tsOverrides replaces definitions swag cannot describe, e.g. types with
+// custom JSON encoding.
+var tsOverrides = map[string]string{
+	"models.MonthDate": `/** Month in "MM-YYYY" format. */
+export type MonthDate = string;`,
+}
+
+// schema is the subset of a Swagger 2.0 schema used for the declarations.
+type schema struct {
+	Type                 string            `json:"type"`
+	Format               string            `json:"format"`
+	Description          string            `json:"description"`
+	Ref                  string            `json:"$ref"`
+	AllOf                []schema          `json:"allOf"`
+	Items                *schema           `json:"items"`
+	Enum                 []any             `json:"enum"`
+	Required             []string          `json:"required"`
+	Properties           map[string]schema `json:"properties"`
+	AdditionalProperties *schema           `json:"additionalProperties"`
+}
+
+// TypeScript returns TypeScript declarations of the API models generated
+// from the Swagger definitions, so the frontend models follow the DTOs.
+var TypeScript = sync.OnceValues(func() (string, error) {
+	var doc struct {
+		Definitions map[string]schema `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(SwaggerInfo.ReadDoc()), &doc); err != nil {
+		return "", fmt.Errorf("parse swagger doc: %w", err)
+	}
+	return typeScript(doc.Definitions), nil
+})
+
+// TypeScriptHandler serves the declarations returned by TypeScript.
+func TypeScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts, err := TypeScript()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+		_, _ = w.Write([]byte(ts))
+	})
+}
+
+func typeScript(defs map[string]schema) string {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("// Code generated from the Swagger definitions. DO NOT EDIT.\n")
+	for _, name := range names {
+		b.WriteString("\n")
+		if decl, ok := tsOverrides[name]; ok {
+			b.WriteString(decl + "\n")
+			continue
+		}
+
+		def := defs[name]
+		writeComment(&b, "", def.Description)
+		if def.Type != "object" || def.Properties == nil {
+			fmt.Fprintf(&b, "export type %s = %s;\n", tsName(name), tsType(def))
+			continue
+		}
+
+		fmt.Fprintf(&b, "export interface %s {\n", tsName(name))
+		props := make([]string, 0, len(def.Properties))
+		for p := range def.Properties {
+			props = append(props, p)
+		}
+		slices.Sort(props)
+		for _, p := range props {
+			prop := def.Properties[p]
+			writeComment(&b, "  ", prop.Description)
+			optional := "?"
+			if slices.Contains(def.Required, p) {
+				optional = ""
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", p, optional, tsType(prop))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// tsName drops the Go package from a definition name: models.Subscription -> Subscription.
+func tsName(def string) string {
+	return def[strings.LastIndex(def, ".")+1:]
+}
+
+func tsType(s schema) string {
+	switch {
+	case s.Ref != "":
+		return tsName(strings.TrimPrefix(s.Ref, "#/definitions/"))
+	case len(s.AllOf) == 1:
+		return tsType(s.AllOf[0])
+	case len(s.Enum) > 0:
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			b, _ := json.Marshal(v)
+			values[i] = string(b)
+		}
+		return strings.Join(values, " | ")
+	}
+
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if s.Items == nil {
+			return "unknown[]"
+		}
+		item := tsType(*s.Items)
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + tsType(*s.AdditionalProperties) + ">"
+		}
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+func writeComment(b *strings.Builder, indent, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(text, "\n", " "))
+}
@@ -0,0 +1,48 @@
+package docs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeScript(t *testing.T) {
+	ts, err := TypeScript()
+	require.NoError(t, err)
+
+	assert.Contains(t, ts, "export type MonthDate = string;")
+	assert.Contains(t, ts, "export interface Subscription {\n")
+	assert.Contains(t, ts, "  /** Service name. */\n  service_name: string;\n")
+	assert.Contains(t, ts, "  end_date?: MonthDate;\n")
+	assert.Contains(t, ts, "  links?: ResourceLinks;\n")
+	assert.NotContains(t, ts, "unknown")
+}
+
+func TestTSType(t *testing.T) {
+	tests := []struct {
+		schema schema
+		want   string
+	}{
+		{schema{Type: "integer"}, "number"},
+		{schema{Type: "string", Format: "date-time"}, "string"},
+		{schema{Type: "string", Enum: []any{"read", "write"}}, `"read" | "write"`},
+		{schema{Type: "array", Items: &schema{Ref: "#/definitions/models.Overlap"}}, "Overlap[]"},
+		{schema{Type: "array", Items: &schema{Enum: []any{1, 2}}}, "(1 | 2)[]"},
+		{schema{Type: "object", AdditionalProperties: &schema{Type: "boolean"}}, "Record<string, boolean>"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tsType(tt.schema))
+	}
+}
+
+func TestTypeScriptHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	TypeScriptHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/typescript/api.d.ts", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/typescript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "export interface Overlap {")
+}
@@ -0,0 +1,103 @@
+// Package encryption encrypts sensitive column values at rest with
+// AES-256-GCM. Keys have IDs, stored next to the values they encrypted, so
+// the active key can be rotated while values encrypted with older keys stay
+// readable until they are re-encrypted.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeySize is the size of keys: AES-256.
+const KeySize = 32
+
+// ErrUnknownKey is returned when a value was encrypted with a key that is
+// not configured, e.g. removed before values were re-encrypted.
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// keyIDPattern restricts key IDs, e.g. to dates of rotation like 2025-07.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Keyring encrypts values with the active key and decrypts them with the
+// key they were encrypted with.
+type Keyring struct {
+	aeads  map[string]cipher.AEAD
+	active string
+}
+
+// New creates a Keyring from key specs "<id>:<base64 key>" with the active
+// key ID; the key material can come from the config or be injected into the
+// environment by a KMS or secrets manager.
+func New(specs []string, active string) (*Keyring, error) {
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(specs)), active: active}
+	for _, spec := range specs {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(spec), ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, errors.New(`key must be "<id>:<base64 key>" with an id of letters, digits, '.', '_' or '-'`)
+		}
+		if _, ok := k.aeads[id]; ok {
+			return nil, fmt.Errorf("key %s: duplicate id", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %s: must be %d bytes, got %d", id, KeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if k.aeads[id], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+	}
+
+	if _, ok := k.aeads[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not among the keys", active)
+	}
+	return k, nil
+}
+
+// ActiveKeyID returns the ID of the key values are encrypted with.
+func (k *Keyring) ActiveKeyID() string { return k.active }
+
+// Encrypt encrypts plaintext with the active key and returns the ciphertext
+// with the key ID. aad binds the ciphertext to its place, e.g. the column
+// and row, so it does not decrypt if copied to another row.
+func (k *Keyring) Encrypt(plaintext, aad []byte) ([]byte, string, error) {
+	aead := k.aeads[k.active]
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), k.active, nil
+}
+
+// Decrypt decrypts ciphertext encrypted with the key keyID and the same aad.
+func (k *Keyring) Decrypt(ciphertext, aad []byte, keyID string) ([]byte, error) {
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt with key %s: %w", keyID, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,56 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"subscriptionsservice/internal/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, encryption.KeySize))
+}
+
+func TestKeyring(t *testing.T) {
+	old, err := encryption.New([]string{"2025-01:" + key(1)}, "2025-01")
+	require.NoError(t, err)
+	ciphertext, keyID, err := old.Encrypt([]byte("secret"), []byte("row 1"))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", keyID)
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	rotated, err := encryption.New([]string{"2025-01:" + key(1), "2025-07:" + key(2)}, "2025-07")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-07", rotated.ActiveKeyID())
+
+	plaintext, err := rotated.Decrypt(ciphertext, []byte("row 1"), keyID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext), "values of older keys stay readable")
+
+	_, err = rotated.Decrypt(ciphertext, []byte("row 2"), keyID)
+	assert.Error(t, err, "a value copied to another row does not decrypt")
+
+	_, err = rotated.Decrypt(ciphertext, []byte("row 1"), "2024-07")
+	assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, specs := range map[string][]string{
+		"no id":        {key(1)},
+		"short key":    {"k:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		"not base64":   {"k:???"},
+		"duplicate id": {"k:" + key(1), "k:" + key(2)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encryption.New(specs, "k")
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := encryption.New([]string{"k:" + key(1)}, "other")
+	assert.ErrorContains(t, err, "active key")
+}
@@ -0,0 +1,173 @@
+// Package eventbus delivers values to subscribers within the process, so the
+// code publishing them does not depend on what consumes them, e.g. SSE
+// streams, webhooks or cache invalidation.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// ErrClosed is returned by Publish and Subscribe after Close.
+var ErrClosed = errors.New("event bus is closed")
+
+// DefaultBuffer is the number of values buffered per subscriber if New is
+// given a non-positive buffer.
+const DefaultBuffer = 64
+
+// Handler handles a value delivered to a subscriber. ctx is canceled when
+// Close gives up waiting for the subscriber.
+type Handler[T any] func(ctx context.Context, v T)
+
+// Bus fans values out to subscribers. Each subscriber has its own buffer
+// and goroutine, so a slow one delays neither publishers nor other
+// subscribers: values that do not fit into its buffer are dropped for it.
+// It is safe for concurrent use.
+type Bus[T any] struct {
+	buffer int
+	log    *zap.Logger
+
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+
+	// ctx is passed to handlers and canceled once Close returns.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a bus buffering up to buffer values per subscriber.
+func New[T any](buffer int, log *zap.Logger) *Bus[T] {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus[T]{
+		buffer: buffer,
+		log:    log,
+		subs:   make(map[*Subscription[T]]struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Subscription is a subscriber of a Bus.
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	name    string
+	ch      chan T
+	dropped atomic.Uint64
+}
+
+// Subscribe calls h for every value published after it returns, in order,
+// one at a time. name identifies the subscriber in logs. A panic in h is
+// logged and does not stop the subscription.
+func (b *Bus[T]) Subscribe(name string, h Handler[T]) (*Subscription[T], error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	s := &Subscription[T]{bus: b, name: name, ch: make(chan T, b.buffer)}
+	b.subs[s] = struct{}{}
+	b.wg.Add(1)
+	go s.run(h)
+	return s, nil
+}
+
+// Publish queues v for every subscriber without blocking. It returns
+// ErrClosed after Close; values dropped for slow subscribers are logged,
+// not returned.
+func (b *Bus[T]) Publish(_ context.Context, v T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	for s := range b.subs {
+		select {
+		case s.ch <- v:
+		default:
+			n := s.dropped.Add(1)
+			b.log.Warn("event dropped, subscriber is too slow",
+				zap.String("subscriber", s.name),
+				zap.Uint64("dropped", n),
+			)
+		}
+	}
+	return nil
+}
+
+// Close stops accepting values and waits until subscribers handle the
+// buffered ones. If ctx is done first, handlers get their context canceled
+// and ctx.Err() is returned. Close may be called more than once.
+func (b *Bus[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for s := range b.subs {
+			delete(b.subs, s)
+			close(s.ch)
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	defer b.cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event bus not drained: %w", ctx.Err())
+	}
+}
+
+// Unsubscribe stops the subscription. Values already buffered for it are
+// still handled.
+func (s *Subscription[T]) Unsubscribe() {
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Dropped returns the number of values dropped because the subscriber's
+// buffer was full.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription[T]) run(h Handler[T]) {
+	defer s.bus.wg.Done()
+	for v := range s.ch {
+		s.handle(h, v)
+	}
+}
+
+func (s *Subscription[T]) handle(h Handler[T], v T) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.bus.log.Error("event handler panicked",
+				zap.String("subscriber", s.name),
+				zap.Any("panic", r),
+			)
+		}
+	}()
+	h(s.bus.ctx, v)
+}
@@ -0,0 +1,164 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// collector records handled values.
+type collector struct {
+	mu     sync.Mutex
+	values []int
+}
+
+func (c *collector) handle(_ context.Context, v int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = append(c.values, v)
+}
+
+func (c *collector) got() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.values...)
+}
+
+func TestBus_FanOut(t *testing.T) {
+	bus := New[int](10, zap.NewNop())
+	var a, b collector
+	_, err := bus.Subscribe("a", a.handle)
+	require.NoError(t, err)
+	_, err = bus.Subscribe("b", b.handle)
+	require.NoError(t, err)
+
+	for i := range 5 {
+		require.NoError(t, bus.Publish(t.Context(), i))
+	}
+	require.NoError(t, bus.Close(t.Context()))
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, a.got(), "values are delivered in order")
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, b.got())
+}
+
+func TestBus_SlowSubscriberDropsValues(t *testing.T) {
+	bus := New[int](1, zap.NewNop())
+	release := make(chan struct{})
+	var slow collector
+	sub, err := bus.Subscribe("slow", func(ctx context.Context, v int) {
+		<-release
+		slow.handle(ctx, v)
+	})
+	require.NoError(t, err)
+	var fast collector
+	_, err = bus.Subscribe("fast", fast.handle)
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(t.Context(), 1)) // taken by the handler
+	require.Eventually(t, func() bool { return len(sub.ch) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, bus.Publish(t.Context(), 2)) // buffered
+	require.NoError(t, bus.Publish(t.Context(), 3)) // dropped
+	assert.Equal(t, uint64(1), sub.Dropped())
+
+	close(release)
+	require.NoError(t, bus.Close(t.Context()))
+	assert.Equal(t, []int{1, 2}, slow.got())
+	assert.Equal(t, []int{1, 2, 3}, fast.got(), "a slow subscriber does not affect others")
+}
+
+func TestBus_Close(t *testing.T) {
+	t.Run("rejects new values and subscribers", func(t *testing.T) {
+		bus := New[int](0, zap.NewNop())
+		require.NoError(t, bus.Close(t.Context()))
+		require.NoError(t, bus.Close(t.Context()), "close is idempotent")
+
+		assert.ErrorIs(t, bus.Publish(t.Context(), 1), ErrClosed)
+		_, err := bus.Subscribe("late", func(context.Context, int) {})
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+
+	t.Run("gives up after the deadline", func(t *testing.T) {
+		bus := New[int](0, zap.NewNop())
+		canceled := make(chan struct{})
+		_, err := bus.Subscribe("stuck", func(ctx context.Context, _ int) {
+			<-ctx.Done()
+			close(canceled)
+		})
+		require.NoError(t, err)
+		require.NoError(t, bus.Publish(t.Context(), 1))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, bus.Close(ctx), context.DeadlineExceeded)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("handler context was not canceled")
+		}
+	})
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := New[int](10, zap.NewNop())
+	var c collector
+	sub, err := bus.Subscribe("c", c.handle)
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(t.Context(), 1))
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	require.NoError(t, bus.Publish(t.Context(), 2))
+	require.NoError(t, bus.Close(t.Context()))
+
+	assert.Equal(t, []int{1}, c.got())
+}
+
+func TestBus_HandlerPanic(t *testing.T) {
+	bus := New[int](10, zap.NewNop())
+	var c collector
+	_, err := bus.Subscribe("panicky", func(ctx context.Context, v int) {
+		if v == 1 {
+			panic("boom")
+		}
+		c.handle(ctx, v)
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(t.Context(), 1))
+	require.NoError(t, bus.Publish(t.Context(), 2))
+	require.NoError(t, bus.Close(t.Context()))
+
+	assert.Equal(t, []int{2}, c.got())
+}
+
+func TestBus_ConcurrentUse(t *testing.T) {
+	bus := New[int](1000, zap.NewNop())
+	var c collector
+	_, err := bus.Subscribe("c", c.handle)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			for j := range 50 {
+				_ = bus.Publish(t.Context(), i*100+j)
+			}
+		})
+		wg.Go(func() {
+			sub, err := bus.Subscribe("short-lived", func(context.Context, int) {})
+			if err == nil {
+				sub.Unsubscribe()
+			}
+		})
+	}
+	wg.Wait()
+	require.NoError(t, bus.Close(t.Context()))
+
+	assert.Len(t, c.got(), 500)
+}
@@ -0,0 +1,77 @@
+// Package events defines domain events published by the service, their
+// JSON Schemas, and publishers that validate events against the schemas.
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types. Each has a schema in the schemas directory.
+const (
+	TypeSubscriptionCreated = "subscription.created"
+	TypeSubscriptionUpdated = "subscription.updated"
+	TypeSubscriptionDeleted = "subscription.deleted"
+)
+
+// Event is the envelope of a domain event.
+type Event struct {
+	ID         uuid.UUID `json:"id"`          // Unique event ID; consumers deduplicate by it.
+	Type       string    `json:"type"`        // Event type, e.g. "subscription.created".
+	OccurredAt time.Time `json:"occurred_at"` // When the change happened (UTC).
+	Data       any       `json:"data"`        // Type-specific payload.
+}
+
+// New creates an event of type t with a random ID.
+func New(t string, data any, occurredAt time.Time) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       t,
+		OccurredAt: occurredAt.UTC(),
+		Data:       data,
+	}
+}
+
+// SubscriptionDeleted is the data of a subscription.deleted event when only
+// the ID of the deleted subscription is known.
+type SubscriptionDeleted struct {
+	ID        int64     `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Publisher delivers events to consumers.
+type Publisher interface {
+	Publish(ctx context.Context, ev Event) error
+}
+
+// Discard drops events. It is used until a broker is configured.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(context.Context, Event) error { return nil }
+
+// validating checks events against the registry before publishing.
+type validating struct {
+	reg  *Registry
+	next Publisher
+}
+
+// Validating returns a Publisher that validates events against their
+// schemas in reg and passes only valid ones to next.
+func Validating(reg *Registry, next Publisher) Publisher {
+	return &validating{reg: reg, next: next}
+}
+
+func (v *validating) Publish(ctx context.Context, ev Event) error {
+	if err := v.reg.Validate(ev); err != nil {
+		return err
+	}
+	if err := v.next.Publish(ctx, ev); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", ev.Type, err)
+	}
+	return nil
+}
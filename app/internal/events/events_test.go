@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Validate(t *testing.T) {
+	reg, err := NewRegistry()
+	require.NoError(t, err)
+	assert.Equal(t, []string{TypeSubscriptionCreated, TypeSubscriptionDeleted, TypeSubscriptionUpdated}, reg.Types())
+
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	sub := map[string]any{
+		"id":           1,
+		"service_name": "Netflix",
+		"price":        799,
+		"user_id":      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		"start_date":   "07-2025",
+	}
+	with := func(k string, v any) map[string]any {
+		m := map[string]any{}
+		for k, v := range sub {
+			m[k] = v
+		}
+		if v == nil {
+			delete(m, k)
+		} else {
+			m[k] = v
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		ev      Event
+		wantErr string
+	}{
+		{name: "created", ev: New(TypeSubscriptionCreated, sub, now)},
+		{name: "updated with end date", ev: New(TypeSubscriptionUpdated, with("end_date", "12-2025"), now)},
+		{name: "deleted by id", ev: New(TypeSubscriptionDeleted, SubscriptionDeleted{ID: 1, DeletedAt: now}, now)},
+		{name: "missing field", ev: New(TypeSubscriptionCreated, with("service_name", nil), now),
+			wantErr: `$.data: missing required property "service_name"`},
+		{name: "extra field", ev: New(TypeSubscriptionCreated, with("discount", 10), now),
+			wantErr: `$.data: unexpected property "discount"`},
+		{name: "bad month", ev: New(TypeSubscriptionCreated, with("start_date", "2025-07"), now),
+			wantErr: "$.data.start_date: must match"},
+		{name: "negative price", ev: New(TypeSubscriptionCreated, with("price", -1), now),
+			wantErr: "$.data.price: must be >= 0"},
+		{name: "fractional id", ev: New(TypeSubscriptionCreated, with("id", 1.5), now),
+			wantErr: "$.data.id: expected integer, got number"},
+		{name: "unknown type", ev: New("subscription.paused", sub, now), wantErr: "unknown event type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := reg.Validate(tt.ev)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+type countingPublisher int
+
+func (p *countingPublisher) Publish(context.Context, Event) error {
+	*p++
+	return nil
+}
+
+func TestValidating(t *testing.T) {
+	reg, err := NewRegistry()
+	require.NoError(t, err)
+
+	var next countingPublisher
+	pub := Validating(reg, &next)
+
+	err = pub.Publish(t.Context(), New(TypeSubscriptionDeleted, map[string]any{"id": 1}, time.Now()))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.Zero(t, next)
+
+	require.NoError(t, pub.Publish(t.Context(), New(TypeSubscriptionDeleted, SubscriptionDeleted{ID: 1, DeletedAt: time.Now()}, time.Now())))
+	assert.Equal(t, countingPublisher(1), next)
+}
+
+func TestRegistry_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg, err := NewRegistry()
+	require.NoError(t, err)
+	e := gin.New()
+	e.Use(apierr.Middleware())
+	reg.RegisterRoutes(e)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []SchemaRef `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Contains(t, list.Data, SchemaRef{Type: TypeSubscriptionCreated, URL: "/schemas/subscription.created"})
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas/subscription.created", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/schema+json", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas/nope", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,132 @@
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// ErrUnknownType is returned when validating an event without a schema.
+var ErrUnknownType = errors.New("unknown event type")
+
+// ErrInvalidEvent is returned when an event does not match its schema.
+var ErrInvalidEvent = errors.New("event does not match its schema")
+
+// Registry holds event schemas by event type.
+type Registry struct {
+	raw     map[string]json.RawMessage
+	schemas map[string]*Schema
+}
+
+// NewRegistry loads the built-in event schemas.
+func NewRegistry() (*Registry, error) {
+	files, err := fs.Glob(schemaFiles, "schemas/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		raw:     make(map[string]json.RawMessage, len(files)),
+		schemas: make(map[string]*Schema, len(files)),
+	}
+	for _, name := range files {
+		b, err := schemaFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var s Schema
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := s.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		t := strings.TrimSuffix(path.Base(name), ".json")
+		r.raw[t] = b
+		r.schemas[t] = &s
+	}
+	return r, nil
+}
+
+// Types returns the event types with schemas, sorted.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.raw))
+	for t := range r.raw {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// Schema returns the JSON Schema document of the event type.
+func (r *Registry) Schema(t string) (json.RawMessage, bool) {
+	b, ok := r.raw[t]
+	return b, ok
+}
+
+// Validate checks ev against the schema of its type.
+func (r *Registry) Validate(ev Event) error {
+	s, ok := r.schemas[ev.Type]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, ev.Type)
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", ev.Type, err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", ev.Type, err)
+	}
+
+	if err := s.Validate(v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidEvent, ev.Type, err)
+	}
+	return nil
+}
+
+// SchemaRef describes a schema in the GET /schemas listing.
+type SchemaRef struct {
+	Type string `json:"type"` // Event type.
+	URL  string `json:"url"`  // Path of the schema document.
+}
+
+// RegisterRoutes registers GET /schemas, listing event types, and
+// GET /schemas/:type, returning the JSON Schema of an event type.
+func (r *Registry) RegisterRoutes(e gin.IRoutes) {
+	e.GET("/schemas", r.list)
+	e.GET("/schemas/:type", r.get)
+}
+
+func (r *Registry) list(c *gin.Context) {
+	refs := make([]SchemaRef, 0, len(r.raw))
+	for _, t := range r.Types() {
+		refs = append(refs, SchemaRef{Type: t, URL: "/schemas/" + t})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": refs})
+}
+
+func (r *Registry) get(c *gin.Context) {
+	b, ok := r.Schema(c.Param("type"))
+	if !ok {
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "schema not found")
+		return
+	}
+	c.Data(http.StatusOK, "application/schema+json", b)
+}
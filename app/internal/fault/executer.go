@@ -0,0 +1,80 @@
+package fault
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbError is returned by failed database calls. connection_failure is
+// retryable, so it exercises the repository retrier.
+func dbError() error {
+	return &pgconn.PgError{Severity: "ERROR", Code: "08006", Message: "injected fault"}
+}
+
+// Executer is a repository.Executer injecting the DB faults of the request,
+// or the injector's defaults outside requests.
+type Executer struct {
+	next repository.Executer
+	inj  *Injector
+}
+
+// NewExecuter wraps next, usually a *pgxpool.Pool.
+func NewExecuter(next repository.Executer, inj *Injector) *Executer {
+	return &Executer{next: next, inj: inj}
+}
+
+func (e *Executer) inject(ctx context.Context) error {
+	f, ok := FromContext(ctx)
+	if !ok {
+		f = e.inj.Defaults()
+	}
+	fail, err := e.inj.Inject(ctx, f.DB)
+	if err != nil {
+		return err
+	}
+	if fail {
+		return dbError()
+	}
+	return nil
+}
+
+// Query implements repository.Executer.
+func (e *Executer) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := e.inject(ctx); err != nil {
+		return nil, err
+	}
+	return e.next.Query(ctx, sql, args...)
+}
+
+// QueryRow implements repository.Executer.
+func (e *Executer) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := e.inject(ctx); err != nil {
+		return errRow{err}
+	}
+	return e.next.QueryRow(ctx, sql, args...)
+}
+
+// Exec implements repository.Executer.
+func (e *Executer) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := e.inject(ctx); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return e.next.Exec(ctx, sql, args...)
+}
+
+// CopyFrom implements repository.Executer.
+func (e *Executer) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	if err := e.inject(ctx); err != nil {
+		return 0, err
+	}
+	return e.next.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// errRow is a pgx.Row failing with err.
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
@@ -0,0 +1,131 @@
+// Package fault injects latency and errors into HTTP responses and database
+// calls, so client retries and the service's own retry wiring can be tested.
+// It is for test environments only and is off unless faults.enabled is set.
+//
+// Faults come from configuration or, if allowed, from request headers, which
+// are carried to database calls through the request context.
+package fault
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Request headers overriding the configured faults of one request.
+const (
+	HeaderLatency     = "Fault-Latency"       // HTTP response latency, e.g. 200ms
+	HeaderErrorRate   = "Fault-Error-Rate"    // Share of failed responses, 0..1
+	HeaderStatus      = "Fault-Status"        // Status of failed responses, e.g. 503
+	HeaderDBLatency   = "Fault-DB-Latency"    // Latency of every database call
+	HeaderDBErrorRate = "Fault-DB-Error-Rate" // Share of failed database calls, 0..1
+	HeaderInjected    = "Fault-Injected"      // Response header listing injected faults
+)
+
+// ErrInvalidHeader is returned by Parse for malformed fault headers.
+var ErrInvalidHeader = errors.New("invalid fault header")
+
+// Spec describes faults of one kind of call.
+type Spec struct {
+	Latency   time.Duration // Added before the call
+	ErrorRate float64       // Probability of failing the call, 0..1
+}
+
+// Faults describes faults of a request.
+type Faults struct {
+	HTTP   Spec
+	Status int // Status of failed HTTP responses
+	DB     Spec
+}
+
+// Parse overrides f with the fault headers returned by header.
+func (f Faults) Parse(header func(string) string) (Faults, error) {
+	var errs []error
+	duration := func(name string, dst *time.Duration) {
+		if v := header(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				errs = append(errs, errors.New(name))
+				return
+			}
+			*dst = d
+		}
+	}
+	rate := func(name string, dst *float64) {
+		if v := header(name); v != "" {
+			r, err := strconv.ParseFloat(v, 64)
+			if err != nil || r < 0 || r > 1 {
+				errs = append(errs, errors.New(name))
+				return
+			}
+			*dst = r
+		}
+	}
+
+	duration(HeaderLatency, &f.HTTP.Latency)
+	rate(HeaderErrorRate, &f.HTTP.ErrorRate)
+	if v := header(HeaderStatus); v != "" {
+		s, err := strconv.Atoi(v)
+		if err != nil || s < 400 || s > 599 {
+			errs = append(errs, errors.New(HeaderStatus))
+		} else {
+			f.Status = s
+		}
+	}
+	duration(HeaderDBLatency, &f.DB.Latency)
+	rate(HeaderDBErrorRate, &f.DB.ErrorRate)
+
+	if err := errors.Join(errs...); err != nil {
+		return f, errors.Join(ErrInvalidHeader, err)
+	}
+	return f, nil
+}
+
+// Injector injects configured faults.
+type Injector struct {
+	defaults Faults
+	headers  bool
+	rand     func() float64
+}
+
+// New creates an Injector applying defaults to every request. With
+// allowHeaders, requests may override them with fault headers.
+func New(defaults Faults, allowHeaders bool) *Injector {
+	return &Injector{defaults: defaults, headers: allowHeaders, rand: rand.Float64}
+}
+
+// Defaults returns the faults applied to requests without fault headers.
+func (i *Injector) Defaults() Faults { return i.defaults }
+
+// AllowHeaders reports whether requests may override the defaults.
+func (i *Injector) AllowHeaders() bool { return i.headers }
+
+// Inject waits for s.Latency and reports whether the call must fail.
+// It returns ctx.Err() if ctx is done while waiting.
+func (i *Injector) Inject(ctx context.Context, s Spec) (bool, error) {
+	if s.Latency > 0 {
+		t := time.NewTimer(s.Latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return s.ErrorRate > 0 && i.rand() < s.ErrorRate, nil
+}
+
+type ctxKey struct{}
+
+// WithFaults returns a copy of ctx carrying the faults of its request.
+func WithFaults(ctx context.Context, f Faults) context.Context {
+	return context.WithValue(ctx, ctxKey{}, f)
+}
+
+// FromContext returns the faults carried by ctx.
+func FromContext(ctx context.Context) (Faults, bool) {
+	f, ok := ctx.Value(ctxKey{}).(Faults)
+	return f, ok
+}
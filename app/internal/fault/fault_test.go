@@ -0,0 +1,107 @@
+package fault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaults_Parse(t *testing.T) {
+	defaults := Faults{HTTP: Spec{Latency: time.Second}, DB: Spec{ErrorRate: 0.5}}
+	headers := map[string]string{
+		HeaderErrorRate:   "0.25",
+		HeaderStatus:      "502",
+		HeaderDBLatency:   "100ms",
+		HeaderDBErrorRate: "0",
+	}
+
+	got, err := defaults.Parse(func(name string) string { return headers[name] })
+	require.NoError(t, err)
+	assert.Equal(t, Faults{
+		HTTP:   Spec{Latency: time.Second, ErrorRate: 0.25},
+		Status: 502,
+		DB:     Spec{Latency: 100 * time.Millisecond},
+	}, got)
+
+	for name, value := range map[string]string{
+		HeaderLatency:     "-1s",
+		HeaderErrorRate:   "1.5",
+		HeaderStatus:      "200",
+		HeaderDBLatency:   "soon",
+		HeaderDBErrorRate: "half",
+	} {
+		_, err := Faults{}.Parse(func(h string) string {
+			if h == name {
+				return value
+			}
+			return ""
+		})
+		assert.ErrorIs(t, err, ErrInvalidHeader, name)
+	}
+}
+
+func TestInjector_Inject(t *testing.T) {
+	inj := New(Faults{}, false)
+	inj.rand = func() float64 { return 0.3 }
+
+	fail, err := inj.Inject(t.Context(), Spec{ErrorRate: 0.5})
+	require.NoError(t, err)
+	assert.True(t, fail)
+
+	fail, err = inj.Inject(t.Context(), Spec{ErrorRate: 0.2})
+	require.NoError(t, err)
+	assert.False(t, fail)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = inj.Inject(ctx, Spec{Latency: time.Hour})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// okExecuter succeeds without a database.
+type okExecuter struct{ calls int }
+
+func (e *okExecuter) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	e.calls++
+	return nil, nil
+}
+
+func (e *okExecuter) QueryRow(context.Context, string, ...any) pgx.Row {
+	e.calls++
+	return nil
+}
+
+func (e *okExecuter) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	e.calls++
+	return pgconn.CommandTag{}, nil
+}
+
+func (e *okExecuter) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	e.calls++
+	return 0, nil
+}
+
+func TestExecuter(t *testing.T) {
+	next := &okExecuter{}
+	exec := NewExecuter(next, New(Faults{DB: Spec{ErrorRate: 1}}, false))
+
+	_, err := exec.Exec(t.Context(), "SELECT 1")
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "08006", pgErr.Code)
+	assert.Error(t, exec.QueryRow(t.Context(), "SELECT 1").Scan())
+	assert.Zero(t, next.calls)
+
+	// Faults of the request replace the defaults.
+	ctx := WithFaults(t.Context(), Faults{})
+	_, err = exec.Exec(ctx, "SELECT 1")
+	require.NoError(t, err)
+	_, err = exec.Query(ctx, "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+}
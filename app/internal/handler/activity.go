@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WithActivity включает ленту действий пользователя: маршрут
+// /users/:user_id/activity
+func WithActivity(srv *service.ActivityService) Option {
+	return func(h *SubscriptionHandler) {
+		h.activity = srv
+	}
+}
+
+// Activity godoc
+// @Summary Лента действий пользователя
+// @Description Возвращает изменения подписок пользователя из аудит-лога, начиная с новых: создание, изменение цены, установку даты окончания, другие изменения и удаление.
+// @Description kind — created, price_changed, ended, updated или cancelled; message — краткое описание, например "cancelled Spotify"; details — записанные данные, у изменений — changes с from и to по полям.
+// @Description Лента ведется с момента включения аудита в БД, более ранних действий в ней нет
+// @Tags users
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param limit query int false "Размер страницы"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} map[string]interface{} "data: действия пользователя, limit, offset"
+// @Failure 400 {object} map[string]string "Некорректный user_id или limit больше допустимого"
+// @Failure 403 {object} map[string]string "Лента другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/activity [get]
+func (h *SubscriptionHandler) Activity(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+		return
+	}
+	limit, offset, ok := h.page(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.activity.Feed(c.Request.Context(), userID, limit, offset)
+	if errors.Is(err, service.ErrForbidden) {
+		apierr.Abort(c, http.StatusForbidden, apierr.CodeForbidden, "activity of other users is not accessible")
+		return
+	}
+	if err != nil {
+		abortWithServiceError(c, err, "failed to list activity")
+		return
+	}
+
+	renderJSON(c, http.StatusOK, gin.H{
+		"data":   items,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
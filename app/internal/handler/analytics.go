@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AnalyticsHandler отвечает за аналитические отчеты
+type AnalyticsHandler struct {
+	service *service.AnalyticsService
+	log     *zap.Logger
+}
+
+func NewAnalyticsHandler(srv *service.AnalyticsService, log *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{service: srv, log: log}
+}
+
+// RegisterRoutes регистрирует маршруты отчетов в группе rg (/analytics)
+func (h *AnalyticsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/retention", h.Retention)
+}
+
+// Retention godoc
+// @Summary Удержание подписок по когортам
+// @Description Группирует подписки по месяцу начала и показывает, сколько из них были активны через 1, 3, 6 и 12 месяцев.
+// @Description Еще не наступившие месяцы не возвращаются. Требуется заголовок Authorization: Bearer <admin.token>.
+// @Tags analytics
+// @Produce json
+// @Param from query string false "Первая когорта (MM-YYYY)"
+// @Param to query string false "Последняя когорта (MM-YYYY)"
+// @Success 200 {object} map[string][]models.RetentionCohort "data: когорты"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 401 {object} map[string]string "Неверный токен"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /analytics/retention [get]
+func (h *AnalyticsHandler) Retention(c *gin.Context) {
+	var req models.RetentionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid query parameters")
+		return
+	}
+	cohorts, err := h.service.Retention(c.Request.Context(), &req)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to calculate retention")
+		return
+	}
+	if cohorts == nil {
+		cohorts = []models.RetentionCohort{}
+	}
+
+	renderJSON(c, http.StatusOK, gin.H{"data": cohorts})
+}
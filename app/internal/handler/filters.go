@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WithSavedFilters включает сохраненные фильтры пользователей: маршруты
+// /users/{user_id}/filters и параметр filter_id списка и суммы подписок
+func WithSavedFilters(srv *service.SavedFilterService) Option {
+	return func(h *SubscriptionHandler) {
+		h.filters = srv
+	}
+}
+
+// registerFilterRoutes регистрирует маршруты сохраненных фильтров в группе
+// rg (/users)
+func (h *SubscriptionHandler) registerFilterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/:user_id/filters", h.CreateFilter)
+	rg.GET("/:user_id/filters", h.ListFilters)
+	rg.GET("/:user_id/filters/:filter_id", h.GetFilter)
+	rg.PUT("/:user_id/filters/:filter_id", h.UpdateFilter)
+	rg.DELETE("/:user_id/filters/:filter_id", h.DeleteFilter)
+}
+
+// CreateFilter godoc
+// @Summary Сохранить фильтр
+// @Description Сохраняет именованный набор фильтров пользователя; его id передается как filter_id в GET /subscriptions/ и запросы суммы.
+// @Tags filters
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param filter body models.SavedFilter true "Название и фильтры (id, user_id и даты игнорируются)"
+// @Success 201 {object} models.SavedFilter "Фильтр сохранен"
+// @Header 201 {string} Location "URL фильтра"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "Фильтры другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 409 {object} map[string]string "У пользователя уже есть фильтр с таким названием"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/filters [post]
+func (h *SubscriptionHandler) CreateFilter(c *gin.Context) {
+	userID, ok := filterUserID(c)
+	if !ok {
+		return
+	}
+	f, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+	f.UserID = userID
+
+	if err := h.filters.Create(c.Request.Context(), f); err != nil {
+		abortWithFilterError(c, err, "failed to save filter")
+		return
+	}
+
+	c.Header("Location", filterURL(f))
+	renderJSON(c, http.StatusCreated, f)
+}
+
+// ListFilters godoc
+// @Summary Сохраненные фильтры пользователя
+// @Description Возвращает сохраненные фильтры пользователя, упорядоченные по названию
+// @Tags filters
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 200 {object} map[string][]models.SavedFilter "data: фильтры"
+// @Failure 400 {object} map[string]string "Некорректный user_id"
+// @Failure 403 {object} map[string]string "Фильтры другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/filters [get]
+func (h *SubscriptionHandler) ListFilters(c *gin.Context) {
+	userID, ok := filterUserID(c)
+	if !ok {
+		return
+	}
+
+	filters, err := h.filters.List(c.Request.Context(), userID)
+	if err != nil {
+		abortWithFilterError(c, err, "failed to list saved filters")
+		return
+	}
+	if filters == nil {
+		filters = []models.SavedFilter{}
+	}
+
+	renderJSON(c, http.StatusOK, gin.H{"data": filters})
+}
+
+// GetFilter godoc
+// @Summary Получить сохраненный фильтр
+// @Description Возвращает сохраненный фильтр пользователя по ID
+// @Tags filters
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param filter_id path int true "ID фильтра"
+// @Success 200 {object} models.SavedFilter "Найден"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} map[string]string "Фильтры другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 404 {object} map[string]string "Не найден"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/filters/{filter_id} [get]
+func (h *SubscriptionHandler) GetFilter(c *gin.Context) {
+	userID, id, ok := filterIDs(c)
+	if !ok {
+		return
+	}
+
+	f, err := h.filters.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		abortWithFilterError(c, err, "failed to get saved filter")
+		return
+	}
+
+	renderJSON(c, http.StatusOK, f)
+}
+
+// UpdateFilter godoc
+// @Summary Изменить сохраненный фильтр
+// @Description Заменяет название и фильтры сохраненного фильтра пользователя
+// @Tags filters
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param filter_id path int true "ID фильтра"
+// @Param filter body models.SavedFilter true "Название и фильтры (id, user_id и даты игнорируются)"
+// @Success 200 {object} models.SavedFilter "Фильтр изменен"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "Фильтры другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 404 {object} map[string]string "Не найден"
+// @Failure 409 {object} map[string]string "У пользователя уже есть фильтр с таким названием"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/filters/{filter_id} [put]
+func (h *SubscriptionHandler) UpdateFilter(c *gin.Context) {
+	userID, id, ok := filterIDs(c)
+	if !ok {
+		return
+	}
+	f, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+	f.ID, f.UserID = id, userID
+
+	if err := h.filters.Update(c.Request.Context(), f); err != nil {
+		abortWithFilterError(c, err, "failed to update saved filter")
+		return
+	}
+
+	renderJSON(c, http.StatusOK, f)
+}
+
+// DeleteFilter godoc
+// @Summary Удалить сохраненный фильтр
+// @Description Удаляет сохраненный фильтр пользователя
+// @Tags filters
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param filter_id path int true "ID фильтра"
+// @Success 204 "Фильтр удален"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} map[string]string "Фильтры другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 404 {object} map[string]string "Не найден"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/filters/{filter_id} [delete]
+func (h *SubscriptionHandler) DeleteFilter(c *gin.Context) {
+	userID, id, ok := filterIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.filters.Delete(c.Request.Context(), userID, id); err != nil {
+		abortWithFilterError(c, err, "failed to delete saved filter")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// savedFilter возвращает критерии фильтра из параметра filter_id; без
+// параметра — пустые критерии. При ошибке отвечает и возвращает ok = false
+func (h *SubscriptionHandler) savedFilter(c *gin.Context) (criteria models.FilterCriteria, ok bool) {
+	raw := c.Query("filter_id")
+	if raw == "" {
+		return models.FilterCriteria{}, true
+	}
+	if h.filters == nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "saved filters are not enabled")
+		return models.FilterCriteria{}, false
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid filter_id")
+		return models.FilterCriteria{}, false
+	}
+
+	criteria, err = h.filters.Resolve(c.Request.Context(), id)
+	if err != nil {
+		abortWithFilterError(c, err, "failed to load saved filter")
+		return models.FilterCriteria{}, false
+	}
+	return criteria, true
+}
+
+// filterURL возвращает URL сохраненного фильтра
+func filterURL(f *models.SavedFilter) string {
+	return "/users/" + f.UserID.String() + "/filters/" + strconv.FormatInt(f.ID, 10)
+}
+
+// filterUserID читает user_id из пути; при ошибке отвечает 400
+func filterUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// filterIDs читает user_id и filter_id из пути; при ошибке отвечает 400
+func filterIDs(c *gin.Context) (userID uuid.UUID, id int64, ok bool) {
+	if userID, ok = filterUserID(c); !ok {
+		return uuid.Nil, 0, false
+	}
+	id, err := strconv.ParseInt(c.Param("filter_id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid filter_id")
+		return uuid.Nil, 0, false
+	}
+	return userID, id, true
+}
+
+// bindFilter читает и валидирует тело с названием и фильтрами
+func bindFilter(c *gin.Context) (*models.SavedFilter, bool) {
+	var req models.SavedFilter
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
+		return nil, false
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return nil, false
+	}
+	return &models.SavedFilter{Name: req.Name, Filter: req.Filter}, true
+}
+
+// abortWithFilterError преобразует ошибку сервиса сохраненных фильтров в
+// ошибку API
+func abortWithFilterError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		apierr.Abort(c, http.StatusForbidden, apierr.CodeForbidden, "saved filters of other users are not accessible")
+	case errors.Is(err, service.ErrSavedFilterNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "saved filter not found")
+	case errors.Is(err, service.ErrSavedFilterExists):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "saved filter with this name already exists")
+	default:
+		abortWithServiceError(c, err, detail)
+	}
+}
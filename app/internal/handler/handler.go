@@ -1,35 +1,131 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
 	"subscriptionsservice/internal/models"
 	"subscriptionsservice/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 // SubscriptionHandler отвечает за обработку HTTP-запросов подписок
 type SubscriptionHandler struct {
-	service *service.SubscriptionService
-	log     *zap.Logger
+	service     *service.SubscriptionService
+	filters     *service.SavedFilterService
+	summarySubs *service.SummarySubscriptionService
+	activity    *service.ActivityService
+	log         *zap.Logger
+
+	defaultPageSize int
+	maxPageSize     int
+	middleware      []gin.HandlerFunc
+}
+
+// Option настраивает SubscriptionHandler
+type Option func(*SubscriptionHandler)
+
+// WithPageSize задает размер страницы по умолчанию и максимально допустимый limit
+func WithPageSize(defaultSize, maxSize int) Option {
+	return func(h *SubscriptionHandler) {
+		if defaultSize > 0 {
+			h.defaultPageSize = defaultSize
+		}
+		if maxSize > 0 {
+			h.maxPageSize = maxSize
+		}
+	}
+}
+
+// WithMiddleware добавляет middleware к маршрутам подписок, например
+// идентификацию пользователя
+func WithMiddleware(mw ...gin.HandlerFunc) Option {
+	return func(h *SubscriptionHandler) {
+		h.middleware = append(h.middleware, mw...)
+	}
+}
+
+func NewSubscriptionHandler(srv *service.SubscriptionService, log *zap.Logger, opts ...Option) *SubscriptionHandler {
+	h := &SubscriptionHandler{
+		service:         srv,
+		log:             log,
+		defaultPageSize: 10,
+		maxPageSize:     100,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.defaultPageSize > h.maxPageSize {
+		h.defaultPageSize = h.maxPageSize
+	}
+	return h
+}
+
+// SubscriptionResource — подписка со ссылками на связанные ресурсы
+type SubscriptionResource struct {
+	models.Subscription
+	Links ResourceLinks `json:"links"`
+}
+
+// ResourceLinks содержит ссылки ресурса
+type ResourceLinks struct {
+	Self string `json:"self" example:"/subscriptions/1"` // URL самого ресурса
 }
 
-func NewSubscriptionHandler(srv *service.SubscriptionService, log *zap.Logger) *SubscriptionHandler {
-	return &SubscriptionHandler{service: srv, log: log}
+// subscriptionURL возвращает URL подписки
+func subscriptionURL(id int64) string {
+	return "/subscriptions/" + strconv.FormatInt(id, 10)
 }
 
 // RegisterRoutes регистрирует маршруты
 func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
-	g := r.Group("/subscriptions")
+	g := r.Group("/subscriptions", h.middleware...)
 
 	g.POST("/", h.CreateSubscription)
 	g.GET("/", h.List)
+	g.OPTIONS("/", allow(http.MethodGet, http.MethodPost))
+	g.GET("/active", h.ActiveOn)
+	g.GET("/services", h.Services)
 	g.GET("/:id", h.GetByID)
+	g.HEAD("/:id", h.Exists)
 	g.PUT("/:id", h.Update)
+	g.PATCH("/:id", h.Patch)
 	g.DELETE("/:id", h.Delete)
+	g.OPTIONS("/:id", allow(http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete))
+	g.GET("/summary", h.SummaryQuery)
 	g.POST("/summary", h.Summary)
+	g.OPTIONS("/summary", allow(http.MethodGet, http.MethodPost))
+
+	users := r.Group("/users", h.middleware...)
+	users.GET("/:user_id/subscriptions/overlaps", h.Overlaps)
+	if h.activity != nil {
+		users.GET("/:user_id/activity", h.Activity)
+	}
+	if h.filters != nil {
+		h.registerFilterRoutes(users)
+	}
+	if h.summarySubs != nil {
+		h.registerSummarySubscriptionRoutes(r)
+	}
+}
+
+// allow отвечает на OPTIONS списком разрешенных методов ресурса
+func allow(methods ...string) gin.HandlerFunc {
+	value := strings.Join(append(methods, http.MethodOptions), ", ")
+	return func(c *gin.Context) {
+		c.Header("Allow", value)
+		c.Status(http.StatusNoContent)
+	}
 }
 
 // CreateSubscription godoc
@@ -39,87 +135,430 @@ func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
 // @Accept json
 // @Produce json
 // @Param subscription body models.Subscription true "Данные подписки"
-// @Success 201 {object} models.Subscription "Успешное создание"
+// @Param Prefer header string false "return=existing — вернуть существующую подписку вместо ошибки 409"
+// @Param on_conflict query string false "ignore — то же, что Prefer: return=existing" Enums(ignore)
+// @Success 200 {object} SubscriptionResource "Подписка уже существует (Prefer: return=existing)"
+// @Success 201 {object} SubscriptionResource "Успешное создание"
+// @Header 200,201 {string} Location "URL подписки"
 // @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "Подписка другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 409 {object} map[string]interface{} "Подписка с таким user_id, service_name и start_date уже существует; existing_id — ее ID"
+// @Failure 422 {object} map[string]string "Превышен лимит активных подписок пользователя, пользователь не создан (users.require_provisioned) или неизвестен сервису аккаунтов"
+// @Failure 429 {object} map[string]string "Превышена квота записи пользователя"
 // @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Failure 503 {object} map[string]string "Сервис аккаунтов недоступен"
 // @Router /subscriptions/ [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	var sub models.Subscription
 	if err := c.ShouldBindJSON(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	if err := models.Validate(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortValidation(c, err)
 		return
 	}
 
-	if err := h.service.CreateSubscription(c.Request.Context(), &sub); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	status := http.StatusCreated
+	if preferReturnExisting(c) {
+		created, err := h.service.CreateOrGet(c.Request.Context(), &sub)
+		if err != nil {
+			abortWithServiceError(c, err, "failed to create subscription")
+			return
+		}
+		if !created {
+			status = http.StatusOK
+			c.Header("Preference-Applied", "return=existing")
+		}
+	} else if err := h.service.CreateSubscription(c.Request.Context(), &sub); err != nil {
+		abortWithServiceError(c, err, "failed to create subscription")
 		return
 	}
 
-	c.JSON(http.StatusCreated, sub)
+	self := subscriptionURL(sub.ID)
+	c.Header("Location", self)
+	renderJSON(c, status, SubscriptionResource{
+		Subscription: sub,
+		Links:        ResourceLinks{Self: self},
+	})
+}
+
+// renderJSON отвечает obj в JSON; месяцы кодируются в формате из заголовка
+// Date-Format, если он передан, иначе в формате по умолчанию
+func renderJSON(c *gin.Context, status int, obj any) {
+	if f, ok := models.DateFormatFromContext(c.Request.Context()); ok {
+		obj = models.FormatDates(obj, f)
+	}
+	c.JSON(status, obj)
+}
+
+// abortWithServiceError преобразует ошибку сервиса в ошибку API;
+// detail используется для непредвиденных ошибок
+func abortWithServiceError(c *gin.Context, err error, detail string) {
+	var (
+		quotaErr      *service.QuotaError
+		periodErr     *service.PeriodError
+		priceErr      *service.PriceChangeError
+		rejectedErr   *service.RejectedError
+		conflictErr   *service.ConflictError
+		validationErr validator.ValidationErrors
+	)
+	switch {
+	case errors.As(err, &quotaErr):
+		retryAfter := int(math.Ceil(time.Until(quotaErr.ResetAt).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		apierr.Abortf(c, http.StatusTooManyRequests, apierr.CodeQuotaExceeded,
+			"write quota of %d per hour exceeded", quotaErr.Limit)
+	case errors.As(err, &periodErr):
+		apierr.Abortf(c, http.StatusBadRequest, apierr.CodeValidationFailed,
+			"%s must not be before %s", periodErr.End, periodErr.Start)
+	case errors.As(err, &priceErr):
+		apierr.Abortf(c, http.StatusUnprocessableEntity, apierr.CodePriceChangeTooLarge,
+			"price change from %d to %d exceeds %g%%, repeat with allow_price_change=true to confirm",
+			priceErr.From, priceErr.To, priceErr.MaxPercent)
+	case errors.Is(err, service.ErrAdminRequired):
+		apierr.Abort(c, http.StatusForbidden, apierr.CodeForbidden, "available to admins only")
+	case errors.Is(err, service.ErrForbidden):
+		apierr.Abort(c, http.StatusForbidden, apierr.CodeForbidden, "subscriptions of other users are not accessible")
+	case errors.Is(err, service.ErrSubscriptionNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "subscription not found")
+	case errors.As(err, &conflictErr):
+		apierr.AbortWithError(c, &apierr.Error{
+			Status:     http.StatusConflict,
+			Code:       apierr.CodeConflict,
+			Detail:     "subscription already exists",
+			Err:        err,
+			Extensions: map[string]any{"existing_id": conflictErr.ExistingID},
+		})
+	case errors.Is(err, service.ErrSubscriptionExists):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "subscription already exists")
+	case errors.Is(err, context.DeadlineExceeded):
+		apierr.Abort(c, http.StatusGatewayTimeout, apierr.CodeTimeout, "request timed out")
+	case errors.Is(err, service.ErrUserNotProvisioned):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CodeValidationFailed, "user is not provisioned, create it via POST /users")
+	case errors.Is(err, service.ErrUnknownUser):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CodeValidationFailed, "user does not exist")
+	case errors.Is(err, service.ErrUserCheckFailed):
+		c.Header("Retry-After", "5")
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusServiceUnavailable,
+			Code:   apierr.CodeUpstreamUnavailable,
+			Detail: "failed to check that the user exists, try again later",
+			Err:    err,
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "user not found")
+	case errors.Is(err, service.ErrUserHasSubscriptions):
+		apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "user has subscriptions, delete them first")
+	case errors.Is(err, service.ErrLimitExceeded):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CodeLimitExceeded, "active subscription limit exceeded for the user")
+	case errors.Is(err, service.ErrMixedCurrencies):
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeValidationFailed, "subscriptions have different currencies, filter by currency")
+	case errors.As(err, &rejectedErr):
+		apierr.Abortf(c, http.StatusUnprocessableEntity, apierr.CodeRejected, "request rejected: %s", rejectedErr.Reason)
+	case errors.As(err, &validationErr):
+		abortValidation(c, err)
+	case errors.Is(err, service.ErrReadOnly):
+		c.Header("Retry-After", "60")
+		apierr.Abort(c, http.StatusServiceUnavailable, apierr.CodeReadOnly, "service is in read-only mode, try again later")
+	default:
+		apierr.AbortWithError(c, &apierr.Error{
+			Status: http.StatusInternalServerError,
+			Code:   apierr.CodeInternal,
+			Detail: detail,
+			Err:    err,
+		})
+	}
+}
+
+// abortValidation отвечает 400 с ошибкой валидации; err сохраняется,
+// чтобы сообщения о полях можно было перевести
+func abortValidation(c *gin.Context, err error) {
+	apierr.AbortWithError(c, &apierr.Error{
+		Status: http.StatusBadRequest,
+		Code:   apierr.CodeValidationFailed,
+		Detail: err.Error(),
+		Err:    err,
+	})
+}
+
+// preferReturnExisting сообщает, попросил ли клиент вернуть существующую
+// подписку при конфликте (Prefer: return=existing или on_conflict=ignore)
+func preferReturnExisting(c *gin.Context) bool {
+	return c.Query("on_conflict") == "ignore" || hasPreference(c, "return=existing")
+}
+
+// preferReturnRepresentation сообщает, попросил ли клиент вернуть тело
+// удаленной подписки (Prefer: return=representation или ?return=representation)
+func preferReturnRepresentation(c *gin.Context) bool {
+	return c.Query("return") == "representation" || hasPreference(c, "return=representation")
+}
+
+// hasPreference сообщает, передана ли pref в заголовке Prefer
+func hasPreference(c *gin.Context, pref string) bool {
+	for _, header := range c.Request.Header.Values("Prefer") {
+		for _, p := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(p), pref) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // List godoc
 // @Summary Получить список подписок
-// @Description Возвращает список подписок с пагинацией
+// @Description Возвращает список подписок с пагинацией, с фильтрами по пользователю и сервису или сохраненного фильтра filter_id.
+// @Description При заданном auth.user_header пользователь видит только свои подписки, администратор — любые
 // @Tags subscriptions
 // @Produce json
-// @Param limit query int false "Количество элементов на странице (по умолчанию 10)"
+// @Param user_id query string false "Фильтр по пользователю (UUID)"
+// @Param service_name query string false "Фильтр по сервису"
+// @Param limit query int false "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)"
 // @Param offset query int false "Смещение (по умолчанию 0)"
+// @Param filter_id query int false "ID сохраненного фильтра вызывающего (см. /users/{user_id}/filters); user_id и service_name имеют приоритет"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
 // @Success 200 {object} map[string]interface{} "data: список подписок, limit, offset"
+// @Failure 400 {object} map[string]string "Превышен максимальный limit, некорректный user_id или filter_id"
+// @Failure 403 {object} map[string]string "user_id другого пользователя (при заданном auth.user_header)"
+// @Failure 404 {object} map[string]string "Сохраненный фильтр не найден"
 // @Failure 500 {object} map[string]string "Ошибка сервера"
 // @Router /subscriptions/ [get]
 func (h *SubscriptionHandler) List(c *gin.Context) {
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	limit, offset, ok := h.page(c)
+	if !ok {
+		return
+	}
+	criteria, ok := h.savedFilter(c)
+	if !ok {
+		return
+	}
+	filter, ok := listFilter(c, criteria)
+	if !ok {
+		return
+	}
+
+	subs, err := h.service.List(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to list subscriptions")
+		return
+	}
+
+	renderSubscriptionPage(c, subs, limit, offset)
+}
+
+// listFilter возвращает фильтр списка: критерии сохраненного фильтра,
+// переопределенные параметрами user_id и service_name. При некорректном
+// user_id отвечает 400 и возвращает ok = false
+func listFilter(c *gin.Context, criteria models.FilterCriteria) (filter models.SubscriptionFilter, ok bool) {
+	filter = criteria.SubscriptionFilter()
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+			return models.SubscriptionFilter{}, false
+		}
+		filter.UserID = &userID
+	}
+	if name := c.Query("service_name"); name != "" {
+		filter.ServiceName = &name
+	}
+	return filter, true
+}
+
+// page читает limit и offset из запроса; при превышении максимального
+// limit отвечает 400 и возвращает ok = false
+func (h *SubscriptionHandler) page(c *gin.Context) (limit, offset int, ok bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.defaultPageSize)))
 	if err != nil || limit < 1 {
-		limit = 10
+		limit = h.defaultPageSize
 	}
-	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > h.maxPageSize {
+		apierr.Abortf(c, http.StatusBadRequest, apierr.CodeInvalidRequest,
+			"limit must not exceed %d, use offset to fetch further pages", h.maxPageSize)
+		return 0, 0, false
+	}
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
 	if err != nil || offset < 0 {
 		offset = 0
 	}
+	return limit, offset, true
+}
+
+// ActiveOn godoc
+// @Summary Подписки, активные в месяце
+// @Description Возвращает подписки, период которых включает указанный месяц
+// @Tags subscriptions
+// @Produce json
+// @Param on query string true "Месяц (MM-YYYY)"
+// @Param user_id query string false "Фильтр по пользователю"
+// @Param service_name query string false "Фильтр по сервису"
+// @Param limit query int false "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)"
+// @Param offset query int false "Смещение (по умолчанию 0)"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
+// @Success 200 {object} map[string]interface{} "data: список подписок, limit, offset"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "user_id другого пользователя (при заданном auth.user_header)"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /subscriptions/active [get]
+func (h *SubscriptionHandler) ActiveOn(c *gin.Context) {
+	var req models.ActiveOnRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid query parameters")
+		return
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	limit, offset, ok := h.page(c)
+	if !ok {
+		return
+	}
+
+	subs, err := h.service.ActiveOn(c.Request.Context(), &req, limit, offset)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to list subscriptions")
+		return
+	}
+
+	renderSubscriptionPage(c, subs, limit, offset)
+}
+
+// Services godoc
+// @Summary Список сервисов
+// @Description Возвращает уникальные названия сервисов с числом подписок, начиная с самых популярных (для автодополнения). Результат может кэшироваться.
+// @Tags subscriptions
+// @Produce json
+// @Param prefix query string false "Начало названия сервиса, без учета регистра"
+// @Param limit query int false "Количество элементов на странице (по умолчанию app.default_page_size, не больше app.max_page_size)"
+// @Param offset query int false "Смещение (по умолчанию 0)"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
+// @Success 200 {object} map[string]interface{} "data: список сервисов (service_name, count), limit, offset"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /subscriptions/services [get]
+func (h *SubscriptionHandler) Services(c *gin.Context) {
+	var req models.ServicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid query parameters")
+		return
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	limit, offset, ok := h.page(c)
+	if !ok {
+		return
+	}
 
-	subs, err := h.service.List(c.Request.Context(), limit, offset)
+	services, err := h.service.DistinctServices(c.Request.Context(), req.Prefix, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list subscriptions"})
+		abortWithServiceError(c, err, "failed to list services")
 		return
 	}
+	if services == nil {
+		services = []models.ServiceCount{}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":   subs,
+	renderJSON(c, http.StatusOK, gin.H{
+		"data":   services,
 		"limit":  limit,
 		"offset": offset,
 	})
 }
 
+// Overlaps godoc
+// @Summary Пересекающиеся подписки пользователя
+// @Description Возвращает пары подписок пользователя на один сервис с пересекающимися периодами (вероятная двойная оплата)
+// @Tags subscriptions
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
+// @Success 200 {object} map[string][]models.Overlap "data: пары пересекающихся подписок"
+// @Failure 400 {object} map[string]string "Некорректный user_id"
+// @Failure 403 {object} map[string]string "Подписки другого пользователя (при заданном auth.user_header)"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id}/subscriptions/overlaps [get]
+func (h *SubscriptionHandler) Overlaps(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+		return
+	}
+
+	overlaps, err := h.service.Overlaps(c.Request.Context(), userID)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to detect overlapping subscriptions")
+		return
+	}
+	if overlaps == nil {
+		overlaps = []models.Overlap{}
+	}
+
+	renderJSON(c, http.StatusOK, gin.H{"data": overlaps})
+}
+
 // GetByID godoc
 // @Summary Получить подписку по ID
 // @Description Возвращает данные подписки по ID
 // @Tags subscriptions
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
 // @Success 200 {object} models.Subscription "Найдена"
 // @Failure 400 {object} map[string]string "Некорректный ID"
 // @Failure 404 {object} map[string]string "Не найдена"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
 		return
 	}
 
 	sub, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		abortWithServiceError(c, err, "failed to get subscription")
 		return
 	}
 
-	c.JSON(http.StatusOK, sub)
+	renderSubscription(c, http.StatusOK, sub)
+}
+
+// Exists godoc
+// @Summary Проверить существование подписки
+// @Description Возвращает 200, если подписка существует, без тела ответа
+// @Tags subscriptions
+// @Param id path int true "ID подписки"
+// @Success 200 "Существует"
+// @Failure 400 "Некорректный ID"
+// @Failure 404 "Не найдена"
+// @Failure 500 "Ошибка сервера"
+// @Router /subscriptions/{id} [head]
+func (h *SubscriptionHandler) Exists(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	exists, err := h.service.Exists(c.Request.Context(), id)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Status(http.StatusOK)
 }
 
 // Update godoc
@@ -130,55 +569,135 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 // @Produce json
 // @Param id path int true "ID подписки"
 // @Param subscription body models.Subscription true "Обновленные данные подписки"
+// @Param allow_price_change query bool false "Подтвердить изменение цены больше limits.max_price_change_percent"
 // @Success 200 {object} models.Subscription "Обновлено"
 // @Failure 400 {object} map[string]string "Некорректные данные"
+// @Failure 403 {object} map[string]string "Перенос подписки другому пользователю"
+// @Failure 404 {object} map[string]string "Не найдена"
+// @Failure 422 {object} map[string]string "Изменение цены больше допустимого без allow_price_change, пользователь не создан (users.require_provisioned) или неизвестен сервису аккаунтов"
+// @Failure 429 {object} map[string]string "Превышена квота записи пользователя"
 // @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Failure 503 {object} map[string]string "Сервис аккаунтов недоступен"
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
 		return
 	}
 
 	var sub models.Subscription
 	if err := c.ShouldBindJSON(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
 		return
 	}
 	sub.ID = id
 
 	if err := models.Validate(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortValidation(c, err)
+		return
+	}
+
+	var opts []service.UpdateOption
+	if allow, _ := strconv.ParseBool(c.Query("allow_price_change")); allow {
+		opts = append(opts, service.AllowPriceChange())
+	}
+
+	if err := h.service.Update(c.Request.Context(), &sub, opts...); err != nil {
+		abortWithServiceError(c, err, "failed to update subscription")
+		return
+	}
+
+	renderJSON(c, http.StatusOK, sub)
+}
+
+// Patch godoc
+// @Summary Частично обновить подписку
+// @Description Меняет только переданные поля: service_name, price и end_date (null — бессрочная подписка).
+// @Description Остальные поля не перезаписываются, поэтому параллельные изменения других полей сохраняются
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param patch body models.SubscriptionPatch true "Изменяемые поля"
+// @Param allow_price_change query bool false "Подтвердить изменение цены больше limits.max_price_change_percent"
+// @Success 200 {object} models.Subscription "Обновленная подписка"
+// @Failure 400 {object} map[string]string "Некорректные данные или нет изменяемых полей"
+// @Failure 404 {object} map[string]string "Не найдена"
+// @Failure 422 {object} map[string]string "Изменение цены больше допустимого без allow_price_change"
+// @Failure 429 {object} map[string]string "Превышена квота записи пользователя"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) Patch(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+
+	var patch models.SubscriptionPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if patch.Empty() {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "nothing to update, expected service_name, price or end_date")
+		return
+	}
+	if err := models.Validate(&patch); err != nil {
+		abortValidation(c, err)
 		return
 	}
 
-	if err := h.service.Update(c.Request.Context(), &sub); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update subscription"})
+	var opts []service.UpdateOption
+	if allow, _ := strconv.ParseBool(c.Query("allow_price_change")); allow {
+		opts = append(opts, service.AllowPriceChange())
+	}
+
+	sub, err := h.service.Patch(c.Request.Context(), id, patch, opts...)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to patch subscription")
 		return
 	}
 
-	c.JSON(http.StatusOK, sub)
+	renderJSON(c, http.StatusOK, sub)
 }
 
 // Delete godoc
 // @Summary Удалить подписку
-// @Description Удаляет подписку по ID
+// @Description Удаляет подписку по ID.
+// @Description С return=representation (query или Prefer) возвращает удаленную подписку и время удаления
 // @Tags subscriptions
+// @Produce json
 // @Param id path int true "ID подписки"
+// @Param return query string false "representation — вернуть удаленную подписку" Enums(representation)
+// @Param Prefer header string false "return=representation — то же, что return=representation в query"
+// @Success 200 {object} models.DeletedSubscription "Удалено (return=representation)"
 // @Success 204 "Удалено"
 // @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 404 {object} map[string]string "Не найдена"
 // @Failure 500 {object} map[string]string "Ошибка сервера"
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+
+	if preferReturnRepresentation(c) {
+		deleted, err := h.service.DeleteReturning(c.Request.Context(), id)
+		if err != nil {
+			abortWithServiceError(c, err, "failed to delete subscription")
+			return
+		}
+		c.Header("Preference-Applied", "return=representation")
+		renderJSON(c, http.StatusOK, deleted)
 		return
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete subscription"})
+		abortWithServiceError(c, err, "failed to delete subscription")
 		return
 	}
 
@@ -187,32 +706,142 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 
 // Summary godoc
 // @Summary Получить сумму подписок за период
-// @Description Возвращает общую сумму подписок за указанный период с учетом фильтров
+// @Description Возвращает общую сумму подписок за указанный период с учетом фильтров.
+// @Description Устарел: используйте GET /subscriptions/summary.
 // @Tags subscriptions
+// @Deprecated
 // @Accept json
 // @Produce json
 // @Param summary body models.SummaryRequest true "Параметры периода и фильтров"
-// @Success 200 {object} map[string]int "Сумма подписок"
+// @Param filter_id query int false "ID сохраненного фильтра вызывающего; фильтры запроса имеют приоритет"
+// @Param debug query bool false "Вернуть вклад каждой подписки (только для администраторов)"
+// @Success 200 {object} SummaryResponse "Сумма подписок"
 // @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "debug и group_by=user_id доступны только администраторам, user_id — только свой"
+// @Failure 404 {object} map[string]string "Сохраненный фильтр не найден"
 // @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Failure 503 {object} map[string]string "Превышен лимит одновременных запросов (routes)"
+// @Failure 504 {object} map[string]string "Превышено время обработки (routes)"
 // @Router /subscriptions/summary [post]
 func (h *SubscriptionHandler) Summary(c *gin.Context) {
 	var req models.SummaryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	if err := models.Validate(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	h.summary(c, &req)
+}
+
+// SummaryQuery godoc
+// @Summary Получить сумму подписок за период
+// @Description Возвращает общую сумму подписок за указанный период с учетом фильтров
+// @Tags subscriptions
+// @Produce json
+// @Param from query string true "Начало периода (MM-YYYY)"
+// @Param to query string true "Конец периода (MM-YYYY)"
+// @Param user_id query string false "Фильтр по пользователю"
+// @Param service_name query string false "Фильтр по сервису"
+// @Param exclude_trials query bool false "Не учитывать пробные подписки"
+// @Param min_price query int false "Не учитывать подписки дешевле указанной цены (например, 1 — без бесплатных тарифов)"
+// @Param currency query string false "Валюта ISO 4217; обязательна, если у подписок разные валюты"
+// @Param filter_id query int false "ID сохраненного фильтра вызывающего; фильтры запроса имеют приоритет"
+// @Param group_by query string false "Разбить сумму по пользователям (только для администраторов) или сервисам; группы по убыванию суммы" Enums(user_id, service_name)
+// @Param limit query int false "Групп разбивки на странице (по умолчанию app.default_page_size, не больше app.max_page_size); остальные суммируются в other"
+// @Param offset query int false "Смещение по группам разбивки (по умолчанию 0)"
+// @Param debug query bool false "Вернуть вклад каждой подписки (только для администраторов)"
+// @Param Consistency header string false "strong — читать только из основной БД, минуя реплики и кэш" Enums(strong, eventual)
+// @Success 200 {object} SummaryResponse "Сумма подписок"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "debug и group_by=user_id доступны только администраторам, user_id — только свой"
+// @Failure 404 {object} map[string]string "Сохраненный фильтр не найден"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Failure 503 {object} map[string]string "Превышен лимит одновременных запросов (routes)"
+// @Failure 504 {object} map[string]string "Превышено время обработки (routes)"
+// @Router /subscriptions/summary [get]
+func (h *SubscriptionHandler) SummaryQuery(c *gin.Context) {
+	var req models.SummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid query parameters")
+		return
+	}
+
+	h.summary(c, &req)
+}
+
+// summary дополняет запрос сохраненным фильтром filter_id, валидирует его
+// и отвечает суммой подписок
+func (h *SubscriptionHandler) summary(c *gin.Context, req *models.SummaryRequest) {
+	criteria, ok := h.savedFilter(c)
+	if !ok {
 		return
 	}
+	criteria.ApplyTo(req)
 
-	sum, err := h.service.Summary(c.Request.Context(), &req)
+	if err := models.Validate(req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	if req.GroupBy != "" {
+		if req.Limit == 0 {
+			req.Limit = h.defaultPageSize
+		}
+		if req.Limit > h.maxPageSize {
+			apierr.Abortf(c, http.StatusBadRequest, apierr.CodeInvalidRequest,
+				"limit must not exceed %d, use offset to fetch further pages", h.maxPageSize)
+			return
+		}
+	}
+
+	summarize := h.service.Summary
+	if debug, _ := strconv.ParseBool(c.Query("debug")); debug {
+		summarize = h.service.ExplainSummary
+	}
+	sum, err := summarize(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate summary"})
+		abortWithServiceError(c, err, "failed to calculate summary")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"total": sum})
+	renderJSON(c, http.StatusOK, SummaryResponse{
+		Total:    sum.Total.Major(),
+		Currency: sum.Total.Currency,
+		Count:    sum.Count,
+		From:     sum.From,
+		To:       sum.To,
+		Filters: SummaryFilters{
+			UserID:        req.UserID,
+			ServiceName:   req.ServiceName,
+			ExcludeTrials: req.ExcludeTrials,
+			MinPrice:      req.MinPrice,
+			Currency:      req.Currency,
+		},
+		Lines:     sum.Lines,
+		Breakdown: sum.Breakdown,
+	})
+}
+
+// SummaryResponse сумма подписок за период; count и filters позволяют
+// отличить отсутствие данных от фильтров, под которые ничего не подошло
+type SummaryResponse struct {
+	Total    int64            `json:"total" example:"1200"`   // Сумма в целых единицах валюты
+	Currency models.Currency  `json:"currency" example:"RUB"` // Валюта ISO 4217
+	Count    int              `json:"count" example:"3"`      // Число подписок, попавших в период
+	From     models.MonthDate `json:"from"`                   // Начало периода, использованное при подсчете
+	To       models.MonthDate `json:"to"`                     // Конец периода, использованный при подсчете
+	Filters  SummaryFilters   `json:"filters"`                // Примененные фильтры
+	// Вклад каждой подписки в сумму; только с debug=true
+	Lines []models.SummaryLine `json:"lines,omitempty"`
+	// Страница разбивки суммы по group_by; только с group_by
+	Breakdown *models.SummaryBreakdown `json:"breakdown,omitempty"`
+}
+
+// SummaryFilters фильтры, примененные при подсчете суммы
+type SummaryFilters struct {
+	UserID        *string          `json:"user_id,omitempty"`        // Пользователь
+	ServiceName   *string          `json:"service_name,omitempty"`   // Сервис
+	ExcludeTrials bool             `json:"exclude_trials,omitempty"` // Пробные подписки не учитывались
+	MinPrice      *int             `json:"min_price,omitempty"`      // Минимальная цена
+	Currency      *models.Currency `json:"currency,omitempty"`       // Валюта
 }
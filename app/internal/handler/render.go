@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Подписки и их страницы отдаются чаще всего, поэтому кодируются без
+// encoding/json: готовыми энкодерами models в буфер из пула
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// maxPooledBuffer — буферы больше этого размера не возвращаются в пул,
+// чтобы одна большая страница не удерживала память
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// subscriptionJSON — gin render одной подписки
+type subscriptionJSON struct {
+	sub    *models.Subscription
+	format models.DateFormat
+}
+
+// subscriptionPageJSON — gin render страницы подписок
+// {"data": [...], "limit": N, "offset": M}
+type subscriptionPageJSON struct {
+	subs          []models.Subscription
+	limit, offset int
+	format        models.DateFormat
+}
+
+func (r subscriptionJSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, func(b []byte) []byte {
+		return r.sub.AppendJSON(b, r.format)
+	})
+}
+
+func (r subscriptionJSON) WriteContentType(w http.ResponseWriter) {
+	writeJSONContentType(w)
+}
+
+func (r subscriptionPageJSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, func(b []byte) []byte {
+		b = append(b, `{"data":`...)
+		b = models.AppendSubscriptionsJSON(b, r.subs, r.format)
+		b = append(b, `,"limit":`...)
+		b = strconv.AppendInt(b, int64(r.limit), 10)
+		b = append(b, `,"offset":`...)
+		b = strconv.AppendInt(b, int64(r.offset), 10)
+		return append(b, '}')
+	})
+}
+
+func (r subscriptionPageJSON) WriteContentType(w http.ResponseWriter) {
+	writeJSONContentType(w)
+}
+
+// writeJSON пишет в w JSON, собранный encode в буфере из пула
+func writeJSON(w http.ResponseWriter, encode func([]byte) []byte) error {
+	writeJSONContentType(w)
+	bp := bufferPool.Get().(*[]byte)
+	b := encode((*bp)[:0])
+	_, err := w.Write(b)
+	if cap(b) <= maxPooledBuffer {
+		*bp = b
+		bufferPool.Put(bp)
+	}
+	return err
+}
+
+func writeJSONContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = jsonContentType
+	}
+}
+
+// dateFormat возвращает формат месяцев из заголовка Date-Format; пустой
+// формат означает формат по умолчанию
+func dateFormat(c *gin.Context) models.DateFormat {
+	f, _ := models.DateFormatFromContext(c.Request.Context())
+	return f
+}
+
+// renderSubscription отвечает подпиской; то же, что renderJSON, но без
+// encoding/json
+func renderSubscription(c *gin.Context, status int, sub *models.Subscription) {
+	c.Render(status, subscriptionJSON{sub: sub, format: dateFormat(c)})
+}
+
+// renderSubscriptionPage отвечает страницей подписок
+func renderSubscriptionPage(c *gin.Context, subs []models.Subscription, limit, offset int) {
+	c.Render(http.StatusOK, subscriptionPageJSON{subs: subs, limit: limit, offset: offset, format: dateFormat(c)})
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WithSummarySubscriptions включает подписки на регулярную сумму: маршруты
+// /summary-subscriptions
+func WithSummarySubscriptions(srv *service.SummarySubscriptionService) Option {
+	return func(h *SubscriptionHandler) {
+		h.summarySubs = srv
+	}
+}
+
+// registerSummarySubscriptionRoutes регистрирует маршруты подписок на сумму
+func (h *SubscriptionHandler) registerSummarySubscriptionRoutes(r *gin.Engine) {
+	g := r.Group("/summary-subscriptions", h.middleware...)
+	g.POST("/", h.CreateSummarySubscription)
+	g.POST("/confirm", h.ConfirmSummarySubscription)
+	g.GET("/", h.ListSummarySubscriptions)
+	g.DELETE("/:id", h.DeleteSummarySubscription)
+}
+
+// CreateSummarySubscription godoc
+// @Summary Подписаться на регулярную сумму
+// @Description Подписывает пользователя на сумму его подписок за каждый месяц, квартал или год с фильтрами, как в запросе суммы (user_id фильтра всегда равен user_id подписки). Сумма за прошедший период отправляется в начале следующего через канал уведомлений с адресатом (email) на адрес recipient. Каналы с общим назначением (slack, telegram, webhook) не подходят. На recipient отправляется токен подтверждения: суммы отправляются только после подтверждения через POST /summary-subscriptions/confirm.
+// @Tags summary-subscriptions
+// @Accept json
+// @Produce json
+// @Param subscription body models.SummarySubscription true "user_id, period, filter, channel и recipient (остальные поля игнорируются)"
+// @Success 201 {object} models.SummarySubscription "Подписка создана и ожидает подтверждения"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 403 {object} map[string]string "Подписка другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 422 {object} map[string]string "Канал не настроен, не отправляет адресату или recipient ему не подходит"
+// @Failure 500 {object} map[string]string "Ошибка сервера или отправки подтверждения"
+// @Router /summary-subscriptions/ [post]
+func (h *SubscriptionHandler) CreateSummarySubscription(c *gin.Context) {
+	var req models.SummarySubscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	sub := &models.SummarySubscription{
+		UserID:    req.UserID,
+		Period:    req.Period,
+		Filter:    req.Filter,
+		Channel:   req.Channel,
+		Recipient: req.Recipient,
+	}
+	if err := h.summarySubs.Create(c.Request.Context(), sub); err != nil {
+		abortWithSummarySubscriptionError(c, err, "failed to create summary subscription")
+		return
+	}
+
+	renderJSON(c, http.StatusCreated, sub)
+}
+
+// ConfirmSummarySubscription godoc
+// @Summary Подтвердить подписку на регулярную сумму
+// @Description Подтверждает адрес подписки на сумму токеном, отправленным на него при подписке; после этого суммы отправляются. Токен действует один раз.
+// @Tags summary-subscriptions
+// @Accept json
+// @Produce json
+// @Param confirmation body models.SummarySubscriptionConfirmation true "Токен подтверждения"
+// @Success 200 {object} models.SummarySubscription "Подписка подтверждена"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 404 {object} map[string]string "Ни одна подписка не ожидает такого токена"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /summary-subscriptions/confirm [post]
+func (h *SubscriptionHandler) ConfirmSummarySubscription(c *gin.Context) {
+	var req models.SummarySubscriptionConfirmation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	sub, err := h.summarySubs.Confirm(c.Request.Context(), req.Token)
+	if err != nil {
+		abortWithSummarySubscriptionError(c, err, "failed to confirm summary subscription")
+		return
+	}
+
+	renderJSON(c, http.StatusOK, sub)
+}
+
+// ListSummarySubscriptions godoc
+// @Summary Подписки пользователя на сумму
+// @Description Возвращает подписки пользователя на регулярную сумму, упорядоченные по ID
+// @Tags summary-subscriptions
+// @Produce json
+// @Param user_id query string true "ID пользователя (UUID)"
+// @Success 200 {object} map[string][]models.SummarySubscription "data: подписки на сумму"
+// @Failure 400 {object} map[string]string "Некорректный user_id"
+// @Failure 403 {object} map[string]string "Подписки другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /summary-subscriptions/ [get]
+func (h *SubscriptionHandler) ListSummarySubscriptions(c *gin.Context) {
+	userID, ok := summarySubscriptionUserID(c)
+	if !ok {
+		return
+	}
+
+	subs, err := h.summarySubs.List(c.Request.Context(), userID)
+	if err != nil {
+		abortWithSummarySubscriptionError(c, err, "failed to list summary subscriptions")
+		return
+	}
+	if subs == nil {
+		subs = []models.SummarySubscription{}
+	}
+
+	renderJSON(c, http.StatusOK, gin.H{"data": subs})
+}
+
+// DeleteSummarySubscription godoc
+// @Summary Отписаться от регулярной суммы
+// @Description Удаляет подписку пользователя на регулярную сумму
+// @Tags summary-subscriptions
+// @Param id path int true "ID подписки на сумму"
+// @Param user_id query string true "ID пользователя (UUID)"
+// @Success 204 "Подписка удалена"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} map[string]string "Подписка другого пользователя (при включенной идентификации вызывающего)"
+// @Failure 404 {object} map[string]string "Не найдена"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /summary-subscriptions/{id} [delete]
+func (h *SubscriptionHandler) DeleteSummarySubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid id")
+		return
+	}
+	userID, ok := summarySubscriptionUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.summarySubs.Delete(c.Request.Context(), userID, id); err != nil {
+		abortWithSummarySubscriptionError(c, err, "failed to delete summary subscription")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// summarySubscriptionUserID читает обязательный параметр user_id; при
+// ошибке отвечает 400
+func summarySubscriptionUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// abortWithSummarySubscriptionError преобразует ошибку сервиса подписок на
+// сумму в ошибку API
+func abortWithSummarySubscriptionError(c *gin.Context, err error, detail string) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		apierr.Abort(c, http.StatusForbidden, apierr.CodeForbidden, "summary subscriptions of other users are not accessible")
+	case errors.Is(err, service.ErrSummarySubscriptionNotFound):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "summary subscription not found")
+	case errors.Is(err, service.ErrInvalidConfirmation):
+		apierr.Abort(c, http.StatusNotFound, apierr.CodeNotFound, "no summary subscription awaits the token")
+	case errors.Is(err, service.ErrInvalidChannel):
+		apierr.Abort(c, http.StatusUnprocessableEntity, apierr.CodeValidationFailed, err.Error())
+	default:
+		abortWithServiceError(c, err, detail)
+	}
+}
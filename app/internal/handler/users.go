@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultUsersPageSize — размер страницы GET /users по умолчанию
+	defaultUsersPageSize = 100
+	// maxUsersPageSize — максимально допустимый limit GET /users
+	maxUsersPageSize = 1000
+)
+
+// UserHandler отвечает за синхронизацию пользователей из внешней системы
+// идентификации
+type UserHandler struct {
+	service *service.UserService
+	log     *zap.Logger
+}
+
+func NewUserHandler(srv *service.UserService, log *zap.Logger) *UserHandler {
+	return &UserHandler{service: srv, log: log}
+}
+
+// RegisterRoutes регистрирует маршруты пользователей в группе rg (/users)
+func (h *UserHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/", h.Create)
+	rg.GET("/", h.List)
+	rg.DELETE("/:user_id", h.Delete)
+}
+
+// Create godoc
+// @Summary Создать пользователя
+// @Description Регистрирует пользователя, которому могут принадлежать подписки. Повторный вызов для существующего пользователя возвращает 200.
+// @Description Требуется заголовок Authorization: Bearer <admin.token>.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body models.User true "Пользователь (created_at игнорируется)"
+// @Success 200 {object} models.User "Пользователь уже существует"
+// @Success 201 {object} models.User "Пользователь создан"
+// @Failure 400 {object} map[string]string "Некорректный запрос"
+// @Failure 401 {object} map[string]string "Неверный токен"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/ [post]
+func (h *UserHandler) Create(c *gin.Context) {
+	var req models.User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := models.Validate(&req); err != nil {
+		abortValidation(c, err)
+		return
+	}
+
+	u := &models.User{ID: req.ID}
+	created, err := h.service.CreateUser(c.Request.Context(), u)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to create user")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, u)
+}
+
+// List godoc
+// @Summary Получить список пользователей
+// @Description Возвращает пользователей, упорядоченных по ID, для сверки с внешней системой идентификации.
+// @Description Требуется заголовок Authorization: Bearer <admin.token>.
+// @Tags users
+// @Produce json
+// @Param limit query int false "Количество элементов на странице (по умолчанию 100, не больше 1000)"
+// @Param offset query int false "Смещение (по умолчанию 0)"
+// @Success 200 {object} map[string]interface{} "data: список пользователей, limit, offset"
+// @Failure 400 {object} map[string]string "Превышен максимальный limit"
+// @Failure 401 {object} map[string]string "Неверный токен"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/ [get]
+func (h *UserHandler) List(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultUsersPageSize)))
+	if err != nil || limit < 1 {
+		limit = defaultUsersPageSize
+	}
+	if limit > maxUsersPageSize {
+		apierr.Abortf(c, http.StatusBadRequest, apierr.CodeInvalidRequest,
+			"limit must not exceed %d, use offset to fetch further pages", maxUsersPageSize)
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	users, err := h.service.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		abortWithServiceError(c, err, "failed to list users")
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   users,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// Delete godoc
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя без подписок; подписки пользователя нужно удалить заранее.
+// @Description Требуется заголовок Authorization: Bearer <admin.token>.
+// @Tags users
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 204 "Пользователь удален"
+// @Failure 400 {object} map[string]string "Некорректный user_id"
+// @Failure 401 {object} map[string]string "Неверный токен"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 409 {object} map[string]string "У пользователя есть подписки"
+// @Failure 500 {object} map[string]string "Ошибка сервера"
+// @Router /users/{user_id} [delete]
+func (h *UserHandler) Delete(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidID, "invalid user_id")
+		return
+	}
+
+	if err := h.service.DeleteUser(c.Request.Context(), userID); err != nil {
+		abortWithServiceError(c, err, "failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+// Package health builds a component-based health report from checks that
+// subsystems register into a Registry.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status of a component or of the whole service.
+type Status string
+
+const (
+	StatusUp       Status = "up"       // Working.
+	StatusDegraded Status = "degraded" // A non-critical component is down.
+	StatusDown     Status = "down"     // A critical component is down.
+)
+
+// CheckFunc checks a component; a non-nil error marks it down.
+type CheckFunc func(ctx context.Context) error
+
+// Component is the result of a single check.
+type Component struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the health of the service and its components.
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  []check
+	timeout time.Duration
+}
+
+// NewRegistry creates a registry; each check is limited by timeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// RegisterOption configures a registered check.
+type RegisterOption func(*check)
+
+// NonCritical makes a failing check degrade the service instead of marking it down.
+func NonCritical() RegisterOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// Register adds a check under name. Checks are critical unless NonCritical is given.
+// Registering an existing name replaces its check.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...RegisterOption) {
+	c := check{name: name, fn: fn, critical: true}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Check runs all checks concurrently and aggregates their results.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		report.Components[c.name] = res
+		if res.Status == StatusUp {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c check) Component {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := c.fn(ctx)
+	res := Component{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Handler serves the health report: 200 when up or degraded, 503 when down.
+func Handler(r *Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Check(c.Request.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
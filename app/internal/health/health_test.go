@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(r *Registry)
+		status Status
+	}{
+		{
+			name:   "empty registry is up",
+			setup:  func(r *Registry) {},
+			status: StatusUp,
+		},
+		{
+			name: "all up",
+			setup: func(r *Registry) {
+				r.Register("db", ok)
+				r.Register("cache", ok, NonCritical())
+			},
+			status: StatusUp,
+		},
+		{
+			name: "non-critical down degrades",
+			setup: func(r *Registry) {
+				r.Register("db", ok)
+				r.Register("cache", fail, NonCritical())
+			},
+			status: StatusDegraded,
+		},
+		{
+			name: "critical down",
+			setup: func(r *Registry) {
+				r.Register("db", fail)
+				r.Register("cache", fail, NonCritical())
+			},
+			status: StatusDown,
+		},
+		{
+			name: "re-register replaces check",
+			setup: func(r *Registry) {
+				r.Register("db", fail)
+				r.Register("db", ok)
+			},
+			status: StatusUp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(time.Second)
+			tt.setup(r)
+			assert.Equal(t, tt.status, r.Check(t.Context()).Status)
+		})
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry(10 * time.Millisecond)
+	r.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := r.Check(t.Context())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["slow"].Error)
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := NewRegistry(time.Second)
+	r.Register("db", fail)
+
+	e := gin.New()
+	e.GET("/healthz", Handler(r))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusDown, report.Components["db"].Status)
+	assert.True(t, report.Components["db"].Critical)
+	assert.Equal(t, "connection refused", report.Components["db"].Error)
+}
@@ -0,0 +1,65 @@
+// Package hedge implements hedged requests: if a call is slow, a second
+// identical call is started and the first successful result is used. It
+// trades extra load for lower tail latency, so it suits idempotent reads only.
+package hedge
+
+import (
+	"context"
+	"time"
+)
+
+// Func is a hedged call. It must stop when ctx is canceled.
+type Func[T any] func(ctx context.Context) (T, error)
+
+// Do runs f and, if it has not finished after delay, runs a second f
+// concurrently. It returns the first successful result and cancels the other
+// call; if both fail, it returns the error of the one that failed first.
+// A non-positive delay disables hedging.
+func Do[T any](ctx context.Context, delay time.Duration, f Func[T]) (T, error) {
+	if delay <= 0 {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		val T
+		err error
+	}
+	results := make(chan result, 2)
+	call := func() {
+		v, err := f(ctx)
+		results <- result{v, err}
+	}
+
+	go call()
+	running := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			go call()
+			running++
+			continue
+		case res := <-results:
+			running--
+			if res.err == nil {
+				return res.val, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if running > 0 {
+				continue
+			}
+			// The first call failed before the delay: hedging would not help.
+			var zero T
+			return zero, firstErr
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errQuery = errors.New("query failed")
+
+func TestDo(t *testing.T) {
+	t.Run("fast call is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		got, err := Do(t.Context(), 50*time.Millisecond, func(context.Context) (int, error) {
+			calls.Add(1)
+			return 1, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("slow call is hedged and canceled", func(t *testing.T) {
+		var calls atomic.Int32
+		slowCanceled := make(chan struct{})
+		got, err := Do(t.Context(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				close(slowCanceled)
+				return 0, ctx.Err()
+			}
+			return 2, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, got)
+		assert.Equal(t, int32(2), calls.Load())
+		select {
+		case <-slowCanceled:
+		case <-time.After(time.Second):
+			t.Fatal("slow call was not canceled")
+		}
+	})
+
+	t.Run("failed hedge waits for the first call", func(t *testing.T) {
+		var calls atomic.Int32
+		got, err := Do(t.Context(), 10*time.Millisecond, func(context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				time.Sleep(30 * time.Millisecond)
+				return 1, nil
+			}
+			return 0, errQuery
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+	})
+
+	t.Run("early failure is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := Do(t.Context(), 10*time.Millisecond, func(context.Context) (int, error) {
+			calls.Add(1)
+			return 0, errQuery
+		})
+
+		assert.ErrorIs(t, err, errQuery)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := Do(t.Context(), 5*time.Millisecond, func(context.Context) (int, error) {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return 0, errQuery
+		})
+
+		assert.ErrorIs(t, err, errQuery)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var calls atomic.Int32
+		_, _ = Do(t.Context(), 0, func(context.Context) (int, error) {
+			calls.Add(1)
+			time.Sleep(5 * time.Millisecond)
+			return 0, nil
+		})
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow(), "probe after cooldown")
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "only one probe at a time")
+
+	b.Done(false)
+	assert.Equal(t, BreakerOpen, b.State(), "failed probe reopens")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	b.Done(true)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, b.Allow())
+}
@@ -0,0 +1,249 @@
+// Package httpclient provides the HTTP client for outbound calls (webhooks,
+// provider integrations) with retries, circuit breaking, tracing and metrics.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Defaults used by New.
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// StatusError is returned when the server responds with a retryable status
+// (429 or 5xx) on the last attempt.
+type StatusError struct {
+	StatusCode int
+}
+
+// Error returns the error message.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Client sends outbound HTTP requests. It is safe for concurrent use.
+type Client struct {
+	name    string
+	http    *http.Client
+	retry   retry.Retrier
+	breaker *Breaker
+	metrics *Metrics
+}
+
+// Option configures Client.
+type Option func(*Client)
+
+// WithTimeout limits a single attempt, including reading the response body.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.http.Timeout = d
+	}
+}
+
+// WithTransport sets the underlying transport (http.DefaultTransport by default).
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.http.Transport = rt
+	}
+}
+
+// WithRetrier sets the retry strategy. It should use IsRetryable.
+func WithRetrier(r retry.Retrier) Option {
+	return func(c *Client) {
+		c.retry = r
+	}
+}
+
+// WithBreaker sets the circuit breaker.
+func WithBreaker(b *Breaker) Option {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// WithMetrics records request metrics labeled with the client name.
+func WithMetrics(m *Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// New creates a client named name (used in metrics and spans). By default an
+// attempt times out after 10s, requests are tried up to 3 times with
+// exponential backoff, and the breaker opens after 5 consecutive failures for 30s.
+func New(name string, opts ...Option) *Client {
+	c := &Client{
+		name: name,
+		http: &http.Client{Timeout: defaultTimeout},
+		retry: retry.New(
+			retry.WithMaxAttempts(3),
+			retry.WithBackoff(retry.ExponentialBackoff{
+				Base:   200 * time.Millisecond,
+				Factor: 2,
+				Max:    5 * time.Second,
+				Jitter: 0.2,
+			}),
+			retry.WithIsRetryableFunc(IsRetryable),
+		),
+		breaker: NewBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	base := c.http.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.http.Transport = otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return name + " " + r.Method
+		}),
+	)
+
+	return c
+}
+
+// IsRetryable reports whether a failed attempt may be retried: transport
+// errors and retryable statuses are, an open breaker and cancellation are not.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	default:
+		return true
+	}
+}
+
+// Do sends req, retrying transport errors and 429/5xx responses. Only
+// idempotent requests (by method or with an Idempotency-Key header) whose
+// body can be replayed are retried. A response with a retryable status on the
+// last attempt is returned as *StatusError; other responses are returned as
+// is and the caller must close their body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	retrier := c.retry
+	if !replayable(req) || !idempotent(req) {
+		retrier = retry.NoRetry()
+	}
+
+	var (
+		resp    *http.Response
+		attempt int
+	)
+	err := retrier.Do(req.Context(), func() error {
+		r := req
+		if attempt > 0 {
+			c.observeRetry()
+			var err error
+			if r, err = rewind(req); err != nil {
+				return err
+			}
+		}
+		attempt++
+
+		res, err := c.send(r)
+		if err != nil {
+			return err
+		}
+		if retryableStatus(res.StatusCode) {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			return &StatusError{StatusCode: res.StatusCode}
+		}
+		resp = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send performs a single attempt through the circuit breaker.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		c.observeBreaker()
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	c.breaker.Done(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	c.observe(req.Method, code, time.Since(start))
+	c.observeBreaker()
+
+	return resp, err
+}
+
+func (c *Client) observe(method, code string, d time.Duration) {
+	if c.metrics == nil {
+		return
+	}
+	c.metrics.requests.WithLabelValues(c.name, method, code).Inc()
+	c.metrics.duration.WithLabelValues(c.name, method).Observe(d.Seconds())
+}
+
+func (c *Client) observeRetry() {
+	if c.metrics != nil {
+		c.metrics.retries.WithLabelValues(c.name).Inc()
+	}
+}
+
+func (c *Client) observeBreaker() {
+	if c.metrics != nil {
+		c.metrics.breaker.WithLabelValues(c.name).Set(float64(c.breaker.State()))
+	}
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rewind request body: %w", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
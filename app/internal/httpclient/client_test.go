@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry retries up to 3 times without waiting.
+func fastRetry() Option {
+	return WithRetrier(retry.New(
+		retry.WithMaxAttempts(3),
+		retry.WithBackoff(retry.LinearBackoff{}),
+		retry.WithIsRetryableFunc(IsRetryable),
+	))
+}
+
+// flakyServer fails the first failures requests with status.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestClient_Do(t *testing.T) {
+	t.Run("retries idempotent request", func(t *testing.T) {
+		srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+		m := NewMetrics(prometheus.NewRegistry())
+		c := New("test", fastRetry(), WithMetrics(m))
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodPut, srv.URL, strings.NewReader(`{"a":1}`))
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.EqualValues(t, 3, calls.Load())
+		assert.Equal(t, 2.0, testutil.ToFloat64(m.retries.WithLabelValues("test")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("test", http.MethodPut, "200")))
+	})
+
+	t.Run("does not retry non-idempotent request", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusBadGateway)
+		c := New("test", fastRetry())
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL, strings.NewReader(`{}`))
+		_, err := c.Do(req)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("retries request with idempotency key", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusTooManyRequests)
+		c := New("test", fastRetry())
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL, strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "abc")
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("client errors are returned as is", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusNotFound)
+		c := New("test", fastRetry())
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL, nil)
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("open breaker fails fast", func(t *testing.T) {
+		srv, calls := flakyServer(t, 100, http.StatusInternalServerError)
+		c := New("test", fastRetry(), WithBreaker(NewBreaker(2, time.Hour)))
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL, nil)
+		_, err := c.Do(req)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+}
@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are outbound request metrics shared by all clients, labeled by client name.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	breaker  *prometheus.GaugeVec
+}
+
+// NewMetrics creates outbound request metrics and registers them in reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "subscriptions",
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Outbound HTTP requests by client, method and status code (\"error\" for transport errors).",
+		}, []string{"client", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "subscriptions",
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of single outbound HTTP attempts.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"client", "method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "subscriptions",
+			Subsystem: "http_client",
+			Name:      "retries_total",
+			Help:      "Retried outbound HTTP attempts.",
+		}, []string{"client"}),
+		breaker: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "subscriptions",
+			Subsystem: "http_client",
+			Name:      "circuit_breaker_state",
+			Help:      "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+		}, []string{"client"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.retries, m.breaker)
+
+	return m
+}
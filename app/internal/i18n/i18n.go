@@ -0,0 +1,128 @@
+// Package i18n localizes API error messages based on Accept-Language.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	"golang.org/x/text/language"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+// matcher picks a supported language; the first one is the default.
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Russian})
+
+// Negotiate returns the supported language that best matches an
+// Accept-Language header value, English by default.
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return English
+	}
+	return []string{English, Russian}[index]
+}
+
+// Catalog translates API errors and validation errors.
+type Catalog struct {
+	messages    map[string]map[string]string
+	translators map[string]ut.Translator
+}
+
+// NewCatalog creates a catalog and registers validation error translations on v.
+func NewCatalog(v *validator.Validate) (*Catalog, error) {
+	uni := ut.New(en.New(), en.New(), ru.New())
+
+	enTrans, _ := uni.GetTranslator(English)
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return nil, fmt.Errorf("register en translations: %w", err)
+	}
+	ruTrans, _ := uni.GetTranslator(Russian)
+	if err := ru_translations.RegisterDefaultTranslations(v, ruTrans); err != nil {
+		return nil, fmt.Errorf("register ru translations: %w", err)
+	}
+
+	c := &Catalog{
+		messages: map[string]map[string]string{
+			Russian: ruMessages,
+		},
+		translators: map[string]ut.Translator{
+			English: enTrans,
+			Russian: ruTrans,
+		},
+	}
+
+	if err := c.registerValidation(v, "monthdate", map[string]string{
+		English: "{0} must be a date in MM-YYYY format",
+		Russian: "{0} должно быть датой в формате MM-YYYY",
+	}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// registerValidation adds translations for a custom validation tag.
+func (c *Catalog) registerValidation(v *validator.Validate, tag string, texts map[string]string) error {
+	for lang, text := range texts {
+		trans := c.translators[lang]
+		err := v.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error {
+				return ut.Add(tag, text, false)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				msg, _ := ut.T(tag, fe.Field())
+				return msg
+			})
+		if err != nil {
+			return fmt.Errorf("register %s translation for %q: %w", lang, tag, err)
+		}
+	}
+	return nil
+}
+
+// Message returns the translation of an English message format, formatted
+// with args. Unknown messages are returned untranslated.
+func (c *Catalog) Message(lang, format string, args ...any) string {
+	if translated, ok := c.messages[lang][format]; ok {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Translate implements apierr.Translator.
+func (c *Catalog) Translate(r *http.Request, err *apierr.Error) string {
+	lang := Negotiate(r.Header.Get("Accept-Language"))
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		msgs := make([]string, 0, len(verrs))
+		for _, fe := range verrs {
+			msgs = append(msgs, fe.Translate(c.translators[lang]))
+		}
+		return strings.Join(msgs, "; ")
+	}
+
+	return c.Message(lang, err.Detail, err.Args...)
+}
@@ -0,0 +1,78 @@
+package i18n_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/i18n"
+	"subscriptionsservice/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", i18n.English},
+		{"ru", i18n.Russian},
+		{"ru-RU,ru;q=0.9,en;q=0.8", i18n.Russian},
+		{"en-US,en;q=0.9,ru;q=0.8", i18n.English},
+		{"de-DE", i18n.English},
+		{"not a language", i18n.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, i18n.Negotiate(tt.header))
+		})
+	}
+}
+
+func TestCatalog_Translate(t *testing.T) {
+	catalog, err := i18n.NewCatalog(models.Validator())
+	require.NoError(t, err)
+
+	request := func(lang string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if lang != "" {
+			r.Header.Set("Accept-Language", lang)
+		}
+		return r
+	}
+
+	t.Run("message", func(t *testing.T) {
+		e := &apierr.Error{Detail: "subscription not found"}
+		assert.Equal(t, "subscription not found", catalog.Translate(request(""), e))
+		assert.Equal(t, "подписка не найдена", catalog.Translate(request("ru"), e))
+	})
+
+	t.Run("message with args", func(t *testing.T) {
+		e := &apierr.Error{Detail: "limit must not exceed %d, use offset to fetch further pages", Args: []any{100}}
+		assert.Equal(t, "limit must not exceed 100, use offset to fetch further pages", catalog.Translate(request("en"), e))
+		assert.Equal(t, "limit не может превышать 100, используйте offset для получения следующих страниц", catalog.Translate(request("ru"), e))
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		e := &apierr.Error{Detail: "something else"}
+		assert.Equal(t, "something else", catalog.Translate(request("ru"), e))
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		verr := models.Validate(&models.Subscription{Price: 100, UserID: uuid.New()})
+		require.Error(t, verr)
+		e := &apierr.Error{Detail: verr.Error(), Err: verr}
+
+		assert.Equal(t,
+			"service_name is a required field; start_date must be a date in MM-YYYY format",
+			catalog.Translate(request("en"), e))
+		assert.Equal(t,
+			"service_name обязательное поле; start_date должно быть датой в формате MM-YYYY",
+			catalog.Translate(request("ru"), e))
+	})
+}
@@ -0,0 +1,113 @@
+package i18n
+
+// ruMessages maps English API messages to Russian. English messages are
+// used as keys, so a missing entry falls back to the original text.
+var ruMessages = map[string]string{
+	"internal server error": "внутренняя ошибка сервера",
+	"route not found":       "маршрут не найден",
+	"method not allowed":    "метод не поддерживается",
+
+	"invalid or missing token": "неверный или отсутствующий токен",
+
+	"missing or invalid caller identity":              "отсутствует или некорректен идентификатор вызывающего",
+	"missing or invalid tenant":                       "отсутствует или некорректен идентификатор арендатора",
+	"invalid api key":                                 "неверный API-ключ",
+	"failed to resolve tenant":                        "не удалось определить арендатора",
+	"subscriptions of other users are not accessible": "подписки других пользователей недоступны",
+	"available to admins only":                        "доступно только администраторам",
+
+	"request timed out": "превышено время обработки запроса",
+
+	"invalid fault headers": "некорректные заголовки внедрения сбоев",
+	"injected fault":        "внедренный сбой",
+
+	"too many concurrent requests, try again later": "слишком много одновременных запросов, повторите позже",
+	"service is in read-only mode, try again later": "сервис в режиме только для чтения, повторите позже",
+	"request rejected: %s":                          "запрос отклонен: %s",
+
+	"invalid id":               "некорректный id",
+	"invalid user_id":          "некорректный user_id",
+	"invalid request body":     "некорректное тело запроса",
+	"invalid query parameters": "некорректные параметры запроса",
+
+	"%s must not be before %s": "%s не может быть раньше %s",
+
+	"consistency must be strong or eventual": "consistency должен быть strong или eventual",
+
+	"Date-Format must be MM-YYYY, YYYY-MM or RFC3339": "Date-Format должен быть MM-YYYY, YYYY-MM или RFC3339",
+
+	"limit must not exceed %d, use offset to fetch further pages": "limit не может превышать %d, используйте offset для получения следующих страниц",
+
+	"subscription not found":      "подписка не найдена",
+	"subscription already exists": "подписка уже существует",
+
+	"active subscription limit exceeded for the user":                                         "превышен лимит активных подписок пользователя",
+	"subscriptions have different currencies, filter by currency":                             "у подписок разные валюты, укажите фильтр currency",
+	"write quota of %d per hour exceeded":                                                     "превышена квота записи: %d в час",
+	"price change from %d to %d exceeds %g%%, repeat with allow_price_change=true to confirm": "изменение цены с %d на %d превышает %g%%, повторите с allow_price_change=true для подтверждения",
+
+	"schema not found": "схема не найдена",
+
+	"dead letter not found":                                       "dead letter не найдено",
+	"dead letter already redelivered":                             "dead letter уже доставлено повторно",
+	"no redelivery handler for kind %q":                           "нет обработчика повторной доставки для типа %q",
+	"redelivery failed, the error is appended to the dead letter": "повторная доставка не удалась, ошибка добавлена в dead letter",
+	"failed to list dead letters":                                 "не удалось получить список dead letters",
+	"failed to get dead letter":                                   "не удалось получить dead letter",
+	"failed to redeliver dead letter":                             "не удалось повторно доставить dead letter",
+
+	"backup not found":            "резервная копия не найдена",
+	"invalid backup name":         "некорректное имя резервной копии",
+	"format must be jsonl or csv": "format должен быть jsonl или csv",
+	"failed to create backup":     "не удалось создать резервную копию",
+	"failed to list backups":      "не удалось получить список резервных копий",
+	"failed to open backup":       "не удалось открыть резервную копию",
+	"invalid snapshot row %d: %s": "некорректная строка снимка %d: %s",
+	"snapshot conflicts with existing subscriptions, use replace=true": "снимок конфликтует с существующими подписками, используйте replace=true",
+	"failed to restore snapshot":                                       "не удалось восстановить снимок",
+
+	"invalid reindex":   "некорректный reindex",
+	"failed to reindex": "не удалось перестроить индексы",
+	"failed to analyze": "не удалось обновить статистику планировщика",
+
+	"invalid tenant":                     "некорректный идентификатор арендатора",
+	"tenant not found":                   "арендатор не найден",
+	"tenant already exists":              "арендатор уже существует",
+	"tenant limits must not be negative": "лимиты арендатора не могут быть отрицательными",
+	"api key not found":                  "API-ключ не найден",
+	"failed to create tenant":            "не удалось зарегистрировать арендатора",
+	"failed to list tenants":             "не удалось получить список арендаторов",
+	"failed to get tenant":               "не удалось получить арендатора",
+	"failed to update tenant":            "не удалось обновить арендатора",
+	"failed to delete tenant":            "не удалось удалить арендатора",
+	"failed to migrate tenant schema":    "не удалось применить миграции к схеме арендатора",
+	"failed to issue api key":            "не удалось выпустить API-ключ",
+	"failed to list api keys":            "не удалось получить список API-ключей",
+	"failed to revoke api key":           "не удалось отозвать API-ключ",
+
+	"webhook key was rotated concurrently": "ключ подписи вебхуков был ротирован параллельно",
+	"failed to list webhook keys":          "не удалось получить список ключей подписи вебхуков",
+	"failed to rotate webhook key":         "не удалось ротировать ключ подписи вебхуков",
+	"failed to load webhook keys":          "не удалось загрузить ключи подписи вебхуков",
+	"failed to re-encrypt values":          "не удалось перешифровать значения",
+
+	"user is not provisioned, create it via POST /users": "пользователь не создан, создайте его через POST /users",
+	"user not found": "пользователь не найден",
+	"user has subscriptions, delete them first":             "у пользователя есть подписки, сначала удалите их",
+	"failed to create user":                                 "не удалось создать пользователя",
+	"failed to list users":                                  "не удалось получить список пользователей",
+	"failed to delete user":                                 "не удалось удалить пользователя",
+	"user does not exist":                                   "пользователь не существует",
+	"failed to check that the user exists, try again later": "не удалось проверить, что пользователь существует, повторите позже",
+
+	"failed to create subscription": "не удалось создать подписку",
+	"failed to list subscriptions":  "не удалось получить список подписок",
+	"failed to get subscription":    "не удалось получить подписку",
+	"failed to update subscription": "не удалось обновить подписку",
+	"failed to delete subscription": "не удалось удалить подписку",
+	"failed to calculate summary":   "не удалось посчитать сумму подписок",
+
+	"failed to detect overlapping subscriptions": "не удалось найти пересекающиеся подписки",
+	"failed to list services":                    "не удалось получить список сервисов",
+	"failed to calculate retention":              "не удалось посчитать удержание подписок",
+}
@@ -0,0 +1,130 @@
+// Package inbox provides exactly-once processing of consumed events (Kafka,
+// NATS, ...). Brokers deliver at least once; Consumer records each message
+// ID in the inbox table in the same transaction as the handler's writes,
+// so a redelivered message is acknowledged without running the handler again.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Message is a consumed event.
+type Message struct {
+	ID      string            // Unique message ID assigned by the producer; the dedup key.
+	Topic   string            // Topic or subject the message came from.
+	Payload []byte            // Raw event payload.
+	Headers map[string]string // Transport headers.
+}
+
+// HandlerFunc processes a message. Database writes must use WithTx(tx) to be
+// committed atomically with the inbox record.
+type HandlerFunc func(ctx context.Context, tx pgx.Tx, msg Message) error
+
+// Store defines repository methods required by Consumer.
+type Store interface {
+	// MarkProcessed records the message and reports whether it is seen for the first time.
+	MarkProcessed(ctx context.Context, consumer, messageID string, opts ...repository.Option) (bool, error)
+}
+
+// Source is a broker subscription adapter.
+type Source interface {
+	// Fetch blocks until the next message is available or ctx is done.
+	Fetch(ctx context.Context) (Message, error)
+	// Ack confirms the message, so the broker does not redeliver it.
+	Ack(ctx context.Context, msg Message) error
+	// Nack asks the broker to redeliver the message later.
+	Nack(ctx context.Context, msg Message) error
+}
+
+// ErrMissingID is returned for messages without an ID, which cannot be deduplicated.
+var ErrMissingID = errors.New("message has no id")
+
+// Consumer runs a handler at most once per message ID.
+type Consumer struct {
+	name    string
+	tx      *repository.TxManager
+	store   Store
+	handler HandlerFunc
+	log     *zap.Logger
+}
+
+// NewConsumer creates a Consumer. name identifies the consumer in the inbox,
+// so different consumers of the same topic process each message independently.
+func NewConsumer(name string, tx *repository.TxManager, store Store, h HandlerFunc, log *zap.Logger) *Consumer {
+	return &Consumer{
+		name:    name,
+		tx:      tx,
+		store:   store,
+		handler: h,
+		log:     log.With(zap.String("consumer", name)),
+	}
+}
+
+// Handle processes msg unless it was already processed. The inbox record
+// and the handler's writes are committed in one transaction; if the handler
+// fails, both are rolled back and the message may be redelivered.
+func (c *Consumer) Handle(ctx context.Context, msg Message) error {
+	if msg.ID == "" {
+		return ErrMissingID
+	}
+
+	duplicate := false
+	err := c.tx.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		fresh, err := c.store.MarkProcessed(ctx, c.name, msg.ID, repository.WithTx(tx))
+		if err != nil {
+			return fmt.Errorf("failed to record message in inbox: %w", err)
+		}
+		if !fresh {
+			duplicate = true
+			return nil
+		}
+		return c.handler(ctx, tx, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	if duplicate {
+		c.log.Debug("skipped duplicate message", zap.String("id", msg.ID), zap.String("topic", msg.Topic))
+	}
+	return nil
+}
+
+// Run consumes src until ctx is canceled. Processed and duplicate messages
+// are acknowledged; failed ones are negatively acknowledged for redelivery.
+// Messages without an ID are logged and acknowledged.
+func (c *Consumer) Run(ctx context.Context, src Source) error {
+	for {
+		msg, err := src.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		err = c.Handle(ctx, msg)
+		if errors.Is(err, ErrMissingID) {
+			// Redelivery would not help, so the message is dropped.
+			c.log.Error("dropped message without id", zap.String("topic", msg.Topic))
+		} else if err != nil {
+			c.log.Error("failed to handle message",
+				zap.String("id", msg.ID), zap.String("topic", msg.Topic), zap.Error(err))
+			if err := src.Nack(ctx, msg); err != nil {
+				c.log.Error("failed to nack message", zap.String("id", msg.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		if err := src.Ack(ctx, msg); err != nil {
+			c.log.Error("failed to ack message", zap.String("id", msg.ID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store.
+type memStore map[string]bool
+
+func (s memStore) MarkProcessed(_ context.Context, consumer, id string, _ ...repository.Option) (bool, error) {
+	key := consumer + "/" + id
+	if s[key] {
+		return false, nil
+	}
+	s[key] = true
+	return true, nil
+}
+
+// sliceSource serves messages from a slice, then blocks until ctx is done.
+type sliceSource struct {
+	msgs          []Message
+	acked, nacked []string
+	cancel        context.CancelFunc
+}
+
+func (s *sliceSource) Fetch(ctx context.Context) (Message, error) {
+	if len(s.msgs) == 0 {
+		s.cancel()
+		<-ctx.Done()
+		return Message{}, ctx.Err()
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *sliceSource) Ack(_ context.Context, msg Message) error {
+	s.acked = append(s.acked, msg.ID)
+	return nil
+}
+
+func (s *sliceSource) Nack(_ context.Context, msg Message) error {
+	s.nacked = append(s.nacked, msg.ID)
+	return nil
+}
+
+func TestConsumer_Run(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	var handled []string
+	c := NewConsumer("user-deleted", repository.NewTxManager(mock), memStore{},
+		func(_ context.Context, _ pgx.Tx, msg Message) error {
+			if string(msg.Payload) == "bad" {
+				return errors.New("boom")
+			}
+			handled = append(handled, msg.ID)
+			return nil
+		}, zap.NewNop())
+
+	// m1, its redelivery and m3 commit; m2 rolls back. The message without
+	// an ID never reaches the database.
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	src := &sliceSource{
+		cancel: cancel,
+		msgs: []Message{
+			{ID: "m1"},
+			{ID: "m1"},
+			{ID: "m2", Payload: []byte("bad")},
+			{ID: ""},
+			{ID: "m3"},
+		},
+	}
+
+	require.NoError(t, c.Run(ctx, src))
+	assert.Equal(t, []string{"m1", "m3"}, handled)
+	assert.Equal(t, []string{"m1", "m1", "", "m3"}, src.acked)
+	assert.Equal(t, []string{"m2"}, src.nacked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
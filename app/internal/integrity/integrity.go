@@ -0,0 +1,149 @@
+// Package integrity periodically checks stored subscriptions for anomalies
+// constraints do not prevent, e.g. rows written before a validation was
+// added, and keeps the findings for metrics and /admin/integrity.
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"subscriptionsservice/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Check is an integrity check.
+type Check struct {
+	Name        string
+	Description string
+}
+
+// Checks are the checks Checker runs, in report order.
+var Checks = []Check{
+	{repository.CheckEndBeforeStart, "subscriptions ending before they start"},
+	{repository.CheckNegativePrice, "subscriptions with a negative price"},
+	{repository.CheckOrphanUser, "subscriptions of users missing from the users table"},
+	{repository.CheckOverlappingDuplicate, "subscriptions of a user to a service overlapping another one to the same service"},
+}
+
+// Finder runs a check, e.g. repository.IntegrityRepo.
+type Finder interface {
+	Find(ctx context.Context, check string, limit int, opts ...repository.Option) (count int, ids []int64, err error)
+}
+
+// Recorder receives the results of runs, e.g. to export them as metrics.
+type Recorder interface {
+	// Checked records the number of subscriptions a check found.
+	Checked(check string, count int)
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Checked(string, int) {}
+
+// Finding is the result of a check.
+type Finding struct {
+	Check       string  `json:"check"`
+	Description string  `json:"description"`
+	Count       int     `json:"count"`
+	SampleIDs   []int64 `json:"sample_ids"` // IDs of the first found subscriptions
+}
+
+// Report is the result of a run, with a finding for every check.
+type Report struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Duration  string    `json:"duration"`
+	Total     int       `json:"total"` // Subscriptions found by all checks
+	Findings  []Finding `json:"findings"`
+}
+
+// Option configures Checker.
+type Option func(*Checker)
+
+// WithRecorder reports the results of runs to r.
+func WithRecorder(r Recorder) Option {
+	return func(c *Checker) {
+		c.recorder = r
+	}
+}
+
+// WithSamples sets how many IDs of found subscriptions a finding lists.
+func WithSamples(n int) Option {
+	return func(c *Checker) {
+		c.samples = n
+	}
+}
+
+// Checker runs the checks and keeps the last report. It is safe for
+// concurrent use.
+type Checker struct {
+	finder   Finder
+	recorder Recorder
+	samples  int
+	log      *zap.Logger
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewChecker creates a Checker running the checks with f.
+func NewChecker(f Finder, log *zap.Logger, opts ...Option) *Checker {
+	c := &Checker{
+		finder:   f,
+		recorder: nopRecorder{},
+		samples:  10,
+		log:      log,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run runs all checks and replaces the last report. It matches lock.Func,
+// so it can run as a periodic job.
+func (c *Checker) Run(ctx context.Context) error {
+	start := c.now()
+	report := Report{CheckedAt: start.UTC(), Findings: make([]Finding, 0, len(Checks))}
+	for _, check := range Checks {
+		count, ids, err := c.finder.Find(ctx, check.Name, c.samples)
+		if err != nil {
+			return fmt.Errorf("integrity check %s: %w", check.Name, err)
+		}
+		if ids == nil {
+			ids = []int64{}
+		}
+		c.recorder.Checked(check.Name, count)
+		report.Total += count
+		report.Findings = append(report.Findings, Finding{
+			Check:       check.Name,
+			Description: check.Description,
+			Count:       count,
+			SampleIDs:   ids,
+		})
+		if count > 0 {
+			c.log.Warn("integrity check found anomalies", zap.String("check", check.Name),
+				zap.Int("count", count), zap.Int64s("sample_ids", ids))
+		}
+	}
+	report.Duration = c.now().Sub(start).Round(time.Millisecond).String()
+
+	c.mu.Lock()
+	c.last = &report
+	c.mu.Unlock()
+	return nil
+}
+
+// Last returns the report of the last run; false if none completed yet.
+func (c *Checker) Last() (Report, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil {
+		return Report{}, false
+	}
+	return *c.last, true
+}
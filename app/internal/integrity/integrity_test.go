@@ -0,0 +1,67 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeFinder finds subscriptions by check.
+type fakeFinder map[string][]int64
+
+func (f fakeFinder) Find(_ context.Context, check string, limit int, _ ...repository.Option) (int, []int64, error) {
+	if check == repository.CheckOrphanUser && f[check] == nil {
+		return 0, nil, errors.New("connection refused")
+	}
+	ids := f[check]
+	return len(ids), ids[:min(limit, len(ids))], nil
+}
+
+// recorded holds the counts recorded by check.
+type recorded map[string]int
+
+func (r recorded) Checked(check string, count int) { r[check] = count }
+
+func TestChecker_Run(t *testing.T) {
+	rec := recorded{}
+	c := NewChecker(fakeFinder{
+		repository.CheckNegativePrice: {4, 9, 12},
+		repository.CheckOrphanUser:    {},
+	}, zap.NewNop(), WithRecorder(rec), WithSamples(2))
+
+	_, ok := c.Last()
+	assert.False(t, ok)
+
+	require.NoError(t, c.Run(t.Context()))
+	report, ok := c.Last()
+	require.True(t, ok)
+	assert.Equal(t, 3, report.Total)
+	require.Len(t, report.Findings, len(Checks))
+	assert.Equal(t, Finding{
+		Check:       repository.CheckNegativePrice,
+		Description: "subscriptions with a negative price",
+		Count:       3,
+		SampleIDs:   []int64{4, 9},
+	}, report.Findings[1])
+	assert.Equal(t, []int64{}, report.Findings[0].SampleIDs)
+	assert.Equal(t, recorded{
+		repository.CheckEndBeforeStart:       0,
+		repository.CheckNegativePrice:        3,
+		repository.CheckOrphanUser:           0,
+		repository.CheckOverlappingDuplicate: 0,
+	}, rec)
+}
+
+func TestChecker_Run_Error(t *testing.T) {
+	c := NewChecker(fakeFinder{}, zap.NewNop())
+
+	assert.ErrorContains(t, c.Run(t.Context()), "integrity check orphan_user: connection refused")
+	_, ok := c.Last()
+	assert.False(t, ok, "a failed run keeps the last report")
+}
@@ -0,0 +1,125 @@
+// Package listen creates the HTTP server listener from an address spec.
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// Listen creates a listener for spec:
+//
+//	host:port or tcp://host:port — TCP address, IPv4 and IPv6 if host is empty or [::];
+//	tcp4://host:port             — IPv4 only;
+//	tcp6://host:port             — IPv6 only, e.g. tcp6://[::]:8080 in IPv6-only clusters;
+//	unix:///path/to.sock         — unix domain socket, a stale socket file is removed;
+//	systemd                      — the first socket inherited via systemd socket activation.
+//
+// IPv6 hosts are written in brackets: [::1]:8080.
+func Listen(spec string) (net.Listener, error) {
+	switch {
+	case spec == "systemd":
+		return systemdListener()
+	case strings.HasPrefix(spec, "unix://"):
+		return unixListener(strings.TrimPrefix(spec, "unix://"))
+	default:
+		network, addr := tcpAddr(spec)
+		return net.Listen(network, addr)
+	}
+}
+
+// ListenAll creates listeners for specs (see Listen). If one fails, those
+// already created are closed.
+func ListenAll(specs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(specs))
+	for _, spec := range specs {
+		l, err := Listen(spec)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("listen on %s: %w", spec, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// Check reports whether spec is well-formed without listening, so typos are
+// found when the config is loaded.
+func Check(spec string) error {
+	switch {
+	case spec == "systemd":
+		return nil
+	case strings.HasPrefix(spec, "unix://"):
+		if strings.TrimPrefix(spec, "unix://") == "" {
+			return errors.New("unix socket path is empty")
+		}
+		return nil
+	default:
+		_, addr := tcpAddr(spec)
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid address %q, expected host:port with IPv6 hosts in brackets: %w", spec, err)
+		}
+		return nil
+	}
+}
+
+// tcpAddr splits a TCP spec into the network and address for net.Listen.
+func tcpAddr(spec string) (network, addr string) {
+	for _, network := range []string{"tcp4", "tcp6", "tcp"} {
+		if addr, ok := strings.CutPrefix(spec, network+"://"); ok {
+			return network, addr
+		}
+	}
+	return "tcp", spec
+}
+
+func unixListener(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+
+	// A socket left by a previous run makes bind fail with "address already in use".
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	return net.Listen("unix", path)
+}
+
+// systemdListener returns the first listener passed by systemd
+// (see sd_listen_fds(3)): LISTEN_PID must match the process and
+// LISTEN_FDS must be at least 1.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_PID is not set for this process")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_FDS is not set")
+	}
+
+	// Do not pass the sockets on to child processes.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	defer f.Close()
+
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherit systemd socket: %w", err)
+	}
+	return l, nil
+}
@@ -0,0 +1,78 @@
+package listen
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_TCP(t *testing.T) {
+	for _, spec := range []string{"127.0.0.1:0", "tcp://127.0.0.1:0"} {
+		l, err := Listen(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, "tcp", l.Addr().Network())
+		l.Close()
+	}
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	l, err := Listen("unix://" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "unix", l.Addr().Network())
+
+	// Simulate a crash that leaves the socket file behind.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+
+	l, err = Listen("unix://" + path)
+	require.NoError(t, err, "stale socket is replaced")
+	l.Close()
+}
+
+func TestListen_SystemdWithoutSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	_, err := Listen("systemd")
+	assert.Error(t, err)
+}
+
+func TestListen_IPv6(t *testing.T) {
+	l, err := Listen("tcp6://[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback is not available:", err)
+	}
+	defer l.Close()
+	assert.Equal(t, "::1", l.Addr().(*net.TCPAddr).IP.String())
+}
+
+func TestListenAll(t *testing.T) {
+	listeners, err := ListenAll([]string{"127.0.0.1:0", "tcp4://127.0.0.1:0"})
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+	for _, l := range listeners {
+		l.Close()
+	}
+
+	first, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer first.Close()
+	_, err = ListenAll([]string{"127.0.0.1:0", first.Addr().String()})
+	assert.Error(t, err, "an address in use fails all listeners")
+}
+
+func TestCheck(t *testing.T) {
+	for _, spec := range []string{":8080", "[::]:8080", "tcp6://[::1]:8080", "tcp4://0.0.0.0:8080", "unix:///run/app.sock", "systemd"} {
+		assert.NoError(t, Check(spec), spec)
+	}
+	for _, spec := range []string{"::1:8080", "8080", "unix://", "tcp://localhost"} {
+		assert.Error(t, Check(spec), spec)
+	}
+}
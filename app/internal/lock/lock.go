@@ -0,0 +1,108 @@
+// Package lock makes periodic jobs (reminders, reports, checks) run on one
+// replica at a time. A job holds a row lock on its job_locks row while it
+// runs; replicas finding the row locked skip the run. The lock lives in a
+// transaction, so it is released when the job ends or its replica dies.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Func is a job run under a lock.
+type Func func(ctx context.Context) error
+
+// Locker runs jobs under named locks shared by all replicas.
+type Locker interface {
+	// TryDo runs fn holding the lock name unless another replica holds it
+	// (or, with MinInterval, ran the job too recently). It reports whether
+	// fn ran; fn's error is returned as is.
+	TryDo(ctx context.Context, name string, fn Func, opts ...Option) (bool, error)
+}
+
+// Option configures a TryDo call.
+type Option func(*options)
+
+type options struct {
+	minInterval time.Duration
+}
+
+// MinInterval skips the run if the job last started less than d ago on any
+// replica, so replicas whose timers fire at different moments do not run
+// it once each.
+func MinInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.minInterval = d
+	}
+}
+
+// lockNotAvailable is the SQLSTATE of FOR UPDATE NOWAIT on a locked row.
+const lockNotAvailable = "55P03"
+
+// Postgres is a Locker on the job_locks table. It works on CockroachDB too.
+type Postgres struct {
+	db  repository.Beginner
+	now func() time.Time
+}
+
+// NewPostgres creates a Locker on db. db should not be read-only guarded:
+// the lock bookkeeping is not user data.
+func NewPostgres(db repository.Beginner) *Postgres {
+	return &Postgres{db: db, now: time.Now}
+}
+
+// TryDo implements Locker. The lock is held in a transaction open while fn
+// runs; fn makes its own queries outside of it. A failed run is not
+// recorded, so with MinInterval the next attempt is not skipped.
+func (p *Postgres) TryDo(ctx context.Context, name string, fn Func, opts ...Option) (ran bool, err error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("lock %s: begin: %w", name, err)
+	}
+	defer func() {
+		if !ran || err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `INSERT INTO job_locks (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, name); err != nil {
+		return false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	var lastRun *time.Time
+	err = tx.QueryRow(ctx, `SELECT last_run_at FROM job_locks WHERE name = $1 FOR UPDATE NOWAIT`, name).Scan(&lastRun)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailable {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lock %s: %w", name, err)
+	}
+
+	start := p.now()
+	if lastRun != nil && o.minInterval > 0 && start.Sub(*lastRun) < o.minInterval {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE job_locks SET last_run_at = $2 WHERE name = $1`, name, start); err != nil {
+		return false, fmt.Errorf("lock %s: %w", name, err)
+	}
+
+	ran = true
+	if err := fn(ctx); err != nil {
+		return true, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return true, fmt.Errorf("lock %s: commit: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,139 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	insertSQL = `INSERT INTO job_locks (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`
+	selectSQL = `SELECT last_run_at FROM job_locks WHERE name = $1 FOR UPDATE NOWAIT`
+	updateSQL = `UPDATE job_locks SET last_run_at = $2 WHERE name = $1`
+)
+
+func TestPostgres_TryDo(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	newLocker := func(t *testing.T) (*Postgres, pgxmock.PgxPoolIface) {
+		mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, mock.ExpectationsWereMet())
+			mock.Close()
+		})
+		l := NewPostgres(mock)
+		l.now = func() time.Time { return now }
+		return l, mock
+	}
+	expectLock := func(mock pgxmock.PgxPoolIface, lastRun *time.Time) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insertSQL).WithArgs("reminders").WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery(selectSQL).WithArgs("reminders").
+			WillReturnRows(pgxmock.NewRows([]string{"last_run_at"}).AddRow(lastRun))
+	}
+	calls := func(n *int) Func {
+		return func(context.Context) error {
+			*n++
+			return nil
+		}
+	}
+
+	t.Run("runs", func(t *testing.T) {
+		l, mock := newLocker(t)
+		expectLock(mock, nil)
+		mock.ExpectExec(updateSQL).WithArgs("reminders", now).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+
+		n := 0
+		ran, err := l.TryDo(t.Context(), "reminders", calls(&n), MinInterval(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("held elsewhere", func(t *testing.T) {
+		l, mock := newLocker(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(insertSQL).WithArgs("reminders").WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery(selectSQL).WithArgs("reminders").
+			WillReturnError(&pgconn.PgError{Code: "55P03", Message: "could not obtain lock"})
+		mock.ExpectRollback()
+
+		n := 0
+		ran, err := l.TryDo(t.Context(), "reminders", calls(&n))
+		require.NoError(t, err)
+		assert.False(t, ran)
+		assert.Zero(t, n)
+	})
+
+	t.Run("ran recently", func(t *testing.T) {
+		l, mock := newLocker(t)
+		lastRun := now.Add(-30 * time.Minute)
+		expectLock(mock, &lastRun)
+		mock.ExpectRollback()
+
+		n := 0
+		ran, err := l.TryDo(t.Context(), "reminders", calls(&n), MinInterval(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, ran)
+		assert.Zero(t, n)
+	})
+
+	t.Run("job fails", func(t *testing.T) {
+		l, mock := newLocker(t)
+		expectLock(mock, nil)
+		mock.ExpectExec(updateSQL).WithArgs("reminders", now).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectRollback()
+
+		boom := errors.New("boom")
+		ran, err := l.TryDo(t.Context(), "reminders", func(context.Context) error { return boom })
+		assert.True(t, ran)
+		assert.ErrorIs(t, err, boom, "the run is not recorded")
+	})
+}
+
+// onceLocker lets one of the jobs sharing it run at a time.
+type onceLocker struct {
+	held atomic.Bool
+}
+
+func (l *onceLocker) TryDo(ctx context.Context, _ string, fn Func, _ ...Option) (bool, error) {
+	if !l.held.CompareAndSwap(false, true) {
+		return false, nil
+	}
+	defer l.held.Store(false)
+	return true, fn(ctx)
+}
+
+func TestPeriodic(t *testing.T) {
+	locker := &onceLocker{}
+	var runs, concurrent, maxConcurrent atomic.Int32
+	job := func(context.Context) error {
+		runs.Add(1)
+		if c := concurrent.Add(1); c > maxConcurrent.Load() {
+			maxConcurrent.Store(c)
+		}
+		time.Sleep(5 * time.Millisecond)
+		concurrent.Add(-1)
+		return nil
+	}
+
+	// Two replicas of the same job.
+	a := NewPeriodic("reminders", time.Millisecond, locker, job, zap.NewNop())
+	b := NewPeriodic("reminders", time.Millisecond, locker, job, zap.NewNop())
+	require.NoError(t, a.Start(t.Context()))
+	require.NoError(t, b.Start(t.Context()))
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	require.NoError(t, a.Stop(t.Context()))
+	require.NoError(t, b.Stop(t.Context()))
+
+	assert.EqualValues(t, 1, maxConcurrent.Load())
+}
@@ -0,0 +1,84 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/runtimeutil"
+
+	"go.uber.org/zap"
+)
+
+// Periodic runs a job every interval on one replica at a time. It is a
+// lifecycle component: Start starts the timer, Stop stops it and waits for
+// a run in progress.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	locker   Locker
+	fn       Func
+	log      *zap.Logger
+
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+// NewPeriodic creates a periodic job. name identifies its lock, so it must
+// be the same on all replicas and unique among jobs.
+func NewPeriodic(name string, interval time.Duration, locker Locker, fn Func, log *zap.Logger) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		locker:   locker,
+		fn:       fn,
+		log:      log.With(zap.String("job", name)),
+	}
+}
+
+// Start starts running the job in the background.
+func (p *Periodic) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = runtimeutil.Go(ctx, p.log, p.run, runtimeutil.WithName(p.name))
+	return nil
+}
+
+// Stop stops the job, waiting for a run in progress until ctx is done.
+func (p *Periodic) Stop(ctx context.Context) error {
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Periodic) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce runs the job now unless another replica is running it or ran it
+// within the last interval. Timers of replicas drift, so runs less than a
+// tenth of the interval early still count as due.
+func (p *Periodic) RunOnce(ctx context.Context) {
+	start := time.Now()
+	ran, err := p.locker.TryDo(ctx, p.name, p.fn, MinInterval(p.interval-p.interval/10))
+	switch {
+	case err != nil:
+		p.log.Error("periodic job failed", zap.Error(err), zap.Duration("duration", time.Since(start)))
+	case ran:
+		p.log.Info("periodic job done", zap.Duration("duration", time.Since(start)))
+	default:
+		p.log.Debug("periodic job skipped: running or ran on another replica")
+	}
+}
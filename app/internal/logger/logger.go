@@ -7,31 +7,66 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func NewLogger(level string) *zap.Logger {
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
-	case "warn":
-		zapLevel = zapcore.WarnLevel
-	case "error":
-		zapLevel = zapcore.ErrorLevel
-	default:
-		zapLevel = zapcore.InfoLevel
+// Option configures a logger created by New.
+type Option func(*options)
+
+type options struct {
+	unredacted bool
+}
+
+// WithoutRedaction turns off masking of user IDs, emails and payment
+// details (see Redact). It is meant for debugging in dev environments.
+func WithoutRedaction() Option {
+	return func(o *options) { o.unredacted = true }
+}
+
+// NewLogger creates a console logger writing to stdout at the given level.
+func NewLogger(level string, opts ...Option) *zap.Logger {
+	log, _ := New(level, opts...)
+	return log
+}
+
+// New creates a console logger writing to stdout and returns its level,
+// which can be changed at runtime (e.g. on config reload). Sensitive values
+// are redacted unless WithoutRedaction is given.
+func New(level string, opts ...Option) (*zap.Logger, zap.AtomicLevel) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
+	atomicLevel := zap.NewAtomicLevelAt(ParseLevel(level))
+
 	cfg := zap.NewDevelopmentEncoderConfig()
 	cfg.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05")
 	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	encoder := zapcore.NewConsoleEncoder(cfg)
 
-	core := zapcore.NewCore(
+	var core zapcore.Core = zapcore.NewCore(
 		encoder,
 		zapcore.AddSync(os.Stdout),
-		zapLevel,
+		atomicLevel,
 	)
+	if !o.unredacted {
+		core = Redact(core)
+	}
 
-	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)), atomicLevel
+}
+
+// ParseLevel converts a level name (debug, info, warn, error) to a zap level.
+// Unknown names map to info.
+func ParseLevel(level string) zapcore.Level {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
 }
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Masks written instead of redacted values.
+const (
+	Redacted    = "[redacted]"
+	maskedEmail = "[email]"
+	maskedUser  = "[uuid]"
+	maskedCard  = "[card]"
+)
+
+// sensitiveKeys are field keys whose values are always masked: user
+// identifiers, contact details and payment information.
+var sensitiveKeys = map[string]bool{
+	"user_id":        true,
+	"user":           true,
+	"actor":          true,
+	"email":          true,
+	"emails":         true,
+	"phone":          true,
+	"card":           true,
+	"card_number":    true,
+	"pan":            true,
+	"iban":           true,
+	"account_number": true,
+	"payment":        true,
+	"payment_method": true,
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Users are identified by UUIDs; other entities have numeric IDs.
+	uuidPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	// Card numbers: 13-19 digits, optionally grouped by spaces or dashes;
+	// only those passing the Luhn check are masked.
+	cardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// RedactText masks emails, UUIDs and card numbers in s.
+func RedactText(s string) string {
+	if s == "" {
+		return s
+	}
+	s = emailPattern.ReplaceAllString(s, maskedEmail)
+	s = uuidPattern.ReplaceAllString(s, maskedUser)
+	return cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return maskedCard
+		}
+		return m
+	})
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Redact wraps core so that fields with sensitive keys (see sensitiveKeys)
+// are replaced with Redacted, and emails, UUIDs and card numbers in
+// messages, string fields, errors and map values are masked.
+func Redact(core zapcore.Core) zapcore.Core {
+	return redactCore{core}
+}
+
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = RedactText(ent.Message)
+	ent.Stack = RedactText(ent.Stack)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = redactField(f)
+	}
+	return out
+}
+
+func redactField(f zapcore.Field) zapcore.Field {
+	if sensitiveKeys[strings.ToLower(f.Key)] {
+		return zap.String(f.Key, Redacted)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = RedactText(f.String)
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			f.Interface = redactedError{err}
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, RedactText(s.String()))
+		}
+	case zapcore.ReflectType:
+		if m, ok := f.Interface.(map[string]any); ok {
+			f.Interface = redactMap(m)
+		}
+	}
+	return f
+}
+
+// redactMap returns a copy of m with the values of sensitive keys replaced
+// and text masked, e.g. for audit event details.
+func redactMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if sensitiveKeys[strings.ToLower(k)] {
+			out[k] = Redacted
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			out[k] = RedactText(v)
+		case fmt.Stringer:
+			out[k] = RedactText(v.String())
+		case map[string]any:
+			out[k] = redactMap(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// redactedError masks the message of an error. It still matches the
+// wrapped error with errors.Is and errors.As.
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string { return RedactText(e.err.Error()) }
+
+func (e redactedError) Unwrap() error { return e.err }
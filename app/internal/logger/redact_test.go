@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactText(t *testing.T) {
+	for in, want := range map[string]string{
+		"sent to ivan.petrov@example.com":                      "sent to [email]",
+		"check user 1b4e28ba-2fa1-11d2-883f-0016d3cca427: 500": "check user [uuid]: 500",
+		"card 4111 1111 1111 1111 declined":                    "card [card] declined",
+		"card 4111-1111-1111-1111 declined":                    "card [card] declined",
+		"request took 1234567890123 ns":                        "request took 1234567890123 ns",
+		"subscription 42 updated":                              "subscription 42 updated",
+	} {
+		assert.Equal(t, want, RedactText(in), in)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	log := zap.New(Redact(core)).With(zap.String("email", "a@b.io"))
+	user := uuid.New()
+	err := errors.New("check user " + user.String() + ": timeout")
+
+	log.Warn("subscription of "+user.String()+" rejected",
+		zap.String("user_id", user.String()),
+		zap.Stringer("actor", user),
+		zap.String("service_name", "Yandex Plus"),
+		zap.Error(err),
+		zap.Any("details", map[string]any{"user": user.String(), "from": 100, "note": "by x@y.com"}),
+	)
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "subscription of [uuid] rejected", entry.Message)
+	fields := entry.ContextMap()
+	assert.Equal(t, Redacted, fields["email"])
+	assert.Equal(t, Redacted, fields["user_id"])
+	assert.Equal(t, Redacted, fields["actor"])
+	assert.Equal(t, "Yandex Plus", fields["service_name"])
+	assert.Equal(t, "check user [uuid]: timeout", fields["error"])
+	assert.Equal(t, map[string]any{"user": Redacted, "from": 100, "note": "by [email]"}, fields["details"])
+
+	for _, f := range entry.Context {
+		if f.Key == "error" {
+			assert.ErrorIs(t, f.Interface.(error), err)
+		}
+	}
+}
+
+func TestNew_WithoutRedaction(t *testing.T) {
+	_, redacted := NewLogger("info").Core().(redactCore)
+	assert.True(t, redacted)
+	_, redacted = NewLogger("info", WithoutRedaction()).Core().(redactCore)
+	assert.False(t, redacted)
+}
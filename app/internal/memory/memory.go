@@ -0,0 +1,139 @@
+// Package memory tunes the Go runtime's memory limit and garbage collector
+// and reports memory statistics, to keep the service within its container
+// limit, e.g. during large exports.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/runtimeutil"
+
+	"go.uber.org/zap"
+)
+
+// ErrInvalidLimit is returned by ParseLimit for malformed limits.
+var ErrInvalidLimit = errors.New("invalid memory limit")
+
+// limitUnits are the suffixes accepted by ParseLimit, as by GOMEMLIMIT.
+var limitUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseLimit parses a memory limit in GOMEMLIMIT syntax: a number of bytes
+// with an optional B, KiB, MiB, GiB or TiB suffix, e.g. "900MiB".
+func ParseLimit(s string) (int64, error) {
+	n, unit := s, int64(1)
+	for _, u := range limitUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			n, unit = strings.TrimSuffix(s, u.suffix), u.bytes
+			break
+		}
+	}
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || v <= 0 || v > math.MaxInt64/unit {
+		return 0, fmt.Errorf("%w %q, expected e.g. 900MiB", ErrInvalidLimit, s)
+	}
+	return v * unit, nil
+}
+
+// Settings are the runtime memory settings.
+type Settings struct {
+	// Limit is the soft memory limit in bytes; 0 keeps GOMEMLIMIT or none.
+	Limit int64
+	// GCPercent is the heap growth triggering a collection, as GOGC; -1
+	// collects only at the limit, 0 keeps GOGC or 100.
+	GCPercent int
+}
+
+// Apply sets the runtime memory settings given in s and returns the ones in
+// effect.
+func Apply(s Settings) Settings {
+	if s.Limit > 0 {
+		debug.SetMemoryLimit(s.Limit)
+	}
+	if s.GCPercent != 0 {
+		debug.SetGCPercent(s.GCPercent)
+	}
+	gcPercent := debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+	return Settings{Limit: debug.SetMemoryLimit(-1), GCPercent: gcPercent}
+}
+
+// Reporter logs runtime memory statistics periodically. It is a lifecycle
+// component: Start starts logging, Stop stops it.
+type Reporter struct {
+	log      *zap.Logger
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+// NewReporter creates a reporter logging every interval.
+func NewReporter(log *zap.Logger, interval time.Duration) *Reporter {
+	return &Reporter{log: log, interval: interval}
+}
+
+// Start starts logging in the background.
+func (r *Reporter) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = runtimeutil.Go(ctx, r.log, r.run, runtimeutil.WithName("memory stats"))
+	return nil
+}
+
+// Stop stops logging, waiting for the logging goroutine until ctx is done.
+func (r *Reporter) Stop(ctx context.Context) error {
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Reporter) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Report()
+		}
+	}
+}
+
+// Report logs the current memory statistics.
+func (r *Reporter) Report() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r.log.Info("memory stats",
+		zap.Uint64("heap_alloc_bytes", m.HeapAlloc),
+		zap.Uint64("heap_inuse_bytes", m.HeapInuse),
+		zap.Uint64("heap_sys_bytes", m.HeapSys),
+		zap.Uint64("sys_bytes", m.Sys),
+		zap.Uint64("next_gc_bytes", m.NextGC),
+		zap.Int64("memory_limit_bytes", debug.SetMemoryLimit(-1)),
+		zap.Uint32("num_gc", m.NumGC),
+		zap.Float64("gc_cpu_fraction", m.GCCPUFraction),
+		zap.Int("goroutines", runtime.NumGoroutine()),
+	)
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1024", 1024},
+		{"512B", 512},
+		{"64KiB", 64 << 10},
+		{"900MiB", 900 << 20},
+		{"2GiB", 2 << 30},
+		{"1TiB", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, in := range []string{"", "MiB", "1.5GiB", "-1MiB", "0", "1GB", "9999999TiB"} {
+		_, err := ParseLimit(in)
+		assert.ErrorIs(t, err, ErrInvalidLimit, in)
+	}
+}
+
+func TestApply(t *testing.T) {
+	limit, gcPercent := debug.SetMemoryLimit(-1), debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(limit)
+		debug.SetGCPercent(gcPercent)
+	})
+
+	got := Apply(Settings{Limit: 512 << 20, GCPercent: 50})
+	assert.Equal(t, Settings{Limit: 512 << 20, GCPercent: 50}, got)
+
+	assert.Equal(t, got, Apply(Settings{}), "zero settings keep the current ones")
+}
+
+func TestReporter(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	r := NewReporter(zap.New(core), time.Millisecond)
+
+	require.NoError(t, r.Start(t.Context()))
+	assert.Eventually(t, func() bool { return logs.FilterMessage("memory stats").Len() > 0 }, time.Second, time.Millisecond)
+	require.NoError(t, r.Stop(t.Context()))
+
+	entry := logs.FilterMessage("memory stats").All()[0]
+	assert.Contains(t, entry.ContextMap(), "heap_alloc_bytes")
+	assert.Contains(t, entry.ContextMap(), "memory_limit_bytes")
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/runtimeutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// otherServices labels the services outside the top ones by count.
+const otherServices = "other"
+
+// ActivitySource reports the subscriptions active in a month by service,
+// e.g. repository.AnalyticsRepo.
+type ActivitySource interface {
+	ActiveByService(ctx context.Context, month models.MonthDate, opts ...repository.Option) ([]models.ServiceActivity, error)
+}
+
+// BusinessMetrics exports product KPIs of the subscriptions active in the
+// current month. It is a lifecycle component: Start refreshes them every
+// interval, Stop stops it. Every replica refreshes its own, so aggregate
+// with max across replicas rather than sum.
+type BusinessMetrics struct {
+	src         ActivitySource
+	interval    time.Duration
+	topServices int
+	log         *zap.Logger
+	now         func() time.Time
+
+	cancel context.CancelFunc
+	done   <-chan struct{}
+
+	active    prometheus.Gauge
+	spend     *prometheus.GaugeVec
+	services  *prometheus.GaugeVec
+	refreshed prometheus.Gauge
+}
+
+// NewBusinessMetrics creates business metrics refreshed from src every
+// interval and registers them in reg. Per-service counts are exported for
+// the topServices services with most subscriptions, the rest are summed up
+// as "other" to bound the label cardinality.
+func NewBusinessMetrics(src ActivitySource, interval time.Duration, topServices int, log *zap.Logger, reg prometheus.Registerer) *BusinessMetrics {
+	m := &BusinessMetrics{
+		src:         src,
+		interval:    interval,
+		topServices: topServices,
+		log:         log,
+		now:         time.Now,
+		active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "business",
+			Name:      "active_subscriptions",
+			Help:      "Subscriptions active in the current month.",
+		}),
+		spend: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "business",
+			Name:      "monthly_spend",
+			Help:      "Total monthly price of the active subscriptions in major units of the currency.",
+		}, []string{"currency"}),
+		services: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "business",
+			Name:      "service_subscriptions",
+			Help:      "Active subscriptions by service; services outside the top ones are labeled other.",
+		}, []string{"service"}),
+		refreshed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "business",
+			Name:      "refreshed_timestamp_seconds",
+			Help:      "Unix time of the last successful refresh of the business metrics.",
+		}),
+	}
+	reg.MustRegister(m.active, m.spend, m.services, m.refreshed)
+	return m
+}
+
+// Start refreshes the metrics now and then every interval in the
+// background.
+func (m *BusinessMetrics) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = runtimeutil.Go(ctx, m.log, m.run, runtimeutil.WithName("business metrics"))
+	return nil
+}
+
+// Stop stops refreshing, waiting for a refresh in progress until ctx is
+// done.
+func (m *BusinessMetrics) Stop(ctx context.Context) error {
+	m.cancel()
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *BusinessMetrics) run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+			m.log.Warn("business metrics refresh failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh queries the subscriptions active in the current month and
+// updates the metrics; on error they keep the previous values.
+func (m *BusinessMetrics) Refresh(ctx context.Context) error {
+	now := m.now().UTC()
+	month := models.MonthDate{Time: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)}
+	activity, err := m.src.ActiveByService(ctx, month)
+	if err != nil {
+		return fmt.Errorf("refresh business metrics: %w", err)
+	}
+
+	total := 0
+	spend := make(map[models.Currency]int64)
+	counts := make(map[string]int)
+	for _, a := range activity {
+		total += a.Count
+		spend[a.Currency.OrDefault()] += a.Spend
+		counts[a.ServiceName] += a.Count
+	}
+
+	m.active.Set(float64(total))
+	m.spend.Reset()
+	for c, minor := range spend {
+		m.spend.WithLabelValues(string(c)).Set(float64(minor) / math.Pow10(c.MinorUnits()))
+	}
+	m.services.Reset()
+	for service, count := range m.top(counts) {
+		m.services.WithLabelValues(service).Set(float64(count))
+	}
+	m.refreshed.Set(float64(now.Unix()))
+	return nil
+}
+
+// top keeps the topServices services with most subscriptions, ties broken
+// by name, and sums up the rest as other.
+func (m *BusinessMetrics) top(counts map[string]int) map[string]int {
+	if len(counts) <= m.topServices {
+		return counts
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	top := make(map[string]int, m.topServices+1)
+	for i, name := range names {
+		if i < m.topServices {
+			top[name] = counts[name]
+		} else {
+			top[otherServices] += counts[name]
+		}
+	}
+	return top
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeActivity returns activity for August 2025 only.
+type fakeActivity []models.ServiceActivity
+
+func (f fakeActivity) ActiveByService(_ context.Context, month models.MonthDate, _ ...repository.Option) ([]models.ServiceActivity, error) {
+	if month.Time != time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC) {
+		return nil, errors.New("unexpected month " + month.Time.String())
+	}
+	return f, nil
+}
+
+func TestBusinessMetrics_Refresh(t *testing.T) {
+	m := NewBusinessMetrics(fakeActivity{
+		{ServiceName: "Netflix", Currency: "RUB", Count: 3, Spend: 149700},
+		{ServiceName: "Netflix", Currency: "USD", Count: 1, Spend: 1599},
+		{ServiceName: "Spotify", Currency: "RUB", Count: 2, Spend: 33800},
+		{ServiceName: "Yandex Plus", Currency: "RUB", Count: 1, Spend: 39900},
+		{ServiceName: "Kinopoisk", Currency: "RUB", Count: 1, Spend: 29900},
+	}, time.Minute, 2, zap.NewNop(), prometheus.NewRegistry())
+	m.now = func() time.Time { return time.Date(2025, time.August, 17, 9, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, m.Refresh(t.Context()))
+
+	assert.Equal(t, 8.0, testutil.ToFloat64(m.active))
+	assert.Equal(t, 2533.0, testutil.ToFloat64(m.spend.WithLabelValues("RUB")))
+	assert.Equal(t, 15.99, testutil.ToFloat64(m.spend.WithLabelValues("USD")))
+	assert.Equal(t, 4.0, testutil.ToFloat64(m.services.WithLabelValues("Netflix")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.services.WithLabelValues("Spotify")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.services.WithLabelValues("other")), "Kinopoisk and Yandex Plus")
+	assert.Equal(t, 3, testutil.CollectAndCount(m.services))
+	assert.Equal(t, float64(m.now().Unix()), testutil.ToFloat64(m.refreshed))
+}
+
+func TestBusinessMetrics_Refresh_Error(t *testing.T) {
+	m := NewBusinessMetrics(fakeActivity{}, time.Minute, 20, zap.NewNop(), prometheus.NewRegistry())
+	m.now = func() time.Time { return time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC) }
+
+	assert.ErrorContains(t, m.Refresh(t.Context()), "refresh business metrics: unexpected month")
+	assert.Zero(t, testutil.ToFloat64(m.refreshed), "a failed refresh keeps the metrics")
+}
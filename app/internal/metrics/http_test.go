@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	m := NewHTTPMetrics(prometheus.NewRegistry())
+
+	m.ObserveRequest("GET", "/subscriptions/:id", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/subscriptions/:id", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/subscriptions/:id", 404, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/subscriptions/:id", "200")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.duration))
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// IntegrityMetrics exports the subscriptions integrity checks found by
+// check, see integrity.Checker. Only the replica running the job updates
+// them, so alert on the maximum across replicas.
+type IntegrityMetrics struct {
+	findings *prometheus.GaugeVec
+}
+
+// NewIntegrityMetrics creates integrity metrics and registers them in reg.
+func NewIntegrityMetrics(reg prometheus.Registerer) *IntegrityMetrics {
+	m := &IntegrityMetrics{
+		findings: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "integrity",
+			Name:      "findings",
+			Help:      "Subscriptions found by the last run of each integrity check.",
+		}, []string{"check"}),
+	}
+	reg.MustRegister(m.findings)
+	return m
+}
+
+// Checked implements integrity.Recorder.
+func (m *IntegrityMetrics) Checked(check string, count int) {
+	m.findings.WithLabelValues(check).Set(float64(count))
+}
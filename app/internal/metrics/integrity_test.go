@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrityMetrics(t *testing.T) {
+	m := NewIntegrityMetrics(prometheus.NewRegistry())
+
+	m.Checked("negative_price", 3)
+	m.Checked("negative_price", 1)
+	m.Checked("orphan_user", 0)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.findings.WithLabelValues("negative_price")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.findings))
+}
@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InstrumentedRepo decorates a service.SubscriptionRepo with per-method
+// duration, error class, returned rows and retry attempts metrics.
+type InstrumentedRepo struct {
+	next service.SubscriptionRepo
+
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	rows     *prometheus.HistogramVec
+	attempts *prometheus.HistogramVec
+}
+
+// NewInstrumentedRepo wraps next and registers its metrics in reg.
+func NewInstrumentedRepo(next service.SubscriptionRepo, reg prometheus.Registerer) *InstrumentedRepo {
+	r := &InstrumentedRepo{
+		next: next,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repo",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of repository operations, including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repo",
+			Name:      "errors_total",
+			Help:      "Failed repository operations by error class.",
+		}, []string{"method", "class"}),
+		rows: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repo",
+			Name:      "rows_returned",
+			Help:      "Number of rows returned by repository read operations.",
+			Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000},
+		}, []string{"method"}),
+		attempts: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repo",
+			Name:      "failed_attempts",
+			Help:      "Attempts made by repository operations that gave up retrying.",
+			Buckets:   []float64{1, 2, 3, 5, 10},
+		}, []string{"method", "reason"}),
+	}
+
+	reg.MustRegister(r.duration, r.errors, r.rows, r.attempts)
+
+	return r
+}
+
+// observe records duration and error class of a finished call.
+func (r *InstrumentedRepo) observe(method string, start time.Time, err error) {
+	r.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.errors.WithLabelValues(method, errorClass(err)).Inc()
+
+		var rerr *retry.RetryError
+		if errors.As(err, &rerr) {
+			r.attempts.WithLabelValues(method, rerr.Reason.String()).Observe(float64(rerr.Attempts))
+		}
+	}
+}
+
+// CreateSubscription implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) CreateSubscription(ctx context.Context, s *models.Subscription, opts ...repository.Option) error {
+	start := time.Now()
+	err := r.next.CreateSubscription(ctx, s, opts...)
+	r.observe("CreateSubscription", start, err)
+	return err
+}
+
+// GetByID implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := r.next.GetByID(ctx, id, opts...)
+	r.observe("GetByID", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("GetByID").Observe(1)
+	}
+	return sub, err
+}
+
+// GetByKey implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) GetByKey(ctx context.Context, userID uuid.UUID, serviceName string, startDate models.MonthDate, opts ...repository.Option) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := r.next.GetByKey(ctx, userID, serviceName, startDate, opts...)
+	r.observe("GetByKey", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("GetByKey").Observe(1)
+	}
+	return sub, err
+}
+
+// Exists implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) Exists(ctx context.Context, id int64, opts ...repository.Option) (bool, error) {
+	start := time.Now()
+	exists, err := r.next.Exists(ctx, id, opts...)
+	r.observe("Exists", start, err)
+	return exists, err
+}
+
+// CountActive implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) CountActive(ctx context.Context, userID uuid.UUID, month models.MonthDate, opts ...repository.Option) (int, error) {
+	start := time.Now()
+	count, err := r.next.CountActive(ctx, userID, month, opts...)
+	r.observe("CountActive", start, err)
+	return count, err
+}
+
+// List implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) List(ctx context.Context, filter models.SubscriptionFilter, limit, offset int, opts ...repository.Option) ([]models.Subscription, error) {
+	start := time.Now()
+	subs, err := r.next.List(ctx, filter, limit, offset, opts...)
+	r.observe("List", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("List").Observe(float64(len(subs)))
+	}
+	return subs, err
+}
+
+// ActiveOn implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) ActiveOn(ctx context.Context, month models.MonthDate, filter models.SubscriptionFilter, limit, offset int, opts ...repository.Option) ([]models.Subscription, error) {
+	start := time.Now()
+	subs, err := r.next.ActiveOn(ctx, month, filter, limit, offset, opts...)
+	r.observe("ActiveOn", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("ActiveOn").Observe(float64(len(subs)))
+	}
+	return subs, err
+}
+
+// DistinctServices implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) DistinctServices(ctx context.Context, prefix string, limit, offset int, opts ...repository.Option) ([]models.ServiceCount, error) {
+	start := time.Now()
+	services, err := r.next.DistinctServices(ctx, prefix, limit, offset, opts...)
+	r.observe("DistinctServices", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("DistinctServices").Observe(float64(len(services)))
+	}
+	return services, err
+}
+
+// Overlaps implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) Overlaps(ctx context.Context, userID uuid.UUID, opts ...repository.Option) ([]models.Overlap, error) {
+	start := time.Now()
+	overlaps, err := r.next.Overlaps(ctx, userID, opts...)
+	r.observe("Overlaps", start, err)
+	return overlaps, err
+}
+
+// Update implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) Update(ctx context.Context, s *models.Subscription, opts ...repository.Option) error {
+	start := time.Now()
+	err := r.next.Update(ctx, s, opts...)
+	r.observe("Update", start, err)
+	return err
+}
+
+// UpdatePartial implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) UpdatePartial(ctx context.Context, id int64, p models.SubscriptionPatch, opts ...repository.Option) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := r.next.UpdatePartial(ctx, id, p, opts...)
+	r.observe("UpdatePartial", start, err)
+	return sub, err
+}
+
+// Delete implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) Delete(ctx context.Context, id int64, opts ...repository.Option) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id, opts...)
+	r.observe("Delete", start, err)
+	return err
+}
+
+// DeleteReturning implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) DeleteReturning(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := r.next.DeleteReturning(ctx, id, opts...)
+	r.observe("DeleteReturning", start, err)
+	if err == nil {
+		r.rows.WithLabelValues("DeleteReturning").Observe(1)
+	}
+	return sub, err
+}
+
+// Summary implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) Summary(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.Summary, error) {
+	start := time.Now()
+	sum, err := r.next.Summary(ctx, q, opts...)
+	r.observe("Summary", start, err)
+	return sum, err
+}
+
+// ExplainSummary implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) ExplainSummary(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.Summary, error) {
+	start := time.Now()
+	sum, err := r.next.ExplainSummary(ctx, q, opts...)
+	r.observe("ExplainSummary", start, err)
+	return sum, err
+}
+
+// SummaryBreakdown implements service.SubscriptionRepo.
+func (r *InstrumentedRepo) SummaryBreakdown(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.SummaryBreakdown, error) {
+	start := time.Now()
+	b, err := r.next.SummaryBreakdown(ctx, q, opts...)
+	r.observe("SummaryBreakdown", start, err)
+	return b, err
+}
+
+// errorClass maps an error to a low-cardinality label value.
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, repository.ErrDuplicate):
+		return "duplicate"
+	case errors.Is(err, repository.ErrForeignKeyViolation):
+		return "foreign_key"
+	case errors.Is(err, repository.ErrTxAborted):
+		return "tx_aborted"
+	case errors.Is(err, repository.ErrSerialization):
+		return "serialization"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{repository.ErrNotFound, "not_found"},
+		{fmt.Errorf("unretryable error: %w", repository.ErrDuplicate), "duplicate"},
+		{repository.ErrForeignKeyViolation, "foreign_key"},
+		{repository.ErrTxAborted, "tx_aborted"},
+		{fmt.Errorf("%w: restart transaction", repository.ErrSerialization), "serialization"},
+		{context.Canceled, "canceled"},
+		{fmt.Errorf("all attempts failed: %w", context.DeadlineExceeded), "timeout"},
+		{&retry.RetryError{Attempts: 3, Reason: retry.StopExhausted, LastErr: repository.ErrTxAborted}, "tx_aborted"},
+		{errors.New("boom"), "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorClass(tt.err))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"subscriptionsservice/internal/retry"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RetryMetrics exports results of retry.Retrier calls, labeled by retrier
+// name, so rising retry rates are visible before they turn into outages.
+type RetryMetrics struct {
+	calls    *prometheus.CounterVec
+	attempts *prometheus.HistogramVec
+	retried  *prometheus.CounterVec
+	backoff  *prometheus.CounterVec
+	giveUps  *prometheus.CounterVec
+}
+
+// NewRetryMetrics creates retry metrics and registers them in reg.
+func NewRetryMetrics(reg prometheus.Registerer) *RetryMetrics {
+	m := &RetryMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "retry",
+			Name:      "calls_total",
+			Help:      "Operations run with retries.",
+		}, []string{"retrier"}),
+		attempts: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "retry",
+			Name:      "attempts",
+			Help:      "Attempts made per operation.",
+			Buckets:   []float64{1, 2, 3, 5, 10},
+		}, []string{"retrier"}),
+		retried: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "retry",
+			Name:      "success_after_retry_total",
+			Help:      "Operations that succeeded after a failed attempt.",
+		}, []string{"retrier"}),
+		backoff: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "retry",
+			Name:      "backoff_seconds_total",
+			Help:      "Time slept between attempts.",
+		}, []string{"retrier"}),
+		giveUps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "retry",
+			Name:      "give_ups_total",
+			Help:      "Operations that failed, by the reason retrying stopped.",
+		}, []string{"retrier", "reason"}),
+	}
+
+	reg.MustRegister(m.calls, m.attempts, m.retried, m.backoff, m.giveUps)
+
+	return m
+}
+
+// Recorder returns a retry.MetricsRecorder labeling results with name.
+func (m *RetryMetrics) Recorder(name string) retry.MetricsRecorder {
+	return retryRecorder{m: m, name: name}
+}
+
+type retryRecorder struct {
+	m    *RetryMetrics
+	name string
+}
+
+// Record implements retry.MetricsRecorder.
+func (r retryRecorder) Record(res retry.Result) {
+	r.m.calls.WithLabelValues(r.name).Inc()
+	r.m.attempts.WithLabelValues(r.name).Observe(float64(res.Attempts))
+	r.m.backoff.WithLabelValues(r.name).Add(res.Backoff.Seconds())
+	switch {
+	case res.Retried():
+		r.m.retried.WithLabelValues(r.name).Inc()
+	case !res.Success:
+		r.m.giveUps.WithLabelValues(r.name, res.Reason.String()).Inc()
+	}
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryMetrics(t *testing.T) {
+	m := NewRetryMetrics(prometheus.NewRegistry())
+	rec := m.Recorder("read")
+
+	rec.Record(retry.Result{Attempts: 1, Success: true})
+	rec.Record(retry.Result{Attempts: 2, Backoff: time.Second, Success: true})
+	rec.Record(retry.Result{Attempts: 3, Backoff: 2 * time.Second, Reason: retry.StopExhausted})
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.calls.WithLabelValues("read")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.retried.WithLabelValues("read")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.backoff.WithLabelValues("read")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.giveUps.WithLabelValues("read", "exhausted")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.attempts))
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"time"
+
+	"subscriptionsservice/internal/workerpool"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WorkerPoolMetrics exports task events of worker pools labeled by pool
+// name: a growing queue or rejections mean the pool is too small.
+type WorkerPoolMetrics struct {
+	queued   *prometheus.GaugeVec
+	running  *prometheus.GaugeVec
+	rejected *prometheus.CounterVec
+	tasks    *prometheus.CounterVec
+	wait     *prometheus.HistogramVec
+	duration *prometheus.HistogramVec
+}
+
+// NewWorkerPoolMetrics creates worker pool metrics and registers them in reg.
+func NewWorkerPoolMetrics(reg prometheus.Registerer) *WorkerPoolMetrics {
+	m := &WorkerPoolMetrics{
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "queued_tasks",
+			Help:      "Tasks waiting for a worker.",
+		}, []string{"pool"}),
+		running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "running_tasks",
+			Help:      "Tasks being run by workers.",
+		}, []string{"pool"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "rejected_tasks_total",
+			Help:      "Tasks not accepted because the queue was full or the pool stopped.",
+		}, []string{"pool"}),
+		tasks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "tasks_total",
+			Help:      "Finished tasks by result.",
+		}, []string{"pool", "result"}),
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "queue_wait_seconds",
+			Help:      "Time tasks waited for a worker.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"pool"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "workerpool",
+			Name:      "task_duration_seconds",
+			Help:      "Time tasks ran.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"pool"}),
+	}
+
+	reg.MustRegister(m.queued, m.running, m.rejected, m.tasks, m.wait, m.duration)
+
+	return m
+}
+
+// Recorder returns a workerpool.MetricsRecorder labeling events with name.
+func (m *WorkerPoolMetrics) Recorder(name string) workerpool.MetricsRecorder {
+	return poolRecorder{m: m, name: name}
+}
+
+type poolRecorder struct {
+	m    *WorkerPoolMetrics
+	name string
+}
+
+// Queued implements workerpool.MetricsRecorder.
+func (r poolRecorder) Queued() {
+	r.m.queued.WithLabelValues(r.name).Inc()
+}
+
+// Rejected implements workerpool.MetricsRecorder.
+func (r poolRecorder) Rejected() {
+	r.m.rejected.WithLabelValues(r.name).Inc()
+}
+
+// Started implements workerpool.MetricsRecorder.
+func (r poolRecorder) Started(wait time.Duration) {
+	r.m.queued.WithLabelValues(r.name).Dec()
+	r.m.running.WithLabelValues(r.name).Inc()
+	r.m.wait.WithLabelValues(r.name).Observe(wait.Seconds())
+}
+
+// Finished implements workerpool.MetricsRecorder.
+func (r poolRecorder) Finished(d time.Duration, err error) {
+	r.m.running.WithLabelValues(r.name).Dec()
+	r.m.duration.WithLabelValues(r.name).Observe(d.Seconds())
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	r.m.tasks.WithLabelValues(r.name, result).Inc()
+}
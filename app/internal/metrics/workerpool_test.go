@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	m := NewWorkerPoolMetrics(prometheus.NewRegistry())
+	rec := m.Recorder("background")
+
+	rec.Queued()
+	rec.Queued()
+	rec.Rejected()
+	rec.Started(time.Second)
+	rec.Finished(time.Second, errors.New("boom"))
+	rec.Started(time.Second)
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.queued.WithLabelValues("background")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.running.WithLabelValues("background")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rejected.WithLabelValues("background")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.tasks.WithLabelValues("background", "error")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.duration))
+}
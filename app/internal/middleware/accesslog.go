@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// unmatchedRoute labels requests matching no route, so scans of random
+// paths do not create a label per path.
+const unmatchedRoute = "unmatched"
+
+// Route returns the route pattern of the request, e.g. /subscriptions/:id
+// for /subscriptions/12345, to be used in logs and metric labels instead of
+// the path: patterns are few, while paths carry IDs.
+func Route(c *gin.Context) string {
+	if p := c.FullPath(); p != "" {
+		return p
+	}
+	return unmatchedRoute
+}
+
+// AccessLogConfig configures AccessLog.
+type AccessLogConfig struct {
+	SampleRate    float64       // Fraction of other requests logged, 1 — all.
+	SlowThreshold time.Duration // Slower requests are always logged, 0 — none are slow.
+}
+
+// AccessLog logs served requests with their route pattern (see Route).
+// Server errors and slow requests are always logged; others are sampled.
+// Each logged line carries the number of requests to the same route
+// skipped since the previous line, so the volume can still be estimated.
+func AccessLog(log *zap.Logger, cfg AccessLogConfig) gin.HandlerFunc {
+	var skipped sync.Map // method + route -> *atomic.Int64
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		status := c.Writer.Status()
+		route := Route(c)
+		counter, _ := skipped.LoadOrStore(routeKey(c.Request.Method, route), new(atomic.Int64))
+
+		slow := cfg.SlowThreshold > 0 && elapsed >= cfg.SlowThreshold
+		if status < http.StatusInternalServerError && !slow && rand.Float64() >= cfg.SampleRate {
+			counter.(*atomic.Int64).Add(1)
+			return
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.Int("status", status),
+			zap.Duration("duration", elapsed),
+			zap.Int("size", c.Writer.Size()),
+		}
+		if n := counter.(*atomic.Int64).Swap(0); n > 0 {
+			fields = append(fields, zap.Int64("skipped", n))
+		}
+		switch {
+		case status >= http.StatusInternalServerError:
+			log.Error("request", fields...)
+		case slow:
+			log.Warn("slow request", fields...)
+		default:
+			log.Info("request", fields...)
+		}
+	}
+}
+
+// RequestRecorder receives served requests, e.g. to export them as metrics.
+type RequestRecorder interface {
+	ObserveRequest(method, route string, status int, d time.Duration)
+}
+
+// Metrics reports served requests to rec, labeled by route pattern (see
+// Route) to keep the number of label values bounded.
+func Metrics(rec RequestRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		rec.ObserveRequest(c.Request.Method, Route(c), c.Writer.Status(), time.Since(start))
+	}
+}
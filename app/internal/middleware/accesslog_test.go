@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.InfoLevel)
+	e := gin.New()
+	e.Use(AccessLog(zap.New(core), AccessLogConfig{SampleRate: 0, SlowThreshold: 20 * time.Millisecond}))
+	e.GET("/items/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "fail":
+			c.Status(http.StatusInternalServerError)
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+			c.Status(http.StatusOK)
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/fail", "/missing/1", "/items/slow"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.All()
+	require.Len(t, entries, 2, "only failed and slow requests are logged")
+
+	failed := entries[0].ContextMap()
+	assert.Equal(t, "/items/:id", failed["route"], "IDs are not logged")
+	assert.Equal(t, int64(http.StatusInternalServerError), failed["status"])
+	assert.Equal(t, int64(2), failed["skipped"])
+
+	slow := entries[1]
+	assert.Equal(t, "slow request", slow.Message)
+	assert.NotContains(t, slow.ContextMap(), "skipped", "the counter is reset")
+}
+
+// fakeRecorder records observed routes.
+type fakeRecorder struct {
+	routes []string
+}
+
+func (r *fakeRecorder) ObserveRequest(method, route string, status int, _ time.Duration) {
+	r.routes = append(r.routes, method+" "+route)
+}
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := &fakeRecorder{}
+	e := gin.New()
+	e.Use(Metrics(rec))
+	e.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/items/1", "/items/2", "/random/path"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, []string{"GET /items/:id", "GET /items/:id", "GET unmatched"}, rec.routes)
+}
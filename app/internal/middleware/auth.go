@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BearerToken rejects requests whose Authorization header does not carry
+// "Bearer <token>" with 401.
+func BearerToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, got, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			apierr.Abort(c, http.StatusUnauthorized, apierr.CodeUnauthorized, "invalid or missing token")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/cache"
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cache sets how responses of a GET route are cached.
+type Cache struct {
+	// MaxAge is how long clients may reuse a response without revalidating
+	// it; 0 — they revalidate every time (with If-None-Match).
+	MaxAge time.Duration
+}
+
+// Cache makes successful responses of the GET route cacheable, see
+// ResponseCache.
+func (r *Routes) Cache(method, path string, c Cache) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.caches[routeKey(method, path)] = c
+}
+
+func (r *Routes) cache(method, path string) (Cache, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.caches[routeKey(method, path)]
+	return c, ok
+}
+
+// Responses is a shared cache of responses to GET requests keyed by the
+// tenant, the caller, the route, the normalized query and the date format.
+// A nil *Responses caches nothing. It is safe for concurrent use.
+type Responses struct {
+	cache *cache.TTL[string, cachedResponse]
+	// generation is incremented by Invalidate, so responses computed
+	// before an invalidation are not cached after it.
+	generation atomic.Uint64
+}
+
+type cachedResponse struct {
+	body        []byte
+	etag        string
+	contentType string
+}
+
+// NewResponses creates a shared cache keeping up to maxEntries responses
+// for ttl. It returns nil (no shared cache) if ttl is not positive.
+func NewResponses(ttl time.Duration, maxEntries int) *Responses {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Responses{cache: cache.New[string, cachedResponse](ttl, maxEntries)}
+}
+
+// Invalidate drops all cached responses, e.g. after a write.
+func (r *Responses) Invalidate() {
+	if r == nil {
+		return
+	}
+	r.generation.Add(1)
+	r.cache.Clear()
+}
+
+func (r *Responses) get(key string) (cachedResponse, bool) {
+	if r == nil {
+		return cachedResponse{}, false
+	}
+	return r.cache.Get(key)
+}
+
+func (r *Responses) generationNow() uint64 {
+	if r == nil {
+		return 0
+	}
+	return r.generation.Load()
+}
+
+// set caches resp unless the cache was invalidated since generation.
+func (r *Responses) set(key string, generation uint64, resp cachedResponse) {
+	if r == nil || r.generation.Load() != generation {
+		return
+	}
+	r.cache.Set(key, resp)
+}
+
+// ResponseCache sets Cache-Control and ETag on successful responses of GET
+// routes registered with Routes.Cache and answers requests with a matching
+// If-None-Match with 304. With shared responses, responses are served from
+// it; Consistency: strong requests bypass it and refresh it.
+//
+// It must run after the Tenant and Principal middleware, as responses
+// depend on the tenant and the caller. Entries are dropped by
+// Responses.Invalidate on writes of this instance (see
+// service.WithResponseCache); writes of other instances are seen once
+// entries expire.
+func ResponseCache(routes *Routes, shared *Responses) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc, ok := routes.cache(c.Request.Method, c.FullPath())
+		if !ok || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := responseKey(c)
+		if !consistency.IsStrong(c.Request.Context()) {
+			if resp, ok := shared.get(key); ok {
+				c.Header("X-Cache", "HIT")
+				writeCached(c, rc, resp)
+				c.Abort()
+				return
+			}
+		}
+		generation := shared.generationNow()
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK, size: -1}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		if len(c.Errors) > 0 || w.status != http.StatusOK || w.size < 0 {
+			w.flush()
+			return
+		}
+		sum := sha256.Sum256(w.body.Bytes())
+		resp := cachedResponse{
+			body:        w.body.Bytes(),
+			etag:        `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`,
+			contentType: w.Header().Get("Content-Type"),
+		}
+		shared.set(key, generation, resp)
+		c.Writer = w.ResponseWriter
+		if shared != nil {
+			c.Header("X-Cache", "MISS")
+		}
+		writeCached(c, rc, resp)
+	}
+}
+
+// writeCached writes resp, or 304 if the request has its ETag.
+func writeCached(c *gin.Context, rc Cache, resp cachedResponse) {
+	c.Header("ETag", resp.etag)
+	if rc.MaxAge > 0 {
+		c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(rc.MaxAge.Seconds())))
+	} else {
+		c.Header("Cache-Control", "private, no-cache")
+	}
+	if etagMatches(c.GetHeader("If-None-Match"), resp.etag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, resp.contentType, resp.body)
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag,
+// using the weak comparison of RFC 9110.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// responseKey identifies the response to a GET request: the tenant schema,
+// the caller, the route, the query with parameters sorted and the date
+// format.
+func responseKey(c *gin.Context) string {
+	ctx := c.Request.Context()
+	f, ok := models.DateFormatFromContext(ctx)
+	if !ok {
+		f = models.DefaultDateFormat()
+	}
+	caller := ""
+	if p, ok := auth.FromContext(ctx); ok {
+		caller = p.UserID.String() + "/" + strconv.FormatBool(p.Admin)
+	}
+	return tenant.SchemaFromContext(ctx) + " " + caller + " " +
+		c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "#" + string(f)
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResponseCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRoutes()
+	routes.Cache(http.MethodGet, "/items", Cache{MaxAge: time.Minute})
+	shared := NewResponses(time.Minute, 100)
+
+	calls := 0
+	e := gin.New()
+	e.Use(Consistency(), DateFormat(), ResponseCache(routes, shared))
+	e.GET("/items", func(c *gin.Context) {
+		calls++
+		if c.Query("fail") != "" {
+			c.JSON(http.StatusInternalServerError, gin.H{"calls": calls})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	e.GET("/other", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/items?b=2&a=1")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.JSONEq(t, `{"calls":1}`, first.Body.String())
+	assert.Equal(t, "private, max-age=60", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	assert.Equal(t, "application/json; charset=utf-8", first.Header().Get("Content-Type"))
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	hit := get("/items?a=1&b=2")
+	assert.Equal(t, "HIT", hit.Header().Get("X-Cache"), "the query is normalized")
+	assert.Equal(t, first.Body.String(), hit.Body.String())
+	assert.Equal(t, etag, hit.Header().Get("ETag"))
+
+	notModified := get("/items?a=1&b=2", "If-None-Match", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	assert.JSONEq(t, `{"calls":2}`, get("/items?a=1&b=2", "Date-Format", "RFC3339").Body.String(), "keyed by date format")
+	assert.JSONEq(t, `{"calls":3}`, get("/items?a=1&b=2", ConsistencyHeader, "strong").Body.String(), "strong reads bypass the cache")
+	assert.JSONEq(t, `{"calls":3}`, get("/items?a=1&b=2").Body.String(), "strong reads refresh the cache")
+
+	failed := get("/items?fail=1")
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+	assert.Empty(t, failed.Header().Get("ETag"))
+	assert.JSONEq(t, `{"calls":5}`, get("/items?fail=1").Body.String(), "errors are not cached")
+
+	shared.Invalidate()
+	assert.JSONEq(t, `{"calls":6}`, get("/items?a=1&b=2").Body.String())
+
+	assert.Empty(t, get("/other").Header().Get("ETag"), "routes are cached only if registered")
+}
+
+func TestResponseCache_PerCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRoutes()
+	routes.Cache(http.MethodGet, "/items", Cache{})
+	calls := 0
+	e := gin.New()
+	e.Use(Principal("X-User-ID", "X-Roles"), ResponseCache(routes, NewResponses(time.Minute, 100)))
+	e.GET("/items", func(c *gin.Context) {
+		calls++
+		p, _ := auth.FromContext(c.Request.Context())
+		c.String(http.StatusOK, p.UserID.String()+" "+strconv.Itoa(calls))
+	})
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("X-User-ID", user)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+	alice, bob := uuid.NewString(), uuid.NewString()
+	assert.Equal(t, alice+" 1", get(alice).Body.String())
+	assert.Equal(t, bob+" 2", get(bob).Body.String())
+	w := get(alice)
+	assert.Equal(t, alice+" 1", w.Body.String())
+	assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+}
+
+// createdRepo accepts every created subscription.
+type createdRepo struct {
+	service.SubscriptionRepo
+}
+
+func (createdRepo) CreateSubscription(_ context.Context, sub *models.Subscription, _ ...repository.Option) error {
+	sub.ID = 1
+	return nil
+}
+
+func TestResponseCache_InvalidatedByWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	routes := NewRoutes()
+	routes.Cache(http.MethodGet, "/items", Cache{MaxAge: time.Minute})
+	shared := NewResponses(time.Minute, 100)
+	svc := service.NewSubscriptionService(createdRepo{}, zap.NewNop(), service.WithResponseCache(shared))
+
+	calls := 0
+	e := gin.New()
+	e.Use(Transaction(routes, mock), ResponseCache(routes, shared))
+	e.GET("/items", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	e.POST("/items", func(c *gin.Context) {
+		sub := &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()}
+		if err := svc.CreateSubscription(c.Request.Context(), sub); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	})
+	serve := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, "/items", nil))
+		return w
+	}
+
+	assert.JSONEq(t, `{"calls":1}`, serve(http.MethodGet).Body.String())
+	assert.Equal(t, "HIT", serve(http.MethodGet).Header().Get("X-Cache"))
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	require.Equal(t, http.StatusCreated, serve(http.MethodPost).Code)
+
+	read := serve(http.MethodGet)
+	assert.Equal(t, "MISS", read.Header().Get("X-Cache"), "a read right after the write sees it")
+	assert.JSONEq(t, `{"calls":2}`, read.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
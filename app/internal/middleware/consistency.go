@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/consistency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsistencyHeader is the request header selecting read consistency.
+const ConsistencyHeader = "Consistency"
+
+// Consistency stores the read consistency requested by the Consistency
+// header or the consistency query parameter (the header wins) in the request
+// context. Unknown levels are rejected with 400.
+func Consistency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", ConsistencyHeader)
+
+		value := c.GetHeader(ConsistencyHeader)
+		if value == "" {
+			value = c.Query("consistency")
+		}
+
+		level, err := consistency.Parse(value)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest,
+				"consistency must be strong or eventual")
+			return
+		}
+
+		c.Request = c.Request.WithContext(consistency.WithLevel(c.Request.Context(), level))
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/consistency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsistency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(apierr.Middleware(), Consistency())
+	e.GET("/items", func(c *gin.Context) {
+		c.String(http.StatusOK, string(consistency.FromContext(c.Request.Context())))
+	})
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "default", target: "/items", wantStatus: http.StatusOK, wantBody: "eventual"},
+		{name: "header", target: "/items", header: "Strong", wantStatus: http.StatusOK, wantBody: "strong"},
+		{name: "query", target: "/items?consistency=strong", wantStatus: http.StatusOK, wantBody: "strong"},
+		{name: "header wins", target: "/items?consistency=strong", header: "eventual", wantStatus: http.StatusOK, wantBody: "eventual"},
+		{name: "invalid", target: "/items?consistency=linearizable", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set(ConsistencyHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, ConsistencyHeader, w.Header().Get("Vary"))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DateFormatHeader is the request header selecting the format of month
+// dates in the response.
+const DateFormatHeader = "Date-Format"
+
+// DateFormat stores the date format requested by the Date-Format header in
+// the request context. Without the header dates use the configured default.
+// Unknown formats are rejected with 400.
+func DateFormat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", DateFormatHeader)
+
+		value := c.GetHeader(DateFormatHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		f, err := models.ParseDateFormat(value)
+		if err != nil {
+			apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest,
+				"Date-Format must be MM-YYYY, YYYY-MM or RFC3339")
+			return
+		}
+
+		c.Request = c.Request.WithContext(models.WithDateFormat(c.Request.Context(), f))
+		c.Next()
+	}
+}
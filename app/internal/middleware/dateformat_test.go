@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(apierr.Middleware(), DateFormat())
+	e.GET("/items", func(c *gin.Context) {
+		f, ok := models.DateFormatFromContext(c.Request.Context())
+		if !ok {
+			f = "default"
+		}
+		c.String(http.StatusOK, string(f))
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantBody: "default"},
+		{name: "year month", header: "YYYY-MM", wantStatus: http.StatusOK, wantBody: "YYYY-MM"},
+		{name: "case insensitive", header: "rfc3339", wantStatus: http.StatusOK, wantBody: "RFC3339"},
+		{name: "invalid", header: "DD-MM-YYYY", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			if tt.header != "" {
+				r.Header.Set(DateFormatHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, DateFormatHeader, w.Header().Get("Vary"))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
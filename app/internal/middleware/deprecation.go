@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation describes a deprecated route.
+type Deprecation struct {
+	Since     time.Time // When the route was deprecated.
+	Sunset    time.Time // Optional: when the route will be removed.
+	Successor string    // Optional: URL of the replacement route.
+	Docs      string    // Optional: URL of the migration guide.
+}
+
+// Deprecate marks the route as deprecated.
+func (r *Routes) Deprecate(method, path string, d Deprecation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deprecations[routeKey(method, path)] = d
+}
+
+// Deprecation returns deprecation info of the route, if it is deprecated.
+func (r *Routes) Deprecation(method, path string) (Deprecation, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.deprecations[routeKey(method, path)]
+	return d, ok
+}
+
+// Deprecated attaches Deprecation (RFC 9745), Sunset (RFC 8594) and Link
+// headers to responses of routes marked deprecated in the registry.
+func Deprecated(routes *Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d, ok := routes.Deprecation(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			h.Add("Link", "<"+d.Successor+`>; rel="successor-version"`)
+		}
+		if d.Docs != "" {
+			h.Add("Link", "<"+d.Docs+`>; rel="deprecation"; type="text/html"`)
+		}
+
+		c.Next()
+	}
+}
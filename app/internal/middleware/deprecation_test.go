@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRoutes()
+	routes.Deprecate(http.MethodPost, "/items/:id", Deprecation{
+		Since:     time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Successor: "/v2/items",
+	})
+
+	e := gin.New()
+	e.Use(Deprecated(routes))
+	e.POST("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	e.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("deprecated route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/1", nil))
+
+		assert.Equal(t, "@1767225600", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</v2/items>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("other method of the same path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		assert.Empty(t, w.Header().Get("Deprecation"))
+		assert.Empty(t, w.Header().Get("Sunset"))
+	})
+}
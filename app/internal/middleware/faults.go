@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/fault"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Faults injects the configured faults, or those requested by fault headers
+// if the injector allows them, into responses and stores them in the request
+// context for fault.Executer. Injected faults are listed in the
+// Fault-Injected response header.
+func Faults(inj *fault.Injector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := inj.Defaults()
+		if inj.AllowHeaders() {
+			var err error
+			if f, err = f.Parse(c.GetHeader); err != nil {
+				apierr.Abort(c, http.StatusBadRequest, apierr.CodeInvalidRequest, "invalid fault headers")
+				return
+			}
+		}
+		c.Request = c.Request.WithContext(fault.WithFaults(c.Request.Context(), f))
+
+		fail, err := inj.Inject(c.Request.Context(), f.HTTP)
+		if err != nil {
+			c.Abort()
+			return
+		}
+
+		var injected []string
+		if f.HTTP.Latency > 0 {
+			injected = append(injected, "latency")
+		}
+		if fail {
+			injected = append(injected, "error")
+		}
+		if len(injected) > 0 {
+			c.Header(fault.HeaderInjected, strings.Join(injected, ","))
+		}
+
+		if fail {
+			status := f.Status
+			if status == 0 {
+				status = http.StatusServiceUnavailable
+			}
+			apierr.Abort(c, status, apierr.CodeInjectedFault, "injected fault")
+			return
+		}
+		c.Next()
+	}
+}
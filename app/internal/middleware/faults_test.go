@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/fault"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(inj *fault.Injector) *gin.Engine {
+		e := gin.New()
+		e.Use(apierr.Middleware(), Faults(inj))
+		e.GET("/items", func(c *gin.Context) {
+			f, _ := fault.FromContext(c.Request.Context())
+			c.String(http.StatusOK, f.DB.Latency.String())
+		})
+		return e
+	}
+
+	tests := []struct {
+		name         string
+		inj          *fault.Injector
+		headers      map[string]string
+		wantStatus   int
+		wantInjected string
+		wantBody     string
+	}{
+		{
+			name:       "no faults",
+			inj:        fault.New(fault.Faults{}, false),
+			wantStatus: http.StatusOK,
+			wantBody:   "0s",
+		},
+		{
+			name:         "configured error",
+			inj:          fault.New(fault.Faults{HTTP: fault.Spec{ErrorRate: 1}}, false),
+			wantStatus:   http.StatusServiceUnavailable,
+			wantInjected: "error",
+		},
+		{
+			name:       "headers ignored",
+			inj:        fault.New(fault.Faults{}, false),
+			headers:    map[string]string{fault.HeaderErrorRate: "1"},
+			wantStatus: http.StatusOK,
+			wantBody:   "0s",
+		},
+		{
+			name:         "header error and status",
+			inj:          fault.New(fault.Faults{}, true),
+			headers:      map[string]string{fault.HeaderLatency: "1ms", fault.HeaderErrorRate: "1", fault.HeaderStatus: "500"},
+			wantStatus:   http.StatusInternalServerError,
+			wantInjected: "latency,error",
+		},
+		{
+			name:       "header db faults reach the context",
+			inj:        fault.New(fault.Faults{}, true),
+			headers:    map[string]string{fault.HeaderDBLatency: "20ms"},
+			wantStatus: http.StatusOK,
+			wantBody:   (20 * time.Millisecond).String(),
+		},
+		{
+			name:       "invalid header",
+			inj:        fault.New(fault.Faults{}, true),
+			headers:    map[string]string{fault.HeaderErrorRate: "2"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			newEngine(tt.inj).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantInjected, w.Header().Get(fault.HeaderInjected))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limit restricts a route.
+type Limit struct {
+	Timeout     time.Duration // Optional: deadline of the request context.
+	MaxInFlight int           // Optional: max concurrently served requests.
+}
+
+type routeLimit struct {
+	Limit
+	slots chan struct{} // nil if MaxInFlight is not set
+}
+
+// Limit sets the timeout and concurrency limit of the route.
+func (r *Routes) Limit(method, path string, l Limit) {
+	rl := &routeLimit{Limit: l}
+	if l.MaxInFlight > 0 {
+		rl.slots = make(chan struct{}, l.MaxInFlight)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits[routeKey(method, path)] = rl
+}
+
+func (r *Routes) limit(method, path string) (*routeLimit, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.limits[routeKey(method, path)]
+	return l, ok
+}
+
+// Limits applies route limits from the registry. A request over the
+// concurrency limit is rejected with 503 at once rather than queued, so a slow
+// route cannot hold connections needed by others. The timeout cancels the
+// request context; handlers report it as 504.
+func Limits(routes *Routes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l, ok := routes.limit(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			default:
+				c.Header("Retry-After", "1")
+				apierr.Abort(c, http.StatusServiceUnavailable, apierr.CodeOverloaded,
+					"too many concurrent requests, try again later")
+				return
+			}
+		}
+
+		if l.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), l.Timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		c.Next()
+	}
+}
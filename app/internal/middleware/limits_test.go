@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRoutes()
+	routes.Limit(http.MethodGet, "/slow", Limit{MaxInFlight: 1})
+	routes.Limit(http.MethodGet, "/deadline", Limit{Timeout: 10 * time.Millisecond})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	e := gin.New()
+	e.Use(apierr.Middleware(), Limits(routes))
+	e.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	e.GET("/deadline", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Status(http.StatusGatewayTimeout)
+	})
+	e.GET("/free", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("concurrency limit", func(t *testing.T) {
+		var wg sync.WaitGroup
+		first := httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		}()
+		<-entered
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+		w = httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/free", nil))
+		assert.Equal(t, http.StatusOK, w.Code, "other routes are not limited")
+
+		close(release)
+		wg.Wait()
+		assert.Equal(t, http.StatusOK, first.Code)
+
+		go func() { <-entered }()
+		w = httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		assert.Equal(t, http.StatusOK, w.Code, "slot is released")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		done := make(chan int)
+		go func() {
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deadline", nil))
+			done <- w.Code
+		}()
+
+		select {
+		case code := <-done:
+			require.Equal(t, http.StatusGatewayTimeout, code)
+		case <-time.After(time.Second):
+			t.Fatal("request context was not canceled")
+		}
+	})
+}
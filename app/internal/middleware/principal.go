@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Principal identifies the caller by the user ID in userHeader and the
+// comma-separated roles in rolesHeader, both set by an authenticating
+// gateway, and stores it in the request context. Requests without a valid
+// user ID are rejected with 401.
+func Principal(userHeader, rolesHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetHeader(userHeader))
+		if err != nil {
+			apierr.Abort(c, http.StatusUnauthorized, apierr.CodeUnauthorized, "missing or invalid caller identity")
+			return
+		}
+
+		p := auth.Principal{UserID: userID}
+		if rolesHeader != "" {
+			roles := strings.Split(c.GetHeader(rolesHeader), ",")
+			for i := range roles {
+				roles[i] = strings.TrimSpace(roles[i])
+			}
+			p.Admin = slices.Contains(roles, auth.RoleAdmin)
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
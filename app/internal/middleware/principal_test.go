@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(apierr.Middleware(), Principal("X-User-ID", "X-Roles"))
+	e.GET("/items", func(c *gin.Context) {
+		p, _ := auth.FromContext(c.Request.Context())
+		c.String(http.StatusOK, p.UserID.String()+" "+strconv.FormatBool(p.Admin))
+	})
+
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	tests := []struct {
+		name       string
+		user       string
+		roles      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "user", user: userID, wantStatus: http.StatusOK, wantBody: userID + " false"},
+		{name: "admin", user: userID, roles: "reader, admin", wantStatus: http.StatusOK, wantBody: userID + " true"},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+		{name: "invalid", user: "alice", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			r.Header.Set("X-User-ID", tt.user)
+			r.Header.Set("X-Roles", tt.roles)
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
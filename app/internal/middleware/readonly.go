@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarkSafe marks a route that does not modify data although its method is
+// not GET, HEAD or OPTIONS, e.g. a query with a request body, so ReadOnly
+// lets it through.
+func (r *Routes) MarkSafe(method, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.safe[routeKey(method, path)] = struct{}{}
+}
+
+func (r *Routes) isSafe(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.safe[routeKey(method, path)]
+	return ok
+}
+
+// ReadOnly rejects requests that may modify data with 503 while readOnly
+// reports true. Safe methods and routes marked with MarkSafe are served.
+func ReadOnly(routes *Routes, readOnly func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if readOnly() && !routes.isSafe(c.Request.Method, c.FullPath()) {
+			c.Header("Retry-After", "60")
+			apierr.Abort(c, http.StatusServiceUnavailable, apierr.CodeReadOnly,
+				"service is in read-only mode, try again later")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRoutes()
+	routes.MarkSafe(http.MethodPost, "/search")
+	readOnly := true
+
+	e := gin.New()
+	e.Use(apierr.Middleware(), ReadOnly(routes, func() bool { return readOnly }))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	e.GET("/items", ok)
+	e.POST("/items", ok)
+	e.POST("/search", ok)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		readOnly bool
+		want     int
+	}{
+		{"read", http.MethodGet, "/items", true, http.StatusOK},
+		{"write", http.MethodPost, "/items", true, http.StatusServiceUnavailable},
+		{"safe route", http.MethodPost, "/search", true, http.StatusOK},
+		{"write when writable", http.MethodPost, "/items", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readOnly = tt.readOnly
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusServiceUnavailable {
+				assert.Equal(t, "60", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
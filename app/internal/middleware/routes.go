@@ -0,0 +1,27 @@
+package middleware
+
+import "sync"
+
+// Routes is a registry of route metadata keyed by method and route pattern
+// (as returned by gin.Context.FullPath).
+type Routes struct {
+	mu           sync.RWMutex
+	deprecations map[string]Deprecation
+	limits       map[string]*routeLimit
+	safe         map[string]struct{}
+	caches       map[string]Cache
+}
+
+// NewRoutes creates an empty route registry.
+func NewRoutes() *Routes {
+	return &Routes{
+		deprecations: make(map[string]Deprecation),
+		limits:       make(map[string]*routeLimit),
+		safe:         make(map[string]struct{}),
+		caches:       make(map[string]Cache),
+	}
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantResolver resolves the tenants of requests, see tenant.Registry.
+type TenantResolver interface {
+	// Authenticate returns the tenant of an API key, or
+	// tenant.ErrInvalidAPIKey.
+	Authenticate(ctx context.Context, key string) (tenant.ID, error)
+
+	// Known reports whether a tenant is registered.
+	Known(ctx context.Context, id tenant.ID) (bool, error)
+}
+
+// Tenant resolves the tenant of the request and stores it in the request
+// context, so repository queries run in the tenant's schema. The tenant is
+// the one of the API key in keyHeader if the request has one, otherwise the
+// one in header, set by an authenticating gateway, which must be registered.
+// Requests without a valid tenant are rejected with 401.
+func Tenant(header, keyHeader string, tenants TenantResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var id tenant.ID
+		if key := c.GetHeader(keyHeader); key != "" {
+			var err error
+			id, err = tenants.Authenticate(ctx, key)
+			if err != nil {
+				abortTenant(c, err, "invalid api key")
+				return
+			}
+		} else {
+			var err error
+			id, err = tenant.Parse(c.GetHeader(header))
+			if err != nil {
+				apierr.Abort(c, http.StatusUnauthorized, apierr.CodeUnauthorized, "missing or invalid tenant")
+				return
+			}
+			known, err := tenants.Known(ctx, id)
+			if err == nil && !known {
+				err = tenant.ErrNotFound
+			}
+			if err != nil {
+				abortTenant(c, err, "missing or invalid tenant")
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithTenant(ctx, id))
+		c.Next()
+	}
+}
+
+// abortTenant rejects a request with an unknown tenant or API key with 401
+// and detail, and fails it if the tenant could not be resolved.
+func abortTenant(c *gin.Context, err error, detail string) {
+	if errors.Is(err, tenant.ErrInvalidAPIKey) || errors.Is(err, tenant.ErrNotFound) {
+		apierr.Abort(c, http.StatusUnauthorized, apierr.CodeUnauthorized, detail)
+		return
+	}
+	apierr.AbortWithError(c, &apierr.Error{
+		Status: http.StatusInternalServerError,
+		Code:   apierr.CodeInternal,
+		Detail: "failed to resolve tenant",
+		Err:    err,
+	})
+}
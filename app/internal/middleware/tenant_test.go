@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTenants knows the tenant "billing" with the API key "subs_key".
+type fakeTenants struct {
+	err error
+}
+
+func (f fakeTenants) Authenticate(_ context.Context, key string) (tenant.ID, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	if key != "subs_key" {
+		return "", tenant.ErrInvalidAPIKey
+	}
+	return "billing", nil
+}
+
+func (f fakeTenants) Known(_ context.Context, id tenant.ID) (bool, error) {
+	return id == "billing", f.err
+}
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(tenants TenantResolver) *gin.Engine {
+		e := gin.New()
+		e.Use(apierr.Middleware(), Tenant("X-Tenant-ID", "X-API-Key", tenants))
+		e.GET("/items", func(c *gin.Context) {
+			c.String(http.StatusOK, tenant.SchemaFromContext(c.Request.Context()))
+		})
+		return e
+	}
+
+	tests := []struct {
+		name       string
+		tenant     string
+		key        string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "tenant", tenant: "billing", wantStatus: http.StatusOK, wantBody: "tenant_billing"},
+		{name: "api key", key: "subs_key", wantStatus: http.StatusOK, wantBody: "tenant_billing"},
+		{name: "api key over header", tenant: "search", key: "subs_key", wantStatus: http.StatusOK, wantBody: "tenant_billing"},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+		{name: "invalid", tenant: "public; --", wantStatus: http.StatusUnauthorized},
+		{name: "unknown", tenant: "search", wantStatus: http.StatusUnauthorized},
+		{name: "invalid api key", tenant: "billing", key: "subs_other", wantStatus: http.StatusUnauthorized},
+		{name: "registry failure", tenant: "billing", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			r.Header.Set("X-Tenant-ID", tt.tenant)
+			r.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			newEngine(fakeTenants{err: tt.err}).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Transaction runs requests that may modify data (see Routes.MarkSafe) in a
+// database transaction begun on db and stored in the request context
+// (repository.ContextWithTx), so all repository calls of a handler commit or
+// roll back together without passing the transaction along.
+//
+// The transaction is committed if the handler neither failed nor responded
+// with a status of 400 or above, and rolled back otherwise or on panic. The
+// response is held back until then: if the commit fails, it is replaced by
+// 500. Side effects outside the database deferred with
+// repository.AfterCommit, such as dropping cached reads and publishing
+// events, run after the commit and before the response is sent; they are
+// skipped on rollback. Other side effects are not undone by a rollback.
+func Transaction(routes *Routes, db repository.Beginner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if routes.isSafe(c.Request.Method, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			apierr.AbortWithError(c, &apierr.Error{
+				Status: http.StatusInternalServerError,
+				Code:   apierr.CodeInternal,
+				Detail: "internal server error",
+				Err:    err,
+			})
+			return
+		}
+		rollback := func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK, size: -1}
+		header := maps.Clone(c.Writer.Header())
+		c.Writer = w
+		txCtx, hooks := repository.ContextWithCommitHooks(repository.ContextWithTx(ctx, tx))
+		c.Request = c.Request.WithContext(txCtx)
+		defer func() {
+			c.Writer = w.ResponseWriter
+			if p := recover(); p != nil {
+				rollback()
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 || w.status >= http.StatusBadRequest {
+			rollback()
+			w.flush()
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			rollback()
+			// The handler's response is dropped, including its headers.
+			clear(w.Header())
+			maps.Copy(w.Header(), header)
+			_ = c.Error(&apierr.Error{
+				Status: http.StatusInternalServerError,
+				Code:   apierr.CodeInternal,
+				Detail: "internal server error",
+				Err:    err,
+			})
+			return
+		}
+		hooks.Run()
+		w.flush()
+	}
+}
+
+// bufferedWriter holds the response back until flush.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	size   int // -1 until the header is written, as in gin
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && w.size < 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.size < 0 {
+		w.size = 0
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.body.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Status() int   { return w.status }
+func (w *bufferedWriter) Size() int     { return w.size }
+func (w *bufferedWriter) Written() bool { return w.size >= 0 }
+
+// Flush does nothing: the response is sent once the transaction ends.
+func (w *bufferedWriter) Flush() {}
+
+// flush sends the held back response.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.size < 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(t *testing.T) (*gin.Engine, pgxmock.PgxPoolIface) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, mock.ExpectationsWereMet())
+			mock.Close()
+		})
+
+		e := gin.New()
+		routes := NewRoutes()
+		routes.MarkSafe(http.MethodPost, "/search")
+		e.Use(apierr.Middleware(), Transaction(routes, mock))
+		read := func(c *gin.Context) {
+			_, ok := repository.TxFromContext(c.Request.Context())
+			assert.False(t, ok, "reads are not wrapped")
+			c.Status(http.StatusOK)
+		}
+		e.GET("/", read)
+		e.POST("/search", read)
+		e.POST("/ok", func(c *gin.Context) {
+			_, ok := repository.TxFromContext(c.Request.Context())
+			assert.True(t, ok)
+			c.Header("Location", "/ok/1")
+			c.JSON(http.StatusCreated, gin.H{"id": 1})
+		})
+		e.POST("/conflict", func(c *gin.Context) {
+			apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "conflict")
+		})
+		e.POST("/bad", func(c *gin.Context) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad"})
+		})
+		return e, mock
+	}
+
+	t.Run("read", func(t *testing.T) {
+		e, _ := newEngine(t)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/search", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		e, mock := newEngine(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ok", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id":1}`, w.Body.String())
+		assert.Equal(t, "/ok/1", w.Header().Get("Location"))
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		e, mock := newEngine(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/conflict", nil))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"error":"conflict"}`, w.Body.String())
+	})
+
+	t.Run("rollback on error status", func(t *testing.T) {
+		e, mock := newEngine(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bad", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"bad"}`, w.Body.String())
+	})
+
+	t.Run("failed commit", func(t *testing.T) {
+		e, mock := newEngine(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("connection lost"))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ok", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("Location"), "the handler's response is dropped")
+	})
+	t.Run("after commit", func(t *testing.T) {
+		e, mock := newEngine(t)
+		var ran []string
+		e.POST("/hooks/:status", func(c *gin.Context) {
+			repository.AfterCommit(c.Request.Context(), func() { ran = append(ran, c.Param("status")) })
+			assert.NotContains(t, ran, c.Param("status"), "deferred until the commit")
+			if c.Param("status") == "fail" {
+				apierr.Abort(c, http.StatusConflict, apierr.CodeConflict, "conflict")
+				return
+			}
+			c.Status(http.StatusNoContent)
+		})
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hooks/ok", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/hooks/fail", nil))
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		assert.Equal(t, []string{"ok"}, ran, "skipped on rollback")
+	})
+}
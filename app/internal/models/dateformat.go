@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DateFormat is how MonthDate is encoded in JSON.
+type DateFormat string
+
+const (
+	// DateFormatMonthYear is "07-2025". It is the default.
+	DateFormatMonthYear DateFormat = "MM-YYYY"
+	// DateFormatYearMonth is "2025-07".
+	DateFormatYearMonth DateFormat = "YYYY-MM"
+	// DateFormatRFC3339 is the month start in UTC, "2025-07-01T00:00:00Z".
+	DateFormatRFC3339 DateFormat = "RFC3339"
+)
+
+// DateFormats are the supported date formats.
+var DateFormats = []DateFormat{DateFormatMonthYear, DateFormatYearMonth, DateFormatRFC3339}
+
+// ErrUnknownDateFormat is returned by ParseDateFormat for unknown formats.
+var ErrUnknownDateFormat = errors.New("unknown date format")
+
+// ParseDateFormat parses a format name case-insensitively.
+func ParseDateFormat(s string) (DateFormat, error) {
+	for _, f := range DateFormats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownDateFormat, s)
+}
+
+// layout returns the time layout of the format.
+func (f DateFormat) layout() string {
+	switch f {
+	case DateFormatYearMonth:
+		return "2006-01"
+	case DateFormatRFC3339:
+		return time.RFC3339
+	default:
+		return "01-2006"
+	}
+}
+
+var defaultDateFormat atomic.Value
+
+// SetDefaultDateFormat sets the format of MonthDate values without one of
+// their own. It is meant to be called once at startup.
+func SetDefaultDateFormat(f DateFormat) {
+	defaultDateFormat.Store(f)
+}
+
+// DefaultDateFormat returns the format set by SetDefaultDateFormat,
+// DateFormatMonthYear if none.
+func DefaultDateFormat() DateFormat {
+	if f, ok := defaultDateFormat.Load().(DateFormat); ok {
+		return f
+	}
+	return DateFormatMonthYear
+}
+
+type dateFormatKey struct{}
+
+// WithDateFormat returns a copy of ctx carrying the date format requested
+// for the response.
+func WithDateFormat(ctx context.Context, f DateFormat) context.Context {
+	return context.WithValue(ctx, dateFormatKey{}, f)
+}
+
+// DateFormatFromContext returns the date format carried by ctx.
+func DateFormatFromContext(ctx context.Context) (DateFormat, bool) {
+	f, ok := ctx.Value(dateFormatKey{}).(DateFormat)
+	return f, ok
+}
+
+var monthDateType = reflect.TypeFor[MonthDate]()
+
+// FormatDates returns a copy of v with every MonthDate reachable through
+// exported fields, pointers, slices, arrays, maps and interfaces encoded in
+// f. v itself is not changed, so it may be shared, e.g. cached.
+func FormatDates(v any, f DateFormat) any {
+	if v == nil {
+		return nil
+	}
+	return formatDates(reflect.ValueOf(v), f).Interface()
+}
+
+// formatDates returns a deep copy of v with the format of MonthDate values set.
+func formatDates(v reflect.Value, f DateFormat) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(formatDates(v.Elem(), f))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(formatDates(v.Elem(), f))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type())
+		c.Elem().Set(v)
+		if v.Type() == monthDateType {
+			c.Interface().(*MonthDate).format = f
+			return c.Elem()
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				c.Elem().Field(i).Set(formatDates(v.Field(i), f))
+			}
+		}
+		return c.Elem()
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(formatDates(v.Index(i), f))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			c.Index(i).Set(formatDates(v.Index(i), f))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), formatDates(iter.Value(), f))
+		}
+		return c
+	default:
+		return v
+	}
+}
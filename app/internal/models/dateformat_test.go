@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthDate_UnmarshalParam(t *testing.T) {
+	want := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{"07-2025", "2025-07", "2025-07-01T00:00:00Z", "2025-07-15T10:00:00+03:00"} {
+		var m MonthDate
+		require.NoError(t, m.UnmarshalParam(s), s)
+		assert.Equal(t, want, m.Time, s)
+	}
+
+	var m MonthDate
+	assert.Error(t, m.UnmarshalParam("2025/07"))
+}
+
+func TestFormatDates(t *testing.T) {
+	month := func(m time.Month) MonthDate { return MonthDate{Time: time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC)} }
+	end := month(time.December)
+	sub := Subscription{ID: 1, StartDate: month(time.July), EndDate: &end}
+
+	tests := []struct {
+		format DateFormat
+		want   string
+	}{
+		{DateFormatMonthYear, `{"start":"07-2025","end":"12-2025"}`},
+		{DateFormatYearMonth, `{"start":"2025-07","end":"2025-12"}`},
+		{DateFormatRFC3339, `{"start":"2025-07-01T00:00:00Z","end":"2025-12-01T00:00:00Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			subs := []Subscription{sub}
+			resp := FormatDates(map[string]any{"data": subs, "total": 1}, tt.format)
+
+			b, err := json.Marshal(resp)
+			require.NoError(t, err)
+			var got struct {
+				Data []struct {
+					Start string `json:"start_date"`
+					End   string `json:"end_date"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(b, &got))
+			require.Len(t, got.Data, 1)
+
+			out, err := json.Marshal(map[string]string{"start": got.Data[0].Start, "end": got.Data[0].End})
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(out))
+		})
+	}
+
+	t.Run("value", func(t *testing.T) {
+		b, err := json.Marshal(FormatDates(DeletedSubscription{Subscription: sub}, DateFormatYearMonth))
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"start_date":"2025-07"`)
+
+		b, err = json.Marshal(sub)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"start_date":"07-2025"`)
+		assert.Contains(t, string(b), `"end_date":"12-2025"`, "values behind pointers are copied, not changed")
+	})
+}
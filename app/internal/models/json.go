@@ -0,0 +1,140 @@
+package models
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Hand-written encoders of the types on hot read paths. They append to a
+// caller's buffer without reflection or intermediate allocations and
+// produce the same bytes as encoding/json, including its HTML escaping.
+
+// AppendJSON appends the JSON encoding of m to b in its own date format,
+// f if it has none, or DefaultDateFormat if f is empty too.
+func (m MonthDate) AppendJSON(b []byte, f DateFormat) []byte {
+	if m.format != "" {
+		f = m.format
+	}
+	if f == "" {
+		f = DefaultDateFormat()
+	}
+	b = append(b, '"')
+	b = m.Time.AppendFormat(b, f.layout())
+	return append(b, '"')
+}
+
+// AppendJSON appends the JSON encoding of s to b with dates in f, see
+// MonthDate.AppendJSON.
+func (s *Subscription) AppendJSON(b []byte, f DateFormat) []byte {
+	b = append(b, `{"id":`...)
+	b = strconv.AppendInt(b, s.ID, 10)
+	b = append(b, `,"service_name":`...)
+	b = appendJSONString(b, s.ServiceName)
+	b = append(b, `,"price":`...)
+	b = strconv.AppendInt(b, int64(s.Price), 10)
+	if s.Currency != "" {
+		b = append(b, `,"currency":`...)
+		b = appendJSONString(b, string(s.Currency))
+	}
+	b = append(b, `,"user_id":`...)
+	b = appendUUID(b, s.UserID)
+	b = append(b, `,"start_date":`...)
+	b = s.StartDate.AppendJSON(b, f)
+	if s.EndDate != nil {
+		b = append(b, `,"end_date":`...)
+		b = s.EndDate.AppendJSON(b, f)
+	}
+	b = append(b, `,"trial":`...)
+	b = strconv.AppendBool(b, s.Trial)
+	if s.OriginRegion != "" {
+		b = append(b, `,"origin_region":`...)
+		b = appendJSONString(b, s.OriginRegion)
+	}
+	return append(b, '}')
+}
+
+// AppendSubscriptionsJSON appends the JSON array of subs to b, null if
+// subs is nil.
+func AppendSubscriptionsJSON(b []byte, subs []Subscription, f DateFormat) []byte {
+	if subs == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i := range subs {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = subs[i].AppendJSON(b, f)
+	}
+	return append(b, ']')
+}
+
+// appendUUID appends id as a JSON string in the canonical form.
+func appendUUID(b []byte, id uuid.UUID) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i, c := range id {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			b = append(b, '-')
+		}
+		b = append(b, hex[c>>4], hex[c&0x0f])
+	}
+	return append(b, '"')
+}
+
+// appendJSONString appends s as a JSON string escaped like encoding/json
+// does: control characters, quotes, backslashes, <, > and & are escaped,
+// invalid UTF-8 is replaced with U+FFFD and U+2028, U+2029 are escaped.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0x0f])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0x0f])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jsonSubscriptions() []Subscription {
+	start := MonthDate{Time: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	end := MonthDate{Time: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	return []Subscription{
+		{ID: 1, ServiceName: "Netflix", Price: 499, UserID: uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"), StartDate: start},
+		{ID: 2, ServiceName: "Yandex Плюс", Price: 0, Currency: "USD", UserID: uuid.New(), StartDate: start, EndDate: &end, Trial: true, OriginRegion: "eu-central"},
+		{ID: -3, ServiceName: "<a href=\"x\">&\\\n\t\x01  \xff</a>", Price: -1, UserID: uuid.Nil, StartDate: start},
+	}
+}
+
+func TestSubscription_AppendJSON(t *testing.T) {
+	subs := jsonSubscriptions()
+	for _, f := range DateFormats {
+		t.Run(string(f), func(t *testing.T) {
+			for i := range subs {
+				want, err := json.Marshal(FormatDates(subs[i], f))
+				require.NoError(t, err)
+				assert.Equal(t, string(want), string(subs[i].AppendJSON(nil, f)))
+			}
+
+			want, err := json.Marshal(FormatDates(subs, f))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(AppendSubscriptionsJSON(nil, subs, f)))
+		})
+	}
+
+	want, err := json.Marshal(subs[0])
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(subs[0].AppendJSON(nil, "")), "default format")
+	assert.Equal(t, "null", string(AppendSubscriptionsJSON(nil, nil, "")))
+	assert.Equal(t, "[]", string(AppendSubscriptionsJSON(nil, []Subscription{}, "")))
+}
+
+func TestSubscription_AppendJSON_NoAllocs(t *testing.T) {
+	subs := jsonSubscriptions()
+	buf := make([]byte, 0, 4096)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendSubscriptionsJSON(buf[:0], subs, DateFormatRFC3339)
+	})
+	assert.Zero(t, allocs)
+}
+
+func benchmarkPage() []Subscription {
+	subs := make([]Subscription, 1000)
+	for i := range subs {
+		subs[i] = jsonSubscriptions()[i%2]
+		subs[i].ID = int64(i)
+	}
+	return subs
+}
+
+func BenchmarkAppendSubscriptionsJSON(b *testing.B) {
+	subs := benchmarkPage()
+	buf := make([]byte, 0, 1<<20)
+	b.ReportAllocs()
+	for b.Loop() {
+		buf = AppendSubscriptionsJSON(buf[:0], subs, "")
+	}
+}
+
+func BenchmarkSubscriptions_EncodingJSON(b *testing.B) {
+	subs := benchmarkPage()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(subs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSubscriptionPatch_UnmarshalJSON(t *testing.T) {
+	var p SubscriptionPatch
+	require.NoError(t, json.Unmarshal([]byte(`{"price": 599, "end_date": null}`), &p))
+	require.NotNil(t, p.Price)
+	assert.Equal(t, Price(599), *p.Price)
+	assert.Nil(t, p.ServiceName)
+	assert.True(t, p.ClearEndDate)
+	assert.False(t, p.Empty())
+
+	start := MonthDate{Time: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	end := MonthDate{Time: time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)}
+	got := p.Apply(Subscription{ServiceName: "Netflix", Price: 499, StartDate: start, EndDate: &end})
+	assert.Equal(t, Subscription{ServiceName: "Netflix", Price: 599, StartDate: start}, got)
+
+	p = SubscriptionPatch{}
+	require.NoError(t, json.Unmarshal([]byte(`{"end_date": "12-2025"}`), &p))
+	require.NotNil(t, p.EndDate)
+	assert.Equal(t, end, *p.EndDate)
+	assert.False(t, p.ClearEndDate)
+
+	p = SubscriptionPatch{}
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &p))
+	assert.True(t, p.Empty())
+
+	empty := ""
+	assert.Error(t, Validate(&SubscriptionPatch{ServiceName: &empty}), "a service name must not be empty")
+}
@@ -1,73 +1,500 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
-var vld = validator.New()
-
-func init() {
-	// Register custom validation for MonthDate fields.
-	vld.RegisterValidation("monthdate", func(fl validator.FieldLevel) bool {
-		md, ok := fl.Field().Interface().(MonthDate)
-		if !ok {
-			return false
-		}
-		return !md.Time.IsZero()
-	})
-}
-
-// Validate runs field validation based on struct tags.
-func Validate(modelsStruct interface{}) error {
-	return vld.Struct(modelsStruct)
-}
-
 // MonthDate represents a date limited to month and year precision.
-// It is encoded and decoded in the "MM-YYYY" format.
+// It is decoded from "MM-YYYY", "YYYY-MM" or RFC3339 and encoded in its
+// own format if set by FormatDates, otherwise in DefaultDateFormat.
 type MonthDate struct {
 	time.Time
+
+	format DateFormat
 }
 
-// UnmarshalJSON parses a JSON string in "MM-YYYY" format.
+// UnmarshalJSON parses a JSON string in "MM-YYYY", "YYYY-MM" or RFC3339 format.
 func (m *MonthDate) UnmarshalJSON(b []byte) error {
 	s, err := strconv.Unquote(string(b))
 	if err != nil {
 		return fmt.Errorf("invalid month date: %w", err)
 	}
-	t, err := time.Parse("01-2006", s)
-	if err != nil {
-		return fmt.Errorf("invalid month date: %w", err)
+	return m.UnmarshalParam(s)
+}
+
+// UnmarshalParam parses a query or form parameter in "MM-YYYY", "YYYY-MM"
+// or RFC3339 format. An RFC3339 time is truncated to its month.
+func (m *MonthDate) UnmarshalParam(s string) error {
+	for _, f := range DateFormats {
+		t, err := time.Parse(f.layout(), s)
+		if err != nil {
+			continue
+		}
+		m.Time = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return nil
 	}
-	m.Time = t
-	return nil
+	return fmt.Errorf("invalid month date %q, expected MM-YYYY, YYYY-MM or RFC3339", s)
 }
 
-// MarshalJSON formats MonthDate as "MM-YYYY".
+// MarshalJSON formats MonthDate in its date format.
 func (m MonthDate) MarshalJSON() ([]byte, error) {
-	s := fmt.Sprintf("%02d-%04d", m.Time.Month(), m.Time.Year())
-	return []byte(strconv.Quote(s)), nil
+	f := m.format
+	if f == "" {
+		f = DefaultDateFormat()
+	}
+	return []byte(strconv.Quote(m.Time.Format(f.layout()))), nil
 }
 
 // Subscription defines a user subscription entity.
 type Subscription struct {
-	ID          int64      `json:"id"`                                       // Subscription identifier.
-	ServiceName string     `json:"service_name" validate:"required"`         // Service name.
-	Price       int        `json:"price" validate:"gte=0"`                   // Monthly price.
-	UserID      uuid.UUID  `json:"user_id" validate:"required"`              // Associated user ID.
-	StartDate   MonthDate  `json:"start_date" validate:"required,monthdate"` // Start date (month-year).
-	EndDate     *MonthDate `json:"end_date,omitempty"`                       // Optional end date.
+	ID          int64      `json:"id"`                                              // Subscription identifier.
+	ServiceName string     `json:"service_name" validate:"required"`                // Service name.
+	Price       Price      `json:"price" validate:"gte=0"`                          // Monthly price in whole units of Currency.
+	Currency    Currency   `json:"currency,omitempty" validate:"omitempty,iso4217"` // ISO 4217 code; RUB if empty.
+	UserID      uuid.UUID  `json:"user_id" validate:"required"`                     // Associated user ID.
+	StartDate   MonthDate  `json:"start_date" validate:"required,monthdate"`        // Start date (month-year).
+	EndDate     *MonthDate `json:"end_date,omitempty"`                              // Optional end date.
+	Trial       bool       `json:"trial"`                                           // Trial period; excluded from reports on request.
+
+	// OriginRegion is the region of the instance that last wrote the
+	// subscription (app.region); empty if not configured. It is set by the
+	// service, values sent by clients are ignored.
+	OriginRegion string `json:"origin_region,omitempty"`
+}
+
+// SubscriptionPatch holds the fields of a partial update; nil fields are
+// left unchanged.
+type SubscriptionPatch struct {
+	ServiceName *string    `json:"service_name,omitempty" validate:"omitempty,min=1"` // New service name.
+	Price       *Price     `json:"price,omitempty" validate:"omitempty,gte=0"`        // New monthly price.
+	EndDate     *MonthDate `json:"end_date,omitempty"`                                // New end date; null makes it open-ended.
+
+	// ClearEndDate is set by "end_date": null; the subscription becomes
+	// open-ended.
+	ClearEndDate bool `json:"-"`
+}
+
+// UnmarshalJSON decodes the patch, telling "end_date": null from an absent
+// end_date.
+func (p *SubscriptionPatch) UnmarshalJSON(b []byte) error {
+	type plain SubscriptionPatch
+	var v plain
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	end, ok := fields["end_date"]
+	v.ClearEndDate = ok && string(end) == "null"
+	*p = SubscriptionPatch(v)
+	return nil
+}
+
+// Empty reports whether the patch changes nothing.
+func (p SubscriptionPatch) Empty() bool {
+	return p.ServiceName == nil && p.Price == nil && p.EndDate == nil && !p.ClearEndDate
+}
+
+// Apply returns a copy of sub with the patch applied.
+func (p SubscriptionPatch) Apply(sub Subscription) Subscription {
+	if p.ServiceName != nil {
+		sub.ServiceName = *p.ServiceName
+	}
+	if p.Price != nil {
+		sub.Price = *p.Price
+	}
+	if p.EndDate != nil {
+		end := *p.EndDate
+		sub.EndDate = &end
+	}
+	if p.ClearEndDate {
+		sub.EndDate = nil
+	}
+	return sub
+}
+
+// DeletedSubscription is a tombstone of a deleted subscription.
+type DeletedSubscription struct {
+	Subscription
+	DeletedAt time.Time `json:"deleted_at"` // Deletion time (UTC).
 }
 
 // SummaryRequest defines the payload for requesting
 // subscription cost summary within a given period.
 type SummaryRequest struct {
-	From        MonthDate `json:"from" validate:"required,monthdate"`           // Start of the period.
-	To          MonthDate `json:"to" validate:"required,monthdate"`             // End of the period.
-	UserID      *string   `json:"user_id,omitempty" validate:"omitempty,uuid4"` // Optional user filter.
-	ServiceName *string   `json:"service_name,omitempty" validate:"omitempty"`  // Optional service filter.
+	From          MonthDate `json:"from" form:"from" validate:"required,monthdate"`                  // Start of the period.
+	To            MonthDate `json:"to" form:"to" validate:"required,monthdate"`                      // End of the period.
+	UserID        *string   `json:"user_id,omitempty" form:"user_id" validate:"omitempty,uuid4"`     // Optional user filter.
+	ServiceName   *string   `json:"service_name,omitempty" form:"service_name" validate:"omitempty"` // Optional service filter.
+	ExcludeTrials bool      `json:"exclude_trials,omitempty" form:"exclude_trials"`                  // Ignore trial subscriptions.
+	MinPrice      *int      `json:"min_price,omitempty" form:"min_price" validate:"omitempty,gte=0"` // Ignore subscriptions cheaper than this, e.g. 1 for free tiers.
+	Currency      *Currency `json:"currency,omitempty" form:"currency" validate:"omitempty,iso4217"` // Only subscriptions in this currency; required if they use several.
+
+	// Optional breakdown of the total by user or service. Groups are ordered
+	// by amount, largest first; Limit and Offset select a page of them and
+	// the groups after the page are added up into one "other" bucket.
+	GroupBy SummaryGroupBy `json:"group_by,omitempty" form:"group_by" validate:"omitempty,oneof=user_id service_name"`
+	Limit   int            `json:"limit,omitempty" form:"limit" validate:"gte=0"`   // Groups per page; 0 means the default page size.
+	Offset  int            `json:"offset,omitempty" form:"offset" validate:"gte=0"` // Groups to skip.
+}
+
+// SummaryGroupBy is the field a summary breakdown groups subscriptions by.
+type SummaryGroupBy string
+
+const (
+	GroupByUser    SummaryGroupBy = "user_id"
+	GroupByService SummaryGroupBy = "service_name"
+)
+
+// Summary is the total price of subscriptions over a period.
+type Summary struct {
+	Total Money         // Sum of monthly prices times months of overlap with the period.
+	Count int           // Number of subscriptions overlapping the period.
+	From  MonthDate     // Start of the period actually used, e.g. moved to the month start.
+	To    MonthDate     // End of the period actually used.
+	Lines []SummaryLine // Contribution of each subscription; only set when explaining a summary.
+
+	Breakdown *SummaryBreakdown // Breakdown of the total; only set if requested.
+}
+
+// SummaryBreakdown is a page of a summary total broken down by groups.
+type SummaryBreakdown struct {
+	GroupBy     SummaryGroupBy `json:"group_by"`        // Field the subscriptions are grouped by.
+	Groups      []SummaryGroup `json:"groups"`          // Groups of the page, largest amount first.
+	Other       *SummaryOther  `json:"other,omitempty"` // Groups after the page added up; nil if there are none.
+	TotalGroups int            `json:"total_groups"`    // Number of groups on all pages.
+	Limit       int            `json:"limit"`           // Page size.
+	Offset      int            `json:"offset"`          // Groups skipped before the page.
+}
+
+// SummaryGroup is the part of a summary total falling to a group.
+type SummaryGroup struct {
+	Key    string `json:"key"`                          // User ID or service name.
+	Amount Money  `json:"amount" swaggertype:"integer"` // Sum; in JSON in whole units of the currency, like the total.
+	Count  int    `json:"count"`                        // Number of subscriptions in the group.
+}
+
+// MarshalJSON renders the amount in whole units of the currency.
+func (g SummaryGroup) MarshalJSON() ([]byte, error) {
+	type group SummaryGroup
+	return json.Marshal(struct {
+		group
+		Amount int64 `json:"amount"`
+	}{group(g), g.Amount.Major()})
+}
+
+// SummaryOther is the remainder of a summary total after a page of groups.
+type SummaryOther struct {
+	Groups int   `json:"groups"`                       // Number of groups added up.
+	Amount Money `json:"amount" swaggertype:"integer"` // Sum; in JSON in whole units of the currency, like the total.
+	Count  int   `json:"count"`                        // Number of subscriptions in the groups.
+}
+
+// MarshalJSON renders the amount in whole units of the currency.
+func (o SummaryOther) MarshalJSON() ([]byte, error) {
+	type other SummaryOther
+	return json.Marshal(struct {
+		other
+		Amount int64 `json:"amount"`
+	}{other(o), o.Amount.Major()})
+}
+
+// SummaryLine is the contribution of a subscription to a summary.
+type SummaryLine struct {
+	ID          int64     `json:"id"`                           // Subscription ID.
+	ServiceName string    `json:"service_name"`                 // Service name.
+	UserID      uuid.UUID `json:"user_id"`                      // Owner of the subscription.
+	Price       Price     `json:"price"`                        // Monthly price.
+	Currency    Currency  `json:"currency"`                     // Currency of the price.
+	From        MonthDate `json:"from"`                         // First counted month.
+	To          MonthDate `json:"to"`                           // Last counted month.
+	Months      int       `json:"months"`                       // Number of counted months.
+	Amount      Money     `json:"amount" swaggertype:"integer"` // Price times months; in JSON in whole units of the currency.
+}
+
+// MarshalJSON renders the amount in whole units of the currency.
+func (l SummaryLine) MarshalJSON() ([]byte, error) {
+	type line SummaryLine
+	return json.Marshal(struct {
+		line
+		Amount int64 `json:"amount"`
+	}{line(l), l.Amount.Major()})
+}
+
+// ActiveOnRequest defines the query for subscriptions active in a month.
+type ActiveOnRequest struct {
+	On          MonthDate `form:"on" validate:"required,monthdate"`   // Month the subscription period must cover.
+	UserID      *string   `form:"user_id" validate:"omitempty,uuid4"` // Optional user filter.
+	ServiceName *string   `form:"service_name" validate:"omitempty"`  // Optional service filter.
+}
+
+// ServicesRequest defines the query for distinct service names.
+type ServicesRequest struct {
+	Prefix string `form:"prefix" validate:"max=100"` // Optional case-insensitive name prefix.
+}
+
+// ServiceCount is a service name with the number of its subscriptions.
+type ServiceCount struct {
+	ServiceName string `json:"service_name"` // Service name.
+	Count       int    `json:"count"`        // Number of subscriptions to the service.
+}
+
+// Overlap is a pair of a user's subscriptions to the same service whose
+// periods overlap, which usually means double billing.
+type Overlap struct {
+	ServiceName string     `json:"service_name"` // Service both subscriptions are for.
+	FirstID     int64      `json:"first_id"`     // Subscription that starts first.
+	SecondID    int64      `json:"second_id"`    // Subscription that starts later.
+	From        MonthDate  `json:"from"`         // First month of the overlap.
+	To          *MonthDate `json:"to,omitempty"` // Last month of the overlap; absent if both are open-ended.
+}
+
+// AuditEntry is a stored audit event, see audit.Store.
+type AuditEntry struct {
+	ID             int64          // Entry identifier.
+	Action         string         // What happened, e.g. "subscription.created".
+	UserID         *uuid.UUID     // User whose subscription is affected; nil if unknown.
+	SubscriptionID int64          // Affected subscription, 0 if none.
+	Actor          *uuid.UUID     // Caller who acted; nil without caller identification.
+	Details        map[string]any // Action-specific data.
+	CreatedAt      time.Time      // When it happened.
+}
+
+// ActivityItem is an entry of a user's activity feed.
+type ActivityItem struct {
+	ID             int64          `json:"id"`                     // Audit entry identifier.
+	At             time.Time      `json:"at"`                     // When it happened (UTC).
+	Kind           string         `json:"kind"`                   // created, updated, price_changed, ended or cancelled.
+	SubscriptionID int64          `json:"subscription_id"`        // Affected subscription.
+	ServiceName    string         `json:"service_name,omitempty"` // Service of the subscription.
+	Message        string         `json:"message"`                // Human-readable summary, e.g. "cancelled Spotify".
+	Actor          *uuid.UUID     `json:"actor,omitempty"`        // Caller who acted, if identified.
+	Details        map[string]any `json:"details,omitempty"`      // Recorded data, e.g. changed fields.
+}
+
+// RetentionRequest defines the cohort range of the retention report.
+type RetentionRequest struct {
+	From MonthDate `form:"from"` // Optional first cohort month.
+	To   MonthDate `form:"to"`   // Optional last cohort month.
+}
+
+// RetentionCohort reports how many subscriptions started in a month were
+// still active some months later. Counts are absent for months not reached yet.
+type RetentionCohort struct {
+	Cohort  MonthDate `json:"cohort"`             // Start month of the subscriptions.
+	Size    int       `json:"size"`               // Subscriptions started in the cohort month.
+	Month1  *int      `json:"month_1,omitempty"`  // Still active 1 month later.
+	Month3  *int      `json:"month_3,omitempty"`  // Still active 3 months later.
+	Month6  *int      `json:"month_6,omitempty"`  // Still active 6 months later.
+	Month12 *int      `json:"month_12,omitempty"` // Still active 12 months later.
+}
+
+// ServiceActivity sums up the subscriptions to a service in a currency
+// active in a month.
+type ServiceActivity struct {
+	ServiceName string   // Service name.
+	Currency    Currency // Currency of the prices.
+	Count       int      // Active subscriptions.
+	Spend       int64    // Sum of their monthly prices in minor units.
+}
+
+// SubscriptionFilter narrows subscription queries. Nil fields are not applied.
+type SubscriptionFilter struct {
+	UserID        *uuid.UUID // Only subscriptions of this user.
+	ServiceName   *string    // Only subscriptions of this service.
+	ExcludeTrials bool       // Only paid subscriptions.
+	MinPrice      *int       // Only subscriptions costing at least this much.
+	Currency      *Currency  // Only subscriptions in this currency.
+}
+
+// DeadLetter is an async delivery (outbox event, webhook, job) that
+// exhausted its retries.
+type DeadLetter struct {
+	ID            int64           `json:"id"`                       // Dead letter identifier.
+	Kind          string          `json:"kind"`                     // Delivery kind, e.g. "notification".
+	Payload       json.RawMessage `json:"payload"`                  // Original payload.
+	Errors        []string        `json:"errors"`                   // Error chain, outermost first; redelivery failures are appended.
+	Attempts      int             `json:"attempts"`                 // Delivery attempts made so far.
+	CreatedAt     time.Time       `json:"created_at"`               // When the delivery was given up.
+	RedeliveredAt *time.Time      `json:"redelivered_at,omitempty"` // When a redelivery succeeded.
+}
+
+// Tenant is a team the service is provided to in schema-per-tenant mode.
+type Tenant struct {
+	ID        string       `json:"id"`         // Tenant identifier, see tenant.Parse.
+	Name      string       `json:"name"`       // Human-readable name, e.g. of the team.
+	Limits    TenantLimits `json:"limits"`     // Overrides of the service-wide usage limits.
+	CreatedAt time.Time    `json:"created_at"` // When the tenant was registered.
+	UpdatedAt time.Time    `json:"updated_at"` // When the tenant was last changed.
+}
+
+// TenantLimits override service-wide usage limits for the users of a
+// tenant. Nil fields keep the service-wide limit, zero disables it.
+type TenantLimits struct {
+	MaxActivePerUser      *int     `json:"max_active_per_user,omitempty"`      // Active subscriptions per user.
+	WritesPerUserPerHour  *int     `json:"writes_per_user_per_hour,omitempty"` // Creates and updates per user per hour.
+	MaxPriceChangePercent *float64 `json:"max_price_change_percent,omitempty"` // Price change per update without confirmation.
+}
+
+// APIKey is a key the clients of a tenant authenticate with. The key itself
+// is only shown when issued; a hash of it is stored.
+type APIKey struct {
+	ID        int64      `json:"id"`                   // Key identifier.
+	TenantID  string     `json:"tenant_id"`            // Tenant the key authenticates as.
+	Prefix    string     `json:"prefix"`               // First characters of the key, to tell keys apart.
+	CreatedAt time.Time  `json:"created_at"`           // When the key was issued.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // When the key was revoked.
+}
+
+// User is a user subscriptions belong to, provisioned by the upstream
+// identity system.
+type User struct {
+	ID        uuid.UUID `json:"id" validate:"required"` // User ID, as in subscriptions.
+	CreatedAt time.Time `json:"created_at"`             // When the user was provisioned.
+}
+
+// WebhookKey is an Ed25519 key outgoing webhook requests are signed with.
+type WebhookKey struct {
+	ID         string     `json:"id"`                   // Key ID (kid) sent with signed requests.
+	PublicKey  []byte     `json:"-"`                    // Ed25519 public key, published as a JWK.
+	PrivateKey []byte     `json:"-"`                    // Ed25519 seed of the private key.
+	CreatedAt  time.Time  `json:"created_at"`           // When the key was generated.
+	RetiredAt  *time.Time `json:"retired_at,omitempty"` // When a newer key replaced it.
+}
+
+// BackfillStatus is the state of a backfill.
+type BackfillStatus string
+
+const (
+	BackfillPending BackfillStatus = "pending" // Never started.
+	BackfillRunning BackfillStatus = "running" // Started; resumed after a restart.
+	BackfillPaused  BackfillStatus = "paused"  // Stopped by an admin; resumes where it stopped.
+	BackfillDone    BackfillStatus = "done"    // All rows processed.
+	BackfillFailed  BackfillStatus = "failed"  // A batch failed; resumes with the failed batch.
+)
+
+// Backfill is the progress of a data migration run in batches.
+type Backfill struct {
+	Name        string         `json:"name"`                  // Backfill name, e.g. "normalize_dates".
+	Description string         `json:"description,omitempty"` // What the backfill does.
+	Status      BackfillStatus `json:"status"`                // Current state.
+	LastID      int64          `json:"last_id"`               // ID of the last processed row.
+	Processed   int64          `json:"processed"`             // Rows processed so far.
+	Error       string         `json:"error,omitempty"`       // Error of the failed batch.
+	StartedAt   *time.Time     `json:"started_at,omitempty"`  // When the backfill was first started.
+	UpdatedAt   time.Time      `json:"updated_at"`            // When the progress last changed.
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"` // When the last batch was processed.
+}
+
+// SavedFilter is a named combination of subscription filters saved by a
+// user to be passed as filter_id to list and summary requests.
+type SavedFilter struct {
+	ID        int64          `json:"id"`                               // Saved filter identifier.
+	UserID    uuid.UUID      `json:"user_id"`                          // Owner; set from the path.
+	Name      string         `json:"name" validate:"required,max=100"` // Name, unique per user.
+	Filter    FilterCriteria `json:"filter"`                           // Saved filters.
+	CreatedAt time.Time      `json:"created_at"`                       // When the filter was saved.
+	UpdatedAt time.Time      `json:"updated_at"`                       // When the filter was last changed.
+}
+
+// FilterCriteria are the filters of a SavedFilter, as in SummaryRequest.
+type FilterCriteria struct {
+	UserID        *uuid.UUID `json:"user_id,omitempty"`                               // Only subscriptions of this user.
+	ServiceName   *string    `json:"service_name,omitempty"`                          // Only subscriptions of this service.
+	ExcludeTrials bool       `json:"exclude_trials,omitempty"`                        // Ignore trial subscriptions.
+	MinPrice      *int       `json:"min_price,omitempty" validate:"omitempty,gte=0"`  // Ignore subscriptions cheaper than this.
+	Currency      *Currency  `json:"currency,omitempty" validate:"omitempty,iso4217"` // Only subscriptions in this currency.
+}
+
+// SubscriptionFilter returns the criteria as a filter of subscription
+// queries.
+func (f FilterCriteria) SubscriptionFilter() SubscriptionFilter {
+	return SubscriptionFilter{
+		UserID:        f.UserID,
+		ServiceName:   f.ServiceName,
+		ExcludeTrials: f.ExcludeTrials,
+		MinPrice:      f.MinPrice,
+		Currency:      f.Currency,
+	}
+}
+
+// ApplyTo sets the filters of req that the request itself leaves unset to
+// the criteria, so parameters of a request override a saved filter.
+func (f FilterCriteria) ApplyTo(req *SummaryRequest) {
+	if req.UserID == nil && f.UserID != nil {
+		userID := f.UserID.String()
+		req.UserID = &userID
+	}
+	if req.ServiceName == nil {
+		req.ServiceName = f.ServiceName
+	}
+	req.ExcludeTrials = req.ExcludeTrials || f.ExcludeTrials
+	if req.MinPrice == nil {
+		req.MinPrice = f.MinPrice
+	}
+	if req.Currency == nil {
+		req.Currency = f.Currency
+	}
+}
+
+// SummaryPeriod is the period a scheduled summary covers and is sent after.
+type SummaryPeriod string
+
+const (
+	PeriodMonth   SummaryPeriod = "month"   // Calendar month.
+	PeriodQuarter SummaryPeriod = "quarter" // Calendar quarter, starting in January, April, July or October.
+	PeriodYear    SummaryPeriod = "year"    // Calendar year.
+)
+
+// Months returns the length of the period in months.
+func (p SummaryPeriod) Months() int {
+	switch p {
+	case PeriodQuarter:
+		return 3
+	case PeriodYear:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// Start returns the start of the period containing t, in UTC.
+func (p SummaryPeriod) Start(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	month -= (month - 1) % time.Month(p.Months())
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SummarySubscription is a summary of a user's filters sent through a
+// notification channel after each period.
+type SummarySubscription struct {
+	ID     int64          `json:"id"`                                                  // Summary subscription identifier.
+	UserID uuid.UUID      `json:"user_id" validate:"required"`                         // User who subscribed.
+	Period SummaryPeriod  `json:"period" validate:"required,oneof=month quarter year"` // Period each summary covers.
+	Filter FilterCriteria `json:"filter"`                                              // Filters of the summary, as in SummaryRequest.
+	// Notification channel, one of notifications.channels, e.g. email.
+	Channel string `json:"channel" validate:"required"`
+	// Address the summaries are sent to; only channels sending to any
+	// recipient (email) can be used.
+	Recipient  string     `json:"recipient" validate:"required,max=254"`
+	NextRunAt  time.Time  `json:"next_run_at"`            // When the next summary is sent.
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"` // When the last summary was sent.
+	LastError  string     `json:"last_error,omitempty"`   // Why the last attempt failed; retried on the next run.
+	CreatedAt  time.Time  `json:"created_at"`             // When the user subscribed.
+	// When the recipient confirmed the address with the token sent there;
+	// summaries are only sent after that.
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// SummarySubscriptionConfirmation confirms the address of a summary
+// subscription with the token sent there.
+type SummarySubscriptionConfirmation struct {
+	Token string `json:"token" validate:"required,max=100"` // Token from the confirmation message.
 }
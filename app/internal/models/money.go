@@ -0,0 +1,180 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code, e.g. "RUB".
+type Currency string
+
+// DefaultCurrency is the currency of prices stored without one.
+const DefaultCurrency Currency = "RUB"
+
+var (
+	// ErrInvalidCurrency is returned for malformed currency codes.
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrCurrencyMismatch is returned by arithmetic on different currencies.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrMoneyOverflow is returned when a result does not fit into int64.
+	ErrMoneyOverflow = errors.New("money amount overflow")
+)
+
+// minorUnitExceptions lists currencies without the usual 2 minor unit digits.
+var minorUnitExceptions = map[Currency]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+}
+
+// ParseCurrency parses a three-letter code case-insensitively.
+func ParseCurrency(s string) (Currency, error) {
+	if len(s) != 3 {
+		return "", fmt.Errorf("%w %q", ErrInvalidCurrency, s)
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return "", fmt.Errorf("%w %q", ErrInvalidCurrency, s)
+		}
+	}
+	return Currency(strings.ToUpper(s)), nil
+}
+
+// OrDefault returns c, or DefaultCurrency if c is empty.
+func (c Currency) OrDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// MinorUnits returns the number of minor unit digits, e.g. 2 for kopecks.
+func (c Currency) MinorUnits() int {
+	if n, ok := minorUnitExceptions[c]; ok {
+		return n
+	}
+	return 2
+}
+
+// scale returns the number of minor units in a major unit.
+func (c Currency) scale() int64 {
+	s := int64(1)
+	for range c.MinorUnits() {
+		s *= 10
+	}
+	return s
+}
+
+// Money is an amount in minor units of a currency, e.g. kopecks. Arithmetic
+// methods fail instead of overflowing or mixing currencies. It is used for
+// computed amounts, e.g. summary totals, and is not stored: subscription
+// prices are still whole units (Price).
+type Money struct {
+	Amount   int64    `json:"amount"`   // Amount in minor units.
+	Currency Currency `json:"currency"` // ISO 4217 code.
+}
+
+// NewMoney returns major whole units of c, e.g. rubles, as Money.
+func NewMoney(major int64, c Currency) (Money, error) {
+	amount, err := mul(major, c.scale())
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: c}, nil
+}
+
+// Zero returns no money in c.
+func Zero(c Currency) Money {
+	return Money{Currency: c}
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Mul returns m multiplied by n, e.g. a monthly price by months.
+func (m Money) Mul(n int64) (Money, error) {
+	amount, err := mul(m.Amount, n)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Major returns the amount in whole major units, truncated toward zero.
+func (m Money) Major() int64 {
+	return m.Amount / m.Currency.scale()
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats m as "499.00 RUB".
+func (m Money) String() string {
+	digits := m.Currency.MinorUnits()
+	if digits == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	scale := uint64(m.Currency.scale())
+	major, minor := abs/scale, abs%scale
+	return fmt.Sprintf("%s%d.%0*d %s", sign, major, digits, minor, m.Currency)
+}
+
+// UnmarshalJSON decodes {"amount": ..., "currency": ...} and checks the
+// currency code.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Amount   *int64 `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v.Amount == nil {
+		return errors.New("money amount is missing")
+	}
+	c, err := ParseCurrency(v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = Money{Amount: *v.Amount, Currency: c}
+	return nil
+}
+
+func mul(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	r := a * b
+	if r/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrMoneyOverflow
+	}
+	return r, nil
+}
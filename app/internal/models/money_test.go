@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Arithmetic(t *testing.T) {
+	rub := func(amount int64) Money { return Money{Amount: amount, Currency: "RUB"} }
+
+	m, err := NewMoney(499, "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, rub(49900), m)
+	assert.Equal(t, int64(499), m.Major())
+
+	m, err = m.Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, rub(149700), m)
+
+	m, err = m.Add(rub(300))
+	require.NoError(t, err)
+	m, err = m.Sub(rub(1000))
+	require.NoError(t, err)
+	assert.Equal(t, rub(149000), m)
+
+	_, err = m.Add(Money{Amount: 1, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = rub(math.MaxInt64).Add(rub(1))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = rub(math.MinInt64).Sub(rub(1))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = rub(math.MaxInt64 / 2).Mul(3)
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = NewMoney(math.MaxInt64/10, "RUB")
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+
+	jpy, err := NewMoney(500, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), jpy.Amount)
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "499.00 RUB", Money{Amount: 49900, Currency: "RUB"}.String())
+	assert.Equal(t, "-1.00 RUB", Money{Amount: -100, Currency: "RUB"}.String())
+	assert.Equal(t, "-0.05 USD", Money{Amount: -5, Currency: "USD"}.String())
+	assert.Equal(t, "1.500 KWD", Money{Amount: 1500, Currency: "KWD"}.String())
+	assert.Equal(t, "500 JPY", Money{Amount: 500, Currency: "JPY"}.String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	b, err := json.Marshal(Money{Amount: 49900, Currency: "RUB"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":49900,"currency":"RUB"}`, string(b))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":100,"currency":"usd"}`), &m))
+	assert.Equal(t, Money{Amount: 100, Currency: "USD"}, m)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":100,"currency":"rubles"}`), &m), ErrInvalidCurrency)
+	assert.Error(t, json.Unmarshal([]byte(`{"currency":"RUB"}`), &m))
+}
+
+func TestSummaryBreakdown_JSON(t *testing.T) {
+	// The total of 800 RUB is rendered by handlers as Total.Major().
+	b, err := json.Marshal(SummaryBreakdown{
+		GroupBy: GroupByService,
+		Groups:  []SummaryGroup{{Key: "Spotify", Amount: Money{Amount: 50000, Currency: "RUB"}, Count: 2}},
+		Other:   &SummaryOther{Groups: 1, Amount: Money{Amount: 30000, Currency: "RUB"}, Count: 1},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"group_by": "service_name",
+		"groups": [{"key": "Spotify", "amount": 500, "count": 2}],
+		"other": {"groups": 1, "amount": 300, "count": 1},
+		"total_groups": 0, "limit": 0, "offset": 0
+	}`, string(b), "amounts are in whole units like the total")
+
+	b, err = json.Marshal(SummaryLine{ID: 1, Amount: Money{Amount: 99800, Currency: "RUB"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"amount":998}`)
+}
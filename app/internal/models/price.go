@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+)
+
+// Price is a monthly price in whole currency units. It is stored as a
+// 32-bit integer, so larger values are rejected when decoding.
+type Price int
+
+// ErrInvalidPrice is returned when a JSON price is not an integer in range.
+var ErrInvalidPrice = errors.New("invalid price")
+
+var lenientPrices atomic.Bool
+
+// SetLenientPrices sets whether prices sent as numeric strings, e.g. "499",
+// are accepted. It is meant to be called once at startup.
+func SetLenientPrices(on bool) {
+	lenientPrices.Store(on)
+}
+
+// UnmarshalJSON parses a JSON integer or, with SetLenientPrices, a string
+// of decimal digits. Fractions, exponents, signs and surrounding spaces in
+// strings are rejected, as are values out of the 32-bit range.
+func (p *Price) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		if !lenientPrices.Load() {
+			return fmt.Errorf("%w: a number is expected", ErrInvalidPrice)
+		}
+		s, err := strconv.Unquote(string(b))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidPrice, err)
+		}
+		if !isDigits(s) {
+			return fmt.Errorf("%w: %q is not a whole number", ErrInvalidPrice, s)
+		}
+		b = []byte(s)
+	}
+
+	var n int64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPrice, err)
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return fmt.Errorf("%w: %d is out of range", ErrInvalidPrice, n)
+	}
+	*p = Price(n)
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrice_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		lenient bool
+		want    Price
+		wantErr bool
+	}{
+		{name: "number", json: `499`, want: 499},
+		{name: "string strict", json: `"499"`, wantErr: true},
+		{name: "string lenient", json: `"499"`, lenient: true, want: 499},
+		{name: "fraction", json: `4.99`, wantErr: true},
+		{name: "fraction string", json: `"4.99"`, lenient: true, wantErr: true},
+		{name: "spaces", json: `" 499"`, lenient: true, wantErr: true},
+		{name: "signed string", json: `"+499"`, lenient: true, wantErr: true},
+		{name: "empty string", json: `""`, lenient: true, wantErr: true},
+		{name: "out of range", json: `2147483648`, wantErr: true},
+		{name: "out of range string", json: `"99999999999999999999"`, lenient: true, wantErr: true},
+		{name: "negative is left to validation", json: `-1`, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLenientPrices(tt.lenient)
+			t.Cleanup(func() { SetLenientPrices(false) })
+
+			var p Price
+			err := json.Unmarshal([]byte(tt.json), &p)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPrice)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// vldMu guards vld: validator.Validate is safe for concurrent validation
+	// but not for registrations concurrent with it.
+	vldMu sync.RWMutex
+	vld   = newValidator()
+
+	// structRules are the struct-level validations registered per type;
+	// validator keeps only one function per type, so they are combined.
+	structRules = map[reflect.Type][]validator.StructLevelFunc{}
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// Report JSON field names in validation errors.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return f.Name
+		}
+		return name
+	})
+
+	// Register custom validation for MonthDate fields.
+	v.RegisterValidation("monthdate", func(fl validator.FieldLevel) bool {
+		md, ok := fl.Field().Interface().(MonthDate)
+		if !ok {
+			return false
+		}
+		return !md.Time.IsZero()
+	})
+	return v
+}
+
+// Validator returns the validator used by Validate, e.g. to register translations.
+// Registrations on it are not synchronized with Validate, so they must be made
+// before requests are served; RegisterValidation and RegisterStructValidation
+// may be called at any time.
+func Validator() *validator.Validate {
+	return vld
+}
+
+// Validate runs field validation based on struct tags, then the struct-level
+// validations registered for the struct's type.
+func Validate(modelsStruct interface{}) error {
+	vldMu.RLock()
+	defer vldMu.RUnlock()
+	return vld.Struct(modelsStruct)
+}
+
+// RegisterValidation adds a validation tag for struct tags, so extensions can
+// add rules to their own request types without changing this package. A tag
+// that is already registered, built-in or not, is replaced. It is safe to
+// call concurrently with Validate.
+func RegisterValidation(tag string, fn validator.Func) error {
+	vldMu.Lock()
+	defer vldMu.Unlock()
+	if err := vld.RegisterValidation(tag, fn); err != nil {
+		return fmt.Errorf("register validation %q: %w", tag, err)
+	}
+	return nil
+}
+
+// RegisterStructValidation adds a struct-level validation for the types of
+// the given values, run by Validate after their field validations, e.g. to
+// restrict Subscription.ServiceName to a tenant's whitelist. Failures are
+// reported with validator.StructLevel.ReportError. Validations registered
+// for the same type run in registration order and all their failures are
+// reported. It is safe to call concurrently with Validate.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	vldMu.Lock()
+	defer vldMu.Unlock()
+	for _, t := range types {
+		typ := reflect.TypeOf(t)
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		structRules[typ] = append(structRules[typ], fn)
+
+		rules := structRules[typ]
+		vld.RegisterStructValidation(func(sl validator.StructLevel) {
+			for _, rule := range rules {
+				rule(sl)
+			}
+		}, reflect.New(typ).Elem().Interface())
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterValidation(t *testing.T) {
+	type request struct {
+		Service string `json:"service" validate:"required,test_whitelist"`
+	}
+	require.NoError(t, RegisterValidation("test_whitelist", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "Netflix"
+	}))
+
+	assert.NoError(t, Validate(request{Service: "Netflix"}))
+
+	var verrs validator.ValidationErrors
+	require.True(t, errors.As(Validate(request{Service: "Hulu"}), &verrs))
+	assert.Equal(t, "service", verrs[0].Field())
+	assert.Equal(t, "test_whitelist", verrs[0].Tag())
+
+	assert.Error(t, RegisterValidation("", nil))
+}
+
+func TestRegisterStructValidation(t *testing.T) {
+	type period struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	}
+	RegisterStructValidation(func(sl validator.StructLevel) {
+		if p := sl.Current().Interface().(period); p.To < p.From {
+			sl.ReportError(p.To, "to", "To", "gtefield", "from")
+		}
+	}, period{})
+	RegisterStructValidation(func(sl validator.StructLevel) {
+		if p := sl.Current().Interface().(period); p.From < 0 {
+			sl.ReportError(p.From, "from", "From", "gte", "0")
+		}
+	}, &period{})
+
+	assert.NoError(t, Validate(period{From: 1, To: 2}))
+
+	var verrs validator.ValidationErrors
+	require.True(t, errors.As(Validate(&period{From: -1, To: -2}), &verrs))
+	require.Len(t, verrs, 2, "both rules run")
+	assert.Equal(t, "to", verrs[0].Field())
+	assert.Equal(t, "from", verrs[1].Field())
+}
+
+func TestRegisterValidation_Concurrent(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			assert.NoError(t, Validate(request{Name: "x"}))
+		})
+		wg.Go(func() {
+			RegisterStructValidation(func(validator.StructLevel) {}, request{})
+		})
+	}
+	wg.Wait()
+}
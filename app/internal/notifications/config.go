@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+
+	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/httpclient"
+)
+
+// New builds the channel selected by cfg.Channels: Nop if none, the channel
+// itself if one, Multi otherwise. client is used by HTTP-based channels,
+// signer signs the requests of the webhook one.
+func New(cfg config.Notifications, client *httpclient.Client, signer Signer) (Channel, error) {
+	var channels Multi
+	for _, name := range cfg.Channels {
+		ch, err := newChannel(name, cfg, client, signer)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, ch)
+	}
+
+	switch len(channels) {
+	case 0:
+		return Nop{}, nil
+	case 1:
+		return channels[0], nil
+	default:
+		return channels, nil
+	}
+}
+
+func newChannel(name string, cfg config.Notifications, client *httpclient.Client, signer Signer) (Channel, error) {
+	switch name {
+	case "email":
+		e := cfg.Email
+		if e.Host == "" || e.From == "" || len(e.To) == 0 {
+			return nil, errors.New("notifications.email: host, from and to are required")
+		}
+		return NewEmail(e.Host, e.Port, e.Username, e.Password, e.From, e.To), nil
+	case "telegram":
+		if cfg.Telegram.Token == "" || cfg.Telegram.ChatID == "" {
+			return nil, errors.New("notifications.telegram: token and chat_id are required")
+		}
+		return NewTelegram(client, cfg.Telegram.Token, cfg.Telegram.ChatID), nil
+	case "slack":
+		if cfg.Slack.WebhookURL == "" {
+			return nil, errors.New("notifications.slack: webhook_url is required")
+		}
+		return NewSlack(client, cfg.Slack.WebhookURL), nil
+	case "webhook":
+		if cfg.Webhook.URL == "" {
+			return nil, errors.New("notifications.webhook: url is required")
+		}
+		if signer == nil {
+			return nil, errors.New("notifications.webhook: requests must be signed, no signer is given")
+		}
+		return NewWebhook(client, cfg.Webhook.URL, signer), nil
+	default:
+		return nil, fmt.Errorf("notifications: unknown channel %q", name)
+	}
+}
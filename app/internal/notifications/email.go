@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+)
+
+// Email sends messages over SMTP.
+type Email struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an SMTP channel. Authentication (PLAIN) is used only if
+// username is set; smtp.SendMail upgrades to TLS when the server supports it.
+func NewEmail(host string, port int, username, password, from string, to []string) *Email {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &Email{
+		addr: net.JoinHostPort(host, fmt.Sprint(port)),
+		auth: auth,
+		from: from,
+		to:   to,
+		send: smtp.SendMail,
+	}
+}
+
+// Name implements Channel.
+func (e *Email) Name() string { return "email" }
+
+// To implements Addressed. recipient is a single address, optionally with a
+// display name.
+func (e *Email) To(recipient string) (Channel, error) {
+	addr, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address: %w", err)
+	}
+	cp := *e
+	cp.to = []string{addr.Address}
+	return &cp, nil
+}
+
+// Send implements Channel. smtp.SendMail does not support contexts, so ctx is
+// only checked before sending.
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+
+	if err := e.send(e.addr, e.auth, e.from, e.to, []byte(b.String())); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// sanitizeHeader prevents header injection through line breaks.
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,75 @@
+// Package notifications delivers messages to people through pluggable
+// channels (email, Telegram, Slack, signed webhooks) selected in the config.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Message is a notification.
+type Message struct {
+	Subject string `json:"subject"` // Short summary; used as email subject and message title.
+	Text    string `json:"text"`    // Plain text body.
+}
+
+// Channel delivers messages to a destination.
+type Channel interface {
+	// Name identifies the channel in logs and errors.
+	Name() string
+	// Send delivers msg.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Addressed is a channel that can deliver to other recipients than its
+// configured destination, e.g. email.
+type Addressed interface {
+	Channel
+	// To returns the channel delivering to recipient instead. It returns
+	// an error if recipient is not a valid address for the channel.
+	To(recipient string) (Channel, error)
+}
+
+// Find returns the channel named name of ch, which is a channel or Multi.
+func Find(ch Channel, name string) (Channel, bool) {
+	if m, ok := ch.(Multi); ok {
+		for _, c := range m {
+			if c.Name() == name {
+				return c, true
+			}
+		}
+		return nil, false
+	}
+	if ch.Name() == name {
+		return ch, true
+	}
+	return nil, false
+}
+
+// Multi sends messages to all its channels.
+type Multi []Channel
+
+// Name implements Channel.
+func (m Multi) Name() string { return "multi" }
+
+// Send delivers msg to every channel, even if some fail, and returns the
+// joined errors of the failed ones.
+func (m Multi) Send(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, ch := range m {
+		if err := ch.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Nop discards messages. It is used when no channel is configured.
+type Nop struct{}
+
+// Name implements Channel.
+func (Nop) Name() string { return "nop" }
+
+// Send implements Channel.
+func (Nop) Send(context.Context, Message) error { return nil }
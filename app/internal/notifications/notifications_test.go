@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"subscriptionsservice/internal/config"
+	"subscriptionsservice/internal/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingChannel struct{}
+
+func (failingChannel) Name() string                        { return "failing" }
+func (failingChannel) Send(context.Context, Message) error { return errors.New("unavailable") }
+
+func TestMulti_Send(t *testing.T) {
+	err := Multi{Nop{}, failingChannel{}}.Send(t.Context(), Message{Text: "hi"})
+	assert.EqualError(t, err, "failing: unavailable")
+}
+
+func TestEmail_Send(t *testing.T) {
+	var got []byte
+	e := NewEmail("smtp.example.com", 587, "", "", "noreply@example.com", []string{"ops@example.com"})
+	e.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.Equal(t, []string{"ops@example.com"}, to)
+		got = msg
+		return nil
+	}
+
+	require.NoError(t, e.Send(t.Context(), Message{Subject: "Budget\r\nBcc: evil@example.com", Text: "line1\nline2"}))
+	assert.Contains(t, string(got), "Subject: Budget  Bcc: evil@example.com\r\n")
+	assert.Contains(t, string(got), "\r\n\r\nline1\r\nline2")
+}
+
+func TestEmail_To(t *testing.T) {
+	var to []string
+	e := NewEmail("smtp.example.com", 587, "", "", "noreply@example.com", []string{"ops@example.com"})
+	e.send = func(_ string, _ smtp.Auth, _ string, rcpt []string, _ []byte) error {
+		to = rcpt
+		return nil
+	}
+
+	ch, err := e.To("Anna <anna@example.com>")
+	require.NoError(t, err)
+	require.NoError(t, ch.Send(t.Context(), Message{Text: "hi"}))
+	assert.Equal(t, []string{"anna@example.com"}, to)
+	assert.Equal(t, []string{"ops@example.com"}, e.to, "the configured recipients are kept")
+
+	_, err = e.To("anna@example.com\r\nBcc: evil@example.com")
+	assert.Error(t, err)
+}
+
+func TestFind(t *testing.T) {
+	e := NewEmail("smtp.example.com", 587, "", "", "noreply@example.com", []string{"ops@example.com"})
+
+	ch, ok := Find(Multi{Nop{}, e}, "email")
+	assert.True(t, ok)
+	assert.Same(t, e, ch)
+	_, ok = Find(Multi{Nop{}, e}, "slack")
+	assert.False(t, ok)
+	_, ok = Find(e, "email")
+	assert.True(t, ok)
+}
+
+func TestTelegram_Send(t *testing.T) {
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/botTOKEN/sendMessage", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer srv.Close()
+
+	tg := NewTelegram(httpclient.New("test"), "TOKEN", "42")
+	tg.baseURL = srv.URL
+
+	require.NoError(t, tg.Send(t.Context(), Message{Subject: "Alert", Text: "db is down"}))
+	assert.Equal(t, map[string]string{"chat_id": "42", "text": "Alert\n\ndb is down"}, body)
+}
+
+func TestSlack_Send(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("invalid_token"))
+	}))
+	defer srv.Close()
+
+	err := NewSlack(httpclient.New("test"), srv.URL+"/services/secret").Send(t.Context(), Message{Text: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.NotContains(t, err.Error(), "/services/secret")
+}
+
+// bodySigner "signs" requests with their body.
+type bodySigner struct{}
+
+func (bodySigner) Sign(req *http.Request, body []byte) error {
+	req.Header.Set("Webhook-Signature", string(body))
+	return nil
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var signature string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("Webhook-Signature")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(httpclient.New("test"), srv.URL+"/hooks/secret", bodySigner{})
+	require.NoError(t, wh.Send(t.Context(), Message{Subject: "Alert", Text: "db is down"}))
+	assert.JSONEq(t, `{"subject":"Alert","text":"db is down"}`, string(body))
+	assert.Equal(t, string(body), signature, "the sent body is signed")
+}
+
+func TestNew(t *testing.T) {
+	client := httpclient.New("test")
+
+	ch, err := New(config.Notifications{}, client, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Nop{}, ch)
+
+	ch, err = New(config.Notifications{
+		Channels: []string{"slack", "telegram"},
+		Slack:    config.Slack{WebhookURL: "https://hooks.slack.com/x"},
+		Telegram: config.Telegram{Token: "t", ChatID: "1"},
+	}, client, nil)
+	require.NoError(t, err)
+	assert.Len(t, ch, 2)
+
+	_, err = New(config.Notifications{Channels: []string{"email"}}, client, nil)
+	assert.Error(t, err)
+
+	_, err = New(config.Notifications{
+		Channels: []string{"webhook"},
+		Webhook:  config.Webhook{URL: "https://hooks.example.com/x"},
+	}, client, nil)
+	assert.ErrorContains(t, err, "must be signed")
+
+	_, err = New(config.Notifications{Channels: []string{"pigeon"}}, client, nil)
+	assert.EqualError(t, err, `notifications: unknown channel "pigeon"`)
+}
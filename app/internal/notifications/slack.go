@@ -0,0 +1,30 @@
+package notifications
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/httpclient"
+)
+
+// Slack sends messages to a Slack incoming webhook.
+type Slack struct {
+	client     *httpclient.Client
+	webhookURL string
+}
+
+// NewSlack creates a Slack channel posting to webhookURL.
+func NewSlack(client *httpclient.Client, webhookURL string) *Slack {
+	return &Slack{client: client, webhookURL: webhookURL}
+}
+
+// Name implements Channel.
+func (s *Slack) Name() string { return "slack" }
+
+// Send implements Channel.
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if msg.Subject != "" {
+		text = "*" + msg.Subject + "*\n" + msg.Text
+	}
+	return redact(postJSON(ctx, s.client, s.webhookURL, map[string]string{"text": text}), s.webhookURL)
+}
@@ -0,0 +1,86 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"subscriptionsservice/internal/httpclient"
+)
+
+// telegramAPI is the Telegram Bot API base URL.
+const telegramAPI = "https://api.telegram.org"
+
+// Telegram sends messages to a chat through a Telegram bot.
+type Telegram struct {
+	client  *httpclient.Client
+	baseURL string
+	token   string
+	chatID  string
+}
+
+// NewTelegram creates a Telegram channel posting to chatID as the bot with token.
+func NewTelegram(client *httpclient.Client, token, chatID string) *Telegram {
+	return &Telegram{client: client, baseURL: telegramAPI, token: token, chatID: chatID}
+}
+
+// Name implements Channel.
+func (t *Telegram) Name() string { return "telegram" }
+
+// Send implements Channel.
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	text := msg.Text
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + msg.Text
+	}
+
+	err := postJSON(ctx, t.client, t.baseURL+"/bot"+t.token+"/sendMessage", map[string]string{
+		"chat_id": t.chatID,
+		"text":    text,
+	})
+	return redact(err, t.token)
+}
+
+// redact removes secret from the error message; transport errors include the
+// request URL, which carries the bot token or webhook path.
+func redact(err error, secret string) error {
+	if err == nil || secret == "" || !strings.Contains(err.Error(), secret) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), secret, "[redacted]"))
+}
+
+// postJSON posts body as JSON and expects a 2xx response.
+func postJSON(ctx context.Context, client *httpclient.Client, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(client, req)
+}
+
+// do sends req and expects a 2xx response.
+func do(client *httpclient.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
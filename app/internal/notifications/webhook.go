@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"subscriptionsservice/internal/httpclient"
+)
+
+// Signer signs webhook requests, so receivers can tell they come from the
+// service.
+type Signer interface {
+	// Sign adds signature headers to req, whose body is body.
+	Sign(req *http.Request, body []byte) error
+}
+
+// Webhook posts messages as JSON to a URL, signed by a Signer.
+type Webhook struct {
+	client *httpclient.Client
+	url    string
+	signer Signer
+}
+
+// NewWebhook creates a Webhook channel posting to url.
+func NewWebhook(client *httpclient.Client, url string, signer Signer) *Webhook {
+	return &Webhook{client: client, url: url, signer: signer}
+}
+
+// Name implements Channel.
+func (w *Webhook) Name() string { return "webhook" }
+
+// Send implements Channel.
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return redact(err, w.url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := w.signer.Sign(req, payload); err != nil {
+		return err
+	}
+	return redact(do(w.client, req), w.url)
+}
@@ -0,0 +1,45 @@
+// Package readonly holds the read-only mode switch of the service. In
+// read-only mode writes are rejected while reads are served, e.g. during a
+// database failover or a data migration.
+package readonly
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// State is the read-only mode state.
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"` // Why the mode was switched, for operators.
+	Since   time.Time `json:"since"`            // When the mode was last switched.
+}
+
+// Mode is the read-only mode switch. It is safe for concurrent use.
+type Mode struct {
+	state atomic.Pointer[State]
+}
+
+// New creates a switch, read-only if enabled.
+func New(enabled bool, reason string) *Mode {
+	m := &Mode{}
+	m.Set(enabled, reason)
+	return m
+}
+
+// Enabled reports whether the service is read-only.
+func (m *Mode) Enabled() bool {
+	return m.state.Load().Enabled
+}
+
+// State returns the current state.
+func (m *Mode) State() State {
+	return *m.state.Load()
+}
+
+// Set switches read-only mode on or off and returns the new state.
+func (m *Mode) Set(enabled bool, reason string) State {
+	s := &State{Enabled: enabled, Reason: reason, Since: time.Now().UTC()}
+	m.state.Store(s)
+	return *s
+}
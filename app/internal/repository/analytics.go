@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// AnalyticsRepo runs reporting queries over subscriptions.
+type AnalyticsRepo struct {
+	base
+}
+
+// NewAnalyticsRepo initializes AnalyticsRepo.
+// db is usually a *pgxpool.Pool.
+func NewAnalyticsRepo(db Executer, r retry.Retrier) *AnalyticsRepo {
+	return &AnalyticsRepo{base: newBase(db, r)}
+}
+
+// retentionMonths are the offsets from the cohort month reported by Retention,
+// in the order of the models.RetentionCohort fields.
+var retentionMonths = []int{1, 3, 6, 12}
+
+// Retention groups subscriptions by start month (cohort) and counts how many
+// were still active 1, 3, 6 and 12 months later, i.e. their period covers
+// that month. Offsets not yet reached by the asOf month are left nil.
+// Zero from or to leaves that side of the cohort range open.
+func (r *AnalyticsRepo) Retention(ctx context.Context, from, to models.MonthDate, asOf time.Time, opts ...Option) ([]models.RetentionCohort, error) {
+	opt := r.options(ctx, opts...)
+
+	var cohorts []models.RetentionCohort
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		cohorts = nil
+
+		inner := sq.Select(
+			"date_trunc('month', start_date)::date AS cohort",
+			"date_trunc('month', end_date)::date AS end_month",
+		).From("subscriptions")
+		if !from.IsZero() {
+			inner = inner.Where(sq.GtOrEq{"start_date": from.Time.Format("2006-01-02")})
+		}
+		if !to.IsZero() {
+			inner = inner.Where(sq.Lt{"start_date": to.Time.AddDate(0, 1, 0).Format("2006-01-02")})
+		}
+
+		asOfMonth := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		builder := r.psql.Select("cohort", "COUNT(*)")
+		for _, m := range retentionMonths {
+			builder = builder.Column(sq.Expr(fmt.Sprintf(
+				"CASE WHEN cohort + INTERVAL '%[1]d month' <= ?::date "+
+					"THEN COUNT(*) FILTER (WHERE end_month IS NULL OR end_month >= cohort + INTERVAL '%[1]d month') END", m),
+				asOfMonth))
+		}
+		builder = builder.FromSelect(inner, "s").GroupBy("cohort").OrderBy("cohort")
+
+		sql, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c models.RetentionCohort
+			var cohort time.Time
+			if err := rows.Scan(&cohort, &c.Size, &c.Month1, &c.Month3, &c.Month6, &c.Month12); err != nil {
+				return wrapDBError(err)
+			}
+			c.Cohort = models.MonthDate{Time: cohort}
+			cohorts = append(cohorts, c)
+		}
+		return wrapDBError(rows.Err())
+	}); err != nil {
+		return nil, err
+	}
+
+	return cohorts, nil
+}
+
+// ActiveByService counts the subscriptions active in month and sums their
+// prices by service and currency.
+func (r *AnalyticsRepo) ActiveByService(ctx context.Context, month models.MonthDate, opts ...Option) ([]models.ServiceActivity, error) {
+	day := month.Time.Format("2006-01-02")
+	query := r.psql.Select("service_name", "currency", "COUNT(*)", "COALESCE(SUM(price), 0)").
+		From("subscriptions").
+		Where(sq.LtOrEq{"start_date": day}).
+		Where(sq.Or{
+			sq.Eq{"end_date": nil},
+			sq.GtOrEq{"end_date": day},
+		}).
+		GroupBy("service_name", "currency").
+		OrderBy("service_name", "currency")
+
+	return selectMany(ctx, &r.base, query, scanServiceActivity, opts...)
+}
+
+func scanServiceActivity(row pgx.Row) (models.ServiceActivity, error) {
+	var a models.ServiceActivity
+	err := row.Scan(&a.ServiceName, &a.Currency, &a.Count, &a.Spend)
+	return a, err
+}
@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsRepo_Retention_SQL(t *testing.T) {
+	mock := newMockPool(t)
+	repo := repository.NewAnalyticsRepo(mock, retry.NoRetry())
+
+	columns := []string{"cohort", "COUNT(*)"}
+	for i, m := range []int{1, 3, 6, 12} {
+		columns = append(columns, fmt.Sprintf("CASE WHEN cohort + INTERVAL '%[1]d month' <= $%[2]d::date "+
+			"THEN COUNT(*) FILTER (WHERE end_month IS NULL OR end_month >= cohort + INTERVAL '%[1]d month') END", m, i+1))
+	}
+	sql := "SELECT " + strings.Join(columns, ", ") + " FROM (SELECT date_trunc('month', start_date)::date AS cohort, " +
+		"date_trunc('month', end_date)::date AS end_month FROM subscriptions WHERE start_date >= $5 AND start_date < $6) AS s " +
+		"GROUP BY cohort ORDER BY cohort"
+
+	asOf := time.Date(2025, time.August, 20, 0, 0, 0, 0, time.UTC)
+	three := 3
+	mock.ExpectQuery(sql).
+		WithArgs("2025-08-01", "2025-08-01", "2025-08-01", "2025-08-01", "2025-01-01", "2025-03-01").
+		WillReturnRows(pgxmock.NewRows([]string{"cohort", "count", "m1", "m3", "m6", "m12"}).
+			AddRow(month(2025, time.January), 5, &three, &three, (*int)(nil), (*int)(nil)))
+
+	got, err := repo.Retention(t.Context(),
+		models.MonthDate{Time: month(2025, time.January)},
+		models.MonthDate{Time: month(2025, time.February)},
+		asOf)
+	require.NoError(t, err)
+	assert.Equal(t, []models.RetentionCohort{{
+		Cohort: models.MonthDate{Time: month(2025, time.January)},
+		Size:   5,
+		Month1: &three,
+		Month3: &three,
+	}}, got)
+}
+
+func TestAnalyticsRepo_ActiveByService_SQL(t *testing.T) {
+	mock := newMockPool(t)
+	repo := repository.NewAnalyticsRepo(mock, retry.NoRetry())
+
+	mock.ExpectQuery("SELECT service_name, currency, COUNT(*), COALESCE(SUM(price), 0) FROM subscriptions "+
+		"WHERE start_date <= $1 AND (end_date IS NULL OR end_date >= $2) "+
+		"GROUP BY service_name, currency ORDER BY service_name, currency").
+		WithArgs("2025-08-01", "2025-08-01").
+		WillReturnRows(pgxmock.NewRows([]string{"service_name", "currency", "count", "sum"}).
+			AddRow("Netflix", models.Currency("RUB"), 3, int64(149700)))
+
+	got, err := repo.ActiveByService(t.Context(), models.MonthDate{Time: month(2025, time.August)})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ServiceActivity{{ServiceName: "Netflix", Currency: "RUB", Count: 3, Spend: 149700}}, got)
+}
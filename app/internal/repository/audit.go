@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditRepo stores audit events.
+type AuditRepo struct {
+	base
+}
+
+// NewAuditRepo initializes AuditRepo.
+// db is usually a *pgxpool.Pool.
+func NewAuditRepo(db Executer, r retry.Retrier) *AuditRepo {
+	return &AuditRepo{base: newBase(db, r)}
+}
+
+var auditColumns = []string{"id", "action", "user_id", "subscription_id", "actor", "details", "created_at"}
+
+// Insert stores an audit entry and fills its ID and CreatedAt.
+func (r *AuditRepo) Insert(ctx context.Context, e *models.AuditEntry, opts ...Option) error {
+	details := e.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	query := r.psql.Insert("audit_events").
+		Columns("action", "user_id", "subscription_id", "actor", "details").
+		Values(e.Action, e.UserID, e.SubscriptionID, e.Actor, details).
+		Suffix("RETURNING id, created_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&e.ID, &e.CreatedAt}, opts...)
+}
+
+// ListByUser returns a page of the entries of a user with one of actions,
+// newest first.
+func (r *AuditRepo) ListByUser(ctx context.Context, userID uuid.UUID, actions []string, limit, offset int, opts ...Option) ([]models.AuditEntry, error) {
+	query := r.psql.Select(auditColumns...).From("audit_events").
+		Where(sq.Eq{"user_id": userID, "action": actions}).
+		OrderBy("id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+
+	return selectMany(ctx, &r.base, query, scanAuditEntry, opts...)
+}
+
+func scanAuditEntry(row pgx.Row) (models.AuditEntry, error) {
+	var e models.AuditEntry
+	err := row.Scan(&e.ID, &e.Action, &e.UserID, &e.SubscriptionID, &e.Actor, &e.Details, &e.CreatedAt)
+	return e, err
+}
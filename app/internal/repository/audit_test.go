@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepo_SQL(t *testing.T) {
+	userID := uuid.New()
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("insert", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewAuditRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO audit_events (action,user_id,subscription_id,actor,details) "+
+			"VALUES ($1,$2,$3,$4,$5) RETURNING id, created_at").
+			WithArgs("subscription.created", &userID, int64(7), (*uuid.UUID)(nil), map[string]any{}).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(3), now))
+
+		e := &models.AuditEntry{Action: "subscription.created", UserID: &userID, SubscriptionID: 7}
+		require.NoError(t, repo.Insert(t.Context(), e))
+		assert.EqualValues(t, 3, e.ID)
+		assert.Equal(t, now, e.CreatedAt)
+	})
+
+	t.Run("list by user", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewAuditRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, action, user_id, subscription_id, actor, details, created_at FROM audit_events "+
+			"WHERE action IN ($1,$2) AND user_id = $3 ORDER BY id DESC LIMIT 20 OFFSET 40").
+			WithArgs("subscription.created", "subscription.deleted", userID.String()).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "action", "user_id", "subscription_id", "actor", "details", "created_at"}).
+				AddRow(int64(9), "subscription.deleted", &userID, int64(7), (*uuid.UUID)(nil), map[string]any{"service_name": "Spotify"}, now))
+
+		got, err := repo.ListByUser(t.Context(), userID, []string{"subscription.created", "subscription.deleted"}, 20, 40)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "Spotify", got[0].Details["service_name"])
+		assert.Equal(t, &userID, got[0].UserID)
+		assert.Nil(t, got[0].Actor)
+	})
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// BackfillRepo stores the progress of backfills and runs the batches of
+// the built-in ones.
+type BackfillRepo struct {
+	base
+}
+
+// NewBackfillRepo initializes BackfillRepo.
+// db is usually a *pgxpool.Pool.
+func NewBackfillRepo(db Executer, r retry.Retrier) *BackfillRepo {
+	return &BackfillRepo{base: newBase(db, r)}
+}
+
+var backfillColumns = []string{"name", "status", "last_id", "processed", "error", "started_at", "updated_at", "finished_at"}
+
+// Ensure creates the progress of a backfill unless it exists.
+func (r *BackfillRepo) Ensure(ctx context.Context, name string, opts ...Option) error {
+	opt := r.options(ctx, opts...)
+
+	return opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		sql, args, err := r.psql.Insert("backfills").Columns("name").Values(name).
+			Suffix("ON CONFLICT (name) DO NOTHING").ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = opt.exec.Exec(ctx, sql, args...)
+		return wrapDBError(err)
+	})
+}
+
+// Get retrieves the progress of a backfill.
+func (r *BackfillRepo) Get(ctx context.Context, name string, opts ...Option) (*models.Backfill, error) {
+	b, err := selectOne(ctx, &r.base, r.psql.Select(backfillColumns...).From("backfills").Where(sq.Eq{"name": name}), scanBackfill, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// List returns the progress of all backfills ordered by name.
+func (r *BackfillRepo) List(ctx context.Context, opts ...Option) ([]models.Backfill, error) {
+	return selectMany(ctx, &r.base, r.psql.Select(backfillColumns...).From("backfills").OrderBy("name ASC"), scanBackfill, opts...)
+}
+
+// Update saves the progress of a backfill and sets its UpdatedAt.
+func (r *BackfillRepo) Update(ctx context.Context, b *models.Backfill, opts ...Option) error {
+	query := r.psql.Update("backfills").
+		Set("status", b.Status).
+		Set("last_id", b.LastID).
+		Set("processed", b.Processed).
+		Set("error", b.Error).
+		Set("started_at", b.StartedAt).
+		Set("finished_at", b.FinishedAt).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"name": b.Name}).
+		Suffix("RETURNING updated_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&b.UpdatedAt}, opts...)
+}
+
+// NormalizeDates is a batch of the normalize_dates backfill: it moves start
+// and end dates of the size subscriptions after afterID to the first day of
+// their month, as MonthDate stores them. Rows written around the service,
+// e.g. by imports, may hold other days, which month arithmetic in summaries
+// miscounts. It returns the ID of the last row of the batch and the number
+// of its rows; fewer than size means there are no more.
+func (r *BackfillRepo) NormalizeDates(ctx context.Context, afterID int64, size int, opts ...Option) (lastID int64, n int, err error) {
+	opt := r.options(ctx, opts...)
+
+	err = opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		sql, args, err := r.psql.Select("COALESCE(max(id), 0)", "count(*)").
+			FromSelect(r.psql.Select("id").From("subscriptions").
+				Where(sq.Gt{"id": afterID}).OrderBy("id ASC").Limit(uint64(size)), "batch").
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if err := opt.exec.QueryRow(ctx, sql, args...).Scan(&lastID, &n); err != nil {
+			return wrapDBError(err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		sql, args, err = r.psql.Update("subscriptions").
+			Set("start_date", sq.Expr("date_trunc('month', start_date)::date")).
+			Set("end_date", sq.Expr("date_trunc('month', end_date)::date")).
+			Where(sq.And{
+				sq.Gt{"id": afterID},
+				sq.LtOrEq{"id": lastID},
+				sq.Expr("(extract(day FROM start_date) <> 1 OR extract(day FROM end_date) <> 1)"),
+			}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = opt.exec.Exec(ctx, sql, args...)
+		return wrapDBError(err)
+	})
+	return lastID, n, err
+}
+
+func scanBackfill(row pgx.Row) (models.Backfill, error) {
+	var b models.Backfill
+	err := row.Scan(&b.Name, &b.Status, &b.LastID, &b.Processed, &b.Error, &b.StartedAt, &b.UpdatedAt, &b.FinishedAt)
+	return b, err
+}
@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillRepo_SQL(t *testing.T) {
+	updated := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("get for update", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewBackfillRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT name, status, last_id, processed, error, started_at, updated_at, finished_at FROM backfills " +
+			"WHERE name = $1 FOR UPDATE").
+			WithArgs("normalize_dates").
+			WillReturnRows(pgxmock.NewRows([]string{"name", "status", "last_id", "processed", "error", "started_at", "updated_at", "finished_at"}).
+				AddRow("normalize_dates", models.BackfillRunning, int64(42), int64(40), "", &updated, updated, (*time.Time)(nil)))
+
+		got, err := repo.Get(t.Context(), "normalize_dates", repository.WithLock(repository.ForUpdate))
+		require.NoError(t, err)
+		assert.Equal(t, models.BackfillRunning, got.Status)
+		assert.EqualValues(t, 42, got.LastID)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewBackfillRepo(mock, retry.NoRetry())
+
+		b := &models.Backfill{Name: "normalize_dates", Status: models.BackfillDone, LastID: 50, Processed: 48, StartedAt: &updated, FinishedAt: &updated}
+		mock.ExpectQuery("UPDATE backfills SET status = $1, last_id = $2, processed = $3, error = $4, started_at = $5, "+
+			"finished_at = $6, updated_at = now() WHERE name = $7 RETURNING updated_at").
+			WithArgs(b.Status, b.LastID, b.Processed, "", b.StartedAt, b.FinishedAt, b.Name).
+			WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(updated))
+
+		require.NoError(t, repo.Update(t.Context(), b))
+		assert.Equal(t, updated, b.UpdatedAt)
+	})
+
+	t.Run("normalize dates", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewBackfillRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT COALESCE(max(id), 0), count(*) FROM (SELECT id FROM subscriptions WHERE id > $1 ORDER BY id ASC LIMIT 100) AS batch").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"max", "count"}).AddRow(int64(120), 100))
+		mock.ExpectExec("UPDATE subscriptions SET start_date = date_trunc('month', start_date)::date, "+
+			"end_date = date_trunc('month', end_date)::date "+
+			"WHERE (id > $1 AND id <= $2 AND (extract(day FROM start_date) <> 1 OR extract(day FROM end_date) <> 1))").
+			WithArgs(int64(7), int64(120)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+		lastID, n, err := repo.NormalizeDates(t.Context(), 7, 100)
+		require.NoError(t, err)
+		assert.EqualValues(t, 120, lastID)
+		assert.Equal(t, 100, n)
+	})
+
+	t.Run("normalize dates past the end", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewBackfillRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT COALESCE(max(id), 0), count(*) FROM (SELECT id FROM subscriptions WHERE id > $1 ORDER BY id ASC LIMIT 100) AS batch").
+			WithArgs(int64(120)).
+			WillReturnRows(pgxmock.NewRows([]string{"max", "count"}).AddRow(int64(0), 0))
+
+		_, n, err := repo.NormalizeDates(t.Context(), 120, 100)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// base is embedded by repositories. Together with the helpers below it does
+// the plumbing of a repository method: applying options, retrying with the
+// profile of the statement, building SQL, scanning rows and mapping errors,
+// so a repository of a new table only writes its queries and scan function:
+//
+//	type TagRepo struct{ base }
+//
+//	func (r *TagRepo) GetByID(ctx context.Context, id int64, opts ...Option) (models.Tag, error) {
+//		return selectOne(ctx, &r.base, r.psql.Select(tagColumns...).From("tags").Where(sq.Eq{"id": id}), scanTag, opts...)
+//	}
+type base struct {
+	db    Executer
+	retry retry.Retrier
+	psql  sq.StatementBuilderType
+}
+
+// newBase returns the base of a repository on db. db is usually a
+// *pgxpool.Pool.
+func newBase(db Executer, r retry.Retrier) base {
+	return base{
+		db:    db,
+		retry: r,
+		psql:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// options returns the options of a call made with ctx: its transaction, if
+// any, overridden by opts.
+func (b *base) options(ctx context.Context, opts ...Option) *RepositoryOptions {
+	return buildOptions(ctx, b.db, opts...)
+}
+
+// scanFunc scans a row into a T. pgx.Rows is a pgx.Row, so the same
+// function scans single rows and result sets. Database errors are returned
+// as is: the helpers map them with wrapDBError.
+type scanFunc[T any] func(row pgx.Row) (T, error)
+
+// selectOne runs query with the read profile, locking the row if WithLock
+// is given, and scans the row. It returns ErrNotFound if there is none.
+func selectOne[T any](ctx context.Context, b *base, query sq.SelectBuilder, scan scanFunc[T], opts ...Option) (T, error) {
+	opt := b.options(ctx, opts...)
+	if opt.lock != "" {
+		query = query.Suffix(string(opt.lock))
+	}
+
+	var v T
+
+	err := opt.retrier(b.retry, ReadProfile).Do(ctx, func() error {
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		v, err = scan(opt.exec.QueryRow(ctx, sql, args...))
+		return wrapDBError(err)
+	})
+	return v, err
+}
+
+// selectMany runs query with the read profile, locking the rows if WithLock
+// is given, and scans all rows in order.
+func selectMany[T any](ctx context.Context, b *base, query sq.SelectBuilder, scan scanFunc[T], opts ...Option) ([]T, error) {
+	opt := b.options(ctx, opts...)
+	if opt.lock != "" {
+		query = query.Suffix(string(opt.lock))
+	}
+
+	var vs []T
+
+	if err := opt.retrier(b.retry, ReadProfile).Do(ctx, func() error {
+		vs = nil
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			v, err := scan(rows)
+			if err != nil {
+				return wrapDBError(err)
+			}
+			vs = append(vs, v)
+		}
+		return wrapDBError(rows.Err())
+	}); err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+// scanReturning runs query, a write with a RETURNING clause, with the write
+// profile and scans the returned row into dest. It returns ErrNotFound if
+// no row was written.
+func scanReturning(ctx context.Context, b *base, query sq.Sqlizer, dest []any, opts ...Option) error {
+	opt := b.options(ctx, opts...)
+
+	return opt.retrier(b.retry, WriteProfile).Do(ctx, func() error {
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		return wrapDBError(opt.exec.QueryRow(ctx, sql, args...).Scan(dest...))
+	})
+}
+
+// execOne runs query, an update or delete, with the write profile. It
+// returns ErrNotFound if no row was affected.
+func execOne(ctx context.Context, b *base, query sq.Sqlizer, opts ...Option) error {
+	opt := b.options(ctx, opts...)
+
+	return opt.retrier(b.retry, WriteProfile).Do(ctx, func() error {
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		cmd, err := opt.exec.Exec(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if cmd.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
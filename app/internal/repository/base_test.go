@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"subscriptionsservice/internal/retry"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanName(row pgx.Row) (string, error) {
+	var name string
+	err := row.Scan(&name)
+	return name, err
+}
+
+func TestBaseHelpers(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		mock.Close()
+	})
+	b := newBase(mock, retry.New(retry.WithMaxAttempts(2), retry.WithBackoff(retry.FixedBackoff{}),
+		retry.WithIsRetryableFunc(func(err error) bool { return !errors.Is(err, ErrNotFound) })))
+	query := b.psql.Select("name").From("tags")
+
+	// A failed attempt's rows are dropped before the retry.
+	mock.ExpectQuery("SELECT name FROM tags FOR UPDATE").
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("a").RowError(0, errors.New("connection reset")))
+	mock.ExpectQuery("SELECT name FROM tags FOR UPDATE").
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("a").AddRow("b"))
+	names, err := selectMany(t.Context(), &b, query, scanName, WithLock(ForUpdate))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	mock.ExpectQuery("SELECT name FROM tags WHERE id = $1").WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"name"}))
+	_, err = selectOne(t.Context(), &b, query.Where("id = ?", 1), scanName)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectExec("DELETE FROM tags WHERE id = $1").WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	assert.ErrorIs(t, execOne(t.Context(), &b, b.psql.Delete("tags").Where("id = ?", 1)), ErrNotFound)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FieldCodec encrypts sensitive column values at rest, e.g. with
+// encryption.Keyring. The key ID it returns is stored next to the value and
+// given back to decrypt it.
+type FieldCodec interface {
+	Encrypt(plaintext, aad []byte) (ciphertext []byte, keyID string, err error)
+	Decrypt(ciphertext, aad []byte, keyID string) ([]byte, error)
+	ActiveKeyID() string
+}
+
+// ErrNoCodec is returned when encrypted values are read or re-encrypted
+// without a FieldCodec.
+var ErrNoCodec = errors.New("field encryption is not configured")
+
+// sealField encrypts v of the column at aad with c. Without a codec v is
+// stored as is, with a NULL key ID.
+func sealField(c FieldCodec, v []byte, aad string) ([]byte, *string, error) {
+	if c == nil {
+		return v, nil, nil
+	}
+	sealed, keyID, err := c.Encrypt(v, []byte(aad))
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt %s: %w", aad, err)
+	}
+	return sealed, &keyID, nil
+}
+
+// openField decrypts v of the column at aad stored with keyID. A NULL key
+// ID marks a value stored before encryption was enabled.
+func openField(c FieldCodec, v []byte, aad string, keyID *string) ([]byte, error) {
+	if keyID == nil {
+		return v, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%s is encrypted with key %s: %w", aad, *keyID, ErrNoCodec)
+	}
+	plain, err := c.Decrypt(v, []byte(aad), *keyID)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", aad, err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// DeadLetterRepo stores async deliveries that exhausted their retries.
+type DeadLetterRepo struct {
+	base
+}
+
+// NewDeadLetterRepo initializes DeadLetterRepo.
+// db is usually a *pgxpool.Pool.
+func NewDeadLetterRepo(db Executer, r retry.Retrier) *DeadLetterRepo {
+	return &DeadLetterRepo{base: newBase(db, r)}
+}
+
+var deadLetterColumns = []string{"id", "kind", "payload", "errors", "attempts", "created_at", "redelivered_at"}
+
+// Create inserts a dead letter and fills its ID and CreatedAt.
+func (r *DeadLetterRepo) Create(ctx context.Context, dl *models.DeadLetter, opts ...Option) error {
+	query := r.psql.Insert("dead_letters").
+		Columns("kind", "payload", "errors", "attempts").
+		Values(dl.Kind, dl.Payload, dl.Errors, dl.Attempts).
+		Suffix("RETURNING id, created_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&dl.ID, &dl.CreatedAt}, opts...)
+}
+
+// GetByID retrieves a dead letter by ID.
+func (r *DeadLetterRepo) GetByID(ctx context.Context, id int64, opts ...Option) (*models.DeadLetter, error) {
+	dl, err := selectOne(ctx, &r.base, r.psql.Select(deadLetterColumns...).From("dead_letters").Where(sq.Eq{"id": id}), scanDeadLetter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// List returns dead letters ordered by ID. If pendingOnly is set,
+// successfully redelivered ones are skipped.
+func (r *DeadLetterRepo) List(ctx context.Context, limit, offset int, pendingOnly bool, opts ...Option) ([]models.DeadLetter, error) {
+	query := r.psql.Select(deadLetterColumns...).From("dead_letters").OrderBy("id ASC")
+	if pendingOnly {
+		query = query.Where(sq.Eq{"redelivered_at": nil})
+	}
+	if limit > 0 {
+		query = query.Limit(uint64(limit)).Offset(uint64(offset))
+	}
+
+	return selectMany(ctx, &r.base, query, scanDeadLetter, opts...)
+}
+
+// MarkRedelivered records a successful redelivery.
+func (r *DeadLetterRepo) MarkRedelivered(ctx context.Context, id int64, at time.Time, opts ...Option) error {
+	query := r.psql.Update("dead_letters").
+		Set("redelivered_at", at.UTC()).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(sq.Eq{"id": id})
+
+	return execOne(ctx, &r.base, query, opts...)
+}
+
+// RecordFailure appends a failed redelivery's error to the dead letter.
+func (r *DeadLetterRepo) RecordFailure(ctx context.Context, id int64, errMsg string, opts ...Option) error {
+	query := r.psql.Update("dead_letters").
+		Set("errors", sq.Expr("array_append(errors, ?)", errMsg)).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(sq.Eq{"id": id})
+
+	return execOne(ctx, &r.base, query, opts...)
+}
+
+func scanDeadLetter(row pgx.Row) (models.DeadLetter, error) {
+	var dl models.DeadLetter
+	err := row.Scan(&dl.ID, &dl.Kind, &dl.Payload, &dl.Errors, &dl.Attempts, &dl.CreatedAt, &dl.RedeliveredAt)
+	return dl, err
+}
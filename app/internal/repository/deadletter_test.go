@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterRepo_SQL(t *testing.T) {
+	created := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("create", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewDeadLetterRepo(mock, retry.NoRetry())
+
+		dl := &models.DeadLetter{
+			Kind:     "notification",
+			Payload:  json.RawMessage(`{"text":"hi"}`),
+			Errors:   []string{"slack: 500"},
+			Attempts: 3,
+		}
+		mock.ExpectQuery("INSERT INTO dead_letters (kind,payload,errors,attempts) VALUES ($1,$2,$3,$4) RETURNING id, created_at").
+			WithArgs(dl.Kind, dl.Payload, dl.Errors, dl.Attempts).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), created))
+
+		require.NoError(t, repo.Create(t.Context(), dl))
+		assert.Equal(t, int64(7), dl.ID)
+		assert.Equal(t, created, dl.CreatedAt)
+	})
+
+	t.Run("list pending", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewDeadLetterRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, kind, payload, errors, attempts, created_at, redelivered_at FROM dead_letters " +
+			"WHERE redelivered_at IS NULL ORDER BY id ASC LIMIT 10 OFFSET 0").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "kind", "payload", "errors", "attempts", "created_at", "redelivered_at"}).
+				AddRow(int64(7), "notification", []byte(`{}`), []string{"slack: 500"}, 3, created, (*time.Time)(nil)))
+
+		got, err := repo.List(t.Context(), 10, 0, true)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "notification", got[0].Kind)
+		assert.Nil(t, got[0].RedeliveredAt)
+	})
+
+	t.Run("record failure", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewDeadLetterRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("UPDATE dead_letters SET errors = array_append(errors, $1), attempts = attempts + 1 WHERE id = $2").
+			WithArgs("timeout", int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.RecordFailure(t.Context(), 7, "timeout")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
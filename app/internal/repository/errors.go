@@ -30,6 +30,19 @@ var (
 
 	// ErrTxAborted is returned when a transaction is aborted.
 	ErrTxAborted = pgx.ErrTxClosed
+
+	// ErrTxFailed is returned for statements in a transaction aborted by an
+	// earlier error (SQLSTATE 25P02), e.g. one ignored by the caller. Only
+	// the whole transaction can be retried. It wraps ErrTxAborted.
+	ErrTxFailed = newProxyErr(ErrTxAborted, "transaction failed")
+
+	// ErrSerialization is returned when a transaction conflicts with a
+	// concurrent one (SQLSTATE 40001). The whole transaction may be retried;
+	// CockroachDB, running transactions as SERIALIZABLE, returns it routinely.
+	ErrSerialization = errors.New("serialization failure")
+
+	// ErrReadOnly is returned by ReadOnlyGuard for writes in read-only mode.
+	ErrReadOnly = errors.New("database is read-only")
 )
 
 // wrapDBError converts low-level database errors into higher-level
@@ -46,9 +59,9 @@ func wrapDBError(err error) error {
 		return ErrTxAborted
 	}
 
-	// Postgres: transaction aborted message
+	// Postgres: transaction aborted message (25P02)
 	if strings.Contains(err.Error(), "current transaction is aborted") {
-		return ErrTxAborted
+		return ErrTxFailed
 	}
 
 	var pgErr *pgconn.PgError
@@ -58,6 +71,10 @@ func wrapDBError(err error) error {
 			return ErrDuplicate
 		case "23503": // foreign_key_violation
 			return ErrForeignKeyViolation
+		case "40001": // serialization_failure
+			return fmt.Errorf("%w: %s", ErrSerialization, pgErr.Message)
+		case "25P02": // in_failed_sql_transaction
+			return ErrTxFailed
 		default:
 			return fmt.Errorf("postgres error [%s]: %w", pgErr.Code, err)
 		}
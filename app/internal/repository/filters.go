@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SavedFilterRepo stores the filters users save for list and summary
+// requests.
+type SavedFilterRepo struct {
+	base
+}
+
+// NewSavedFilterRepo initializes SavedFilterRepo.
+// db is usually a *pgxpool.Pool.
+func NewSavedFilterRepo(db Executer, r retry.Retrier) *SavedFilterRepo {
+	return &SavedFilterRepo{base: newBase(db, r)}
+}
+
+var savedFilterColumns = []string{"id", "user_id", "name", "filter", "created_at", "updated_at"}
+
+// Create inserts a saved filter and fills its ID and timestamps. It returns
+// ErrDuplicate if the user has a filter with the same name.
+func (r *SavedFilterRepo) Create(ctx context.Context, f *models.SavedFilter, opts ...Option) error {
+	query := r.psql.Insert("saved_filters").
+		Columns("user_id", "name", "filter").
+		Values(f.UserID, f.Name, f.Filter).
+		Suffix("RETURNING id, created_at, updated_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&f.ID, &f.CreatedAt, &f.UpdatedAt}, opts...)
+}
+
+// GetByID retrieves a saved filter by ID.
+func (r *SavedFilterRepo) GetByID(ctx context.Context, id int64, opts ...Option) (*models.SavedFilter, error) {
+	f, err := selectOne(ctx, &r.base, r.psql.Select(savedFilterColumns...).From("saved_filters").Where(sq.Eq{"id": id}), scanSavedFilter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListByUser returns the saved filters of a user ordered by name.
+func (r *SavedFilterRepo) ListByUser(ctx context.Context, userID uuid.UUID, opts ...Option) ([]models.SavedFilter, error) {
+	query := r.psql.Select(savedFilterColumns...).From("saved_filters").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("name ASC")
+
+	return selectMany(ctx, &r.base, query, scanSavedFilter, opts...)
+}
+
+// Update saves the name and filters of a saved filter of f.UserID and fills
+// its timestamps. It returns ErrNotFound if the user has no such filter and
+// ErrDuplicate if the new name is taken.
+func (r *SavedFilterRepo) Update(ctx context.Context, f *models.SavedFilter, opts ...Option) error {
+	query := r.psql.Update("saved_filters").
+		Set("name", f.Name).
+		Set("filter", f.Filter).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": f.ID, "user_id": f.UserID}).
+		Suffix("RETURNING created_at, updated_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&f.CreatedAt, &f.UpdatedAt}, opts...)
+}
+
+// Delete removes a saved filter of a user. It returns ErrNotFound if the
+// user has no such filter.
+func (r *SavedFilterRepo) Delete(ctx context.Context, userID uuid.UUID, id int64, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Delete("saved_filters").Where(sq.Eq{"id": id, "user_id": userID}), opts...)
+}
+
+func scanSavedFilter(row pgx.Row) (models.SavedFilter, error) {
+	var f models.SavedFilter
+	err := row.Scan(&f.ID, &f.UserID, &f.Name, &f.Filter, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedFilterRepo_SQL(t *testing.T) {
+	userID := uuid.New()
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	service := "Netflix"
+	criteria := models.FilterCriteria{ServiceName: &service, ExcludeTrials: true}
+
+	t.Run("create", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSavedFilterRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO saved_filters (user_id,name,filter) VALUES ($1,$2,$3) RETURNING id, created_at, updated_at").
+			WithArgs(userID, "netflix", criteria).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(int64(3), now, now))
+
+		f := &models.SavedFilter{UserID: userID, Name: "netflix", Filter: criteria}
+		require.NoError(t, repo.Create(t.Context(), f))
+		assert.EqualValues(t, 3, f.ID)
+		assert.Equal(t, now, f.UpdatedAt)
+	})
+
+	t.Run("create duplicate name", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSavedFilterRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO saved_filters (user_id,name,filter) VALUES ($1,$2,$3) RETURNING id, created_at, updated_at").
+			WithArgs(userID, "netflix", criteria).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.Create(t.Context(), &models.SavedFilter{UserID: userID, Name: "netflix", Filter: criteria})
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+	})
+
+	t.Run("list by user", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSavedFilterRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, user_id, name, filter, created_at, updated_at FROM saved_filters WHERE user_id = $1 ORDER BY name ASC").
+			WithArgs(userID.String()).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "user_id", "name", "filter", "created_at", "updated_at"}).
+				AddRow(int64(3), userID, "netflix", criteria, now, now))
+
+		got, err := repo.ListByUser(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, criteria, got[0].Filter)
+	})
+
+	t.Run("update filter of another user", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSavedFilterRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("UPDATE saved_filters SET name = $1, filter = $2, updated_at = now() WHERE id = $3 AND user_id = $4 RETURNING created_at, updated_at").
+			WithArgs("netflix", criteria, int64(3), userID.String()).
+			WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}))
+
+		err := repo.Update(t.Context(), &models.SavedFilter{ID: 3, UserID: userID, Name: "netflix", Filter: criteria})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSavedFilterRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("DELETE FROM saved_filters WHERE id = $1 AND user_id = $2").
+			WithArgs(int64(3), userID.String()).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+		require.NoError(t, repo.Delete(t.Context(), userID, 3))
+	})
+}
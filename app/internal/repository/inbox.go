@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/retry"
+)
+
+// InboxRepo records consumed messages to deduplicate redeliveries.
+type InboxRepo struct {
+	base
+}
+
+// NewInboxRepo initializes InboxRepo.
+// db is usually a *pgxpool.Pool.
+func NewInboxRepo(db Executer, r retry.Retrier) *InboxRepo {
+	return &InboxRepo{base: newBase(db, r)}
+}
+
+// MarkProcessed records that consumer processed the message and reports
+// whether it is seen for the first time. It should run in the transaction
+// of the message's side effects (WithTx), so both commit or roll back together.
+func (r *InboxRepo) MarkProcessed(ctx context.Context, consumer, messageID string, opts ...Option) (bool, error) {
+	opt := r.options(ctx, opts...)
+
+	var fresh bool
+
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		query := r.psql.Insert("inbox").
+			Columns("consumer", "message_id").
+			Values(consumer, messageID).
+			Suffix("ON CONFLICT DO NOTHING")
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		tag, err := opt.exec.Exec(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		fresh = tag.RowsAffected() == 1
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return fresh, nil
+}
@@ -0,0 +1,29 @@
+package repository_test
+
+import (
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboxRepo_MarkProcessed(t *testing.T) {
+	mock := newMockPool(t)
+	repo := repository.NewInboxRepo(mock, retry.NoRetry())
+
+	const query = "INSERT INTO inbox (consumer,message_id) VALUES ($1,$2) ON CONFLICT DO NOTHING"
+	mock.ExpectExec(query).WithArgs("users", "m1").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(query).WithArgs("users", "m1").WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	fresh, err := repo.MarkProcessed(t.Context(), "users", "m1")
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	fresh, err = repo.MarkProcessed(t.Context(), "users", "m1")
+	require.NoError(t, err)
+	assert.False(t, fresh)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"subscriptionsservice/internal/retry"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Integrity checks run by IntegrityRepo.Find.
+const (
+	// CheckEndBeforeStart finds subscriptions ending before they start,
+	// written before the period was validated.
+	CheckEndBeforeStart = "end_before_start"
+	// CheckNegativePrice finds subscriptions with a negative price.
+	CheckNegativePrice = "negative_price"
+	// CheckOrphanUser finds subscriptions of users missing from users,
+	// e.g. restored without their owners.
+	CheckOrphanUser = "orphan_user"
+	// CheckOverlappingDuplicate finds subscriptions of a user to a service
+	// whose periods overlap with another one to the same service.
+	CheckOverlappingDuplicate = "overlapping_duplicate"
+)
+
+// integrityConditions select the subscriptions each check finds.
+var integrityConditions = map[string]string{
+	CheckEndBeforeStart: "s.end_date < s.start_date",
+	CheckNegativePrice:  "s.price < 0",
+	CheckOrphanUser:     "NOT EXISTS (SELECT 1 FROM users u WHERE u.id = s.user_id)",
+	CheckOverlappingDuplicate: "EXISTS (SELECT 1 FROM subscriptions o " +
+		"WHERE o.user_id = s.user_id AND o.service_name = s.service_name AND o.id <> s.id " +
+		"AND (s.end_date IS NULL OR o.start_date <= s.end_date) " +
+		"AND (o.end_date IS NULL OR s.start_date <= o.end_date))",
+}
+
+// IntegrityRepo runs read-only integrity checks of the subscriptions table.
+type IntegrityRepo struct {
+	base
+}
+
+// NewIntegrityRepo initializes IntegrityRepo.
+// db is usually a *pgxpool.Pool.
+func NewIntegrityRepo(db Executer, r retry.Retrier) *IntegrityRepo {
+	return &IntegrityRepo{base: newBase(db, r)}
+}
+
+// integrityRow is an ID of a found subscription with the total count.
+type integrityRow struct {
+	id    int64
+	count int
+}
+
+// Find runs check and returns the number of subscriptions it finds with up
+// to limit of their IDs, lowest first.
+func (r *IntegrityRepo) Find(ctx context.Context, check string, limit int, opts ...Option) (count int, ids []int64, err error) {
+	cond, ok := integrityConditions[check]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown integrity check %q", check)
+	}
+	// The window count is computed before LIMIT, so it counts all rows.
+	query := r.psql.Select("s.id", "COUNT(*) OVER ()").From("subscriptions s").
+		Where(cond).
+		OrderBy("s.id ASC").
+		Limit(uint64(max(limit, 1)))
+
+	rows, err := selectMany(ctx, &r.base, query, scanIntegrityRow, opts...)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i, row := range rows {
+		count = row.count
+		if i < limit {
+			ids = append(ids, row.id)
+		}
+	}
+	return count, ids, nil
+}
+
+func scanIntegrityRow(row pgx.Row) (integrityRow, error) {
+	var r integrityRow
+	err := row.Scan(&r.id, &r.count)
+	return r, err
+}
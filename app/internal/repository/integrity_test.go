@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrityRepo_Find(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewIntegrityRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT s.id, COUNT(*) OVER () FROM subscriptions s WHERE s.price < 0 ORDER BY s.id ASC LIMIT 2").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "count"}).AddRow(int64(3), 5).AddRow(int64(8), 5))
+
+		count, ids, err := repo.Find(t.Context(), repository.CheckNegativePrice, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 5, count)
+		assert.Equal(t, []int64{3, 8}, ids)
+	})
+
+	t.Run("count without samples", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewIntegrityRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT s.id, COUNT(*) OVER () FROM subscriptions s WHERE s.end_date < s.start_date ORDER BY s.id ASC LIMIT 1").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "count"}).AddRow(int64(3), 4))
+
+		count, ids, err := repo.Find(t.Context(), repository.CheckEndBeforeStart, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("unknown check", func(t *testing.T) {
+		repo := repository.NewIntegrityRepo(newMockPool(t), retry.NoRetry())
+
+		_, _, err := repo.Find(t.Context(), "duplicate_ids", 10)
+		assert.ErrorContains(t, err, "unknown integrity check")
+	})
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/retry"
+)
+
+// MaintenanceRepo runs maintenance statements on the subscriptions table.
+type MaintenanceRepo struct {
+	base
+}
+
+// NewMaintenanceRepo initializes MaintenanceRepo.
+// db is usually a *pgxpool.Pool.
+func NewMaintenanceRepo(db Executer, r retry.Retrier) *MaintenanceRepo {
+	return &MaintenanceRepo{base: newBase(db, r)}
+}
+
+// Analyze refreshes planner statistics of the subscriptions table.
+func (r *MaintenanceRepo) Analyze(ctx context.Context, opts ...Option) error {
+	return r.exec(ctx, "ANALYZE subscriptions", opts...)
+}
+
+// Reindex rebuilds the subscriptions indexes without blocking writes.
+// REINDEX CONCURRENTLY cannot run inside a transaction, so WithTx must not
+// be passed.
+func (r *MaintenanceRepo) Reindex(ctx context.Context, opts ...Option) error {
+	return r.exec(ctx, "REINDEX TABLE CONCURRENTLY subscriptions", opts...)
+}
+
+func (r *MaintenanceRepo) exec(ctx context.Context, sql string, opts ...Option) error {
+	opt := r.options(ctx, opts...)
+
+	return opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		_, err := opt.exec.Exec(ctx, sql)
+		return wrapDBError(err)
+	})
+}
@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceRepo(t *testing.T) {
+	mock := newMockPool(t)
+	repo := repository.NewMaintenanceRepo(mock, retry.NoRetry())
+
+	mock.ExpectExec("ANALYZE subscriptions").WillReturnResult(pgxmock.NewResult("ANALYZE", 0))
+	mock.ExpectExec("REINDEX TABLE CONCURRENTLY subscriptions").WillReturnResult(pgxmock.NewResult("REINDEX", 0))
+
+	require.NoError(t, repo.Analyze(t.Context()))
+	require.NoError(t, repo.Reindex(t.Context()))
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// QueryLogger is an Executer that logs every statement at debug level with
+// its duration, error and redacted args. Statements run in a transaction
+// passed with WithTx are not logged.
+type QueryLogger struct {
+	next Executer
+	log  *zap.Logger
+}
+
+// NewQueryLogger wraps next, usually a *pgxpool.Pool.
+func NewQueryLogger(next Executer, log *zap.Logger) *QueryLogger {
+	return &QueryLogger{next: next, log: log}
+}
+
+// Query implements Executer.
+func (q *QueryLogger) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := q.next.Query(ctx, sql, args...)
+	q.logQuery(sql, args, start, err)
+	return rows, err
+}
+
+// QueryRow implements Executer. The error of the row is not known until
+// Scan, so it is not logged.
+func (q *QueryLogger) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	start := time.Now()
+	row := q.next.QueryRow(ctx, sql, args...)
+	q.logQuery(sql, args, start, nil)
+	return row
+}
+
+// Exec implements Executer.
+func (q *QueryLogger) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := q.next.Exec(ctx, sql, args...)
+	q.logQuery(sql, args, start, err)
+	return tag, err
+}
+
+// CopyFrom implements Executer. Rows are not logged.
+func (q *QueryLogger) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	start := time.Now()
+	n, err := q.next.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	if ce := q.log.Check(zap.DebugLevel, "copy"); ce != nil {
+		ce.Write(
+			zap.String("table", tableName.Sanitize()),
+			zap.Strings("columns", columnNames),
+			zap.Int64("rows", n),
+			zap.Duration("duration", time.Since(start)),
+			zap.Error(err),
+		)
+	}
+	return n, err
+}
+
+func (q *QueryLogger) logQuery(sql string, args []any, start time.Time, err error) {
+	ce := q.log.Check(zap.DebugLevel, "query")
+	if ce == nil {
+		return
+	}
+	ce.Write(
+		zap.String("sql", strings.Join(strings.Fields(sql), " ")),
+		zap.Strings("args", RedactArgs(args)),
+		zap.Duration("duration", time.Since(start)),
+		zap.Error(err),
+	)
+}
+
+// RedactArgs formats query args for logs. Values that may identify a user or
+// carry free text (strings, byte slices, UUIDs and other types) are replaced
+// with their type; numbers, booleans, times, dates and NULLs, which are what
+// predicates usually go wrong on, are kept.
+func RedactArgs(args []any) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			out[i] = "NULL"
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			out[i] = fmt.Sprint(v)
+		case string:
+			if _, err := time.Parse(time.DateOnly, v); err == nil {
+				out[i] = v
+			} else {
+				out[i] = "<string>"
+			}
+		case time.Time:
+			out[i] = v.Format(time.RFC3339)
+		case *time.Time:
+			if v == nil {
+				out[i] = "NULL"
+			} else {
+				out[i] = v.Format(time.RFC3339)
+			}
+		default:
+			out[i] = fmt.Sprintf("<%T>", v)
+		}
+	}
+	return out
+}
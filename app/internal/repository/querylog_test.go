@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactArgs(t *testing.T) {
+	at := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	got := repository.RedactArgs([]any{
+		int64(7), true, nil, at, "2025-07-01", "Netflix", uuid.New(), []byte("x"),
+	})
+	assert.Equal(t, []string{
+		"7", "true", "NULL", "2025-07-01T00:00:00Z", "2025-07-01", "<string>", "<uuid.UUID>", "<[]uint8>",
+	}, got)
+}
+
+func TestQueryLogger(t *testing.T) {
+	mock := newMockPool(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	repo := repository.NewSubscriptionsRepo(repository.NewQueryLogger(mock, zap.New(core)), retry.NoRetry())
+
+	mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").
+		WithArgs(int64(7)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	require.NoError(t, repo.Delete(t.Context(), 7))
+
+	entries := logs.FilterMessage("query").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "DELETE FROM subscriptions WHERE id = $1", fields["sql"])
+	assert.Equal(t, []interface{}{"7"}, fields["args"])
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// WriteQuotaRepo counts writes per user in fixed time windows.
+type WriteQuotaRepo struct {
+	base
+}
+
+// NewWriteQuotaRepo initializes WriteQuotaRepo.
+// db is usually a *pgxpool.Pool.
+func NewWriteQuotaRepo(db Executer, r retry.Retrier) *WriteQuotaRepo {
+	return &WriteQuotaRepo{base: newBase(db, r)}
+}
+
+// IncrementWrites increments the user's write counter for the window starting
+// at windowStart and returns the new value. A retried call may count a write
+// twice, which errs on the side of rejecting.
+func (r *WriteQuotaRepo) IncrementWrites(ctx context.Context, userID uuid.UUID, windowStart time.Time, opts ...Option) (int, error) {
+	opt := r.options(ctx, opts...)
+
+	var count int
+
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		query := r.psql.Insert("write_quotas").
+			Columns("user_id", "window_start", "writes").
+			Values(userID, windowStart.UTC(), 1).
+			Suffix("ON CONFLICT (user_id, window_start) DO UPDATE SET writes = write_quotas.writes + 1 RETURNING writes")
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		return wrapDBError(opt.exec.QueryRow(ctx, sql, args...).Scan(&count))
+	}); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// DeleteWindowsBefore removes the user's counters of windows that started before t.
+func (r *WriteQuotaRepo) DeleteWindowsBefore(ctx context.Context, userID uuid.UUID, t time.Time, opts ...Option) (int64, error) {
+	opt := r.options(ctx, opts...)
+
+	var deleted int64
+
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		query := r.psql.Delete("write_quotas").Where(sq.Eq{"user_id": userID}).
+			Where(sq.Lt{"window_start": t.UTC()})
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		tag, err := opt.exec.Exec(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		deleted = tag.RowsAffected()
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
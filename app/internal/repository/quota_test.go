@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteQuotaRepo_SQL(t *testing.T) {
+	userID := uuid.New()
+	window := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("increment", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWriteQuotaRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO write_quotas (user_id,window_start,writes) VALUES ($1,$2,$3) "+
+			"ON CONFLICT (user_id, window_start) DO UPDATE SET writes = write_quotas.writes + 1 RETURNING writes").
+			WithArgs(userID, window, 1).
+			WillReturnRows(pgxmock.NewRows([]string{"writes"}).AddRow(5))
+
+		got, err := repo.IncrementWrites(t.Context(), userID, window)
+		require.NoError(t, err)
+		assert.Equal(t, 5, got)
+	})
+
+	t.Run("delete old windows", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWriteQuotaRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("DELETE FROM write_quotas WHERE user_id = $1 AND window_start < $2").
+			WithArgs(userID.String(), window).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+		got, err := repo.DeleteWindowsBefore(t.Context(), userID, window)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), got)
+	})
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// writeKeywords start statements that modify data.
+var writeKeywords = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "TRUNCATE", "COPY"}
+
+// ReadOnlyGuard is an Executer and Beginner rejecting writes with ErrReadOnly
+// while readOnly reports true, so no write slips past the HTTP layer, e.g.
+// from background tasks. Statements are told apart by their first keyword;
+// maintenance statements such as ANALYZE are let through. Transactions
+// cannot be begun in read-only mode at all, since they are begun for writes.
+type ReadOnlyGuard struct {
+	next     Executer
+	readOnly func() bool
+}
+
+// NewReadOnlyGuard wraps next, usually a *pgxpool.Pool.
+func NewReadOnlyGuard(next Executer, readOnly func() bool) *ReadOnlyGuard {
+	return &ReadOnlyGuard{next: next, readOnly: readOnly}
+}
+
+func (g *ReadOnlyGuard) check(sql string) error {
+	if !g.readOnly() {
+		return nil
+	}
+	stmt := strings.ToUpper(strings.TrimSpace(sql))
+	for _, kw := range writeKeywords {
+		if strings.HasPrefix(stmt, kw) {
+			return ErrReadOnly
+		}
+	}
+	return nil
+}
+
+// Query implements Executer.
+func (g *ReadOnlyGuard) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if err := g.check(sql); err != nil {
+		return nil, err
+	}
+	return g.next.Query(ctx, sql, args...)
+}
+
+// QueryRow implements Executer.
+func (g *ReadOnlyGuard) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if err := g.check(sql); err != nil {
+		return errRow{err}
+	}
+	return g.next.QueryRow(ctx, sql, args...)
+}
+
+// Exec implements Executer.
+func (g *ReadOnlyGuard) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := g.check(sql); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return g.next.Exec(ctx, sql, args...)
+}
+
+// CopyFrom implements Executer.
+func (g *ReadOnlyGuard) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	if g.readOnly() {
+		return 0, ErrReadOnly
+	}
+	return g.next.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// Begin implements Beginner if next does.
+func (g *ReadOnlyGuard) Begin(ctx context.Context) (pgx.Tx, error) {
+	if g.readOnly() {
+		return nil, ErrReadOnly
+	}
+	b, ok := g.next.(Beginner)
+	if !ok {
+		return nil, errors.New("executer does not begin transactions")
+	}
+	return b.Begin(ctx)
+}
+
+// errRow is a pgx.Row failing with err.
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyGuard(t *testing.T) {
+	mock := newMockPool(t)
+	readOnly := true
+	guard := repository.NewReadOnlyGuard(mock, func() bool { return readOnly })
+	repo := repository.NewSubscriptionsRepo(guard, retry.NoRetry())
+
+	mock.ExpectQuery("SELECT EXISTS( SELECT 1 FROM subscriptions WHERE id = $1 )").
+		WithArgs(int64(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	exists, err := repo.Exists(t.Context(), 1)
+	require.NoError(t, err, "reads are allowed")
+	assert.True(t, exists)
+
+	assert.ErrorIs(t, repo.Delete(t.Context(), 1), repository.ErrReadOnly)
+	assert.ErrorIs(t, repo.CreateSubscription(t.Context(), &models.Subscription{}), repository.ErrReadOnly)
+	_, err = repo.CopyFromSubscriptions(t.Context(), []models.Subscription{{}})
+	assert.ErrorIs(t, err, repository.ErrReadOnly)
+	_, err = repo.DeleteReturning(t.Context(), 1)
+	assert.ErrorIs(t, err, repository.ErrReadOnly)
+	_, err = guard.Exec(t.Context(), "ANALYZE subscriptions")
+	require.Error(t, err, "maintenance statements are let through")
+	assert.NotErrorIs(t, err, repository.ErrReadOnly)
+	_, err = guard.Begin(t.Context())
+	assert.ErrorIs(t, err, repository.ErrReadOnly)
+
+	readOnly = false
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	err = repository.NewTxManager(guard).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+		return repo.Delete(ctx, 1, repository.WithTx(tx))
+	})
+	assert.NoError(t, err)
+}
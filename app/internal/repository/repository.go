@@ -2,15 +2,19 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"subscriptionsservice/internal/hedge"
 	"subscriptionsservice/internal/models"
 	"subscriptionsservice/internal/retry"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Executer allows query execution by both Pool and Tx.
@@ -18,11 +22,38 @@ type Executer interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 }
 
-// RepositoryOptions contains options for repository. (Ececuter)
+// LockMode is a row-level lock clause appended to SELECT queries.
+type LockMode string
+
+const (
+	// ForUpdate locks selected rows against concurrent updates and deletes.
+	ForUpdate LockMode = "FOR UPDATE"
+
+	// ForShare locks selected rows against concurrent updates, allowing other shared locks.
+	ForShare LockMode = "FOR SHARE"
+)
+
+// RetryProfile names a retry policy for a class of operations, see retry.Profiles.
+type RetryProfile string
+
+const (
+	// ReadProfile is the default retry profile of queries that do not modify data.
+	ReadProfile RetryProfile = "read"
+
+	// WriteProfile is the default retry profile of inserts, updates and deletes.
+	WriteProfile RetryProfile = "write"
+)
+
+// RepositoryOptions contains options for repository. (Ececuter, transaction, row lock, COPY chunk size, retry profile)
 type RepositoryOptions struct {
-	exec Executer
+	exec      Executer
+	tx        pgx.Tx
+	lock      LockMode
+	chunkSize int
+	profile   RetryProfile
 }
 
 // Option is a function that configures RepositoryOptions.
@@ -32,37 +63,130 @@ type Option func(*RepositoryOptions)
 func WithTx(tx pgx.Tx) Option {
 	return func(o *RepositoryOptions) {
 		o.exec = tx
+		o.tx = tx
+	}
+}
+
+// WithLock makes SELECT queries lock the returned rows with the given mode.
+// Locks are held until the end of the transaction, so it should be combined with WithTx.
+func WithLock(mode LockMode) Option {
+	return func(o *RepositoryOptions) {
+		o.lock = mode
+	}
+}
+
+// WithChunkSize sets the number of rows sent per COPY statement.
+func WithChunkSize(size int) Option {
+	return func(o *RepositoryOptions) {
+		o.chunkSize = size
+	}
+}
+
+// WithRetryProfile retries the operation with the given profile instead of
+// the default one of its class (ReadProfile or WriteProfile). It has no
+// effect unless the repository was created with a *retry.Profiles.
+func WithRetryProfile(p RetryProfile) Option {
+	return func(o *RepositoryOptions) {
+		o.profile = p
 	}
 }
 
 // defaultOptions returns default options (pool).
-func defaultOptions(repo *SubscriptionsRepo) RepositoryOptions {
+func defaultOptions(db Executer) RepositoryOptions {
 	return RepositoryOptions{
-		exec: repo.db,
+		exec:      db,
+		chunkSize: defaultChunkSize,
 	}
 }
 
+// defaultChunkSize is the default number of rows per COPY statement.
+const defaultChunkSize = 5000
+
 // SubscriptionsRepo provides CRUD and summary operations.
 type SubscriptionsRepo struct {
-	db    *pgxpool.Pool
-	retry retry.Retrier
-	psql  sq.StatementBuilderType
+	base
+
+	// hedgeDelay is the delay before GetByID issues a second query, 0 — never.
+	hedgeDelay time.Duration
+	// boundaries are the month boundary semantics of Summary.
+	boundaries SummaryBoundaries
+	// provisionedUsers is set when writes must not register unknown users.
+	provisionedUsers bool
+	// region is stamped as origin_region on written rows.
+	region string
+}
+
+// SummaryBoundaries selects how Summary matches subscription dates against
+// the requested months.
+type SummaryBoundaries string
+
+const (
+	// BoundariesLegacy compares dates with the first days of the From and To
+	// months and counts 30-day periods, so a subscription starting after the
+	// 1st of the To month is left out and day-precise dates are counted
+	// inconsistently. It is the default, for compatibility.
+	BoundariesLegacy SummaryBoundaries = "legacy"
+
+	// BoundariesCalendarMonth normalizes all dates to their months: the range
+	// covers From's first day to To's last day, and a subscription is counted
+	// for every calendar month between its start and end months inclusive.
+	BoundariesCalendarMonth SummaryBoundaries = "calendar_month"
+)
+
+// SubscriptionsRepoOption configures SubscriptionsRepo.
+type SubscriptionsRepoOption func(*SubscriptionsRepo)
+
+// WithHedgedReads makes GetByID issue a second query if the first one has
+// not returned after delay, and use the first result (see package hedge).
+// db must allow concurrent queries, like *pgxpool.Pool does.
+func WithHedgedReads(delay time.Duration) SubscriptionsRepoOption {
+	return func(r *SubscriptionsRepo) {
+		r.hedgeDelay = delay
+	}
+}
+
+// WithSummaryBoundaries sets the month boundary semantics of Summary.
+func WithSummaryBoundaries(b SummaryBoundaries) SubscriptionsRepoOption {
+	return func(r *SubscriptionsRepo) {
+		r.boundaries = b
+	}
+}
+
+// WithProvisionedUsers makes writes fail with ErrForeignKeyViolation for
+// users not in the users table, instead of registering them.
+func WithProvisionedUsers() SubscriptionsRepoOption {
+	return func(r *SubscriptionsRepo) {
+		r.provisionedUsers = true
+	}
+}
+
+// WithRegion stamps writes with the region of this instance, so rows
+// replicated from another region can be told apart, e.g. when debugging
+// replication lag or conflicts.
+func WithRegion(region string) SubscriptionsRepoOption {
+	return func(r *SubscriptionsRepo) {
+		r.region = region
+	}
 }
 
 // NewSubscriptionsRepo initializes SubscriptionsRepo with Squirrel.
-func NewSubscriptionsRepo(db *pgxpool.Pool, r retry.Retrier) *SubscriptionsRepo {
-	return &SubscriptionsRepo{
-		db:    db,
-		retry: r,
-		psql:  sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+// db is usually a *pgxpool.Pool.
+func NewSubscriptionsRepo(db Executer, r retry.Retrier, opts ...SubscriptionsRepoOption) *SubscriptionsRepo {
+	repo := &SubscriptionsRepo{base: newBase(db, r)}
+	for _, opt := range opts {
+		opt(repo)
 	}
+	return repo
 }
 
-// CreateSubscription inserts a new record.
+// CreateSubscription inserts a new record. A subscription with the same
+// user, service and start month is not inserted and ErrDuplicate is returned
+// without failing the transaction the insert runs in, so it can still look
+// the existing one up.
 func (r *SubscriptionsRepo) CreateSubscription(ctx context.Context, subs *models.Subscription, opts ...Option) error {
-	opt := r.applyOptions(opts...)
+	opt := r.options(ctx, opts...)
 
-	return r.retry.Do(ctx, func() error {
+	return opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
 		var endDate interface{}
 		if subs.EndDate != nil {
 			// передаём только дату без времени
@@ -74,81 +198,270 @@ func (r *SubscriptionsRepo) CreateSubscription(ctx context.Context, subs *models
 		query := r.psql.Insert("subscriptions").
 			Columns(
 				"service_name", "price", "user_id",
-				"start_date", "end_date",
+				"start_date", "end_date", "trial", "currency", "origin_region",
 			).Values(
-			subs.ServiceName, subs.Price, subs.UserID,
+			subs.ServiceName, int(subs.Price), subs.UserID,
 			subs.StartDate.Time.Format("2006-01-02"),
-			endDate,
-		).Suffix("RETURNING id")
+			endDate, subs.Trial, string(subs.Currency.OrDefault()), r.region,
+		).Suffix("ON CONFLICT (user_id, service_name, start_date) DO NOTHING RETURNING id")
 
 		sql, args, err := query.ToSql()
 		if err != nil {
 			return err
 		}
 
-		return wrapDBError(opt.exec.QueryRow(ctx, sql, args...).Scan(&subs.ID))
+		if err := r.registerUsers(ctx, opt.exec, subs.UserID); err != nil {
+			return err
+		}
+		if err := opt.exec.QueryRow(ctx, sql, args...).Scan(&subs.ID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrDuplicate
+			}
+			return wrapDBError(err)
+		}
+		subs.OriginRegion = r.region
+		return nil
 	})
 }
 
-// GetByID retrieves a subscription by ID.
+// CopyFromSubscriptions bulk-inserts subscriptions using COPY, splitting them
+// into chunks (see WithChunkSize). Each chunk is retried as a whole. Generated
+// IDs are not populated. Returns the number of inserted rows; without WithTx,
+// chunks copied before a failure stay committed and are counted.
+func (r *SubscriptionsRepo) CopyFromSubscriptions(ctx context.Context, subs []models.Subscription, opts ...Option) (int64, error) {
+	opt := r.options(ctx, opts...)
+
+	chunkSize := opt.chunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(subs)
+	}
+
+	var total int64
+	for start := 0; start < len(subs); start += chunkSize {
+		end := min(start+chunkSize, len(subs))
+		chunk := subs[start:end]
+
+		var copied int64
+		err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+			userIDs := make([]uuid.UUID, len(chunk))
+			for i := range chunk {
+				userIDs[i] = chunk[i].UserID
+			}
+			if err := r.registerUsers(ctx, opt.exec, userIDs...); err != nil {
+				return err
+			}
+
+			n, err := opt.exec.CopyFrom(ctx,
+				pgx.Identifier{"subscriptions"},
+				[]string{"service_name", "price", "user_id", "start_date", "end_date", "trial", "currency", "origin_region"},
+				pgx.CopyFromSlice(len(chunk), func(i int) ([]any, error) {
+					s := chunk[i]
+					var endDate *time.Time
+					if s.EndDate != nil {
+						endDate = &s.EndDate.Time
+					}
+					return []any{s.ServiceName, int(s.Price), s.UserID, s.StartDate.Time, endDate, s.Trial, string(s.Currency.OrDefault()), r.region}, nil
+				}),
+			)
+			if err != nil {
+				return wrapDBError(err)
+			}
+			copied = n
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("copy rows %d-%d: %w", start, end-1, err)
+		}
+		total += copied
+	}
+
+	return total, nil
+}
+
+// registerUsers inserts the users of subscriptions about to be written that
+// are not registered yet, unless WithProvisionedUsers is set.
+func (r *SubscriptionsRepo) registerUsers(ctx context.Context, exec Executer, ids ...uuid.UUID) error {
+	if r.provisionedUsers || len(ids) == 0 {
+		return nil
+	}
+
+	query := r.psql.Insert("users").Columns("id").Suffix("ON CONFLICT (id) DO NOTHING")
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			query = query.Values(id)
+		}
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.Exec(ctx, sql, args...)
+	return wrapDBError(err)
+}
+
+// GetByID retrieves a subscription by ID. Outside a transaction, reads are
+// hedged if the repository was created with WithHedgedReads.
 func (r *SubscriptionsRepo) GetByID(ctx context.Context, id int64, opts ...Option) (*models.Subscription, error) {
-	opt := r.applyOptions(opts...)
+	opt := r.options(ctx, opts...)
 
 	var sub models.Subscription
-	var retryErr error
 
-	if err := r.retry.Do(ctx, func() error {
-		query := r.psql.Select(
-			"id", "service_name", "price",
-			"user_id", "start_date", "end_date",
-		).From("subscriptions").
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		query := r.psql.Select(subscriptionColumns...).From("subscriptions").
 			Where(sq.Eq{"id": id})
 
+		if opt.lock != "" {
+			query = query.Suffix(string(opt.lock))
+		}
+
 		sql, args, err := query.ToSql()
 		if err != nil {
 			return err
 		}
 
-		var startDate time.Time
-		var endDate *time.Time
-		err = opt.exec.QueryRow(ctx, sql, args...).Scan(
-			&sub.ID, &sub.ServiceName, &sub.Price,
-			&sub.UserID, &startDate, &endDate,
-		)
-		if err != nil {
-			return wrapDBError(err)
+		get := func(ctx context.Context) (models.Subscription, error) {
+			return queryOne(ctx, opt.exec, sql, args...)
 		}
 
-		sub.StartDate = models.MonthDate{Time: startDate}
-		if endDate != nil {
-			e := models.MonthDate{Time: *endDate}
-			sub.EndDate = &e
+		var delay time.Duration
+		if opt.tx == nil {
+			delay = r.hedgeDelay
 		}
-		retryErr = nil
-		return nil
+		sub, err = hedge.Do(ctx, delay, get)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// GetByKey retrieves a subscription by its unique key:
+// user, service name and start month.
+func (r *SubscriptionsRepo) GetByKey(ctx context.Context, userID uuid.UUID, serviceName string, startDate models.MonthDate, opts ...Option) (*models.Subscription, error) {
+	opt := r.options(ctx, opts...)
+
+	var sub models.Subscription
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		query := r.psql.Select(subscriptionColumns...).From("subscriptions").
+			Where(sq.Eq{
+				"user_id":      userID,
+				"service_name": serviceName,
+				"start_date":   startDate.Time.Format("2006-01-02"),
+			})
+
+		if opt.lock != "" {
+			query = query.Suffix(string(opt.lock))
+		}
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		sub, err = queryOne(ctx, opt.exec, sql, args...)
+		return err
 	}); err != nil {
 		return nil, err
 	}
 
-	return &sub, retryErr
+	return &sub, nil
+}
+
+// Exists reports whether a subscription with the given ID exists.
+func (r *SubscriptionsRepo) Exists(ctx context.Context, id int64, opts ...Option) (bool, error) {
+	opt := r.options(ctx, opts...)
+
+	var exists bool
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		query := r.psql.Select("1").From("subscriptions").Where(sq.Eq{"id": id}).Prefix("SELECT EXISTS(").Suffix(")")
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		return wrapDBError(opt.exec.QueryRow(ctx, sql, args...).Scan(&exists))
+	}); err != nil {
+		return false, err
+	}
+
+	return exists, nil
 }
 
-// List returns subscriptions ordered by id with optional pagination.
-// If limit == 0 -> no LIMIT applied.
-func (r *SubscriptionsRepo) List(ctx context.Context, limit, offset int, opts ...Option) ([]models.Subscription, error) {
-	opt := r.applyOptions(opts...)
+// CountActive returns the number of the user's subscriptions that are active
+// in the given month or later: without end date or ending not before month.
+func (r *SubscriptionsRepo) CountActive(ctx context.Context, userID uuid.UUID, month models.MonthDate, opts ...Option) (int, error) {
+	opt := r.options(ctx, opts...)
+
+	var count int
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		query := r.psql.Select("COUNT(*)").From("subscriptions").
+			Where(sq.Eq{"user_id": userID}).
+			Where(sq.Or{
+				sq.Eq{"end_date": nil},
+				sq.GtOrEq{"end_date": month.Time.Format("2006-01-02")},
+			})
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		return wrapDBError(opt.exec.QueryRow(ctx, sql, args...).Scan(&count))
+	}); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MostActiveUsers returns up to limit users with the most subscriptions
+// active in month, most first.
+func (r *SubscriptionsRepo) MostActiveUsers(ctx context.Context, month models.MonthDate, limit int, opts ...Option) ([]uuid.UUID, error) {
+	day := month.Time.Format("2006-01-02")
+	query := r.psql.Select("user_id").From("subscriptions").
+		Where(sq.LtOrEq{"start_date": day}).
+		Where(sq.Or{
+			sq.Eq{"end_date": nil},
+			sq.GtOrEq{"end_date": day},
+		}).
+		GroupBy("user_id").
+		OrderBy("COUNT(*) DESC", "user_id ASC").
+		Limit(uint64(limit))
+
+	return selectMany(ctx, &r.base, query, scanUserID, opts...)
+}
+
+func scanUserID(row pgx.Row) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+// List returns subscriptions matching filter ordered by id with optional
+// pagination. If limit == 0 -> no LIMIT applied.
+func (r *SubscriptionsRepo) List(ctx context.Context, filter models.SubscriptionFilter, limit, offset int, opts ...Option) ([]models.Subscription, error) {
+	opt := r.options(ctx, opts...)
 
 	var subs []models.Subscription
 
-	if err := r.retry.Do(ctx, func() error {
-		builder := r.psql.Select(
-			"id", "service_name", "price",
-			"user_id", "start_date", "end_date",
-		).From("subscriptions").OrderBy("id ASC")
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		builder := applyFilter(r.psql.Select(subscriptionColumns...).From("subscriptions"), filter).OrderBy("id ASC")
 
 		if limit > 0 {
 			builder = builder.Limit(uint64(limit)).Offset(uint64(offset))
 		}
+		if opt.lock != "" {
+			builder = builder.Suffix(string(opt.lock))
+		}
 
 		sqlStr, args, err := builder.ToSql()
 		if err != nil {
@@ -159,26 +472,49 @@ func (r *SubscriptionsRepo) List(ctx context.Context, limit, offset int, opts ..
 		if err != nil {
 			return wrapDBError(err)
 		}
-		defer rows.Close()
+		subs, err = pgx.AppendRows(make([]models.Subscription, 0, max(limit, 0)), rows, scanSubscription)
+		return wrapDBError(err)
+	}); err != nil {
+		return nil, err
+	}
 
-		for rows.Next() {
-			var s models.Subscription
-			var startDate time.Time
-			var endDate *time.Time
-			if err := rows.Scan(
-				&s.ID, &s.ServiceName, &s.Price,
-				&s.UserID, &startDate, &endDate,
-			); err != nil {
-				return wrapDBError(err)
-			}
-			s.StartDate = models.MonthDate{Time: startDate}
-			if endDate != nil {
-				e := models.MonthDate{Time: *endDate}
-				s.EndDate = &e
-			}
-			subs = append(subs, s)
+	return subs, nil
+}
+
+// ActiveOn returns subscriptions matching filter whose period covers month,
+// ordered by id. A non-positive limit returns all of them.
+func (r *SubscriptionsRepo) ActiveOn(ctx context.Context, month models.MonthDate, filter models.SubscriptionFilter, limit, offset int, opts ...Option) ([]models.Subscription, error) {
+	opt := r.options(ctx, opts...)
+
+	var subs []models.Subscription
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		subs = nil
+
+		day := month.Time.Format("2006-01-02")
+		builder := applyFilter(r.psql.Select(subscriptionColumns...).From("subscriptions"), filter).
+			Where(sq.LtOrEq{"start_date": day}).
+			Where(sq.Or{
+				sq.Eq{"end_date": nil},
+				sq.GtOrEq{"end_date": day},
+			}).
+			OrderBy("id ASC")
+
+		if limit > 0 {
+			builder = builder.Limit(uint64(limit)).Offset(uint64(offset))
 		}
-		return wrapDBError(rows.Err())
+
+		sqlStr, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sqlStr, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		subs, err = pgx.AppendRows(make([]models.Subscription, 0, max(limit, 0)), rows, scanSubscription)
+		return wrapDBError(err)
 	}); err != nil {
 		return nil, err
 	}
@@ -186,11 +522,117 @@ func (r *SubscriptionsRepo) List(ctx context.Context, limit, offset int, opts ..
 	return subs, nil
 }
 
+// overlapsOrdered numbers the user's subscriptions per service by start
+// month, so each one only needs to be compared with those starting after it:
+// they overlap if the later one starts before the earlier one ends.
+const overlapsOrdered = `
+WITH ordered AS (
+	SELECT id, service_name, start_date, end_date,
+		ROW_NUMBER() OVER (PARTITION BY service_name ORDER BY start_date, id) AS rn
+	FROM subscriptions
+	WHERE user_id = ?
+)`
+
+// overlapsEnd is the last month of an overlap: the earlier of the two ends.
+const overlapsEnd = `CASE
+		WHEN a.end_date IS NULL THEN b.end_date
+		WHEN b.end_date IS NULL THEN a.end_date
+		ELSE LEAST(a.end_date, b.end_date)
+	END`
+
+// DistinctServices returns service names starting with prefix
+// (case-insensitive) with their numbers of subscriptions, most used first.
+// A non-positive limit returns all of them.
+func (r *SubscriptionsRepo) DistinctServices(ctx context.Context, prefix string, limit, offset int, opts ...Option) ([]models.ServiceCount, error) {
+	query := r.psql.Select("service_name", "COUNT(*)").
+		From("subscriptions").
+		GroupBy("service_name").
+		OrderBy("COUNT(*) DESC", "service_name ASC")
+	if prefix != "" {
+		query = query.Where(sq.ILike{"service_name": escapeLike(prefix) + "%"})
+	}
+	if limit > 0 {
+		query = query.Limit(uint64(limit)).Offset(uint64(offset))
+	}
+
+	return selectMany(ctx, &r.base, query, scanServiceCount, opts...)
+}
+
+func scanServiceCount(row pgx.Row) (models.ServiceCount, error) {
+	var sc models.ServiceCount
+	err := row.Scan(&sc.ServiceName, &sc.Count)
+	return sc, err
+}
+
+// Overlaps returns pairs of the user's subscriptions to the same service
+// with overlapping periods.
+func (r *SubscriptionsRepo) Overlaps(ctx context.Context, userID uuid.UUID, opts ...Option) ([]models.Overlap, error) {
+	query := r.psql.Select(
+		"a.service_name", "a.id", "b.id",
+		"b.start_date",
+		overlapsEnd,
+	).
+		Prefix(overlapsOrdered, userID).
+		From("ordered a").
+		Join("ordered b ON b.service_name = a.service_name AND b.rn > a.rn").
+		Where("a.end_date IS NULL OR b.start_date <= a.end_date").
+		OrderBy("a.service_name", "a.rn", "b.rn")
+
+	return selectMany(ctx, &r.base, query, scanOverlap, opts...)
+}
+
+func scanOverlap(row pgx.Row) (models.Overlap, error) {
+	var o models.Overlap
+	var from time.Time
+	var to *time.Time
+	if err := row.Scan(&o.ServiceName, &o.FirstID, &o.SecondID, &from, &to); err != nil {
+		return models.Overlap{}, err
+	}
+	o.From = models.MonthDate{Time: from}
+	if to != nil {
+		o.To = &models.MonthDate{Time: *to}
+	}
+	return o, nil
+}
+
+// Iterate streams subscriptions matching filter, ordered by id, calling fn for
+// each row without loading the whole result set into memory. Iteration stops
+// at the first error returned by fn, which is returned as is. The query is not
+// retried, since fn may already have processed part of the rows.
+func (r *SubscriptionsRepo) Iterate(ctx context.Context, filter models.SubscriptionFilter, fn func(models.Subscription) error, opts ...Option) error {
+	opt := r.options(ctx, opts...)
+
+	builder := applyFilter(r.psql.Select(subscriptionColumns...).From("subscriptions"), filter).OrderBy("id ASC")
+
+	sqlStr, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := opt.exec.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return wrapDBError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+
+	return wrapDBError(rows.Err())
+}
+
 // Update modifies an existing record.
 func (r *SubscriptionsRepo) Update(ctx context.Context, subs *models.Subscription, opts ...Option) error {
-	opt := r.applyOptions(opts...)
+	opt := r.options(ctx, opts...)
 
-	return r.retry.Do(ctx, func() error {
+	return opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
 		var endDate interface{}
 		if subs.EndDate != nil {
 			endDate = subs.EndDate.Time.Format("2006-01-02")
@@ -200,10 +642,13 @@ func (r *SubscriptionsRepo) Update(ctx context.Context, subs *models.Subscriptio
 
 		query := r.psql.Update("subscriptions").
 			Set("service_name", subs.ServiceName).
-			Set("price", subs.Price).
+			Set("price", int(subs.Price)).
 			Set("user_id", subs.UserID).
 			Set("start_date", subs.StartDate.Time.Format("2006-01-02")).
 			Set("end_date", endDate).
+			Set("trial", subs.Trial).
+			Set("currency", string(subs.Currency.OrDefault())).
+			Set("origin_region", r.region).
 			Where(sq.Eq{"id": subs.ID})
 
 		sql, args, err := query.ToSql()
@@ -211,6 +656,9 @@ func (r *SubscriptionsRepo) Update(ctx context.Context, subs *models.Subscriptio
 			return err
 		}
 
+		if err := r.registerUsers(ctx, opt.exec, subs.UserID); err != nil {
+			return err
+		}
 		cmd, err := opt.exec.Exec(ctx, sql, args...)
 		if err != nil {
 			return wrapDBError(err)
@@ -218,59 +666,117 @@ func (r *SubscriptionsRepo) Update(ctx context.Context, subs *models.Subscriptio
 		if cmd.RowsAffected() == 0 {
 			return ErrNotFound
 		}
+		subs.OriginRegion = r.region
 		return nil
 	})
 }
 
-// Delete removes a record by ID.
-func (r *SubscriptionsRepo) Delete(ctx context.Context, id int64, opts ...Option) error {
-	opt := r.applyOptions(opts...)
+// UpdatePartial sets only the fields given in p and returns the updated
+// record, so concurrent updates of other fields are not overwritten.
+func (r *SubscriptionsRepo) UpdatePartial(ctx context.Context, id int64, p models.SubscriptionPatch, opts ...Option) (*models.Subscription, error) {
+	opt := r.options(ctx, opts...)
+
+	var sub models.Subscription
+
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		query := r.psql.Update("subscriptions")
+		if p.ServiceName != nil {
+			query = query.Set("service_name", *p.ServiceName)
+		}
+		if p.Price != nil {
+			query = query.Set("price", int(*p.Price))
+		}
+		if p.EndDate != nil {
+			query = query.Set("end_date", p.EndDate.Time.Format("2006-01-02"))
+		} else if p.ClearEndDate {
+			query = query.Set("end_date", nil)
+		}
+		query = query.Set("origin_region", r.region).
+			Where(sq.Eq{"id": id}).
+			Suffix("RETURNING " + strings.Join(subscriptionColumns, ", "))
 
-	return r.retry.Do(ctx, func() error {
-		query := r.psql.Delete("subscriptions").Where(sq.Eq{"id": id})
 		sql, args, err := query.ToSql()
 		if err != nil {
 			return err
 		}
 
-		cmd, err := opt.exec.Exec(ctx, sql, args...)
+		sub, err = queryOne(ctx, opt.exec, sql, args...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// Delete removes a record by ID.
+func (r *SubscriptionsRepo) Delete(ctx context.Context, id int64, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Delete("subscriptions").Where(sq.Eq{"id": id}), opts...)
+}
+
+// DeleteAll removes all subscriptions and returns how many were removed.
+// It is meant for restoring a snapshot in a transaction (WithTx).
+func (r *SubscriptionsRepo) DeleteAll(ctx context.Context, opts ...Option) (int64, error) {
+	opt := r.options(ctx, opts...)
+
+	var n int64
+
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		cmd, err := opt.exec.Exec(ctx, "DELETE FROM subscriptions")
 		if err != nil {
 			return wrapDBError(err)
 		}
-		if cmd.RowsAffected() == 0 {
-			return ErrNotFound
-		}
+		n = cmd.RowsAffected()
 		return nil
-	})
+	}); err != nil {
+		return 0, err
+	}
+
+	return n, nil
 }
 
-// Summary calculates total price taking into account months of overlap between
-// subscription period and the requested [From, To] range.
-// For each subscription we compute number of months in the intersection (inclusive),
-// then add price * months to total.
-func (r *SubscriptionsRepo) Summary(ctx context.Context, q *models.SummaryRequest, opts ...Option) (int, error) {
-	opt := r.applyOptions(opts...)
+// DeleteReturning removes a record by ID and returns the deleted record.
+func (r *SubscriptionsRepo) DeleteReturning(ctx context.Context, id int64, opts ...Option) (*models.Subscription, error) {
+	opt := r.options(ctx, opts...)
 
-	var total int
+	var sub models.Subscription
 
-	if err := r.retry.Do(ctx, func() error {
-		// select fields needed to compute overlap: price, start_date, end_date
-		builder := r.psql.Select("price", "start_date", "end_date").
-			From("subscriptions").
-			Where(sq.LtOrEq{"start_date": q.To.Time}). // start_date <= to
-			Where(sq.Or{
-				sq.GtOrEq{"end_date": q.From.Time}, // end_date >= from
-				sq.Expr("end_date IS NULL"),
-			})
+	if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		query := r.psql.Delete("subscriptions").Where(sq.Eq{"id": id}).
+			Suffix("RETURNING " + strings.Join(subscriptionColumns, ", "))
 
-		if q.UserID != nil {
-			builder = builder.Where(sq.Eq{"user_id": *q.UserID})
-		}
-		if q.ServiceName != nil {
-			builder = builder.Where(sq.Eq{"service_name": *q.ServiceName})
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
 		}
 
-		sqlStr, args, err := builder.ToSql()
+		sub, err = queryOne(ctx, opt.exec, sql, args...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// Summary calculates total price taking into account months of overlap between
+// subscription period and the requested [From, To] range.
+// For each subscription we compute number of months in the intersection (inclusive),
+// then add price * months to total and count the subscription. How dates are
+// matched against the months depends on WithSummaryBoundaries; the period
+// actually used is returned with the total. The total is in q.Currency if it is set,
+// otherwise in the currency of the matching subscriptions (RUB if there are
+// none); subscriptions in different currencies make it fail with
+// models.ErrCurrencyMismatch.
+func (r *SubscriptionsRepo) Summary(ctx context.Context, q *models.SummaryRequest, opts ...Option) (models.Summary, error) {
+	opt := r.options(ctx, opts...)
+
+	var acc *summarizer
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		acc = r.newSummarizer(q)
+
+		// select fields needed to compute overlap: price, currency, start_date, end_date
+		sqlStr, args, err := r.summaryQuery(q, acc, "price", "currency", "start_date", "end_date").ToSql()
 		if err != nil {
 			return err
 		}
@@ -282,34 +788,79 @@ func (r *SubscriptionsRepo) Summary(ctx context.Context, q *models.SummaryReques
 		defer rows.Close()
 
 		var (
-			price     int
+			price     int64
+			cur       models.Currency
 			startDate time.Time
 			endDate   *time.Time
 		)
 
 		for rows.Next() {
-			if err := rows.Scan(&price, &startDate, &endDate); err != nil {
+			if err := rows.Scan(&price, &cur, &startDate, &endDate); err != nil {
 				return wrapDBError(err)
 			}
-
-			// compute overlap interval [ovStart, ovEnd]
-			ovStart := startDate
-			if q.From.Time.After(ovStart) {
-				ovStart = q.From.Time
+			if _, _, err := acc.add(price, cur, startDate, endDate); err != nil {
+				return err
 			}
+		}
 
-			ovEnd := q.To.Time
-			if endDate != nil && endDate.Before(ovEnd) {
-				ovEnd = *endDate
-			}
+		if err := rows.Err(); err != nil {
+			return wrapDBError(err)
+		}
 
-			// if no overlap (ovEnd < ovStart) skip
-			if ovEnd.Before(ovStart) {
-				continue
-			}
+		return nil
+	}); err != nil {
+		return models.Summary{}, err
+	}
+
+	return acc.sum, nil
+}
+
+// ExplainSummary calculates the same total as Summary and also returns the
+// contribution of every counted subscription in Summary.Lines, ordered by ID.
+// It reads more columns than Summary, so it is kept apart from the hot path.
+func (r *SubscriptionsRepo) ExplainSummary(ctx context.Context, q *models.SummaryRequest, opts ...Option) (models.Summary, error) {
+	opt := r.options(ctx, opts...)
+
+	var acc *summarizer
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		acc = r.newSummarizer(q)
 
-			months := monthsInclusive(ovStart, ovEnd)
-			total += price * months
+		sqlStr, args, err := r.summaryQuery(q, acc,
+			"id", "service_name", "user_id", "price", "currency", "start_date", "end_date").
+			OrderBy("id ASC").
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sqlStr, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		var (
+			id          int64
+			serviceName string
+			userID      uuid.UUID
+			price       int64
+			cur         models.Currency
+			startDate   time.Time
+			endDate     *time.Time
+		)
+
+		for rows.Next() {
+			if err := rows.Scan(&id, &serviceName, &userID, &price, &cur, &startDate, &endDate); err != nil {
+				return wrapDBError(err)
+			}
+			line, ok, err := acc.add(price, cur, startDate, endDate)
+			if err != nil {
+				return err
+			}
+			if ok {
+				line.ID, line.ServiceName, line.UserID = id, serviceName, userID
+				acc.sum.Lines = append(acc.sum.Lines, line)
+			}
 		}
 
 		if err := rows.Err(); err != nil {
@@ -318,14 +869,352 @@ func (r *SubscriptionsRepo) Summary(ctx context.Context, q *models.SummaryReques
 
 		return nil
 	}); err != nil {
-		return 0, err
+		return models.Summary{}, err
 	}
 
-	return total, nil
+	return acc.sum, nil
+}
+
+// SummaryBreakdown breaks the total of Summary down by q.GroupBy. The groups
+// are ranked by amount in the database; the page [q.Offset, q.Offset+q.Limit)
+// is returned and the groups after it are added up into Other, so the result
+// stays small however many users or services there are. q.Limit must be
+// positive. Like Summary, it fails with models.ErrCurrencyMismatch if
+// subscriptions in different currencies match.
+func (r *SubscriptionsRepo) SummaryBreakdown(ctx context.Context, q *models.SummaryRequest, opts ...Option) (models.SummaryBreakdown, error) {
+	opt := r.options(ctx, opts...)
+
+	var key string
+	switch q.GroupBy {
+	case models.GroupByUser:
+		key = "user_id::text"
+	case models.GroupByService:
+		key = "service_name"
+	default:
+		return models.SummaryBreakdown{}, fmt.Errorf("unsupported summary grouping %q", q.GroupBy)
+	}
+	if q.Limit < 1 {
+		return models.SummaryBreakdown{}, fmt.Errorf("summary breakdown limit must be positive, got %d", q.Limit)
+	}
+
+	var out models.SummaryBreakdown
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		out = models.SummaryBreakdown{GroupBy: q.GroupBy, Groups: []models.SummaryGroup{}, Limit: q.Limit, Offset: q.Offset}
+		acc := r.newSummarizer(q)
+		from, to := acc.sum.From.Time, acc.sum.To.Time
+
+		contrib := r.summaryQuery(q, acc, key+" AS key", "currency", "price").
+			Column(r.overlapMonths(from, to))
+		grouped := sq.Select("key", "currency", "SUM(price::bigint * months) AS amount", "COUNT(*) AS count").
+			FromSelect(contrib, "c").
+			Where("months > 0").
+			GroupBy("key", "currency")
+		ranked := sq.Select("*",
+			"ROW_NUMBER() OVER (ORDER BY amount DESC, key) AS rn",
+			"COUNT(*) OVER () AS total_groups",
+			"MIN(currency) OVER () AS min_currency",
+			"MAX(currency) OVER () AS max_currency",
+		).FromSelect(grouped, "g")
+		// bucket is the rank for groups on the page, 0 for the groups before
+		// it and NULL for the remainder, so the remainder is aggregated in
+		// the same query and at most limit+2 rows come back.
+		builder := r.psql.Select().
+			Column(sq.Expr("CASE WHEN rn <= ? THEN 0 WHEN rn <= ? THEN rn END AS bucket", q.Offset, q.Offset+q.Limit)).
+			Columns(
+				"MIN(key)",
+				"SUM(amount)::bigint",
+				"SUM(count)::bigint",
+				"COUNT(*)",
+				"MAX(total_groups)",
+				"MIN(min_currency)",
+				"MAX(max_currency)",
+			).
+			FromSelect(ranked, "r").
+			GroupBy("bucket").
+			OrderBy("MIN(rn)")
+
+		sqlStr, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sqlStr, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				bucket         *int64
+				key            string
+				amount         int64
+				count          int64
+				groups         int
+				minCur, maxCur models.Currency
+			)
+			if err := rows.Scan(&bucket, &key, &amount, &count, &groups, &out.TotalGroups, &minCur, &maxCur); err != nil {
+				return wrapDBError(err)
+			}
+			if minCur != maxCur {
+				return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, minCur, maxCur)
+			}
+			// Prices are in whole units; amounts are Money like the total.
+			money, err := models.NewMoney(amount, minCur)
+			if err != nil {
+				return err
+			}
+			switch {
+			case bucket == nil:
+				out.Other = &models.SummaryOther{Groups: groups, Amount: money, Count: int(count)}
+			case *bucket > 0:
+				out.Groups = append(out.Groups, models.SummaryGroup{Key: key, Amount: money, Count: int(count)})
+			}
+		}
+
+		return wrapDBError(rows.Err())
+	}); err != nil {
+		return models.SummaryBreakdown{}, err
+	}
+
+	return out, nil
+}
+
+// overlapMonths selects the months a subscription overlaps [from, to],
+// counted like summarizer.add does; it is 0 if there is no overlap.
+func (r *SubscriptionsRepo) overlapMonths(from, to time.Time) sq.Sqlizer {
+	if r.boundaries == BoundariesCalendarMonth {
+		const month = "(EXTRACT(YEAR FROM %[1]s) * 12 + EXTRACT(MONTH FROM %[1]s))::int"
+		end := fmt.Sprintf(month, "LEAST(end_date_eff, ?::date)")
+		start := fmt.Sprintf(month, "GREATEST(start_date, ?::date)")
+		return sq.Expr("GREATEST("+end+" - "+start+" + 1, 0) AS months", to, to, from, from)
+	}
+	// 30-day periods of the overlapping days, rounded up.
+	return sq.Expr("GREATEST((LEAST(end_date_eff, ?::date) - GREATEST(start_date, ?::date) + 30) / 30, 0) AS months", to, from)
+}
+
+// summaryQuery selects columns of subscriptions that may overlap the period
+// of acc and match the filters of q.
+func (r *SubscriptionsRepo) summaryQuery(q *models.SummaryRequest, acc *summarizer, columns ...string) sq.SelectBuilder {
+	from, to := acc.sum.From.Time, acc.sum.To.Time
+	var startsBefore sq.Sqlizer = sq.LtOrEq{"start_date": to} // start_date <= to
+	if acc.calendar {
+		startsBefore = sq.Lt{"start_date": to.AddDate(0, 1, 0)} // start_date before the month after to
+	}
+
+	// end_date_eff is end_date with open-ended subscriptions ending in the
+	// far future, so both conditions can be served by a range index scan.
+	builder := r.psql.Select(columns...).
+		From("subscriptions").
+		Where(sq.GtOrEq{"end_date_eff": from}). // end_date >= from or open-ended
+		Where(startsBefore)
+
+	if q.UserID != nil {
+		builder = builder.Where(sq.Eq{"user_id": *q.UserID})
+	}
+	if q.ServiceName != nil {
+		builder = builder.Where(sq.Eq{"service_name": *q.ServiceName})
+	}
+	if q.ExcludeTrials {
+		builder = builder.Where(sq.Eq{"trial": false})
+	}
+	if q.MinPrice != nil {
+		builder = builder.Where(sq.GtOrEq{"price": *q.MinPrice})
+	}
+	if q.Currency != nil {
+		builder = builder.Where(sq.Eq{"currency": string(*q.Currency)})
+	}
+	return builder
+}
+
+// summarizer adds subscriptions up into a models.Summary.
+type summarizer struct {
+	calendar bool
+	// first is set until the first subscription is added when the total
+	// takes its currency, so a single non-default currency sums up too.
+	first bool
+	sum   models.Summary
+}
+
+func (r *SubscriptionsRepo) newSummarizer(q *models.SummaryRequest) *summarizer {
+	acc := &summarizer{
+		calendar: r.boundaries == BoundariesCalendarMonth,
+		first:    q.Currency == nil,
+	}
+	acc.sum.Total = models.Zero(models.DefaultCurrency)
+	if q.Currency != nil {
+		acc.sum.Total = models.Zero(*q.Currency)
+	}
+
+	from, to := q.From.Time, q.To.Time
+	if acc.calendar {
+		from, to = monthStart(from), monthStart(to)
+	}
+	acc.sum.From, acc.sum.To = models.MonthDate{Time: from}, models.MonthDate{Time: to}
+	return acc
+}
+
+// add adds price times the months a subscription overlaps the period to the
+// total and returns its contribution without the subscription's identity.
+// ok is false if the subscription does not overlap the period.
+func (acc *summarizer) add(price int64, cur models.Currency, startDate time.Time, endDate *time.Time) (line models.SummaryLine, ok bool, err error) {
+	if acc.calendar {
+		startDate = monthStart(startDate)
+		if endDate != nil {
+			e := monthStart(*endDate)
+			endDate = &e
+		}
+	}
+
+	// compute overlap interval [ovStart, ovEnd]
+	from, to := acc.sum.From.Time, acc.sum.To.Time
+	ovStart := startDate
+	if from.After(ovStart) {
+		ovStart = from
+	}
+
+	ovEnd := to
+	if endDate != nil && endDate.Before(ovEnd) {
+		ovEnd = *endDate
+	}
+
+	// if no overlap (ovEnd < ovStart) skip
+	if ovEnd.Before(ovStart) {
+		return models.SummaryLine{}, false, nil
+	}
+
+	months := monthsInclusive(ovStart, ovEnd)
+	if acc.calendar {
+		months = calendarMonthsInclusive(ovStart, ovEnd)
+	}
+	if acc.first {
+		acc.sum.Total, acc.first = models.Zero(cur), false
+	}
+	monthly, err := models.NewMoney(price, cur)
+	if err != nil {
+		return models.SummaryLine{}, false, err
+	}
+	cost, err := monthly.Mul(int64(months))
+	if err != nil {
+		return models.SummaryLine{}, false, err
+	}
+	if acc.sum.Total, err = acc.sum.Total.Add(cost); err != nil {
+		return models.SummaryLine{}, false, err
+	}
+	acc.sum.Count++
+
+	return models.SummaryLine{
+		Price:    models.Price(price),
+		Currency: cur,
+		From:     models.MonthDate{Time: ovStart},
+		To:       models.MonthDate{Time: ovEnd},
+		Months:   months,
+		Amount:   cost,
+	}, true, nil
 }
 
-func (r *SubscriptionsRepo) applyOptions(opts ...Option) *RepositoryOptions {
-	opt := defaultOptions(r)
+// applyFilter adds WHERE conditions for the set filter fields.
+func applyFilter(builder sq.SelectBuilder, f models.SubscriptionFilter) sq.SelectBuilder {
+	if f.UserID != nil {
+		builder = builder.Where(sq.Eq{"user_id": *f.UserID})
+	}
+	if f.ServiceName != nil {
+		builder = builder.Where(sq.Eq{"service_name": *f.ServiceName})
+	}
+	if f.ExcludeTrials {
+		builder = builder.Where(sq.Eq{"trial": false})
+	}
+	if f.MinPrice != nil {
+		builder = builder.Where(sq.GtOrEq{"price": *f.MinPrice})
+	}
+	if f.Currency != nil {
+		builder = builder.Where(sq.Eq{"currency": string(*f.Currency)})
+	}
+	return builder
+}
+
+// subscriptionColumns are the columns of subscriptionRow, selected for
+// scanSubscription.
+var subscriptionColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "trial", "currency", "origin_region"}
+
+// subscriptionRow is a row of subscriptions. Columns are matched by name,
+// so their order in a query does not matter.
+type subscriptionRow struct {
+	ID          int64           `db:"id"`
+	ServiceName string          `db:"service_name"`
+	Price       models.Price    `db:"price"`
+	UserID      uuid.UUID       `db:"user_id"`
+	StartDate   time.Time       `db:"start_date"`
+	EndDate     *time.Time      `db:"end_date"`
+	Trial       bool            `db:"trial"`
+	Currency    models.Currency `db:"currency"`
+	Region      string          `db:"origin_region"`
+}
+
+// scanSubscription scans a row with subscriptionColumns in any order. It is
+// a pgx.RowToFunc for pgx.CollectRows and friends.
+func scanSubscription(row pgx.CollectableRow) (models.Subscription, error) {
+	r, err := pgx.RowToStructByName[subscriptionRow](row)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	s := models.Subscription{
+		ID:           r.ID,
+		ServiceName:  r.ServiceName,
+		Price:        r.Price,
+		Currency:     r.Currency,
+		UserID:       r.UserID,
+		StartDate:    models.MonthDate{Time: r.StartDate},
+		Trial:        r.Trial,
+		OriginRegion: r.Region,
+	}
+	if r.EndDate != nil {
+		s.EndDate = &models.MonthDate{Time: *r.EndDate}
+	}
+	return s, nil
+}
+
+// queryOne runs a query returning at most one subscription; ErrNotFound is
+// returned if there is none.
+func queryOne(ctx context.Context, exec Executer, sql string, args ...any) (models.Subscription, error) {
+	rows, err := exec.Query(ctx, sql, args...)
+	if err != nil {
+		return models.Subscription{}, wrapDBError(err)
+	}
+	sub, err := pgx.CollectExactlyOneRow(rows, scanSubscription)
+	return sub, wrapDBError(err)
+}
+
+// retrier returns the Retrier of the selected profile, or of def if none was
+// selected. Statements in a transaction are not retried: a failed statement
+// aborts the transaction, so only the whole transaction can be (see TxManager).
+func (o *RepositoryOptions) retrier(r retry.Retrier, def RetryProfile) retry.Retrier {
+	if o.tx != nil {
+		return txRetrier{}
+	}
+	if o.profile != "" {
+		def = o.profile
+	}
+	return retry.ForProfile(r, string(def))
+}
+
+// txRetrier runs statements of a transaction once and records failures that
+// require rerunning the whole transaction for the TxManager that owns it.
+type txRetrier struct{}
+
+func (txRetrier) Do(ctx context.Context, f retry.AttemptFunc) error {
+	err := retry.NoRetry().Do(ctx, f)
+	markRestart(ctx, err)
+	return err
+}
+
+// buildOptions applies opts over the default options for db. A transaction
+// carried by ctx (ContextWithTx) is used unless opts select another one.
+func buildOptions(ctx context.Context, db Executer, opts ...Option) *RepositoryOptions {
+	opt := defaultOptions(db)
+	if tx, ok := TxFromContext(ctx); ok {
+		WithTx(tx)(&opt)
+	}
 	for _, o := range opts {
 		if o != nil {
 			o(&opt)
@@ -0,0 +1,844 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var subscriptionColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "trial", "currency", "origin_region"}
+
+func newMockRepo(t *testing.T) (*repository.SubscriptionsRepo, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	mock := newMockPool(t)
+	return repository.NewSubscriptionsRepo(mock, retry.NoRetry()), mock
+}
+
+// newMockPool creates a pool mock matching SQL exactly and checks its
+// expectations at the end of the test.
+func newMockPool(t *testing.T) pgxmock.PgxPoolIface {
+	t.Helper()
+
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		mock.Close()
+	})
+
+	return mock
+}
+
+// expectRegisterUsers expects the users of subscriptions about to be
+// written to be registered.
+func expectRegisterUsers(mock pgxmock.PgxPoolIface, ids ...uuid.UUID) {
+	values := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = fmt.Sprintf("($%d)", i+1)
+		args[i] = id
+	}
+	mock.ExpectExec("INSERT INTO users (id) VALUES " + strings.Join(values, ",") + " ON CONFLICT (id) DO NOTHING").
+		WithArgs(args...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSubscriptionsRepo_CreateSubscription_SQL(t *testing.T) {
+	userID := uuid.New()
+	end := models.MonthDate{Time: month(2025, time.December)}
+
+	tests := []struct {
+		name    string
+		sub     *models.Subscription
+		endDate any
+	}{
+		{
+			name: "without end date",
+			sub: &models.Subscription{
+				ServiceName: "Netflix", Price: 15, UserID: userID,
+				StartDate: models.MonthDate{Time: month(2025, time.July)},
+			},
+			endDate: nil,
+		},
+		{
+			name: "with end date",
+			sub: &models.Subscription{
+				ServiceName: "Netflix", Price: 15, UserID: userID,
+				StartDate: models.MonthDate{Time: month(2025, time.July)},
+				EndDate:   &end,
+			},
+			endDate: "2025-12-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepo(t)
+
+			expectRegisterUsers(mock, userID)
+			mock.ExpectQuery("INSERT INTO subscriptions (service_name,price,user_id,start_date,end_date,trial,currency,origin_region) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (user_id, service_name, start_date) DO NOTHING RETURNING id").
+				WithArgs("Netflix", 15, userID, "2025-07-01", tt.endDate, false, "RUB", "").
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(42)))
+
+			require.NoError(t, repo.CreateSubscription(t.Context(), tt.sub))
+			assert.Equal(t, int64(42), tt.sub.ID)
+		})
+	}
+
+	t.Run("provisioned users", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry(), repository.WithProvisionedUsers())
+
+		mock.ExpectQuery("INSERT INTO subscriptions (service_name,price,user_id,start_date,end_date,trial,currency,origin_region) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (user_id, service_name, start_date) DO NOTHING RETURNING id").
+			WithArgs("Netflix", 15, userID, "2025-07-01", nil, false, "RUB", "").
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+
+		err := repo.CreateSubscription(t.Context(), tests[0].sub)
+		assert.ErrorIs(t, err, repository.ErrForeignKeyViolation)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		expectRegisterUsers(mock, userID)
+		mock.ExpectQuery("INSERT INTO subscriptions (service_name,price,user_id,start_date,end_date,trial,currency,origin_region) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (user_id, service_name, start_date) DO NOTHING RETURNING id").
+			WithArgs("Netflix", 15, userID, "2025-07-01", nil, false, "RUB", "").
+			WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+		err := repo.CreateSubscription(t.Context(), tests[0].sub)
+		assert.ErrorIs(t, err, repository.ErrDuplicate, "no error fails the transaction")
+	})
+
+	t.Run("region", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry(), repository.WithRegion("eu-central"))
+
+		sub := &models.Subscription{
+			ServiceName: "Netflix", Price: 15, UserID: userID,
+			StartDate: models.MonthDate{Time: month(2025, time.July)}, OriginRegion: "us-east",
+		}
+		expectRegisterUsers(mock, userID)
+		mock.ExpectQuery("INSERT INTO subscriptions (service_name,price,user_id,start_date,end_date,trial,currency,origin_region) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (user_id, service_name, start_date) DO NOTHING RETURNING id").
+			WithArgs("Netflix", 15, userID, "2025-07-01", nil, false, "RUB", "eu-central").
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(42)))
+
+		require.NoError(t, repo.CreateSubscription(t.Context(), sub))
+		assert.Equal(t, "eu-central", sub.OriginRegion, "the client's value is replaced")
+	})
+}
+
+func TestSubscriptionsRepo_CopyFromSubscriptions(t *testing.T) {
+	columns := []string{"service_name", "price", "user_id", "start_date", "end_date", "trial", "currency", "origin_region"}
+
+	subs := make([]models.Subscription, 5)
+	for i := range subs {
+		subs[i] = models.Subscription{
+			ServiceName: "Netflix", Price: 15, UserID: uuid.New(),
+			StartDate: models.MonthDate{Time: month(2025, time.July)},
+		}
+	}
+	subs[1].UserID = subs[0].UserID
+
+	t.Run("chunked", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		expectRegisterUsers(mock, subs[0].UserID)
+		mock.ExpectCopyFrom(pgx.Identifier{"subscriptions"}, columns).WillReturnResult(2)
+		expectRegisterUsers(mock, subs[2].UserID, subs[3].UserID)
+		mock.ExpectCopyFrom(pgx.Identifier{"subscriptions"}, columns).WillReturnResult(2)
+		expectRegisterUsers(mock, subs[4].UserID)
+		mock.ExpectCopyFrom(pgx.Identifier{"subscriptions"}, columns).WillReturnResult(1)
+
+		n, err := repo.CopyFromSubscriptions(t.Context(), subs, repository.WithChunkSize(2))
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+	})
+
+	t.Run("error maps and reports copied rows", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		expectRegisterUsers(mock, subs[0].UserID, subs[2].UserID)
+		mock.ExpectCopyFrom(pgx.Identifier{"subscriptions"}, columns).WillReturnResult(3)
+		expectRegisterUsers(mock, subs[3].UserID, subs[4].UserID)
+		mock.ExpectCopyFrom(pgx.Identifier{"subscriptions"}, columns).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		n, err := repo.CopyFromSubscriptions(t.Context(), subs, repository.WithChunkSize(3))
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+		assert.Equal(t, int64(3), n)
+	})
+}
+
+func TestSubscriptionsRepo_GetByID_SQL(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		userID := uuid.New()
+		end := month(2025, time.September)
+
+		mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+				AddRow(int64(7), "Spotify", 10, userID, month(2025, time.July), &end, false, "RUB", "eu-central"))
+
+		got, err := repo.GetByID(t.Context(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, "Spotify", got.ServiceName)
+		assert.Equal(t, userID, got.UserID)
+		assert.Equal(t, "eu-central", got.OriginRegion)
+		require.NotNil(t, got.EndDate)
+		assert.Equal(t, end, got.EndDate.Time)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1").
+			WithArgs(int64(7)).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetByID(t.Context(), 7)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestSubscriptionsRepo_RetryProfiles(t *testing.T) {
+	errConn := errors.New("connection reset")
+	profiles := retry.NewProfiles(retry.NoRetry(), map[string]retry.Retrier{
+		"read": retry.New(retry.WithMaxAttempts(2), retry.WithBackoff(retry.FixedBackoff{})),
+	})
+	getByID := "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1"
+
+	t.Run("reads use the read profile", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSubscriptionsRepo(mock, profiles)
+
+		mock.ExpectQuery(getByID).WithArgs(int64(7)).WillReturnError(errConn)
+		mock.ExpectQuery(getByID).WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+				AddRow(int64(7), "Spotify", 10, uuid.New(), month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+		_, err := repo.GetByID(t.Context(), 7)
+		assert.NoError(t, err)
+	})
+
+	t.Run("writes use the write profile", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSubscriptionsRepo(mock, profiles)
+
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(7)).WillReturnError(errConn)
+
+		err := repo.Delete(t.Context(), 7)
+		assert.ErrorIs(t, err, errConn)
+	})
+
+	t.Run("option overrides the profile", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSubscriptionsRepo(mock, profiles)
+
+		mock.ExpectQuery(getByID).WithArgs(int64(7)).WillReturnError(errConn)
+
+		_, err := repo.GetByID(t.Context(), 7, repository.WithRetryProfile(repository.WriteProfile))
+		assert.ErrorIs(t, err, errConn)
+	})
+}
+
+func TestSubscriptionsRepo_GetByID_Hedged(t *testing.T) {
+	mock := newMockPool(t)
+	mock.MatchExpectationsInOrder(false)
+	repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry(), repository.WithHedgedReads(10*time.Millisecond))
+	getByID := "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1"
+
+	mock.ExpectQuery(getByID).WithArgs(int64(7)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(7), "Slow", 10, uuid.New(), month(2025, time.July), (*time.Time)(nil), false, "RUB", "")).
+		WillDelayFor(300 * time.Millisecond)
+	mock.ExpectQuery(getByID).WithArgs(int64(7)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(7), "Spotify", 10, uuid.New(), month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+	start := time.Now()
+	got, err := repo.GetByID(t.Context(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Spotify", got.ServiceName)
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestSubscriptionsRepo_DistinctServices_SQL(t *testing.T) {
+	t.Run("prefix with wildcards", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		mock.ExpectQuery("SELECT service_name, COUNT(*) FROM subscriptions WHERE service_name ILIKE $1 " +
+			"GROUP BY service_name ORDER BY COUNT(*) DESC, service_name ASC LIMIT 5 OFFSET 10").
+			WithArgs(`100\%\_%`).
+			WillReturnRows(pgxmock.NewRows([]string{"service_name", "count"}).
+				AddRow("100%_Music", 3))
+
+		got, err := repo.DistinctServices(t.Context(), "100%_", 5, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ServiceCount{{ServiceName: "100%_Music", Count: 3}}, got)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		mock.ExpectQuery("SELECT service_name, COUNT(*) FROM subscriptions " +
+			"GROUP BY service_name ORDER BY COUNT(*) DESC, service_name ASC").
+			WillReturnRows(pgxmock.NewRows([]string{"service_name", "count"}))
+
+		got, err := repo.DistinctServices(t.Context(), "", 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestSubscriptionsRepo_GetByKey_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE service_name = $1 AND start_date = $2 AND user_id = $3").
+		WithArgs("Netflix", "2025-07-01", userID.String()).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(9), "Netflix", 15, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+	got, err := repo.GetByKey(t.Context(), userID, "Netflix", models.MonthDate{Time: month(2025, time.July)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), got.ID)
+}
+
+func TestSubscriptionsRepo_Exists_SQL(t *testing.T) {
+	for _, exists := range []bool{true, false} {
+		repo, mock := newMockRepo(t)
+
+		mock.ExpectQuery("SELECT EXISTS( SELECT 1 FROM subscriptions WHERE id = $1 )").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(exists))
+
+		got, err := repo.Exists(t.Context(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, exists, got)
+	}
+}
+
+func TestSubscriptionsRepo_CountActive_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery("SELECT COUNT(*) FROM subscriptions WHERE user_id = $1 AND (end_date IS NULL OR end_date >= $2)").
+		WithArgs(userID.String(), "2025-07-01").
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+
+	got, err := repo.CountActive(t.Context(), userID, models.MonthDate{Time: month(2025, time.July)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, got)
+}
+
+func TestSubscriptionsRepo_MostActiveUsers_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectQuery("SELECT user_id FROM subscriptions WHERE start_date <= $1 AND (end_date IS NULL OR end_date >= $2) "+
+		"GROUP BY user_id ORDER BY COUNT(*) DESC, user_id ASC LIMIT 2").
+		WithArgs("2025-07-01", "2025-07-01").
+		WillReturnRows(pgxmock.NewRows([]string{"user_id"}).AddRow(first).AddRow(second))
+
+	got, err := repo.MostActiveUsers(t.Context(), models.MonthDate{Time: month(2025, time.July)}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first, second}, got)
+}
+
+func TestSubscriptionsRepo_WithLock_SQL(t *testing.T) {
+	tests := []struct {
+		name string
+		mode repository.LockMode
+		sql  string
+	}{
+		{
+			name: "for update",
+			mode: repository.ForUpdate,
+			sql:  "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1 FOR UPDATE",
+		},
+		{
+			name: "for share",
+			mode: repository.ForShare,
+			sql:  "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE id = $1 FOR SHARE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepo(t)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(tt.sql).
+				WithArgs(int64(7)).
+				WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+					AddRow(int64(7), "Spotify", 10, uuid.New(), month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+			mock.ExpectRollback()
+
+			tx, err := mock.Begin(t.Context())
+			require.NoError(t, err)
+			defer tx.Rollback(t.Context())
+
+			_, err = repo.GetByID(t.Context(), 7, repository.WithTx(tx), repository.WithLock(tt.mode))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionsRepo_List_SQL(t *testing.T) {
+	minPrice, currency := 1, models.Currency("RUB")
+
+	tests := []struct {
+		name   string
+		filter models.SubscriptionFilter
+		limit  int
+		offset int
+		sql    string
+		args   []any
+	}{
+		{
+			name:  "with pagination",
+			limit: 10, offset: 20,
+			sql: "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions ORDER BY id ASC LIMIT 10 OFFSET 20",
+		},
+		{
+			name:  "without limit",
+			limit: 0, offset: 20,
+			sql: "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions ORDER BY id ASC",
+		},
+		{
+			name:   "with filter",
+			filter: models.SubscriptionFilter{ExcludeTrials: true, MinPrice: &minPrice, Currency: &currency},
+			limit:  10,
+			sql: "SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions " +
+				"WHERE trial = $1 AND price >= $2 AND currency = $3 ORDER BY id ASC LIMIT 10 OFFSET 0",
+			args: []any{false, 1, "RUB"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepo(t)
+
+			mock.ExpectQuery(tt.sql).
+				WithArgs(tt.args...).
+				WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+					AddRow(int64(1), "Netflix", 15, uuid.New(), month(2025, time.July), (*time.Time)(nil), false, "RUB", "").
+					AddRow(int64(2), "Spotify", 10, uuid.New(), month(2025, time.August), (*time.Time)(nil), false, "RUB", ""))
+
+			subs, err := repo.List(t.Context(), tt.filter, tt.limit, tt.offset)
+			require.NoError(t, err)
+			assert.Len(t, subs, 2)
+		})
+	}
+}
+
+func TestSubscriptionsRepo_ActiveOn_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	userID := uuid.New()
+	on := models.MonthDate{Time: month(2025, time.August)}
+
+	mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions "+
+		"WHERE user_id = $1 AND start_date <= $2 AND (end_date IS NULL OR end_date >= $3) ORDER BY id ASC LIMIT 10 OFFSET 0").
+		WithArgs(userID.String(), "2025-08-01", "2025-08-01").
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(1), "Netflix", 15, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+	subs, err := repo.ActiveOn(t.Context(), on, models.SubscriptionFilter{UserID: &userID}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, int64(1), subs[0].ID)
+}
+
+func TestSubscriptionsRepo_Overlaps_SQL(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+	repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry())
+
+	userID := uuid.New()
+	end := month(2025, time.April)
+	mock.ExpectQuery(`ROW_NUMBER\(\) OVER \(PARTITION BY service_name ORDER BY start_date, id\)`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows([]string{"service_name", "first_id", "second_id", "from", "to"}).
+			AddRow("Netflix", int64(1), int64(2), month(2025, time.March), &end).
+			AddRow("Spotify", int64(3), int64(4), month(2025, time.July), (*time.Time)(nil)))
+
+	got, err := repo.Overlaps(t.Context(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Overlap{
+		{ServiceName: "Netflix", FirstID: 1, SecondID: 2, From: models.MonthDate{Time: month(2025, time.March)}, To: &models.MonthDate{Time: end}},
+		{ServiceName: "Spotify", FirstID: 3, SecondID: 4, From: models.MonthDate{Time: month(2025, time.July)}},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionsRepo_Iterate_SQL(t *testing.T) {
+	userID := uuid.New()
+	service := "Netflix"
+
+	t.Run("filters and streams rows", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions WHERE user_id = $1 AND service_name = $2 ORDER BY id ASC").
+			WithArgs(userID.String(), service).
+			WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+				AddRow(int64(1), service, 15, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", "").
+				AddRow(int64(2), service, 15, userID, month(2025, time.August), (*time.Time)(nil), false, "RUB", ""))
+
+		var ids []int64
+		err := repo.Iterate(t.Context(), models.SubscriptionFilter{UserID: &userID, ServiceName: &service},
+			func(s models.Subscription) error {
+				ids = append(ids, s.ID)
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+
+	t.Run("stops on callback error", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		errStop := errors.New("stop")
+
+		mock.ExpectQuery("SELECT id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region FROM subscriptions ORDER BY id ASC").
+			WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+				AddRow(int64(1), service, 15, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", "").
+				AddRow(int64(2), service, 15, userID, month(2025, time.August), (*time.Time)(nil), false, "RUB", "")).
+			RowsWillBeClosed()
+
+		calls := 0
+		err := repo.Iterate(t.Context(), models.SubscriptionFilter{}, func(s models.Subscription) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestSubscriptionsRepo_Update_SQL(t *testing.T) {
+	const sql = "UPDATE subscriptions SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5, trial = $6, currency = $7, origin_region = $8 WHERE id = $9"
+
+	userID := uuid.New()
+	sub := &models.Subscription{
+		ID: 3, ServiceName: "Netflix", Price: 20, UserID: userID,
+		StartDate: models.MonthDate{Time: month(2025, time.July)},
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		expectRegisterUsers(mock, userID)
+		mock.ExpectExec(sql).
+			WithArgs("Netflix", 20, userID, "2025-07-01", nil, false, "RUB", "", int64(3)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		assert.NoError(t, repo.Update(t.Context(), sub))
+	})
+
+	t.Run("no rows affected", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+
+		expectRegisterUsers(mock, userID)
+		mock.ExpectExec(sql).
+			WithArgs("Netflix", 20, userID, "2025-07-01", nil, false, "RUB", "", int64(3)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		assert.ErrorIs(t, repo.Update(t.Context(), sub), repository.ErrNotFound)
+	})
+}
+
+func TestSubscriptionsRepo_Delete_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").
+		WithArgs(int64(3)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	assert.NoError(t, repo.Delete(t.Context(), 3))
+}
+
+func TestSubscriptionsRepo_UpdatePartial_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	userID := uuid.New()
+	price := models.Price(599)
+
+	mock.ExpectQuery("UPDATE subscriptions SET price = $1, end_date = $2, origin_region = $3 WHERE id = $4 "+
+		"RETURNING id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region").
+		WithArgs(599, nil, "", int64(3)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(3), "Netflix", 599, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+	got, err := repo.UpdatePartial(t.Context(), 3, models.SubscriptionPatch{Price: &price, ClearEndDate: true})
+	require.NoError(t, err)
+	assert.Equal(t, models.Price(599), got.Price)
+	assert.Equal(t, "Netflix", got.ServiceName, "fields not in the patch are returned as stored")
+	assert.Nil(t, got.EndDate)
+
+	name := "Kinopoisk"
+	mock.ExpectQuery("UPDATE subscriptions SET service_name = $1, origin_region = $2 WHERE id = $3 "+
+		"RETURNING id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region").
+		WithArgs(name, "", int64(4)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns))
+
+	_, err = repo.UpdatePartial(t.Context(), 4, models.SubscriptionPatch{ServiceName: &name})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestSubscriptionsRepo_DeleteAll_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectExec("DELETE FROM subscriptions").
+		WillReturnResult(pgxmock.NewResult("DELETE", 5))
+
+	n, err := repo.DeleteAll(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+}
+
+func TestSubscriptionsRepo_DeleteReturning_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery("DELETE FROM subscriptions WHERE id = $1 RETURNING id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region").
+		WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns).
+			AddRow(int64(3), "Netflix", 15, userID, month(2025, time.July), (*time.Time)(nil), false, "RUB", ""))
+
+	got, err := repo.DeleteReturning(t.Context(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), got.ID)
+	assert.Equal(t, userID, got.UserID)
+
+	mock.ExpectQuery("DELETE FROM subscriptions WHERE id = $1 RETURNING id, service_name, price, user_id, start_date, end_date, trial, currency, origin_region").
+		WithArgs(int64(4)).
+		WillReturnRows(pgxmock.NewRows(subscriptionColumns))
+
+	_, err = repo.DeleteReturning(t.Context(), 4)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestSubscriptionsRepo_Summary_SQL(t *testing.T) {
+	from := models.MonthDate{Time: month(2025, time.February)}
+	to := models.MonthDate{Time: month(2025, time.March)}
+	userID := uuid.NewString()
+	service := "Netflix"
+	minPrice := 1
+
+	const base = "SELECT price, currency, start_date, end_date FROM subscriptions WHERE end_date_eff >= $1 AND start_date <= $2"
+
+	tests := []struct {
+		name string
+		req  *models.SummaryRequest
+		sql  string
+		args []any
+	}{
+		{
+			name: "no filters",
+			req:  &models.SummaryRequest{From: from, To: to},
+			sql:  base,
+			args: []any{from.Time, to.Time},
+		},
+		{
+			name: "user filter",
+			req:  &models.SummaryRequest{From: from, To: to, UserID: &userID},
+			sql:  base + " AND user_id = $3",
+			args: []any{from.Time, to.Time, userID},
+		},
+		{
+			name: "user and service filters",
+			req:  &models.SummaryRequest{From: from, To: to, UserID: &userID, ServiceName: &service},
+			sql:  base + " AND user_id = $3 AND service_name = $4",
+			args: []any{from.Time, to.Time, userID, service},
+		},
+		{
+			name: "exclude trials and free tiers",
+			req:  &models.SummaryRequest{From: from, To: to, ExcludeTrials: true, MinPrice: &minPrice},
+			sql:  base + " AND trial = $3 AND price >= $4",
+			args: []any{from.Time, to.Time, false, minPrice},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepo(t)
+
+			end := month(2025, time.March)
+			mock.ExpectQuery(tt.sql).
+				WithArgs(tt.args...).
+				WillReturnRows(pgxmock.NewRows([]string{"price", "currency", "start_date", "end_date"}).
+					AddRow(20, "RUB", month(2025, time.January), &end).
+					AddRow(15, "RUB", month(2025, time.February), (*time.Time)(nil)))
+
+			sum, err := repo.Summary(t.Context(), tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, models.Money{Amount: (20 + 15) * 100, Currency: "RUB"}, sum.Total)
+			assert.Equal(t, 2, sum.Count)
+			assert.Equal(t, from, sum.From)
+			assert.Equal(t, to, sum.To)
+		})
+	}
+}
+
+func TestSubscriptionsRepo_ExplainSummary_SQL(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	from := models.MonthDate{Time: month(2025, time.February)}
+	to := models.MonthDate{Time: month(2025, time.April)}
+	userID := uuid.New()
+
+	end := month(2025, time.March)
+	mock.ExpectQuery("SELECT id, service_name, user_id, price, currency, start_date, end_date FROM subscriptions "+
+		"WHERE end_date_eff >= $1 AND start_date <= $2 ORDER BY id ASC").
+		WithArgs(from.Time, to.Time).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "service_name", "user_id", "price", "currency", "start_date", "end_date"}).
+			AddRow(int64(1), "Netflix", userID, 20, "RUB", month(2025, time.January), &end).
+			AddRow(int64(2), "Spotify", userID, 15, "RUB", month(2025, time.March), (*time.Time)(nil)))
+
+	sum, err := repo.ExplainSummary(t.Context(), &models.SummaryRequest{From: from, To: to})
+	require.NoError(t, err)
+	// Default boundaries count 30-day spans: February 1 to March 1 is one month.
+	assert.Equal(t, int64(20*1+15*2), sum.Total.Major())
+	assert.Equal(t, 2, sum.Count)
+	assert.Equal(t, []models.SummaryLine{
+		{ID: 1, ServiceName: "Netflix", UserID: userID, Price: 20, Currency: "RUB", From: from, To: models.MonthDate{Time: end}, Months: 1, Amount: models.Money{Amount: 2000, Currency: "RUB"}},
+		{ID: 2, ServiceName: "Spotify", UserID: userID, Price: 15, Currency: "RUB", From: models.MonthDate{Time: month(2025, time.March)}, To: to, Months: 2, Amount: models.Money{Amount: 3000, Currency: "RUB"}},
+	}, sum.Lines)
+}
+
+func TestSubscriptionsRepo_Summary_CalendarMonth(t *testing.T) {
+	mock := newMockPool(t)
+	repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry(),
+		repository.WithSummaryBoundaries(repository.BoundariesCalendarMonth))
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	// Requested months may carry days too, e.g. when called from code.
+	req := &models.SummaryRequest{
+		From: models.MonthDate{Time: day(time.February, 10)},
+		To:   models.MonthDate{Time: day(time.March, 20)},
+	}
+
+	endsFeb28 := day(time.February, 28)
+	endsJan31 := day(time.January, 31)
+	mock.ExpectQuery("SELECT price, currency, start_date, end_date FROM subscriptions WHERE end_date_eff >= $1 AND start_date < $2").
+		WithArgs(day(time.February, 1), day(time.April, 1)).
+		WillReturnRows(pgxmock.NewRows([]string{"price", "currency", "start_date", "end_date"}).
+			AddRow(100, "RUB", day(time.March, 31), (*time.Time)(nil)).  // starts on the last day of To: March
+			AddRow(10, "RUB", day(time.January, 15), &endsFeb28).        // ends on the last day of February: February
+			AddRow(1, "RUB", day(time.February, 15), (*time.Time)(nil)). // February and March
+			AddRow(1000, "RUB", day(time.January, 1), &endsJan31))       // before the range, if returned
+
+	sum, err := repo.Summary(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100+10+2), sum.Total.Major())
+	assert.Equal(t, 3, sum.Count, "the subscription before the range is not counted")
+	assert.Equal(t, day(time.February, 1), sum.From.Time, "the period is moved to month starts")
+	assert.Equal(t, day(time.March, 1), sum.To.Time)
+}
+
+func TestSubscriptionsRepo_SummaryBreakdown_SQL(t *testing.T) {
+	from := models.MonthDate{Time: month(2025, time.February)}
+	to := models.MonthDate{Time: month(2025, time.April)}
+	const query = "SELECT CASE WHEN rn <= $1 THEN 0 WHEN rn <= $2 THEN rn END AS bucket, " +
+		"MIN(key), SUM(amount)::bigint, SUM(count)::bigint, COUNT(*), MAX(total_groups), MIN(min_currency), MAX(max_currency) " +
+		"FROM (SELECT *, ROW_NUMBER() OVER (ORDER BY amount DESC, key) AS rn, COUNT(*) OVER () AS total_groups, " +
+		"MIN(currency) OVER () AS min_currency, MAX(currency) OVER () AS max_currency " +
+		"FROM (SELECT key, currency, SUM(price::bigint * months) AS amount, COUNT(*) AS count " +
+		"FROM (SELECT service_name AS key, currency, price, " +
+		"GREATEST((LEAST(end_date_eff, $3::date) - GREATEST(start_date, $4::date) + 30) / 30, 0) AS months " +
+		"FROM subscriptions WHERE end_date_eff >= $5 AND start_date <= $6) AS c " +
+		"WHERE months > 0 GROUP BY key, currency) AS g) AS r GROUP BY bucket ORDER BY MIN(rn)"
+	columns := []string{"bucket", "key", "amount", "count", "groups", "total_groups", "min_currency", "max_currency"}
+	req := func() *models.SummaryRequest {
+		return &models.SummaryRequest{From: from, To: to, GroupBy: models.GroupByService, Limit: 2, Offset: 1}
+	}
+	bucket := func(n int64) *int64 { return &n }
+
+	t.Run("page and remainder", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery(query).
+			WithArgs(1, 3, to.Time, from.Time, from.Time, to.Time).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(bucket(0), "Netflix", int64(900), int64(30), 1, 6, models.Currency("RUB"), models.Currency("RUB")).
+				AddRow(bucket(2), "Spotify", int64(500), int64(20), 1, 6, models.Currency("RUB"), models.Currency("RUB")).
+				AddRow(bucket(3), "Hulu", int64(300), int64(10), 1, 6, models.Currency("RUB"), models.Currency("RUB")).
+				AddRow((*int64)(nil), "Apple", int64(150), int64(7), 3, 6, models.Currency("RUB"), models.Currency("RUB")))
+
+		got, err := repo.SummaryBreakdown(t.Context(), req())
+		require.NoError(t, err)
+		assert.Equal(t, models.SummaryBreakdown{
+			GroupBy: models.GroupByService,
+			Groups: []models.SummaryGroup{
+				{Key: "Spotify", Amount: models.Money{Amount: 50000, Currency: "RUB"}, Count: 20},
+				{Key: "Hulu", Amount: models.Money{Amount: 30000, Currency: "RUB"}, Count: 10},
+			},
+			Other:       &models.SummaryOther{Groups: 3, Amount: models.Money{Amount: 15000, Currency: "RUB"}, Count: 7},
+			TotalGroups: 6,
+			Limit:       2,
+			Offset:      1,
+		}, got)
+	})
+
+	t.Run("mixed currencies", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectQuery(query).
+			WithArgs(1, 3, to.Time, from.Time, from.Time, to.Time).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(bucket(2), "Spotify", int64(500), int64(20), 1, 2, models.Currency("EUR"), models.Currency("RUB")))
+
+		_, err := repo.SummaryBreakdown(t.Context(), req())
+		assert.ErrorIs(t, err, models.ErrCurrencyMismatch)
+	})
+
+	t.Run("unsupported grouping", func(t *testing.T) {
+		repo, _ := newMockRepo(t)
+		r := req()
+		r.GroupBy = "price"
+		_, err := repo.SummaryBreakdown(t.Context(), r)
+		assert.Error(t, err)
+	})
+}
+
+// BenchmarkSubscriptionsRepo_List measures scanning a page of 10k rows. The
+// rows come from a mock, so only the repository's own overhead is measured.
+func BenchmarkSubscriptionsRepo_List(b *testing.B) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(b, err)
+	defer mock.Close()
+	repo := repository.NewSubscriptionsRepo(mock, retry.NoRetry())
+
+	const n = 10000
+	userID := uuid.New()
+	end := month(2026, time.June)
+	values := make([][]any, n)
+	for i := range values {
+		values[i] = []any{int64(i + 1), "Netflix", 799, userID, month(2025, time.July), &end, false, "RUB", ""}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		b.StopTimer()
+		mock.ExpectQuery("SELECT").WillReturnRows(pgxmock.NewRows(subscriptionColumns).AddRows(values...))
+		b.StartTimer()
+
+		subs, err := repo.List(b.Context(), models.SubscriptionFilter{}, n, 0)
+		if err != nil || len(subs) != n {
+			b.Fatalf("List() = %d rows, %v", len(subs), err)
+		}
+	}
+}
@@ -5,25 +5,34 @@ package repository_test
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"log"
 	"os"
 	"testing"
 	"time"
 
 	"subscriptionsservice/internal/database"
+	"subscriptionsservice/internal/encryption"
 	"subscriptionsservice/internal/models"
 	"subscriptionsservice/internal/repository"
 	"subscriptionsservice/internal/retry"
+	"subscriptionsservice/internal/tenant"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	tc "github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-var db *pgxpool.Pool
+var (
+	db  *pgxpool.Pool
+	dsn string
+)
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -41,7 +50,7 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 
-	dsn, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	dsn, err = pgContainer.ConnectionString(ctx, "sslmode=disable")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -90,6 +99,30 @@ func TestSubscriptionsRepo_CRUD(t *testing.T) {
 		assert.Equal(t, subs.ServiceName, got.ServiceName)
 	})
 
+	t.Run("Duplicate key", func(t *testing.T) {
+		// savepoint keeps tx usable after the unique violation
+		err := repository.NewTxManager(db).Do(t.Context(), func(ctx context.Context, sp pgx.Tx) error {
+			dup := *subs
+			return repo.CreateSubscription(ctx, &dup, repository.WithTx(sp))
+		}, repository.WithTx(tx))
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		got, err := repo.GetByKey(t.Context(), subs.UserID, subs.ServiceName, subs.StartDate, repository.WithTx(tx))
+		assert.NoError(t, err)
+		assert.Equal(t, subs.ID, got.ID)
+	})
+
+	t.Run("Duplicate key in the transaction", func(t *testing.T) {
+		// no savepoint needed: the conflicting row is skipped, not an error
+		dup := *subs
+		err := repo.CreateSubscription(t.Context(), &dup, repository.WithTx(tx))
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+		got, err := repo.GetByKey(t.Context(), subs.UserID, subs.ServiceName, subs.StartDate, repository.WithTx(tx))
+		assert.NoError(t, err)
+		assert.Equal(t, subs.ID, got.ID)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		subs.Price = 20
 		err := repo.Update(t.Context(), subs, repository.WithTx(tx))
@@ -110,7 +143,7 @@ func TestSubscriptionsRepo_CRUD(t *testing.T) {
 		}
 		assert.NoError(t, repo.CreateSubscription(t.Context(), another, repository.WithTx(tx)))
 
-		all, err := repo.List(t.Context(), 10, 0, repository.WithTx(tx))
+		all, err := repo.List(t.Context(), models.SubscriptionFilter{}, 10, 0, repository.WithTx(tx))
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, len(all), 2)
 	})
@@ -223,6 +256,106 @@ func TestSubscriptionsRepo_Summary(t *testing.T) {
 	}
 }
 
+func TestSubscriptionsRepo_Summary_MonthBoundaries(t *testing.T) {
+	tx, err := db.Begin(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback(t.Context())
+
+	day := func(m time.Month, d int) models.MonthDate {
+		return models.MonthDate{Time: time.Date(2025, m, d, 0, 0, 0, 0, time.UTC)}
+	}
+	endFeb := day(time.February, 28)
+	user := uuid.New()
+	subs := []*models.Subscription{
+		{ServiceName: "Starts on the last day of To", Price: 100, UserID: user, StartDate: day(time.March, 31)},
+		{ServiceName: "Ends on the last day of From", Price: 10, UserID: user, StartDate: day(time.January, 15), EndDate: &endFeb},
+		{ServiceName: "Starts mid-From", Price: 1, UserID: user, StartDate: day(time.February, 15)},
+	}
+	legacy := repository.NewSubscriptionsRepo(db, retry.NoRetry())
+	for _, s := range subs {
+		require.NoError(t, legacy.CreateSubscription(t.Context(), s, repository.WithTx(tx)))
+	}
+
+	userID := user.String()
+	req := &models.SummaryRequest{From: day(time.February, 1), To: day(time.March, 1), UserID: &userID}
+
+	calendar := repository.NewSubscriptionsRepo(db, retry.NoRetry(),
+		repository.WithSummaryBoundaries(repository.BoundariesCalendarMonth))
+	sum, err := calendar.Summary(t.Context(), req, repository.WithTx(tx))
+	require.NoError(t, err)
+	assert.Equal(t, 100+10+2, sum)
+
+	// Legacy semantics leave out the subscription starting after March 1st.
+	sum, err = legacy.Summary(t.Context(), req, repository.WithTx(tx))
+	require.NoError(t, err)
+	assert.NotEqual(t, 100+10+2, sum)
+}
+
+// TestSubscriptionsRepo_SummaryBreakdown checks that the months counted in
+// SQL by the breakdown agree with Summary for both boundary semantics.
+func TestSubscriptionsRepo_SummaryBreakdown(t *testing.T) {
+	tx, err := db.Begin(t.Context())
+	require.NoError(t, err)
+	defer tx.Rollback(t.Context())
+
+	day := func(m time.Month, d int) models.MonthDate {
+		return models.MonthDate{Time: time.Date(2025, m, d, 0, 0, 0, 0, time.UTC)}
+	}
+	endFeb := day(time.February, 28)
+	user := uuid.New()
+	subs := []*models.Subscription{
+		{ServiceName: "A", Price: 100, UserID: user, StartDate: day(time.March, 31)},
+		{ServiceName: "B", Price: 10, UserID: user, StartDate: day(time.January, 15), EndDate: &endFeb},
+		{ServiceName: "B", Price: 1, UserID: user, StartDate: day(time.February, 15)},
+		{ServiceName: "C", Price: 7, UserID: user, StartDate: day(time.January, 1)},
+		{ServiceName: "D", Price: 3, UserID: user, StartDate: day(time.February, 1)},
+	}
+	legacy := repository.NewSubscriptionsRepo(db, retry.NoRetry())
+	for _, s := range subs {
+		require.NoError(t, legacy.CreateSubscription(t.Context(), s, repository.WithTx(tx)))
+	}
+	calendar := repository.NewSubscriptionsRepo(db, retry.NoRetry(),
+		repository.WithSummaryBoundaries(repository.BoundariesCalendarMonth))
+
+	userID := user.String()
+	for name, repo := range map[string]*repository.SubscriptionsRepo{"legacy": legacy, "calendar": calendar} {
+		t.Run(name, func(t *testing.T) {
+			req := &models.SummaryRequest{
+				From: day(time.February, 1), To: day(time.March, 1), UserID: &userID,
+				GroupBy: models.GroupByService, Limit: 2,
+			}
+			b, err := repo.SummaryBreakdown(t.Context(), req, repository.WithTx(tx))
+			require.NoError(t, err)
+			require.Len(t, b.Groups, 2)
+
+			total := models.Zero(models.DefaultCurrency)
+			count := 0
+			for _, g := range b.Groups {
+				service := g.Key
+				sum, err := repo.Summary(t.Context(), &models.SummaryRequest{
+					From: req.From, To: req.To, UserID: &userID, ServiceName: &service,
+				}, repository.WithTx(tx))
+				require.NoError(t, err)
+				assert.Equal(t, sum.Total, g.Amount, g.Key)
+				assert.Equal(t, sum.Count, g.Count, g.Key)
+				total, err = total.Add(g.Amount)
+				require.NoError(t, err)
+				count += g.Count
+			}
+			if b.Other != nil {
+				total, err = total.Add(b.Other.Amount)
+				require.NoError(t, err)
+				count += b.Other.Count
+			}
+
+			sum, err := repo.Summary(t.Context(), &models.SummaryRequest{From: req.From, To: req.To, UserID: &userID}, repository.WithTx(tx))
+			require.NoError(t, err)
+			assert.Equal(t, sum.Total, total, "the page and the remainder add up to the total")
+			assert.Equal(t, sum.Count, count)
+		})
+	}
+}
+
 func ptrString(s string) *string { return &s }
 func ptrUUIDToString(u *uuid.UUID) *string {
 	if u == nil {
@@ -231,3 +364,211 @@ func ptrUUIDToString(u *uuid.UUID) *string {
 	s := u.String()
 	return &s
 }
+
+func TestSubscriptionsRepo_Overlaps(t *testing.T) {
+	repo := repository.NewSubscriptionsRepo(db, retry.NoRetry())
+
+	tx, err := db.Begin(t.Context())
+	assert.NoError(t, err)
+	defer tx.Rollback(t.Context())
+
+	userID := uuid.New()
+	month := func(year int, m time.Month) models.MonthDate {
+		return models.MonthDate{Time: time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	end := func(year int, m time.Month) *models.MonthDate {
+		md := month(year, m)
+		return &md
+	}
+
+	// Netflix: A (01-12.2025) contains B (03-04.2025) and C (06.2025-);
+	// B and C do not overlap. Spotify subscriptions follow each other.
+	subs := []*models.Subscription{
+		{ServiceName: "Netflix", Price: 10, UserID: userID, StartDate: month(2025, time.January), EndDate: end(2025, time.December)},
+		{ServiceName: "Netflix", Price: 10, UserID: userID, StartDate: month(2025, time.March), EndDate: end(2025, time.April)},
+		{ServiceName: "Netflix", Price: 10, UserID: userID, StartDate: month(2025, time.June)},
+		{ServiceName: "Spotify", Price: 5, UserID: userID, StartDate: month(2025, time.January), EndDate: end(2025, time.June)},
+		{ServiceName: "Spotify", Price: 5, UserID: userID, StartDate: month(2025, time.July)},
+	}
+	for _, s := range subs {
+		assert.NoError(t, repo.CreateSubscription(t.Context(), s, repository.WithTx(tx)))
+	}
+
+	got, err := repo.Overlaps(t.Context(), userID, repository.WithTx(tx))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Overlap{
+		{ServiceName: "Netflix", FirstID: subs[0].ID, SecondID: subs[1].ID, From: month(2025, time.March), To: end(2025, time.April)},
+		{ServiceName: "Netflix", FirstID: subs[0].ID, SecondID: subs[2].ID, From: month(2025, time.June), To: end(2025, time.December)},
+	}, got)
+}
+
+func TestAnalyticsRepo_Retention(t *testing.T) {
+	subsRepo := repository.NewSubscriptionsRepo(db, retry.NoRetry())
+	repo := repository.NewAnalyticsRepo(db, retry.NoRetry())
+
+	tx, err := db.Begin(t.Context())
+	assert.NoError(t, err)
+	defer tx.Rollback(t.Context())
+
+	month := func(year int, m time.Month) models.MonthDate {
+		return models.MonthDate{Time: time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	end := func(year int, m time.Month) *models.MonthDate {
+		md := month(year, m)
+		return &md
+	}
+
+	// January 2001 cohort: ends in January, ends in April, open-ended.
+	userID := uuid.New()
+	for i, endDate := range []*models.MonthDate{end(2001, time.January), end(2001, time.April), nil} {
+		assert.NoError(t, subsRepo.CreateSubscription(t.Context(), &models.Subscription{
+			ServiceName: fmt.Sprintf("Service %d", i),
+			UserID:      userID,
+			StartDate:   month(2001, time.January),
+			EndDate:     endDate,
+		}, repository.WithTx(tx)))
+	}
+
+	// As of August 2001 the 12 months offset is not reached yet.
+	got, err := repo.Retention(t.Context(), month(2001, time.January), month(2001, time.December),
+		time.Date(2001, time.August, 15, 0, 0, 0, 0, time.UTC), repository.WithTx(tx))
+	assert.NoError(t, err)
+
+	two, one := 2, 1
+	assert.Equal(t, []models.RetentionCohort{{
+		Cohort: month(2001, time.January),
+		Size:   3,
+		Month1: &two,
+		Month3: &two,
+		Month6: &one,
+	}}, got)
+}
+
+func TestSubscriptionsRepo_TenantSchemas(t *testing.T) {
+	const migrations = "../../../migrations"
+	for _, schema := range []string{"tenant_a", "tenant_b"} {
+		require.NoError(t, database.MigrateSchema(t.Context(), db, migrations, dsn, schema))
+		// Migrating again applies nothing.
+		require.NoError(t, database.MigrateSchema(t.Context(), db, migrations, dsn, schema))
+	}
+	latest, err := database.LatestMigration(migrations)
+	require.NoError(t, err)
+	version, dirty, err := database.SchemaMigrationVersion(t.Context(), db, "tenant_a")
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	assert.False(t, dirty)
+
+	pool, err := database.Connect(t.Context(), dsn, database.WithSearchPath(tenant.SchemaFromContext))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	repo := repository.NewSubscriptionsRepo(pool, retry.NoRetry())
+
+	ctxA := tenant.WithTenant(t.Context(), "a")
+	ctxB := tenant.WithTenant(t.Context(), "b")
+	subs := &models.Subscription{
+		ServiceName: "Tenant Service",
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   models.MonthDate{Time: time.Now()},
+	}
+	require.NoError(t, repo.CreateSubscription(ctxA, subs))
+
+	_, err = repo.GetByID(ctxA, subs.ID)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(ctxB, subs.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "other tenants do not see it")
+	_, err = repo.GetByID(t.Context(), subs.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "requests without a tenant use the default schema")
+
+	// Transactions run in the schema of the context they are begun with.
+	tx, err := pool.Begin(ctxB)
+	require.NoError(t, err)
+	defer tx.Rollback(t.Context())
+	_, err = repo.GetByID(t.Context(), subs.ID, repository.WithTx(tx))
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = repo.GetByID(tenant.WithTenant(t.Context(), "missing"), subs.ID)
+	assert.Error(t, err, "a tenant without a schema does not fall back to the default one")
+}
+
+func TestUserRepo_ProvisionedUsers(t *testing.T) {
+	users := repository.NewUserRepo(db, retry.NoRetry())
+	provisioned := repository.NewSubscriptionsRepo(db, retry.NoRetry(), repository.WithProvisionedUsers())
+
+	subs := &models.Subscription{
+		ServiceName: "Provisioned Service",
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   models.MonthDate{Time: time.Now()},
+	}
+	err := provisioned.CreateSubscription(t.Context(), subs)
+	assert.ErrorIs(t, err, repository.ErrForeignKeyViolation, "a mistyped user is rejected")
+
+	require.NoError(t, users.Create(t.Context(), &models.User{ID: subs.UserID}))
+	require.NoError(t, provisioned.CreateSubscription(t.Context(), subs))
+	assert.ErrorIs(t, users.Delete(t.Context(), subs.UserID), repository.ErrForeignKeyViolation)
+
+	// By default, writes register unknown users.
+	implicit := repository.NewSubscriptionsRepo(db, retry.NoRetry())
+	subs.UserID = uuid.New()
+	require.NoError(t, implicit.Update(t.Context(), subs))
+	u, err := users.GetByID(t.Context(), subs.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, subs.UserID, u.ID)
+}
+
+func TestWebhookKeyRepo_Rotate(t *testing.T) {
+	repo := repository.NewWebhookKeyRepo(db, retry.NoRetry())
+	now := time.Now().UTC().Truncate(time.Second)
+	newKey := func(created time.Time) *models.WebhookKey {
+		return &models.WebhookKey{ID: uuid.NewString(), PublicKey: []byte{1}, PrivateKey: []byte{2}, CreatedAt: created}
+	}
+
+	first, second := newKey(now.Add(-2*time.Hour)), newKey(now.Add(-time.Hour))
+	require.NoError(t, repo.Rotate(t.Context(), first, now.Add(-24*time.Hour)))
+	require.NoError(t, repo.Rotate(t.Context(), second, now.Add(-24*time.Hour)))
+
+	keys, err := repo.List(t.Context(), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(keys), 2)
+	assert.Equal(t, second.ID, keys[0].ID)
+	assert.Nil(t, keys[0].RetiredAt)
+	assert.Equal(t, first.ID, keys[1].ID)
+	require.NotNil(t, keys[1].RetiredAt)
+	assert.True(t, second.CreatedAt.Equal(*keys[1].RetiredAt), "retired when the next key was created")
+
+	third := newKey(now)
+	require.NoError(t, repo.Rotate(t.Context(), third, now.Add(-30*time.Minute)))
+	keys, err = repo.List(t.Context(), time.Time{})
+	require.NoError(t, err)
+	var ids []string
+	for _, k := range keys {
+		ids = append(ids, k.ID)
+	}
+	assert.Equal(t, []string{third.ID, second.ID}, ids[:2])
+	assert.NotContains(t, ids, first.ID, "keys retired before the grace period are pruned")
+}
+
+func TestWebhookKeyRepo_Encryption(t *testing.T) {
+	keyring, err := encryption.New([]string{"test:" + base64.StdEncoding.EncodeToString(make([]byte, encryption.KeySize))}, "test")
+	require.NoError(t, err)
+	plain := repository.NewWebhookKeyRepo(db, retry.NoRetry())
+	encrypted := repository.NewWebhookKeyRepo(db, retry.NoRetry(), repository.WithWebhookKeyCodec(keyring))
+
+	key := &models.WebhookKey{ID: uuid.NewString(), PublicKey: []byte{1}, PrivateKey: []byte("seed"), CreatedAt: time.Now()}
+	require.NoError(t, plain.Rotate(t.Context(), key, time.Now().Add(-24*time.Hour)))
+
+	keys, err := encrypted.List(t.Context(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("seed"), keys[0].PrivateKey, "plain text keys stay readable")
+
+	n, err := encrypted.Reencrypt(t.Context())
+	require.NoError(t, err)
+	assert.Positive(t, n)
+
+	_, err = plain.List(t.Context(), time.Now())
+	assert.ErrorIs(t, err, repository.ErrNoCodec)
+	keys, err = encrypted.List(t.Context(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("seed"), keys[0].PrivateKey)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SummarySubscriptionRepo stores the scheduled summaries users subscribed
+// to.
+type SummarySubscriptionRepo struct {
+	base
+}
+
+// NewSummarySubscriptionRepo initializes SummarySubscriptionRepo.
+// db is usually a *pgxpool.Pool.
+func NewSummarySubscriptionRepo(db Executer, r retry.Retrier) *SummarySubscriptionRepo {
+	return &SummarySubscriptionRepo{base: newBase(db, r)}
+}
+
+var summarySubscriptionColumns = []string{
+	"id", "user_id", "period", "filter", "channel", "recipient", "next_run_at", "last_sent_at", "last_error", "created_at", "confirmed_at",
+}
+
+// Create inserts an unconfirmed summary subscription with the hash of its
+// confirmation token and fills its ID and CreatedAt.
+func (r *SummarySubscriptionRepo) Create(ctx context.Context, s *models.SummarySubscription, confirmationHash []byte, opts ...Option) error {
+	query := r.psql.Insert("summary_subscriptions").
+		Columns("user_id", "period", "filter", "channel", "recipient", "next_run_at", "confirmation_hash").
+		Values(s.UserID, s.Period, s.Filter, s.Channel, s.Recipient, s.NextRunAt, confirmationHash).
+		Suffix("RETURNING id, created_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&s.ID, &s.CreatedAt}, opts...)
+}
+
+// Confirm marks the unconfirmed summary subscription with the hash of a
+// confirmation token confirmed at at and returns it; the token can not be
+// used again. It returns ErrNotFound if no subscription awaits the token.
+func (r *SummarySubscriptionRepo) Confirm(ctx context.Context, confirmationHash []byte, at time.Time, opts ...Option) (*models.SummarySubscription, error) {
+	query := r.psql.Update("summary_subscriptions").
+		Set("confirmed_at", at).
+		Set("confirmation_hash", nil).
+		Where(sq.Eq{"confirmation_hash": confirmationHash, "confirmed_at": nil}).
+		Suffix("RETURNING " + strings.Join(summarySubscriptionColumns, ", "))
+
+	var s models.SummarySubscription
+	if err := scanReturning(ctx, &r.base, query, summarySubscriptionFields(&s), opts...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListByUser returns the summary subscriptions of a user ordered by ID.
+func (r *SummarySubscriptionRepo) ListByUser(ctx context.Context, userID uuid.UUID, opts ...Option) ([]models.SummarySubscription, error) {
+	query := r.psql.Select(summarySubscriptionColumns...).From("summary_subscriptions").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("id ASC")
+
+	return selectMany(ctx, &r.base, query, scanSummarySubscription, opts...)
+}
+
+// Delete removes a summary subscription of a user. It returns ErrNotFound
+// if the user has no such subscription.
+func (r *SummarySubscriptionRepo) Delete(ctx context.Context, userID uuid.UUID, id int64, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Delete("summary_subscriptions").Where(sq.Eq{"id": id, "user_id": userID}), opts...)
+}
+
+// Due returns up to limit confirmed summary subscriptions due at now,
+// longest overdue first.
+func (r *SummarySubscriptionRepo) Due(ctx context.Context, now time.Time, limit int, opts ...Option) ([]models.SummarySubscription, error) {
+	query := r.psql.Select(summarySubscriptionColumns...).From("summary_subscriptions").
+		Where(sq.LtOrEq{"next_run_at": now}).
+		Where(sq.NotEq{"confirmed_at": nil}).
+		OrderBy("next_run_at ASC", "id ASC").
+		Limit(uint64(limit))
+
+	return selectMany(ctx, &r.base, query, scanSummarySubscription, opts...)
+}
+
+// MarkSent records a summary sent at sentAt and schedules the next one at
+// next.
+func (r *SummarySubscriptionRepo) MarkSent(ctx context.Context, id int64, sentAt, next time.Time, opts ...Option) error {
+	query := r.psql.Update("summary_subscriptions").
+		Set("last_sent_at", sentAt).
+		Set("next_run_at", next).
+		Set("last_error", "").
+		Where(sq.Eq{"id": id})
+
+	return execOne(ctx, &r.base, query, opts...)
+}
+
+// MarkFailed records why a summary could not be sent. Its next_run_at is
+// kept, so it is retried.
+func (r *SummarySubscriptionRepo) MarkFailed(ctx context.Context, id int64, reason string, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Update("summary_subscriptions").Set("last_error", reason).Where(sq.Eq{"id": id}), opts...)
+}
+
+func scanSummarySubscription(row pgx.Row) (models.SummarySubscription, error) {
+	var s models.SummarySubscription
+	err := row.Scan(summarySubscriptionFields(&s)...)
+	return s, err
+}
+
+// summarySubscriptionFields returns the destinations of
+// summarySubscriptionColumns in s.
+func summarySubscriptionFields(s *models.SummarySubscription) []any {
+	return []any{&s.ID, &s.UserID, &s.Period, &s.Filter, &s.Channel, &s.Recipient, &s.NextRunAt, &s.LastSentAt, &s.LastError, &s.CreatedAt, &s.ConfirmedAt}
+}
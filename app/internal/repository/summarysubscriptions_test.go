@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarySubscriptionRepo_SQL(t *testing.T) {
+	userID := uuid.New()
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	next := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "period", "filter", "channel", "recipient", "next_run_at", "last_sent_at", "last_error", "created_at", "confirmed_at"}
+	hash := []byte("hash")
+
+	t.Run("create", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO summary_subscriptions (user_id,period,filter,channel,recipient,next_run_at,confirmation_hash) "+
+			"VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id, created_at").
+			WithArgs(userID, models.PeriodMonth, models.FilterCriteria{}, "email", "anna@example.com", next, hash).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(5), now))
+
+		s := &models.SummarySubscription{UserID: userID, Period: models.PeriodMonth, Channel: "email", Recipient: "anna@example.com", NextRunAt: next}
+		require.NoError(t, repo.Create(t.Context(), s, hash))
+		assert.EqualValues(t, 5, s.ID)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("UPDATE summary_subscriptions SET confirmed_at = $1, confirmation_hash = $2 "+
+			"WHERE confirmation_hash = $3 AND confirmed_at IS NULL "+
+			"RETURNING id, user_id, period, filter, channel, recipient, next_run_at, last_sent_at, last_error, created_at, confirmed_at").
+			WithArgs(now, nil, hash).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(int64(5), userID, models.PeriodMonth, models.FilterCriteria{}, "email", "anna@example.com", next, (*time.Time)(nil), "", now, &now))
+
+		s, err := repo.Confirm(t.Context(), hash, now)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, s.ID)
+		assert.Equal(t, &now, s.ConfirmedAt)
+	})
+
+	t.Run("confirm with a used token", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("UPDATE summary_subscriptions SET confirmed_at = $1, confirmation_hash = $2 "+
+			"WHERE confirmation_hash = $3 AND confirmed_at IS NULL "+
+			"RETURNING id, user_id, period, filter, channel, recipient, next_run_at, last_sent_at, last_error, created_at, confirmed_at").
+			WithArgs(now, nil, hash).
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		_, err := repo.Confirm(t.Context(), hash, now)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("due", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, user_id, period, filter, channel, recipient, next_run_at, last_sent_at, last_error, created_at, confirmed_at " +
+			"FROM summary_subscriptions WHERE next_run_at <= $1 AND confirmed_at IS NOT NULL ORDER BY next_run_at ASC, id ASC LIMIT 100").
+			WithArgs(now).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(int64(5), userID, models.PeriodMonth, models.FilterCriteria{}, "email", "anna@example.com", next, (*time.Time)(nil), "", now, &now))
+
+		due, err := repo.Due(t.Context(), now, 100)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, "anna@example.com", due[0].Recipient)
+	})
+
+	t.Run("mark sent", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("UPDATE summary_subscriptions SET last_sent_at = $1, next_run_at = $2, last_error = $3 WHERE id = $4").
+			WithArgs(now, next, "", int64(5)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		require.NoError(t, repo.MarkSent(t.Context(), 5, now, next))
+	})
+
+	t.Run("delete subscription of another user", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewSummarySubscriptionRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("DELETE FROM summary_subscriptions WHERE id = $1 AND user_id = $2").
+			WithArgs(int64(5), userID.String()).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		assert.ErrorIs(t, repo.Delete(t.Context(), userID, 5), repository.ErrNotFound)
+	})
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// TenantRepo stores the tenant registry and tenant API keys. The tables live
+// in the default schema, so it must be used without a tenant in the context
+// when the pool routes tenants to their schemas.
+type TenantRepo struct {
+	base
+}
+
+// NewTenantRepo initializes TenantRepo.
+// db is usually a *pgxpool.Pool.
+func NewTenantRepo(db Executer, r retry.Retrier) *TenantRepo {
+	return &TenantRepo{base: newBase(db, r)}
+}
+
+var (
+	tenantColumns = []string{"id", "name", "limits", "created_at", "updated_at"}
+	apiKeyColumns = []string{"id", "tenant_id", "prefix", "created_at", "revoked_at"}
+)
+
+// Create inserts a tenant and fills its CreatedAt and UpdatedAt. It returns
+// ErrDuplicate if the ID is taken.
+func (r *TenantRepo) Create(ctx context.Context, t *models.Tenant, opts ...Option) error {
+	limits, err := json.Marshal(t.Limits)
+	if err != nil {
+		return fmt.Errorf("encode tenant limits: %w", err)
+	}
+
+	query := r.psql.Insert("tenants").
+		Columns("id", "name", "limits").
+		Values(t.ID, t.Name, string(limits)).
+		Suffix("RETURNING created_at, updated_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&t.CreatedAt, &t.UpdatedAt}, opts...)
+}
+
+// GetByID retrieves a tenant by ID.
+func (r *TenantRepo) GetByID(ctx context.Context, id string, opts ...Option) (*models.Tenant, error) {
+	t, err := selectOne(ctx, &r.base, r.psql.Select(tenantColumns...).From("tenants").Where(sq.Eq{"id": id}), scanTenant, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns tenants ordered by ID.
+func (r *TenantRepo) List(ctx context.Context, limit, offset int, opts ...Option) ([]models.Tenant, error) {
+	query := r.psql.Select(tenantColumns...).From("tenants").OrderBy("id ASC")
+	if limit > 0 {
+		query = query.Limit(uint64(limit)).Offset(uint64(offset))
+	}
+
+	return selectMany(ctx, &r.base, query, scanTenant, opts...)
+}
+
+// Update replaces the name and limits of a tenant and fills its CreatedAt
+// and UpdatedAt. It returns ErrNotFound if the tenant does not exist.
+func (r *TenantRepo) Update(ctx context.Context, t *models.Tenant, opts ...Option) error {
+	limits, err := json.Marshal(t.Limits)
+	if err != nil {
+		return fmt.Errorf("encode tenant limits: %w", err)
+	}
+
+	query := r.psql.Update("tenants").
+		Set("name", t.Name).
+		Set("limits", string(limits)).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": t.ID}).
+		Suffix("RETURNING created_at, updated_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&t.CreatedAt, &t.UpdatedAt}, opts...)
+}
+
+// Delete removes a tenant with its API keys. It returns ErrNotFound if the
+// tenant does not exist.
+func (r *TenantRepo) Delete(ctx context.Context, id string, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Delete("tenants").Where(sq.Eq{"id": id}), opts...)
+}
+
+// CreateAPIKey stores the hash of a new key of k.TenantID and fills the ID
+// and CreatedAt of k. It returns ErrForeignKeyViolation if the tenant does
+// not exist.
+func (r *TenantRepo) CreateAPIKey(ctx context.Context, k *models.APIKey, hash []byte, opts ...Option) error {
+	query := r.psql.Insert("tenant_api_keys").
+		Columns("tenant_id", "key_hash", "prefix").
+		Values(k.TenantID, hash, k.Prefix).
+		Suffix("RETURNING id, created_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&k.ID, &k.CreatedAt}, opts...)
+}
+
+// ListAPIKeys returns the API keys of a tenant ordered by ID, revoked ones
+// included.
+func (r *TenantRepo) ListAPIKeys(ctx context.Context, tenantID string, opts ...Option) ([]models.APIKey, error) {
+	query := r.psql.Select(apiKeyColumns...).From("tenant_api_keys").
+		Where(sq.Eq{"tenant_id": tenantID}).
+		OrderBy("id ASC")
+
+	return selectMany(ctx, &r.base, query, scanAPIKey, opts...)
+}
+
+// RevokeAPIKey marks an API key of a tenant revoked at at. It returns
+// ErrNotFound if the tenant has no such key or it is already revoked.
+func (r *TenantRepo) RevokeAPIKey(ctx context.Context, tenantID string, id int64, at time.Time, opts ...Option) error {
+	query := r.psql.Update("tenant_api_keys").
+		Set("revoked_at", at.UTC()).
+		Where(sq.Eq{"id": id, "tenant_id": tenantID, "revoked_at": nil})
+
+	return execOne(ctx, &r.base, query, opts...)
+}
+
+// TenantByAPIKey returns the tenant ID of the not revoked key with hash.
+// It returns ErrNotFound if there is none.
+func (r *TenantRepo) TenantByAPIKey(ctx context.Context, hash []byte, opts ...Option) (string, error) {
+	query := r.psql.Select("tenant_id").From("tenant_api_keys").
+		// Not sq.Eq: it would expand the []byte into an IN list.
+		Where("key_hash = ?", hash).
+		Where(sq.Eq{"revoked_at": nil})
+
+	return selectOne(ctx, &r.base, query, func(row pgx.Row) (string, error) {
+		var tenantID string
+		err := row.Scan(&tenantID)
+		return tenantID, err
+	}, opts...)
+}
+
+func scanTenant(row pgx.Row) (models.Tenant, error) {
+	var (
+		t      models.Tenant
+		limits []byte
+	)
+	if err := row.Scan(&t.ID, &t.Name, &limits, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(limits, &t.Limits); err != nil {
+		return t, fmt.Errorf("decode limits of tenant %s: %w", t.ID, err)
+	}
+	return t, nil
+}
+
+func scanAPIKey(row pgx.Row) (models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(&k.ID, &k.TenantID, &k.Prefix, &k.CreatedAt, &k.RevokedAt)
+	return k, err
+}
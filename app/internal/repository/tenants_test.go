@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepo_SQL(t *testing.T) {
+	created := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("create", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewTenantRepo(mock, retry.NoRetry())
+
+		limit := 5
+		tenant := &models.Tenant{ID: "billing", Name: "Billing", Limits: models.TenantLimits{MaxActivePerUser: &limit}}
+		mock.ExpectQuery("INSERT INTO tenants (id,name,limits) VALUES ($1,$2,$3) RETURNING created_at, updated_at").
+			WithArgs("billing", "Billing", `{"max_active_per_user":5}`).
+			WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}).AddRow(created, created))
+
+		require.NoError(t, repo.Create(t.Context(), tenant))
+		assert.Equal(t, created, tenant.CreatedAt)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewTenantRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, name, limits, created_at, updated_at FROM tenants WHERE id = $1").
+			WithArgs("billing").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name", "limits", "created_at", "updated_at"}).
+				AddRow("billing", "Billing", []byte(`{"writes_per_user_per_hour":0}`), created, created))
+
+		got, err := repo.GetByID(t.Context(), "billing")
+		require.NoError(t, err)
+		require.NotNil(t, got.Limits.WritesPerUserPerHour)
+		assert.Zero(t, *got.Limits.WritesPerUserPerHour)
+		assert.Nil(t, got.Limits.MaxActivePerUser)
+	})
+
+	t.Run("tenant by api key", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewTenantRepo(mock, retry.NoRetry())
+
+		hash := []byte{1, 2, 3}
+		mock.ExpectQuery("SELECT tenant_id FROM tenant_api_keys WHERE key_hash = $1 AND revoked_at IS NULL").
+			WithArgs(hash).
+			WillReturnRows(pgxmock.NewRows([]string{"tenant_id"}).AddRow("billing"))
+
+		got, err := repo.TenantByAPIKey(t.Context(), hash)
+		require.NoError(t, err)
+		assert.Equal(t, "billing", got)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewTenantRepo(mock, retry.NoRetry())
+
+		mock.ExpectExec("UPDATE tenant_api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL AND tenant_id = $3").
+			WithArgs(created, int64(7), "billing").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.RevokeAPIKey(t.Context(), "billing", 7, created)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Beginner starts transactions. It is satisfied by *pgxpool.Pool and pgx.Tx
+// (for the latter Begin creates a savepoint).
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// TxFunc is executed inside a transaction. Repository calls made by it
+// should receive WithTx(tx).
+type TxFunc func(ctx context.Context, tx pgx.Tx) error
+
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx. Repository calls and
+// TxManager.Do given the returned context run in tx as if WithTx(tx) was
+// passed, so code between the transaction owner (e.g. a per-request
+// middleware) and the repositories does not have to pass it along.
+func ContextWithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+type commitHooksKey struct{}
+
+// CommitHooks collects functions to run once a transaction owned by a caller
+// of ContextWithCommitHooks commits, see AfterCommit.
+type CommitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// ContextWithCommitHooks returns a copy of ctx collecting the functions
+// passed to AfterCommit in the returned hooks. The owner of the transaction
+// calls Run once it commits and drops the hooks if it rolls back.
+func ContextWithCommitHooks(ctx context.Context) (context.Context, *CommitHooks) {
+	h := &CommitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, h), h
+}
+
+// AfterCommit defers fn, e.g. dropping cached reads or publishing an event
+// about a write, until the transaction of ctx commits, so nothing observes
+// a write that may still be rolled back. Without hooks in ctx
+// (ContextWithCommitHooks) the write is already committed and fn runs now.
+func AfterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	if !ok {
+		fn()
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
+
+// Run runs the collected functions in the order they were added.
+func (h *CommitHooks) Run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// RestartsTx reports whether err leaves the transaction it occurred in
+// unusable, so that retrying the statement is futile and only rerunning the
+// whole transaction can succeed: a serialization failure (ErrSerialization)
+// or a statement in an already failed transaction (ErrTxFailed).
+func RestartsTx(err error) bool {
+	return errors.Is(err, ErrSerialization) || errors.Is(err, ErrTxFailed)
+}
+
+// txRun is the state of a run of a top-level transaction, shared through
+// the context with the code running in it.
+type txRun struct {
+	// restart is the first error that requires rerunning the transaction,
+	// recorded even if the code that got it went on.
+	restart error
+}
+
+type txRunKey struct{}
+
+// markRestart records err in the run of the top-level transaction carried
+// by ctx if err requires rerunning it.
+func markRestart(ctx context.Context, err error) {
+	if !RestartsTx(err) {
+		return
+	}
+	if run, ok := ctx.Value(txRunKey{}).(*txRun); ok && run.restart == nil {
+		run.restart = err
+	}
+}
+
+// TxManager runs functions in transactions, using savepoints for nested calls.
+type TxManager struct {
+	db Beginner
+
+	// serializationAttempts is how many times a top-level transaction is run
+	// when it fails with an error for which RestartsTx is true.
+	serializationAttempts int
+}
+
+// TxManagerOption configures TxManager.
+type TxManagerOption func(*TxManager)
+
+// WithSerializationRetries reruns a top-level transaction failing with
+// ErrSerialization or ErrTxFailed, up to attempts runs in total. fn must then
+// be safe to run again: its database work is rolled back, other side effects
+// are not.
+func WithSerializationRetries(attempts int) TxManagerOption {
+	return func(m *TxManager) {
+		m.serializationAttempts = attempts
+	}
+}
+
+// NewTxManager creates a TxManager that starts top-level transactions on db.
+func NewTxManager(db Beginner, opts ...TxManagerOption) *TxManager {
+	m := &TxManager{db: db, serializationAttempts: 1}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Do runs fn in a new transaction and commits it if fn returns nil.
+// If opts or ctx carry a transaction (WithTx, ContextWithTx), fn runs in a
+// savepoint of that transaction instead: an error rolls back only fn's work
+// and the outer transaction stays usable.
+//
+// Errors for which RestartsTx is true are different: rolling back to a
+// savepoint does not help, so they are recorded for the top-level
+// transaction, whether they come from a savepoint or from a repository call
+// given ctx, and even if fn goes on after them. The top-level transaction is
+// then rolled back instead of committed and, if the manager was created with
+// WithSerializationRetries, rerun.
+func (m *TxManager) Do(ctx context.Context, fn TxFunc, opts ...Option) error {
+	opt := buildOptions(ctx, nil, opts...)
+	if opt.tx != nil {
+		err := m.run(ctx, opt.tx, fn, nil)
+		markRestart(ctx, err)
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < max(m.serializationAttempts, 1); attempt++ {
+		run := &txRun{}
+		if err = m.run(context.WithValue(ctx, txRunKey{}, run), m.db, fn, run); !RestartsTx(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// run runs fn in a transaction (or savepoint) started on parent. For a
+// top-level transaction, run is its state: it is not committed if a restart
+// was recorded.
+func (m *TxManager) run(ctx context.Context, parent Beginner, fn TxFunc, run *txRun) (err error) {
+	tx, err := parent.Begin(ctx)
+	if err != nil {
+		return wrapDBError(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err = fn(ctx, tx)
+	if err == nil && run != nil && run.restart != nil {
+		err = run.restart
+	}
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return errors.Join(err, wrapDBError(rbErr))
+		}
+		return err
+	}
+
+	return wrapDBError(tx.Commit(ctx))
+}
@@ -0,0 +1,194 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscriptionsservice/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxManager_Do(t *testing.T) {
+	errStep := errors.New("step failed")
+
+	t.Run("commit on success", func(t *testing.T) {
+		_, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := repository.NewTxManager(mock).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		_, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := repository.NewTxManager(mock).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			return errStep
+		})
+		assert.ErrorIs(t, err, errStep)
+	})
+
+	t.Run("nested failure rolls back only the savepoint", func(t *testing.T) {
+		_, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").
+			WithArgs(int64(1)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectCommit()
+
+		txm := repository.NewTxManager(mock)
+		err := txm.Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "DELETE FROM subscriptions WHERE id = $1", int64(1)); err != nil {
+				return err
+			}
+
+			nestedErr := txm.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+				return errStep
+			}, repository.WithTx(tx))
+			require.ErrorIs(t, nestedErr, errStep)
+
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestTxManager_Do_SerializationRetries(t *testing.T) {
+	serializationErr := &pgconn.PgError{Code: "40001", Message: "restart transaction"}
+
+	t.Run("rerun on serialization failure", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		for range 2 {
+			mock.ExpectBegin()
+			mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+				WillReturnError(serializationErr)
+			mock.ExpectRollback()
+		}
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+
+		runs := 0
+		err := repository.NewTxManager(mock, repository.WithSerializationRetries(3)).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			runs++
+			return repo.Delete(ctx, 1, repository.WithTx(tx))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("not rerun by default", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnError(serializationErr)
+		mock.ExpectRollback()
+
+		err := repository.NewTxManager(mock).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			return repo.Delete(ctx, 1, repository.WithTx(tx))
+		})
+		assert.ErrorIs(t, err, repository.ErrSerialization)
+	})
+}
+
+func TestTxManager_Do_RestartsWholeTransaction(t *testing.T) {
+	serializationErr := &pgconn.PgError{Code: "40001", Message: "restart transaction"}
+
+	t.Run("failure in a savepoint reruns the outer transaction", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnError(serializationErr)
+		mock.ExpectRollback()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+		mock.ExpectCommit()
+
+		txm := repository.NewTxManager(mock, repository.WithSerializationRetries(2))
+		runs := 0
+		err := txm.Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			runs++
+			// The savepoint's error is handled, as if the step were optional.
+			_ = txm.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+				return repo.Delete(ctx, 1, repository.WithTx(tx))
+			}, repository.WithTx(tx))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, runs)
+	})
+
+	t.Run("ignored failure is not committed", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnError(&pgconn.PgError{Code: "25P02", Message: "current transaction is aborted"})
+		mock.ExpectRollback()
+
+		err := repository.NewTxManager(mock).Do(t.Context(), func(ctx context.Context, tx pgx.Tx) error {
+			_ = repo.Delete(ctx, 1, repository.WithTx(tx))
+			return nil
+		})
+		assert.ErrorIs(t, err, repository.ErrTxFailed)
+		assert.ErrorIs(t, err, repository.ErrTxAborted)
+		assert.True(t, repository.RestartsTx(err))
+	})
+}
+
+func TestContextWithTx(t *testing.T) {
+	t.Run("repository calls use the transaction", func(t *testing.T) {
+		repo, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM subscriptions WHERE id = $1").WithArgs(int64(1)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+
+		tx, err := mock.Begin(t.Context())
+		require.NoError(t, err)
+		ctx := repository.ContextWithTx(t.Context(), tx)
+
+		got, ok := repository.TxFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, tx, got)
+
+		require.NoError(t, repo.Delete(ctx, 1))
+		require.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("tx manager uses a savepoint", func(t *testing.T) {
+		_, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectCommit()
+
+		tx, err := mock.Begin(t.Context())
+		require.NoError(t, err)
+		ctx := repository.ContextWithTx(t.Context(), tx)
+
+		err = repository.NewTxManager(mock).Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			return errors.New("step failed")
+		})
+		require.Error(t, err)
+		require.NoError(t, tx.Commit(ctx), "the outer transaction stays usable")
+	})
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// UserRepo stores the users subscriptions belong to.
+type UserRepo struct {
+	base
+}
+
+// NewUserRepo initializes UserRepo.
+// db is usually a *pgxpool.Pool.
+func NewUserRepo(db Executer, r retry.Retrier) *UserRepo {
+	return &UserRepo{base: newBase(db, r)}
+}
+
+var userColumns = []string{"id", "created_at"}
+
+// Create inserts a user and fills its CreatedAt. It returns ErrDuplicate if
+// the user exists.
+func (r *UserRepo) Create(ctx context.Context, u *models.User, opts ...Option) error {
+	query := r.psql.Insert("users").
+		Columns("id").
+		Values(u.ID).
+		Suffix("RETURNING created_at")
+
+	return scanReturning(ctx, &r.base, query, []any{&u.CreatedAt}, opts...)
+}
+
+// GetByID retrieves a user by ID.
+func (r *UserRepo) GetByID(ctx context.Context, id uuid.UUID, opts ...Option) (*models.User, error) {
+	u, err := selectOne(ctx, &r.base, r.psql.Select(userColumns...).From("users").Where(sq.Eq{"id": id}), scanUser, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// List returns users ordered by ID.
+func (r *UserRepo) List(ctx context.Context, limit, offset int, opts ...Option) ([]models.User, error) {
+	query := r.psql.Select(userColumns...).From("users").OrderBy("id ASC")
+	if limit > 0 {
+		query = query.Limit(uint64(limit)).Offset(uint64(offset))
+	}
+
+	return selectMany(ctx, &r.base, query, scanUser, opts...)
+}
+
+// Delete removes a user. It returns ErrNotFound if the user does not exist
+// and ErrForeignKeyViolation if the user has subscriptions.
+func (r *UserRepo) Delete(ctx context.Context, id uuid.UUID, opts ...Option) error {
+	return execOne(ctx, &r.base, r.psql.Delete("users").Where(sq.Eq{"id": id}), opts...)
+}
+
+func scanUser(row pgx.Row) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.CreatedAt)
+	return u, err
+}
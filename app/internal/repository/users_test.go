@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepo_SQL(t *testing.T) {
+	userID := uuid.New()
+	created := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("create", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewUserRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("INSERT INTO users (id) VALUES ($1) RETURNING created_at").
+			WithArgs(userID).
+			WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(created))
+
+		u := &models.User{ID: userID}
+		require.NoError(t, repo.Create(t.Context(), u))
+		assert.Equal(t, created, u.CreatedAt)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewUserRepo(mock, retry.NoRetry())
+
+		mock.ExpectQuery("SELECT id, created_at FROM users ORDER BY id ASC LIMIT 10 OFFSET 20").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(userID, created))
+
+		got, err := repo.List(t.Context(), 10, 20)
+		require.NoError(t, err)
+		assert.Equal(t, []models.User{{ID: userID, CreatedAt: created}}, got)
+	})
+
+	t.Run("delete user with subscriptions", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewUserRepo(mock, retry.NoRetry())
+
+		// squirrel passes the value of uuid.UUID, a driver.Valuer.
+		mock.ExpectExec("DELETE FROM users WHERE id = $1").
+			WithArgs(userID.String()).
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+
+		assert.ErrorIs(t, repo.Delete(t.Context(), userID), repository.ErrForeignKeyViolation)
+	})
+}
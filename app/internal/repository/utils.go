@@ -1,6 +1,9 @@
 package repository
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 func monthsInclusive(a, b time.Time) int {
 	if b.Before(a) {
@@ -10,3 +13,23 @@ func monthsInclusive(a, b time.Time) int {
 	months := (days + 29) / 30
 	return months
 }
+
+// monthStart returns the first day of t's month.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// calendarMonthsInclusive counts calendar months from a's month to b's
+// month inclusive, regardless of the days.
+func calendarMonthsInclusive(a, b time.Time) int {
+	n := (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month()) + 1
+	return max(n, 0)
+}
+
+// likeEscaper escapes LIKE wildcards with the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes s match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -69,3 +69,17 @@ func TestMonthsInclusive(t *testing.T) {
 		})
 	}
 }
+
+func TestCalendarMonthsInclusive(t *testing.T) {
+	day := func(s string) time.Time {
+		tm, _ := time.Parse(time.DateOnly, s)
+		return tm
+	}
+
+	assert.Equal(t, 1, calendarMonthsInclusive(day("2025-01-01"), day("2025-01-31")))
+	assert.Equal(t, 2, calendarMonthsInclusive(day("2025-01-31"), day("2025-02-01")))
+	assert.Equal(t, 3, calendarMonthsInclusive(day("2025-02-15"), day("2025-04-15")))
+	assert.Equal(t, 13, calendarMonthsInclusive(day("2024-12-01"), day("2025-12-01")))
+	assert.Equal(t, 0, calendarMonthsInclusive(day("2025-03-01"), day("2025-01-01")))
+	assert.Equal(t, day("2025-02-01"), monthStart(day("2025-02-28")))
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/retry"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// WebhookKeyRepo stores the keys outgoing webhook requests are signed with.
+// Like the tenant registry, the table lives in the default schema.
+type WebhookKeyRepo struct {
+	base
+	codec FieldCodec
+}
+
+// WebhookKeyRepoOption configures WebhookKeyRepo.
+type WebhookKeyRepoOption func(*WebhookKeyRepo)
+
+// WithWebhookKeyCodec encrypts private keys with c. Keys stored in plain
+// text before stay readable until they are re-encrypted.
+func WithWebhookKeyCodec(c FieldCodec) WebhookKeyRepoOption {
+	return func(r *WebhookKeyRepo) {
+		r.codec = c
+	}
+}
+
+// NewWebhookKeyRepo initializes WebhookKeyRepo.
+// db is usually a *pgxpool.Pool.
+func NewWebhookKeyRepo(db Executer, r retry.Retrier, opts ...WebhookKeyRepoOption) *WebhookKeyRepo {
+	repo := &WebhookKeyRepo{base: newBase(db, r)}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+var webhookKeyColumns = []string{"id", "public_key", "private_key", "private_key_key_id", "created_at", "retired_at"}
+
+// privateKeyAAD binds an encrypted private key to the key it belongs to.
+func privateKeyAAD(id string) string {
+	return "webhook_keys.private_key:" + id
+}
+
+// List returns the active key and the keys retired after retiredAfter,
+// newest first.
+func (r *WebhookKeyRepo) List(ctx context.Context, retiredAfter time.Time, opts ...Option) ([]models.WebhookKey, error) {
+	opt := r.options(ctx, opts...)
+
+	var keys []models.WebhookKey
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		keys = nil
+
+		query := r.psql.Select(webhookKeyColumns...).From("webhook_keys").
+			Where(sq.Or{sq.Eq{"retired_at": nil}, sq.Gt{"retired_at": retiredAfter.UTC()}}).
+			OrderBy("created_at DESC", "id DESC")
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				k     models.WebhookKey
+				keyID *string
+			)
+			if err := rows.Scan(&k.ID, &k.PublicKey, &k.PrivateKey, &keyID, &k.CreatedAt, &k.RetiredAt); err != nil {
+				return wrapDBError(err)
+			}
+			if k.PrivateKey, err = openField(r.codec, k.PrivateKey, privateKeyAAD(k.ID), keyID); err != nil {
+				return err
+			}
+			keys = append(keys, k)
+		}
+		return wrapDBError(rows.Err())
+	}); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// rotateWebhookKeyQuery retires the active key at the creation time of the
+// new one, deletes keys retired before $5 and inserts the new key in one
+// statement. The insert reads the retired rows, so it runs after the update
+// and does not collide with the old key in idx_webhook_keys_active; a
+// concurrent rotation blocks on the update and then collides with the key
+// inserted here.
+const rotateWebhookKeyQuery = `
+WITH retired AS (
+	UPDATE webhook_keys SET retired_at = $4 WHERE retired_at IS NULL
+	RETURNING id
+), pruned AS (
+	DELETE FROM webhook_keys WHERE retired_at < $5
+)
+INSERT INTO webhook_keys (id, public_key, private_key, private_key_key_id, created_at)
+SELECT $1, $2, $3, $6, $4 FROM (SELECT COUNT(*) FROM retired) AS r`
+
+// Rotate makes k the active key, retiring the current one at k.CreatedAt,
+// and deletes keys retired before pruneBefore. It returns ErrDuplicate if
+// a concurrent rotation won.
+func (r *WebhookKeyRepo) Rotate(ctx context.Context, k *models.WebhookKey, pruneBefore time.Time, opts ...Option) error {
+	opt := r.options(ctx, opts...)
+
+	privateKey, keyID, err := sealField(r.codec, k.PrivateKey, privateKeyAAD(k.ID))
+	if err != nil {
+		return err
+	}
+
+	return opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+		_, err := opt.exec.Exec(ctx, rotateWebhookKeyQuery,
+			k.ID, k.PublicKey, privateKey, k.CreatedAt.UTC(), pruneBefore.UTC(), keyID)
+		return wrapDBError(err)
+	})
+}
+
+// Reencrypt encrypts the private keys not encrypted with the active key of
+// the codec, plain text ones included, with it, so older keys can be removed
+// from the config. It returns the number of re-encrypted keys, or
+// ErrNoCodec without a codec.
+func (r *WebhookKeyRepo) Reencrypt(ctx context.Context, opts ...Option) (int, error) {
+	if r.codec == nil {
+		return 0, ErrNoCodec
+	}
+	opt := r.options(ctx, opts...)
+	active := r.codec.ActiveKeyID()
+
+	type stale struct {
+		id         string
+		privateKey []byte
+		keyID      *string
+	}
+	var keys []stale
+
+	if err := opt.retrier(r.retry, ReadProfile).Do(ctx, func() error {
+		keys = nil
+
+		query := r.psql.Select("id", "private_key", "private_key_key_id").From("webhook_keys").
+			Where("private_key_key_id IS DISTINCT FROM ?", active)
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		rows, err := opt.exec.Query(ctx, sql, args...)
+		if err != nil {
+			return wrapDBError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var k stale
+			if err := rows.Scan(&k.id, &k.privateKey, &k.keyID); err != nil {
+				return wrapDBError(err)
+			}
+			keys = append(keys, k)
+		}
+		return wrapDBError(rows.Err())
+	}); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, k := range keys {
+		aad := privateKeyAAD(k.id)
+		plain, err := openField(r.codec, k.privateKey, aad, k.keyID)
+		if err != nil {
+			return n, err
+		}
+		sealed, keyID, err := sealField(r.codec, plain, aad)
+		if err != nil {
+			return n, err
+		}
+
+		var updated bool
+		if err := opt.retrier(r.retry, WriteProfile).Do(ctx, func() error {
+			// Unless it was re-encrypted concurrently.
+			query := r.psql.Update("webhook_keys").
+				Set("private_key", sealed).
+				Set("private_key_key_id", *keyID).
+				Where(sq.Eq{"id": k.id}).
+				Where("private_key_key_id IS NOT DISTINCT FROM ?", k.keyID)
+
+			sql, args, err := query.ToSql()
+			if err != nil {
+				return err
+			}
+
+			cmd, err := opt.exec.Exec(ctx, sql, args...)
+			if err != nil {
+				return wrapDBError(err)
+			}
+			updated = cmd.RowsAffected() > 0
+			return nil
+		}); err != nil {
+			return n, err
+		}
+		if updated {
+			n++
+		}
+	}
+	return n, nil
+}
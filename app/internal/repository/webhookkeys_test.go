@@ -0,0 +1,118 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookKeyRepo_SQL(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("list", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWebhookKeyRepo(mock, retry.NoRetry())
+
+		retired := now.Add(-time.Hour)
+		mock.ExpectQuery("SELECT id, public_key, private_key, private_key_key_id, created_at, retired_at FROM webhook_keys " +
+			"WHERE (retired_at IS NULL OR retired_at > $1) ORDER BY created_at DESC, id DESC").
+			WithArgs(now.Add(-72 * time.Hour)).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "public_key", "private_key", "private_key_key_id", "created_at", "retired_at"}).
+				AddRow("new", []byte{1}, []byte{2}, (*string)(nil), retired, (*time.Time)(nil)).
+				AddRow("old", []byte{3}, []byte{4}, (*string)(nil), now.Add(-48*time.Hour), &retired))
+
+		got, err := repo.List(t.Context(), now.Add(-72*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Nil(t, got[0].RetiredAt)
+		assert.Equal(t, "old", got[1].ID)
+		assert.Equal(t, &retired, got[1].RetiredAt)
+	})
+
+	t.Run("concurrent rotation", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWebhookKeyRepo(mock, retry.NoRetry())
+
+		key := &models.WebhookKey{ID: "kid", PublicKey: []byte{1}, PrivateKey: []byte{2}, CreatedAt: now}
+		mock.ExpectExec(`
+WITH retired AS (
+	UPDATE webhook_keys SET retired_at = $4 WHERE retired_at IS NULL
+	RETURNING id
+), pruned AS (
+	DELETE FROM webhook_keys WHERE retired_at < $5
+)
+INSERT INTO webhook_keys (id, public_key, private_key, private_key_key_id, created_at)
+SELECT $1, $2, $3, $6, $4 FROM (SELECT COUNT(*) FROM retired) AS r`).
+			WithArgs("kid", []byte{1}, []byte{2}, now, now.Add(-72*time.Hour), (*string)(nil)).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.Rotate(t.Context(), key, now.Add(-72*time.Hour))
+		assert.ErrorIs(t, err, repository.ErrDuplicate)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWebhookKeyRepo(mock, retry.NoRetry(), repository.WithWebhookKeyCodec(xorCodec{key: "v2"}))
+
+		old := "v1"
+		mock.ExpectQuery("SELECT id, public_key, private_key, private_key_key_id, created_at, retired_at FROM webhook_keys " +
+			"WHERE (retired_at IS NULL OR retired_at > $1) ORDER BY created_at DESC, id DESC").
+			WithArgs(now).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "public_key", "private_key", "private_key_key_id", "created_at", "retired_at"}).
+				AddRow("kid", []byte{1}, xor([]byte("seed"), "webhook_keys.private_key:kid"), &old, now, (*time.Time)(nil)))
+
+		got, err := repo.List(t.Context(), now)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("seed"), got[0].PrivateKey)
+	})
+
+	t.Run("reencrypt", func(t *testing.T) {
+		mock := newMockPool(t)
+		repo := repository.NewWebhookKeyRepo(mock, retry.NoRetry(), repository.WithWebhookKeyCodec(xorCodec{key: "v2"}))
+
+		mock.ExpectQuery("SELECT id, private_key, private_key_key_id FROM webhook_keys WHERE private_key_key_id IS DISTINCT FROM $1").
+			WithArgs("v2").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "private_key", "private_key_key_id"}).
+				AddRow("plain", []byte("seed"), (*string)(nil)))
+		mock.ExpectExec("UPDATE webhook_keys SET private_key = $1, private_key_key_id = $2 "+
+			"WHERE id = $3 AND private_key_key_id IS NOT DISTINCT FROM $4").
+			WithArgs(xor([]byte("seed"), "webhook_keys.private_key:plain"), "v2", "plain", (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		n, err := repo.Reencrypt(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		_, err = repository.NewWebhookKeyRepo(mock, retry.NoRetry()).Reencrypt(t.Context())
+		assert.ErrorIs(t, err, repository.ErrNoCodec)
+	})
+}
+
+// xorCodec "encrypts" values by XOR with their AAD, whatever the key.
+type xorCodec struct{ key string }
+
+func (c xorCodec) Encrypt(plaintext, aad []byte) ([]byte, string, error) {
+	return xor(plaintext, string(aad)), c.key, nil
+}
+
+func (c xorCodec) Decrypt(ciphertext, aad []byte, _ string) ([]byte, error) {
+	return xor(ciphertext, string(aad)), nil
+}
+
+func (c xorCodec) ActiveKeyID() string { return c.key }
+
+func xor(b []byte, aad string) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ aad[i%len(aad)]
+	}
+	return out
+}
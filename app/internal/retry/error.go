@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"fmt"
+	"time"
+)
+
+// StopReason tells why Retrier.Do gave up.
+type StopReason int
+
+const (
+	// StopExhausted means all attempts failed.
+	StopExhausted StopReason = iota
+	// StopUnretryable means an attempt failed with an unretryable error.
+	StopUnretryable
+	// StopContext means the context was done before an attempt succeeded.
+	StopContext
+)
+
+// String returns the reason name.
+func (r StopReason) String() string {
+	switch r {
+	case StopExhausted:
+		return "exhausted"
+	case StopUnretryable:
+		return "unretryable"
+	case StopContext:
+		return "context"
+	default:
+		return fmt.Sprintf("StopReason(%d)", int(r))
+	}
+}
+
+// RetryError is returned by Retrier.Do when the operation did not succeed.
+// It matches both LastErr and, if the context stopped the loop, the context
+// error with errors.Is.
+type RetryError struct {
+	Attempts int           // Attempts made.
+	Elapsed  time.Duration // Time spent, including backoff waits.
+	Reason   StopReason    // Why the loop stopped.
+	LastErr  error         // Error of the last attempt; nil if none was made.
+	CtxErr   error         // Context error if Reason is StopContext.
+}
+
+// Error returns the error message.
+func (e *RetryError) Error() string {
+	switch {
+	case e.Reason == StopUnretryable:
+		return "unretryable error: " + e.LastErr.Error()
+	case e.Reason == StopContext && e.LastErr == nil:
+		return e.CtxErr.Error()
+	case e.Reason == StopContext:
+		return fmt.Sprintf("%v after %d attempts: %v", e.CtxErr, e.Attempts, e.LastErr)
+	default:
+		return "all attempts failed: " + e.LastErr.Error()
+	}
+}
+
+// Unwrap returns the context error and the last attempt's error.
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.CtxErr != nil {
+		errs = append(errs, e.CtxErr)
+	}
+	if e.LastErr != nil {
+		errs = append(errs, e.LastErr)
+	}
+	return errs
+}
+
+// StoppedByContext reports whether the context ended the retries.
+func (e *RetryError) StoppedByContext() bool {
+	return e.Reason == StopContext
+}
+
+// Summary describes the retries, e.g. "gave up after 3 attempts in 2.1s".
+func (e *RetryError) Summary() string {
+	return fmt.Sprintf("gave up after %d attempts in %s (%s)", e.Attempts, e.Elapsed.Round(time.Millisecond), e.Reason)
+}
@@ -0,0 +1,29 @@
+package retry
+
+import "time"
+
+// Result describes a finished Retrier.Do call.
+type Result struct {
+	Attempts int           // Attempts made.
+	Backoff  time.Duration // Time slept between attempts.
+	Success  bool          // An attempt succeeded.
+	Reason   StopReason    // Why Do gave up; meaningless on success.
+}
+
+// Retried reports whether the call succeeded after a failed attempt.
+func (r Result) Retried() bool {
+	return r.Success && r.Attempts > 1
+}
+
+// MetricsRecorder observes Retrier.Do calls, e.g. to export retry rates.
+// Record is called once per call and must be safe for concurrent use.
+type MetricsRecorder interface {
+	Record(r Result)
+}
+
+// WithMetricsRecorder reports the result of every Do call to m.
+func WithMetricsRecorder(m MetricsRecorder) RetryOption {
+	return func(r *retrier) {
+		r.metrics = m
+	}
+}
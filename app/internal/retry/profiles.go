@@ -0,0 +1,40 @@
+package retry
+
+import "context"
+
+// Profiles is a Retrier with named alternatives, e.g. a cautious policy for
+// writes next to an aggressive one for reads. Do uses the default Retrier.
+type Profiles struct {
+	def   Retrier
+	named map[string]Retrier
+}
+
+// NewProfiles creates Profiles with the default Retrier def and named ones.
+func NewProfiles(def Retrier, named map[string]Retrier) *Profiles {
+	p := &Profiles{def: def, named: make(map[string]Retrier, len(named))}
+	for name, r := range named {
+		p.named[name] = r
+	}
+	return p
+}
+
+// Do executes f with the default Retrier.
+func (p *Profiles) Do(ctx context.Context, f AttemptFunc) error {
+	return p.def.Do(ctx, f)
+}
+
+// Profile returns the Retrier named name, or the default one if there is none.
+func (p *Profiles) Profile(name string) Retrier {
+	if r, ok := p.named[name]; ok {
+		return r
+	}
+	return p.def
+}
+
+// ForProfile returns the Retrier named name if r is *Profiles, otherwise r.
+func ForProfile(r Retrier, name string) Retrier {
+	if p, ok := r.(*Profiles); ok {
+		return p.Profile(name)
+	}
+	return r
+}
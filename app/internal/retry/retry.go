@@ -2,7 +2,7 @@ package retry
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 )
 
@@ -26,6 +26,7 @@ type retrier struct {
 	backoff     Backoff         // strategy for calculating delay between attempts
 	maxAttempts int             // maximum number of attempts (0 = unlimited)
 	isRetryable IsRetryableFunc // function to determine if an error is retryable
+	metrics     MetricsRecorder // optional observer of Do results
 }
 
 // New constructs a new Retrier with optional configurations.
@@ -52,31 +53,64 @@ func NoRetry() Retrier {
 }
 
 // Do executes the given AttemptFunc with retries according to the retrier's configuration.
-// Returns nil if the attempt succeeds, or the last error if all retries fail.
+// Returns nil if the attempt succeeds, or a *RetryError describing the attempts.
+// An attempt failing with a *RetryError (a nested Retrier already gave up) is
+// not retried and its error is returned unchanged, so it is not wrapped twice.
 func (r *retrier) Do(ctx context.Context, f AttemptFunc) error {
-	var err error
+	start := time.Now()
+	rerr := &RetryError{}
+	var slept time.Duration
+	record := func(success bool, reason StopReason) {
+		if r.metrics != nil {
+			r.metrics.Record(Result{Attempts: rerr.Attempts, Backoff: slept, Success: success, Reason: reason})
+		}
+	}
+	stop := func(reason StopReason, ctxErr error) error {
+		rerr.Reason = reason
+		rerr.CtxErr = ctxErr
+		rerr.Elapsed = time.Since(start)
+		record(false, reason)
+		return rerr
+	}
 
 	for attempt := 0; r.maxAttempts == 0 || attempt < r.maxAttempts; attempt++ {
 		if ctxErr := ctx.Err(); ctxErr != nil {
-			return ctxErr
+			return stop(StopContext, ctxErr)
 		}
 
-		if err = f(); err == nil {
+		rerr.Attempts++
+		err := f()
+		if err == nil {
+			record(true, 0)
 			return nil
 		}
 
+		var nested *RetryError
+		if errors.As(err, &nested) {
+			record(false, nested.Reason)
+			return err
+		}
+		rerr.LastErr = err
+
 		if r.isRetryable != nil && !r.isRetryable(err) {
-			return fmt.Errorf("unretryable error: %w", err)
+			return stop(StopUnretryable, nil)
+		}
+
+		if r.maxAttempts != 0 && attempt+1 == r.maxAttempts {
+			break
 		}
 
+		sleepStart := time.Now()
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			slept += time.Since(sleepStart)
+			return stop(StopContext, ctx.Err())
 		case <-time.After(r.backoff.Next(attempt)):
+			slept += time.Since(sleepStart)
 		}
 	}
 
-	return fmt.Errorf("all attempts failed: %w", err)
+	return stop(StopExhausted, nil)
 }
 
 // defaultAttempts returns the default maximum number of retry attempts.
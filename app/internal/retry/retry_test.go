@@ -88,7 +88,8 @@ func TestRetrier_Do(t *testing.T) {
 				return errAlwaysFail
 			},
 			ctx: func() context.Context {
-				ctx, _ := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				t.Cleanup(cancel)
 				return ctx
 			},
 			wantErr: context.DeadlineExceeded,
@@ -146,3 +147,114 @@ func TestRetrier_Do(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrier_Do_RetryError(t *testing.T) {
+	fast := WithBackoff(FixedBackoff{Interval: time.Millisecond})
+
+	t.Run("exhausted", func(t *testing.T) {
+		err := New(WithMaxAttempts(3), fast).Do(t.Context(), func() error { return errAlwaysFail })
+
+		var rerr *RetryError
+		require.ErrorAs(t, err, &rerr)
+		assert.Equal(t, 3, rerr.Attempts)
+		assert.Equal(t, StopExhausted, rerr.Reason)
+		assert.Equal(t, errAlwaysFail, rerr.LastErr)
+		assert.False(t, rerr.StoppedByContext())
+		assert.Positive(t, rerr.Elapsed)
+		assert.Contains(t, rerr.Summary(), "gave up after 3 attempts in")
+	})
+
+	t.Run("stopped by context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		err := New(WithMaxAttempts(5), fast).Do(ctx, func() error {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+			return errAlwaysFail
+		})
+
+		var rerr *RetryError
+		require.ErrorAs(t, err, &rerr)
+		assert.True(t, rerr.StoppedByContext())
+		assert.Equal(t, 2, rerr.Attempts)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, errAlwaysFail)
+		assert.EqualError(t, err, "context canceled after 2 attempts: always fail")
+	})
+
+	t.Run("nested retrier is not wrapped twice", func(t *testing.T) {
+		inner := New(WithMaxAttempts(2), fast)
+		outerCalls := 0
+		err := New(WithMaxAttempts(3), fast).Do(t.Context(), func() error {
+			outerCalls++
+			return inner.Do(t.Context(), func() error { return errAlwaysFail })
+		})
+
+		assert.Equal(t, 1, outerCalls)
+		assert.EqualError(t, err, "all attempts failed: always fail")
+		var rerr *RetryError
+		require.ErrorAs(t, err, &rerr)
+		assert.Equal(t, 2, rerr.Attempts)
+	})
+}
+
+func TestProfiles(t *testing.T) {
+	fast := WithBackoff(FixedBackoff{Interval: time.Millisecond})
+	def := New(WithMaxAttempts(3), fast)
+	writes := NoRetry()
+	p := NewProfiles(def, map[string]Retrier{"write": writes})
+
+	attempts := func(r Retrier) int {
+		var rerr *RetryError
+		require.ErrorAs(t, r.Do(t.Context(), func() error { return errAlwaysFail }), &rerr)
+		return rerr.Attempts
+	}
+
+	assert.Equal(t, 3, attempts(p))
+	assert.Equal(t, 1, attempts(ForProfile(p, "write")))
+	assert.Equal(t, 3, attempts(ForProfile(p, "read")))
+	assert.Equal(t, 3, attempts(ForProfile(def, "write")))
+}
+
+// recorder collects Do results.
+type recorder struct {
+	results []Result
+}
+
+func (r *recorder) Record(res Result) {
+	r.results = append(r.results, res)
+}
+
+func TestRetrier_Do_MetricsRecorder(t *testing.T) {
+	rec := &recorder{}
+	r := New(WithMaxAttempts(3), WithBackoff(FixedBackoff{Interval: time.Millisecond}), WithMetricsRecorder(rec))
+
+	require.NoError(t, r.Do(t.Context(), func() error { return nil }))
+
+	calls := 0
+	require.NoError(t, r.Do(t.Context(), func() error {
+		calls++
+		if calls < 2 {
+			return errAlwaysFail
+		}
+		return nil
+	}))
+
+	require.Error(t, r.Do(t.Context(), func() error { return errAlwaysFail }))
+
+	require.Len(t, rec.results, 3)
+
+	assert.Equal(t, Result{Attempts: 1, Success: true}, rec.results[0])
+	assert.False(t, rec.results[0].Retried())
+
+	assert.True(t, rec.results[1].Retried())
+	assert.Equal(t, 2, rec.results[1].Attempts)
+	assert.GreaterOrEqual(t, rec.results[1].Backoff, time.Millisecond)
+
+	assert.False(t, rec.results[2].Success)
+	assert.Equal(t, 3, rec.results[2].Attempts)
+	assert.Equal(t, StopExhausted, rec.results[2].Reason)
+	assert.GreaterOrEqual(t, rec.results[2].Backoff, 2*time.Millisecond)
+}
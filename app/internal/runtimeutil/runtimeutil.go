@@ -0,0 +1,93 @@
+// Package runtimeutil runs background goroutines that survive panics, so a
+// bug in a server loop, relay or scheduler is logged instead of crashing the
+// process or silently stopping the work.
+package runtimeutil
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	"go.uber.org/zap"
+)
+
+// Option configures Go.
+type Option func(*runner)
+
+// WithName identifies the goroutine in logs.
+func WithName(name string) Option {
+	return func(r *runner) {
+		r.name = name
+	}
+}
+
+// WithRestart restarts fn after a panic, waiting b.Next(n) before the n-th
+// consecutive restart. Without it, fn is not run again after a panic.
+func WithRestart(b retry.Backoff) Option {
+	return func(r *runner) {
+		r.restart = b
+	}
+}
+
+type runner struct {
+	name    string
+	restart retry.Backoff
+	log     *zap.Logger
+}
+
+// Go runs fn in a new goroutine. A panic in fn is recovered and logged with
+// its stack. The returned channel is closed once the goroutine exits: when fn
+// returns, or after a panic unless fn is restarted, or when ctx is done while
+// waiting to restart.
+func Go(ctx context.Context, log *zap.Logger, fn func(ctx context.Context), opts ...Option) <-chan struct{} {
+	r := &runner{name: "background", log: log}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.loop(ctx, fn)
+	}()
+	return done
+}
+
+func (r *runner) loop(ctx context.Context, fn func(ctx context.Context)) {
+	for attempt := 0; ; attempt++ {
+		if !r.run(ctx, fn) || r.restart == nil {
+			return
+		}
+
+		delay := r.restart.Next(attempt)
+		r.log.Warn("restarting goroutine after panic",
+			zap.String("goroutine", r.name),
+			zap.Duration("delay", delay),
+		)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// run calls fn and reports whether it panicked.
+func (r *runner) run(ctx context.Context, fn func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			panicked = true
+			r.log.Error("goroutine panicked",
+				zap.String("goroutine", r.name),
+				zap.Any("panic", p),
+				zap.Stack("stack"),
+			)
+		}
+	}()
+
+	fn(ctx)
+	return false
+}
@@ -0,0 +1,67 @@
+package runtimeutil
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func wait(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("goroutine did not exit")
+	}
+}
+
+func TestGo_RecoversPanic(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	var calls atomic.Int32
+
+	wait(t, Go(t.Context(), zap.New(core), func(context.Context) {
+		calls.Add(1)
+		panic("boom")
+	}, WithName("relay")))
+
+	assert.Equal(t, int32(1), calls.Load(), "not restarted by default")
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "relay", entry.ContextMap()["goroutine"])
+	assert.Contains(t, entry.ContextMap()["stack"], "runtimeutil")
+}
+
+func TestGo_Restart(t *testing.T) {
+	var calls atomic.Int32
+
+	wait(t, Go(t.Context(), zap.NewNop(), func(context.Context) {
+		if calls.Add(1) < 3 {
+			panic("boom")
+		}
+	}, WithRestart(retry.FixedBackoff{Interval: time.Millisecond})))
+
+	assert.Equal(t, int32(3), calls.Load(), "restarted until fn returns")
+}
+
+func TestGo_RestartStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	var calls atomic.Int32
+
+	done := Go(ctx, zap.NewNop(), func(context.Context) {
+		calls.Add(1)
+		panic("boom")
+	}, WithRestart(retry.FixedBackoff{Interval: time.Hour}))
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	wait(t, done)
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"subscriptionsservice/internal/audit"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Kinds of activity feed items.
+const (
+	ActivityCreated      = "created"
+	ActivityUpdated      = "updated"
+	ActivityPriceChanged = "price_changed"
+	ActivityEnded        = "ended"
+	ActivityCancelled    = "cancelled"
+)
+
+// feedActions are the audited actions listed in activity feeds.
+var feedActions = []string{audit.ActionCreated, audit.ActionUpdated, audit.ActionDeleted}
+
+// ActivityRepo defines repository methods required by ActivityService.
+type ActivityRepo interface {
+	// ListByUser returns a page of the audit entries of a user with one of actions, newest first.
+	ListByUser(ctx context.Context, userID uuid.UUID, actions []string, limit, offset int, opts ...repository.Option) ([]models.AuditEntry, error)
+}
+
+// ActivityService builds activity feeds of users from the audit entries of
+// the writes of their subscriptions, stored by audit.Store. Users see their
+// own feeds, admins those of any user.
+type ActivityService struct {
+	repo   ActivityRepo
+	log    *zap.Logger
+	policy OwnershipPolicy
+}
+
+// NewActivityService creates a new instance of ActivityService.
+func NewActivityService(repo ActivityRepo, log *zap.Logger) *ActivityService {
+	return &ActivityService{repo: repo, log: log}
+}
+
+// Feed returns a page of the activity of a user, newest first. Returns
+// ErrForbidden if the caller is another user.
+func (s *ActivityService) Feed(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.ActivityItem, error) {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.ListByUser(ctx, userID, feedActions, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list activity", zap.String("user_id", userID.String()), zap.Error(err), retryInfo(err))
+		return nil, err
+	}
+	items := make([]models.ActivityItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, activityItem(e))
+	}
+	return items, nil
+}
+
+// activityItem describes an audit entry of a write for the feed.
+func activityItem(e models.AuditEntry) models.ActivityItem {
+	name, _ := e.Details["service_name"].(string)
+	item := models.ActivityItem{
+		ID:             e.ID,
+		At:             e.CreatedAt.UTC(),
+		SubscriptionID: e.SubscriptionID,
+		ServiceName:    name,
+		Actor:          e.Actor,
+		Details:        e.Details,
+	}
+	switch e.Action {
+	case audit.ActionCreated:
+		item.Kind, item.Message = ActivityCreated, "created "+name
+	case audit.ActionDeleted:
+		item.Kind, item.Message = ActivityCancelled, "cancelled "+name
+	default:
+		item.Kind, item.Message = updateActivity(name, e.Details)
+	}
+	return item
+}
+
+// updateActivity describes an update by its most notable change: a price
+// change, then a set end date.
+func updateActivity(name string, details map[string]any) (kind, message string) {
+	changes, _ := details["changes"].(map[string]any)
+	if price, ok := changes["price"].(map[string]any); ok {
+		return ActivityPriceChanged, fmt.Sprintf("changed the price of %s from %v to %v", name, price["from"], price["to"])
+	}
+	if end, ok := changes["end_date"].(map[string]any); ok && end["to"] != nil {
+		return ActivityEnded, fmt.Sprintf("set %s to end in %v", name, end["to"])
+	}
+	return ActivityUpdated, "updated " + name
+}
+
+// subscriptionDetails are the audited data of a created or deleted
+// subscription.
+func subscriptionDetails(sub *models.Subscription) map[string]any {
+	return map[string]any{
+		"service_name": sub.ServiceName,
+		"price":        int(sub.Price),
+		"currency":     string(sub.Currency.OrDefault()),
+		"start_date":   auditMonth(&sub.StartDate),
+		"end_date":     auditMonth(sub.EndDate),
+	}
+}
+
+// subscriptionChanges returns the fields changed from current to sub with
+// their old and new values.
+func subscriptionChanges(current, sub *models.Subscription) map[string]any {
+	changes := map[string]any{}
+	change := func(field string, from, to any) {
+		if from != to {
+			changes[field] = map[string]any{"from": from, "to": to}
+		}
+	}
+	change("service_name", current.ServiceName, sub.ServiceName)
+	change("price", int(current.Price), int(sub.Price))
+	change("currency", string(current.Currency.OrDefault()), string(sub.Currency.OrDefault()))
+	change("user_id", current.UserID.String(), sub.UserID.String())
+	change("start_date", auditMonth(&current.StartDate), auditMonth(&sub.StartDate))
+	change("end_date", auditMonth(current.EndDate), auditMonth(sub.EndDate))
+	change("trial", current.Trial, sub.Trial)
+	return changes
+}
+
+// auditMonth formats a month as MM-YYYY for audit details; nil for no month.
+func auditMonth(m *models.MonthDate) any {
+	if m == nil {
+		return nil
+	}
+	return m.Time.Format("01-2006")
+}
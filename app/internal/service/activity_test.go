@@ -0,0 +1,152 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/audit"
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSubscriptionService_AuditsWrites(t *testing.T) {
+	userID := uuid.New()
+	repo := &ownedRepo{subs: map[int64]*models.Subscription{
+		1: {ID: 1, ServiceName: "Netflix", Price: 499, UserID: userID, StartDate: *monthDate(2025, time.July)},
+	}}
+	var events recordedEvents
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithWriteAudit(&events))
+
+	price := models.Price(599)
+	_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{Price: &price})
+	require.NoError(t, err)
+	require.NoError(t, svc.Update(t.Context(), &models.Subscription{
+		ID: 1, ServiceName: "Netflix", Price: 599, UserID: userID,
+		StartDate: *monthDate(2025, time.July), EndDate: monthDate(2025, time.December),
+	}))
+	require.NoError(t, svc.Delete(t.Context(), 1))
+
+	require.Len(t, events, 3)
+	assert.Equal(t, audit.Event{
+		Action:         audit.ActionUpdated,
+		SubscriptionID: 1,
+		UserID:         userID,
+		Details: map[string]any{
+			"service_name": "Netflix",
+			"changes":      map[string]any{"price": map[string]any{"from": 499, "to": 599}},
+		},
+	}, events[0])
+	assert.Equal(t, map[string]any{"end_date": map[string]any{"from": nil, "to": "12-2025"}}, events[1].Details["changes"])
+	assert.Equal(t, audit.ActionDeleted, events[2].Action)
+	assert.Equal(t, userID, events[2].UserID)
+	assert.Equal(t, "Netflix", events[2].Details["service_name"])
+}
+
+// callsRepo records which reads and deletes of ownedRepo are called.
+type callsRepo struct {
+	ownedRepo
+	calls []string
+}
+
+func (r *callsRepo) GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	r.calls = append(r.calls, "GetByID")
+	return r.ownedRepo.GetByID(ctx, id, opts...)
+}
+
+func (r *callsRepo) Delete(ctx context.Context, id int64, opts ...repository.Option) error {
+	r.calls = append(r.calls, "Delete")
+	return r.ownedRepo.Delete(ctx, id, opts...)
+}
+
+func (r *callsRepo) DeleteReturning(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	r.calls = append(r.calls, "DeleteReturning")
+	return r.ownedRepo.DeleteReturning(ctx, id, opts...)
+}
+
+func TestSubscriptionService_WritesNotAudited(t *testing.T) {
+	userID := uuid.New()
+	repo := &callsRepo{ownedRepo: ownedRepo{subs: map[int64]*models.Subscription{
+		1: {ID: 1, ServiceName: "Netflix", Price: 499, UserID: userID, StartDate: *monthDate(2025, time.July)},
+	}}}
+	var events recordedEvents
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithAudit(&events))
+
+	require.NoError(t, svc.Update(t.Context(), &models.Subscription{
+		ID: 1, ServiceName: "Netflix", Price: 599, UserID: userID, StartDate: *monthDate(2025, time.July),
+	}))
+	require.NoError(t, svc.Delete(t.Context(), 1))
+
+	assert.Equal(t, []string{"Delete"}, repo.calls, "without write audit Update does not load the current subscription")
+	assert.Empty(t, events)
+}
+
+// activityRepo returns the entries of a user.
+type activityRepo map[uuid.UUID][]models.AuditEntry
+
+func (r activityRepo) ListByUser(_ context.Context, userID uuid.UUID, _ []string, limit, offset int, _ ...repository.Option) ([]models.AuditEntry, error) {
+	entries := r[userID]
+	return entries[min(offset, len(entries)):min(offset+limit, len(entries))], nil
+}
+
+func TestActivityService_Feed(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	at := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	// Numbers are decoded from JSONB as float64.
+	svc := service.NewActivityService(activityRepo{owner: {
+		{ID: 5, Action: audit.ActionDeleted, SubscriptionID: 2, CreatedAt: at, Details: map[string]any{"service_name": "Spotify"}},
+		{ID: 4, Action: audit.ActionUpdated, SubscriptionID: 1, CreatedAt: at, Details: map[string]any{
+			"service_name": "Netflix",
+			"changes":      map[string]any{"end_date": map[string]any{"from": nil, "to": "12-2025"}},
+		}},
+		{ID: 3, Action: audit.ActionUpdated, SubscriptionID: 1, CreatedAt: at, Details: map[string]any{
+			"service_name": "Netflix",
+			"changes":      map[string]any{"price": map[string]any{"from": 499.0, "to": 599.0}},
+		}},
+		{ID: 2, Action: audit.ActionUpdated, SubscriptionID: 1, CreatedAt: at, Details: map[string]any{
+			"service_name": "Netflix",
+			"changes":      map[string]any{"trial": map[string]any{"from": true, "to": false}},
+		}},
+		{ID: 1, Action: audit.ActionCreated, SubscriptionID: 1, CreatedAt: at, Details: map[string]any{"service_name": "Netflix"}},
+	}}, zap.NewNop())
+
+	feed, err := svc.Feed(t.Context(), owner, 10, 0)
+	require.NoError(t, err)
+	var messages, kinds []string
+	for _, item := range feed {
+		messages = append(messages, item.Message)
+		kinds = append(kinds, item.Kind)
+	}
+	assert.Equal(t, []string{
+		"cancelled Spotify",
+		"set Netflix to end in 12-2025",
+		"changed the price of Netflix from 499 to 599",
+		"updated Netflix",
+		"created Netflix",
+	}, messages)
+	assert.Equal(t, []string{
+		service.ActivityCancelled, service.ActivityEnded, service.ActivityPriceChanged, service.ActivityUpdated, service.ActivityCreated,
+	}, kinds)
+	assert.Equal(t, "Spotify", feed[0].ServiceName)
+
+	page, err := svc.Feed(t.Context(), owner, 2, 4)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.EqualValues(t, 1, page[0].ID)
+
+	as := func(userID uuid.UUID) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: userID})
+	}
+	_, err = svc.Feed(as(stranger), owner, 10, 0)
+	assert.ErrorIs(t, err, service.ErrForbidden)
+	empty, err := svc.Feed(as(stranger), stranger, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
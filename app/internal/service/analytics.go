@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// AnalyticsRepo defines repository methods required by AnalyticsService.
+type AnalyticsRepo interface {
+	// Retention returns retention of subscription cohorts as of a month.
+	Retention(ctx context.Context, from, to models.MonthDate, asOf time.Time, opts ...repository.Option) ([]models.RetentionCohort, error)
+}
+
+// AnalyticsService provides reports over subscriptions.
+type AnalyticsService struct {
+	repo AnalyticsRepo
+	log  *zap.Logger
+	now  func() time.Time
+}
+
+// NewAnalyticsService creates a new instance of AnalyticsService.
+func NewAnalyticsService(repo AnalyticsRepo, log *zap.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo: repo,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+// Retention reports, per start month cohort, how many subscriptions were
+// still active 1, 3, 6 and 12 months later, as of the current month.
+// Returns *PeriodError if the cohort range ends before it starts.
+func (s *AnalyticsService) Retention(ctx context.Context, req *models.RetentionRequest) ([]models.RetentionCohort, error) {
+	if err := checkPeriod(req.From, &req.To, "from", "to"); err != nil {
+		return nil, err
+	}
+	s.log.Info("calculating retention",
+		zap.Time("from", req.From.Time),
+		zap.Time("to", req.To.Time),
+	)
+	cohorts, err := s.repo.Retention(ctx, req.From, req.To, s.now().UTC())
+	if err != nil {
+		s.log.Error("failed to calculate retention", zap.Error(err), retryInfo(err))
+		return nil, err
+	}
+	return cohorts, nil
+}
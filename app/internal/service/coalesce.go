@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/tenant"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalesce runs fn once for concurrent calls with the same key and gives
+// its result to all of them, so a burst of identical reads, e.g. dashboards
+// refreshing in many tabs, makes one database query. shared is true if
+// the result may be given to other callers too, which must then copy what
+// they change.
+//
+// Strong reads and reads in a request transaction run on their own: the
+// former must see writes made after a shared query started, the latter
+// must use their transaction. fn gets a context that is not canceled with
+// the caller's, as other callers may still wait for it; a caller whose
+// context is done stops waiting.
+func coalesce[T any](ctx context.Context, g *singleflight.Group, key string, fn func(context.Context) (T, error)) (v T, shared bool, err error) {
+	if _, inTx := repository.TxFromContext(ctx); inTx || consistency.IsStrong(ctx) {
+		v, err = fn(ctx)
+		return v, false, err
+	}
+
+	key = tenant.SchemaFromContext(ctx) + "/" + key
+	ch := g.DoChan(key, func() (any, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return v, res.Shared, res.Err
+		}
+		return res.Val.(T), res.Shared, nil
+	case <-ctx.Done():
+		return v, false, ctx.Err()
+	}
+}
+
+// subscriptionReadKey is the coalescing key of a subscription read by ID.
+func subscriptionReadKey(id int64) string {
+	return "subscription/" + strconv.FormatInt(id, 10)
+}
+
+// summaryReadKey is the coalescing key of a summary request.
+func summaryReadKey(req *models.SummaryRequest) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	return "summary/" + string(b), nil
+}
+
+// cloneSummary returns a copy of sum not sharing lines or the breakdown.
+func cloneSummary(sum models.Summary) models.Summary {
+	sum.Lines = slices.Clone(sum.Lines)
+	if sum.Breakdown != nil {
+		b := *sum.Breakdown
+		b.Groups = slices.Clone(b.Groups)
+		if b.Other != nil {
+			other := *b.Other
+			b.Other = &other
+		}
+		sum.Breakdown = &b
+	}
+	return sum
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// blockingRepo holds reads until release is closed.
+type blockingRepo struct {
+	fakeRepo
+
+	release   chan struct{}
+	gets      atomic.Int32
+	summaries atomic.Int32
+}
+
+func (r *blockingRepo) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.Subscription, error) {
+	r.gets.Add(1)
+	<-r.release
+	return &models.Subscription{ID: id, ServiceName: "Netflix", EndDate: monthDate(2025, time.December)}, nil
+}
+
+func (r *blockingRepo) Summary(_ context.Context, q *models.SummaryRequest, _ ...repository.Option) (models.Summary, error) {
+	r.summaries.Add(1)
+	<-r.release
+	return models.Summary{Count: 1, From: q.From, To: q.To}, nil
+}
+
+// waitFor waits until cond holds, then a little more for other goroutines
+// to catch up.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	require.Eventually(t, cond, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestSubscriptionService_CoalescesReads(t *testing.T) {
+	repo := &blockingRepo{release: make(chan struct{})}
+	svc := service.NewSubscriptionService(repo, zap.NewNop())
+
+	const callers = 10
+	userID := uuid.New()
+	var wg sync.WaitGroup
+	subs := make([]*models.Subscription, callers)
+	for i := range callers {
+		wg.Go(func() {
+			sub, err := svc.GetByID(t.Context(), 1)
+			assert.NoError(t, err)
+			subs[i] = sub
+		})
+		wg.Go(func() {
+			sum, err := svc.Summary(t.Context(), userSummary(userID))
+			assert.NoError(t, err)
+			assert.Equal(t, 1, sum.Count)
+		})
+	}
+	waitFor(t, func() bool { return repo.gets.Load() == 1 && repo.summaries.Load() == 1 })
+
+	// Strong reads do not join the queries in flight.
+	wg.Go(func() {
+		_, err := svc.GetByID(consistency.WithLevel(t.Context(), consistency.Strong), 1)
+		assert.NoError(t, err)
+	})
+	waitFor(t, func() bool { return repo.gets.Load() == 2 })
+
+	close(repo.release)
+	wg.Wait()
+	assert.EqualValues(t, 2, repo.gets.Load())
+	assert.EqualValues(t, 1, repo.summaries.Load())
+
+	subs[0].EndDate.Time = time.Time{}
+	assert.Equal(t, *monthDate(2025, time.December), *subs[1].EndDate, "callers get copies")
+}
+
+func TestSubscriptionService_CoalescedReadCanceled(t *testing.T) {
+	repo := &blockingRepo{release: make(chan struct{})}
+	svc := service.NewSubscriptionService(repo, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() {
+		_, err := svc.GetByID(ctx, 1)
+		done <- err
+	}()
+	waitFor(t, func() bool { return repo.gets.Load() == 1 })
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		sub, err := svc.GetByID(t.Context(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), sub.ID)
+	})
+	waitFor(t, func() bool { return true })
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	close(repo.release)
+	wg.Wait()
+	assert.EqualValues(t, 1, repo.gets.Load(), "the query outlives the canceled caller")
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+)
+
+// Domain errors returned by the services. Transports should map these
+// instead of repository errors; the repository error, if any, stays in the
+// chain for logs.
+var (
+	// ErrSubscriptionNotFound is returned when a subscription does not exist.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+
+	// ErrSubscriptionExists is returned when a subscription with the same
+	// user, service and start month already exists.
+	ErrSubscriptionExists = errors.New("subscription already exists")
+
+	// ErrForbidden is returned when the caller may not perform a write on
+	// behalf of another user.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrAdminRequired is returned when an operation is limited to admins.
+	ErrAdminRequired = errors.New("admin role required")
+
+	// ErrInvalidPeriod matches *PeriodError.
+	ErrInvalidPeriod = errors.New("invalid period")
+
+	// ErrLimitExceeded is returned when a user already has the maximum number
+	// of active subscriptions.
+	ErrLimitExceeded = errors.New("active subscription limit exceeded")
+
+	// ErrQuotaExceeded matches *QuotaError.
+	ErrQuotaExceeded = errors.New("write quota exceeded")
+
+	// ErrPriceChangeTooLarge matches *PriceChangeError.
+	ErrPriceChangeTooLarge = errors.New("price change too large")
+
+	// ErrMixedCurrencies is returned when amounts in different currencies
+	// would be added up, e.g. by a summary without a currency filter.
+	ErrMixedCurrencies = errors.New("mixed currencies")
+
+	// ErrReadOnly is returned for writes while the service is read-only.
+	ErrReadOnly = errors.New("service is read-only")
+
+	// ErrRejected matches *RejectedError.
+	ErrRejected = errors.New("rejected")
+
+	// ErrUserNotFound is returned when a user does not exist.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserNotProvisioned is returned when a subscription is written for a
+	// user not provisioned via UserService, with provisioned users required.
+	ErrUserNotProvisioned = errors.New("user not provisioned")
+
+	// ErrUserHasSubscriptions is returned when deleting a user who still
+	// has subscriptions.
+	ErrUserHasSubscriptions = errors.New("user has subscriptions")
+
+	// ErrUnknownUser is returned when a subscription is written for a user
+	// the UserValidator does not know.
+	ErrUnknownUser = errors.New("unknown user")
+
+	// ErrUserCheckFailed is returned when the UserValidator fails, e.g. the
+	// accounts service is down; the write may be retried later.
+	ErrUserCheckFailed = errors.New("user check failed")
+
+	// ErrSavedFilterNotFound is returned when a saved filter does not exist.
+	ErrSavedFilterNotFound = errors.New("saved filter not found")
+
+	// ErrSavedFilterExists is returned when a user already has a saved
+	// filter with the same name.
+	ErrSavedFilterExists = errors.New("saved filter already exists")
+
+	// ErrSummarySubscriptionNotFound is returned when a summary
+	// subscription does not exist.
+	ErrSummarySubscriptionNotFound = errors.New("summary subscription not found")
+
+	// ErrInvalidChannel is returned when a summary subscription names a
+	// notification channel that is not configured or does not send to
+	// recipients, or a recipient that does not suit the channel.
+	ErrInvalidChannel = errors.New("invalid notification channel")
+
+	// ErrInvalidConfirmation is returned when confirming a summary
+	// subscription with a token no subscription awaits.
+	ErrInvalidConfirmation = errors.New("invalid confirmation token")
+)
+
+// PeriodError is returned when a period ends before it starts.
+type PeriodError struct {
+	Start string // Name of the field holding the start, e.g. "start_date".
+	End   string // Name of the field holding the end, e.g. "end_date".
+}
+
+// Error returns the error message.
+func (e *PeriodError) Error() string {
+	return fmt.Sprintf("%s must not be before %s", e.End, e.Start)
+}
+
+// Is reports whether target is ErrInvalidPeriod.
+func (e *PeriodError) Is(target error) bool {
+	return target == ErrInvalidPeriod
+}
+
+// PriceChangeError is returned when an update changes the price by more
+// than allowed without confirmation.
+type PriceChangeError struct {
+	From, To   models.Price // Current and requested prices.
+	MaxPercent float64      // Largest change allowed without confirmation.
+}
+
+// Error returns the error message.
+func (e *PriceChangeError) Error() string {
+	return fmt.Sprintf("price change from %d to %d exceeds %g%%", e.From, e.To, e.MaxPercent)
+}
+
+// Is reports whether target is ErrPriceChangeTooLarge.
+func (e *PriceChangeError) Is(target error) bool {
+	return target == ErrPriceChangeTooLarge
+}
+
+// QuotaError is returned when a user exceeds the hourly write quota.
+type QuotaError struct {
+	Limit   int       // Writes allowed per hour.
+	ResetAt time.Time // Start of the next quota window.
+}
+
+// Error returns the error message.
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("write quota of %d per hour exceeded", e.Limit)
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ConflictError is returned when a created subscription duplicates an
+// existing one with the same user, service and start month. It wraps
+// ErrSubscriptionExists.
+type ConflictError struct {
+	ExistingID int64 // ID of the existing subscription.
+	Err        error
+}
+
+// Error returns the error message.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: conflicts with subscription %d", e.Err, e.ExistingID)
+}
+
+// Unwrap returns the wrapped ErrSubscriptionExists.
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// checkPeriod returns *PeriodError if end is set and before start.
+func checkPeriod(start models.MonthDate, end *models.MonthDate, startField, endField string) error {
+	if end != nil && !start.IsZero() && !end.IsZero() && end.Before(start.Time) {
+		return &PeriodError{Start: startField, End: endField}
+	}
+	return nil
+}
+
+// domainError wraps repository errors in the matching domain errors.
+func domainError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrSubscriptionNotFound, err)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %w", ErrSubscriptionExists, err)
+	case errors.Is(err, repository.ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %w", ErrUserNotProvisioned, err)
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		return fmt.Errorf("%w: %w", ErrMixedCurrencies, err)
+	case errors.Is(err, repository.ErrReadOnly):
+		return fmt.Errorf("%w: %w", ErrReadOnly, err)
+	default:
+		return err
+	}
+}
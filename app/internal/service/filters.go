@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SavedFilterRepo defines repository methods required by SavedFilterService.
+type SavedFilterRepo interface {
+	// Create inserts a saved filter; ErrDuplicate if the user has one with the same name.
+	Create(ctx context.Context, f *models.SavedFilter, opts ...repository.Option) error
+
+	// GetByID returns a saved filter by ID.
+	GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.SavedFilter, error)
+
+	// ListByUser returns the saved filters of a user ordered by name.
+	ListByUser(ctx context.Context, userID uuid.UUID, opts ...repository.Option) ([]models.SavedFilter, error)
+
+	// Update saves a saved filter of f.UserID.
+	Update(ctx context.Context, f *models.SavedFilter, opts ...repository.Option) error
+
+	// Delete removes a saved filter of a user.
+	Delete(ctx context.Context, userID uuid.UUID, id int64, opts ...repository.Option) error
+}
+
+// SavedFilterService manages the filter combinations users save to reuse
+// in list and summary requests. Users manage their own filters, admins
+// those of any user.
+type SavedFilterService struct {
+	repo   SavedFilterRepo
+	log    *zap.Logger
+	policy OwnershipPolicy
+}
+
+// NewSavedFilterService creates a new instance of SavedFilterService.
+func NewSavedFilterService(repo SavedFilterRepo, log *zap.Logger) *SavedFilterService {
+	return &SavedFilterService{repo: repo, log: log}
+}
+
+// Create saves a filter of f.UserID and fills its ID and timestamps.
+// Returns ErrForbidden if the caller is another user and
+// ErrSavedFilterExists if the user has a filter with the same name.
+func (s *SavedFilterService) Create(ctx context.Context, f *models.SavedFilter) error {
+	if err := s.policy.CanAssign(ctx, f.UserID); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, f); err != nil {
+		s.log.Error("failed to save filter", zap.Error(err), retryInfo(err))
+		return savedFilterError(err)
+	}
+
+	s.log.Info("filter saved", zap.String("user_id", f.UserID.String()), zap.Int64("id", f.ID))
+	return nil
+}
+
+// List returns the saved filters of a user ordered by name. Returns
+// ErrForbidden if the caller is another user.
+func (s *SavedFilterService) List(ctx context.Context, userID uuid.UUID) ([]models.SavedFilter, error) {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return nil, err
+	}
+	filters, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list saved filters", zap.Error(err), retryInfo(err))
+		return nil, savedFilterError(err)
+	}
+	return filters, nil
+}
+
+// Get returns a saved filter of a user. Returns ErrSavedFilterNotFound if
+// the user has no such filter and ErrForbidden if the caller is another
+// user.
+func (s *SavedFilterService) Get(ctx context.Context, userID uuid.UUID, id int64) (*models.SavedFilter, error) {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return nil, err
+	}
+	f, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, savedFilterError(err)
+	}
+	if f.UserID != userID {
+		return nil, ErrSavedFilterNotFound
+	}
+	return f, nil
+}
+
+// Update saves the name and filters of a saved filter of f.UserID and fills
+// its timestamps. Returns ErrSavedFilterNotFound if the user has no such
+// filter, ErrSavedFilterExists if the new name is taken and ErrForbidden if
+// the caller is another user.
+func (s *SavedFilterService) Update(ctx context.Context, f *models.SavedFilter) error {
+	if err := s.policy.CanAssign(ctx, f.UserID); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, f); err != nil {
+		return savedFilterError(err)
+	}
+
+	s.log.Info("saved filter updated", zap.String("user_id", f.UserID.String()), zap.Int64("id", f.ID))
+	return nil
+}
+
+// Delete removes a saved filter of a user. Returns ErrSavedFilterNotFound
+// if the user has no such filter and ErrForbidden if the caller is another
+// user.
+func (s *SavedFilterService) Delete(ctx context.Context, userID uuid.UUID, id int64) error {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return savedFilterError(err)
+	}
+
+	s.log.Info("saved filter deleted", zap.String("user_id", userID.String()), zap.Int64("id", id))
+	return nil
+}
+
+// Resolve returns the criteria of the saved filter passed as filter_id.
+// Returns ErrSavedFilterNotFound if it does not exist or belongs to another
+// user than the caller, so filters of other users are indistinguishable
+// from missing ones.
+func (s *SavedFilterService) Resolve(ctx context.Context, id int64) (models.FilterCriteria, error) {
+	f, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return models.FilterCriteria{}, savedFilterError(err)
+	}
+	if err := s.policy.CanAssign(ctx, f.UserID); err != nil {
+		return models.FilterCriteria{}, ErrSavedFilterNotFound
+	}
+	return f.Filter, nil
+}
+
+// savedFilterError wraps repository errors in the matching domain errors.
+func savedFilterError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrSavedFilterNotFound, err)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %w", ErrSavedFilterExists, err)
+	default:
+		return domainError(err)
+	}
+}
@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// filterRepo holds saved filters by ID.
+type filterRepo struct {
+	service.SavedFilterRepo
+
+	filters map[int64]models.SavedFilter
+}
+
+func (r *filterRepo) Create(_ context.Context, f *models.SavedFilter, _ ...repository.Option) error {
+	for _, existing := range r.filters {
+		if existing.UserID == f.UserID && existing.Name == f.Name {
+			return repository.ErrDuplicate
+		}
+	}
+	f.ID = int64(len(r.filters) + 1)
+	r.filters[f.ID] = *f
+	return nil
+}
+
+func (r *filterRepo) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.SavedFilter, error) {
+	f, ok := r.filters[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &f, nil
+}
+
+func (r *filterRepo) Delete(_ context.Context, userID uuid.UUID, id int64, _ ...repository.Option) error {
+	if f, ok := r.filters[id]; !ok || f.UserID != userID {
+		return repository.ErrNotFound
+	}
+	delete(r.filters, id)
+	return nil
+}
+
+func TestSavedFilterService(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	netflix := "Netflix"
+	svc := service.NewSavedFilterService(&filterRepo{filters: map[int64]models.SavedFilter{}}, zap.NewNop())
+	as := func(userID uuid.UUID, admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: userID, Admin: admin})
+	}
+
+	f := &models.SavedFilter{UserID: owner, Name: "netflix", Filter: models.FilterCriteria{ServiceName: &netflix}}
+	require.NoError(t, svc.Create(as(owner, false), f))
+	assert.ErrorIs(t, svc.Create(as(owner, false), &models.SavedFilter{UserID: owner, Name: "netflix"}), service.ErrSavedFilterExists)
+	assert.ErrorIs(t, svc.Create(as(other, false), &models.SavedFilter{UserID: owner, Name: "other"}), service.ErrForbidden)
+
+	criteria, err := svc.Resolve(as(owner, false), f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, f.Filter, criteria)
+	_, err = svc.Resolve(as(other, true), f.ID)
+	assert.NoError(t, err, "admins use filters of any user")
+	_, err = svc.Resolve(as(other, false), f.ID)
+	assert.ErrorIs(t, err, service.ErrSavedFilterNotFound, "filters of other users look missing")
+	_, err = svc.Resolve(t.Context(), 42)
+	assert.ErrorIs(t, err, service.ErrSavedFilterNotFound)
+
+	_, err = svc.Get(as(other, false), other, f.ID)
+	assert.ErrorIs(t, err, service.ErrSavedFilterNotFound, "a filter of another user in the path")
+	_, err = svc.Get(as(other, false), owner, f.ID)
+	assert.ErrorIs(t, err, service.ErrForbidden)
+
+	assert.ErrorIs(t, svc.Delete(as(owner, false), other, f.ID), service.ErrForbidden)
+	require.NoError(t, svc.Delete(as(owner, false), owner, f.ID))
+	assert.ErrorIs(t, svc.Delete(as(owner, false), owner, f.ID), service.ErrSavedFilterNotFound)
+}
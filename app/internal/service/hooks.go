@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"subscriptionsservice/internal/models"
+)
+
+// Hook runs before an operation. It may modify v, e.g. to enrich a
+// subscription, or reject the operation by returning an error.
+type Hook[T any] func(ctx context.Context, v T) error
+
+// Observer runs after an operation succeeded, e.g. a write was committed.
+// It cannot fail the operation.
+type Observer[T any] func(ctx context.Context, v T)
+
+// Hooks are extension points in the operations of SubscriptionService, so
+// tenant-specific behavior (enrichment, extra validation) can be added from
+// the application wiring without changing the handlers.
+//
+// Before hooks run in registration order before the built-in checks of the
+// operation; the first error stops the operation and is returned as is.
+// To be reported to the client as a client error it should be a
+// *RejectedError or the result of models.Validate. After hooks run in
+// registration order once the operation succeeded.
+//
+// Hooks must be registered before the service is used; registration is not
+// synchronized with the operations.
+type Hooks struct {
+	beforeCreate  []Hook[*models.Subscription]
+	afterCreate   []Observer[*models.Subscription]
+	beforeUpdate  []Hook[*models.Subscription]
+	afterUpdate   []Observer[*models.Subscription]
+	beforeDelete  []Hook[int64]
+	afterDelete   []Observer[int64]
+	beforeSummary []Hook[*models.SummaryRequest]
+	afterSummary  []Observer[*models.Summary]
+}
+
+// NewHooks creates an empty hook registry.
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// BeforeCreate registers fn to run before a subscription is created.
+func (h *Hooks) BeforeCreate(fn Hook[*models.Subscription]) {
+	h.beforeCreate = append(h.beforeCreate, fn)
+}
+
+// AfterCreate registers fn to run after a subscription was created.
+func (h *Hooks) AfterCreate(fn Observer[*models.Subscription]) {
+	h.afterCreate = append(h.afterCreate, fn)
+}
+
+// BeforeUpdate registers fn to run before a subscription is updated.
+func (h *Hooks) BeforeUpdate(fn Hook[*models.Subscription]) {
+	h.beforeUpdate = append(h.beforeUpdate, fn)
+}
+
+// AfterUpdate registers fn to run after a subscription was updated.
+func (h *Hooks) AfterUpdate(fn Observer[*models.Subscription]) {
+	h.afterUpdate = append(h.afterUpdate, fn)
+}
+
+// BeforeDelete registers fn to run before a subscription is deleted by ID.
+func (h *Hooks) BeforeDelete(fn Hook[int64]) {
+	h.beforeDelete = append(h.beforeDelete, fn)
+}
+
+// AfterDelete registers fn to run after a subscription was deleted by ID.
+func (h *Hooks) AfterDelete(fn Observer[int64]) {
+	h.afterDelete = append(h.afterDelete, fn)
+}
+
+// BeforeSummary registers fn to run before a summary is calculated, e.g. to
+// restrict the request to a tenant's services.
+func (h *Hooks) BeforeSummary(fn Hook[*models.SummaryRequest]) {
+	h.beforeSummary = append(h.beforeSummary, fn)
+}
+
+// AfterSummary registers fn to run after a summary was calculated.
+func (h *Hooks) AfterSummary(fn Observer[*models.Summary]) {
+	h.afterSummary = append(h.afterSummary, fn)
+}
+
+// runBefore runs hooks until one fails.
+func runBefore[T any](ctx context.Context, hooks []Hook[T], v T) error {
+	for _, hook := range hooks {
+		if err := hook(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfter runs all observers.
+func runAfter[T any](ctx context.Context, observers []Observer[T], v T) {
+	for _, observe := range observers {
+		observe(ctx, v)
+	}
+}
+
+// RejectedError is returned by a Hook rejecting an operation.
+type RejectedError struct {
+	Reason string // Why the operation was rejected, shown to the client.
+}
+
+// Error returns the error message.
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("rejected: %s", e.Reason)
+}
+
+// Is reports whether target is ErrRejected.
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
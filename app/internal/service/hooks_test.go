@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSubscriptionService_Hooks(t *testing.T) {
+	newSub := func(name string) *models.Subscription {
+		return &models.Subscription{ServiceName: name, Price: 100, UserID: uuid.New(), StartDate: *monthDate(2025, time.July)}
+	}
+
+	t.Run("before hooks enrich and reject in order", func(t *testing.T) {
+		repo := &fakeRepo{}
+		hooks := service.NewHooks()
+		var calls []string
+		hooks.BeforeCreate(func(_ context.Context, sub *models.Subscription) error {
+			calls = append(calls, "enrich")
+			sub.Trial = sub.Price == 0
+			return nil
+		})
+		hooks.BeforeCreate(func(_ context.Context, sub *models.Subscription) error {
+			calls = append(calls, "whitelist")
+			if sub.ServiceName != "Netflix" {
+				return &service.RejectedError{Reason: "service is not allowed for the tenant"}
+			}
+			return nil
+		})
+		var created []int64
+		hooks.AfterCreate(func(_ context.Context, sub *models.Subscription) {
+			created = append(created, sub.ID)
+		})
+		svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithHooks(hooks))
+
+		err := svc.CreateSubscription(t.Context(), newSub("Hulu"))
+		require.ErrorIs(t, err, service.ErrRejected)
+		assert.Equal(t, []string{"enrich", "whitelist"}, calls)
+		assert.Zero(t, repo.created, "a rejected subscription is not created")
+		assert.Empty(t, created)
+
+		sub := newSub("Netflix")
+		sub.Price = 0
+		require.NoError(t, svc.CreateSubscription(t.Context(), sub))
+		assert.True(t, sub.Trial, "enriched before it is stored")
+		assert.Equal(t, []int64{sub.ID}, created)
+	})
+
+	t.Run("first failure stops the operation", func(t *testing.T) {
+		hooks := service.NewHooks()
+		errFirst := errors.New("first")
+		hooks.BeforeSummary(func(context.Context, *models.SummaryRequest) error { return errFirst })
+		hooks.BeforeSummary(func(context.Context, *models.SummaryRequest) error {
+			t.Error("not run after a failure")
+			return nil
+		})
+		svc := service.NewSubscriptionService(&fakeRepo{}, zap.NewNop(), service.WithHooks(hooks))
+
+		_, err := svc.Summary(t.Context(), &models.SummaryRequest{})
+		assert.ErrorIs(t, err, errFirst)
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		svc := service.NewSubscriptionService(&fakeRepo{}, zap.NewNop())
+		assert.NoError(t, svc.CreateSubscription(t.Context(), newSub("Netflix")))
+	})
+}
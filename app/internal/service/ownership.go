@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// OwnershipPolicy decides whether the caller in the context may access a
+// subscription: its owner and admins may, others may not. Without a caller
+// (auth disabled) everything is allowed.
+type OwnershipPolicy struct{}
+
+// CanRead returns ErrSubscriptionNotFound if the caller may not access sub,
+// so subscriptions of other users are indistinguishable from missing ones.
+func (OwnershipPolicy) CanRead(ctx context.Context, sub *models.Subscription) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Admin || p.UserID == sub.UserID {
+		return nil
+	}
+	return ErrSubscriptionNotFound
+}
+
+// CanAssign returns ErrForbidden if the caller may not make userID the owner
+// of a subscription, i.e. create or move one on behalf of another user.
+func (OwnershipPolicy) CanAssign(ctx context.Context, userID uuid.UUID) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Admin || p.UserID == userID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// Restrict returns the user whose subscriptions the caller may read by a
+// query of those of userID, nil for all users: the caller for a restricted
+// caller, who gets ErrForbidden for another user.
+func (p OwnershipPolicy) Restrict(ctx context.Context, userID *uuid.UUID) (*uuid.UUID, error) {
+	if userID != nil {
+		return userID, p.CanAssign(ctx, *userID)
+	}
+	if caller, ok := auth.FromContext(ctx); ok && !caller.Admin {
+		return &caller.UserID, nil
+	}
+	return nil, nil
+}
+
+// CanInspect returns ErrAdminRequired unless the caller is an admin, e.g.
+// for debug output listing subscriptions of all users.
+func (OwnershipPolicy) CanInspect(ctx context.Context) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Admin {
+		return nil
+	}
+	return ErrAdminRequired
+}
+
+// Enforced reports whether ctx carries a caller whose access is restricted.
+func (OwnershipPolicy) Enforced(ctx context.Context) bool {
+	p, ok := auth.FromContext(ctx)
+	return ok && !p.Admin
+}
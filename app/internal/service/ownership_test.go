@@ -0,0 +1,310 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// ownedRepo holds subscriptions by ID and records writes.
+type ownedRepo struct {
+	service.SubscriptionRepo
+
+	subs    map[int64]*models.Subscription
+	updated int
+	deleted int
+}
+
+func (r *ownedRepo) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.Subscription, error) {
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (r *ownedRepo) Exists(_ context.Context, id int64, _ ...repository.Option) (bool, error) {
+	_, ok := r.subs[id]
+	return ok, nil
+}
+
+func (r *ownedRepo) Update(_ context.Context, s *models.Subscription, _ ...repository.Option) error {
+	r.updated++
+	return nil
+}
+
+func (r *ownedRepo) UpdatePartial(_ context.Context, id int64, p models.SubscriptionPatch, _ ...repository.Option) (*models.Subscription, error) {
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	r.updated++
+	*sub = p.Apply(*sub)
+	cp := *sub
+	return &cp, nil
+}
+
+func (r *ownedRepo) Delete(_ context.Context, id int64, _ ...repository.Option) error {
+	r.deleted++
+	return nil
+}
+
+func (r *ownedRepo) DeleteReturning(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	r.deleted++
+	return r.GetByID(ctx, id, opts...)
+}
+
+func (r *ownedRepo) List(_ context.Context, f models.SubscriptionFilter, _, _ int, _ ...repository.Option) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	for _, sub := range r.subs {
+		if f.UserID == nil || *f.UserID == sub.UserID {
+			subs = append(subs, *sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *ownedRepo) Summary(context.Context, *models.SummaryRequest, ...repository.Option) (models.Summary, error) {
+	return models.Summary{Count: len(r.subs)}, nil
+}
+
+func (r *ownedRepo) SummaryBreakdown(_ context.Context, req *models.SummaryRequest, _ ...repository.Option) (models.SummaryBreakdown, error) {
+	return models.SummaryBreakdown{GroupBy: req.GroupBy}, nil
+}
+
+func (r *ownedRepo) ActiveOn(ctx context.Context, _ models.MonthDate, f models.SubscriptionFilter, limit, offset int, opts ...repository.Option) ([]models.Subscription, error) {
+	return r.List(ctx, f, limit, offset, opts...)
+}
+
+func (r *ownedRepo) Overlaps(context.Context, uuid.UUID, ...repository.Option) ([]models.Overlap, error) {
+	return nil, nil
+}
+
+func (r *ownedRepo) ExplainSummary(context.Context, *models.SummaryRequest, ...repository.Option) (models.Summary, error) {
+	return models.Summary{Count: len(r.subs)}, nil
+}
+
+func TestSubscriptionService_ExplainSummaryAdminOnly(t *testing.T) {
+	svc := service.NewSubscriptionService(&ownedRepo{}, zap.NewNop())
+	as := func(admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: uuid.New(), Admin: admin})
+	}
+
+	callers := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"auth disabled", t.Context(), nil},
+		{"admin", as(true), nil},
+		{"user", as(false), service.ErrAdminRequired},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.ExplainSummary(c.ctx, &models.SummaryRequest{})
+			assert.ErrorIs(t, err, c.want)
+		})
+	}
+}
+
+func TestSubscriptionService_Ownership(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	newService := func() (*service.SubscriptionService, *ownedRepo) {
+		repo := &ownedRepo{subs: map[int64]*models.Subscription{
+			1: {ID: 1, ServiceName: "Netflix", UserID: owner, StartDate: *monthDate(2025, time.July)},
+		}}
+		return service.NewSubscriptionService(repo, zap.NewNop()), repo
+	}
+	as := func(userID uuid.UUID, admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: userID, Admin: admin})
+	}
+
+	callers := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"auth disabled", t.Context(), nil},
+		{"owner", as(owner, false), nil},
+		{"admin", as(stranger, true), nil},
+		{"other user", as(stranger, false), service.ErrSubscriptionNotFound},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			svc, repo := newService()
+
+			_, err := svc.GetByID(c.ctx, 1)
+			assert.ErrorIs(t, err, c.want, "get")
+
+			exists, err := svc.Exists(c.ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, c.want == nil, exists, "exists")
+
+			err = svc.Update(c.ctx, &models.Subscription{ID: 1, ServiceName: "Netflix", UserID: owner})
+			assert.ErrorIs(t, err, c.want, "update")
+
+			price := models.Price(599)
+			_, err = svc.Patch(c.ctx, 1, models.SubscriptionPatch{Price: &price})
+			assert.ErrorIs(t, err, c.want, "patch")
+
+			err = svc.Delete(c.ctx, 1)
+			assert.ErrorIs(t, err, c.want, "delete")
+
+			_, err = svc.DeleteReturning(c.ctx, 1)
+			assert.ErrorIs(t, err, c.want, "delete returning")
+
+			if c.want != nil {
+				assert.Zero(t, repo.updated)
+				assert.Zero(t, repo.deleted)
+			}
+		})
+	}
+
+	t.Run("missing subscription", func(t *testing.T) {
+		svc, _ := newService()
+		err := svc.Delete(as(owner, false), 2)
+		assert.ErrorIs(t, err, service.ErrSubscriptionNotFound)
+	})
+
+	t.Run("owner cannot move a subscription to another user", func(t *testing.T) {
+		svc, repo := newService()
+		err := svc.Update(as(owner, false), &models.Subscription{ID: 1, ServiceName: "Netflix", UserID: stranger})
+		assert.ErrorIs(t, err, service.ErrForbidden)
+		assert.Zero(t, repo.updated)
+	})
+
+	t.Run("user cannot create a subscription for another user", func(t *testing.T) {
+		svc, _ := newService()
+		err := svc.CreateSubscription(as(stranger, false), &models.Subscription{ServiceName: "Netflix", UserID: owner})
+		assert.ErrorIs(t, err, service.ErrForbidden)
+	})
+}
+
+func TestSubscriptionService_ListOwnership(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	svc := service.NewSubscriptionService(&ownedRepo{subs: map[int64]*models.Subscription{
+		1: {ID: 1, ServiceName: "Netflix", UserID: owner},
+		2: {ID: 2, ServiceName: "Spotify", UserID: stranger},
+	}}, zap.NewNop())
+	as := func(userID uuid.UUID, admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: userID, Admin: admin})
+	}
+	ids := func(subs []models.Subscription) []int64 {
+		var ids []int64
+		for _, sub := range subs {
+			ids = append(ids, sub.ID)
+		}
+		return ids
+	}
+
+	subs, err := svc.List(as(owner, false), models.SubscriptionFilter{UserID: &owner}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids(subs), "own subscriptions")
+
+	subs, err = svc.List(as(owner, false), models.SubscriptionFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids(subs), "without user_id only the caller's")
+
+	_, err = svc.List(as(owner, false), models.SubscriptionFilter{UserID: &stranger}, 10, 0)
+	assert.ErrorIs(t, err, service.ErrForbidden, "another user's")
+
+	subs, err = svc.List(as(owner, true), models.SubscriptionFilter{UserID: &stranger}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(subs), "admins list anyone's")
+
+	subs, err = svc.List(t.Context(), models.SubscriptionFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, ids(subs), "auth disabled")
+}
+
+func TestSubscriptionService_UserBreakdownAdminOnly(t *testing.T) {
+	svc := service.NewSubscriptionService(&ownedRepo{}, zap.NewNop())
+	as := func(admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: uuid.New(), Admin: admin})
+	}
+	req := func(groupBy models.SummaryGroupBy) *models.SummaryRequest {
+		return &models.SummaryRequest{
+			From:    *monthDate(2025, time.January),
+			To:      *monthDate(2025, time.December),
+			GroupBy: groupBy,
+		}
+	}
+
+	_, err := svc.Summary(as(false), req(models.GroupByUser))
+	assert.ErrorIs(t, err, service.ErrAdminRequired, "spend of every user")
+
+	sum, err := svc.Summary(as(true), req(models.GroupByUser))
+	require.NoError(t, err)
+	require.NotNil(t, sum.Breakdown)
+	assert.Equal(t, models.GroupByUser, sum.Breakdown.GroupBy)
+
+	_, err = svc.Summary(t.Context(), req(models.GroupByUser))
+	assert.NoError(t, err, "auth disabled")
+
+	_, err = svc.Summary(as(false), req(models.GroupByService))
+	assert.NoError(t, err, "by service")
+}
+
+func TestSubscriptionService_PerUserReadsOwnership(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	svc := service.NewSubscriptionService(&ownedRepo{subs: map[int64]*models.Subscription{
+		1: {ID: 1, ServiceName: "Netflix", UserID: owner},
+		2: {ID: 2, ServiceName: "Spotify", UserID: stranger},
+	}}, zap.NewNop())
+	as := func(userID uuid.UUID, admin bool) context.Context {
+		return auth.WithPrincipal(t.Context(), auth.Principal{UserID: userID, Admin: admin})
+	}
+	summary := func(userID uuid.UUID) *models.SummaryRequest {
+		id := userID.String()
+		return &models.SummaryRequest{From: *monthDate(2025, time.January), To: *monthDate(2025, time.December), UserID: &id}
+	}
+	activeOn := func(userID *uuid.UUID) *models.ActiveOnRequest {
+		req := &models.ActiveOnRequest{On: *monthDate(2025, time.July)}
+		if userID != nil {
+			id := userID.String()
+			req.UserID = &id
+		}
+		return req
+	}
+
+	callers := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"auth disabled", t.Context(), nil},
+		{"owner", as(owner, false), nil},
+		{"admin", as(stranger, true), nil},
+		{"other user", as(stranger, false), service.ErrForbidden},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := svc.Overlaps(c.ctx, owner)
+			assert.ErrorIs(t, err, c.want, "overlaps")
+
+			_, err = svc.Summary(c.ctx, summary(owner))
+			assert.ErrorIs(t, err, c.want, "summary")
+
+			_, err = svc.ActiveOn(c.ctx, activeOn(&owner), 10, 0)
+			assert.ErrorIs(t, err, c.want, "active on")
+		})
+	}
+
+	t.Run("active on without user_id", func(t *testing.T) {
+		subs, err := svc.ActiveOn(as(owner, false), activeOn(nil), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, subs, 1)
+		assert.Equal(t, owner, subs[0].UserID, "only the caller's")
+	})
+}
@@ -2,11 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"subscriptionsservice/internal/audit"
+	"subscriptionsservice/internal/cache"
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/events"
 	"subscriptionsservice/internal/models"
 	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/retry"
+	"subscriptionsservice/internal/tenant"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 // SubscriptionRepo defines repository methods required by SubscriptionService.
@@ -17,99 +33,1116 @@ type SubscriptionRepo interface {
 	// GetByID returns a subscription by its ID.
 	GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error)
 
-	// List returns all subscriptions.
-	List(ctx context.Context, limit, offset int, opts ...repository.Option) ([]models.Subscription, error)
+	// GetByKey returns a subscription by user, service name and start month.
+	GetByKey(ctx context.Context, userID uuid.UUID, serviceName string, startDate models.MonthDate, opts ...repository.Option) (*models.Subscription, error)
+
+	// Exists reports whether a subscription with the given ID exists.
+	Exists(ctx context.Context, id int64, opts ...repository.Option) (bool, error)
+
+	// CountActive returns the number of the user's subscriptions active in month or later.
+	CountActive(ctx context.Context, userID uuid.UUID, month models.MonthDate, opts ...repository.Option) (int, error)
+
+	// List returns subscriptions matching filter.
+	List(ctx context.Context, filter models.SubscriptionFilter, limit, offset int, opts ...repository.Option) ([]models.Subscription, error)
+
+	// ActiveOn returns subscriptions matching filter whose period covers month.
+	ActiveOn(ctx context.Context, month models.MonthDate, filter models.SubscriptionFilter, limit, offset int, opts ...repository.Option) ([]models.Subscription, error)
+
+	// DistinctServices returns service names starting with prefix with their numbers of subscriptions.
+	DistinctServices(ctx context.Context, prefix string, limit, offset int, opts ...repository.Option) ([]models.ServiceCount, error)
+
+	// Overlaps returns pairs of the user's subscriptions to the same service with overlapping periods.
+	Overlaps(ctx context.Context, userID uuid.UUID, opts ...repository.Option) ([]models.Overlap, error)
 
 	// Update modifies an existing subscription.
 	Update(ctx context.Context, s *models.Subscription, opts ...repository.Option) error
 
+	// UpdatePartial sets the fields given in p and returns the updated subscription.
+	UpdatePartial(ctx context.Context, id int64, p models.SubscriptionPatch, opts ...repository.Option) (*models.Subscription, error)
+
 	// Delete removes a subscription by ID.
 	Delete(ctx context.Context, id int64, opts ...repository.Option) error
 
+	// DeleteReturning removes a subscription by ID and returns it.
+	DeleteReturning(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error)
+
 	// Summary returns the sum of subscription prices matching the query.
-	Summary(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (int, error)
+	Summary(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.Summary, error)
+
+	// ExplainSummary returns the summary with the contribution of each subscription.
+	ExplainSummary(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.Summary, error)
+
+	// SummaryBreakdown returns a page of the summary total broken down by q.GroupBy.
+	SummaryBreakdown(ctx context.Context, q *models.SummaryRequest, opts ...repository.Option) (models.SummaryBreakdown, error)
+}
+
+// WriteQuotaRepo defines methods required to account per-user write quotas.
+type WriteQuotaRepo interface {
+	// IncrementWrites increments the user's write counter for a window and returns it.
+	IncrementWrites(ctx context.Context, userID uuid.UUID, windowStart time.Time, opts ...repository.Option) (int, error)
+
+	// DeleteWindowsBefore removes the user's counters of windows started before t.
+	DeleteWindowsBefore(ctx context.Context, userID uuid.UUID, t time.Time, opts ...repository.Option) (int64, error)
+}
+
+// TenantLimitsSource returns the limit overrides of tenants.
+type TenantLimitsSource interface {
+	// Limits returns the limit overrides of a tenant.
+	Limits(ctx context.Context, id tenant.ID) (models.TenantLimits, error)
+}
+
+// UserValidator checks that the users subscriptions are attached to exist,
+// e.g. in an accounts service.
+type UserValidator interface {
+	// UserExists reports whether the user exists.
+	UserExists(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// ResponseInvalidator drops cached responses that may include subscriptions,
+// e.g. middleware.Responses.
+type ResponseInvalidator interface {
+	Invalidate()
+}
+
+// Transactor runs functions in a transaction, e.g. repository.TxManager.
+type Transactor interface {
+	Do(ctx context.Context, fn repository.TxFunc, opts ...repository.Option) error
 }
 
 // SubscriptionService provides business logic for managing subscriptions.
 type SubscriptionService struct {
 	repo SubscriptionRepo
 	log  *zap.Logger
+
+	limits    atomic.Pointer[Limits]
+	tenants   TenantLimitsSource
+	users     UserValidator
+	quotas    WriteQuotaRepo
+	events    events.Publisher
+	services  *cache.TTL[servicesKey, []models.ServiceCount]
+	summaries *cache.TTL[summaryKey, models.Summary]
+	responses ResponseInvalidator
+	tx        Transactor
+	reads     singleflight.Group // Coalesces identical concurrent reads, see coalesce
+	policy    OwnershipPolicy
+	audit     audit.Recorder
+	writes    audit.Recorder // Audits writes, see WithWriteAudit.
+	hooks     *Hooks
+	now       func() time.Time
+}
+
+// Limits are per-user usage limits. Zero or negative disables a limit.
+type Limits struct {
+	MaxActivePerUser int     // Active subscriptions per user
+	WritesPerHour    int     // Creates and updates per user per hour, needs WithWriteQuota
+	MaxPriceChange   float64 // Price change per update in percent without AllowPriceChange
+}
+
+// servicesKey identifies a cached DistinctServices result.
+type servicesKey struct {
+	schema        string // Of the request's tenant, see tenant.SchemaFromContext
+	prefix        string
+	limit, offset int
+}
+
+// servicesCacheSize bounds the number of cached DistinctServices results.
+const servicesCacheSize = 1000
+
+// summaryKey identifies a cached summary of a user's subscriptions.
+type summaryKey struct {
+	schema   string // Of the request's tenant, see tenant.SchemaFromContext
+	userID   uuid.UUID
+	from, to int64 // Unix seconds of the period months
+}
+
+// summaryCacheSize bounds the number of cached summaries.
+const summaryCacheSize = 10000
+
+// Option configures SubscriptionService.
+type Option func(*SubscriptionService)
+
+// WithMaxActivePerUser limits the number of active subscriptions a user may
+// have when creating new ones. Zero or negative means no limit.
+func WithMaxActivePerUser(n int) Option {
+	return func(s *SubscriptionService) {
+		s.updateLimits(func(l *Limits) { l.MaxActivePerUser = n })
+	}
+}
+
+// WithWriteQuota limits the number of creates and updates per user in a
+// fixed hourly window, counted in quotas. Zero or negative means no limit.
+func WithWriteQuota(quotas WriteQuotaRepo, perHour int) Option {
+	return func(s *SubscriptionService) {
+		s.quotas = quotas
+		s.updateLimits(func(l *Limits) { l.WritesPerHour = perHour })
+	}
+}
+
+// WithPublisher publishes subscription.* events to p after successful writes.
+func WithPublisher(p events.Publisher) Option {
+	return func(s *SubscriptionService) {
+		s.events = p
+	}
+}
+
+// WithServicesCache caches distinct service names for ttl. Writes through
+// the service drop the cache; writes by other instances show up after ttl.
+func WithServicesCache(ttl time.Duration) Option {
+	return func(s *SubscriptionService) {
+		s.services = cache.New[servicesKey, []models.ServiceCount](ttl, servicesCacheSize)
+	}
+}
+
+// WithResponseCache drops the responses cached in r on writes through the
+// service, once they are committed and before they are answered, so a
+// client reading right after its write sees it. Writes by other instances
+// show up once the responses expire.
+func WithResponseCache(r ResponseInvalidator) Option {
+	return func(s *SubscriptionService) {
+		s.responses = r
+	}
+}
+
+// WithTransactor runs read-check-write operations, e.g. Patch, in
+// transactions of tx, locking the subscription from the read until the write.
+// Without it they are not protected against concurrent writes.
+func WithTransactor(tx Transactor) Option {
+	return func(s *SubscriptionService) {
+		s.tx = tx
+	}
+}
+
+// WithSummaryCache caches for ttl summaries of a single user without other
+// filters or a breakdown, as requested by dashboards (see SummaryWarmer).
+// Writes through the service drop the summaries of the users they change, so
+// Update always loads the current subscription to learn its previous user;
+// writes by other instances show up after ttl.
+func WithSummaryCache(ttl time.Duration) Option {
+	return func(s *SubscriptionService) {
+		s.summaries = cache.New[summaryKey, models.Summary](ttl, summaryCacheSize)
+	}
+}
+
+// WithPriceChangeGuard rejects updates changing the price by more than
+// maxPercent percent unless AllowPriceChange is passed, protecting reports
+// from fat-finger edits. Zero or negative means no limit.
+func WithPriceChangeGuard(maxPercent float64) Option {
+	return func(s *SubscriptionService) {
+		s.updateLimits(func(l *Limits) { l.MaxPriceChange = maxPercent })
+	}
+}
+
+// WithAudit records audited actions, e.g. guarded price changes, to r.
+func WithAudit(r audit.Recorder) Option {
+	return func(s *SubscriptionService) {
+		s.audit = r
+	}
+}
+
+// WithWriteAudit records creations, updates and deletions of subscriptions
+// with their changes to r, e.g. for activity feeds. To audit the changes,
+// Update always loads the current subscription and Delete returns the
+// deleted one.
+func WithWriteAudit(r audit.Recorder) Option {
+	return func(s *SubscriptionService) {
+		s.writes = r
+	}
+}
+
+// WithTenantLimits applies the limit overrides of the request's tenant
+// from src over the service-wide limits.
+func WithTenantLimits(src TenantLimitsSource) Option {
+	return func(s *SubscriptionService) {
+		s.tenants = src
+	}
+}
+
+// WithUserValidator rejects creating subscriptions of users v does not know
+// and moving subscriptions to them with ErrUnknownUser.
+func WithUserValidator(v UserValidator) Option {
+	return func(s *SubscriptionService) {
+		s.users = v
+	}
+}
+
+// WithHooks runs the extension hooks registered in h.
+func WithHooks(h *Hooks) Option {
+	return func(s *SubscriptionService) {
+		s.hooks = h
+	}
+}
+
+// UpdateOption configures a single Update call.
+type UpdateOption func(*updateOptions)
+
+type updateOptions struct {
+	allowPriceChange bool
+}
+
+// AllowPriceChange confirms a price change exceeding WithPriceChangeGuard.
+func AllowPriceChange() UpdateOption {
+	return func(o *updateOptions) {
+		o.allowPriceChange = true
+	}
 }
 
 // NewSubscriptionService creates a new instance of SubscriptionService.
-func NewSubscriptionService(repo SubscriptionRepo, log *zap.Logger) *SubscriptionService {
-	return &SubscriptionService{
-		repo: repo,
-		log:  log,
+func NewSubscriptionService(repo SubscriptionRepo, log *zap.Logger, opts ...Option) *SubscriptionService {
+	s := &SubscriptionService{
+		repo:   repo,
+		log:    log,
+		audit:  audit.Discard,
+		writes: audit.Discard,
+		hooks:  NewHooks(),
+		now:    time.Now,
+	}
+	s.limits.Store(&Limits{})
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// Limits returns the current usage limits.
+func (s *SubscriptionService) Limits() Limits {
+	return *s.limits.Load()
+}
+
+// SetLimits replaces the usage limits at runtime; requests in flight may
+// still use the previous ones.
+func (s *SubscriptionService) SetLimits(l Limits) {
+	s.limits.Store(&l)
+}
+
+// limitsFor returns the limits applying to a request: the service-wide ones
+// with the overrides of the request's tenant, if any.
+func (s *SubscriptionService) limitsFor(ctx context.Context) (Limits, error) {
+	l := *s.limits.Load()
+	id, ok := tenant.FromContext(ctx)
+	if s.tenants == nil || !ok {
+		return l, nil
+	}
+
+	o, err := s.tenants.Limits(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get tenant limits", zap.String("tenant", string(id)), zap.Error(err))
+		return l, err
+	}
+	if o.MaxActivePerUser != nil {
+		l.MaxActivePerUser = *o.MaxActivePerUser
+	}
+	if o.WritesPerUserPerHour != nil {
+		l.WritesPerHour = *o.WritesPerUserPerHour
+	}
+	if o.MaxPriceChangePercent != nil {
+		l.MaxPriceChange = *o.MaxPriceChangePercent
+	}
+	return l, nil
+}
+
+func (s *SubscriptionService) updateLimits(f func(*Limits)) {
+	l := *s.limits.Load()
+	f(&l)
+	s.limits.Store(&l)
 }
 
 // CreateSubscription adds a new subscription to the repository.
+// Returns *PeriodError if the subscription ends before it starts,
+// ErrForbidden if the caller creates it for another user,
+// ErrUnknownUser if WithUserValidator does not know the user,
+// ErrSubscriptionExists for a duplicate, as *ConflictError if the existing
+// subscription could be looked up, ErrLimitExceeded if the user has
+// reached the active subscription limit and *QuotaError if the user has
+// exceeded the write quota.
 func (s *SubscriptionService) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
 	s.log.Info("creating subscription", zap.String("service_name", sub.ServiceName))
-	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
-		s.log.Error("failed to create subscription", zap.Error(err))
+	if err := runBefore(ctx, s.hooks.beforeCreate, sub); err != nil {
+		return err
+	}
+	sub.Currency = sub.Currency.OrDefault()
+	if err := checkPeriod(sub.StartDate, sub.EndDate, "start_date", "end_date"); err != nil {
+		return err
+	}
+	if err := s.policy.CanAssign(ctx, sub.UserID); err != nil {
+		return err
+	}
+	if err := s.checkUser(ctx, sub.UserID); err != nil {
+		return err
+	}
+	limits, err := s.limitsFor(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.checkWriteQuota(ctx, sub.UserID, limits.WritesPerHour); err != nil {
 		return err
 	}
+	if err := s.checkActiveLimit(ctx, sub, limits.MaxActivePerUser); err != nil {
+		return err
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return s.conflict(ctx, sub, domainError(err))
+		}
+		s.log.Error("failed to create subscription", zap.Error(err), retryInfo(err))
+		return domainError(err)
+	}
 	s.log.Info("subscription created", zap.Int64("id", sub.ID))
+	s.writes.Record(ctx, audit.Event{
+		Action:         audit.ActionCreated,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Details:        subscriptionDetails(sub),
+	})
+	s.changed(ctx, events.TypeSubscriptionCreated, sub, sub.UserID)
+	runAfter(ctx, s.hooks.afterCreate, sub)
+	return nil
+}
+
+// conflict returns *ConflictError with the ID of the subscription sub
+// duplicates. If it cannot be looked up, e.g. it was deleted meanwhile,
+// err is returned as is.
+func (s *SubscriptionService) conflict(ctx context.Context, sub *models.Subscription, err error) error {
+	existing, lookupErr := s.repo.GetByKey(ctx, sub.UserID, sub.ServiceName, sub.StartDate)
+	if lookupErr != nil {
+		s.log.Warn("failed to get conflicting subscription", zap.Error(lookupErr))
+		return err
+	}
+	s.log.Info("subscription already exists", zap.Int64("existing_id", existing.ID))
+	return &ConflictError{ExistingID: existing.ID, Err: err}
+}
+
+// changed runs after a successful write: it drops cached reads and publishes
+// the event once the write is committed (see repository.AfterCommit).
+// Cached summaries are dropped for users, or for everyone if the changed
+// users are not known.
+func (s *SubscriptionService) changed(ctx context.Context, eventType string, data any, users ...uuid.UUID) {
+	repository.AfterCommit(ctx, func() {
+		s.services.Clear()
+		if s.responses != nil {
+			s.responses.Invalidate()
+		}
+		if len(users) == 0 {
+			s.summaries.Clear()
+		} else {
+			schema := tenant.SchemaFromContext(ctx)
+			s.summaries.DeleteFunc(func(k summaryKey) bool {
+				return k.schema == schema && slices.Contains(users, k.userID)
+			})
+		}
+		s.publish(ctx, eventType, data)
+	})
+}
+
+// publish emits a domain event if a publisher is configured. The change is
+// already committed, so failures are only logged.
+func (s *SubscriptionService) publish(ctx context.Context, eventType string, data any) {
+	if s.events == nil {
+		return
+	}
+	// Event schemas fix the MM-YYYY format regardless of app.date_format.
+	data = models.FormatDates(data, models.DateFormatMonthYear)
+	if err := s.events.Publish(ctx, events.New(eventType, data, s.now())); err != nil {
+		s.log.Error("failed to publish event", zap.String("type", eventType), zap.Error(err))
+	}
+}
+
+// checkWriteQuota counts a write of the user and returns *QuotaError if the
+// hourly quota is exceeded. Rejected writes are counted too. The first write
+// in a window removes the user's counters of previous windows.
+func (s *SubscriptionService) checkWriteQuota(ctx context.Context, userID uuid.UUID, limit int) error {
+	if s.quotas == nil || limit <= 0 {
+		return nil
+	}
+
+	window := s.now().UTC().Truncate(time.Hour)
+	count, err := s.quotas.IncrementWrites(ctx, userID, window)
+	if err != nil {
+		s.log.Error("failed to count write", zap.Error(err), retryInfo(err))
+		return domainError(err)
+	}
+
+	if count == 1 {
+		if _, err := s.quotas.DeleteWindowsBefore(ctx, userID, window); err != nil {
+			s.log.Warn("failed to delete old write counters", zap.Error(err))
+		}
+	}
+
+	if count > limit {
+		s.log.Warn("write quota exceeded",
+			zap.String("user_id", userID.String()),
+			zap.Int("limit", limit),
+		)
+		return &QuotaError{Limit: limit, ResetAt: window.Add(time.Hour)}
+	}
+	return nil
+}
+
+// checkUser returns ErrUnknownUser if the user validator does not know
+// userID, and ErrUserCheckFailed if it cannot tell.
+func (s *SubscriptionService) checkUser(ctx context.Context, userID uuid.UUID) error {
+	if s.users == nil {
+		return nil
+	}
+	ok, err := s.users.UserExists(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to check user", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("%w: %w", ErrUserCheckFailed, err)
+	}
+	if !ok {
+		s.log.Warn("subscription of unknown user rejected", zap.String("user_id", userID.String()))
+		return ErrUnknownUser
+	}
 	return nil
 }
 
+// checkActiveLimit returns ErrLimitExceeded if creating sub would exceed the
+// per-user limit. Subscriptions that ended before the current month are not
+// active and are always allowed. The check is not atomic with the insert, so
+// concurrent requests may overshoot the limit slightly.
+func (s *SubscriptionService) checkActiveLimit(ctx context.Context, sub *models.Subscription, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	month := s.currentMonth()
+	if sub.EndDate != nil && sub.EndDate.Before(month.Time) {
+		return nil
+	}
+
+	count, err := s.repo.CountActive(ctx, sub.UserID, month)
+	if err != nil {
+		s.log.Error("failed to count active subscriptions", zap.Error(err), retryInfo(err))
+		return err
+	}
+	if count >= limit {
+		s.log.Warn("active subscription limit exceeded",
+			zap.String("user_id", sub.UserID.String()),
+			zap.Int("limit", limit),
+		)
+		return ErrLimitExceeded
+	}
+	return nil
+}
+
+// CreateOrGet creates a subscription or, if one with the same user, service
+// and start month already exists, loads the existing one into sub.
+// Returns true if a new subscription was created.
+func (s *SubscriptionService) CreateOrGet(ctx context.Context, sub *models.Subscription) (bool, error) {
+	createErr := s.CreateSubscription(ctx, sub)
+	if createErr == nil {
+		return true, nil
+	}
+	if !errors.Is(createErr, ErrSubscriptionExists) && !errors.Is(createErr, ErrLimitExceeded) {
+		return false, createErr
+	}
+
+	// An existing subscription is returned even if the user is at the limit.
+	existing, err := s.repo.GetByKey(ctx, sub.UserID, sub.ServiceName, sub.StartDate)
+	if errors.Is(err, repository.ErrNotFound) && errors.Is(createErr, ErrLimitExceeded) {
+		return false, createErr
+	}
+	if err != nil {
+		s.log.Error("failed to get existing subscription", zap.Error(err), retryInfo(err))
+		return false, domainError(err)
+	}
+	s.log.Info("returning existing subscription", zap.Int64("id", existing.ID))
+	*sub = *existing
+	return false, nil
+}
+
 // GetByID retrieves a subscription by its ID.
+// Returns ErrSubscriptionNotFound if it does not exist or belongs to another
+// user than the caller.
 func (s *SubscriptionService) GetByID(ctx context.Context, id int64) (*models.Subscription, error) {
 	s.log.Info("getting subscription by id", zap.Int64("id", id))
-	sub, err := s.repo.GetByID(ctx, id)
+	sub, shared, err := coalesce(ctx, &s.reads, subscriptionReadKey(id), func(ctx context.Context) (*models.Subscription, error) {
+		return s.repo.GetByID(ctx, id)
+	})
 	if err != nil {
-		s.log.Error("failed to get subscription", zap.Int64("id", id), zap.Error(err))
+		s.log.Error("failed to get subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+		return nil, domainError(err)
+	}
+	if err := s.policy.CanRead(ctx, sub); err != nil {
 		return nil, err
 	}
+	if shared {
+		cp := *sub
+		if cp.EndDate != nil {
+			end := *cp.EndDate
+			cp.EndDate = &end
+		}
+		sub = &cp
+	}
 	return sub, nil
 }
 
-// List returns all subscriptions.
-func (s *SubscriptionService) List(ctx context.Context, limit, offset int) ([]models.Subscription, error) {
+// checkAccess returns ErrSubscriptionNotFound if the subscription does not
+// exist or the caller may not access it. The subscription is loaded only if
+// the ownership policy is enforced for the caller.
+func (s *SubscriptionService) checkAccess(ctx context.Context, id int64) error {
+	if !s.policy.Enforced(ctx) {
+		return nil
+	}
+	_, err := s.current(ctx, id)
+	return err
+}
+
+// current loads a subscription the caller may access, like GetByID.
+func (s *SubscriptionService) current(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	sub, err := s.repo.GetByID(ctx, id, opts...)
+	if err != nil {
+		s.log.Error("failed to get subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+		return nil, domainError(err)
+	}
+	if err := s.policy.CanRead(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Exists reports whether a subscription with the given ID exists and the
+// caller may access it.
+func (s *SubscriptionService) Exists(ctx context.Context, id int64) (bool, error) {
+	s.log.Debug("checking subscription existence", zap.Int64("id", id))
+	if err := s.checkAccess(ctx, id); err != nil {
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	exists, err := s.repo.Exists(ctx, id)
+	if err != nil {
+		s.log.Error("failed to check subscription existence", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+		return false, err
+	}
+	return exists, nil
+}
+
+// List returns subscriptions matching filter. A restricted caller (see
+// OwnershipPolicy) gets only their own, and ErrForbidden for filter.UserID of
+// another user.
+func (s *SubscriptionService) List(ctx context.Context, filter models.SubscriptionFilter, limit, offset int) ([]models.Subscription, error) {
 	s.log.Info("listing subscriptions")
-	subs, err := s.repo.List(ctx, limit, offset)
+	userID, err := s.policy.Restrict(ctx, filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userID
+	subs, err := s.repo.List(ctx, filter, limit, offset)
 	if err != nil {
-		s.log.Error("failed to list subscriptions", zap.Error(err))
+		s.log.Error("failed to list subscriptions", zap.Error(err), retryInfo(err))
 		return nil, err
 	}
 	return subs, nil
 }
 
+// ActiveOn returns subscriptions whose period covers the requested month,
+// optionally filtered by user and service. Like List, it returns only the
+// caller's subscriptions to a restricted caller.
+func (s *SubscriptionService) ActiveOn(ctx context.Context, req *models.ActiveOnRequest, limit, offset int) ([]models.Subscription, error) {
+	s.log.Info("listing subscriptions active on month", zap.Time("on", req.On.Time))
+
+	filter := models.SubscriptionFilter{ServiceName: req.ServiceName}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id: %w", err)
+		}
+		filter.UserID = &userID
+	}
+	userID, err := s.policy.Restrict(ctx, filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userID
+
+	subs, err := s.repo.ActiveOn(ctx, req.On, filter, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list active subscriptions", zap.Error(err), retryInfo(err))
+		return nil, err
+	}
+	return subs, nil
+}
+
+// DistinctServices returns service names starting with prefix with their
+// numbers of subscriptions, most used first. Results may come from the cache
+// (see WithServicesCache) unless ctx requires strong consistency.
+func (s *SubscriptionService) DistinctServices(ctx context.Context, prefix string, limit, offset int) ([]models.ServiceCount, error) {
+	key := servicesKey{schema: tenant.SchemaFromContext(ctx), prefix: strings.ToLower(prefix), limit: limit, offset: offset}
+	if !consistency.IsStrong(ctx) {
+		if services, ok := s.services.Get(key); ok {
+			return services, nil
+		}
+	}
+
+	services, err := s.repo.DistinctServices(ctx, prefix, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list services", zap.Error(err), retryInfo(err))
+		return nil, err
+	}
+	s.services.Set(key, services)
+	return services, nil
+}
+
+// Overlaps returns pairs of the user's subscriptions to the same service
+// that are active at the same time, likely billed twice. Returns
+// ErrForbidden if the caller is another user.
+func (s *SubscriptionService) Overlaps(ctx context.Context, userID uuid.UUID) ([]models.Overlap, error) {
+	s.log.Info("detecting overlapping subscriptions", zap.String("user_id", userID.String()))
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return nil, err
+	}
+	overlaps, err := s.repo.Overlaps(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to detect overlapping subscriptions", zap.Error(err), retryInfo(err))
+		return nil, err
+	}
+	return overlaps, nil
+}
+
 // Update modifies an existing subscription.
-func (s *SubscriptionService) Update(ctx context.Context, sub *models.Subscription) error {
+// Returns ErrSubscriptionNotFound if it does not exist or belongs to another
+// user than the caller, ErrForbidden if the caller moves it to another user,
+// ErrUnknownUser if WithUserValidator does not know the user,
+// *PeriodError if it would end before it starts, *PriceChangeError if the
+// price changes more than WithPriceChangeGuard allows and *QuotaError if the
+// user has exceeded the write quota.
+func (s *SubscriptionService) Update(ctx context.Context, sub *models.Subscription, opts ...UpdateOption) error {
 	s.log.Info("updating subscription", zap.Int64("id", sub.ID))
-	if err := s.repo.Update(ctx, sub); err != nil {
-		s.log.Error("failed to update subscription", zap.Int64("id", sub.ID), zap.Error(err))
+	var o updateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := runBefore(ctx, s.hooks.beforeUpdate, sub); err != nil {
+		return err
+	}
+	sub.Currency = sub.Currency.OrDefault()
+
+	if err := checkPeriod(sub.StartDate, sub.EndDate, "start_date", "end_date"); err != nil {
+		return err
+	}
+	limits, err := s.limitsFor(ctx)
+	if err != nil {
+		return err
+	}
+	maxPriceChange := limits.MaxPriceChange
+	changedUsers := []uuid.UUID{sub.UserID}
+	var current *models.Subscription
+	if s.policy.Enforced(ctx) || maxPriceChange > 0 || s.summaries != nil || s.writes != audit.Discard {
+		current, err = s.current(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPriceChange(ctx, current, sub, maxPriceChange, o.allowPriceChange); err != nil {
+			return err
+		}
+		if current.UserID != sub.UserID {
+			changedUsers = append(changedUsers, current.UserID)
+		}
+	}
+	if err := s.policy.CanAssign(ctx, sub.UserID); err != nil {
+		return err
+	}
+	if err := s.checkUser(ctx, sub.UserID); err != nil {
+		return err
+	}
+	if err := s.checkWriteQuota(ctx, sub.UserID, limits.WritesPerHour); err != nil {
 		return err
 	}
+	if err := s.repo.Update(ctx, sub); err != nil {
+		s.log.Error("failed to update subscription", zap.Int64("id", sub.ID), zap.Error(err), retryInfo(err))
+		return domainError(err)
+	}
 	s.log.Info("subscription updated", zap.Int64("id", sub.ID))
+	if current != nil {
+		s.auditUpdate(ctx, current, sub)
+	}
+	s.changed(ctx, events.TypeSubscriptionUpdated, sub, changedUsers...)
+	runAfter(ctx, s.hooks.afterUpdate, sub)
 	return nil
 }
 
+// Patch updates only the fields given in p and returns the updated
+// subscription, so fields changed concurrently by others are kept. The
+// patched subscription is passed to the before hooks of Update, validated
+// and checked like by Update, and the fields that differ from the current
+// subscription are saved, including those changed by the hooks. The
+// subscription stays locked from the read until the write (WithTransactor).
+// Returns the errors of Update, validator.ValidationErrors if the patched
+// subscription is invalid and *RejectedError if a hook changes a field
+// that cannot be patched.
+func (s *SubscriptionService) Patch(ctx context.Context, id int64, p models.SubscriptionPatch, opts ...UpdateOption) (*models.Subscription, error) {
+	s.log.Info("patching subscription", zap.Int64("id", id))
+	var o updateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var current, sub *models.Subscription
+	var patched models.Subscription
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = s.current(ctx, id, repository.WithLock(repository.ForUpdate))
+		if err != nil {
+			return err
+		}
+		patched = p.Apply(*current)
+		if err := runBefore(ctx, s.hooks.beforeUpdate, &patched); err != nil {
+			return err
+		}
+		write, err := patchOf(current, &patched)
+		if err != nil {
+			return err
+		}
+		if err := models.Validate(&patched); err != nil {
+			return err
+		}
+		if err := checkPeriod(patched.StartDate, patched.EndDate, "start_date", "end_date"); err != nil {
+			return err
+		}
+		limits, err := s.limitsFor(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPriceChange(ctx, current, &patched, limits.MaxPriceChange, o.allowPriceChange); err != nil {
+			return err
+		}
+		if err := s.checkWriteQuota(ctx, current.UserID, limits.WritesPerHour); err != nil {
+			return err
+		}
+		if sub, err = s.repo.UpdatePartial(ctx, id, write); err != nil {
+			s.log.Error("failed to patch subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+			return domainError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("subscription patched", zap.Int64("id", id))
+	s.auditUpdate(ctx, current, &patched)
+	s.changed(ctx, events.TypeSubscriptionUpdated, sub, sub.UserID)
+	runAfter(ctx, s.hooks.afterUpdate, sub)
+	return sub, nil
+}
+
+// patchOf returns the patch of the fields patched differs from current in.
+// Returns *RejectedError if they differ in a field a patch cannot set.
+func patchOf(current, patched *models.Subscription) (models.SubscriptionPatch, error) {
+	var p models.SubscriptionPatch
+	if patched.ServiceName != current.ServiceName {
+		p.ServiceName = &patched.ServiceName
+	}
+	if patched.Price != current.Price {
+		p.Price = &patched.Price
+	}
+	switch {
+	case patched.EndDate == nil && current.EndDate != nil:
+		p.ClearEndDate = true
+	case patched.EndDate != nil && (current.EndDate == nil || !patched.EndDate.Time.Equal(current.EndDate.Time)):
+		p.EndDate = patched.EndDate
+	}
+	fixed := []struct {
+		field   string
+		changed bool
+	}{
+		{"id", patched.ID != current.ID},
+		{"user_id", patched.UserID != current.UserID},
+		{"start_date", !patched.StartDate.Time.Equal(current.StartDate.Time)},
+		{"currency", patched.Currency != current.Currency},
+		{"trial", patched.Trial != current.Trial},
+	}
+	for _, f := range fixed {
+		if f.changed {
+			return models.SubscriptionPatch{}, &RejectedError{Reason: f.field + " cannot be changed by PATCH, use PUT"}
+		}
+	}
+	return p, nil
+}
+
+// inTx runs fn in a transaction carried by the context fn is given, so the
+// repository calls of fn run in it; without WithTransactor fn just runs.
+func (s *SubscriptionService) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx == nil {
+		return fn(ctx)
+	}
+	return s.tx.Do(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		return fn(repository.ContextWithTx(ctx, tx))
+	})
+}
+
+// auditUpdate records the changes of an update of current to sub.
+func (s *SubscriptionService) auditUpdate(ctx context.Context, current, sub *models.Subscription) {
+	s.writes.Record(ctx, audit.Event{
+		Action:         audit.ActionUpdated,
+		SubscriptionID: current.ID,
+		UserID:         sub.UserID,
+		Details: map[string]any{
+			"service_name": sub.ServiceName,
+			"changes":      subscriptionChanges(current, sub),
+		},
+	})
+}
+
+// checkPriceChange returns *PriceChangeError if updating current to sub
+// changes the price by more than maxPercent and the change is not
+// confirmed. Rejected and confirmed large changes are audited.
+func (s *SubscriptionService) checkPriceChange(ctx context.Context, current, sub *models.Subscription, maxPercent float64, allow bool) error {
+	sameCurrency := current.Currency.OrDefault() == sub.Currency.OrDefault()
+	if maxPercent <= 0 || (current.Price == sub.Price && sameCurrency) {
+		return nil
+	}
+	// A change from a zero price or to another currency has no percentage
+	// and is always large.
+	if current.Price != 0 && sameCurrency {
+		change := math.Abs(float64(sub.Price-current.Price)) / float64(current.Price) * 100
+		if change <= maxPercent {
+			return nil
+		}
+	}
+
+	ev := audit.Event{
+		SubscriptionID: sub.ID,
+		UserID:         current.UserID,
+		Details: map[string]any{
+			"from":        current.Price,
+			"to":          sub.Price,
+			"max_percent": maxPercent,
+		},
+	}
+	if allow {
+		ev.Action = "price_change.confirmed"
+		s.audit.Record(ctx, ev)
+		return nil
+	}
+	ev.Action = "price_change.rejected"
+	s.audit.Record(ctx, ev)
+	s.log.Warn("large price change rejected", zap.Int64("id", sub.ID),
+		zap.Int("from", int(current.Price)), zap.Int("to", int(sub.Price)))
+	return &PriceChangeError{From: current.Price, To: sub.Price, MaxPercent: maxPercent}
+}
+
 // Delete removes a subscription by its ID.
+// Returns ErrSubscriptionNotFound if it does not exist or belongs to another
+// user than the caller.
 func (s *SubscriptionService) Delete(ctx context.Context, id int64) error {
 	s.log.Info("deleting subscription", zap.Int64("id", id))
-	if err := s.repo.Delete(ctx, id); err != nil {
-		s.log.Error("failed to delete subscription", zap.Int64("id", id), zap.Error(err))
+	if err := runBefore(ctx, s.hooks.beforeDelete, id); err != nil {
+		return err
+	}
+	if err := s.checkAccess(ctx, id); err != nil {
 		return err
 	}
+	if s.writes == audit.Discard {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			s.log.Error("failed to delete subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+			return domainError(err)
+		}
+		s.log.Info("subscription deleted", zap.Int64("id", id))
+		// The user of the deleted subscription is not known: all summaries are dropped.
+		s.changed(ctx, events.TypeSubscriptionDeleted, events.SubscriptionDeleted{ID: id, DeletedAt: s.now().UTC()})
+		runAfter(ctx, s.hooks.afterDelete, id)
+		return nil
+	}
+	// The deleted row tells whose subscription it was, for the audit and
+	// the summary cache, and the event carries the same tombstone as
+	// DeleteReturning.
+	sub, err := s.repo.DeleteReturning(ctx, id)
+	if err != nil {
+		s.log.Error("failed to delete subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+		return domainError(err)
+	}
 	s.log.Info("subscription deleted", zap.Int64("id", id))
+	s.auditDelete(ctx, sub)
+	s.changed(ctx, events.TypeSubscriptionDeleted, &models.DeletedSubscription{
+		Subscription: *sub,
+		DeletedAt:    s.now().UTC(),
+	}, sub.UserID)
+	runAfter(ctx, s.hooks.afterDelete, id)
 	return nil
 }
 
+// DeleteReturning removes a subscription by its ID and returns a tombstone
+// with the deleted data. Access is checked as by Delete.
+func (s *SubscriptionService) DeleteReturning(ctx context.Context, id int64) (*models.DeletedSubscription, error) {
+	s.log.Info("deleting subscription", zap.Int64("id", id))
+	if err := runBefore(ctx, s.hooks.beforeDelete, id); err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, id); err != nil {
+		return nil, err
+	}
+	sub, err := s.repo.DeleteReturning(ctx, id)
+	if err != nil {
+		s.log.Error("failed to delete subscription", zap.Int64("id", id), zap.Error(err), retryInfo(err))
+		return nil, domainError(err)
+	}
+	s.log.Info("subscription deleted", zap.Int64("id", id))
+	s.auditDelete(ctx, sub)
+	deleted := &models.DeletedSubscription{
+		Subscription: *sub,
+		DeletedAt:    s.now().UTC(),
+	}
+	s.changed(ctx, events.TypeSubscriptionDeleted, deleted, sub.UserID)
+	runAfter(ctx, s.hooks.afterDelete, id)
+	return deleted, nil
+}
+
+// auditDelete records the deletion of sub.
+func (s *SubscriptionService) auditDelete(ctx context.Context, sub *models.Subscription) {
+	s.writes.Record(ctx, audit.Event{
+		Action:         audit.ActionDeleted,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Details:        subscriptionDetails(sub),
+	})
+}
+
+// retryInfo describes the attempts of a repository call that gave up,
+// e.g. "gave up after 3 attempts in 2.1s (exhausted)".
+func retryInfo(err error) zap.Field {
+	var rerr *retry.RetryError
+	if !errors.As(err, &rerr) {
+		return zap.Skip()
+	}
+	return zap.String("retry", rerr.Summary())
+}
+
 // Summary calculates total subscription price within a time range and optional filters.
-func (s *SubscriptionService) Summary(ctx context.Context, req *models.SummaryRequest) (int, error) {
+// Returns *PeriodError if the range ends before it starts and
+// ErrMixedCurrencies if matching subscriptions have different currencies.
+// If req.GroupBy is set, a page of the total broken down by it is added;
+// broken down by user it lists the spend of every user, so it returns
+// ErrAdminRequired unless the caller is an admin. Returns ErrForbidden for
+// req.UserID of another user than the caller.
+// Summaries of a single user may come from the cache (see WithSummaryCache)
+// unless ctx requires strong consistency.
+func (s *SubscriptionService) Summary(ctx context.Context, req *models.SummaryRequest) (models.Summary, error) {
+	if err := runBefore(ctx, s.hooks.beforeSummary, req); err != nil {
+		return models.Summary{}, err
+	}
+	if req.GroupBy == models.GroupByUser {
+		if err := s.policy.CanInspect(ctx); err != nil {
+			return models.Summary{}, err
+		}
+	}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return models.Summary{}, fmt.Errorf("invalid user_id: %w", err)
+		}
+		if err := s.policy.CanAssign(ctx, userID); err != nil {
+			return models.Summary{}, err
+		}
+	}
+	if err := checkPeriod(req.From, &req.To, "from", "to"); err != nil {
+		return models.Summary{}, err
+	}
+	key, cacheable := s.summaryKey(ctx, req)
+	if cacheable && !consistency.IsStrong(ctx) {
+		if sum, ok := s.summaries.Get(key); ok {
+			runAfter(ctx, s.hooks.afterSummary, &sum)
+			return sum, nil
+		}
+	}
+	readKey, err := summaryReadKey(req)
+	if err != nil {
+		return models.Summary{}, fmt.Errorf("summary failed: %w", err)
+	}
+	sum, shared, err := coalesce(ctx, &s.reads, readKey, func(ctx context.Context) (models.Summary, error) {
+		return s.summary(ctx, req)
+	})
+	if err != nil {
+		return models.Summary{}, err
+	}
+	if shared {
+		sum = cloneSummary(sum)
+	}
+	if cacheable {
+		s.summaries.Set(key, sum)
+	}
+	runAfter(ctx, s.hooks.afterSummary, &sum)
+	return sum, nil
+}
+
+// summary calculates the summary of req with its breakdown.
+func (s *SubscriptionService) summary(ctx context.Context, req *models.SummaryRequest) (models.Summary, error) {
 	s.log.Info("calculating subscription summary",
 		zap.Time("from", req.From.Time),
 		zap.Time("to", req.To.Time),
 	)
-	total, err := s.repo.Summary(ctx, req)
+	sum, err := s.repo.Summary(ctx, req)
+	if err != nil {
+		s.log.Error("failed to calculate summary", zap.Error(err), retryInfo(err))
+		return models.Summary{}, fmt.Errorf("summary failed: %w", domainError(err))
+	}
+	s.log.Info("subscription summary calculated",
+		zap.Stringer("total", sum.Total),
+		zap.Int("count", sum.Count),
+	)
+	if err := s.breakdown(ctx, req, &sum); err != nil {
+		return models.Summary{}, err
+	}
+	return sum, nil
+}
+
+// summaryKey returns the cache key of req if its summary may be cached: it
+// is of a single user without other filters or a breakdown.
+func (s *SubscriptionService) summaryKey(ctx context.Context, req *models.SummaryRequest) (summaryKey, bool) {
+	if s.summaries == nil || req.UserID == nil || req.ServiceName != nil || req.ExcludeTrials ||
+		req.MinPrice != nil || req.Currency != nil || req.GroupBy != "" {
+		return summaryKey{}, false
+	}
+	userID, err := uuid.Parse(*req.UserID)
+	if err != nil {
+		return summaryKey{}, false
+	}
+	return summaryKey{
+		schema: tenant.SchemaFromContext(ctx),
+		userID: userID,
+		from:   req.From.Time.Unix(),
+		to:     req.To.Time.Unix(),
+	}, true
+}
+
+// breakdown adds the breakdown requested by req.GroupBy to sum.
+func (s *SubscriptionService) breakdown(ctx context.Context, req *models.SummaryRequest, sum *models.Summary) error {
+	if req.GroupBy == "" {
+		return nil
+	}
+	b, err := s.repo.SummaryBreakdown(ctx, req)
+	if err != nil {
+		s.log.Error("failed to break summary down", zap.Error(err), retryInfo(err))
+		return fmt.Errorf("summary breakdown failed: %w", domainError(err))
+	}
+	sum.Breakdown = &b
+	return nil
+}
+
+// ExplainSummary calculates the summary like Summary and adds the
+// contribution of each subscription, for disputes about a total.
+// Returns ErrAdminRequired if the caller is not an admin.
+func (s *SubscriptionService) ExplainSummary(ctx context.Context, req *models.SummaryRequest) (models.Summary, error) {
+	if err := runBefore(ctx, s.hooks.beforeSummary, req); err != nil {
+		return models.Summary{}, err
+	}
+	if err := s.policy.CanInspect(ctx); err != nil {
+		return models.Summary{}, err
+	}
+	if err := checkPeriod(req.From, &req.To, "from", "to"); err != nil {
+		return models.Summary{}, err
+	}
+	s.log.Info("explaining subscription summary",
+		zap.Time("from", req.From.Time),
+		zap.Time("to", req.To.Time),
+	)
+	sum, err := s.repo.ExplainSummary(ctx, req)
 	if err != nil {
-		s.log.Error("failed to calculate summary", zap.Error(err))
-		return 0, fmt.Errorf("summary failed: %w", err)
+		s.log.Error("failed to explain summary", zap.Error(err), retryInfo(err))
+		return models.Summary{}, fmt.Errorf("summary failed: %w", domainError(err))
+	}
+	if err := s.breakdown(ctx, req, &sum); err != nil {
+		return models.Summary{}, err
 	}
-	s.log.Info("subscription summary calculated", zap.Int("total", total))
-	return total, nil
+	runAfter(ctx, s.hooks.afterSummary, &sum)
+	return sum, nil
 }
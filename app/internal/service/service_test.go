@@ -0,0 +1,549 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/audit"
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/events"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+	"subscriptionsservice/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeRepo implements the repository methods used by the tests;
+// others panic via the nil embedded interface.
+type fakeRepo struct {
+	service.SubscriptionRepo
+
+	active   int
+	created  int
+	existing *models.Subscription
+	services int // DistinctServices calls
+}
+
+func (r *fakeRepo) CountActive(ctx context.Context, userID uuid.UUID, month models.MonthDate, opts ...repository.Option) (int, error) {
+	return r.active, nil
+}
+
+func (r *fakeRepo) CreateSubscription(ctx context.Context, s *models.Subscription, opts ...repository.Option) error {
+	if r.existing != nil {
+		return repository.ErrDuplicate
+	}
+	r.created++
+	s.ID = int64(r.created)
+	return nil
+}
+
+func (r *fakeRepo) GetByKey(ctx context.Context, userID uuid.UUID, serviceName string, startDate models.MonthDate, opts ...repository.Option) (*models.Subscription, error) {
+	if r.existing == nil {
+		return nil, repository.ErrNotFound
+	}
+	return r.existing, nil
+}
+
+func (r *fakeRepo) DistinctServices(ctx context.Context, prefix string, limit, offset int, opts ...repository.Option) ([]models.ServiceCount, error) {
+	r.services++
+	return []models.ServiceCount{{ServiceName: "Netflix", Count: r.services}}, nil
+}
+
+func monthDate(year int, m time.Month) *models.MonthDate {
+	return &models.MonthDate{Time: time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestSubscriptionService_ActiveLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		active  int
+		endDate *models.MonthDate
+		wantErr error
+	}{
+		{name: "no limit", limit: 0, active: 1000},
+		{name: "below limit", limit: 3, active: 2},
+		{name: "at limit", limit: 3, active: 3, wantErr: service.ErrLimitExceeded},
+		{name: "ended subscription is not limited", limit: 3, active: 3, endDate: monthDate(2000, time.January)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{active: tt.active}
+			svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithMaxActivePerUser(tt.limit))
+
+			err := svc.CreateSubscription(t.Context(), &models.Subscription{
+				ServiceName: "Netflix",
+				UserID:      uuid.New(),
+				StartDate:   *monthDate(2000, time.January),
+				EndDate:     tt.endDate,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, repo.created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, repo.created)
+		})
+	}
+}
+
+func TestSubscriptionService_CreateOrGet_AtLimit(t *testing.T) {
+	existing := &models.Subscription{ID: 42, ServiceName: "Netflix"}
+	repo := &fakeRepo{active: 1, existing: existing}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithMaxActivePerUser(1))
+
+	sub := &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()}
+	created, err := svc.CreateOrGet(t.Context(), sub)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, int64(42), sub.ID)
+
+	repo.existing = nil
+	_, err = svc.CreateOrGet(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()})
+	assert.ErrorIs(t, err, service.ErrLimitExceeded)
+}
+
+func TestSubscriptionService_SetLimits(t *testing.T) {
+	repo := &fakeRepo{active: 1}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(),
+		service.WithMaxActivePerUser(1), service.WithPriceChangeGuard(50))
+	assert.Equal(t, service.Limits{MaxActivePerUser: 1, MaxPriceChange: 50}, svc.Limits())
+
+	create := func() error {
+		return svc.CreateSubscription(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()})
+	}
+	assert.ErrorIs(t, create(), service.ErrLimitExceeded)
+
+	svc.SetLimits(service.Limits{MaxActivePerUser: 2})
+	require.NoError(t, create())
+	assert.Equal(t, service.Limits{MaxActivePerUser: 2}, svc.Limits())
+}
+
+// tenantLimits are limit overrides by tenant.
+type tenantLimits map[tenant.ID]models.TenantLimits
+
+func (l tenantLimits) Limits(_ context.Context, id tenant.ID) (models.TenantLimits, error) {
+	return l[id], nil
+}
+
+func TestSubscriptionService_TenantLimits(t *testing.T) {
+	two, none := 2, 0
+	repo := &fakeRepo{active: 1}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(),
+		service.WithMaxActivePerUser(1),
+		service.WithTenantLimits(tenantLimits{
+			"billing": {MaxActivePerUser: &two},
+			"sandbox": {MaxActivePerUser: &none},
+		}))
+
+	create := func(ctx context.Context) error {
+		return svc.CreateSubscription(ctx, &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()})
+	}
+	assert.ErrorIs(t, create(t.Context()), service.ErrLimitExceeded, "service-wide limit without a tenant")
+	assert.ErrorIs(t, create(tenant.WithTenant(t.Context(), "search")), service.ErrLimitExceeded, "no overrides")
+	assert.NoError(t, create(tenant.WithTenant(t.Context(), "billing")), "raised")
+	repo.active = 100
+	assert.NoError(t, create(tenant.WithTenant(t.Context(), "sandbox")), "disabled")
+}
+
+// knownUsers is a UserValidator knowing the users in the map; err fails it.
+type knownUsers struct {
+	users map[uuid.UUID]bool
+	err   error
+}
+
+func (k knownUsers) UserExists(_ context.Context, id uuid.UUID) (bool, error) {
+	return k.users[id], k.err
+}
+
+func TestSubscriptionService_UserValidator(t *testing.T) {
+	known := uuid.New()
+	repo := &fakeRepo{}
+	users := knownUsers{users: map[uuid.UUID]bool{known: true}}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithUserValidator(users))
+
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: known}))
+	err := svc.CreateSubscription(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()})
+	assert.ErrorIs(t, err, service.ErrUnknownUser)
+	assert.Equal(t, 1, repo.created)
+
+	users.err = errors.New("connection refused")
+	svc = service.NewSubscriptionService(repo, zap.NewNop(), service.WithUserValidator(users))
+	err = svc.CreateSubscription(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: known})
+	assert.ErrorIs(t, err, service.ErrUserCheckFailed, "subscriptions are not created unchecked")
+}
+
+func TestSubscriptionService_DomainErrors(t *testing.T) {
+	repo := &fakeRepo{existing: &models.Subscription{ID: 42}}
+	svc := service.NewSubscriptionService(repo, zap.NewNop())
+
+	err := svc.CreateSubscription(t.Context(), &models.Subscription{ServiceName: "Netflix", UserID: uuid.New()})
+	assert.ErrorIs(t, err, service.ErrSubscriptionExists)
+	assert.ErrorIs(t, err, repository.ErrDuplicate, "the repository error stays in the chain")
+	var conflictErr *service.ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.EqualValues(t, 42, conflictErr.ExistingID)
+
+	err = svc.CreateSubscription(t.Context(), &models.Subscription{
+		ServiceName: "Netflix",
+		UserID:      uuid.New(),
+		StartDate:   *monthDate(2025, time.July),
+		EndDate:     monthDate(2025, time.June),
+	})
+	var periodErr *service.PeriodError
+	require.ErrorAs(t, err, &periodErr)
+	assert.ErrorIs(t, err, service.ErrInvalidPeriod)
+	assert.Equal(t, "end_date must not be before start_date", err.Error())
+
+	_, err = svc.Summary(t.Context(), &models.SummaryRequest{From: *monthDate(2025, time.July), To: *monthDate(2025, time.January)})
+	assert.ErrorIs(t, err, service.ErrInvalidPeriod)
+}
+
+// fakeQuotas counts writes per user, ignoring windows.
+type fakeQuotas struct {
+	writes  map[uuid.UUID]int
+	deletes int
+}
+
+func (q *fakeQuotas) IncrementWrites(ctx context.Context, userID uuid.UUID, windowStart time.Time, opts ...repository.Option) (int, error) {
+	q.writes[userID]++
+	return q.writes[userID], nil
+}
+
+func (q *fakeQuotas) DeleteWindowsBefore(ctx context.Context, userID uuid.UUID, t time.Time, opts ...repository.Option) (int64, error) {
+	q.deletes++
+	return 0, nil
+}
+
+func TestSubscriptionService_WriteQuota(t *testing.T) {
+	quotas := &fakeQuotas{writes: map[uuid.UUID]int{}}
+	repo := &fakeRepo{}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithWriteQuota(quotas, 2))
+
+	userID := uuid.New()
+	for range 2 {
+		require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{UserID: userID}))
+	}
+	assert.Equal(t, 1, quotas.deletes, "old windows are removed on the first write")
+
+	err := svc.CreateSubscription(t.Context(), &models.Subscription{UserID: userID})
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	var quotaErr *service.QuotaError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, 2, quotaErr.Limit)
+	assert.True(t, quotaErr.ResetAt.After(time.Now()))
+	assert.Equal(t, 2, repo.created)
+
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{UserID: uuid.New()}),
+		"quota is per user")
+}
+
+// recordingPublisher collects published events.
+type recordingPublisher []events.Event
+
+func (p *recordingPublisher) Publish(_ context.Context, ev events.Event) error {
+	*p = append(*p, ev)
+	return nil
+}
+
+func TestSubscriptionService_PublishesValidEvents(t *testing.T) {
+	reg, err := events.NewRegistry()
+	require.NoError(t, err)
+
+	var published recordingPublisher
+	svc := service.NewSubscriptionService(&fakeRepo{}, zap.NewNop(),
+		service.WithPublisher(events.Validating(reg, &published)))
+
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{
+		ServiceName: "Netflix",
+		Price:       799,
+		UserID:      uuid.New(),
+		StartDate:   *monthDate(2025, time.July),
+	}))
+
+	require.Len(t, published, 1)
+	assert.Equal(t, events.TypeSubscriptionCreated, published[0].Type)
+}
+
+func TestSubscriptionService_ChangedAfterCommit(t *testing.T) {
+	var published recordingPublisher
+	svc := service.NewSubscriptionService(&fakeRepo{}, zap.NewNop(), service.WithPublisher(&published))
+	ctx, hooks := repository.ContextWithCommitHooks(t.Context())
+
+	require.NoError(t, svc.CreateSubscription(ctx, &models.Subscription{
+		ServiceName: "Netflix", UserID: uuid.New(), StartDate: *monthDate(2025, time.July),
+	}))
+	assert.Empty(t, published, "published once the request transaction commits")
+
+	hooks.Run()
+	require.Len(t, published, 1)
+	assert.Equal(t, events.TypeSubscriptionCreated, published[0].Type)
+}
+
+func TestSubscriptionService_DeletePublishesTombstone(t *testing.T) {
+	reg, err := events.NewRegistry()
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	repo := &ownedRepo{subs: map[int64]*models.Subscription{
+		1: {ID: 1, ServiceName: "Netflix", Price: 499, UserID: userID, StartDate: *monthDate(2025, time.July)},
+	}}
+	var audited recordedEvents
+	var published recordingPublisher
+	svc := service.NewSubscriptionService(repo, zap.NewNop(),
+		service.WithWriteAudit(&audited),
+		service.WithPublisher(events.Validating(reg, &published)))
+
+	require.NoError(t, svc.Delete(t.Context(), 1))
+
+	require.Len(t, published, 1)
+	assert.Equal(t, events.TypeSubscriptionDeleted, published[0].Type)
+	deleted, ok := published[0].Data.(*models.DeletedSubscription)
+	require.True(t, ok, "the deleted row is known, so the event carries it, got %T", published[0].Data)
+	assert.Equal(t, int64(1), deleted.ID)
+	assert.Equal(t, userID, deleted.UserID)
+	assert.Equal(t, "Netflix", deleted.ServiceName)
+	assert.False(t, deleted.DeletedAt.IsZero())
+}
+
+func TestSubscriptionService_DistinctServicesCache(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithServicesCache(time.Minute))
+
+	first, err := svc.DistinctServices(t.Context(), "net", 10, 0)
+	require.NoError(t, err)
+	cached, err := svc.DistinctServices(t.Context(), "NET", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, first, cached)
+	assert.Equal(t, 1, repo.services)
+
+	_, err = svc.DistinctServices(consistency.WithLevel(t.Context(), consistency.Strong), "net", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.services, "strong reads bypass the cache")
+
+	_, err = svc.DistinctServices(tenant.WithTenant(t.Context(), "billing"), "net", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.services, "tenants are cached apart")
+
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{
+		ServiceName: "Netflix", UserID: uuid.New(), StartDate: *monthDate(2025, time.July),
+	}))
+	got, err := svc.DistinctServices(t.Context(), "net", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, repo.services, "writes drop the cache")
+	assert.Equal(t, 4, got[0].Count)
+}
+
+func TestSubscriptionService_Patch(t *testing.T) {
+	userID := uuid.New()
+	newService := func() (*service.SubscriptionService, *ownedRepo) {
+		repo := &ownedRepo{subs: map[int64]*models.Subscription{
+			1: {ID: 1, ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: *monthDate(2025, time.July), EndDate: monthDate(2025, time.December)},
+		}}
+		return service.NewSubscriptionService(repo, zap.NewNop(), service.WithPriceChangeGuard(50)), repo
+	}
+
+	t.Run("sets the given fields", func(t *testing.T) {
+		svc, repo := newService()
+		price := models.Price(120)
+		got, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{Price: &price, ClearEndDate: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.Price(120), got.Price)
+		assert.Nil(t, got.EndDate)
+		assert.Equal(t, "Netflix", got.ServiceName)
+		assert.Equal(t, 1, repo.updated)
+	})
+
+	t.Run("end before start", func(t *testing.T) {
+		svc, repo := newService()
+		_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{EndDate: monthDate(2025, time.January)})
+		var periodErr *service.PeriodError
+		assert.ErrorAs(t, err, &periodErr)
+		assert.Zero(t, repo.updated)
+	})
+
+	t.Run("large price change", func(t *testing.T) {
+		svc, repo := newService()
+		price := models.Price(1000)
+		_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{Price: &price})
+		assert.ErrorIs(t, err, service.ErrPriceChangeTooLarge)
+		assert.Zero(t, repo.updated)
+
+		_, err = svc.Patch(t.Context(), 1, models.SubscriptionPatch{Price: &price}, service.AllowPriceChange())
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.updated)
+	})
+
+	t.Run("invalid service name", func(t *testing.T) {
+		svc, repo := newService()
+		empty := ""
+		_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{ServiceName: &empty})
+		assert.Error(t, err)
+		assert.Zero(t, repo.updated)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		svc, _ := newService()
+		_, err := svc.Patch(t.Context(), 2, models.SubscriptionPatch{ClearEndDate: true})
+		assert.ErrorIs(t, err, service.ErrSubscriptionNotFound)
+	})
+
+	t.Run("locked from read to write", func(t *testing.T) {
+		repo := &txRepo{ownedRepo: ownedRepo{subs: map[int64]*models.Subscription{
+			1: {ID: 1, ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: *monthDate(2025, time.July)},
+		}}}
+		var tx fakeTransactor
+		svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithTransactor(&tx))
+
+		_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{EndDate: monthDate(2025, time.December)})
+		require.NoError(t, err)
+		assert.Equal(t, 1, tx.runs)
+		assert.Equal(t, []string{"GetByID in tx with lock", "UpdatePartial in tx"}, repo.calls)
+	})
+
+	t.Run("saves changes of hooks", func(t *testing.T) {
+		repo := &ownedRepo{subs: map[int64]*models.Subscription{
+			1: {ID: 1, ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: *monthDate(2025, time.July)},
+		}}
+		hooks := service.NewHooks()
+		hooks.BeforeUpdate(func(_ context.Context, sub *models.Subscription) error {
+			sub.ServiceName = strings.TrimSpace(sub.ServiceName)
+			return nil
+		})
+		svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithHooks(hooks))
+
+		name := "  Hulu "
+		got, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{ServiceName: &name})
+		require.NoError(t, err)
+		assert.Equal(t, "Hulu", got.ServiceName)
+		assert.Equal(t, "Hulu", repo.subs[1].ServiceName, "the stored row is the validated one")
+	})
+
+	t.Run("hook changes a field patches cannot set", func(t *testing.T) {
+		repo := &ownedRepo{subs: map[int64]*models.Subscription{
+			1: {ID: 1, ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: *monthDate(2025, time.July)},
+		}}
+		hooks := service.NewHooks()
+		hooks.BeforeUpdate(func(_ context.Context, sub *models.Subscription) error {
+			sub.Trial = sub.Price == 0
+			return nil
+		})
+		svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithHooks(hooks))
+
+		price := models.Price(0)
+		_, err := svc.Patch(t.Context(), 1, models.SubscriptionPatch{Price: &price}, service.AllowPriceChange())
+		assert.ErrorIs(t, err, service.ErrRejected)
+		assert.ErrorContains(t, err, "trial cannot be changed by PATCH")
+		assert.Zero(t, repo.updated)
+	})
+}
+
+// fakeTransactor runs functions in a fake transaction.
+type fakeTransactor struct {
+	runs int
+}
+
+func (f *fakeTransactor) Do(ctx context.Context, fn repository.TxFunc, _ ...repository.Option) error {
+	f.runs++
+	return fn(ctx, fakeTx{})
+}
+
+// fakeTx is a transaction only telling calls in it apart.
+type fakeTx struct {
+	pgx.Tx
+}
+
+// txRepo records whether its calls run in a transaction.
+type txRepo struct {
+	ownedRepo
+	calls []string
+}
+
+func (r *txRepo) record(ctx context.Context, method string, opts []repository.Option) {
+	call := method
+	if _, ok := repository.TxFromContext(ctx); ok {
+		call += " in tx"
+	}
+	if len(opts) > 0 {
+		call += " with lock"
+	}
+	r.calls = append(r.calls, call)
+}
+
+func (r *txRepo) GetByID(ctx context.Context, id int64, opts ...repository.Option) (*models.Subscription, error) {
+	r.record(ctx, "GetByID", opts)
+	return r.ownedRepo.GetByID(ctx, id, opts...)
+}
+
+func (r *txRepo) UpdatePartial(ctx context.Context, id int64, p models.SubscriptionPatch, opts ...repository.Option) (*models.Subscription, error) {
+	r.record(ctx, "UpdatePartial", opts)
+	return r.ownedRepo.UpdatePartial(ctx, id, p, opts...)
+}
+
+// recordedEvents collects audit events.
+type recordedEvents []audit.Event
+
+func (r *recordedEvents) Record(_ context.Context, ev audit.Event) {
+	*r = append(*r, ev)
+}
+
+func TestSubscriptionService_PriceChangeGuard(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name       string
+		current    models.Price
+		price      models.Price
+		currency   models.Currency
+		opts       []service.UpdateOption
+		wantErr    error
+		wantAction string
+	}{
+		{name: "within limit", current: 100, price: 150},
+		{name: "unchanged", current: 100, price: 100},
+		{name: "too large", current: 100, price: 10000, wantErr: service.ErrPriceChangeTooLarge, wantAction: "price_change.rejected"},
+		{name: "too large drop", current: 100, price: 10, wantErr: service.ErrPriceChangeTooLarge, wantAction: "price_change.rejected"},
+		{name: "from zero", current: 0, price: 1, wantErr: service.ErrPriceChangeTooLarge, wantAction: "price_change.rejected"},
+		{name: "other currency", current: 100, price: 100, currency: "USD", wantErr: service.ErrPriceChangeTooLarge, wantAction: "price_change.rejected"},
+		{name: "default currency", current: 100, price: 100, currency: models.DefaultCurrency},
+		{name: "confirmed", current: 100, price: 10000, opts: []service.UpdateOption{service.AllowPriceChange()}, wantAction: "price_change.confirmed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &ownedRepo{subs: map[int64]*models.Subscription{
+				1: {ID: 1, ServiceName: "Netflix", Price: tt.current, UserID: userID},
+			}}
+			var events recordedEvents
+			svc := service.NewSubscriptionService(repo, zap.NewNop(),
+				service.WithPriceChangeGuard(50), service.WithAudit(&events))
+
+			err := svc.Update(t.Context(), &models.Subscription{ID: 1, ServiceName: "Netflix", Price: tt.price, Currency: tt.currency, UserID: userID}, tt.opts...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, repo.updated)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, 1, repo.updated)
+			}
+
+			if tt.wantAction == "" {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			assert.Equal(t, tt.wantAction, events[0].Action)
+			assert.Equal(t, int64(1), events[0].SubscriptionID)
+		})
+	}
+}
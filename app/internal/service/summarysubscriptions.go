@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/notifications"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SummarySubscriptionRepo defines repository methods required by
+// SummarySubscriptionService.
+type SummarySubscriptionRepo interface {
+	// Create inserts an unconfirmed summary subscription with the hash of
+	// its confirmation token.
+	Create(ctx context.Context, s *models.SummarySubscription, confirmationHash []byte, opts ...repository.Option) error
+
+	// Confirm confirms the summary subscription awaiting a confirmation
+	// token by its hash.
+	Confirm(ctx context.Context, confirmationHash []byte, at time.Time, opts ...repository.Option) (*models.SummarySubscription, error)
+
+	// ListByUser returns the summary subscriptions of a user ordered by ID.
+	ListByUser(ctx context.Context, userID uuid.UUID, opts ...repository.Option) ([]models.SummarySubscription, error)
+
+	// Delete removes a summary subscription of a user.
+	Delete(ctx context.Context, userID uuid.UUID, id int64, opts ...repository.Option) error
+
+	// Due returns up to limit confirmed summary subscriptions due at now.
+	Due(ctx context.Context, now time.Time, limit int, opts ...repository.Option) ([]models.SummarySubscription, error)
+
+	// MarkSent records a sent summary and schedules the next one.
+	MarkSent(ctx context.Context, id int64, sentAt, next time.Time, opts ...repository.Option) error
+
+	// MarkFailed records why a summary could not be sent.
+	MarkFailed(ctx context.Context, id int64, reason string, opts ...repository.Option) error
+}
+
+// Summarizer calculates summaries of subscriptions, e.g. SubscriptionService.
+type Summarizer interface {
+	Summary(ctx context.Context, req *models.SummaryRequest) (models.Summary, error)
+}
+
+// confirmationTokenBytes is the number of random bytes of a confirmation
+// token.
+const confirmationTokenBytes = 32
+
+// SummarySubscriptionService manages the summaries users subscribe to and
+// sends the due ones through the notification channels. After each month,
+// quarter or year a subscription gets the summary of that period with its
+// filters, limited to the subscriptions of its user. Summaries are sent to
+// an address only once its owner confirms it with the token sent there, as
+// nothing proves it belongs to the user. Users manage their own
+// subscriptions, admins those of any user.
+type SummarySubscriptionService struct {
+	repo      SummarySubscriptionRepo
+	summaries Summarizer
+	channels  notifications.Channel
+	log       *zap.Logger
+	policy    OwnershipPolicy
+	now       func() time.Time
+}
+
+// NewSummarySubscriptionService creates a new instance of
+// SummarySubscriptionService. Summaries are calculated by summaries and
+// sent through channels, a channel or notifications.Multi.
+func NewSummarySubscriptionService(repo SummarySubscriptionRepo, summaries Summarizer, channels notifications.Channel, log *zap.Logger) *SummarySubscriptionService {
+	return &SummarySubscriptionService{
+		repo:      repo,
+		summaries: summaries,
+		channels:  channels,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// Create subscribes sub.UserID to a summary of their own subscriptions and
+// sends a confirmation token to the recipient. Once confirmed (see
+// Confirm), the summary is first sent after the current period ends. It
+// fills the ID, filter and schedule of sub. Returns ErrForbidden if the
+// caller is another user and ErrInvalidChannel if the channel is not
+// configured, does not send to recipients or the recipient does not suit
+// it.
+func (s *SummarySubscriptionService) Create(ctx context.Context, sub *models.SummarySubscription) error {
+	if err := s.policy.CanAssign(ctx, sub.UserID); err != nil {
+		return err
+	}
+	ch, err := s.channel(sub)
+	if err != nil {
+		return err
+	}
+	userID := sub.UserID
+	sub.Filter.UserID = &userID
+
+	b := make([]byte, confirmationTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("generate confirmation token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	hash := sha256.Sum256([]byte(token))
+
+	// The token is sent only once it can be confirmed; a subscription
+	// whose token was not sent could never be, so it is removed.
+	sub.NextRunAt = nextRun(sub.Period, s.now())
+	sub.LastSentAt, sub.LastError, sub.ConfirmedAt = nil, "", nil
+	if err := s.repo.Create(ctx, sub, hash[:]); err != nil {
+		s.log.Error("failed to create summary subscription", zap.Error(err), retryInfo(err))
+		return domainError(err)
+	}
+	if err := ch.Send(ctx, confirmationMessage(sub, token)); err != nil {
+		s.log.Warn("failed to send summary subscription confirmation", zap.String("channel", sub.Channel), zap.Error(err))
+		if delErr := s.repo.Delete(ctx, userID, sub.ID); delErr != nil {
+			s.log.Error("failed to delete unconfirmable summary subscription", zap.Int64("id", sub.ID),
+				zap.Error(delErr), retryInfo(delErr))
+		}
+		sub.ID = 0
+		return fmt.Errorf("send confirmation: %w", err)
+	}
+
+	s.log.Info("summary subscription created", zap.String("user_id", sub.UserID.String()),
+		zap.Int64("id", sub.ID), zap.String("period", string(sub.Period)), zap.String("channel", sub.Channel))
+	return nil
+}
+
+// Confirm confirms the summary subscription a confirmation token was sent
+// for, so its summaries are sent, and returns it. A token confirms once.
+// Returns ErrInvalidConfirmation if no subscription awaits the token.
+func (s *SummarySubscriptionService) Confirm(ctx context.Context, token string) (*models.SummarySubscription, error) {
+	hash := sha256.Sum256([]byte(token))
+	sub, err := s.repo.Confirm(ctx, hash[:], s.now().UTC())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfirmation, err)
+		}
+		s.log.Error("failed to confirm summary subscription", zap.Error(err), retryInfo(err))
+		return nil, domainError(err)
+	}
+
+	s.log.Info("summary subscription confirmed", zap.String("user_id", sub.UserID.String()), zap.Int64("id", sub.ID))
+	return sub, nil
+}
+
+// List returns the summary subscriptions of a user ordered by ID. Returns
+// ErrForbidden if the caller is another user.
+func (s *SummarySubscriptionService) List(ctx context.Context, userID uuid.UUID) ([]models.SummarySubscription, error) {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return nil, err
+	}
+	subs, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list summary subscriptions", zap.Error(err), retryInfo(err))
+		return nil, domainError(err)
+	}
+	return subs, nil
+}
+
+// Delete unsubscribes a user from a summary. Returns
+// ErrSummarySubscriptionNotFound if the user has no such subscription and
+// ErrForbidden if the caller is another user.
+func (s *SummarySubscriptionService) Delete(ctx context.Context, userID uuid.UUID, id int64) error {
+	if err := s.policy.CanAssign(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: %w", ErrSummarySubscriptionNotFound, err)
+		}
+		return domainError(err)
+	}
+
+	s.log.Info("summary subscription deleted", zap.String("user_id", userID.String()), zap.Int64("id", id))
+	return nil
+}
+
+// SendDue sends up to limit due summaries of confirmed subscriptions in the
+// schema of ctx, see tenant.Registry.ForEach. A summary that fails, e.g.
+// because the channel is down, keeps its schedule and is retried by the
+// next call; the error is recorded with it. Only errors recording the
+// outcomes are returned.
+func (s *SummarySubscriptionService) SendDue(ctx context.Context, limit int) error {
+	now := s.now().UTC()
+	due, err := s.repo.Due(ctx, now, limit)
+	if err != nil {
+		return fmt.Errorf("list due summaries: %w", err)
+	}
+
+	var errs []error
+	for _, sub := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.send(ctx, &sub, now); err != nil {
+			s.log.Warn("failed to send summary", zap.Int64("id", sub.ID), zap.String("channel", sub.Channel), zap.Error(err))
+			if err := s.repo.MarkFailed(ctx, sub.ID, err.Error()); err != nil {
+				errs = append(errs, fmt.Errorf("summary subscription %d: %w", sub.ID, err))
+			}
+			continue
+		}
+		if err := s.repo.MarkSent(ctx, sub.ID, now, nextRun(sub.Period, now)); err != nil {
+			errs = append(errs, fmt.Errorf("summary subscription %d: %w", sub.ID, err))
+		}
+	}
+	if len(due) > 0 {
+		s.log.Info("due summaries processed", zap.Int("count", len(due)))
+	}
+	return errors.Join(errs...)
+}
+
+// send sends the summary of the last complete period before now.
+func (s *SummarySubscriptionService) send(ctx context.Context, sub *models.SummarySubscription, now time.Time) error {
+	ch, err := s.channel(sub)
+	if err != nil {
+		return err
+	}
+
+	start := sub.Period.Start(now)
+	req := &models.SummaryRequest{
+		From: models.MonthDate{Time: start.AddDate(0, -sub.Period.Months(), 0)},
+		To:   models.MonthDate{Time: start.AddDate(0, -1, 0)},
+	}
+	sub.Filter.ApplyTo(req)
+	// Also for subscriptions created before Create set the filter.
+	userID := sub.UserID.String()
+	req.UserID = &userID
+	sum, err := s.summaries.Summary(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return ch.Send(ctx, summaryMessage(req, sum))
+}
+
+// channel returns the channel of sub addressed to its recipient. Only
+// notifications.Addressed channels are used: others send to a destination
+// shared by all users, e.g. a Slack channel. Returns ErrInvalidChannel if
+// the channel is not configured or not addressed, or the recipient does not
+// suit it.
+func (s *SummarySubscriptionService) channel(sub *models.SummarySubscription) (notifications.Channel, error) {
+	ch, ok := notifications.Find(s.channels, sub.Channel)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not configured", ErrInvalidChannel, sub.Channel)
+	}
+	addressed, ok := ch.(notifications.Addressed)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s sends to a shared destination, summaries are only sent to a recipient", ErrInvalidChannel, sub.Channel)
+	}
+	if sub.Recipient == "" {
+		return nil, fmt.Errorf("%w: %s requires a recipient", ErrInvalidChannel, sub.Channel)
+	}
+	ch, err := addressed.To(sub.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidChannel, err)
+	}
+	return ch, nil
+}
+
+// nextRun returns when the summary of the current period at now is due:
+// at the start of the next one.
+func nextRun(p models.SummaryPeriod, now time.Time) time.Time {
+	return p.Start(now).AddDate(0, p.Months(), 0)
+}
+
+// confirmationMessage asks the recipient of sub to confirm receiving its
+// summaries with token.
+func confirmationMessage(sub *models.SummarySubscription, token string) notifications.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A %s summary of the subscriptions of user %s was requested to be sent to this address.\n", sub.Period, sub.UserID)
+	fmt.Fprintf(&b, "To receive it, confirm with POST /summary-subscriptions/confirm and the token:\n\n%s\n\n", token)
+	b.WriteString("If you did not request it, ignore this message: nothing is sent without confirmation.\n")
+	return notifications.Message{Subject: "Confirm your subscriptions summary", Text: b.String()}
+}
+
+// summaryMessage formats a summary of req for a notification.
+func summaryMessage(req *models.SummaryRequest, sum models.Summary) notifications.Message {
+	period := req.From.Format("01-2006")
+	if !req.To.Equal(req.From.Time) {
+		period += " – " + req.To.Format("01-2006")
+	}
+
+	var filters []string
+	if req.UserID != nil {
+		filters = append(filters, "user_id="+*req.UserID)
+	}
+	if req.ServiceName != nil {
+		filters = append(filters, "service_name="+*req.ServiceName)
+	}
+	if req.ExcludeTrials {
+		filters = append(filters, "exclude_trials")
+	}
+	if req.MinPrice != nil {
+		filters = append(filters, fmt.Sprintf("min_price=%d", *req.MinPrice))
+	}
+	if req.Currency != nil {
+		filters = append(filters, "currency="+string(*req.Currency))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Total: %s\n", sum.Total)
+	fmt.Fprintf(&b, "Subscriptions: %d\n", sum.Count)
+	fmt.Fprintf(&b, "Period: %s\n", period)
+	if len(filters) > 0 {
+		fmt.Fprintf(&b, "Filters: %s\n", strings.Join(filters, ", "))
+	}
+	return notifications.Message{Subject: "Subscriptions summary for " + period, Text: b.String()}
+}
@@ -0,0 +1,219 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/auth"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/notifications"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// summarySubscriptionRepo holds summary subscriptions and records outcomes.
+type summarySubscriptionRepo struct {
+	service.SummarySubscriptionRepo
+
+	subs      []models.SummarySubscription
+	hashes    map[int64]string // Confirmation token hash by ID until confirmed
+	sent      map[int64]time.Time
+	failed    map[int64]string
+	createErr error
+}
+
+func (r *summarySubscriptionRepo) Create(_ context.Context, s *models.SummarySubscription, confirmationHash []byte, _ ...repository.Option) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	s.ID = int64(len(r.subs) + 1)
+	r.subs = append(r.subs, *s)
+	r.hashes[s.ID] = string(confirmationHash)
+	return nil
+}
+
+func (r *summarySubscriptionRepo) Confirm(_ context.Context, confirmationHash []byte, at time.Time, _ ...repository.Option) (*models.SummarySubscription, error) {
+	for i, s := range r.subs {
+		if hash, ok := r.hashes[s.ID]; ok && hash == string(confirmationHash) {
+			delete(r.hashes, s.ID)
+			r.subs[i].ConfirmedAt = &at
+			return &r.subs[i], nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *summarySubscriptionRepo) Delete(_ context.Context, userID uuid.UUID, id int64, _ ...repository.Option) error {
+	for i, s := range r.subs {
+		if s.ID == id && s.UserID == userID {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			delete(r.hashes, id)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *summarySubscriptionRepo) Due(context.Context, time.Time, int, ...repository.Option) ([]models.SummarySubscription, error) {
+	var due []models.SummarySubscription
+	for _, s := range r.subs {
+		if s.ConfirmedAt != nil {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (r *summarySubscriptionRepo) MarkSent(_ context.Context, id int64, _, next time.Time, _ ...repository.Option) error {
+	r.sent[id] = next
+	return nil
+}
+
+func (r *summarySubscriptionRepo) MarkFailed(_ context.Context, id int64, reason string, _ ...repository.Option) error {
+	r.failed[id] = reason
+	return nil
+}
+
+// summarizer records summary requests.
+type summarizer struct {
+	requests []models.SummaryRequest
+}
+
+func (s *summarizer) Summary(_ context.Context, req *models.SummaryRequest) (models.Summary, error) {
+	s.requests = append(s.requests, *req)
+	return models.Summary{Count: 2}, nil
+}
+
+// recordingChannel records sent messages or fails with err.
+type recordingChannel struct {
+	name string
+	err  error
+	sent []notifications.Message
+}
+
+func (c *recordingChannel) Name() string { return c.name }
+
+func (c *recordingChannel) Send(_ context.Context, msg notifications.Message) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+// addressedChannel is a recordingChannel sent to recipients.
+type addressedChannel struct {
+	recordingChannel
+
+	recipients []string
+}
+
+func (c *addressedChannel) To(recipient string) (notifications.Channel, error) {
+	c.recipients = append(c.recipients, recipient)
+	return &c.recordingChannel, nil
+}
+
+func TestSummarySubscriptionService_Create(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	repo := &summarySubscriptionRepo{hashes: map[int64]string{}}
+	email := &addressedChannel{recordingChannel: recordingChannel{name: "email"}}
+	channels := notifications.Multi{&recordingChannel{name: "slack"}, email}
+	svc := service.NewSummarySubscriptionService(repo, &summarizer{}, channels, zap.NewNop())
+	ctx := auth.WithPrincipal(t.Context(), auth.Principal{UserID: owner})
+
+	sub := &models.SummarySubscription{
+		UserID: owner, Period: models.PeriodQuarter, Channel: "email", Recipient: "anna@example.com",
+		Filter: models.FilterCriteria{UserID: &stranger},
+	}
+	require.NoError(t, svc.Create(ctx, sub))
+	assert.EqualValues(t, 1, sub.ID)
+	assert.Equal(t, &owner, sub.Filter.UserID, "summaries cover the subscriptions of the subscriber only")
+	assert.Nil(t, sub.ConfirmedAt)
+	assert.True(t, sub.NextRunAt.After(time.Now()))
+	assert.Equal(t, models.PeriodQuarter.Start(sub.NextRunAt), sub.NextRunAt, "sent at the start of a quarter")
+
+	require.Len(t, email.sent, 1, "the recipient gets a confirmation token")
+	assert.Equal(t, []string{"anna@example.com"}, email.recipients)
+	token := strings.Split(email.sent[0].Text, "\n\n")[1]
+	_, err := svc.Confirm(t.Context(), "wrong")
+	assert.ErrorIs(t, err, service.ErrInvalidConfirmation)
+	confirmed, err := svc.Confirm(t.Context(), token)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, confirmed.ID)
+	assert.NotNil(t, confirmed.ConfirmedAt)
+	_, err = svc.Confirm(t.Context(), token)
+	assert.ErrorIs(t, err, service.ErrInvalidConfirmation, "a token confirms once")
+
+	for name, sub := range map[string]models.SummarySubscription{
+		"unknown channel":         {UserID: owner, Period: models.PeriodMonth, Channel: "telegram", Recipient: "@anna"},
+		"shared destination":      {UserID: owner, Period: models.PeriodMonth, Channel: "slack"},
+		"recipient for slack":     {UserID: owner, Period: models.PeriodMonth, Channel: "slack", Recipient: "#general"},
+		"email without recipient": {UserID: owner, Period: models.PeriodMonth, Channel: "email"},
+	} {
+		assert.ErrorIs(t, svc.Create(ctx, &sub), service.ErrInvalidChannel, name)
+	}
+	assert.ErrorIs(t, svc.Create(ctx, &models.SummarySubscription{UserID: stranger, Period: models.PeriodMonth, Channel: "email", Recipient: "anna@example.com"}), service.ErrForbidden)
+
+	repo.createErr = errors.New("connection refused")
+	assert.Error(t, svc.Create(ctx, &models.SummarySubscription{UserID: owner, Period: models.PeriodMonth, Channel: "email", Recipient: "anna@example.com"}))
+	assert.Len(t, email.sent, 1, "no token is sent for a subscription that was not created")
+	repo.createErr = nil
+
+	email.err = errors.New("unavailable")
+	failed := &models.SummarySubscription{UserID: owner, Period: models.PeriodMonth, Channel: "email", Recipient: "anna@example.com"}
+	assert.ErrorContains(t, svc.Create(ctx, failed), "send confirmation: unavailable")
+	assert.Zero(t, failed.ID)
+	assert.Len(t, repo.subs, 1, "a subscription whose confirmation was not sent is removed")
+	assert.Len(t, repo.hashes, 0)
+}
+
+func TestSummarySubscriptionService_SendDue(t *testing.T) {
+	owner := uuid.New()
+	netflix := "Netflix"
+	confirmed := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	repo := &summarySubscriptionRepo{
+		subs: []models.SummarySubscription{
+			// Created before the user filter was set.
+			{ID: 1, UserID: owner, Period: models.PeriodMonth, Channel: "email", Recipient: "anna@example.com", Filter: models.FilterCriteria{ServiceName: &netflix}, ConfirmedAt: &confirmed},
+			{ID: 2, UserID: owner, Period: models.PeriodYear, Channel: "email", Recipient: "anna@example.com", ConfirmedAt: &confirmed},
+			// Created before only addressed channels were allowed.
+			{ID: 3, UserID: owner, Period: models.PeriodMonth, Channel: "slack", ConfirmedAt: &confirmed},
+			{ID: 4, UserID: owner, Period: models.PeriodMonth, Channel: "email", Recipient: "bob@example.com"},
+		},
+		sent:   map[int64]time.Time{},
+		failed: map[int64]string{},
+	}
+	sums := &summarizer{}
+	email := &addressedChannel{recordingChannel: recordingChannel{name: "email"}}
+	slack := &recordingChannel{name: "slack"}
+	svc := service.NewSummarySubscriptionService(repo, sums, notifications.Multi{email, slack}, zap.NewNop())
+
+	require.NoError(t, svc.SendDue(t.Context(), 10))
+
+	now := time.Now().UTC()
+	require.Len(t, email.sent, 2)
+	assert.Equal(t, []string{"anna@example.com", "anna@example.com"}, email.recipients, "unconfirmed addresses get nothing")
+	assert.Contains(t, email.sent[0].Text, "Filters: user_id="+owner.String()+", service_name=Netflix")
+	assert.Equal(t, models.PeriodMonth.Start(now).AddDate(0, 1, 0), repo.sent[1])
+	assert.Empty(t, slack.sent)
+	assert.Contains(t, repo.failed[3], "shared destination")
+	assert.NotContains(t, repo.sent, int64(3), "a failed summary keeps its schedule")
+
+	require.Len(t, sums.requests, 2)
+	lastMonth := models.PeriodMonth.Start(now).AddDate(0, -1, 0)
+	assert.Equal(t, lastMonth, sums.requests[0].From.Time)
+	assert.Equal(t, lastMonth, sums.requests[0].To.Time)
+	assert.Equal(t, &netflix, sums.requests[0].ServiceName)
+	assert.Equal(t, owner.String(), *sums.requests[0].UserID)
+	lastYear := models.PeriodYear.Start(now).AddDate(-1, 0, 0)
+	assert.Equal(t, lastYear, sums.requests[1].From.Time)
+	assert.Equal(t, lastYear.AddDate(0, 11, 0), sums.requests[1].To.Time)
+}
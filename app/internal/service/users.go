@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// UserRepo defines repository methods required by UserService.
+type UserRepo interface {
+	// Create inserts a user; ErrDuplicate if it exists.
+	Create(ctx context.Context, u *models.User, opts ...repository.Option) error
+
+	// GetByID returns a user by ID.
+	GetByID(ctx context.Context, id uuid.UUID, opts ...repository.Option) (*models.User, error)
+
+	// List returns users ordered by ID.
+	List(ctx context.Context, limit, offset int, opts ...repository.Option) ([]models.User, error)
+
+	// Delete removes a user without subscriptions.
+	Delete(ctx context.Context, id uuid.UUID, opts ...repository.Option) error
+}
+
+// UserService syncs the users subscriptions belong to from the upstream
+// identity system.
+type UserService struct {
+	repo UserRepo
+	log  *zap.Logger
+}
+
+// NewUserService creates a new instance of UserService.
+func NewUserService(repo UserRepo, log *zap.Logger) *UserService {
+	return &UserService{repo: repo, log: log}
+}
+
+// CreateUser provisions a user. It is idempotent: for an existing user it
+// fills u from the stored one and reports created as false.
+func (s *UserService) CreateUser(ctx context.Context, u *models.User) (created bool, err error) {
+	err = s.repo.Create(ctx, u)
+	if errors.Is(err, repository.ErrDuplicate) {
+		existing, err := s.repo.GetByID(ctx, u.ID)
+		if err != nil {
+			return false, userError(err)
+		}
+		*u = *existing
+		return false, nil
+	}
+	if err != nil {
+		s.log.Error("failed to create user", zap.Error(err), retryInfo(err))
+		return false, userError(err)
+	}
+
+	s.log.Info("user provisioned", zap.String("user_id", u.ID.String()))
+	return true, nil
+}
+
+// List returns users ordered by ID.
+func (s *UserService) List(ctx context.Context, limit, offset int) ([]models.User, error) {
+	users, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		s.log.Error("failed to list users", zap.Error(err), retryInfo(err))
+		return nil, userError(err)
+	}
+	return users, nil
+}
+
+// DeleteUser deprovisions a user. Returns ErrUserHasSubscriptions if the
+// user still has subscriptions: they must be deleted first.
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return userError(err)
+	}
+
+	s.log.Info("user deprovisioned", zap.String("user_id", id.String()))
+	return nil
+}
+
+// userError wraps repository errors in the matching domain errors.
+func userError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	case errors.Is(err, repository.ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %w", ErrUserHasSubscriptions, err)
+	default:
+		return domainError(err)
+	}
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// userRepo holds users by ID; owners have subscriptions.
+type userRepo struct {
+	service.UserRepo
+
+	users  map[uuid.UUID]time.Time
+	owners map[uuid.UUID]bool
+}
+
+func (r *userRepo) Create(_ context.Context, u *models.User, _ ...repository.Option) error {
+	if _, ok := r.users[u.ID]; ok {
+		return repository.ErrDuplicate
+	}
+	u.CreatedAt = time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	r.users[u.ID] = u.CreatedAt
+	return nil
+}
+
+func (r *userRepo) GetByID(_ context.Context, id uuid.UUID, _ ...repository.Option) (*models.User, error) {
+	created, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.User{ID: id, CreatedAt: created}, nil
+}
+
+func (r *userRepo) Delete(_ context.Context, id uuid.UUID, _ ...repository.Option) error {
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	if r.owners[id] {
+		return repository.ErrForeignKeyViolation
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func TestUserService(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	repo := &userRepo{users: map[uuid.UUID]time.Time{owner: {}}, owners: map[uuid.UUID]bool{owner: true}}
+	svc := service.NewUserService(repo, zap.NewNop())
+
+	u := &models.User{ID: uuid.New()}
+	created, err := svc.CreateUser(ctx, u)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	again := &models.User{ID: u.ID}
+	created, err = svc.CreateUser(ctx, again)
+	require.NoError(t, err)
+	assert.False(t, created, "creating is idempotent")
+	assert.Equal(t, u.CreatedAt, again.CreatedAt)
+
+	assert.ErrorIs(t, svc.DeleteUser(ctx, owner), service.ErrUserHasSubscriptions)
+	require.NoError(t, svc.DeleteUser(ctx, u.ID))
+	assert.ErrorIs(t, svc.DeleteUser(ctx, u.ID), service.ErrUserNotFound)
+}
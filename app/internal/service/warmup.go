@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ActiveUsersRepo finds the users whose summaries are worth precomputing.
+type ActiveUsersRepo interface {
+	// MostActiveUsers returns up to limit users with the most subscriptions active in month.
+	MostActiveUsers(ctx context.Context, month models.MonthDate, limit int, opts ...repository.Option) ([]uuid.UUID, error)
+}
+
+// SummaryWarmer precomputes the current-month summaries of the most active
+// users into the summary cache of a SubscriptionService (see
+// WithSummaryCache), so their dashboards are served from the cache from the
+// first request on. Summaries are computed in the default schema.
+type SummaryWarmer struct {
+	subs  *SubscriptionService
+	users ActiveUsersRepo
+	limit int
+	log   *zap.Logger
+
+	mu     sync.Mutex
+	warmed []uuid.UUID
+}
+
+// NewSummaryWarmer creates a warmer of the summaries of limit users.
+func NewSummaryWarmer(subs *SubscriptionService, users ActiveUsersRepo, limit int, log *zap.Logger) *SummaryWarmer {
+	return &SummaryWarmer{subs: subs, users: users, limit: limit, log: log}
+}
+
+// Warm finds the most active users of the current month and computes their
+// summaries. Users the summary of which fails are skipped.
+func (w *SummaryWarmer) Warm(ctx context.Context) error {
+	start := time.Now()
+	month := w.subs.currentMonth()
+	users, err := w.users.MostActiveUsers(ctx, month, w.limit)
+	if err != nil {
+		w.log.Error("failed to find users to warm summaries of", zap.Error(err), retryInfo(err))
+		return err
+	}
+
+	w.mu.Lock()
+	w.warmed = users
+	w.mu.Unlock()
+
+	warmed := 0
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if w.warm(ctx, userID, month) {
+			warmed++
+		}
+	}
+	w.log.Info("summaries warmed", zap.Int("users", warmed), zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// Refill computes the summaries of warmed users that are no longer cached,
+// e.g. dropped by a write. It is meant to run asynchronously after writes.
+func (w *SummaryWarmer) Refill(ctx context.Context) {
+	w.mu.Lock()
+	users := w.warmed
+	w.mu.Unlock()
+
+	month := w.subs.currentMonth()
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		key, cacheable := w.subs.summaryKey(ctx, summaryRequest(userID, month))
+		if !cacheable {
+			return
+		}
+		if _, ok := w.subs.summaries.Get(key); !ok {
+			w.warm(ctx, userID, month)
+		}
+	}
+}
+
+// warm computes and caches the summary of userID for month.
+func (w *SummaryWarmer) warm(ctx context.Context, userID uuid.UUID, month models.MonthDate) bool {
+	// A strong read bypasses the cache and replaces the cached summary.
+	ctx = consistency.WithLevel(ctx, consistency.Strong)
+	if _, err := w.subs.Summary(ctx, summaryRequest(userID, month)); err != nil {
+		w.log.Warn("failed to warm summary", zap.String("user_id", userID.String()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// summaryRequest returns the request of a user's summary for month.
+func summaryRequest(userID uuid.UUID, month models.MonthDate) *models.SummaryRequest {
+	id := userID.String()
+	return &models.SummaryRequest{From: month, To: month, UserID: &id}
+}
+
+// currentMonth returns the first day of the current month in UTC.
+func (s *SubscriptionService) currentMonth() models.MonthDate {
+	now := s.now().UTC()
+	return models.MonthDate{Time: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)}
+}
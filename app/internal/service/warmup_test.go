@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/consistency"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+	"subscriptionsservice/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// summaryRepo counts summaries computed per user.
+type summaryRepo struct {
+	fakeRepo
+
+	subs      map[int64]*models.Subscription
+	summaries map[string]int
+	active    []uuid.UUID
+}
+
+func newSummaryRepo(active ...uuid.UUID) *summaryRepo {
+	return &summaryRepo{subs: map[int64]*models.Subscription{}, summaries: map[string]int{}, active: active}
+}
+
+func (r *summaryRepo) Summary(_ context.Context, q *models.SummaryRequest, _ ...repository.Option) (models.Summary, error) {
+	user := ""
+	if q.UserID != nil {
+		user = *q.UserID
+	}
+	r.summaries[user]++
+	return models.Summary{Count: r.summaries[user], From: q.From, To: q.To}, nil
+}
+
+func (r *summaryRepo) GetByID(_ context.Context, id int64, _ ...repository.Option) (*models.Subscription, error) {
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (r *summaryRepo) Update(_ context.Context, s *models.Subscription, _ ...repository.Option) error {
+	cp := *s
+	r.subs[s.ID] = &cp
+	return nil
+}
+
+func (r *summaryRepo) MostActiveUsers(_ context.Context, _ models.MonthDate, limit int, _ ...repository.Option) ([]uuid.UUID, error) {
+	return r.active[:min(limit, len(r.active))], nil
+}
+
+func userSummary(userID uuid.UUID) *models.SummaryRequest {
+	id := userID.String()
+	month := *monthDate(2025, time.July)
+	return &models.SummaryRequest{From: month, To: month, UserID: &id}
+}
+
+func TestSubscriptionService_SummaryCache(t *testing.T) {
+	repo := newSummaryRepo()
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithSummaryCache(time.Minute))
+	alice, bob := uuid.New(), uuid.New()
+
+	summary := func(ctx context.Context, req *models.SummaryRequest) int {
+		t.Helper()
+		sum, err := svc.Summary(ctx, req)
+		require.NoError(t, err)
+		return sum.Count
+	}
+
+	assert.Equal(t, 1, summary(t.Context(), userSummary(alice)))
+	assert.Equal(t, 1, summary(t.Context(), userSummary(alice)), "cached")
+	assert.Equal(t, 2, summary(consistency.WithLevel(t.Context(), consistency.Strong), userSummary(alice)), "strong reads bypass the cache")
+	assert.Equal(t, 2, summary(t.Context(), userSummary(alice)), "strong reads refresh the cache")
+
+	filtered := userSummary(alice)
+	netflix := "Netflix"
+	filtered.ServiceName = &netflix
+	assert.Equal(t, 3, summary(t.Context(), filtered), "only unfiltered user summaries are cached")
+	assert.Equal(t, 4, summary(t.Context(), filtered))
+
+	assert.Equal(t, 1, summary(t.Context(), userSummary(bob)))
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{
+		ServiceName: "Netflix", UserID: alice, StartDate: *monthDate(2025, time.July),
+	}))
+	assert.Equal(t, 5, summary(t.Context(), userSummary(alice)), "writes drop the user's summaries")
+	assert.Equal(t, 1, summary(t.Context(), userSummary(bob)), "other users stay cached")
+
+	repo.subs[1] = &models.Subscription{ID: 1, ServiceName: "Netflix", UserID: alice, StartDate: *monthDate(2025, time.July)}
+	require.NoError(t, svc.Update(t.Context(), &models.Subscription{
+		ID: 1, ServiceName: "Netflix", UserID: bob, StartDate: *monthDate(2025, time.July),
+	}))
+	assert.Equal(t, 6, summary(t.Context(), userSummary(alice)), "moving a subscription drops the previous user's summaries")
+	assert.Equal(t, 2, summary(t.Context(), userSummary(bob)))
+}
+
+func TestSummaryWarmer(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	repo := newSummaryRepo(alice, bob, carol)
+	svc := service.NewSubscriptionService(repo, zap.NewNop(), service.WithSummaryCache(time.Minute))
+	warmer := service.NewSummaryWarmer(svc, repo, 2, zap.NewNop())
+
+	require.NoError(t, warmer.Warm(t.Context()))
+	assert.Equal(t, map[string]int{alice.String(): 1, bob.String(): 1}, repo.summaries)
+
+	now := time.Now().UTC()
+	month := models.MonthDate{Time: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)}
+	id := alice.String()
+	sum, err := svc.Summary(t.Context(), &models.SummaryRequest{From: month, To: month, UserID: &id})
+	require.NoError(t, err)
+	assert.Equal(t, 1, sum.Count, "served from the cache")
+
+	require.NoError(t, svc.CreateSubscription(t.Context(), &models.Subscription{
+		ServiceName: "Netflix", UserID: alice, StartDate: month,
+	}))
+	warmer.Refill(t.Context())
+	assert.Equal(t, map[string]int{alice.String(): 2, bob.String(): 1}, repo.summaries, "only dropped summaries are recomputed")
+}
@@ -0,0 +1,340 @@
+// Package signing signs outgoing webhook requests with rotating Ed25519 keys
+// and publishes their public keys as a JWK Set, so receivers can verify
+// deliveries without a shared static secret.
+//
+// A signed request carries the headers of the Standard Webhooks spec with an
+// asymmetric signature, plus the ID of the key that made it:
+//
+//	Webhook-Id: 5f0c...             unique per message, kept on retries
+//	Webhook-Timestamp: 1751371200   Unix seconds
+//	Webhook-Key-Id: Xq3...          kid of the key in the JWK Set
+//	Webhook-Signature: v1a,<base64 Ed25519 signature of "<id>.<timestamp>.<body>">
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"subscriptionsservice/internal/apierr"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Headers of signed requests.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderKeyID     = "Webhook-Key-Id"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// signatureVersion prefixes signatures: v1a marks Ed25519 ones in the
+// Standard Webhooks spec.
+const signatureVersion = "v1a"
+
+// refreshInterval is how long keys are cached. Rotations made by other
+// instances are picked up within it; the replaced key stays published, so
+// their receivers are not affected meanwhile.
+const refreshInterval = time.Minute
+
+var (
+	// ErrRotationConflict is returned when a concurrent rotation won.
+	ErrRotationConflict = errors.New("webhook key rotation conflict")
+	// ErrUnknownKey is returned by Verify for a key ID missing from the key
+	// set; receivers should refetch it once before rejecting the request.
+	ErrUnknownKey = errors.New("unknown webhook key")
+	// ErrInvalidSignature is returned by Verify for a missing, malformed,
+	// stale or not matching signature.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Store defines repository methods required by Keyring.
+type Store interface {
+	// List returns the active key and the keys retired after retiredAfter,
+	// newest first.
+	List(ctx context.Context, retiredAfter time.Time, opts ...repository.Option) ([]models.WebhookKey, error)
+
+	// Rotate makes k the active key and deletes keys retired before
+	// pruneBefore; ErrDuplicate if a concurrent rotation won.
+	Rotate(ctx context.Context, k *models.WebhookKey, pruneBefore time.Time, opts ...repository.Option) error
+}
+
+// Keyring signs requests with the active key and publishes the keys
+// receivers may see signatures of: the active one and those retired within
+// the grace period, which should cover the longest redelivery of a request
+// plus the time receivers cache the key set. The first key is generated on
+// the first signature.
+type Keyring struct {
+	store Store
+	grace time.Duration
+	log   *zap.Logger
+
+	mu sync.Mutex
+	// keys are the published keys, newest first, loaded at loadedAt.
+	keys     []models.WebhookKey
+	loadedAt time.Time
+
+	now func() time.Time
+}
+
+// NewKeyring creates a Keyring backed by store, publishing retired keys for
+// grace.
+func NewKeyring(store Store, grace time.Duration, log *zap.Logger) *Keyring {
+	return &Keyring{store: store, grace: grace, log: log, now: time.Now}
+}
+
+// Keys returns the published keys, newest first.
+func (k *Keyring) Keys(ctx context.Context) ([]models.WebhookKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.load(ctx)
+}
+
+// Rotate generates a key that signs from now on. The replaced key stays
+// published for the grace period; keys retired before it are deleted.
+func (k *Keyring) Rotate(ctx context.Context) (models.WebhookKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, err := k.rotate(ctx)
+	if err != nil {
+		return models.WebhookKey{}, err
+	}
+	k.log.Info("webhook signing key rotated", zap.String("kid", key.ID))
+	return key, nil
+}
+
+// Sign signs req, whose body is body, with the active key. The headers are
+// sent again on retries of req, so receivers can deduplicate deliveries by
+// the message ID.
+func (k *Keyring) Sign(req *http.Request, body []byte) error {
+	key, err := k.active(req.Context())
+	if err != nil {
+		return fmt.Errorf("sign webhook: %w", err)
+	}
+
+	id := uuid.NewString()
+	timestamp := strconv.FormatInt(k.now().Unix(), 10)
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(key.PrivateKey), signedContent(id, timestamp, body))
+
+	req.Header.Set(HeaderID, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderKeyID, key.ID)
+	req.Header.Set(HeaderSignature, signatureVersion+","+base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// JWKS returns the published keys as a JWK Set.
+func (k *Keyring) JWKS(ctx context.Context) (JWKS, error) {
+	keys, err := k.Keys(ctx)
+	if err != nil {
+		return JWKS{}, err
+	}
+
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, PublicJWK(key.ID, key.PublicKey))
+	}
+	return set, nil
+}
+
+// Handler serves the key set, e.g. at /.well-known/webhook-keys. Receivers
+// may cache it briefly: a key ID they do not know is a reason to refetch.
+func Handler(k *Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, err := k.JWKS(c.Request.Context())
+		if err != nil {
+			apierr.AbortWithError(c, &apierr.Error{
+				Status: http.StatusInternalServerError,
+				Code:   apierr.CodeInternal,
+				Detail: "failed to load webhook keys",
+				Err:    err,
+			})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, set)
+	}
+}
+
+// active returns the signing key, generating the first one if there is
+// none yet.
+func (k *Keyring) active(ctx context.Context) (models.WebhookKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys, err := k.load(ctx)
+	if err != nil {
+		return models.WebhookKey{}, err
+	}
+	if len(keys) > 0 && keys[0].RetiredAt == nil {
+		return keys[0], nil
+	}
+
+	key, err := k.rotate(ctx)
+	if errors.Is(err, ErrRotationConflict) {
+		// Another instance generated it first.
+		if keys, err = k.load(ctx); err != nil {
+			return models.WebhookKey{}, err
+		}
+		if len(keys) > 0 && keys[0].RetiredAt == nil {
+			return keys[0], nil
+		}
+		return models.WebhookKey{}, errors.New("no active webhook key")
+	}
+	if err != nil {
+		return models.WebhookKey{}, err
+	}
+	k.log.Info("webhook signing key generated", zap.String("kid", key.ID))
+	return key, nil
+}
+
+// load returns the cached keys, reloading them if they are stale. It is
+// called with mu held.
+func (k *Keyring) load(ctx context.Context) ([]models.WebhookKey, error) {
+	now := k.now()
+	if !k.loadedAt.IsZero() && now.Sub(k.loadedAt) < refreshInterval {
+		return k.keys, nil
+	}
+
+	keys, err := k.store.List(ctx, now.Add(-k.grace))
+	if err != nil {
+		return nil, fmt.Errorf("load webhook keys: %w", err)
+	}
+	k.keys, k.loadedAt = keys, now
+	return keys, nil
+}
+
+// rotate stores a new active key and drops the cached keys. It is called
+// with mu held.
+func (k *Keyring) rotate(ctx context.Context) (models.WebhookKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return models.WebhookKey{}, err
+	}
+
+	now := k.now()
+	key := models.WebhookKey{
+		ID:         Thumbprint(pub),
+		PublicKey:  pub,
+		PrivateKey: priv.Seed(),
+		CreatedAt:  now,
+	}
+	err = k.store.Rotate(ctx, &key, now.Add(-k.grace))
+	// Even a failed rotation may mean the keys changed, e.g. by another one.
+	k.loadedAt = time.Time{}
+	if errors.Is(err, repository.ErrDuplicate) {
+		return models.WebhookKey{}, fmt.Errorf("%w: %w", ErrRotationConflict, err)
+	}
+	if err != nil {
+		return models.WebhookKey{}, fmt.Errorf("rotate webhook key: %w", err)
+	}
+	return key, nil
+}
+
+// JWK is an Ed25519 public key as a JSON Web Key (RFC 8037).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the JWK of pub with key ID kid.
+func PublicJWK(kid string, pub ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(pub),
+		KeyID:     kid,
+		Use:       "sig",
+		Algorithm: "EdDSA",
+	}
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of the JWK of pub, which is
+// used as its key ID.
+func Thumbprint(pub ed25519.PublicKey) string {
+	x := base64.RawURLEncoding.EncodeToString(pub)
+	sum := sha256.Sum256([]byte(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Verify checks a signed request with header h and body as a receiver
+// does: the signature must be made over its ID, timestamp and body by the
+// key of the key set with its key ID, and the timestamp must be within
+// tolerance of now, which bounds replays.
+func Verify(set JWKS, h http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	id, timestamp, kid := h.Get(HeaderID), h.Get(HeaderTimestamp), h.Get(HeaderKeyID)
+	if id == "" || timestamp == "" || kid == "" {
+		return fmt.Errorf("%w: missing headers", ErrInvalidSignature)
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	var pub ed25519.PublicKey
+	for _, key := range set.Keys {
+		if key.KeyID == kid && key.KeyType == "OKP" && key.Curve == "Ed25519" {
+			pub, err = base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil || len(pub) != ed25519.PublicKeySize {
+				return fmt.Errorf("%w: malformed key %s", ErrUnknownKey, kid)
+			}
+			break
+		}
+	}
+	if pub == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+
+	// The header may list several space-separated signatures.
+	content := signedContent(id, timestamp, body)
+	for _, s := range strings.Fields(h.Get(HeaderSignature)) {
+		version, encoded, ok := strings.Cut(s, ",")
+		if !ok || version != signatureVersion {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil && ed25519.Verify(pub, content, sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// signedContent is what a signature is made over.
+func signedContent(id, timestamp string, body []byte) []byte {
+	content := make([]byte, 0, len(id)+len(timestamp)+len(body)+2)
+	content = append(content, id...)
+	content = append(content, '.')
+	content = append(content, timestamp...)
+	content = append(content, '.')
+	return append(content, body...)
+}
@@ -0,0 +1,152 @@
+package signing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStore keeps keys newest first, like WebhookKeyRepo.
+type fakeStore struct {
+	keys     []models.WebhookKey
+	lists    int
+	conflict *models.WebhookKey // Inserted instead of the next rotated key.
+}
+
+func (s *fakeStore) List(_ context.Context, retiredAfter time.Time, _ ...repository.Option) ([]models.WebhookKey, error) {
+	s.lists++
+	var keys []models.WebhookKey
+	for _, k := range s.keys {
+		if k.RetiredAt == nil || k.RetiredAt.After(retiredAfter) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *fakeStore) Rotate(_ context.Context, k *models.WebhookKey, pruneBefore time.Time, _ ...repository.Option) error {
+	if s.conflict != nil {
+		s.keys = append([]models.WebhookKey{*s.conflict}, s.keys...)
+		s.conflict = nil
+		return repository.ErrDuplicate
+	}
+	var kept []models.WebhookKey
+	for _, old := range s.keys {
+		if old.RetiredAt == nil {
+			old.RetiredAt = &k.CreatedAt
+		}
+		if !old.RetiredAt.Before(pruneBefore) {
+			kept = append(kept, old)
+		}
+	}
+	s.keys = append([]models.WebhookKey{*k}, kept...)
+	return nil
+}
+
+func signedRequest(t *testing.T, k *Keyring, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	require.NoError(t, k.Sign(req, []byte(body)))
+	return req
+}
+
+func TestKeyring(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{}
+	k := NewKeyring(store, 72*time.Hour, zap.NewNop())
+	k.now = func() time.Time { return now }
+
+	set, err := k.JWKS(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, set.Keys, "keys are generated on the first signature")
+
+	body := `{"subject":"Alert","text":"db is down"}`
+	first := signedRequest(t, k, body)
+	kid := first.Header.Get(HeaderKeyID)
+	assert.True(t, strings.HasPrefix(first.Header.Get(HeaderSignature), "v1a,"))
+
+	set, err = k.JWKS(t.Context())
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, kid, set.Keys[0].KeyID)
+	assert.Equal(t, "Ed25519", set.Keys[0].Curve)
+	require.NoError(t, Verify(set, first.Header, []byte(body), now, 5*time.Minute))
+
+	t.Run("rejected", func(t *testing.T) {
+		err := Verify(set, first.Header, []byte(`{"subject":"Alert","text":"all good"}`), now, 5*time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature, "tampered body")
+
+		err = Verify(set, first.Header, []byte(body), now.Add(time.Hour), 5*time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature, "replayed later")
+
+		err = Verify(JWKS{}, first.Header, []byte(body), now, 5*time.Minute)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		lists := store.lists
+		signedRequest(t, k, body)
+		assert.Equal(t, lists, store.lists, "keys are cached")
+
+		now = now.Add(time.Hour)
+		rotated, err := k.Rotate(t.Context())
+		require.NoError(t, err)
+		assert.NotEqual(t, kid, rotated.ID)
+
+		second := signedRequest(t, k, body)
+		assert.Equal(t, rotated.ID, second.Header.Get(HeaderKeyID))
+
+		set, err := k.JWKS(t.Context())
+		require.NoError(t, err)
+		require.Len(t, set.Keys, 2, "the replaced key stays published")
+		require.NoError(t, Verify(set, first.Header, []byte(body), now.Add(-time.Hour), 5*time.Minute))
+		require.NoError(t, Verify(set, second.Header, []byte(body), now, 5*time.Minute))
+
+		now = now.Add(73 * time.Hour)
+		set, err = k.JWKS(t.Context())
+		require.NoError(t, err)
+		require.Len(t, set.Keys, 1, "after the grace period it is not")
+		assert.Equal(t, rotated.ID, set.Keys[0].KeyID)
+	})
+}
+
+func TestKeyring_ConcurrentFirstKey(t *testing.T) {
+	other := models.WebhookKey{ID: "other", PublicKey: make([]byte, 32), PrivateKey: make([]byte, 32)}
+	store := &fakeStore{conflict: &other}
+	k := NewKeyring(store, time.Hour, zap.NewNop())
+
+	req := signedRequest(t, k, "{}")
+	assert.Equal(t, "other", req.Header.Get(HeaderKeyID), "the key generated by another instance is used")
+
+	_, err := k.Rotate(t.Context())
+	require.NoError(t, err)
+	store.conflict = &other
+	_, err = k.Rotate(t.Context())
+	assert.ErrorIs(t, err, ErrRotationConflict)
+}
+
+func TestHandler(t *testing.T) {
+	store := &fakeStore{}
+	k := NewKeyring(store, time.Hour, zap.NewNop())
+	_, err := k.Rotate(t.Context())
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/.well-known/webhook-keys", nil)
+	Handler(k)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"kid":"`+store.keys[0].ID+`"`)
+}
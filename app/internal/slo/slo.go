@@ -0,0 +1,268 @@
+// Package slo tracks served requests over a rolling window and compares
+// their error rate and latency with service level objectives, so probes can
+// read the SLO burn from the service itself.
+package slo
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slots is the number of slots the window is split into; a slot expires
+// as a whole, so the window is rolling with slot precision.
+const slots = 60
+
+// latencyBounds are upper bounds of latency buckets, 1ms to ~11s growing
+// by half. Percentiles are interpolated within a bucket.
+var latencyBounds = func() []time.Duration {
+	bounds := make([]time.Duration, 24)
+	b := float64(time.Millisecond)
+	for i := range bounds {
+		bounds[i] = time.Duration(b)
+		b *= 1.5
+	}
+	return bounds
+}()
+
+// slot holds requests served within one slot of the window.
+type slot struct {
+	start    time.Time
+	requests int64
+	errors   int64
+	latency  []int64 // per bucket of latencyBounds, the last one unbounded
+}
+
+// Tracker records served requests over a rolling window. It implements
+// middleware.RequestRecorder and is safe for concurrent use.
+type Tracker struct {
+	window  time.Duration
+	slot    time.Duration
+	ignored map[string]bool
+
+	mu    sync.Mutex
+	slots [slots]slot
+	now   func() time.Time
+}
+
+// Option configures Tracker.
+type Option func(*Tracker)
+
+// WithIgnoredRoutes excludes requests to the route patterns, e.g. probes,
+// from the status.
+func WithIgnoredRoutes(routes ...string) Option {
+	return func(t *Tracker) {
+		for _, r := range routes {
+			t.ignored[r] = true
+		}
+	}
+}
+
+// NewTracker creates a tracker of requests served within the last window.
+func NewTracker(window time.Duration, opts ...Option) *Tracker {
+	t := &Tracker{
+		window:  window,
+		slot:    max(window/slots, time.Millisecond),
+		ignored: map[string]bool{},
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// ObserveRequest records a served request; server errors (5xx) count
+// against availability.
+func (t *Tracker) ObserveRequest(_, route string, status int, d time.Duration) {
+	if t.ignored[route] {
+		return
+	}
+	bucket := len(latencyBounds)
+	for i, b := range latencyBounds {
+		if d <= b {
+			bucket = i
+			break
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.current()
+	s.requests++
+	if status >= http.StatusInternalServerError {
+		s.errors++
+	}
+	s.latency[bucket]++
+}
+
+// current returns the slot of now, resetting it if it holds an expired one.
+func (t *Tracker) current() *slot {
+	start := t.now().Truncate(t.slot)
+	s := &t.slots[int(start.UnixNano()/int64(t.slot))%slots]
+	if !s.start.Equal(start) {
+		*s = slot{start: start, latency: make([]int64, len(latencyBounds)+1)}
+	}
+	return s
+}
+
+// Stats are requests served within the window.
+type Stats struct {
+	Requests  int64
+	Errors    int64
+	ErrorRate float64 // Share of server errors, 0 without requests
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
+
+// Stats returns the requests served within the last window.
+func (t *Tracker) Stats() Stats {
+	latency := make([]int64, len(latencyBounds)+1)
+	var st Stats
+
+	t.mu.Lock()
+	since := t.now().Add(-t.window)
+	for i := range t.slots {
+		s := &t.slots[i]
+		if s.latency == nil || !s.start.After(since) {
+			continue
+		}
+		st.Requests += s.requests
+		st.Errors += s.errors
+		for j, n := range s.latency {
+			latency[j] += n
+		}
+	}
+	t.mu.Unlock()
+
+	if st.Requests > 0 {
+		st.ErrorRate = float64(st.Errors) / float64(st.Requests)
+	}
+	st.P50 = percentile(latency, st.Requests, 0.50)
+	st.P95 = percentile(latency, st.Requests, 0.95)
+	st.P99 = percentile(latency, st.Requests, 0.99)
+	return st
+}
+
+// percentile estimates the q-th latency percentile of n requests from
+// bucket counts, interpolating linearly within the bucket it falls into.
+func percentile(buckets []int64, n int64, q float64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	rank := q * float64(n)
+	var seen int64
+	for i, count := range buckets {
+		if count == 0 || float64(seen+count) < rank {
+			seen += count
+			continue
+		}
+		if i == len(latencyBounds) {
+			return latencyBounds[i-1]
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		frac := (rank - float64(seen)) / float64(count)
+		return lower + time.Duration(frac*float64(latencyBounds[i]-lower))
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+// Targets are the service level objectives.
+type Targets struct {
+	Availability float64       // Target share of requests without server errors, e.g. 0.999
+	LatencyP95   time.Duration // Target 95th percentile latency, 0 — none
+	LatencyP99   time.Duration // Target 99th percentile latency, 0 — none
+}
+
+// Status of the objectives.
+type Status string
+
+const (
+	StatusOK       Status = "ok"       // All objectives are met.
+	StatusBreached Status = "breached" // An objective is not met.
+)
+
+// Objective compares a target with the actual value over the window.
+// Latencies are in milliseconds.
+type Objective struct {
+	Name   string  `json:"name"`
+	Target float64 `json:"target"`
+	Actual float64 `json:"actual"`
+	// BurnRate of availability: how fast the error budget is spent, 1 —
+	// exactly as fast as the objective allows.
+	BurnRate *float64 `json:"burn_rate,omitempty"`
+	Met      bool     `json:"met"`
+}
+
+// Report is the status of the objectives over the window.
+type Report struct {
+	Status     Status      `json:"status"`
+	Window     string      `json:"window"`
+	Requests   int64       `json:"requests"`
+	Errors     int64       `json:"errors"`
+	ErrorRate  float64     `json:"error_rate"`
+	Latency    Latency     `json:"latency"`
+	Objectives []Objective `json:"objectives"`
+}
+
+// Latency holds latency percentiles in milliseconds.
+type Latency struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// Report compares the requests served within the window with targets.
+func (t *Tracker) Report(targets Targets) Report {
+	st := t.Stats()
+	r := Report{
+		Status:    StatusOK,
+		Window:    t.window.String(),
+		Requests:  st.Requests,
+		Errors:    st.Errors,
+		ErrorRate: st.ErrorRate,
+		Latency:   Latency{P50: ms(st.P50), P95: ms(st.P95), P99: ms(st.P99)},
+	}
+
+	burn := st.ErrorRate / (1 - targets.Availability)
+	r.add(Objective{
+		Name:     "availability",
+		Target:   targets.Availability,
+		Actual:   1 - st.ErrorRate,
+		BurnRate: &burn,
+		Met:      1-st.ErrorRate >= targets.Availability,
+	})
+	if targets.LatencyP95 > 0 {
+		r.add(Objective{Name: "latency_p95", Target: ms(targets.LatencyP95), Actual: ms(st.P95), Met: st.P95 <= targets.LatencyP95})
+	}
+	if targets.LatencyP99 > 0 {
+		r.add(Objective{Name: "latency_p99", Target: ms(targets.LatencyP99), Actual: ms(st.P99), Met: st.P99 <= targets.LatencyP99})
+	}
+	return r
+}
+
+func (r *Report) add(o Objective) {
+	r.Objectives = append(r.Objectives, o)
+	if !o.Met {
+		r.Status = StatusBreached
+	}
+}
+
+// ms returns d in milliseconds with microsecond precision.
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Handler serves the report against targets. It always responds 200: a
+// breached objective is reported in the body, the service still serves.
+func Handler(t *Tracker, targets Targets) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, t.Report(targets))
+	}
+}
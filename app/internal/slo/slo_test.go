@@ -0,0 +1,86 @@
+package slo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_Stats(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(time.Hour, WithIgnoredRoutes("/healthz"))
+	tr.now = func() time.Time { return now }
+
+	for range 95 {
+		tr.ObserveRequest("GET", "/subscriptions/", http.StatusOK, 10*time.Millisecond)
+	}
+	for range 4 {
+		tr.ObserveRequest("GET", "/subscriptions/", http.StatusNotFound, 200*time.Millisecond)
+	}
+	tr.ObserveRequest("POST", "/subscriptions/", http.StatusInternalServerError, 3*time.Second)
+	tr.ObserveRequest("GET", "/healthz", http.StatusServiceUnavailable, time.Millisecond)
+
+	st := tr.Stats()
+	assert.EqualValues(t, 100, st.Requests)
+	assert.EqualValues(t, 1, st.Errors, "client errors do not count")
+	assert.InDelta(t, 0.01, st.ErrorRate, 1e-9)
+	assert.InDelta(t, 10*time.Millisecond, st.P50, float64(3*time.Millisecond))
+	assert.Greater(t, st.P99, 190*time.Millisecond, "estimated within the bucket of 200ms")
+	assert.Less(t, st.P99, 300*time.Millisecond, "estimated within the bucket of 200ms")
+
+	now = now.Add(59 * time.Minute)
+	tr.ObserveRequest("GET", "/subscriptions/", http.StatusOK, 10*time.Millisecond)
+	assert.EqualValues(t, 101, tr.Stats().Requests)
+
+	now = now.Add(2 * time.Minute)
+	assert.EqualValues(t, 1, tr.Stats().Requests, "requests older than the window expire")
+}
+
+func TestTracker_Report(t *testing.T) {
+	now := time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(time.Hour)
+	tr.now = func() time.Time { return now }
+	targets := Targets{Availability: 0.99, LatencyP95: 300 * time.Millisecond, LatencyP99: time.Second}
+
+	r := tr.Report(targets)
+	assert.Equal(t, StatusOK, r.Status, "no requests meet the objectives")
+	require.Len(t, r.Objectives, 3)
+
+	for range 90 {
+		tr.ObserveRequest("GET", "/subscriptions/", http.StatusOK, 10*time.Millisecond)
+	}
+	for range 10 {
+		tr.ObserveRequest("GET", "/subscriptions/", http.StatusBadGateway, 10*time.Millisecond)
+	}
+	r = tr.Report(targets)
+	assert.Equal(t, StatusBreached, r.Status)
+	assert.Equal(t, "availability", r.Objectives[0].Name)
+	assert.False(t, r.Objectives[0].Met)
+	assert.InDelta(t, 10, *r.Objectives[0].BurnRate, 1e-9, "errors spend the budget ten times faster than allowed")
+	assert.True(t, r.Objectives[1].Met)
+	assert.Equal(t, 300.0, r.Objectives[1].Target)
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tr := NewTracker(5 * time.Minute)
+	tr.ObserveRequest("GET", "/subscriptions/", http.StatusOK, 10*time.Millisecond)
+
+	r := gin.New()
+	r.GET("/status", Handler(tr, Targets{Availability: 0.999}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, "5m0s", report.Window)
+	assert.EqualValues(t, 1, report.Requests)
+}
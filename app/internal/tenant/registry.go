@@ -0,0 +1,349 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"subscriptionsservice/internal/cache"
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// Store defines repository methods required by Registry.
+type Store interface {
+	// Create inserts a tenant; ErrDuplicate if the ID is taken.
+	Create(ctx context.Context, t *models.Tenant, opts ...repository.Option) error
+
+	// GetByID returns a tenant by its ID.
+	GetByID(ctx context.Context, id string, opts ...repository.Option) (*models.Tenant, error)
+
+	// List returns tenants ordered by ID.
+	List(ctx context.Context, limit, offset int, opts ...repository.Option) ([]models.Tenant, error)
+
+	// Update replaces the name and limits of a tenant.
+	Update(ctx context.Context, t *models.Tenant, opts ...repository.Option) error
+
+	// Delete removes a tenant with its API keys.
+	Delete(ctx context.Context, id string, opts ...repository.Option) error
+
+	// CreateAPIKey stores the hash of a new API key.
+	CreateAPIKey(ctx context.Context, k *models.APIKey, hash []byte, opts ...repository.Option) error
+
+	// ListAPIKeys returns the API keys of a tenant.
+	ListAPIKeys(ctx context.Context, tenantID string, opts ...repository.Option) ([]models.APIKey, error)
+
+	// RevokeAPIKey marks a not yet revoked API key of a tenant revoked.
+	RevokeAPIKey(ctx context.Context, tenantID string, id int64, at time.Time, opts ...repository.Option) error
+
+	// TenantByAPIKey returns the tenant of the not revoked key with hash.
+	TenantByAPIKey(ctx context.Context, hash []byte, opts ...repository.Option) (string, error)
+}
+
+// SchemaMigrator creates and migrates tenant schemas.
+type SchemaMigrator interface {
+	// MigrateSchema creates schema if needed and applies pending migrations.
+	MigrateSchema(ctx context.Context, schema string) error
+
+	// SchemaVersion returns the migration version of schema.
+	SchemaVersion(ctx context.Context, schema string) (version uint, dirty bool, err error)
+}
+
+var (
+	// ErrNotFound is returned when a tenant does not exist.
+	ErrNotFound = errors.New("tenant not found")
+
+	// ErrAPIKeyNotFound is returned when revoking an API key the tenant
+	// does not have or that is already revoked.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrExists is returned when registering a tenant with a taken ID.
+	ErrExists = errors.New("tenant already exists")
+
+	// ErrInvalidLimits is returned for negative tenant limits.
+	ErrInvalidLimits = errors.New("tenant limits must not be negative")
+
+	// ErrInvalidAPIKey is returned for unknown and revoked API keys.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+const (
+	// cacheTTL is how long tenants and API keys are cached for requests.
+	// Changes made through another instance show up after it.
+	cacheTTL = 30 * time.Second
+	// cacheSize bounds the number of cached tenants and API keys.
+	cacheSize = 10000
+
+	// forEachPageSize is the number of tenants ForEach lists at once.
+	forEachPageSize = 100
+
+	// apiKeyPrefix marks API keys of the service, e.g. for secret scanners.
+	apiKeyPrefix = "subs_"
+	// apiKeyBytes is the number of random bytes of an API key.
+	apiKeyBytes = 32
+	// apiKeyShownLen is the length of APIKey.Prefix.
+	apiKeyShownLen = len(apiKeyPrefix) + 6
+)
+
+// Registry registers tenants, provisions their schemas and issues their API
+// keys. Lookups made for requests (Authenticate, Known, Limits) are cached.
+type Registry struct {
+	store   Store
+	schemas SchemaMigrator
+	log     *zap.Logger
+
+	// tenants caches lookups by ID, nil for unknown tenants.
+	tenants *cache.TTL[ID, *models.Tenant]
+	// keys caches tenants by API key hash.
+	keys *cache.TTL[[sha256.Size]byte, ID]
+
+	now func() time.Time
+}
+
+// NewRegistry creates a Registry backed by store.
+func NewRegistry(store Store, schemas SchemaMigrator, log *zap.Logger) *Registry {
+	return &Registry{
+		store:   store,
+		schemas: schemas,
+		log:     log,
+		tenants: cache.New[ID, *models.Tenant](cacheTTL, cacheSize),
+		keys:    cache.New[[sha256.Size]byte, ID](cacheTTL, cacheSize),
+		now:     time.Now,
+	}
+}
+
+// Create registers a tenant and provisions its schema. The schema is
+// migrated first, so a tenant is only visible once it can be served; if
+// registering fails afterwards, the schema is left for a retry.
+func (r *Registry) Create(ctx context.Context, t *models.Tenant) error {
+	id, err := Parse(t.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkLimits(t.Limits); err != nil {
+		return err
+	}
+
+	if err := r.schemas.MigrateSchema(ctx, id.Schema()); err != nil {
+		return fmt.Errorf("failed to provision schema of tenant %s: %w", id, err)
+	}
+	if err := r.store.Create(ctx, t); err != nil {
+		return storeError(err)
+	}
+	r.tenants.Delete(id)
+
+	r.log.Info("tenant registered", zap.String("tenant", t.ID))
+	return nil
+}
+
+// Get returns a tenant by ID.
+func (r *Registry) Get(ctx context.Context, id ID) (*models.Tenant, error) {
+	t, err := r.store.GetByID(ctx, string(id))
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return t, nil
+}
+
+// List returns tenants ordered by ID.
+func (r *Registry) List(ctx context.Context, limit, offset int) ([]models.Tenant, error) {
+	return r.store.List(ctx, limit, offset)
+}
+
+// ForEach calls fn with ctx in the default schema and then in the schema of
+// each registered tenant, e.g. for background jobs over the data of all
+// tenants. A tenant fn fails for does not stop the others; the errors are
+// returned joined.
+func (r *Registry) ForEach(ctx context.Context, fn func(ctx context.Context) error) error {
+	errs := []error{fn(registryContext(ctx))}
+	for offset := 0; ; offset += forEachPageSize {
+		tenants, err := r.store.List(registryContext(ctx), forEachPageSize, offset)
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("list tenants: %w", err))...)
+		}
+		for _, t := range tenants {
+			if err := ctx.Err(); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			if err := fn(WithTenant(ctx, ID(t.ID))); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", t.ID, err))
+			}
+		}
+		if len(tenants) < forEachPageSize {
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// Update replaces the name and limits of a tenant.
+func (r *Registry) Update(ctx context.Context, t *models.Tenant) error {
+	if err := checkLimits(t.Limits); err != nil {
+		return err
+	}
+	if err := r.store.Update(ctx, t); err != nil {
+		return storeError(err)
+	}
+	r.tenants.Delete(ID(t.ID))
+
+	r.log.Info("tenant updated", zap.String("tenant", t.ID))
+	return nil
+}
+
+// Delete unregisters a tenant and revokes its API keys. The schema and the
+// data in it are kept: drop it manually once no longer needed.
+func (r *Registry) Delete(ctx context.Context, id ID) error {
+	if err := r.store.Delete(ctx, string(id)); err != nil {
+		return storeError(err)
+	}
+	r.tenants.Delete(id)
+	r.keys.Clear()
+
+	r.log.Warn("tenant deleted, its schema is kept", zap.String("tenant", string(id)))
+	return nil
+}
+
+// MigrateSchema applies pending migrations to the schema of a registered
+// tenant, e.g. after deploying new migrations, and returns the resulting
+// migration version.
+func (r *Registry) MigrateSchema(ctx context.Context, id ID) (version uint, dirty bool, err error) {
+	if _, err := r.Get(ctx, id); err != nil {
+		return 0, false, err
+	}
+	if err := r.schemas.MigrateSchema(ctx, id.Schema()); err != nil {
+		return 0, false, err
+	}
+	return r.schemas.SchemaVersion(ctx, id.Schema())
+}
+
+// IssueAPIKey creates an API key of a tenant. The key is only returned
+// here: the registry keeps a hash of it.
+func (r *Registry) IssueAPIKey(ctx context.Context, id ID) (string, *models.APIKey, error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	hash := sha256.Sum256([]byte(key))
+
+	k := &models.APIKey{TenantID: string(id), Prefix: key[:apiKeyShownLen]}
+	if err := r.store.CreateAPIKey(ctx, k, hash[:]); err != nil {
+		if errors.Is(err, repository.ErrForeignKeyViolation) {
+			return "", nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return "", nil, err
+	}
+
+	r.log.Info("tenant api key issued", zap.String("tenant", string(id)), zap.Int64("key_id", k.ID))
+	return key, k, nil
+}
+
+// ListAPIKeys returns the API keys of a tenant, revoked ones included.
+func (r *Registry) ListAPIKeys(ctx context.Context, id ID) ([]models.APIKey, error) {
+	if _, err := r.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return r.store.ListAPIKeys(ctx, string(id))
+}
+
+// RevokeAPIKey revokes an API key of a tenant. Other instances may accept
+// the key until their cache expires.
+func (r *Registry) RevokeAPIKey(ctx context.Context, id ID, keyID int64) error {
+	if err := r.store.RevokeAPIKey(ctx, string(id), keyID, r.now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: %w", ErrAPIKeyNotFound, err)
+		}
+		return err
+	}
+	r.keys.Clear()
+
+	r.log.Warn("tenant api key revoked", zap.String("tenant", string(id)), zap.Int64("key_id", keyID))
+	return nil
+}
+
+// Authenticate returns the tenant of an API key, or ErrInvalidAPIKey if the
+// key is unknown or revoked.
+func (r *Registry) Authenticate(ctx context.Context, key string) (ID, error) {
+	hash := sha256.Sum256([]byte(key))
+	if id, ok := r.keys.Get(hash); ok {
+		return id, nil
+	}
+
+	tenantID, err := r.store.TenantByAPIKey(registryContext(ctx), hash[:])
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", err
+	}
+	id := ID(tenantID)
+	r.keys.Set(hash, id)
+	return id, nil
+}
+
+// Known reports whether a tenant is registered.
+func (r *Registry) Known(ctx context.Context, id ID) (bool, error) {
+	t, err := r.cached(ctx, id)
+	return t != nil, err
+}
+
+// Limits returns the limit overrides of a tenant; none for unknown tenants.
+func (r *Registry) Limits(ctx context.Context, id ID) (models.TenantLimits, error) {
+	t, err := r.cached(ctx, id)
+	if err != nil || t == nil {
+		return models.TenantLimits{}, err
+	}
+	return t.Limits, nil
+}
+
+// cached returns a tenant by ID from the cache or the store, nil if it is
+// not registered.
+func (r *Registry) cached(ctx context.Context, id ID) (*models.Tenant, error) {
+	if t, ok := r.tenants.Get(id); ok {
+		return t, nil
+	}
+	t, err := r.store.GetByID(registryContext(ctx), string(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		t, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.tenants.Set(id, t)
+	return t, nil
+}
+
+// registryContext returns ctx for lookups made for requests: the registry
+// is in the default schema, so they run outside the request's tenant and
+// transaction.
+func registryContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, ctxKey{}, nil)
+	return repository.ContextWithTx(ctx, nil)
+}
+
+// checkLimits returns ErrInvalidLimits if a limit is negative.
+func checkLimits(l models.TenantLimits) error {
+	if (l.MaxActivePerUser != nil && *l.MaxActivePerUser < 0) ||
+		(l.WritesPerUserPerHour != nil && *l.WritesPerUserPerHour < 0) ||
+		(l.MaxPriceChangePercent != nil && *l.MaxPriceChangePercent < 0) {
+		return ErrInvalidLimits
+	}
+	return nil
+}
+
+// storeError wraps repository errors in the matching registry errors.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %w", ErrExists, err)
+	default:
+		return err
+	}
+}
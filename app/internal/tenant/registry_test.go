@@ -0,0 +1,205 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"subscriptionsservice/internal/models"
+	"subscriptionsservice/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStore keeps tenants and API keys in memory and counts lookups.
+type fakeStore struct {
+	tenants map[string]models.Tenant
+	keys    map[string]string // Tenant by key hash
+	ids     map[int64]string  // Key hash by key ID
+	lookups int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{tenants: make(map[string]models.Tenant), keys: make(map[string]string), ids: make(map[int64]string)}
+}
+
+func (f *fakeStore) Create(_ context.Context, t *models.Tenant, _ ...repository.Option) error {
+	if _, ok := f.tenants[t.ID]; ok {
+		return repository.ErrDuplicate
+	}
+	f.tenants[t.ID] = *t
+	return nil
+}
+
+func (f *fakeStore) GetByID(_ context.Context, id string, _ ...repository.Option) (*models.Tenant, error) {
+	f.lookups++
+	t, ok := f.tenants[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (f *fakeStore) List(_ context.Context, limit, offset int, _ ...repository.Option) ([]models.Tenant, error) {
+	ids := slices.Sorted(maps.Keys(f.tenants))
+	var tenants []models.Tenant
+	for _, id := range ids[min(offset, len(ids)):min(offset+limit, len(ids))] {
+		tenants = append(tenants, f.tenants[id])
+	}
+	return tenants, nil
+}
+
+func (f *fakeStore) Update(_ context.Context, t *models.Tenant, _ ...repository.Option) error {
+	f.tenants[t.ID] = *t
+	return nil
+}
+
+func (f *fakeStore) Delete(_ context.Context, id string, _ ...repository.Option) error {
+	delete(f.tenants, id)
+	for hash, tenantID := range f.keys {
+		if tenantID == id {
+			delete(f.keys, hash)
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) CreateAPIKey(_ context.Context, k *models.APIKey, hash []byte, _ ...repository.Option) error {
+	k.ID = int64(len(f.ids) + 1)
+	f.keys[string(hash)] = k.TenantID
+	f.ids[k.ID] = string(hash)
+	return nil
+}
+
+func (f *fakeStore) ListAPIKeys(context.Context, string, ...repository.Option) ([]models.APIKey, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) RevokeAPIKey(_ context.Context, _ string, id int64, _ time.Time, _ ...repository.Option) error {
+	hash, ok := f.ids[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(f.keys, hash)
+	delete(f.ids, id)
+	return nil
+}
+
+func (f *fakeStore) TenantByAPIKey(_ context.Context, hash []byte, _ ...repository.Option) (string, error) {
+	f.lookups++
+	id, ok := f.keys[string(hash)]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	return id, nil
+}
+
+// fakeSchemas records migrated schemas.
+type fakeSchemas struct {
+	migrated []string
+	err      error
+}
+
+func (f *fakeSchemas) MigrateSchema(_ context.Context, schema string) error {
+	f.migrated = append(f.migrated, schema)
+	return f.err
+}
+
+func (f *fakeSchemas) SchemaVersion(context.Context, string) (uint, bool, error) {
+	return 10, false, nil
+}
+
+func TestRegistry_Create(t *testing.T) {
+	ctx := context.Background()
+	store, schemas := newFakeStore(), &fakeSchemas{}
+	r := NewRegistry(store, schemas, zap.NewNop())
+
+	require.NoError(t, r.Create(ctx, &models.Tenant{ID: "billing", Name: "Billing"}))
+	assert.Equal(t, []string{"tenant_billing"}, schemas.migrated)
+
+	assert.ErrorIs(t, r.Create(ctx, &models.Tenant{ID: "billing", Name: "Billing"}), ErrExists)
+	assert.ErrorIs(t, r.Create(ctx, &models.Tenant{ID: "Billing"}), ErrInvalidID)
+	negative := -1
+	assert.ErrorIs(t, r.Create(ctx, &models.Tenant{ID: "search", Limits: models.TenantLimits{MaxActivePerUser: &negative}}), ErrInvalidLimits)
+
+	schemas.err = errors.New("connection refused")
+	require.Error(t, r.Create(ctx, &models.Tenant{ID: "search", Name: "Search"}))
+	assert.NotContains(t, store.tenants, "search", "tenants are registered once their schema is migrated")
+}
+
+func TestRegistry_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	r := NewRegistry(store, &fakeSchemas{}, zap.NewNop())
+	require.NoError(t, r.Create(ctx, &models.Tenant{ID: "billing", Name: "Billing"}))
+
+	key, k, err := r.IssueAPIKey(ctx, "billing")
+	require.NoError(t, err)
+	assert.Equal(t, key[:len(k.Prefix)], k.Prefix)
+
+	for range 2 {
+		id, err := r.Authenticate(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, ID("billing"), id)
+	}
+	assert.Equal(t, 1, store.lookups, "api keys are cached")
+
+	_, err = r.Authenticate(ctx, key+"x")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	require.NoError(t, r.RevokeAPIKey(ctx, "billing", k.ID))
+	_, err = r.Authenticate(ctx, key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey, "revoking clears the cache")
+	assert.ErrorIs(t, r.RevokeAPIKey(ctx, "billing", k.ID), ErrAPIKeyNotFound)
+}
+
+func TestRegistry_Limits(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	r := NewRegistry(store, &fakeSchemas{}, zap.NewNop())
+
+	limits, err := r.Limits(ctx, "billing")
+	require.NoError(t, err)
+	assert.Equal(t, models.TenantLimits{}, limits, "unknown tenants have no overrides")
+	known, err := r.Known(ctx, "billing")
+	require.NoError(t, err)
+	assert.False(t, known, "unknown tenants are cached too")
+	assert.Equal(t, 1, store.lookups)
+
+	five := 5
+	require.NoError(t, r.Create(ctx, &models.Tenant{ID: "billing", Limits: models.TenantLimits{MaxActivePerUser: &five}}))
+	limits, err = r.Limits(ctx, "billing")
+	require.NoError(t, err)
+	require.NotNil(t, limits.MaxActivePerUser, "registering clears the cache")
+	assert.Equal(t, 5, *limits.MaxActivePerUser)
+}
+
+func TestRegistry_ForEach(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	r := NewRegistry(store, &fakeSchemas{}, zap.NewNop())
+	for i := range forEachPageSize + 1 {
+		require.NoError(t, r.Create(ctx, &models.Tenant{ID: fmt.Sprintf("team%03d", i)}))
+	}
+
+	var schemas []string
+	err := r.ForEach(WithTenant(ctx, "team000"), func(ctx context.Context) error {
+		schemas = append(schemas, SchemaFromContext(ctx))
+		if SchemaFromContext(ctx) == "tenant_team001" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, err, "tenant team001: unavailable", "the other tenants are still run")
+	require.Len(t, schemas, forEachPageSize+2)
+	assert.Empty(t, schemas[0], "the default schema first")
+	assert.Equal(t, "tenant_team000", schemas[1])
+	assert.Equal(t, "tenant_team100", schemas[len(schemas)-1])
+}
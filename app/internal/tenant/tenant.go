@@ -0,0 +1,65 @@
+// Package tenant carries the tenant of a request through its context and
+// keeps the registry of tenants. In schema-per-tenant mode the data of each
+// tenant lives in its own database schema, selected for the connection by
+// the tenant in the query context (see database.WithSearchPath). The tenant
+// is resolved from an API key issued by the registry or passed by an
+// authenticating gateway in a trusted header, like the caller (see package
+// auth).
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ID identifies a tenant, e.g. a team the service is provided to.
+type ID string
+
+// idPattern restricts IDs to lowercase identifiers, so schema names need no
+// quoting and stay within the 63 bytes PostgreSQL allows.
+var idPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// schemaPrefix keeps tenant schemas apart from the default one and from
+// system schemas such as pg_catalog.
+const schemaPrefix = "tenant_"
+
+// ErrInvalidID is returned by Parse for a malformed tenant ID.
+var ErrInvalidID = errors.New("invalid tenant")
+
+// Parse validates s as a tenant ID.
+func Parse(s string) (ID, error) {
+	if !idPattern.MatchString(s) {
+		return "", fmt.Errorf("%w %q: must be a lowercase letter followed by up to 39 lowercase letters, digits or underscores", ErrInvalidID, s)
+	}
+	return ID(s), nil
+}
+
+// Schema returns the name of the database schema holding the tenant's data.
+func (id ID) Schema() string {
+	return schemaPrefix + string(id)
+}
+
+type ctxKey struct{}
+
+// WithTenant returns a copy of ctx carrying id.
+func WithTenant(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant carried by ctx. ok is false outside tenant
+// requests, e.g. in background tasks, which use the default schema.
+func FromContext(ctx context.Context) (id ID, ok bool) {
+	id, ok = ctx.Value(ctxKey{}).(ID)
+	return id, ok
+}
+
+// SchemaFromContext returns the schema of the tenant carried by ctx, or ""
+// for the default schema.
+func SchemaFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id.Schema()
+	}
+	return ""
+}
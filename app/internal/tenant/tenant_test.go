@@ -0,0 +1,26 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	id, err := Parse("billing_2")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant_billing_2", id.Schema())
+
+	for _, s := range []string{"", "Billing", "2billing", "billing-team", `x"; DROP SCHEMA public; --`, strings.Repeat("a", 41)} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSchemaFromContext(t *testing.T) {
+	assert.Empty(t, SchemaFromContext(context.Background()))
+	assert.Equal(t, "tenant_billing", SchemaFromContext(WithTenant(context.Background(), "billing")))
+}
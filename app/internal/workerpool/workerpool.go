@@ -0,0 +1,201 @@
+// Package workerpool runs background tasks on a fixed number of goroutines
+// with a bounded queue, so bursts of async work such as webhooks, outbox
+// delivery or report generation cannot spawn unbounded goroutines.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when all workers are busy and the
+	// queue is full.
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrStopped is returned by Submit and SubmitWait after Stop.
+	ErrStopped = errors.New("worker pool is stopped")
+)
+
+// Task is a unit of background work. ctx is canceled when Stop gives up
+// waiting for tasks to finish.
+type Task func(ctx context.Context) error
+
+// MetricsRecorder receives task events, e.g. to export them as metrics.
+type MetricsRecorder interface {
+	// Queued is called when a task is accepted.
+	Queued()
+	// Rejected is called when a task is not accepted.
+	Rejected()
+	// Started is called when a worker takes a task that waited for wait.
+	Started(wait time.Duration)
+	// Finished is called when a task returns after running for d.
+	Finished(d time.Duration, err error)
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Queued()                       {}
+func (nopRecorder) Rejected()                     {}
+func (nopRecorder) Started(time.Duration)         {}
+func (nopRecorder) Finished(time.Duration, error) {}
+
+// Option configures a Pool.
+type Option func(*Pool)
+
+// WithMetricsRecorder reports task events to r.
+func WithMetricsRecorder(r MetricsRecorder) Option {
+	return func(p *Pool) {
+		p.metrics = r
+	}
+}
+
+type job struct {
+	name     string
+	task     Task
+	queuedAt time.Time
+}
+
+// Pool runs tasks on size workers. Tasks wait in a queue of queueDepth
+// while all workers are busy. It is safe for concurrent use.
+type Pool struct {
+	log     *zap.Logger
+	metrics MetricsRecorder
+
+	// mu guards closing queue: senders hold it for reading.
+	mu       sync.RWMutex
+	queue    chan job
+	closed   bool
+	stopping chan struct{}
+	stopOnce sync.Once
+
+	// ctx is passed to tasks and canceled once Stop returns.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New starts a pool of size workers (at least one) with a queue of
+// queueDepth tasks.
+func New(size, queueDepth int, log *zap.Logger, opts ...Option) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		log:      log,
+		metrics:  nopRecorder{},
+		queue:    make(chan job, max(queueDepth, 0)),
+		stopping: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for range max(size, 1) {
+		p.wg.Go(p.work)
+	}
+	return p
+}
+
+// Submit queues task without waiting. It returns ErrQueueFull if no worker
+// or queue slot is free. name identifies the task in logs.
+func (p *Pool) Submit(name string, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.metrics.Rejected()
+		return ErrStopped
+	}
+
+	select {
+	case p.queue <- job{name: name, task: task, queuedAt: time.Now()}:
+		p.metrics.Queued()
+		return nil
+	default:
+		p.metrics.Rejected()
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait queues task, waiting for a free queue slot until ctx is done.
+func (p *Pool) SubmitWait(ctx context.Context, name string, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.metrics.Rejected()
+		return ErrStopped
+	}
+
+	select {
+	case p.queue <- job{name: name, task: task, queuedAt: time.Now()}:
+		p.metrics.Queued()
+		return nil
+	case <-ctx.Done():
+		p.metrics.Rejected()
+		return ctx.Err()
+	case <-p.stopping:
+		p.metrics.Rejected()
+		return ErrStopped
+	}
+}
+
+// Stop stops accepting tasks and waits until the queued and running ones
+// finish. If ctx is done first, the context of running tasks is canceled,
+// tasks still queued are not run, and ctx.Err() is returned. Stop may be
+// called more than once.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		// Wake SubmitWait callers before taking the lock they hold.
+		close(p.stopping)
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return fmt.Errorf("worker pool not drained: %w", ctx.Err())
+	}
+}
+
+func (p *Pool) work() {
+	for j := range p.queue {
+		if p.ctx.Err() != nil {
+			p.log.Warn("background task dropped on shutdown", zap.String("task", j.name))
+			continue
+		}
+		p.run(j)
+	}
+}
+
+func (p *Pool) run(j job) {
+	p.metrics.Started(time.Since(j.queuedAt))
+	start := time.Now()
+	err := errors.New("task panicked")
+	defer func() {
+		if r := recover(); r != nil {
+			p.log.Error("background task panicked", zap.String("task", j.name), zap.Any("panic", r))
+		}
+		p.metrics.Finished(time.Since(start), err)
+	}()
+
+	err = j.task(p.ctx)
+	if err != nil {
+		p.log.Error("background task failed", zap.String("task", j.name), zap.Error(err))
+	}
+}
@@ -0,0 +1,155 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingRecorder counts task events.
+type countingRecorder struct {
+	queued, rejected, started, finished, failed atomic.Int32
+}
+
+func (r *countingRecorder) Queued()               { r.queued.Add(1) }
+func (r *countingRecorder) Rejected()             { r.rejected.Add(1) }
+func (r *countingRecorder) Started(time.Duration) { r.started.Add(1) }
+func (r *countingRecorder) Finished(_ time.Duration, err error) {
+	r.finished.Add(1)
+	if err != nil {
+		r.failed.Add(1)
+	}
+}
+
+func TestPool_BoundedConcurrency(t *testing.T) {
+	rec := &countingRecorder{}
+	p := New(3, 100, zap.NewNop(), WithMetricsRecorder(rec))
+
+	var running, peak atomic.Int32
+	for range 30 {
+		require.NoError(t, p.Submit("task", func(context.Context) error {
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		}))
+	}
+	require.NoError(t, p.Stop(t.Context()))
+
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Equal(t, int32(30), rec.queued.Load())
+	assert.Equal(t, int32(30), rec.finished.Load())
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	rec := &countingRecorder{}
+	p := New(1, 1, zap.NewNop(), WithMetricsRecorder(rec))
+	release := make(chan struct{})
+	block := func(context.Context) error {
+		<-release
+		return nil
+	}
+
+	require.NoError(t, p.Submit("running", block))
+	require.Eventually(t, func() bool { return rec.started.Load() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Submit("queued", block))
+	assert.ErrorIs(t, p.Submit("rejected", block), ErrQueueFull)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.SubmitWait(ctx, "waiting", block), context.DeadlineExceeded)
+	assert.Equal(t, int32(2), rec.rejected.Load())
+
+	close(release)
+	require.NoError(t, p.Stop(t.Context()))
+	assert.Equal(t, int32(2), rec.finished.Load())
+}
+
+func TestPool_Stop(t *testing.T) {
+	t.Run("drains queued tasks", func(t *testing.T) {
+		p := New(1, 10, zap.NewNop())
+		var done atomic.Int32
+		for range 5 {
+			require.NoError(t, p.Submit("task", func(context.Context) error {
+				time.Sleep(time.Millisecond)
+				done.Add(1)
+				return nil
+			}))
+		}
+		require.NoError(t, p.Stop(t.Context()))
+		require.NoError(t, p.Stop(t.Context()), "stop is idempotent")
+
+		assert.Equal(t, int32(5), done.Load())
+		assert.ErrorIs(t, p.Submit("late", func(context.Context) error { return nil }), ErrStopped)
+	})
+
+	t.Run("wakes waiting submitters", func(t *testing.T) {
+		p := New(1, 0, zap.NewNop())
+		release := make(chan struct{})
+		require.NoError(t, p.SubmitWait(t.Context(), "running", func(context.Context) error {
+			<-release
+			return nil
+		}))
+
+		var wg sync.WaitGroup
+		var err error
+		wg.Go(func() {
+			err = p.SubmitWait(t.Context(), "waiting", func(context.Context) error { return nil })
+		})
+		time.Sleep(10 * time.Millisecond)
+
+		stopped := make(chan error)
+		go func() { stopped <- p.Stop(t.Context()) }()
+		wg.Wait()
+		assert.ErrorIs(t, err, ErrStopped)
+
+		close(release)
+		assert.NoError(t, <-stopped)
+	})
+
+	t.Run("cancels tasks after the deadline", func(t *testing.T) {
+		p := New(1, 10, zap.NewNop())
+		var skipped atomic.Bool
+		require.NoError(t, p.Submit("stuck", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		require.NoError(t, p.Submit("never run", func(context.Context) error {
+			skipped.Store(true)
+			return nil
+		}))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.Stop(ctx), context.DeadlineExceeded)
+
+		require.NoError(t, p.Stop(t.Context()), "workers exit once the context is canceled")
+		assert.False(t, skipped.Load(), "queued tasks are dropped after the deadline")
+	})
+}
+
+func TestPool_FailedTasks(t *testing.T) {
+	rec := &countingRecorder{}
+	p := New(1, 10, zap.NewNop(), WithMetricsRecorder(rec))
+
+	require.NoError(t, p.Submit("error", func(context.Context) error { return errors.New("boom") }))
+	require.NoError(t, p.Submit("panic", func(context.Context) error { panic("boom") }))
+	require.NoError(t, p.Submit("ok", func(context.Context) error { return nil }))
+	require.NoError(t, p.Stop(t.Context()))
+
+	assert.Equal(t, int32(3), rec.finished.Load(), "a panic does not kill the worker")
+	assert.Equal(t, int32(2), rec.failed.Load())
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tenant_api_keys;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,23 @@
+-- Tenant registry of the schema-per-tenant mode. It is read from the
+-- default schema only; tenant schemas get an unused empty copy, as they are
+-- migrated from the same files.
+CREATE TABLE tenants (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- Overrides of the service-wide usage limits, e.g. {"max_active_per_user": 10}.
+    limits JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Only SHA-256 hashes of API keys are stored; the key is shown once on issue.
+CREATE TABLE tenant_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    key_hash BYTEA NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_tenant_api_keys_tenant ON tenant_api_keys (tenant_id);
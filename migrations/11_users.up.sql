@@ -0,0 +1,13 @@
+-- Users subscriptions belong to, created by the upstream identity system via
+-- POST /users or, unless users.require_provisioned is set, by the first
+-- subscription of a user. Existing owners are registered here.
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO users (id) SELECT DISTINCT user_id FROM subscriptions;
+
+-- A user with subscriptions cannot be deleted.
+ALTER TABLE subscriptions
+    ADD CONSTRAINT fk_subscriptions_user FOREIGN KEY (user_id) REFERENCES users (id);
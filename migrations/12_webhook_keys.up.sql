@@ -0,0 +1,17 @@
+-- Ed25519 keys outgoing webhook requests are signed with. The newest key
+-- without retired_at signs; retired keys stay published at
+-- /.well-known/webhook-keys for a grace period, so deliveries signed just
+-- before a rotation still verify. Anyone who can read the table can sign
+-- webhooks: grant it only to the service.
+CREATE TABLE webhook_keys (
+    -- Key ID (kid): the RFC 7638 JWK thumbprint of the public key.
+    id TEXT PRIMARY KEY,
+    public_key BYTEA NOT NULL,
+    -- Ed25519 seed of the private key.
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    retired_at TIMESTAMPTZ
+);
+
+-- At most one active key: of concurrent rotations only one succeeds.
+CREATE UNIQUE INDEX idx_webhook_keys_active ON webhook_keys ((retired_at IS NULL)) WHERE retired_at IS NULL;
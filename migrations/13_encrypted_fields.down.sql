@@ -0,0 +1,2 @@
+-- Encrypted values become unreadable without their key IDs.
+ALTER TABLE webhook_keys DROP COLUMN IF EXISTS private_key_key_id;
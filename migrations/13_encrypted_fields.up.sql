@@ -0,0 +1,5 @@
+-- Column-level encryption: the ID of the key a value was encrypted with is
+-- stored next to it, NULL for values stored in plain text before
+-- encryption.keys was set. POST /admin/encryption/reencrypt moves values to
+-- the active key.
+ALTER TABLE webhook_keys ADD COLUMN private_key_key_id TEXT;
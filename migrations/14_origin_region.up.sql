@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN origin_region TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- A row per periodic job, locked by the replica running it, so that with
+-- several replicas each run happens once. Row locks are used instead of
+-- advisory locks, which CockroachDB lacks; last_run_at keeps a replica
+-- whose timer fires just after another's run from repeating it.
+CREATE TABLE job_locks (
+    name TEXT PRIMARY KEY,
+    last_run_at TIMESTAMPTZ
+);
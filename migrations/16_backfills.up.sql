@@ -0,0 +1,16 @@
+-- Progress of data migrations (backfills) run in batches by the service,
+-- see internal/backfill. A batch and the progress it makes are committed
+-- together, so a paused, failed or interrupted backfill resumes after
+-- last_id.
+CREATE TABLE backfills (
+    name TEXT PRIMARY KEY,
+    -- pending, running, paused, done or failed.
+    status TEXT NOT NULL DEFAULT 'pending',
+    -- ID of the last processed row; batches go in ID order.
+    last_id BIGINT NOT NULL DEFAULT 0,
+    processed BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
@@ -0,0 +1,12 @@
+-- Named filter combinations saved by users and passed as filter_id to
+-- GET /subscriptions/ and the summary endpoints. filter holds
+-- models.FilterCriteria.
+CREATE TABLE saved_filters (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
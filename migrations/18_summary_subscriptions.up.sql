@@ -0,0 +1,20 @@
+-- Summaries users subscribed to via POST /summary-subscriptions. A job sends
+-- the summaries with next_run_at in the past and moves next_run_at to the
+-- end of the current period; failed ones keep it and are retried.
+CREATE TABLE summary_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    -- month, quarter or year.
+    period TEXT NOT NULL,
+    -- models.FilterCriteria, as in saved_filters.
+    filter JSONB NOT NULL DEFAULT '{}',
+    channel TEXT NOT NULL,
+    recipient TEXT NOT NULL DEFAULT '',
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_summary_subscriptions_next_run_at ON summary_subscriptions (next_run_at);
+CREATE INDEX idx_summary_subscriptions_user_id ON summary_subscriptions (user_id);
@@ -0,0 +1,15 @@
+-- Audited actions on subscriptions, e.g. writes and guarded price changes,
+-- recorded by audit.Store. GET /users/{user_id}/activity lists the writes
+-- of a user newest first. user_id is NULL for events not tied to a user.
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL,
+    user_id UUID,
+    subscription_id BIGINT NOT NULL DEFAULT 0,
+    -- Caller who made the change; NULL without caller identification.
+    actor UUID,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_events_user_id ON audit_events (user_id, id DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_summary_subscriptions_confirmation_hash;
+
+ALTER TABLE summary_subscriptions
+    DROP COLUMN IF EXISTS confirmed_at,
+    DROP COLUMN IF EXISTS confirmation_hash;
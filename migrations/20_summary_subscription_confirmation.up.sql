@@ -0,0 +1,11 @@
+-- Summaries are sent to an address the user enters, so they are only sent
+-- once its owner confirms it: POST /summary-subscriptions/confirm with the
+-- token sent there on subscribing. Only the SHA-256 hash of the token is
+-- kept, until it is used. Existing subscriptions were never confirmed and
+-- are no longer sent; users subscribe again to get a token.
+ALTER TABLE summary_subscriptions
+    ADD COLUMN confirmation_hash BYTEA,
+    ADD COLUMN confirmed_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_summary_subscriptions_confirmation_hash ON summary_subscriptions (confirmation_hash)
+    WHERE confirmation_hash IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER SEQUENCE IF EXISTS subscriptions_id_seq AS INTEGER;
+ALTER TABLE subscriptions ALTER COLUMN id TYPE INTEGER;
@@ -0,0 +1,7 @@
+-- Subscription IDs are 64-bit, as in the application and in audit_events.
+-- On CockroachDB SERIAL already creates INT8 DEFAULT unique_rowid()
+-- (serial_normalization = rowid), so IDs are not sequential there and may
+-- exceed 2^53; on PostgreSQL the key stays SERIAL, only the INT4 column and
+-- its sequence are widened.
+ALTER TABLE subscriptions ALTER COLUMN id TYPE BIGINT;
+ALTER SEQUENCE IF EXISTS subscriptions_id_seq AS BIGINT;
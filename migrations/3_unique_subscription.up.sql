@@ -0,0 +1,27 @@
+-- Subscriptions created before this migration may repeat a user, service
+-- and start month. Which of them to keep is up to the operator, so the
+-- migration stops with the duplicates instead of dropping any: merge or
+-- delete them and migrate again.
+DO $$
+DECLARE
+    duplicates BIGINT;
+    example TEXT;
+BEGIN
+    SELECT count(*), min(format('user_id %s, service_name %L, start_date %s', user_id, service_name, start_date))
+    INTO duplicates, example
+    FROM (
+        SELECT user_id, service_name, start_date
+        FROM subscriptions
+        GROUP BY user_id, service_name, start_date
+        HAVING count(*) > 1
+    ) d;
+
+    IF duplicates > 0 THEN
+        RAISE EXCEPTION 'cannot add uq_subscriptions_user_service_start: % user, service and start month combinations have several subscriptions, e.g. %', duplicates, example
+            USING HINT = 'Merge or delete them, listed by: SELECT user_id, service_name, start_date, array_agg(id) FROM subscriptions GROUP BY 1, 2, 3 HAVING count(*) > 1; then run the migrations again.';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_subscriptions_user_service_start
+ON subscriptions(user_id, service_name, start_date);
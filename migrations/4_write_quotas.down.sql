@@ -0,0 +1 @@
+DROP TABLE IF EXISTS write_quotas;
@@ -0,0 +1,6 @@
+CREATE TABLE write_quotas (
+    user_id UUID NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    writes INT NOT NULL,
+    PRIMARY KEY (user_id, window_start)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    errors TEXT[] NOT NULL,
+    attempts INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    redelivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_dead_letters_pending ON dead_letters (id) WHERE redelivered_at IS NULL;
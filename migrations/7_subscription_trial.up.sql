@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN trial BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_subscriptions_paid_period;
+DROP INDEX IF EXISTS idx_subscriptions_user_period_eff;
+DROP INDEX IF EXISTS idx_subscriptions_period;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS end_date_eff;
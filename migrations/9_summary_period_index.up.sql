@@ -0,0 +1,20 @@
+-- end_date_eff is end_date with open-ended subscriptions ending in 9999-12-31,
+-- so the summary period filter is a plain range condition instead of
+-- "end_date >= $1 OR end_date IS NULL", which no index can serve.
+-- Adding a stored column rewrites the table.
+ALTER TABLE subscriptions
+    ADD COLUMN end_date_eff DATE GENERATED ALWAYS AS (COALESCE(end_date, DATE '9999-12-31')) STORED;
+
+-- Summaries over all users: range scan on the period, price and currency are
+-- read from the index.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_period
+ON subscriptions(end_date_eff, start_date) INCLUDE (price, currency);
+
+-- Summaries of a user.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_period_eff
+ON subscriptions(user_id, end_date_eff, start_date) INCLUDE (price, currency);
+
+-- Summaries with exclude_trials, which is what finance reports use.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_paid_period
+ON subscriptions(end_date_eff, start_date) INCLUDE (price, currency)
+WHERE trial = FALSE;